@@ -9,12 +9,15 @@ import (
 	"nofx/config"
 	"nofx/decision"
 	"nofx/manager"
+	"nofx/trader"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Server HTTP API服务器
@@ -23,6 +26,7 @@ type Server struct {
 	traderManager *manager.TraderManager
 	database      *config.Database
 	port          int
+	metrics       prometheus.Gatherer
 }
 
 // NewServer 创建API服务器
@@ -64,8 +68,25 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// SetMetrics 设置 GET /metrics 输出的指标注册表（风险指标与交易器运行指标，未设置时返回404）
+func (s *Server) SetMetrics(gatherer prometheus.Gatherer) {
+	s.metrics = gatherer
+}
+
+// handleMetrics 通过promhttp输出注册表中的指标
+func (s *Server) handleMetrics(c *gin.Context) {
+	if s.metrics == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{ErrorLog: log.Default()}).ServeHTTP(c.Writer, c.Request)
+}
+
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
+	// Prometheus抓取（无需认证）
+	s.router.GET("/metrics", s.handleMetrics)
+
 	// API路由组
 	api := s.router.Group("/api")
 	{
@@ -108,6 +129,8 @@ func (s *Server) setupRoutes() {
 			protected.DELETE("/traders/:id", s.handleDeleteTrader)
 			protected.POST("/traders/:id/start", s.handleStartTrader)
 			protected.POST("/traders/:id/stop", s.handleStopTrader)
+			protected.POST("/traders/:id/symbols/:symbol/pause", s.handlePauseSymbol)
+			protected.POST("/traders/:id/symbols/:symbol/resume", s.handleResumeSymbol)
 			protected.PUT("/traders/:id/prompt", s.handleUpdateTraderPrompt)
 
 			// AI模型配置
@@ -126,6 +149,7 @@ func (s *Server) setupRoutes() {
 			protected.GET("/status", s.handleStatus)
 			protected.GET("/account", s.handleAccount)
 			protected.GET("/positions", s.handlePositions)
+			protected.GET("/portfolio", s.handlePortfolio)
 			protected.GET("/decisions", s.handleDecisions)
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
 			protected.GET("/statistics", s.handleStatistics)
@@ -268,6 +292,7 @@ type UpdateExchangeConfigRequest struct {
 		AsterUser             string `json:"aster_user"`
 		AsterSigner           string `json:"aster_signer"`
 		AsterPrivateKey       string `json:"aster_private_key"`
+		OKXPassphrase         string `json:"okx_passphrase"`
 	} `json:"exchanges"`
 }
 
@@ -552,6 +577,12 @@ func (s *Server) handleStartTrader(c *gin.Context) {
 		return
 	}
 
+	// 启动前预检，直接把报告返回给前端
+	if report, err := trader.CheckPreflight(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "preflight": report})
+		return
+	}
+
 	// 启动交易员
 	go func() {
 		log.Printf("▶️  启动交易员 %s (%s)", traderID, trader.GetName())
@@ -608,6 +639,65 @@ func (s *Server) handleStopTrader(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "交易员已停止"})
 }
 
+// handlePauseSymbol 暂停交易员单个币种的交易（不平仓）
+func (s *Server) handlePauseSymbol(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	var req struct {
+		ManageExisting bool   `json:"manage_existing"`
+		By             string `json:"by"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	trader, err := s.getUserTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	by := "api:" + userID
+	if req.By != "" {
+		by += " (" + req.By + ")"
+	}
+	if err := trader.PauseSymbol(c.Param("symbol"), req.ManageExisting, by); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "币种已暂停", "paused_symbols": trader.GetPausedSymbols()})
+}
+
+// handleResumeSymbol 恢复交易员单个币种的交易
+func (s *Server) handleResumeSymbol(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	trader, err := s.getUserTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := trader.ResumeSymbol(c.Param("symbol"), "api:"+userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "币种已恢复交易", "paused_symbols": trader.GetPausedSymbols()})
+}
+
+// getUserTrader 获取属于当前用户的交易员
+func (s *Server) getUserTrader(userID, traderID string) (*trader.AutoTrader, error) {
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		return nil, fmt.Errorf("交易员不存在或无访问权限")
+	}
+	return s.traderManager.GetTrader(traderID)
+}
+
 // handleUpdateTraderPrompt 更新交易员自定义Prompt
 func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	traderID := c.Param("id")
@@ -711,7 +801,7 @@ func (s *Server) handleUpdateExchangeConfigs(c *gin.Context) {
 
 	// 更新每个交易所的配置
 	for exchangeID, exchangeData := range req.Exchanges {
-		err := s.database.UpdateExchange(userID, exchangeID, exchangeData.Enabled, exchangeData.APIKey, exchangeData.SecretKey, exchangeData.Testnet, exchangeData.HyperliquidWalletAddr, exchangeData.AsterUser, exchangeData.AsterSigner, exchangeData.AsterPrivateKey)
+		err := s.database.UpdateExchange(userID, exchangeID, exchangeData.Enabled, exchangeData.APIKey, exchangeData.SecretKey, exchangeData.Testnet, exchangeData.HyperliquidWalletAddr, exchangeData.AsterUser, exchangeData.AsterSigner, exchangeData.AsterPrivateKey, exchangeData.OKXPassphrase)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易所 %s 失败: %v", exchangeID, err)})
 			return
@@ -938,6 +1028,31 @@ func (s *Server) handlePositions(c *gin.Context) {
 	c.JSON(http.StatusOK, positions)
 }
 
+// handlePortfolio 账户风险概览（敞口、保证金、距强平距离）
+func (s *Server) handlePortfolio(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := trader.GetPortfolioSummary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取风险概览失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
 // handleDecisions 决策日志列表
 func (s *Server) handleDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
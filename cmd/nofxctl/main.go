@@ -0,0 +1,830 @@
+// nofxctl 命令行运维工具：查询账户、手动平仓/撤单、暂停或恢复AutoTrader
+//
+// 用法:
+//
+//	nofxctl [全局参数] <命令> [参数]
+//
+// 命令:
+//
+//	balance                                 查看账户余额
+//	positions                               查看持仓
+//	portfolio                               查看风险概览（敞口、保证金、距强平距离）
+//	orders [symbol]                         查看挂单
+//	price <symbol>                          查看市场价格
+//	preflight                               执行启动预检
+//	close <symbol> [--side long|short] [--pct 50]  平仓（默认双向全平）
+//	cancel <symbol>                         撤销该币种所有挂单
+//	cancel --all                            撤销所有币种的挂单（含其他持仓的止损止盈）
+//	flatten                                 平掉所有持仓并撤销挂单
+//	cache                                   通过HTTP API查看AutoTrader的缓存统计
+//	symbols                                 通过HTTP API查看各币种的交易状态
+//	pause-symbol <symbol> [--manage]        通过HTTP API暂停单个币种（不平仓，--manage继续管理已有持仓）
+//	resume-symbol <symbol>                  通过HTTP API恢复单个币种
+//	halt                                    通过HTTP API停止AutoTrader
+//	resume                                  通过HTTP API启动AutoTrader
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"nofx/config"
+	"nofx/trader"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cli 命令行上下文
+type cli struct {
+	dbPath   string
+	userID   string
+	traderID string
+	apiURL   string
+	token    string
+	jsonOut  bool
+	yes      bool
+	verbose  bool
+
+	out io.Writer
+}
+
+func main() {
+	c := &cli{out: os.Stdout}
+
+	fs := flag.NewFlagSet("nofxctl", flag.ExitOnError)
+	fs.StringVar(&c.dbPath, "db", "config.db", "配置数据库路径")
+	fs.StringVar(&c.userID, "user", "admin", "交易员所属用户ID（管理员模式为admin）")
+	fs.StringVar(&c.traderID, "trader", "", "交易员ID（为空时使用该用户的第一个交易员）")
+	fs.StringVar(&c.apiURL, "api", "http://localhost:8080", "AutoTrader HTTP API地址（halt/resume使用）")
+	fs.StringVar(&c.token, "token", os.Getenv("NOFX_TOKEN"), "HTTP API的JWT token（管理员模式可不填）")
+	fs.BoolVar(&c.jsonOut, "json", false, "以JSON格式输出")
+	fs.BoolVar(&c.yes, "yes", false, "跳过危险操作的确认")
+	fs.BoolVar(&c.verbose, "v", false, "输出交易器日志")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: nofxctl [全局参数] <balance|positions|portfolio|orders|price|preflight|close|cancel|flatten|cache|symbols|pause-symbol|resume-symbol|halt|resume> [参数]")
+		fs.PrintDefaults()
+	}
+
+	if err := parseInterspersed(fs, os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if !c.verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if err := c.run(fs.Arg(0), fs.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run 分发子命令
+func (c *cli) run(command string, args []string) error {
+	switch command {
+	case "balance":
+		return c.cmdBalance()
+	case "positions":
+		return c.cmdPositions()
+	case "portfolio":
+		return c.cmdPortfolio()
+	case "orders":
+		return c.cmdOrders(args)
+	case "price":
+		return c.cmdPrice(args)
+	case "preflight":
+		return c.cmdPreflight()
+	case "close":
+		return c.cmdClose(args)
+	case "cancel":
+		return c.cmdCancel(args)
+	case "flatten":
+		return c.cmdFlatten()
+	case "cache":
+		return c.cmdCacheStats()
+	case "symbols":
+		return c.cmdSymbols()
+	case "pause-symbol":
+		return c.cmdPauseSymbol(args)
+	case "resume-symbol":
+		return c.cmdResumeSymbol(args)
+	case "halt":
+		return c.cmdTraderControl("stop")
+	case "resume":
+		return c.cmdTraderControl("start")
+	default:
+		return fmt.Errorf("未知命令: %s", command)
+	}
+}
+
+// parseInterspersed 解析参数，允许flag出现在位置参数之后（如 close BTCUSDT --pct 50）
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// loadTrader 使用与AutoTrader相同的方式构建交易器
+func (c *cli) loadTrader() (trader.Trader, error) {
+	t, _, err := c.loadTraderWithConfig()
+	return t, err
+}
+
+// loadTraderWithConfig 构建交易器并返回对应的AutoTrader配置
+func (c *cli) loadTraderWithConfig() (trader.Trader, trader.AutoTraderConfig, error) {
+	var cfg trader.AutoTraderConfig
+
+	database, err := config.NewDatabase(c.dbPath)
+	if err != nil {
+		return nil, cfg, fmt.Errorf("打开配置数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	traderID := c.traderID
+	if traderID == "" {
+		traders, err := database.GetTraders(c.userID)
+		if err != nil {
+			return nil, cfg, fmt.Errorf("获取交易员列表失败: %w", err)
+		}
+		if len(traders) == 0 {
+			return nil, cfg, fmt.Errorf("用户 %s 没有配置交易员", c.userID)
+		}
+		traderID = traders[0].ID
+		c.traderID = traderID
+	}
+
+	traderCfg, _, exchangeCfg, err := database.GetTraderConfig(c.userID, traderID)
+	if err != nil {
+		return nil, cfg, fmt.Errorf("获取交易员 %s 配置失败: %w", traderID, err)
+	}
+
+	// 与manager中的映射保持一致
+	cfg = trader.AutoTraderConfig{
+		ID:                 traderCfg.ID,
+		Name:               traderCfg.Name,
+		Exchange:           exchangeCfg.ID,
+		HyperliquidTestnet: exchangeCfg.Testnet,
+		ScanInterval:       time.Duration(traderCfg.ScanIntervalMinutes) * time.Minute,
+		InitialBalance:     traderCfg.InitialBalance,
+		BTCETHLeverage:     traderCfg.BTCETHLeverage,
+		AltcoinLeverage:    traderCfg.AltcoinLeverage,
+		MaxDailyLoss:       10.0,
+		MaxDrawdown:        20.0,
+		IsCrossMargin:      traderCfg.IsCrossMargin,
+	}
+	switch exchangeCfg.ID {
+	case "binance":
+		cfg.BinanceAPIKey = exchangeCfg.APIKey
+		cfg.BinanceSecretKey = exchangeCfg.SecretKey
+	case "hyperliquid":
+		cfg.HyperliquidPrivateKey = exchangeCfg.APIKey
+		cfg.HyperliquidWalletAddr = exchangeCfg.HyperliquidWalletAddr
+	case "aster":
+		cfg.AsterUser = exchangeCfg.AsterUser
+		cfg.AsterSigner = exchangeCfg.AsterSigner
+		cfg.AsterPrivateKey = exchangeCfg.AsterPrivateKey
+	case "okx":
+		cfg.OKXAPIKey = exchangeCfg.APIKey
+		cfg.OKXSecretKey = exchangeCfg.SecretKey
+		cfg.OKXPassphrase = exchangeCfg.OKXPassphrase
+		cfg.DemoTrading = exchangeCfg.Testnet
+	case "bybit":
+		cfg.BybitAPIKey = exchangeCfg.APIKey
+		cfg.BybitSecretKey = exchangeCfg.SecretKey
+		cfg.DemoTrading = exchangeCfg.Testnet
+	}
+
+	for _, symbol := range strings.Split(traderCfg.TradingSymbols, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			cfg.TradingCoins = append(cfg.TradingCoins, symbol)
+		}
+	}
+	if v, _ := database.GetSystemConfig("max_daily_loss"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxDailyLoss = val
+		}
+	}
+	if v, _ := database.GetSystemConfig("max_drawdown"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxDrawdown = val
+		}
+	}
+	if v, _ := database.GetSystemConfig("default_coins"); v != "" {
+		json.Unmarshal([]byte(v), &cfg.DefaultCoins)
+	}
+
+	t, err := trader.NewExchangeTrader(cfg)
+	return t, cfg, err
+}
+
+func (c *cli) cmdBalance() error {
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	balance, err := t.GetBalance()
+	if err != nil {
+		return err
+	}
+	if c.jsonOut {
+		return c.printJSON(balance)
+	}
+
+	keys := make([]string, 0, len(balance))
+	for k := range balance {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k, formatValue(balance[k])})
+	}
+	return c.printTable([]string{"字段", "数值"}, rows)
+}
+
+func (c *cli) cmdPositions() error {
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return err
+	}
+	if c.jsonOut {
+		return c.printJSON(positions)
+	}
+
+	rows := make([][]string, 0, len(positions))
+	for _, pos := range positions {
+		rows = append(rows, []string{
+			formatValue(pos["symbol"]),
+			formatValue(pos["side"]),
+			formatValue(pos["positionAmt"]),
+			formatValue(pos["entryPrice"]),
+			formatValue(pos["markPrice"]),
+			formatValue(pos["unRealizedProfit"]),
+			formatValue(pos["leverage"]),
+			formatValue(pos["liquidationPrice"]),
+		})
+	}
+	return c.printTable([]string{"币种", "方向", "数量", "开仓价", "标记价", "未实现盈亏", "杠杆", "强平价"}, rows)
+}
+
+func (c *cli) cmdPortfolio() error {
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	summary, err := trader.GetPortfolioSummary(context.Background(), t)
+	if err != nil {
+		return err
+	}
+	if c.jsonOut {
+		return c.printJSON(summary)
+	}
+
+	if err := c.printTable([]string{"账户净值", "已用保证金", "全仓可用", "逐仓权益", "挂单冻结", "总敞口", "净敞口"}, [][]string{{
+		formatValue(summary.TotalEquity),
+		formatValue(summary.MarginUsed),
+		formatValue(summary.MarginFree),
+		formatValue(summary.Margin.IsolatedEquity),
+		formatValue(summary.Margin.FrozenInOrders),
+		formatValue(summary.GrossExposure),
+		formatValue(summary.NetExposure),
+	}}); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out)
+
+	rows := make([][]string, 0, len(summary.Positions))
+	for _, p := range summary.Positions {
+		rows = append(rows, []string{
+			p.Symbol, p.Side.String(), p.MarginMode,
+			formatValue(p.Notional),
+			formatValue(p.Leverage),
+			formatValue(p.MarginUsed),
+			formatValue(p.UnrealizedPnL),
+			formatValue(p.LiquidationPrice),
+			formatValue(p.LiqDistancePct) + "%",
+		})
+	}
+	return c.printTable([]string{"币种", "方向", "模式", "名义价值", "杠杆", "保证金", "未实现盈亏", "强平价", "距强平"}, rows)
+}
+
+func (c *cli) cmdOrders(args []string) error {
+	symbol := ""
+	if len(args) > 0 {
+		symbol = args[0]
+	}
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	lister, ok := t.(trader.OpenOrderLister)
+	if !ok {
+		return fmt.Errorf("当前交易平台不支持查询挂单")
+	}
+	orders, err := lister.GetOpenOrders(symbol)
+	if err != nil {
+		return err
+	}
+	// 止损止盈单独立于普通挂单的平台一并列出
+	if algoLister, ok := t.(trader.PendingAlgoOrderLister); ok {
+		algos, err := algoLister.GetPendingAlgoOrders(symbol)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, algos...)
+	}
+	if c.jsonOut {
+		return c.printJSON(orders)
+	}
+
+	rows := make([][]string, 0, len(orders))
+	for _, o := range orders {
+		rows = append(rows, []string{
+			o.OrderID, o.Symbol, o.Side, o.PositionSide, o.Type,
+			formatValue(o.Price), formatValue(o.StopPrice), formatValue(o.Quantity),
+			o.CreateTime.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return c.printTable([]string{"订单ID", "币种", "方向", "持仓方向", "类型", "价格", "触发价", "数量", "创建时间"}, rows)
+}
+
+func (c *cli) cmdPreflight() error {
+	t, cfg, err := c.loadTraderWithConfig()
+	if err != nil {
+		return err
+	}
+	report := trader.RunPreflight(cfg, t)
+	if c.jsonOut {
+		if err := c.printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprint(c.out, report.String())
+	}
+	if report.HasErrors() {
+		return fmt.Errorf("预检未通过")
+	}
+	return nil
+}
+
+func (c *cli) cmdPrice(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: price <symbol>")
+	}
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	price, err := t.GetMarketPrice(args[0])
+	if err != nil {
+		return err
+	}
+	if c.jsonOut {
+		return c.printJSON(map[string]interface{}{"symbol": args[0], "price": price})
+	}
+	return c.printTable([]string{"币种", "价格"}, [][]string{{args[0], formatValue(price)}})
+}
+
+func (c *cli) cmdClose(args []string) error {
+	fs := flag.NewFlagSet("close", flag.ContinueOnError)
+	side := fs.String("side", "", "平仓方向 long|short（为空表示两个方向）")
+	pct := fs.Float64("pct", 100, "平仓比例（百分比，1-100）")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: close <symbol> [--side long|short] [--pct 50]")
+	}
+	symbol := fs.Arg(0)
+	if *side != "" && *side != "long" && *side != "short" {
+		return fmt.Errorf("无效的方向: %s（应为 long 或 short）", *side)
+	}
+	if *pct <= 0 || *pct > 100 {
+		return fmt.Errorf("无效的平仓比例: %.2f（应在 0-100 之间）", *pct)
+	}
+
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return err
+	}
+
+	var targets []map[string]interface{}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && (*side == "" || pos["side"] == *side) {
+			targets = append(targets, pos)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("没有找到 %s 的持仓", symbol)
+	}
+
+	if !c.confirm(fmt.Sprintf("确认平仓 %s %s %.0f%%?", symbol, sideLabel(*side), *pct)) {
+		return fmt.Errorf("已取消")
+	}
+
+	var results []map[string]interface{}
+	for _, pos := range targets {
+		result, err := closePosition(t, pos, *pct)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+	return c.printResults(results)
+}
+
+func (c *cli) cmdCancel(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: cancel <symbol>|--all")
+	}
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	if args[0] == "--all" {
+		canceller, ok := t.(trader.AllSymbolsCanceller)
+		if !ok {
+			return fmt.Errorf("该交易所不支持撤销所有币种的挂单")
+		}
+		if !c.confirm("确认撤销所有币种的挂单（包括其他持仓的止损止盈）?") {
+			return fmt.Errorf("已取消")
+		}
+		if err := canceller.CancelAllOrdersAllSymbols(); err != nil {
+			return err
+		}
+		return c.printResults([]map[string]interface{}{{"symbol": "*", "cancelled": true}})
+	}
+	if !c.confirm(fmt.Sprintf("确认撤销 %s 的所有挂单?", args[0])) {
+		return fmt.Errorf("已取消")
+	}
+	if reporter, ok := t.(trader.CancelSummaryReporter); ok {
+		summary, err := reporter.CancelAllOrdersWithSummary(args[0])
+		if summary != nil {
+			for _, f := range summary.Failures {
+				fmt.Fprintf(os.Stderr, "❌ 订单 %s 撤销失败: %v\n", f.OrderID, f.Err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return c.printResults([]map[string]interface{}{{
+			"symbol":           args[0],
+			"orders_cancelled": summary.Orders.Cancelled + summary.Orders.AlreadyGone,
+			"algos_cancelled":  summary.Algos.Cancelled + summary.Algos.AlreadyGone,
+		}})
+	}
+	if err := t.CancelAllOrders(args[0]); err != nil {
+		return err
+	}
+	return c.printResults([]map[string]interface{}{{"symbol": args[0], "cancelled": true}})
+}
+
+func (c *cli) cmdFlatten() error {
+	t, err := c.loadTrader()
+	if err != nil {
+		return err
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return c.printResults(nil)
+	}
+	if !c.confirm(fmt.Sprintf("确认平掉全部 %d 个持仓并撤销挂单?", len(positions))) {
+		return fmt.Errorf("已取消")
+	}
+
+	// 逐个平仓，单个失败不影响其他持仓
+	var results []map[string]interface{}
+	var failed int
+	for _, pos := range positions {
+		result, err := closePosition(t, pos, 100)
+		if err != nil {
+			failed++
+			result = map[string]interface{}{"symbol": pos["symbol"], "side": pos["side"], "error": err.Error()}
+		}
+		results = append(results, result)
+		if symbol, ok := pos["symbol"].(string); ok {
+			if err := t.CancelAllOrders(symbol); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ 撤销 %s 挂单失败: %v\n", symbol, err)
+			}
+		}
+	}
+	if err := c.printResults(results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个持仓平仓失败", failed)
+	}
+	return nil
+}
+
+// cmdTraderControl 通过HTTP API启动/停止AutoTrader
+func (c *cli) cmdTraderControl(action string) error {
+	if c.traderID == "" {
+		return fmt.Errorf("halt/resume 需要通过 --trader 指定交易员ID")
+	}
+	if action == "stop" && !c.confirm(fmt.Sprintf("确认停止交易员 %s?", c.traderID)) {
+		return fmt.Errorf("已取消")
+	}
+
+	body, err := c.apiRequest(http.MethodPost, fmt.Sprintf("/api/traders/%s/%s", c.traderID, action), nil)
+	if err != nil {
+		return err
+	}
+	body["trader_id"] = c.traderID
+	return c.printResults([]map[string]interface{}{body})
+}
+
+// cmdPauseSymbol 通过HTTP API暂停运行中AutoTrader的单个币种
+func (c *cli) cmdPauseSymbol(args []string) error {
+	fs := flag.NewFlagSet("pause-symbol", flag.ContinueOnError)
+	manage := fs.Bool("manage", false, "暂停期间继续管理已有持仓（平仓、追加保证金）")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: pause-symbol <symbol> [--manage]")
+	}
+	if c.traderID == "" {
+		return fmt.Errorf("pause-symbol 需要通过 --trader 指定交易员ID")
+	}
+
+	path := fmt.Sprintf("/api/traders/%s/symbols/%s/pause", c.traderID, url.PathEscape(fs.Arg(0)))
+	body, err := c.apiRequest(http.MethodPost, path, map[string]interface{}{"manage_existing": *manage, "by": "nofxctl"})
+	if err != nil {
+		return err
+	}
+	return c.printResults([]map[string]interface{}{{"symbol": fs.Arg(0), "message": body["message"]}})
+}
+
+// cmdResumeSymbol 通过HTTP API恢复运行中AutoTrader的单个币种
+func (c *cli) cmdResumeSymbol(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: resume-symbol <symbol>")
+	}
+	if c.traderID == "" {
+		return fmt.Errorf("resume-symbol 需要通过 --trader 指定交易员ID")
+	}
+
+	path := fmt.Sprintf("/api/traders/%s/symbols/%s/resume", c.traderID, url.PathEscape(args[0]))
+	body, err := c.apiRequest(http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+	return c.printResults([]map[string]interface{}{{"symbol": args[0], "message": body["message"]}})
+}
+
+// cmdCacheStats 通过HTTP API查看运行中AutoTrader的缓存统计
+func (c *cli) cmdCacheStats() error {
+	if c.traderID == "" {
+		return fmt.Errorf("cache 需要通过 --trader 指定交易员ID")
+	}
+	body, err := c.apiRequest(http.MethodGet, "/api/status?trader_id="+url.QueryEscape(c.traderID), nil)
+	if err != nil {
+		return err
+	}
+	stats, _ := body["cache_stats"].([]interface{})
+	if c.jsonOut {
+		return c.printJSON(stats)
+	}
+
+	rows := make([][]string, 0, len(stats))
+	for _, item := range stats {
+		stat, _ := item.(map[string]interface{})
+		rows = append(rows, []string{
+			formatValue(stat["name"]),
+			formatValue(stat["age_seconds"]),
+			formatValue(stat["hits"]),
+			formatValue(stat["misses"]),
+			formatValue(stat["last_refresh"]),
+			formatValue(stat["last_error"]),
+		})
+	}
+	return c.printTable([]string{"缓存", "已缓存(秒)", "命中", "未命中", "上次刷新", "上次错误"}, rows)
+}
+
+// cmdSymbols 通过HTTP API查看运行中AutoTrader各币种的交易状态
+func (c *cli) cmdSymbols() error {
+	if c.traderID == "" {
+		return fmt.Errorf("symbols 需要通过 --trader 指定交易员ID")
+	}
+	body, err := c.apiRequest(http.MethodGet, "/api/status?trader_id="+url.QueryEscape(c.traderID), nil)
+	if err != nil {
+		return err
+	}
+	symbols, _ := body["symbols"].([]interface{})
+	if c.jsonOut {
+		return c.printJSON(symbols)
+	}
+
+	rows := make([][]string, 0, len(symbols))
+	for _, item := range symbols {
+		state, _ := item.(map[string]interface{})
+		rows = append(rows, []string{
+			formatValue(state["symbol"]),
+			formatValue(state["phase"]),
+			formatValue(state["since"]),
+			formatValue(state["reason"]),
+		})
+	}
+	return c.printTable([]string{"币种", "状态", "开始时间", "原因"}, rows)
+}
+
+// apiRequest 调用AutoTrader HTTP API，返回JSON响应（payload不为nil时作为JSON请求体）
+func (c *cli) apiRequest(method, path string, payload interface{}) (map[string]interface{}, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(c.apiURL, "/")+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求API失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析API响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API返回 %d: %v", resp.StatusCode, body["error"])
+	}
+	return body, nil
+}
+
+// closePosition 按比例平掉单个持仓（pct=100表示全部平仓）
+func closePosition(t trader.Trader, pos map[string]interface{}, pct float64) (map[string]interface{}, error) {
+	symbol, _ := pos["symbol"].(string)
+	side, _ := pos["side"].(string)
+
+	var result map[string]interface{}
+	var err error
+	if closer, ok := t.(trader.PartialCloser); ok {
+		// 按比例平仓，部分平仓保留剩余持仓的止损止盈
+		var positionSide trader.PositionSide
+		if positionSide, err = trader.ParsePositionSide(side); err == nil {
+			result, err = closer.ClosePositionPercent(symbol, positionSide, pct)
+		}
+	} else {
+		quantity := 0.0 // 0 = 全部平仓
+		if pct < 100 {
+			amt, _ := pos["positionAmt"].(float64)
+			if amt < 0 {
+				amt = -amt
+			}
+			quantity = amt * pct / 100
+		}
+		if side == "long" {
+			result, err = t.CloseLong(symbol, quantity)
+		} else {
+			result, err = t.CloseShort(symbol, quantity)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("平仓 %s %s 失败: %w", symbol, side, err)
+	}
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	result["symbol"] = symbol
+	result["side"] = side
+	return result, nil
+}
+
+// confirm 危险操作确认（--yes 跳过）
+func (c *cli) confirm(prompt string) bool {
+	if c.yes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// printResults 输出操作结果
+func (c *cli) printResults(results []map[string]interface{}) error {
+	if results == nil {
+		results = []map[string]interface{}{}
+	}
+	if c.jsonOut {
+		return c.printJSON(results)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(c.out, "（无）")
+		return nil
+	}
+
+	keySet := make(map[string]bool)
+	for _, r := range results {
+		for k := range r {
+			keySet[k] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		row := make([]string, len(keys))
+		for i, k := range keys {
+			row[i] = formatValue(r[k])
+		}
+		rows = append(rows, row)
+	}
+	return c.printTable(keys, rows)
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *cli) printTable(headers []string, rows [][]string) error {
+	if len(rows) == 0 {
+		fmt.Fprintln(c.out, "（无）")
+		return nil
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "-"
+	case float64:
+		return fmt.Sprintf("%.8g", val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func sideLabel(side string) string {
+	switch side {
+	case "long":
+		return "多仓"
+	case "short":
+		return "空仓"
+	default:
+		return "多空双向"
+	}
+}
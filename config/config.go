@@ -15,7 +15,7 @@ type TraderConfig struct {
 	AIModel string `json:"ai_model"` // "qwen" or "deepseek"
 
 	// 交易平台选择（二选一）
-	Exchange string `json:"exchange"` // "binance" or "hyperliquid"
+	Exchange string `json:"exchange"` // "binance", "hyperliquid", "aster", "okx" or "bybit"
 
 	// 币安配置
 	BinanceAPIKey    string `json:"binance_api_key,omitempty"`
@@ -31,6 +31,15 @@ type TraderConfig struct {
 	AsterSigner     string `json:"aster_signer,omitempty"`      // Aster API钱包地址
 	AsterPrivateKey string `json:"aster_private_key,omitempty"` // Aster API钱包私钥
 
+	// OKX配置
+	OKXAPIKey     string `json:"okx_api_key,omitempty"`
+	OKXSecretKey  string `json:"okx_secret_key,omitempty"`
+	OKXPassphrase string `json:"okx_passphrase,omitempty"`
+
+	// Bybit配置
+	BybitAPIKey    string `json:"bybit_api_key,omitempty"`
+	BybitSecretKey string `json:"bybit_secret_key,omitempty"`
+
 	// AI配置
 	QwenKey     string `json:"qwen_key,omitempty"`
 	DeepSeekKey string `json:"deepseek_key,omitempty"`
@@ -128,8 +137,8 @@ func (c *Config) Validate() error {
 		if trader.Exchange == "" {
 			trader.Exchange = "binance" // 默认使用币安
 		}
-		if trader.Exchange != "binance" && trader.Exchange != "hyperliquid" && trader.Exchange != "aster" {
-			return fmt.Errorf("trader[%d]: exchange必须是 'binance', 'hyperliquid' 或 'aster'", i)
+		if trader.Exchange != "binance" && trader.Exchange != "hyperliquid" && trader.Exchange != "aster" && trader.Exchange != "okx" && trader.Exchange != "bybit" {
+			return fmt.Errorf("trader[%d]: exchange必须是 'binance', 'hyperliquid', 'aster', 'okx' 或 'bybit'", i)
 		}
 
 		// 根据平台验证对应的密钥
@@ -145,6 +154,14 @@ func (c *Config) Validate() error {
 			if trader.AsterUser == "" || trader.AsterSigner == "" || trader.AsterPrivateKey == "" {
 				return fmt.Errorf("trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key", i)
 			}
+		} else if trader.Exchange == "okx" {
+			if trader.OKXAPIKey == "" || trader.OKXSecretKey == "" || trader.OKXPassphrase == "" {
+				return fmt.Errorf("trader[%d]: 使用OKX时必须配置okx_api_key, okx_secret_key和okx_passphrase", i)
+			}
+		} else if trader.Exchange == "bybit" {
+			if trader.BybitAPIKey == "" || trader.BybitSecretKey == "" {
+				return fmt.Errorf("trader[%d]: 使用Bybit时必须配置bybit_api_key和bybit_secret_key", i)
+			}
 		}
 
 		if trader.AIModel == "qwen" && trader.QwenKey == "" {
@@ -0,0 +1,259 @@
+// Package journal 把交易事件（下单、成交、止损止盈、撤单与错误）持久化到SQLite，并维护按开平仓更新的持仓记录
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"nofx/trader"
+)
+
+// timeLayout 时间统一以UTC、固定宽度的文本保存，按字符串比较即按时间排序
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+// maxPending 写入失败时暂存待重试的事件数（满时丢弃最旧的事件）
+const maxPending = 1000
+
+// sizeEpsilon 剩余数量小于该值视为已全部平仓（数量为浮点数）
+const sizeEpsilon = 1e-9
+
+// Journal 交易日志
+// 写入失败（磁盘满、数据库被锁等）只记录日志并暂存事件，下次写入时重试，不影响下单
+type Journal struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	pending []trader.TradeEvent
+	closed  bool
+	dropped int64
+}
+
+// Open 打开（不存在时创建）日志数据库
+func Open(path string) (*Journal, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("打开交易日志失败: %w", err)
+	}
+	// 多个交易员的事件回调并发写入，统一经一个连接串行执行
+	db.SetMaxOpenConns(1)
+
+	j := &Journal{db: db}
+	if err := j.createTables(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建交易日志表失败: %w", err)
+	}
+	return j, nil
+}
+
+// createTables 创建日志表
+func (j *Journal) createTables() error {
+	queries := []string{
+		// 所有交易事件
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			time TEXT NOT NULL,
+			trader TEXT NOT NULL DEFAULT '',
+			type TEXT NOT NULL,
+			symbol TEXT NOT NULL DEFAULT '',
+			side TEXT NOT NULL DEFAULT '',
+			order_side TEXT NOT NULL DEFAULT '',
+			size REAL NOT NULL DEFAULT 0,
+			price REAL NOT NULL DEFAULT 0,
+			entry_price REAL NOT NULL DEFAULT 0,
+			leverage INTEGER NOT NULL DEFAULT 0,
+			order_id TEXT NOT NULL DEFAULT '',
+			client_order_id TEXT NOT NULL DEFAULT '',
+			algo_id TEXT NOT NULL DEFAULT '',
+			realized_pnl REAL NOT NULL DEFAULT 0,
+			count INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_events_time ON events(time)`,
+		`CREATE INDEX IF NOT EXISTS idx_events_symbol ON events(symbol, time)`,
+
+		// 持仓：开仓时插入（同方向加仓合并），平仓时累计平仓数量与已实现盈亏，全部平掉后写入closed_at
+		`CREATE TABLE IF NOT EXISTS positions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader TEXT NOT NULL DEFAULT '',
+			symbol TEXT NOT NULL,
+			side TEXT NOT NULL,
+			size REAL NOT NULL DEFAULT 0,       -- 当前未平数量
+			entry_price REAL NOT NULL DEFAULT 0,
+			leverage INTEGER NOT NULL DEFAULT 0,
+			opened_at TEXT,                     -- 日志启用前开的仓为NULL
+			closed_at TEXT,                     -- 未全部平仓时为NULL
+			closed_size REAL NOT NULL DEFAULT 0,
+			exit_price REAL NOT NULL DEFAULT 0, -- 按数量加权的平仓均价
+			realized_pnl REAL NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_positions_open ON positions(trader, symbol, side, closed_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_positions_closed_at ON positions(closed_at)`,
+	}
+	for _, query := range queries {
+		if _, err := j.db.Exec(query); err != nil {
+			return fmt.Errorf("执行SQL失败 [%s]: %w", query, err)
+		}
+	}
+	return nil
+}
+
+// HandleEvent 交易事件回调（可直接传给 trader.AddTradeEventHook）
+// 先重试之前写入失败的事件，再写入本事件；失败时暂存
+func (j *Journal) HandleEvent(e trader.TradeEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return
+	}
+	j.pending = append(j.pending, e)
+	j.flushLocked()
+}
+
+// flushLocked 按顺序写入暂存的事件，遇到失败即停止（保持事件顺序，持仓记录依赖开平仓顺序）
+func (j *Journal) flushLocked() {
+	for len(j.pending) > 0 {
+		if err := j.record(j.pending[0]); err != nil {
+			log.Printf("⚠️  写入交易日志失败（暂存 %d 条待重试）: %v", len(j.pending), err)
+			if len(j.pending) > maxPending {
+				j.pending = j.pending[len(j.pending)-maxPending:]
+				if j.dropped++; j.dropped == 1 || j.dropped%100 == 0 {
+					log.Printf("⚠️  交易日志暂存已满，已丢弃 %d 条事件", j.dropped)
+				}
+			}
+			return
+		}
+		j.pending = j.pending[1:]
+	}
+	j.pending = nil
+}
+
+// Pending 写入失败、等待重试的事件数
+func (j *Journal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.pending)
+}
+
+// Close 最后重试一次暂存的事件后关闭数据库
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+	j.flushLocked()
+	if len(j.pending) > 0 {
+		log.Printf("⚠️  交易日志关闭时仍有 %d 条事件未写入", len(j.pending))
+	}
+	j.closed = true
+	return j.db.Close()
+}
+
+// record 在一个事务中写入事件并更新持仓
+func (j *Journal) record(e trader.TradeEvent) error {
+	tx, err := j.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	at := formatTime(e.Time)
+	if _, err := tx.Exec(`
+		INSERT INTO events (time, trader, type, symbol, side, order_side, size, price, entry_price, leverage,
+			order_id, client_order_id, algo_id, realized_pnl, count, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, at, e.Trader, string(e.Type), e.Symbol, e.Side, e.OrderSide, e.Size, e.Price, e.EntryPrice, e.Leverage,
+		e.OrderID, e.ClientOrderID, e.AlgoID, e.RealizedPnL, e.Count, e.Error); err != nil {
+		return fmt.Errorf("写入事件失败: %w", err)
+	}
+
+	switch e.Type {
+	case trader.EventPositionOpened:
+		err = recordOpen(tx, e, at)
+	case trader.EventPositionClosed:
+		err = recordClose(tx, e, at)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordOpen 新开仓插入一行；已有同方向未平仓位时按数量加权合并开仓价
+func recordOpen(tx *sql.Tx, e trader.TradeEvent, at string) error {
+	var id int64
+	var size, entry float64
+	err := tx.QueryRow(`
+		SELECT id, size, entry_price FROM positions
+		WHERE trader = ? AND symbol = ? AND side = ? AND closed_at IS NULL
+		ORDER BY id DESC LIMIT 1
+	`, e.Trader, e.Symbol, e.Side).Scan(&id, &size, &entry)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`
+			INSERT INTO positions (trader, symbol, side, size, entry_price, leverage, opened_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, e.Trader, e.Symbol, e.Side, e.Size, e.EntryPrice, e.Leverage, at)
+	case err == nil:
+		_, err = tx.Exec(`UPDATE positions SET size = ?, entry_price = ?, leverage = ? WHERE id = ?`,
+			size+e.Size, weighted(entry, size, e.EntryPrice, e.Size), e.Leverage, id)
+	}
+	if err != nil {
+		return fmt.Errorf("更新持仓记录失败: %w", err)
+	}
+	return nil
+}
+
+// recordClose 累计平仓数量、平仓均价与已实现盈亏，剩余数量为0时标记为已平仓
+// 找不到未平仓位（日志启用前开的仓）时插入一条开仓时间为空的记录
+func recordClose(tx *sql.Tx, e trader.TradeEvent, at string) error {
+	var id int64
+	var size, closedSize, exit float64
+	err := tx.QueryRow(`
+		SELECT id, size, closed_size, exit_price FROM positions
+		WHERE trader = ? AND symbol = ? AND side = ? AND closed_at IS NULL
+		ORDER BY id DESC LIMIT 1
+	`, e.Trader, e.Symbol, e.Side).Scan(&id, &size, &closedSize, &exit)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`
+			INSERT INTO positions (trader, symbol, side, size, entry_price, leverage, closed_at, closed_size, exit_price, realized_pnl)
+			VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?, ?)
+		`, e.Trader, e.Symbol, e.Side, e.EntryPrice, e.Leverage, at, e.Size, e.Price, e.RealizedPnL)
+	case err == nil:
+		remaining := math.Max(size-e.Size, 0)
+		var closedAt any
+		if remaining < sizeEpsilon {
+			remaining, closedAt = 0, at
+		}
+		_, err = tx.Exec(`
+			UPDATE positions SET size = ?, closed_size = ?, exit_price = ?, realized_pnl = realized_pnl + ?, closed_at = ?
+			WHERE id = ?
+		`, remaining, closedSize+e.Size, weighted(exit, closedSize, e.Price, e.Size), e.RealizedPnL, closedAt, id)
+	}
+	if err != nil {
+		return fmt.Errorf("更新持仓记录失败: %w", err)
+	}
+	return nil
+}
+
+// weighted 按数量加权平均价格
+func weighted(p1, q1, p2, q2 float64) float64 {
+	if q1+q2 <= 0 {
+		return p2
+	}
+	return (p1*q1 + p2*q2) / (q1 + q2)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,208 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"nofx/trader"
+)
+
+// Entry 日志中的一条事件
+type Entry struct {
+	ID int64 `json:"id"`
+	trader.TradeEvent
+}
+
+// Position 持仓记录（ClosedAt为空表示尚未全部平仓，OpenedAt为空表示日志启用前开的仓）
+type Position struct {
+	ID          int64      `json:"id"`
+	Trader      string     `json:"trader"`
+	Symbol      string     `json:"symbol"`
+	Side        string     `json:"side"`
+	Size        float64    `json:"size"`
+	EntryPrice  float64    `json:"entry_price"`
+	Leverage    int        `json:"leverage"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	ClosedSize  float64    `json:"closed_size"`
+	ExitPrice   float64    `json:"exit_price"`
+	RealizedPnL float64    `json:"realized_pnl"`
+}
+
+// SymbolPnL 单个币种的已实现盈亏
+type SymbolPnL struct {
+	Symbol      string  `json:"symbol"`
+	Closes      int     `json:"closes"` // 平仓成交次数（含部分平仓）
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+// Stats 时间范围内全部平仓的持仓统计
+type Stats struct {
+	Closed      int     `json:"closed"`
+	Wins        int     `json:"wins"`
+	Losses      int     `json:"losses"`
+	WinRate     float64 `json:"win_rate"` // 0~1，没有平仓时为0
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+const eventColumns = `id, time, trader, type, symbol, side, order_side, size, price, entry_price, leverage,
+	order_id, client_order_id, algo_id, realized_pnl, count, error`
+
+// Events 时间范围[from, to)内的所有事件（按时间排序）
+func (j *Journal) Events(from, to time.Time) ([]Entry, error) {
+	return j.queryEvents(`SELECT `+eventColumns+` FROM events WHERE time >= ? AND time < ? ORDER BY time, id`,
+		formatTime(from), formatTime(to))
+}
+
+// Trades 时间范围[from, to)内的开平仓成交（按时间排序）
+func (j *Journal) Trades(from, to time.Time) ([]Entry, error) {
+	return j.queryEvents(`SELECT `+eventColumns+` FROM events WHERE type IN (?, ?) AND time >= ? AND time < ? ORDER BY time, id`,
+		string(trader.EventPositionOpened), string(trader.EventPositionClosed), formatTime(from), formatTime(to))
+}
+
+func (j *Journal) queryEvents(query string, args ...any) ([]Entry, error) {
+	rows, err := j.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易日志失败: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var at, typ string
+		if err := rows.Scan(&e.ID, &at, &e.Trader, &typ, &e.Symbol, &e.Side, &e.OrderSide, &e.Size, &e.Price,
+			&e.EntryPrice, &e.Leverage, &e.OrderID, &e.ClientOrderID, &e.AlgoID, &e.RealizedPnL, &e.Count, &e.Error); err != nil {
+			return nil, fmt.Errorf("读取交易日志失败: %w", err)
+		}
+		e.Type = trader.TradeEventType(typ)
+		e.Time, _ = time.Parse(timeLayout, at)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// OpenPositions 尚未全部平仓的持仓记录
+func (j *Journal) OpenPositions() ([]Position, error) {
+	return j.queryPositions(`SELECT ` + positionColumns + ` FROM positions WHERE closed_at IS NULL ORDER BY id`)
+}
+
+// ClosedPositions 时间范围[from, to)内全部平仓的持仓记录（按平仓时间排序）
+func (j *Journal) ClosedPositions(from, to time.Time) ([]Position, error) {
+	return j.queryPositions(`SELECT `+positionColumns+` FROM positions WHERE closed_at >= ? AND closed_at < ? ORDER BY closed_at, id`,
+		formatTime(from), formatTime(to))
+}
+
+const positionColumns = `id, trader, symbol, side, size, entry_price, leverage, opened_at, closed_at, closed_size, exit_price, realized_pnl`
+
+func (j *Journal) queryPositions(query string, args ...any) ([]Position, error) {
+	rows, err := j.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []Position
+	for rows.Next() {
+		var p Position
+		var openedAt, closedAt sql.NullString
+		if err := rows.Scan(&p.ID, &p.Trader, &p.Symbol, &p.Side, &p.Size, &p.EntryPrice, &p.Leverage,
+			&openedAt, &closedAt, &p.ClosedSize, &p.ExitPrice, &p.RealizedPnL); err != nil {
+			return nil, fmt.Errorf("读取持仓记录失败: %w", err)
+		}
+		p.OpenedAt = parseNullTime(openedAt)
+		p.ClosedAt = parseNullTime(closedAt)
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// PnLBySymbol 时间范围[from, to)内各币种平仓成交的已实现盈亏（按盈亏从高到低）
+func (j *Journal) PnLBySymbol(from, to time.Time) ([]SymbolPnL, error) {
+	rows, err := j.db.Query(`
+		SELECT symbol, COUNT(*), COALESCE(SUM(realized_pnl), 0) FROM events
+		WHERE type = ? AND time >= ? AND time < ?
+		GROUP BY symbol ORDER BY SUM(realized_pnl) DESC, symbol
+	`, string(trader.EventPositionClosed), formatTime(from), formatTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("统计币种盈亏失败: %w", err)
+	}
+	defer rows.Close()
+
+	var result []SymbolPnL
+	for rows.Next() {
+		var s SymbolPnL
+		if err := rows.Scan(&s.Symbol, &s.Closes, &s.RealizedPnL); err != nil {
+			return nil, fmt.Errorf("统计币种盈亏失败: %w", err)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// Stats 时间范围[from, to)内全部平仓的持仓的胜率与盈亏（已实现盈亏>0为盈利，<0为亏损）
+func (j *Journal) Stats(from, to time.Time) (Stats, error) {
+	var s Stats
+	err := j.db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN realized_pnl < 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0)
+		FROM positions WHERE closed_at >= ? AND closed_at < ?
+	`, formatTime(from), formatTime(to)).Scan(&s.Closed, &s.Wins, &s.Losses, &s.RealizedPnL)
+	if err != nil {
+		return Stats{}, fmt.Errorf("统计胜率失败: %w", err)
+	}
+	if s.Closed > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Closed)
+	}
+	return s, nil
+}
+
+// WinRate 时间范围[from, to)内全部平仓的持仓的胜率（0~1）与平仓数
+func (j *Journal) WinRate(from, to time.Time) (float64, int, error) {
+	s, err := j.Stats(from, to)
+	return s.WinRate, s.Closed, err
+}
+
+// ExportCSV 以CSV导出时间范围[from, to)内的所有事件（首行为列名）
+func (j *Journal) ExportCSV(w io.Writer, from, to time.Time) error {
+	entries, err := j.Events(from, to)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "trader", "type", "symbol", "side", "order_side", "size", "price", "entry_price",
+		"leverage", "order_id", "client_order_id", "algo_id", "realized_pnl", "count", "error"})
+	for _, e := range entries {
+		cw.Write([]string{
+			formatTime(e.Time), e.Trader, string(e.Type), e.Symbol, e.Side, e.OrderSide,
+			formatFloat(e.Size), formatFloat(e.Price), formatFloat(e.EntryPrice), strconv.Itoa(e.Leverage),
+			e.OrderID, e.ClientOrderID, e.AlgoID, formatFloat(e.RealizedPnL), strconv.Itoa(e.Count), e.Error,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("导出CSV失败: %w", err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func parseNullTime(s sql.NullString) *time.Time {
+	if !s.Valid {
+		return nil
+	}
+	t, err := time.Parse(timeLayout, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
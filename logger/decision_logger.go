@@ -59,6 +59,10 @@ type DecisionAction struct {
 	Timestamp time.Time `json:"timestamp"` // 执行时间
 	Success   bool      `json:"success"`   // 是否成功
 	Error     string    `json:"error"`     // 错误信息
+
+	// 数量低于最小下单量被提高时记录（原始数量与额外承担的名义价值）
+	RequestedQuantity float64 `json:"requested_quantity,omitempty"`
+	ExtraNotional     float64 `json:"extra_notional,omitempty"`
 }
 
 // DecisionLogger 决策日志记录器
@@ -7,14 +7,21 @@ import (
 	"nofx/api"
 	"nofx/auth"
 	"nofx/config"
+	"nofx/journal"
 	"nofx/manager"
 	"nofx/market"
+	"nofx/notifier"
 	"nofx/pool"
+	"nofx/rpc"
+	"nofx/trader"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // LeverageConfig 杠杆配置
@@ -23,21 +30,43 @@ type LeverageConfig struct {
 	AltcoinLeverage int `json:"altcoin_leverage"`
 }
 
+// OrderLimitsConfig 单笔订单名义价值上限（USDT，0表示不限制）
+type OrderLimitsConfig struct {
+	MaxOrderNotional float64            `json:"max_order_notional"`
+	PerSymbol        map[string]float64 `json:"per_symbol"`
+}
+
+// EventBusConfig 交易事件消息总线配置（backend: nats/redis）
+type EventBusConfig = trader.EventBusConfig
+
+// NotifierConfig 交易通知配置（Telegram / Webhook）
+type NotifierConfig = notifier.Config
+
+// GRPCConfig gRPC服务配置（addr为空时不启动）
+type GRPCConfig = rpc.Config
+
 // ConfigFile 配置文件结构，只包含需要同步到数据库的字段
 type ConfigFile struct {
-	AdminMode          bool           `json:"admin_mode"`
-	BetaMode           bool           `json:"beta_mode"`
-	APIServerPort      int            `json:"api_server_port"`
-	UseDefaultCoins    bool           `json:"use_default_coins"`
-	DefaultCoins       []string       `json:"default_coins"`
-	CoinPoolAPIURL     string         `json:"coin_pool_api_url"`
-	OITopAPIURL        string         `json:"oi_top_api_url"`
-	MaxDailyLoss       float64        `json:"max_daily_loss"`
-	MaxDrawdown        float64        `json:"max_drawdown"`
-	StopTradingMinutes int            `json:"stop_trading_minutes"`
-	Leverage           LeverageConfig `json:"leverage"`
-	JWTSecret          string         `json:"jwt_secret"`
-	DataKLineTime      string         `json:"data_k_line_time"`
+	AdminMode          bool              `json:"admin_mode"`
+	BetaMode           bool              `json:"beta_mode"`
+	APIServerPort      int               `json:"api_server_port"`
+	UseDefaultCoins    bool              `json:"use_default_coins"`
+	DefaultCoins       []string          `json:"default_coins"`
+	CoinPoolAPIURL     string            `json:"coin_pool_api_url"`
+	OITopAPIURL        string            `json:"oi_top_api_url"`
+	MaxDailyLoss       float64           `json:"max_daily_loss"`
+	MaxDrawdown        float64           `json:"max_drawdown"`
+	StopTradingMinutes int               `json:"stop_trading_minutes"`
+	Leverage           LeverageConfig    `json:"leverage"`
+	OrderLimits        OrderLimitsConfig `json:"order_limits"`
+	Language           string            `json:"language"`
+	EventBus           EventBusConfig    `json:"event_bus"`
+	Notifier           NotifierConfig    `json:"notifier"`
+	TradeJournal       string            `json:"trade_journal"` // 交易日志SQLite文件路径（为空时不记录）
+	MetricsInterval    int               `json:"metrics_interval_seconds"`
+	GRPC               GRPCConfig        `json:"grpc"`
+	JWTSecret          string            `json:"jwt_secret"`
+	DataKLineTime      string            `json:"data_k_line_time"`
 }
 
 // syncConfigToDatabase 从config.json读取配置并同步到数据库
@@ -91,6 +120,53 @@ func syncConfigToDatabase(database *config.Database) error {
 		configs["altcoin_leverage"] = strconv.Itoa(configFile.Leverage.AltcoinLeverage)
 	}
 
+	// 同步单笔订单上限
+	if configFile.OrderLimits.MaxOrderNotional > 0 || len(configFile.OrderLimits.PerSymbol) > 0 {
+		orderLimitsJSON, err := json.Marshal(configFile.OrderLimits)
+		if err == nil {
+			configs["order_limits"] = string(orderLimitsJSON)
+		}
+	}
+
+	// 同步日志与错误信息语言
+	if configFile.Language != "" {
+		configs["language"] = configFile.Language
+	}
+
+	// 同步交易事件消息总线配置
+	if configFile.EventBus.Backend != "" {
+		eventBusJSON, err := json.Marshal(configFile.EventBus)
+		if err == nil {
+			configs["event_bus"] = string(eventBusJSON)
+		}
+	}
+
+	// 同步交易通知配置
+	if (configFile.Notifier.Telegram != nil && configFile.Notifier.Telegram.BotToken != "") || len(configFile.Notifier.Webhooks) > 0 {
+		notifierJSON, err := json.Marshal(configFile.Notifier)
+		if err == nil {
+			configs["notifier"] = string(notifierJSON)
+		}
+	}
+
+	// 同步交易日志路径
+	if configFile.TradeJournal != "" {
+		configs["trade_journal"] = configFile.TradeJournal
+	}
+
+	// 同步风险指标刷新间隔
+	if configFile.MetricsInterval > 0 {
+		configs["metrics_interval_seconds"] = strconv.Itoa(configFile.MetricsInterval)
+	}
+
+	// 同步gRPC服务配置
+	if configFile.GRPC.Addr != "" {
+		grpcJSON, err := json.Marshal(configFile.GRPC)
+		if err == nil {
+			configs["grpc"] = string(grpcJSON)
+		}
+	}
+
 	// 如果JWT密钥不为空，也同步
 	if configFile.JWTSecret != "" {
 		configs["jwt_secret"] = configFile.JWTSecret
@@ -150,10 +226,16 @@ func main() {
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
 	fmt.Println()
 
-	// 初始化数据库配置
+	// 解析命令行参数：[dbPath] [--force]
 	dbPath := "config.db"
-	if len(os.Args) > 1 {
-		dbPath = os.Args[1]
+	for _, arg := range os.Args[1:] {
+		if arg == "--force" {
+			// 预检仅有警告时仍然启动交易员
+			trader.SetPreflightForce(true)
+			log.Printf("⚠️  已启用 --force：启动预检的警告将被忽略")
+			continue
+		}
+		dbPath = arg
 	}
 
 	log.Printf("📋 初始化配置数据库: %s", dbPath)
@@ -242,6 +324,65 @@ func main() {
 		log.Printf("✓ 已配置OI Top API")
 	}
 
+	// 单笔订单名义价值上限（交易器下单前强制检查）
+	if orderLimitsJSON, _ := database.GetSystemConfig("order_limits"); orderLimitsJSON != "" {
+		var orderLimits OrderLimitsConfig
+		if err := json.Unmarshal([]byte(orderLimitsJSON), &orderLimits); err != nil {
+			log.Printf("⚠️  解析order_limits配置失败: %v", err)
+		} else {
+			trader.SetMaxOrderNotional(orderLimits.MaxOrderNotional, orderLimits.PerSymbol)
+			log.Printf("✓ 单笔订单上限: %.2f USDT（%d 个币种单独配置）", orderLimits.MaxOrderNotional, len(orderLimits.PerSymbol))
+		}
+	}
+
+	// 日志与错误信息语言（默认中文）
+	if language, _ := database.GetSystemConfig("language"); language != "" {
+		trader.SetLanguage(language)
+		log.Printf("✓ 日志语言: %s", trader.CurrentLanguage())
+	}
+
+	// 交易事件发布到消息总线（需在创建交易员之前设置）
+	if eventBusJSON, _ := database.GetSystemConfig("event_bus"); eventBusJSON != "" {
+		var eventBus trader.EventBusConfig
+		if err := json.Unmarshal([]byte(eventBusJSON), &eventBus); err != nil {
+			log.Printf("⚠️  解析event_bus配置失败: %v", err)
+		} else if publisher, err := trader.NewEventPublisher(eventBus); err != nil {
+			log.Printf("⚠️  初始化交易事件发布失败: %v", err)
+		} else if publisher != nil {
+			trader.SetEventPublisher(publisher)
+			log.Printf("✓ 交易事件发布: %s %s", eventBus.Backend, eventBus.URL)
+		}
+	}
+
+	// 交易通知（开平仓、下单失败、止损设置失败），需在创建交易员之前设置
+	var tradeNotifier *notifier.Service
+	if notifierJSON, _ := database.GetSystemConfig("notifier"); notifierJSON != "" {
+		var notifierConfig NotifierConfig
+		if err := json.Unmarshal([]byte(notifierJSON), &notifierConfig); err != nil {
+			log.Printf("⚠️  解析notifier配置失败: %v", err)
+		} else if tradeNotifier, err = notifier.New(notifierConfig); err != nil {
+			log.Printf("⚠️  初始化交易通知失败: %v", err)
+		} else if tradeNotifier != nil {
+			trader.AddTradeEventHook(tradeNotifier.HandleEvent)
+			log.Printf("✓ 交易通知已启用（Webhook %d 个）", len(notifierConfig.Webhooks))
+		}
+	}
+
+	// 交易日志：记录所有下单、成交、止损止盈、撤单与错误（需在创建交易员之前设置）
+	var tradeJournal *journal.Journal
+	if journalPath, _ := database.GetSystemConfig("trade_journal"); journalPath != "" {
+		if tradeJournal, err = journal.Open(journalPath); err != nil {
+			log.Printf("⚠️  打开交易日志失败: %v", err)
+		} else {
+			trader.AddTradeEventHook(tradeJournal.HandleEvent)
+			log.Printf("✓ 交易日志: %s", journalPath)
+		}
+	}
+
+	// 交易器运行指标（下单、撤单、缓存、REST耗时），与风险指标一起通过 GET /metrics 输出（需在创建交易员之前设置）
+	tradeMetrics := prometheus.NewRegistry()
+	trader.SetMetricsRegisterer(tradeMetrics)
+
 	// 创建TraderManager
 	traderManager := manager.NewTraderManager()
 
@@ -297,12 +438,31 @@ func main() {
 
 	// 创建并启动API服务器
 	apiServer := api.NewServer(traderManager, database, apiPort)
+
+	// 风险指标（Prometheus格式，GET /metrics）
+	metricsInterval := 60
+	if intervalStr, _ := database.GetSystemConfig("metrics_interval_seconds"); intervalStr != "" {
+		if v, err := strconv.Atoi(intervalStr); err == nil && v > 0 {
+			metricsInterval = v
+		}
+	}
+	riskMetrics := trader.NewRiskMetricsCollector(traderManager.GetAllTraders, time.Duration(metricsInterval)*time.Second)
+	tradeMetrics.MustRegister(riskMetrics)
+	riskMetrics.Start()
+	apiServer.SetMetrics(tradeMetrics)
+	log.Printf("✓ 风险指标: 每 %d 秒刷新，GET /metrics", metricsInterval)
 	go func() {
 		if err := apiServer.Start(); err != nil {
 			log.Printf("❌ API服务器错误: %v", err)
 		}
 	}()
 
+	// gRPC服务（委托给指定交易员，与机器人共用审计与风控链路）
+	var grpcServer *rpc.Server
+	if grpcJSON, _ := database.GetSystemConfig("grpc"); grpcJSON != "" {
+		grpcServer = startGRPCServer(grpcJSON, traderManager)
+	}
+
 	// 启动流行情数据 - 默认使用所有交易员设置的币种 如果没有设置币种 则优先使用系统默认
 	go market.NewWSMonitor(150).Start(database.GetCustomCoins())
 	//go market.NewWSMonitor(150).Start([]string{}) //这里是一个使用方式 传入空的话 则使用market市场的所有币种
@@ -318,8 +478,45 @@ func main() {
 	fmt.Println()
 	fmt.Println()
 	log.Println("📛 收到退出信号，正在停止所有trader...")
+	riskMetrics.Stop()
+	if grpcServer != nil {
+		grpcServer.Close()
+	}
 	traderManager.StopAll()
+	if tradeNotifier != nil {
+		tradeNotifier.Close()
+	}
+	if tradeJournal != nil {
+		tradeJournal.Close()
+	}
 
 	fmt.Println()
 	fmt.Println("👋 感谢使用AI交易系统！")
 }
+
+// startGRPCServer 按配置启动gRPC服务，配置无效或交易员不存在时返回nil
+func startGRPCServer(grpcJSON string, traderManager *manager.TraderManager) *rpc.Server {
+	var grpcConfig GRPCConfig
+	if err := json.Unmarshal([]byte(grpcJSON), &grpcConfig); err != nil {
+		log.Printf("⚠️  解析grpc配置失败: %v", err)
+		return nil
+	}
+	at, err := traderManager.GetTrader(grpcConfig.TraderID)
+	if err != nil {
+		log.Printf("⚠️  gRPC服务未启动: %v", err)
+		return nil
+	}
+	server, err := rpc.NewServer(at.Trader(), at, grpcConfig)
+	if err != nil {
+		log.Printf("⚠️  gRPC服务未启动: %v", err)
+		return nil
+	}
+	at.AddTradeEventSink(server)
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			log.Printf("❌ gRPC服务错误: %v", err)
+		}
+	}()
+	log.Printf("✓ gRPC服务: %s（交易员 %s）", grpcConfig.Addr, at.GetName())
+	return server
+}
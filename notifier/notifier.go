@@ -0,0 +1,264 @@
+// Package notifier 把交易事件（开平仓、下单失败、止损止盈设置失败）格式化后推送到Telegram或通用Webhook
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nofx/trader"
+)
+
+// Priority 消息优先级
+type Priority string
+
+const (
+	PriorityNormal Priority = "normal"
+	PriorityAlert  Priority = "alert" // 需要人工处理的错误（如止损设置失败），Telegram中总是带提醒
+)
+
+// Message 待发送的一条通知
+type Message struct {
+	Text     string             `json:"text"`
+	Priority Priority           `json:"priority"`
+	Event    *trader.TradeEvent `json:"event,omitempty"` // 由交易事件生成时为原始事件
+}
+
+// Sender 通知通道
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config 通知配置（未配置任何通道时不启用，bot_token与chat_id都为空视为未配置Telegram）
+type Config struct {
+	Telegram  *TelegramConfig   `json:"telegram,omitempty"`
+	Webhooks  []WebhookConfig   `json:"webhooks,omitempty"`
+	Templates map[string]string `json:"templates,omitempty"` // 事件类型 → text/template模板，覆盖默认模板；模板为空字符串时不通知该事件
+	QueueSize int               `json:"queue_size"`          // 待发送消息数上限（默认256，满时丢弃新消息）
+}
+
+const (
+	defaultQueueSize   = 256
+	sendMaxAttempts    = 4
+	sendMinBackoff     = time.Second
+	sendMaxBackoff     = 30 * time.Second
+	sendTimeout        = 10 * time.Second
+	closeDrainDeadline = 5 * time.Second
+)
+
+// Service 把交易事件格式化为消息，经有界队列由后台goroutine按各通道的速率限制发送
+// 临时性失败（网络错误、429、5xx）按退避重试，发送慢或失败不会阻塞交易事件回调
+type Service struct {
+	channels  []*channel
+	templates *templates
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Message
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// channel 通道及其速率限制
+type channel struct {
+	Sender
+	limiter *rateLimiter
+}
+
+// New 按配置创建通知服务；未配置任何通道时返回nil, nil
+func New(cfg Config) (*Service, error) {
+	tmpl, err := parseTemplates(cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+	var channels []*channel
+	if cfg.Telegram != nil && (cfg.Telegram.BotToken != "" || cfg.Telegram.ChatID != "") {
+		sender, err := NewTelegramSender(*cfg.Telegram)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, &channel{Sender: sender, limiter: newRateLimiter(cfg.Telegram.RatePerSecond, telegramRatePerSecond)})
+	}
+	for i, wh := range cfg.Webhooks {
+		sender, err := NewWebhookSender(wh)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+		channels = append(channels, &channel{Sender: sender, limiter: newRateLimiter(wh.RatePerSecond, webhookRatePerSecond)})
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	return newService(channels, tmpl, cfg.QueueSize), nil
+}
+
+// NewService 使用自定义通道创建通知服务（每个通道的速率为ratePerSecond，<=0时不限速）
+func NewService(ratePerSecond float64, senders ...Sender) *Service {
+	tmpl, _ := parseTemplates(nil)
+	channels := make([]*channel, 0, len(senders))
+	for _, s := range senders {
+		channels = append(channels, &channel{Sender: s, limiter: newRateLimiter(ratePerSecond, 0)})
+	}
+	return newService(channels, tmpl, 0)
+}
+
+func newService(channels []*channel, tmpl *templates, queueSize int) *Service {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		channels:  channels,
+		templates: tmpl,
+		queue:     make(chan Message, queueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+// HandleEvent 交易事件回调：有模板的事件格式化后入队，可直接传给 RegisterHook / trader.AddTradeEventHook
+func (s *Service) HandleEvent(e trader.TradeEvent) {
+	msg, ok, err := s.templates.format(e)
+	if err != nil {
+		log.Printf("⚠️  通知模板执行失败 (%s): %v", e.Type, err)
+		return
+	}
+	if ok {
+		s.Send(msg)
+	}
+}
+
+// Send 消息入队后立即返回；服务已关闭时忽略，队列满时丢弃
+func (s *Service) Send(msg Message) {
+	if msg.Priority == "" {
+		msg.Priority = PriorityNormal
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%100 == 0 {
+			log.Printf("⚠️  通知队列已满，已丢弃 %d 条消息", n)
+		}
+	}
+}
+
+// Dropped 因队列已满丢弃的消息数
+func (s *Service) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close 停止接收新消息，等待队列中的消息发送完毕（最多5秒，超时后放弃剩余消息）
+func (s *Service) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	case <-time.After(closeDrainDeadline):
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+}
+
+func (s *Service) loop() {
+	defer close(s.done)
+	for msg := range s.queue {
+		for _, ch := range s.channels {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.deliver(ch, msg)
+		}
+	}
+}
+
+// deliver 按速率限制发送到单个通道，临时性失败按退避重试
+func (s *Service) deliver(ch *channel, msg Message) {
+	backoff := sendMinBackoff
+	for attempt := 1; ; attempt++ {
+		if err := ch.limiter.wait(s.ctx); err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, sendTimeout)
+		err := ch.Send(ctx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		if !isTemporary(err) || attempt >= sendMaxAttempts || s.ctx.Err() != nil {
+			log.Printf("❌ 发送%s通知失败（第%d次）: %v", ch.Name(), attempt, err)
+			return
+		}
+		delay := backoff
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > delay {
+			delay = httpErr.RetryAfter
+		}
+		log.Printf("⚠️  发送%s通知失败（第%d次），%v后重试: %v", ch.Name(), attempt, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > sendMaxBackoff {
+			backoff = sendMaxBackoff
+		}
+	}
+}
+
+// rateLimiter 按固定间隔放行（只由发送goroutine使用，不需要加锁）
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter 每秒最多放行perSecond次（<=0时使用def，def也<=0时不限速）
+func newRateLimiter(perSecond, def float64) *rateLimiter {
+	if perSecond <= 0 {
+		perSecond = def
+	}
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+	now := time.Now()
+	if wait := l.next.Sub(now); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		now = l.next
+	}
+	l.next = now.Add(l.interval)
+	return nil
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org"
+	telegramRatePerSecond = 30   // Bot API全局上限约30条/秒
+	telegramMaxText       = 4096 // 单条消息最多4096个字符
+)
+
+// TelegramConfig Telegram Bot配置
+type TelegramConfig struct {
+	BotToken      string  `json:"bot_token"`
+	ChatID        string  `json:"chat_id"`         // 用户、群组或频道ID（频道可用 @channelname）
+	APIURL        string  `json:"api_url"`         // 默认 https://api.telegram.org（可换成自建的Bot API服务）
+	RatePerSecond float64 `json:"rate_per_second"` // 默认30
+	SilentNormal  bool    `json:"silent_normal"`   // 普通消息静默发送，告警消息总是带提醒
+}
+
+// TelegramSender 通过Bot API的sendMessage发送通知
+type TelegramSender struct {
+	endpoint     string
+	chatID       string
+	silentNormal bool
+	client       *http.Client
+}
+
+// NewTelegramSender 创建Telegram通道
+func NewTelegramSender(cfg TelegramConfig) (*TelegramSender, error) {
+	if cfg.BotToken == "" || cfg.ChatID == "" {
+		return nil, errors.New("Telegram通知需要bot_token和chat_id")
+	}
+	apiURL := strings.TrimRight(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+	return &TelegramSender{
+		endpoint:     apiURL + "/bot" + cfg.BotToken + "/sendMessage",
+		chatID:       cfg.ChatID,
+		silentNormal: cfg.SilentNormal,
+		client:       &http.Client{},
+	}, nil
+}
+
+func (s *TelegramSender) Name() string { return "Telegram" }
+
+// Send 发送纯文本消息（超过4096个字符时截断）
+func (s *TelegramSender) Send(ctx context.Context, msg Message) error {
+	payload := map[string]any{
+		"chat_id":                  s.chatID,
+		"text":                     truncate(msg.Text, telegramMaxText),
+		"disable_web_page_preview": true,
+		"disable_notification":     s.silentNormal && msg.Priority != PriorityAlert,
+	}
+	_, err := postJSON(ctx, s.client, s.endpoint, nil, payload)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		// 错误响应: {"ok":false,"error_code":429,"description":"...","parameters":{"retry_after":5}}
+		var resp struct {
+			Description string `json:"description"`
+			Parameters  struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"parameters"`
+		}
+		if json.Unmarshal([]byte(httpErr.Body), &resp) == nil {
+			if resp.Description != "" {
+				httpErr.Body = resp.Description
+			}
+			if resp.Parameters.RetryAfter > 0 {
+				httpErr.RetryAfter = time.Duration(resp.Parameters.RetryAfter) * time.Second
+			}
+		}
+	}
+	return err
+}
+
+var _ Sender = (*TelegramSender)(nil)
@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"nofx/trader"
+)
+
+// defaultTemplates 默认消息模板（模板数据为 trader.TradeEvent）
+var defaultTemplates = map[trader.TradeEventType]string{
+	trader.EventPositionOpened: `{{with .Trader}}[{{.}}] {{end}}📈 开仓 {{.Symbol}} {{side .Side}}
+数量: {{num .Size}}
+开仓价: {{num .EntryPrice}}{{if .Leverage}}
+杠杆: {{.Leverage}}x{{end}}`,
+
+	trader.EventPositionClosed: `{{with .Trader}}[{{.}}] {{end}}{{if ge .RealizedPnL 0.0}}✅{{else}}🔻{{end}} 平仓 {{.Symbol}} {{side .Side}}
+数量: {{num .Size}}
+开仓价: {{num .EntryPrice}}
+平仓价: {{num .Price}}
+已实现盈亏: {{signed .RealizedPnL}} USDT`,
+
+	trader.EventOrderRejected: `🚨 {{with .Trader}}[{{.}}] {{end}}下单失败 {{.Symbol}} {{.OrderSide}} {{num .Size}}
+原因: {{.Error}}`,
+
+	trader.EventStopLossFailed: `🚨 {{with .Trader}}[{{.}}] {{end}}止损设置失败 {{.Symbol}} {{side .Side}}
+数量: {{num .Size}}
+触发价: {{num .Price}}
+原因: {{.Error}}
+持仓可能没有止损保护，请尽快检查`,
+
+	trader.EventTakeProfitFailed: `🚨 {{with .Trader}}[{{.}}] {{end}}止盈设置失败 {{.Symbol}} {{side .Side}}
+数量: {{num .Size}}
+触发价: {{num .Price}}
+原因: {{.Error}}`,
+}
+
+// alertEvents 以告警优先级发送的事件
+var alertEvents = map[trader.TradeEventType]bool{
+	trader.EventOrderRejected:    true,
+	trader.EventStopLossFailed:   true,
+	trader.EventTakeProfitFailed: true,
+}
+
+var templateFuncs = template.FuncMap{
+	"num":    formatNumber,
+	"signed": func(v float64) string { return fmt.Sprintf("%+.2f", v) },
+	"side":   sideLabel,
+}
+
+// templates 已解析的事件模板
+type templates struct {
+	byType map[trader.TradeEventType]*template.Template
+}
+
+// parseTemplates 解析默认模板与覆盖模板（覆盖为空字符串时删除该事件的模板）
+func parseTemplates(overrides map[string]string) (*templates, error) {
+	sources := make(map[trader.TradeEventType]string, len(defaultTemplates)+len(overrides))
+	for typ, text := range defaultTemplates {
+		sources[typ] = text
+	}
+	for typ, text := range overrides {
+		if text == "" {
+			delete(sources, trader.TradeEventType(typ))
+			continue
+		}
+		sources[trader.TradeEventType(typ)] = text
+	}
+	t := &templates{byType: make(map[trader.TradeEventType]*template.Template, len(sources))}
+	for typ, text := range sources {
+		tmpl, err := template.New(string(typ)).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("解析通知模板 %s 失败: %w", typ, err)
+		}
+		t.byType[typ] = tmpl
+	}
+	return t, nil
+}
+
+// format 按事件类型的模板生成消息，没有模板时返回false
+func (t *templates) format(e trader.TradeEvent) (Message, bool, error) {
+	tmpl, ok := t.byType[e.Type]
+	if !ok {
+		return Message{}, false, nil
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, e); err != nil {
+		return Message{}, false, err
+	}
+	priority := PriorityNormal
+	if alertEvents[e.Type] {
+		priority = PriorityAlert
+	}
+	return Message{Text: sb.String(), Priority: priority, Event: &e}, true, nil
+}
+
+// formatNumber 去掉多余的0（数量与价格的精度因币种而异）
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func sideLabel(side string) string {
+	switch side {
+	case "long":
+		return "做多"
+	case "short":
+		return "做空"
+	}
+	return side
+}
@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"nofx/trader"
+)
+
+const webhookRatePerSecond = 5
+
+// WebhookConfig 通用Webhook配置
+type WebhookConfig struct {
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers,omitempty"` // 附加请求头（如鉴权）
+	RatePerSecond float64           `json:"rate_per_second"`   // 默认5
+}
+
+// WebhookSender 以JSON POST发送通知
+// 请求体同时带text（Slack）与content（Discord）字段，两者的Incoming Webhook都可以直接使用
+type WebhookSender struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// webhookPayload Webhook请求体
+type webhookPayload struct {
+	Text     string             `json:"text"`
+	Content  string             `json:"content"`
+	Priority Priority           `json:"priority"`
+	Event    *trader.TradeEvent `json:"event,omitempty"`
+	SentAt   time.Time          `json:"sent_at"`
+}
+
+// NewWebhookSender 创建Webhook通道
+func NewWebhookSender(cfg WebhookConfig) (*WebhookSender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("Webhook通知需要url")
+	}
+	return &WebhookSender{url: cfg.URL, headers: cfg.Headers, client: &http.Client{}}, nil
+}
+
+func (s *WebhookSender) Name() string { return "Webhook" }
+
+func (s *WebhookSender) Send(ctx context.Context, msg Message) error {
+	_, err := postJSON(ctx, s.client, s.url, s.headers, webhookPayload{
+		Text:     msg.Text,
+		Content:  truncate(msg.Text, 2000), // Discord单条消息最多2000个字符
+		Priority: msg.Priority,
+		Event:    msg.Event,
+		SentAt:   time.Now().UTC(),
+	})
+	return err
+}
+
+var _ Sender = (*WebhookSender)(nil)
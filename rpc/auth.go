@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorize 校验metadata中的 authorization: Bearer <token>
+func (s *Server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 1 {
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "令牌无效")
+}
+
+// authUnary 一元调用的令牌校验拦截器
+func (s *Server) authUnary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authStream 流式调用的令牌校验拦截器
+func (s *Server) authStream(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// TokenCredentials Go客户端每个请求附带 authorization: Bearer <token>（grpc.WithPerRPCCredentials）
+type TokenCredentials struct {
+	Token string
+	// AllowInsecure 允许在明文连接上发送令牌（服务端未配置TLS时，仅适合本机访问）
+	AllowInsecure bool
+}
+
+// GetRequestMetadata 实现credentials.PerRPCCredentials
+func (c TokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.Token}, nil
+}
+
+// RequireTransportSecurity 实现credentials.PerRPCCredentials
+func (c TokenCredentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
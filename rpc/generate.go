@@ -0,0 +1,3 @@
+package rpc
+
+//go:generate protoc -I . --go_out=traderpb --go_opt=paths=source_relative --go-grpc_out=traderpb --go-grpc_opt=paths=source_relative trader.proto
@@ -0,0 +1,368 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"nofx/rpc/traderpb"
+	"nofx/trader"
+)
+
+// maxMessageSize 单个请求消息的上限
+const maxMessageSize = 4 << 20
+
+// eventBufferSize 每个事件订阅者的缓冲，订阅者读取过慢时丢弃事件
+const eventBufferSize = 256
+
+// Config gRPC服务配置
+type Config struct {
+	Addr     string `json:"addr"`      // 监听地址，如 127.0.0.1:9090
+	TraderID string `json:"trader_id"` // 通过gRPC下单的交易员
+	Token    string `json:"token"`     // 认证令牌（必填，请求头 authorization: Bearer <token>）
+	CertFile string `json:"cert_file"` // TLS证书（为空时使用明文连接，仅适合本机访问）
+	KeyFile  string `json:"key_file"`
+}
+
+// Controls AutoTrader的控制操作（*trader.AutoTrader实现该接口）
+type Controls interface {
+	PauseSymbol(symbol string, manageExisting bool, by string) error
+	ResumeSymbol(symbol string, by string) error
+	Stop()
+	GetStatus() map[string]interface{}
+}
+
+// Server gRPC服务（实现trader.proto生成的TraderServiceServer）：所有请求都委托给注入的Trader
+// （应使用AutoTrader.Trader()，与机器人共用调用监控、审计与下单上限），控制类请求委托给Controls
+type Server struct {
+	traderpb.UnimplementedTraderServiceServer
+
+	cfg        Config
+	trader     trader.Trader
+	controls   Controls
+	grpcServer *grpc.Server
+
+	mu          sync.Mutex
+	subscribers map[chan *traderpb.TradeEvent]struct{}
+	closed      bool
+}
+
+// NewServer 创建gRPC服务（controls为nil时控制类请求返回Unimplemented）
+func NewServer(t trader.Trader, controls Controls, cfg Config) (*Server, error) {
+	if t == nil {
+		return nil, fmt.Errorf("gRPC服务需要Trader")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("gRPC服务必须配置token")
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("cert_file与key_file需同时配置")
+	}
+	s := &Server{
+		cfg:         cfg,
+		trader:      t,
+		controls:    controls,
+		subscribers: make(map[chan *traderpb.TradeEvent]struct{}),
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(s.authUnary, statusUnary),
+		grpc.ChainStreamInterceptor(s.authStream),
+	}
+	if cfg.CertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载TLS证书失败: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s.grpcServer = grpc.NewServer(opts...)
+	traderpb.RegisterTraderServiceServer(s.grpcServer, s)
+	return s, nil
+}
+
+// ListenAndServe 在cfg.Addr上启动服务（阻塞，Close后返回nil）
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Serve 在lis上提供服务（阻塞，Close后返回nil）
+func (s *Server) Serve(lis net.Listener) error {
+	if s.cfg.CertFile == "" {
+		log.Printf("⚠️  gRPC服务未配置TLS，令牌以明文传输，请只在本机或内网使用")
+	}
+	err := s.grpcServer.Serve(lis)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Close 关闭服务并结束所有事件订阅
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	s.mu.Unlock()
+
+	s.grpcServer.Stop()
+	return nil
+}
+
+// HandleTradeEvent 实现trader.TradeEventSink，转发给所有事件订阅者
+func (s *Server) HandleTradeEvent(entry trader.AuditEntry) {
+	event := &traderpb.TradeEvent{
+		TimestampMs:   entry.Timestamp.UnixMilli(),
+		TraderId:      entry.TraderID,
+		Operation:     entry.Operation,
+		ResponseCode:  entry.ResponseCode,
+		Error:         entry.Error,
+		ErrorCode:     string(entry.ErrorCode),
+		CorrelationId: entry.CorrelationID,
+		DurationMs:    entry.DurationMs,
+	}
+	if len(entry.Params) > 0 {
+		event.ParamsJson = toJSON(entry.Params)
+	}
+	if len(entry.ExchangeIDs) > 0 {
+		event.ExchangeIdsJson = toJSON(entry.ExchangeIDs)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("⚠️  gRPC事件订阅者读取过慢，丢弃事件 %s", entry.Operation)
+		}
+	}
+}
+
+// StreamTradeEvents 推送交易事件，直到客户端断开或服务关闭
+func (s *Server) StreamTradeEvents(_ *traderpb.Empty, stream grpc.ServerStreamingServer[traderpb.TradeEvent]) error {
+	ch := make(chan *traderpb.TradeEvent, eventBufferSize)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return status.Error(codes.Unavailable, "服务已关闭")
+	}
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return status.Error(codes.Unavailable, "服务已关闭")
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *Server) GetBalance(context.Context, *traderpb.Empty) (*traderpb.JSONReply, error) {
+	balance, err := s.trader.GetBalance()
+	if err != nil {
+		return nil, err
+	}
+	return &traderpb.JSONReply{Json: toJSON(balance)}, nil
+}
+
+func (s *Server) GetPositions(context.Context, *traderpb.Empty) (*traderpb.PositionsReply, error) {
+	positions, err := s.trader.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	reply := &traderpb.PositionsReply{PositionsJson: make([]string, 0, len(positions))}
+	for _, p := range positions {
+		reply.PositionsJson = append(reply.PositionsJson, toJSON(p))
+	}
+	return reply, nil
+}
+
+func (s *Server) GetMarketPrice(_ context.Context, req *traderpb.SymbolRequest) (*traderpb.PriceReply, error) {
+	price, err := s.trader.GetMarketPrice(req.GetSymbol())
+	if err != nil {
+		return nil, err
+	}
+	return &traderpb.PriceReply{Price: price}, nil
+}
+
+func (s *Server) OpenPosition(_ context.Context, req *traderpb.OrderRequest) (*traderpb.JSONReply, error) {
+	side, err := trader.ParsePositionSide(req.GetSide())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	var result map[string]interface{}
+	if side == trader.PositionLong {
+		result, err = s.trader.OpenLong(req.GetSymbol(), req.GetQuantity(), int(req.GetLeverage()))
+	} else {
+		result, err = s.trader.OpenShort(req.GetSymbol(), req.GetQuantity(), int(req.GetLeverage()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &traderpb.JSONReply{Json: toJSON(result)}, nil
+}
+
+func (s *Server) ClosePosition(_ context.Context, req *traderpb.OrderRequest) (*traderpb.JSONReply, error) {
+	side, err := trader.ParsePositionSide(req.GetSide())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	var result map[string]interface{}
+	if side == trader.PositionLong {
+		result, err = s.trader.CloseLong(req.GetSymbol(), req.GetQuantity())
+	} else {
+		result, err = s.trader.CloseShort(req.GetSymbol(), req.GetQuantity())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &traderpb.JSONReply{Json: toJSON(result)}, nil
+}
+
+func (s *Server) SetStopLoss(_ context.Context, req *traderpb.ProtectiveRequest) (*traderpb.Empty, error) {
+	side, err := trader.ParsePositionSide(req.GetSide())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	if err := s.trader.SetStopLoss(req.GetSymbol(), side, req.GetQuantity(), req.GetPrice()); err != nil {
+		return nil, err
+	}
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) SetTakeProfit(_ context.Context, req *traderpb.ProtectiveRequest) (*traderpb.Empty, error) {
+	side, err := trader.ParsePositionSide(req.GetSide())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	if err := s.trader.SetTakeProfit(req.GetSymbol(), side, req.GetQuantity(), req.GetPrice()); err != nil {
+		return nil, err
+	}
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) CancelAllOrders(_ context.Context, req *traderpb.SymbolRequest) (*traderpb.Empty, error) {
+	if err := s.trader.CancelAllOrders(req.GetSymbol()); err != nil {
+		return nil, err
+	}
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) PauseSymbol(_ context.Context, req *traderpb.PauseRequest) (*traderpb.Empty, error) {
+	if s.controls == nil {
+		return nil, errNoControls
+	}
+	if err := s.controls.PauseSymbol(req.GetSymbol(), req.GetManageExisting(), grpcOperator(req.GetBy())); err != nil {
+		return nil, err
+	}
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) ResumeSymbol(_ context.Context, req *traderpb.PauseRequest) (*traderpb.Empty, error) {
+	if s.controls == nil {
+		return nil, errNoControls
+	}
+	if err := s.controls.ResumeSymbol(req.GetSymbol(), grpcOperator(req.GetBy())); err != nil {
+		return nil, err
+	}
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) Halt(context.Context, *traderpb.Empty) (*traderpb.Empty, error) {
+	if s.controls == nil {
+		return nil, errNoControls
+	}
+	log.Printf("⏹ 收到gRPC停止请求")
+	s.controls.Stop()
+	return &traderpb.Empty{}, nil
+}
+
+func (s *Server) GetStatus(context.Context, *traderpb.Empty) (*traderpb.JSONReply, error) {
+	if s.controls == nil {
+		return nil, errNoControls
+	}
+	return &traderpb.JSONReply{Json: toJSON(s.controls.GetStatus())}, nil
+}
+
+// grpcOperator 暂停/恢复的操作人（未填写时记为grpc）
+func grpcOperator(by string) string {
+	if by == "" {
+		return "grpc"
+	}
+	return by
+}
+
+var errNoControls = status.Error(codes.Unimplemented, "未配置AutoTrader控制")
+
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// statusUnary 交易器返回的错误转换为gRPC状态码
+func statusUnary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+	return resp, nil
+}
+
+// statusFromError 错误转换为gRPC状态；交易器的错误码附在信息前，便于客户端按错误码处理
+func statusFromError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Internal
+	if errors.Is(err, trader.ErrPositionNotFound) {
+		code = codes.NotFound
+	}
+	if ec := trader.ErrorCodeOf(err); ec != "" {
+		return status.Errorf(code, "[%s] %v", ec, err)
+	}
+	return status.Error(code, err.Error())
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,307 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"nofx/rpc/traderpb"
+	"nofx/trader"
+)
+
+// newTestPaper 只有BTCUSDT报价的模拟盘
+func newTestPaper(t *testing.T) *trader.PaperTrader {
+	t.Helper()
+	paper, err := trader.NewPaperTrader(trader.NewFixedPriceSource(map[string]float64{"BTCUSDT": 50000}), trader.PaperConfig{InitialBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paper
+}
+
+// startTestServer 在本机随机端口启动服务，返回监听地址
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(lis) }()
+	t.Cleanup(func() {
+		s.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return lis.Addr().String()
+}
+
+// dialTestClient 生成的客户端存根，每个请求附带token（creds为nil时使用明文连接）
+func dialTestClient(t *testing.T, addr, token string, creds credentials.TransportCredentials) traderpb.TraderServiceClient {
+	t.Helper()
+	perRPC := TokenCredentials{Token: token, AllowInsecure: creds == nil}
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds), grpc.WithPerRPCCredentials(perRPC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return traderpb.NewTraderServiceClient(conn)
+}
+
+// newTestService 基于模拟盘的明文gRPC服务与已连接的客户端
+func newTestService(t *testing.T, controls Controls) (*Server, traderpb.TraderServiceClient, *trader.PaperTrader) {
+	t.Helper()
+	paper := newTestPaper(t)
+	s, err := NewServer(paper, controls, Config{Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTestServer(t, s)
+	return s, dialTestClient(t, addr, "secret", nil), paper
+}
+
+func decodeJSON(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &obj); err != nil {
+		t.Fatalf("无效的JSON %q: %v", data, err)
+	}
+	return obj
+}
+
+// TestServiceEndToEnd 生成的客户端经gRPC调用服务，完整走一遍开仓、止损、平仓
+func TestServiceEndToEnd(t *testing.T) {
+	_, c, paper := newTestService(t, nil)
+	ctx := t.Context()
+
+	balance, err := c.GetBalance(ctx, &traderpb.Empty{})
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if got := decodeJSON(t, balance.GetJson())["totalWalletBalance"]; got != 10000.0 {
+		t.Errorf("totalWalletBalance = %v, want 10000", got)
+	}
+
+	price, err := c.GetMarketPrice(ctx, &traderpb.SymbolRequest{Symbol: "BTCUSDT"})
+	if err != nil || price.GetPrice() != 50000 {
+		t.Fatalf("GetMarketPrice = %v, %v, want 50000", price, err)
+	}
+
+	if _, err := c.OpenPosition(ctx, &traderpb.OrderRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 0.1, Leverage: 5}); err != nil {
+		t.Fatalf("OpenPosition: %v", err)
+	}
+	positions, err := c.GetPositions(ctx, &traderpb.Empty{})
+	if err != nil {
+		t.Fatalf("GetPositions: %v", err)
+	}
+	if len(positions.GetPositionsJson()) != 1 {
+		t.Fatalf("GetPositions = %v, want 1个持仓", positions)
+	}
+	if pos := decodeJSON(t, positions.GetPositionsJson()[0]); pos["symbol"] != "BTCUSDT" || pos["side"] != "long" || pos["positionAmt"] != 0.1 {
+		t.Fatalf("持仓 = %v, want BTCUSDT long 0.1", pos)
+	}
+
+	if _, err := c.SetStopLoss(ctx, &traderpb.ProtectiveRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 0.1, Price: 48000}); err != nil {
+		t.Fatalf("SetStopLoss: %v", err)
+	}
+	if snap := paper.Snapshot(); len(snap.Positions) != 1 || snap.Positions[0].StopLoss != 48000 {
+		t.Errorf("止损未传到交易器: %+v", snap.Positions)
+	}
+
+	if _, err := c.ClosePosition(ctx, &traderpb.OrderRequest{Symbol: "BTCUSDT", Side: "long"}); err != nil {
+		t.Fatalf("ClosePosition: %v", err)
+	}
+	if positions, err := c.GetPositions(ctx, &traderpb.Empty{}); err != nil || len(positions.GetPositionsJson()) != 0 {
+		t.Errorf("平仓后 GetPositions = %v, %v, want 空", positions, err)
+	}
+
+	// 交易器错误映射为gRPC状态码
+	_, err = c.SetStopLoss(ctx, &traderpb.ProtectiveRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 0.1, Price: 48000})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("无持仓时 SetStopLoss = %v, want NotFound", err)
+	}
+}
+
+// TestServiceErrors 认证失败、参数错误与未配置控制分别返回对应状态码
+func TestServiceErrors(t *testing.T) {
+	s, err := NewServer(newTestPaper(t), nil, Config{Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTestServer(t, s)
+	c := dialTestClient(t, addr, "secret", nil)
+	ctx := t.Context()
+
+	for _, token := range []string{"wrong", ""} {
+		wrong := dialTestClient(t, addr, token, nil)
+		if _, err := wrong.GetBalance(ctx, &traderpb.Empty{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("令牌 %q: GetBalance = %v, want Unauthenticated", token, err)
+		}
+		stream, err := wrong.StreamTradeEvents(ctx, &traderpb.Empty{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("令牌 %q: StreamTradeEvents = %v, want Unauthenticated", token, err)
+		}
+	}
+	if _, err := c.OpenPosition(ctx, &traderpb.OrderRequest{Symbol: "BTCUSDT", Side: "sideways", Quantity: 0.1, Leverage: 5}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("无效方向 = %v, want InvalidArgument", err)
+	}
+	if _, err := c.Halt(ctx, &traderpb.Empty{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("未配置控制时 Halt = %v, want Unimplemented", err)
+	}
+	if _, err := c.GetStatus(ctx, &traderpb.Empty{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("未配置控制时 GetStatus = %v, want Unimplemented", err)
+	}
+}
+
+// writeTestCert 为127.0.0.1签发的自签名证书，返回证书与私钥文件路径
+func writeTestCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nofx-test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// TestServiceTLS 配置证书后只接受TLS连接，令牌随请求加密传输
+func TestServiceTLS(t *testing.T) {
+	certFile, keyFile, pool := writeTestCert(t)
+	s, err := NewServer(newTestPaper(t), nil, Config{Token: "secret", CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTestServer(t, s)
+	ctx := t.Context()
+
+	c := dialTestClient(t, addr, "secret", credentials.NewTLS(&tls.Config{RootCAs: pool}))
+	if _, err := c.GetBalance(ctx, &traderpb.Empty{}); err != nil {
+		t.Fatalf("TLS GetBalance: %v", err)
+	}
+
+	plain := dialTestClient(t, addr, "secret", nil)
+	if _, err := plain.GetBalance(ctx, &traderpb.Empty{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("明文连接TLS服务 = %v, want Unavailable", err)
+	}
+
+	if _, err := NewServer(newTestPaper(t), nil, Config{Token: "secret", CertFile: certFile, KeyFile: certFile}); err == nil {
+		t.Error("私钥无效时 NewServer 应返回错误")
+	}
+}
+
+// TestServiceStreamTradeEvents 订阅者收到审计事件，取消ctx后订阅被移除，服务关闭时流以Unavailable结束
+func TestServiceStreamTradeEvents(t *testing.T) {
+	s, c, _ := newTestService(t, nil)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	stream, err := c.StreamTradeEvents(ctx, &traderpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, 1)
+	s.HandleTradeEvent(trader.AuditEntry{
+		Timestamp:   time.UnixMilli(1700000000000),
+		TraderID:    "t1",
+		Operation:   "open_long",
+		Params:      map[string]interface{}{"symbol": "BTCUSDT"},
+		ExchangeIDs: map[string]interface{}{"orderId": "1"},
+		DurationMs:  12,
+	})
+	e, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if e.GetOperation() != "open_long" || e.GetTraderId() != "t1" || e.GetTimestampMs() != 1700000000000 || e.GetDurationMs() != 12 ||
+		e.GetParamsJson() != `{"symbol":"BTCUSDT"}` || e.GetExchangeIdsJson() != `{"orderId":"1"}` {
+		t.Errorf("收到事件 %+v", e)
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Errorf("取消后 Recv = %v, want Canceled", err)
+	}
+	waitSubscribers(t, s, 0)
+
+	stream, err = c.StreamTradeEvents(t.Context(), &traderpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, 1)
+	s.Close()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("服务关闭后 Recv = %v, want Unavailable", err)
+	}
+}
+
+func waitSubscribers(t *testing.T, s *Server, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.subscribers)
+		s.mu.Unlock()
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("订阅者数量 = %d, want %d", n, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,699 @@
+// nofx 交易gRPC服务
+//
+// Go代码由 protoc-gen-go 与 protoc-gen-go-grpc 生成到 rpc/traderpb（见 rpc/generate.go），
+// 修改本文件后在 rpc 目录执行 go generate 重新生成。
+// 其他语言的客户端可直接用本文件生成，例如 Python:
+//   python -m grpc_tools.protoc -I rpc --python_out=. --grpc_python_out=. rpc/trader.proto
+//
+// 认证：每个请求携带 metadata "authorization: Bearer <token>"
+// 返回的账户/持仓/下单结果与Go端Trader接口一致，以JSON对象字符串传递
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: trader.proto
+
+package traderpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_trader_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{0}
+}
+
+type SymbolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SymbolRequest) Reset() {
+	*x = SymbolRequest{}
+	mi := &file_trader_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SymbolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SymbolRequest) ProtoMessage() {}
+
+func (x *SymbolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SymbolRequest.ProtoReflect.Descriptor instead.
+func (*SymbolRequest) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SymbolRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type OrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side          string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"` // long/short
+	Quantity      float64                `protobuf:"fixed64,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Leverage      int32                  `protobuf:"varint,4,opt,name=leverage,proto3" json:"leverage,omitempty"` // 仅开仓使用
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderRequest) Reset() {
+	*x = OrderRequest{}
+	mi := &file_trader_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderRequest) ProtoMessage() {}
+
+func (x *OrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderRequest.ProtoReflect.Descriptor instead.
+func (*OrderRequest) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderRequest) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *OrderRequest) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *OrderRequest) GetLeverage() int32 {
+	if x != nil {
+		return x.Leverage
+	}
+	return 0
+}
+
+type ProtectiveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side          string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"` // 持仓方向 long/short
+	Quantity      float64                `protobuf:"fixed64,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"` // 触发价
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtectiveRequest) Reset() {
+	*x = ProtectiveRequest{}
+	mi := &file_trader_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtectiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtectiveRequest) ProtoMessage() {}
+
+func (x *ProtectiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtectiveRequest.ProtoReflect.Descriptor instead.
+func (*ProtectiveRequest) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProtectiveRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ProtectiveRequest) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *ProtectiveRequest) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *ProtectiveRequest) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type PauseRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Symbol         string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	ManageExisting bool                   `protobuf:"varint,2,opt,name=manage_existing,json=manageExisting,proto3" json:"manage_existing,omitempty"` // 暂停时是否继续管理已有持仓
+	By             string                 `protobuf:"bytes,3,opt,name=by,proto3" json:"by,omitempty"`                                                // 操作人（写入日志）
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PauseRequest) Reset() {
+	*x = PauseRequest{}
+	mi := &file_trader_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseRequest) ProtoMessage() {}
+
+func (x *PauseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseRequest.ProtoReflect.Descriptor instead.
+func (*PauseRequest) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PauseRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *PauseRequest) GetManageExisting() bool {
+	if x != nil {
+		return x.ManageExisting
+	}
+	return false
+}
+
+func (x *PauseRequest) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+type PriceReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         float64                `protobuf:"fixed64,1,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceReply) Reset() {
+	*x = PriceReply{}
+	mi := &file_trader_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceReply) ProtoMessage() {}
+
+func (x *PriceReply) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceReply.ProtoReflect.Descriptor instead.
+func (*PriceReply) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PriceReply) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type JSONReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Json          string                 `protobuf:"bytes,1,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JSONReply) Reset() {
+	*x = JSONReply{}
+	mi := &file_trader_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JSONReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JSONReply) ProtoMessage() {}
+
+func (x *JSONReply) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JSONReply.ProtoReflect.Descriptor instead.
+func (*JSONReply) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *JSONReply) GetJson() string {
+	if x != nil {
+		return x.Json
+	}
+	return ""
+}
+
+type PositionsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PositionsJson []string               `protobuf:"bytes,1,rep,name=positions_json,json=positionsJson,proto3" json:"positions_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PositionsReply) Reset() {
+	*x = PositionsReply{}
+	mi := &file_trader_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PositionsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PositionsReply) ProtoMessage() {}
+
+func (x *PositionsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PositionsReply.ProtoReflect.Descriptor instead.
+func (*PositionsReply) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PositionsReply) GetPositionsJson() []string {
+	if x != nil {
+		return x.PositionsJson
+	}
+	return nil
+}
+
+type TradeEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TimestampMs     int64                  `protobuf:"varint,1,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	TraderId        string                 `protobuf:"bytes,2,opt,name=trader_id,json=traderId,proto3" json:"trader_id,omitempty"`
+	Operation       string                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	ResponseCode    string                 `protobuf:"bytes,4,opt,name=response_code,json=responseCode,proto3" json:"response_code,omitempty"`
+	Error           string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorCode       string                 `protobuf:"bytes,6,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	CorrelationId   string                 `protobuf:"bytes,7,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	DurationMs      int64                  `protobuf:"varint,8,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	ParamsJson      string                 `protobuf:"bytes,9,opt,name=params_json,json=paramsJson,proto3" json:"params_json,omitempty"`
+	ExchangeIdsJson string                 `protobuf:"bytes,10,opt,name=exchange_ids_json,json=exchangeIdsJson,proto3" json:"exchange_ids_json,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TradeEvent) Reset() {
+	*x = TradeEvent{}
+	mi := &file_trader_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TradeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TradeEvent) ProtoMessage() {}
+
+func (x *TradeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_trader_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TradeEvent.ProtoReflect.Descriptor instead.
+func (*TradeEvent) Descriptor() ([]byte, []int) {
+	return file_trader_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TradeEvent) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *TradeEvent) GetTraderId() string {
+	if x != nil {
+		return x.TraderId
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetResponseCode() string {
+	if x != nil {
+		return x.ResponseCode
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *TradeEvent) GetParamsJson() string {
+	if x != nil {
+		return x.ParamsJson
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetExchangeIdsJson() string {
+	if x != nil {
+		return x.ExchangeIdsJson
+	}
+	return ""
+}
+
+var File_trader_proto protoreflect.FileDescriptor
+
+const file_trader_proto_rawDesc = "" +
+	"\n" +
+	"\ftrader.proto\x12\vnofx.rpc.v1\"\a\n" +
+	"\x05Empty\"'\n" +
+	"\rSymbolRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"r\n" +
+	"\fOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\tR\x04side\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x01R\bquantity\x12\x1a\n" +
+	"\bleverage\x18\x04 \x01(\x05R\bleverage\"q\n" +
+	"\x11ProtectiveRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\tR\x04side\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x01R\bquantity\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\"_\n" +
+	"\fPauseRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12'\n" +
+	"\x0fmanage_existing\x18\x02 \x01(\bR\x0emanageExisting\x12\x0e\n" +
+	"\x02by\x18\x03 \x01(\tR\x02by\"\"\n" +
+	"\n" +
+	"PriceReply\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\x01R\x05price\"\x1f\n" +
+	"\tJSONReply\x12\x12\n" +
+	"\x04json\x18\x01 \x01(\tR\x04json\"7\n" +
+	"\x0ePositionsReply\x12%\n" +
+	"\x0epositions_json\x18\x01 \x03(\tR\rpositionsJson\"\xd9\x02\n" +
+	"\n" +
+	"TradeEvent\x12!\n" +
+	"\ftimestamp_ms\x18\x01 \x01(\x03R\vtimestampMs\x12\x1b\n" +
+	"\ttrader_id\x18\x02 \x01(\tR\btraderId\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\tR\toperation\x12#\n" +
+	"\rresponse_code\x18\x04 \x01(\tR\fresponseCode\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x06 \x01(\tR\terrorCode\x12%\n" +
+	"\x0ecorrelation_id\x18\a \x01(\tR\rcorrelationId\x12\x1f\n" +
+	"\vduration_ms\x18\b \x01(\x03R\n" +
+	"durationMs\x12\x1f\n" +
+	"\vparams_json\x18\t \x01(\tR\n" +
+	"paramsJson\x12*\n" +
+	"\x11exchange_ids_json\x18\n" +
+	" \x01(\tR\x0fexchangeIdsJson2\xcd\x06\n" +
+	"\rTraderService\x128\n" +
+	"\n" +
+	"GetBalance\x12\x12.nofx.rpc.v1.Empty\x1a\x16.nofx.rpc.v1.JSONReply\x12?\n" +
+	"\fGetPositions\x12\x12.nofx.rpc.v1.Empty\x1a\x1b.nofx.rpc.v1.PositionsReply\x12E\n" +
+	"\x0eGetMarketPrice\x12\x1a.nofx.rpc.v1.SymbolRequest\x1a\x17.nofx.rpc.v1.PriceReply\x12A\n" +
+	"\fOpenPosition\x12\x19.nofx.rpc.v1.OrderRequest\x1a\x16.nofx.rpc.v1.JSONReply\x12B\n" +
+	"\rClosePosition\x12\x19.nofx.rpc.v1.OrderRequest\x1a\x16.nofx.rpc.v1.JSONReply\x12A\n" +
+	"\vSetStopLoss\x12\x1e.nofx.rpc.v1.ProtectiveRequest\x1a\x12.nofx.rpc.v1.Empty\x12C\n" +
+	"\rSetTakeProfit\x12\x1e.nofx.rpc.v1.ProtectiveRequest\x1a\x12.nofx.rpc.v1.Empty\x12A\n" +
+	"\x0fCancelAllOrders\x12\x1a.nofx.rpc.v1.SymbolRequest\x1a\x12.nofx.rpc.v1.Empty\x12<\n" +
+	"\vPauseSymbol\x12\x19.nofx.rpc.v1.PauseRequest\x1a\x12.nofx.rpc.v1.Empty\x12=\n" +
+	"\fResumeSymbol\x12\x19.nofx.rpc.v1.PauseRequest\x1a\x12.nofx.rpc.v1.Empty\x12.\n" +
+	"\x04Halt\x12\x12.nofx.rpc.v1.Empty\x1a\x12.nofx.rpc.v1.Empty\x127\n" +
+	"\tGetStatus\x12\x12.nofx.rpc.v1.Empty\x1a\x16.nofx.rpc.v1.JSONReply\x12B\n" +
+	"\x11StreamTradeEvents\x12\x12.nofx.rpc.v1.Empty\x1a\x17.nofx.rpc.v1.TradeEvent0\x01B\x13Z\x11nofx/rpc/traderpbb\x06proto3"
+
+var (
+	file_trader_proto_rawDescOnce sync.Once
+	file_trader_proto_rawDescData []byte
+)
+
+func file_trader_proto_rawDescGZIP() []byte {
+	file_trader_proto_rawDescOnce.Do(func() {
+		file_trader_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_trader_proto_rawDesc), len(file_trader_proto_rawDesc)))
+	})
+	return file_trader_proto_rawDescData
+}
+
+var file_trader_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_trader_proto_goTypes = []any{
+	(*Empty)(nil),             // 0: nofx.rpc.v1.Empty
+	(*SymbolRequest)(nil),     // 1: nofx.rpc.v1.SymbolRequest
+	(*OrderRequest)(nil),      // 2: nofx.rpc.v1.OrderRequest
+	(*ProtectiveRequest)(nil), // 3: nofx.rpc.v1.ProtectiveRequest
+	(*PauseRequest)(nil),      // 4: nofx.rpc.v1.PauseRequest
+	(*PriceReply)(nil),        // 5: nofx.rpc.v1.PriceReply
+	(*JSONReply)(nil),         // 6: nofx.rpc.v1.JSONReply
+	(*PositionsReply)(nil),    // 7: nofx.rpc.v1.PositionsReply
+	(*TradeEvent)(nil),        // 8: nofx.rpc.v1.TradeEvent
+}
+var file_trader_proto_depIdxs = []int32{
+	0,  // 0: nofx.rpc.v1.TraderService.GetBalance:input_type -> nofx.rpc.v1.Empty
+	0,  // 1: nofx.rpc.v1.TraderService.GetPositions:input_type -> nofx.rpc.v1.Empty
+	1,  // 2: nofx.rpc.v1.TraderService.GetMarketPrice:input_type -> nofx.rpc.v1.SymbolRequest
+	2,  // 3: nofx.rpc.v1.TraderService.OpenPosition:input_type -> nofx.rpc.v1.OrderRequest
+	2,  // 4: nofx.rpc.v1.TraderService.ClosePosition:input_type -> nofx.rpc.v1.OrderRequest
+	3,  // 5: nofx.rpc.v1.TraderService.SetStopLoss:input_type -> nofx.rpc.v1.ProtectiveRequest
+	3,  // 6: nofx.rpc.v1.TraderService.SetTakeProfit:input_type -> nofx.rpc.v1.ProtectiveRequest
+	1,  // 7: nofx.rpc.v1.TraderService.CancelAllOrders:input_type -> nofx.rpc.v1.SymbolRequest
+	4,  // 8: nofx.rpc.v1.TraderService.PauseSymbol:input_type -> nofx.rpc.v1.PauseRequest
+	4,  // 9: nofx.rpc.v1.TraderService.ResumeSymbol:input_type -> nofx.rpc.v1.PauseRequest
+	0,  // 10: nofx.rpc.v1.TraderService.Halt:input_type -> nofx.rpc.v1.Empty
+	0,  // 11: nofx.rpc.v1.TraderService.GetStatus:input_type -> nofx.rpc.v1.Empty
+	0,  // 12: nofx.rpc.v1.TraderService.StreamTradeEvents:input_type -> nofx.rpc.v1.Empty
+	6,  // 13: nofx.rpc.v1.TraderService.GetBalance:output_type -> nofx.rpc.v1.JSONReply
+	7,  // 14: nofx.rpc.v1.TraderService.GetPositions:output_type -> nofx.rpc.v1.PositionsReply
+	5,  // 15: nofx.rpc.v1.TraderService.GetMarketPrice:output_type -> nofx.rpc.v1.PriceReply
+	6,  // 16: nofx.rpc.v1.TraderService.OpenPosition:output_type -> nofx.rpc.v1.JSONReply
+	6,  // 17: nofx.rpc.v1.TraderService.ClosePosition:output_type -> nofx.rpc.v1.JSONReply
+	0,  // 18: nofx.rpc.v1.TraderService.SetStopLoss:output_type -> nofx.rpc.v1.Empty
+	0,  // 19: nofx.rpc.v1.TraderService.SetTakeProfit:output_type -> nofx.rpc.v1.Empty
+	0,  // 20: nofx.rpc.v1.TraderService.CancelAllOrders:output_type -> nofx.rpc.v1.Empty
+	0,  // 21: nofx.rpc.v1.TraderService.PauseSymbol:output_type -> nofx.rpc.v1.Empty
+	0,  // 22: nofx.rpc.v1.TraderService.ResumeSymbol:output_type -> nofx.rpc.v1.Empty
+	0,  // 23: nofx.rpc.v1.TraderService.Halt:output_type -> nofx.rpc.v1.Empty
+	6,  // 24: nofx.rpc.v1.TraderService.GetStatus:output_type -> nofx.rpc.v1.JSONReply
+	8,  // 25: nofx.rpc.v1.TraderService.StreamTradeEvents:output_type -> nofx.rpc.v1.TradeEvent
+	13, // [13:26] is the sub-list for method output_type
+	0,  // [0:13] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_trader_proto_init() }
+func file_trader_proto_init() {
+	if File_trader_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trader_proto_rawDesc), len(file_trader_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_trader_proto_goTypes,
+		DependencyIndexes: file_trader_proto_depIdxs,
+		MessageInfos:      file_trader_proto_msgTypes,
+	}.Build()
+	File_trader_proto = out.File
+	file_trader_proto_goTypes = nil
+	file_trader_proto_depIdxs = nil
+}
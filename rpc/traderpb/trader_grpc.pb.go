@@ -0,0 +1,601 @@
+// nofx 交易gRPC服务
+//
+// Go代码由 protoc-gen-go 与 protoc-gen-go-grpc 生成到 rpc/traderpb（见 rpc/generate.go），
+// 修改本文件后在 rpc 目录执行 go generate 重新生成。
+// 其他语言的客户端可直接用本文件生成，例如 Python:
+//   python -m grpc_tools.protoc -I rpc --python_out=. --grpc_python_out=. rpc/trader.proto
+//
+// 认证：每个请求携带 metadata "authorization: Bearer <token>"
+// 返回的账户/持仓/下单结果与Go端Trader接口一致，以JSON对象字符串传递
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: trader.proto
+
+package traderpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TraderService_GetBalance_FullMethodName        = "/nofx.rpc.v1.TraderService/GetBalance"
+	TraderService_GetPositions_FullMethodName      = "/nofx.rpc.v1.TraderService/GetPositions"
+	TraderService_GetMarketPrice_FullMethodName    = "/nofx.rpc.v1.TraderService/GetMarketPrice"
+	TraderService_OpenPosition_FullMethodName      = "/nofx.rpc.v1.TraderService/OpenPosition"
+	TraderService_ClosePosition_FullMethodName     = "/nofx.rpc.v1.TraderService/ClosePosition"
+	TraderService_SetStopLoss_FullMethodName       = "/nofx.rpc.v1.TraderService/SetStopLoss"
+	TraderService_SetTakeProfit_FullMethodName     = "/nofx.rpc.v1.TraderService/SetTakeProfit"
+	TraderService_CancelAllOrders_FullMethodName   = "/nofx.rpc.v1.TraderService/CancelAllOrders"
+	TraderService_PauseSymbol_FullMethodName       = "/nofx.rpc.v1.TraderService/PauseSymbol"
+	TraderService_ResumeSymbol_FullMethodName      = "/nofx.rpc.v1.TraderService/ResumeSymbol"
+	TraderService_Halt_FullMethodName              = "/nofx.rpc.v1.TraderService/Halt"
+	TraderService_GetStatus_FullMethodName         = "/nofx.rpc.v1.TraderService/GetStatus"
+	TraderService_StreamTradeEvents_FullMethodName = "/nofx.rpc.v1.TraderService/StreamTradeEvents"
+)
+
+// TraderServiceClient is the client API for TraderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TraderServiceClient interface {
+	// 账户与行情
+	GetBalance(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*JSONReply, error)
+	GetPositions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PositionsReply, error)
+	GetMarketPrice(ctx context.Context, in *SymbolRequest, opts ...grpc.CallOption) (*PriceReply, error)
+	// 开平仓（side: long/short；ClosePosition的quantity=0表示全部平仓）
+	OpenPosition(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*JSONReply, error)
+	ClosePosition(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*JSONReply, error)
+	// 止损止盈与撤单
+	SetStopLoss(ctx context.Context, in *ProtectiveRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetTakeProfit(ctx context.Context, in *ProtectiveRequest, opts ...grpc.CallOption) (*Empty, error)
+	CancelAllOrders(ctx context.Context, in *SymbolRequest, opts ...grpc.CallOption) (*Empty, error)
+	// AutoTrader控制
+	PauseSymbol(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Empty, error)
+	ResumeSymbol(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Empty, error)
+	Halt(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*JSONReply, error)
+	// 交易事件（下单、平仓、撤单等变更类请求的结果）
+	StreamTradeEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TradeEvent], error)
+}
+
+type traderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTraderServiceClient(cc grpc.ClientConnInterface) TraderServiceClient {
+	return &traderServiceClient{cc}
+}
+
+func (c *traderServiceClient) GetBalance(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*JSONReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JSONReply)
+	err := c.cc.Invoke(ctx, TraderService_GetBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) GetPositions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PositionsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PositionsReply)
+	err := c.cc.Invoke(ctx, TraderService_GetPositions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) GetMarketPrice(ctx context.Context, in *SymbolRequest, opts ...grpc.CallOption) (*PriceReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceReply)
+	err := c.cc.Invoke(ctx, TraderService_GetMarketPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) OpenPosition(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*JSONReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JSONReply)
+	err := c.cc.Invoke(ctx, TraderService_OpenPosition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) ClosePosition(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*JSONReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JSONReply)
+	err := c.cc.Invoke(ctx, TraderService_ClosePosition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) SetStopLoss(ctx context.Context, in *ProtectiveRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_SetStopLoss_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) SetTakeProfit(ctx context.Context, in *ProtectiveRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_SetTakeProfit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) CancelAllOrders(ctx context.Context, in *SymbolRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_CancelAllOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) PauseSymbol(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_PauseSymbol_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) ResumeSymbol(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_ResumeSymbol_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) Halt(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, TraderService_Halt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*JSONReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JSONReply)
+	err := c.cc.Invoke(ctx, TraderService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traderServiceClient) StreamTradeEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TradeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TraderService_ServiceDesc.Streams[0], TraderService_StreamTradeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, TradeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TraderService_StreamTradeEventsClient = grpc.ServerStreamingClient[TradeEvent]
+
+// TraderServiceServer is the server API for TraderService service.
+// All implementations must embed UnimplementedTraderServiceServer
+// for forward compatibility.
+type TraderServiceServer interface {
+	// 账户与行情
+	GetBalance(context.Context, *Empty) (*JSONReply, error)
+	GetPositions(context.Context, *Empty) (*PositionsReply, error)
+	GetMarketPrice(context.Context, *SymbolRequest) (*PriceReply, error)
+	// 开平仓（side: long/short；ClosePosition的quantity=0表示全部平仓）
+	OpenPosition(context.Context, *OrderRequest) (*JSONReply, error)
+	ClosePosition(context.Context, *OrderRequest) (*JSONReply, error)
+	// 止损止盈与撤单
+	SetStopLoss(context.Context, *ProtectiveRequest) (*Empty, error)
+	SetTakeProfit(context.Context, *ProtectiveRequest) (*Empty, error)
+	CancelAllOrders(context.Context, *SymbolRequest) (*Empty, error)
+	// AutoTrader控制
+	PauseSymbol(context.Context, *PauseRequest) (*Empty, error)
+	ResumeSymbol(context.Context, *PauseRequest) (*Empty, error)
+	Halt(context.Context, *Empty) (*Empty, error)
+	GetStatus(context.Context, *Empty) (*JSONReply, error)
+	// 交易事件（下单、平仓、撤单等变更类请求的结果）
+	StreamTradeEvents(*Empty, grpc.ServerStreamingServer[TradeEvent]) error
+	mustEmbedUnimplementedTraderServiceServer()
+}
+
+// UnimplementedTraderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTraderServiceServer struct{}
+
+func (UnimplementedTraderServiceServer) GetBalance(context.Context, *Empty) (*JSONReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedTraderServiceServer) GetPositions(context.Context, *Empty) (*PositionsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPositions not implemented")
+}
+func (UnimplementedTraderServiceServer) GetMarketPrice(context.Context, *SymbolRequest) (*PriceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMarketPrice not implemented")
+}
+func (UnimplementedTraderServiceServer) OpenPosition(context.Context, *OrderRequest) (*JSONReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenPosition not implemented")
+}
+func (UnimplementedTraderServiceServer) ClosePosition(context.Context, *OrderRequest) (*JSONReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClosePosition not implemented")
+}
+func (UnimplementedTraderServiceServer) SetStopLoss(context.Context, *ProtectiveRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetStopLoss not implemented")
+}
+func (UnimplementedTraderServiceServer) SetTakeProfit(context.Context, *ProtectiveRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTakeProfit not implemented")
+}
+func (UnimplementedTraderServiceServer) CancelAllOrders(context.Context, *SymbolRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelAllOrders not implemented")
+}
+func (UnimplementedTraderServiceServer) PauseSymbol(context.Context, *PauseRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseSymbol not implemented")
+}
+func (UnimplementedTraderServiceServer) ResumeSymbol(context.Context, *PauseRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeSymbol not implemented")
+}
+func (UnimplementedTraderServiceServer) Halt(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Halt not implemented")
+}
+func (UnimplementedTraderServiceServer) GetStatus(context.Context, *Empty) (*JSONReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedTraderServiceServer) StreamTradeEvents(*Empty, grpc.ServerStreamingServer[TradeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTradeEvents not implemented")
+}
+func (UnimplementedTraderServiceServer) mustEmbedUnimplementedTraderServiceServer() {}
+func (UnimplementedTraderServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeTraderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TraderServiceServer will
+// result in compilation errors.
+type UnsafeTraderServiceServer interface {
+	mustEmbedUnimplementedTraderServiceServer()
+}
+
+func RegisterTraderServiceServer(s grpc.ServiceRegistrar, srv TraderServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTraderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TraderService_ServiceDesc, srv)
+}
+
+func _TraderService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).GetBalance(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_GetPositions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).GetPositions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_GetPositions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).GetPositions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_GetMarketPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymbolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).GetMarketPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_GetMarketPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).GetMarketPrice(ctx, req.(*SymbolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_OpenPosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).OpenPosition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_OpenPosition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).OpenPosition(ctx, req.(*OrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_ClosePosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).ClosePosition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_ClosePosition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).ClosePosition(ctx, req.(*OrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_SetStopLoss_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProtectiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).SetStopLoss(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_SetStopLoss_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).SetStopLoss(ctx, req.(*ProtectiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_SetTakeProfit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProtectiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).SetTakeProfit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_SetTakeProfit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).SetTakeProfit(ctx, req.(*ProtectiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_CancelAllOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymbolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).CancelAllOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_CancelAllOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).CancelAllOrders(ctx, req.(*SymbolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_PauseSymbol_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).PauseSymbol(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_PauseSymbol_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).PauseSymbol(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_ResumeSymbol_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).ResumeSymbol(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_ResumeSymbol_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).ResumeSymbol(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_Halt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).Halt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_Halt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).Halt(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraderServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraderService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraderServiceServer).GetStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraderService_StreamTradeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TraderServiceServer).StreamTradeEvents(m, &grpc.GenericServerStream[Empty, TradeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TraderService_StreamTradeEventsServer = grpc.ServerStreamingServer[TradeEvent]
+
+// TraderService_ServiceDesc is the grpc.ServiceDesc for TraderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TraderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nofx.rpc.v1.TraderService",
+	HandlerType: (*TraderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBalance",
+			Handler:    _TraderService_GetBalance_Handler,
+		},
+		{
+			MethodName: "GetPositions",
+			Handler:    _TraderService_GetPositions_Handler,
+		},
+		{
+			MethodName: "GetMarketPrice",
+			Handler:    _TraderService_GetMarketPrice_Handler,
+		},
+		{
+			MethodName: "OpenPosition",
+			Handler:    _TraderService_OpenPosition_Handler,
+		},
+		{
+			MethodName: "ClosePosition",
+			Handler:    _TraderService_ClosePosition_Handler,
+		},
+		{
+			MethodName: "SetStopLoss",
+			Handler:    _TraderService_SetStopLoss_Handler,
+		},
+		{
+			MethodName: "SetTakeProfit",
+			Handler:    _TraderService_SetTakeProfit_Handler,
+		},
+		{
+			MethodName: "CancelAllOrders",
+			Handler:    _TraderService_CancelAllOrders_Handler,
+		},
+		{
+			MethodName: "PauseSymbol",
+			Handler:    _TraderService_PauseSymbol_Handler,
+		},
+		{
+			MethodName: "ResumeSymbol",
+			Handler:    _TraderService_ResumeSymbol_Handler,
+		},
+		{
+			MethodName: "Halt",
+			Handler:    _TraderService_Halt_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _TraderService_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTradeEvents",
+			Handler:       _TraderService_StreamTradeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "trader.proto",
+}
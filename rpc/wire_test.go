@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"nofx/rpc/traderpb"
+)
+
+// 每个消息的golden编码：字段编号与类型是与Python等其他语言客户端的约定，改动proto时不得破坏
+var wireGoldenCases = []struct {
+	name   string
+	msg    proto.Message
+	golden string // 编码的十六进制
+	json   string // 按proto字段名的JSON
+}{
+	{"Empty", &traderpb.Empty{}, "", `{}`},
+	{"SymbolRequest", &traderpb.SymbolRequest{Symbol: "BTCUSDT"}, "0a07425443555344 54", `{"symbol":"BTCUSDT"}`},
+	{"OrderRequest", &traderpb.OrderRequest{Symbol: "ETHUSDT", Side: "long", Quantity: 0.5, Leverage: 10},
+		"0a0745544855534454 12046c6f6e67 19000000000000e03f 200a",
+		`{"symbol":"ETHUSDT","side":"long","quantity":0.5,"leverage":10}`},
+	{"OrderRequest negative leverage", &traderpb.OrderRequest{Symbol: "BTCUSDT", Side: "short", Leverage: -1},
+		"0a0742544355534454 120573686f7274 20ffffffffffffffffff01",
+		`{"symbol":"BTCUSDT","side":"short","leverage":-1}`},
+	{"ProtectiveRequest", &traderpb.ProtectiveRequest{Symbol: "BTCUSDT", Side: "short", Quantity: 0.25, Price: 61000.5},
+		"0a0742544355534454 120573686f7274 19000000000000d03f 210000000010c9ed40",
+		`{"symbol":"BTCUSDT","side":"short","quantity":0.25,"price":61000.5}`},
+	{"PauseRequest", &traderpb.PauseRequest{Symbol: "SOLUSDT", ManageExisting: true, By: "ops"},
+		"0a07534f4c55534454 1001 1a036f7073",
+		`{"symbol":"SOLUSDT","manageExisting":true,"by":"ops"}`},
+	{"PriceReply", &traderpb.PriceReply{Price: 50000}, "0900000000006ae840", `{"price":50000}`},
+	{"JSONReply", &traderpb.JSONReply{Json: `{"a":1}`}, "0a077b2261223a317d", `{"json":"{\"a\":1}"}`},
+	{"PositionsReply", &traderpb.PositionsReply{PositionsJson: []string{`{}`, `{"s":"x"}`}},
+		"0a027b7d 0a097b2273223a2278227d",
+		`{"positionsJson":["{}","{\"s\":\"x\"}"]}`},
+	{"TradeEvent", &traderpb.TradeEvent{
+		TimestampMs: 1700000000000, TraderId: "t1", Operation: "open_long", ResponseCode: "OK",
+		Error: "e", ErrorCode: "EXCHANGE_TIMEOUT", CorrelationId: "c1", DurationMs: 12,
+		ParamsJson: `{}`, ExchangeIdsJson: `[]`,
+	},
+		"0880d095ffbc31 12027431 1a096f70656e5f6c6f6e67 22024f4b 2a0165 321045584348414e47455f54494d454f5554 3a026331 400c 4a027b7d 52025b5d",
+		`{"timestampMs":"1700000000000","traderId":"t1","operation":"open_long","responseCode":"OK","error":"e","errorCode":"EXCHANGE_TIMEOUT","correlationId":"c1","durationMs":"12","paramsJson":"{}","exchangeIdsJson":"[]"}`},
+}
+
+// TestWireGolden 生成代码的编码与golden逐字节一致，解码后得到原消息
+func TestWireGolden(t *testing.T) {
+	for _, tc := range wireGoldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			golden, err := hex.DecodeString(strings.ReplaceAll(tc.golden, " ", ""))
+			if err != nil {
+				t.Fatalf("无效的golden %q: %v", tc.golden, err)
+			}
+			got, err := proto.MarshalOptions{Deterministic: true}.Marshal(tc.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != hex.EncodeToString(golden) {
+				t.Fatalf("Marshal = %x, want %x", got, golden)
+			}
+
+			decoded := tc.msg.ProtoReflect().New().Interface()
+			if err := proto.Unmarshal(golden, decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !proto.Equal(decoded, tc.msg) {
+				t.Errorf("Unmarshal = %v, want %v", decoded, tc.msg)
+			}
+
+			gotJSON, err := protojson.Marshal(tc.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var x, y interface{}
+			if err := json.Unmarshal(gotJSON, &x); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tc.json), &y); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(x, y) {
+				t.Errorf("protojson = %s, want %s", gotJSON, tc.json)
+			}
+		})
+	}
+}
+
+// TestWireMethodNames 请求路径 /nofx.rpc.v1.TraderService/<方法> 是其他语言客户端依赖的约定
+func TestWireMethodNames(t *testing.T) {
+	desc := traderpb.File_trader_proto.Services().ByName("TraderService")
+	if desc == nil || desc.FullName() != "nofx.rpc.v1.TraderService" {
+		t.Fatalf("服务名 = %v, want nofx.rpc.v1.TraderService", desc)
+	}
+	for _, tc := range []struct{ got, want string }{
+		{traderpb.TraderService_GetBalance_FullMethodName, "/nofx.rpc.v1.TraderService/GetBalance"},
+		{traderpb.TraderService_OpenPosition_FullMethodName, "/nofx.rpc.v1.TraderService/OpenPosition"},
+		{traderpb.TraderService_StreamTradeEvents_FullMethodName, "/nofx.rpc.v1.TraderService/StreamTradeEvents"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("方法路径 = %s, want %s", tc.got, tc.want)
+		}
+	}
+	if n := desc.Methods().Len(); n != 13 {
+		t.Errorf("方法数量 = %d, want 13", n)
+	}
+}
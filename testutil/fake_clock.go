@@ -0,0 +1,127 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// FakeClock 可手动推进的时钟，Sleep/After/Ticker 只在 Advance 时触发
+type FakeClock struct {
+	now     time.Time
+	waiters []*fakeWaiter
+	mu      sync.Mutex
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+	period   time.Duration // >0 表示ticker
+	stopped  bool
+}
+
+// NewFakeClock 创建从start开始的假时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 当前假时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since 距t的假时间间隔
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// After 在假时间推进d后触发
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return w.ch
+}
+
+// Sleep 阻塞直到假时间被推进d（需要其他goroutine调用Advance）
+func (c *FakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-c.After(d)
+}
+
+// NewTicker 创建按假时间触发的ticker
+func (c *FakeClock) NewTicker(d time.Duration) clock.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{deadline: c.now.Add(d), ch: make(chan time.Time, 1), period: d}
+	c.waiters = append(c.waiters, w)
+	return &fakeTicker{clock: c, w: w}
+}
+
+// Advance 推进假时间并触发所有到期的Sleep/After/Ticker
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.stopped {
+			continue
+		}
+		for !w.deadline.After(c.now) {
+			select {
+			case w.ch <- w.deadline:
+			default: // 与time.Ticker一致，消费不及时的tick被丢弃
+			}
+			if w.period <= 0 {
+				break
+			}
+			w.deadline = w.deadline.Add(w.period)
+		}
+		if w.period > 0 || w.deadline.After(c.now) {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
+
+// Set 将假时间设置为t（只能向后推进）
+func (c *FakeClock) Set(t time.Time) {
+	c.Advance(t.Sub(c.Now()))
+}
+
+// Waiters 当前等待中的Sleep/After/Ticker数量（用于测试同步）
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil 阻塞直到至少有n个等待中的Sleep/After/Ticker（等待被测goroutine开始计时后再Advance）
+func (c *FakeClock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	w     *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.stopped = true
+}
+
+var _ clock.Clock = (*FakeClock)(nil)
@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"nofx/clock"
+)
+
+// accountSnapshotMaxSkew 余额与持仓的获取完成时间相差超过该值时，快照标记为不一致
+const accountSnapshotMaxSkew = 2 * time.Second
+
+// AccountSnapshot 同一时刻的账户余额与持仓
+type AccountSnapshot struct {
+	Balance   map[string]interface{}   `json:"balance"`
+	Positions []map[string]interface{} `json:"positions"`
+	Timestamp time.Time                `json:"timestamp"`
+	Skew      time.Duration            `json:"skew"`   // 两次查询完成的时间差
+	Skewed    bool                     `json:"skewed"` // 时间差超过上限，余额与持仓可能不一致
+}
+
+// fetchAccountSnapshot 并发获取余额与持仓（各自仍使用交易器缓存）
+// 交易所SDK不支持context，ctx取消时立即返回，后台请求继续执行直到结束
+func fetchAccountSnapshot(ctx context.Context, t Trader, clk clock.Clock) (*AccountSnapshot, error) {
+	clk = clock.OrReal(clk)
+
+	type balanceResult struct {
+		balance map[string]interface{}
+		at      time.Time
+		err     error
+	}
+	type positionsResult struct {
+		positions []map[string]interface{}
+		at        time.Time
+		err       error
+	}
+	balanceCh := make(chan balanceResult, 1)
+	positionsCh := make(chan positionsResult, 1)
+
+	go func() {
+		balance, err := t.GetBalance()
+		balanceCh <- balanceResult{balance, clk.Now(), err}
+	}()
+	go func() {
+		positions, err := t.GetPositions()
+		positionsCh <- positionsResult{positions, clk.Now(), err}
+	}()
+
+	var b balanceResult
+	var p positionsResult
+	for received := 0; received < 2; received++ {
+		select {
+		case b = <-balanceCh:
+		case p = <-positionsCh:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("获取账户快照失败: %w", ctx.Err())
+		}
+	}
+	if b.err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %w", b.err)
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", p.err)
+	}
+
+	skew := b.at.Sub(p.at)
+	if skew < 0 {
+		skew = -skew
+	}
+	timestamp := b.at
+	if p.at.After(timestamp) {
+		timestamp = p.at
+	}
+	return &AccountSnapshot{
+		Balance:   b.balance,
+		Positions: p.positions,
+		Timestamp: timestamp,
+		Skew:      skew,
+		Skewed:    skew > accountSnapshotMaxSkew,
+	}, nil
+}
+
+// GetAccountSnapshot 获取账户快照（余额与持仓并发查询）
+func (at *AutoTrader) GetAccountSnapshot(ctx context.Context) (*AccountSnapshot, error) {
+	snapshot, err := fetchAccountSnapshot(ctx, at.trader, at.clock)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Skewed {
+		log.Printf("⚠ [%s] 账户快照余额与持仓获取时间相差 %v，数据可能不一致", at.name, snapshot.Skew)
+	}
+	return snapshot, nil
+}
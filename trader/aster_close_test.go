@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+// asterTestTrader 连接假服务器的Aster交易器（Aster接口与币安合约同构，复用fakeBinance）
+func asterTestTrader(t *testing.T, f *fakeBinance) *AsterTrader {
+	t.Helper()
+	tr, err := NewAsterTrader("0xuser", "0xsigner", "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatalf("NewAsterTrader: %v", err)
+	}
+	tr.baseURL = f.srv.URL
+	return tr
+}
+
+func TestAsterCloseCancelsOrders(t *testing.T) {
+	const (
+		positions = `[{"symbol":"BTCUSDT","positionAmt":"-0.5","entryPrice":"60000","markPrice":"60000","unRealizedProfit":"0","leverage":"5","liquidationPrice":"0"}]`
+		info      = `{"symbols":[{"symbol":"BTCUSDT","pricePrecision":1,"quantityPrecision":3,"filters":[{"filterType":"PRICE_FILTER","tickSize":"0.1"},{"filterType":"LOT_SIZE","stepSize":"0.001"}]}]}`
+	)
+
+	t.Run("持仓已不存在", func(t *testing.T) {
+		f := newFakeBinance(t)
+		f.reply("GET /fapi/v3/positionRisk", positions)
+		tr := asterTestTrader(t, f)
+
+		_, err := tr.CloseLong("BTCUSDT", 0)
+		if !errors.Is(err, ErrPositionNotFound) {
+			t.Fatalf("err = %v, want ErrPositionNotFound", err)
+		}
+		if n := f.calls("DELETE /fapi/v3/allOpenOrders"); n != 1 {
+			t.Errorf("allOpenOrders 调用 %d 次, want 1", n)
+		}
+		if n := f.calls("POST /fapi/v3/order"); n != 0 {
+			t.Errorf("不应下平仓单, 实际 %d 次", n)
+		}
+	})
+
+	t.Run("全部平仓", func(t *testing.T) {
+		f := newFakeBinance(t)
+		f.reply("GET /fapi/v3/positionRisk", positions)
+		f.reply("GET /fapi/v3/ticker/price", `{"symbol":"BTCUSDT","price":"60000"}`)
+		f.reply("GET /fapi/v3/exchangeInfo", info)
+		tr := asterTestTrader(t, f)
+
+		if _, err := tr.CloseShort("BTCUSDT", 0); err != nil {
+			t.Fatalf("CloseShort: %v", err)
+		}
+		if n := f.calls("POST /fapi/v3/order"); n != 1 {
+			t.Errorf("平仓单 %d 次, want 1", n)
+		}
+		if n := f.calls("DELETE /fapi/v3/allOpenOrders"); n != 1 {
+			t.Errorf("allOpenOrders 调用 %d 次, want 1", n)
+		}
+	})
+
+	t.Run("部分平仓保留保护单", func(t *testing.T) {
+		f := newFakeBinance(t)
+		f.reply("GET /fapi/v3/ticker/price", `{"symbol":"BTCUSDT","price":"60000"}`)
+		f.reply("GET /fapi/v3/exchangeInfo", info)
+		tr := asterTestTrader(t, f)
+
+		if _, err := tr.CloseShort("BTCUSDT", 0.2); err != nil {
+			t.Fatalf("CloseShort: %v", err)
+		}
+		if n := f.calls("DELETE /fapi/v3/allOpenOrders"); n != 0 {
+			t.Errorf("部分平仓不应取消挂单, 实际 %d 次", n)
+		}
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"math/big"
 	"net/http"
 	"net/url"
@@ -36,6 +35,9 @@ type AsterTrader struct {
 	// 缓存交易对精度信息
 	symbolPrecision map[string]SymbolPrecision
 	mu              sync.RWMutex
+
+	// 各类调用的超时时间
+	timeouts TimeoutConfig
 }
 
 // SymbolPrecision 交易对精度信息
@@ -71,10 +73,16 @@ func NewAsterTrader(user, signer, privateKeyHex string) (*AsterTrader, error) {
 				IdleConnTimeout:       90 * time.Second,
 			},
 		},
-		baseURL: "https://fapi.asterdex.com",
+		baseURL:  "https://fapi.asterdex.com",
+		timeouts: DefaultTimeoutConfig(),
 	}, nil
 }
 
+// SetTimeouts 设置各类调用的超时时间
+func (t *AsterTrader) SetTimeouts(cfg TimeoutConfig) {
+	t.timeouts = cfg
+}
+
 // genNonce 生成微秒时间戳
 func (t *AsterTrader) genNonce() uint64 {
 	return uint64(time.Now().UnixMicro())
@@ -144,17 +152,9 @@ func (t *AsterTrader) getPrecision(symbol string) (SymbolPrecision, error) {
 	return SymbolPrecision{}, fmt.Errorf("未找到交易对 %s 的精度信息", symbol)
 }
 
-// roundToTickSize 将价格/数量四舍五入到tick size/step size的整数倍
+// roundToTickSize 将价格四舍五入到tick size的整数倍
 func roundToTickSize(value float64, tickSize float64) float64 {
-	if tickSize <= 0 {
-		return value
-	}
-	// 计算有多少个tick size
-	steps := value / tickSize
-	// 四舍五入到最近的整数
-	roundedSteps := math.Round(steps)
-	// 乘回tick size
-	return roundedSteps * tickSize
+	return decimalFloat(roundToStep(value, tickSize))
 }
 
 // formatPrice 格式化价格到正确精度和tick size
@@ -170,8 +170,7 @@ func (t *AsterTrader) formatPrice(symbol string, price float64) (float64, error)
 	}
 
 	// 如果没有tick size，则按精度四舍五入
-	multiplier := math.Pow10(prec.PricePrecision)
-	return math.Round(price*multiplier) / multiplier, nil
+	return decimalFloat(roundToPrecision(price, prec.PricePrecision)), nil
 }
 
 // formatQuantity 格式化数量到正确精度和step size
@@ -181,14 +180,13 @@ func (t *AsterTrader) formatQuantity(symbol string, quantity float64) (float64,
 		return 0, err
 	}
 
-	// 优先使用step size，确保数量是step size的整数倍
+	// 优先使用step size，向下截断到step size的整数倍（不超过请求数量）
 	if prec.StepSize > 0 {
-		return roundToTickSize(quantity, prec.StepSize), nil
+		return decimalFloat(floorToStep(quantity, prec.StepSize)), nil
 	}
 
-	// 如果没有step size，则按精度四舍五入
-	multiplier := math.Pow10(prec.QuantityPrecision)
-	return math.Round(quantity*multiplier) / multiplier, nil
+	// 如果没有step size，则按精度截断
+	return decimalFloat(floorToPrecision(quantity, prec.QuantityPrecision)), nil
 }
 
 // formatFloatWithPrecision 将浮点数格式化为指定精度的字符串（去除末尾的0）
@@ -322,10 +320,16 @@ func (t *AsterTrader) sign(params map[string]interface{}, nonce uint64) error {
 }
 
 // request 发送HTTP请求（带重试机制）
+// 变更类请求（POST/DELETE）超时后结果未知，直接返回TimeoutError，不做重试
 func (t *AsterTrader) request(method, endpoint string, params map[string]interface{}) ([]byte, error) {
 	const maxRetries = 3
 	var lastErr error
 
+	class := OpPrivateRead
+	if m := strings.ToUpper(method); m == "POST" || m == "DELETE" {
+		class = OpMutation
+	}
+
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// 每次重试都生成新的nonce和签名
 		nonce := t.genNonce()
@@ -339,15 +343,22 @@ func (t *AsterTrader) request(method, endpoint string, params map[string]interfa
 			return nil, err
 		}
 
-		body, err := t.doRequest(method, endpoint, paramsCopy)
+		ctx, cancel := timeoutContext(t.timeouts, class)
+		body, err := t.doRequest(ctx, method, endpoint, paramsCopy)
+		err = timeoutError(ctx, class, endpoint, err)
+		cancel()
 		if err == nil {
 			return body, nil
 		}
+		if IsOutcomeUnknown(err) {
+			return nil, err
+		}
 
 		lastErr = err
 
 		// 如果是网络超时或临时错误，重试
-		if strings.Contains(err.Error(), "timeout") ||
+		if errors.Is(err, ErrTimeout) ||
+			strings.Contains(err.Error(), "timeout") ||
 			strings.Contains(err.Error(), "connection reset") ||
 			strings.Contains(err.Error(), "EOF") {
 			if attempt < maxRetries {
@@ -365,7 +376,7 @@ func (t *AsterTrader) request(method, endpoint string, params map[string]interfa
 }
 
 // doRequest 执行实际的HTTP请求
-func (t *AsterTrader) doRequest(method, endpoint string, params map[string]interface{}) ([]byte, error) {
+func (t *AsterTrader) doRequest(ctx context.Context, method, endpoint string, params map[string]interface{}) ([]byte, error) {
 	fullURL := t.baseURL + endpoint
 	method = strings.ToUpper(method)
 
@@ -376,7 +387,7 @@ func (t *AsterTrader) doRequest(method, endpoint string, params map[string]inter
 		for k, v := range params {
 			form.Set(k, fmt.Sprintf("%v", v))
 		}
-		req, err := http.NewRequest("POST", fullURL, strings.NewReader(form.Encode()))
+		req, err := http.NewRequestWithContext(ctx, "POST", fullURL, strings.NewReader(form.Encode()))
 		if err != nil {
 			return nil, err
 		}
@@ -403,7 +414,7 @@ func (t *AsterTrader) doRequest(method, endpoint string, params map[string]inter
 		u, _ := url.Parse(fullURL)
 		u.RawQuery = q.Encode()
 
-		req, err := http.NewRequest(method, u.String(), nil)
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -523,12 +534,10 @@ func (t *AsterTrader) GetPositions() ([]map[string]interface{}, error) {
 // OpenLong 开多单
 func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
-	}
-
-	// 先设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	// 设置杠杆后恢复仍有持仓的保护单
+	if err := t.WithProtectiveOrdersSuspended(symbol, func() error {
+		return t.SetLeverage(symbol, leverage)
+	}); err != nil {
 		return nil, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
@@ -590,12 +599,10 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 // OpenShort 开空单
 func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
-	}
-
-	// 先设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	// 设置杠杆后恢复仍有持仓的保护单
+	if err := t.WithProtectiveOrdersSuspended(symbol, func() error {
+		return t.SetLeverage(symbol, leverage)
+	}); err != nil {
 		return nil, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
@@ -656,23 +663,21 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 
 // CloseLong 平多单
 func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
-	// 如果数量为0，获取当前持仓数量
-	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
-				break
+	// 如果数量为0，获取当前持仓数量（全部平仓）
+	fullClose := quantity == 0
+	if fullClose {
+		pos, err := findPosition(t, symbol, PositionLong)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 		log.Printf("  📊 获取到多仓数量: %.8f", quantity)
 	}
 
@@ -728,9 +733,11 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 
 	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, qtyStr)
 
-	// 平仓后取消该币种的所有挂单(止损止盈单)
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	// 全部平仓后取消该币种的所有挂单（止损止盈单），部分平仓保留保护单
+	if fullClose {
+		if err := t.CancelAllOrders(symbol); err != nil {
+			log.Printf("  ⚠ 取消挂单失败: %v", err)
+		}
 	}
 
 	return result, nil
@@ -738,24 +745,21 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 
 // CloseShort 平空单
 func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
-	// 如果数量为0，获取当前持仓数量
-	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				// Aster的GetPositions已经将空仓数量转换为正数，直接使用
-				quantity = pos["positionAmt"].(float64)
-				break
+	// 如果数量为0，获取当前持仓数量（全部平仓）
+	fullClose := quantity == 0
+	if fullClose {
+		pos, err := findPosition(t, symbol, PositionShort)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 		log.Printf("  📊 获取到空仓数量: %.8f", quantity)
 	}
 
@@ -811,9 +815,11 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 
 	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, qtyStr)
 
-	// 平仓后取消该币种的所有挂单(止损止盈单)
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	// 全部平仓后取消该币种的所有挂单（止损止盈单），部分平仓保留保护单
+	if fullClose {
+		if err := t.CancelAllOrders(symbol); err != nil {
+			log.Printf("  ⚠ 取消挂单失败: %v", err)
+		}
 	}
 
 	return result, nil
@@ -890,11 +896,8 @@ func (t *AsterTrader) GetMarketPrice(symbol string) (float64, error) {
 }
 
 // SetStopLoss 设置止损
-func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
-	side := "SELL"
-	if positionSide == "SHORT" {
-		side = "BUY"
-	}
+func (t *AsterTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	side := strings.ToUpper(positionSide.CloseSide().String())
 
 	// 格式化价格和数量到正确精度
 	formattedPrice, err := t.formatPrice(symbol, stopPrice)
@@ -931,11 +934,8 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 }
 
 // SetTakeProfit 设置止盈
-func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
-	side := "SELL"
-	if positionSide == "SHORT" {
-		side = "BUY"
-	}
+func (t *AsterTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	side := strings.ToUpper(positionSide.CloseSide().String())
 
 	// 格式化价格和数量到正确精度
 	formattedPrice, err := t.formatPrice(symbol, takeProfitPrice)
@@ -981,6 +981,71 @@ func (t *AsterTrader) CancelAllOrders(symbol string) error {
 	return err
 }
 
+// GetOpenOrders 获取挂单（symbol为空时返回所有币种）
+func (t *AsterTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	params := make(map[string]interface{})
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	body, err := t.request("GET", "/fapi/v3/openOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var orders []struct {
+		OrderID       int64  `json:"orderId"`
+		Symbol        string `json:"symbol"`
+		Side          string `json:"side"`
+		PositionSide  string `json:"positionSide"`
+		Type          string `json:"type"`
+		Price         string `json:"price"`
+		StopPrice     string `json:"stopPrice"`
+		OrigQty       string `json:"origQty"`
+		ReduceOnly    bool   `json:"reduceOnly"`
+		ClosePosition bool   `json:"closePosition"`
+		Time          int64  `json:"time"`
+	}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析挂单失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(orders))
+	for _, order := range orders {
+		price, _ := strconv.ParseFloat(order.Price, 64)
+		stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+		quantity, _ := strconv.ParseFloat(order.OrigQty, 64)
+		result = append(result, OpenOrder{
+			OrderID:       strconv.FormatInt(order.OrderID, 10),
+			Symbol:        order.Symbol,
+			Side:          order.Side,
+			PositionSide:  order.PositionSide,
+			Type:          order.Type,
+			Price:         price,
+			StopPrice:     stopPrice,
+			Quantity:      quantity,
+			ReduceOnly:    order.ReduceOnly || order.ClosePosition,
+			ClosePosition: order.ClosePosition,
+			CreateTime:    time.UnixMilli(order.Time),
+		})
+	}
+	return result, nil
+}
+
+// SnapshotProtectiveOrders 记录该币种保护现有持仓的止损/止盈单
+func (t *AsterTrader) SnapshotProtectiveOrders(symbol string) (*ProtectiveOrderSnapshot, error) {
+	return snapshotProtectiveOrders(t, t, symbol)
+}
+
+// RestoreProtectiveOrders 按快照重新挂出保护单
+func (t *AsterTrader) RestoreProtectiveOrders(snapshot *ProtectiveOrderSnapshot) error {
+	return restoreProtectiveOrders(t, snapshot, nil)
+}
+
+// WithProtectiveOrdersSuspended 撤销保护单后执行fn，无论成功与否都恢复保护单
+func (t *AsterTrader) WithProtectiveOrdersSuspended(symbol string, fn func() error) error {
+	return withProtectiveOrdersSuspended(t, t, symbol, fn)
+}
+
 // FormatQuantity 格式化数量（实现Trader接口）
 func (t *AsterTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
 	formatted, err := t.formatQuantity(symbol, quantity)
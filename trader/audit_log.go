@@ -0,0 +1,182 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditEntry 订单审计记录（每条对应一次变更类请求）
+type AuditEntry struct {
+	Timestamp     time.Time              `json:"timestamp"`
+	TraderID      string                 `json:"trader_id,omitempty"`
+	Operation     string                 `json:"operation"`            // open_long, close_short, cancel_all, set_leverage...
+	Params        map[string]interface{} `json:"params"`               // 请求参数（不含密钥）
+	ResponseCode  string                 `json:"response_code"`        // OK 或 ERROR
+	Error         string                 `json:"error,omitempty"`      // 失败时的错误信息
+	ErrorCode     ErrorCode              `json:"error_code,omitempty"` // 机器可读错误码（不随语言变化）
+	ExchangeIDs   map[string]interface{} `json:"exchange_ids,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"` // 决策周期/交易关联ID
+	DurationMs    int64                  `json:"duration_ms"`
+}
+
+// AuditWriter 只追加的审计日志写入器
+// 写入在后台goroutine中完成，缓冲区满时丢弃记录，保证不会阻塞或影响交易调用
+type AuditWriter struct {
+	path       string
+	maxSize    int64 // 单个文件最大字节数，超过后轮转
+	maxBackups int   // 保留的历史文件数量
+
+	entries chan AuditEntry
+	dropped int64
+	done    chan struct{}
+
+	file   *os.File
+	size   int64
+	closed bool
+	mu     sync.RWMutex // 保护closed，防止关闭后写入
+}
+
+// NewAuditWriter 创建审计日志写入器（maxSize<=0 默认50MB，maxBackups<=0 默认10个）
+func NewAuditWriter(path string, maxSize int64, maxBackups int) (*AuditWriter, error) {
+	if maxSize <= 0 {
+		maxSize = 50 * 1024 * 1024
+	}
+	if maxBackups <= 0 {
+		maxBackups = 10
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+
+	w := &AuditWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		entries:    make(chan AuditEntry, 1024),
+		done:       make(chan struct{}),
+	}
+	if err := w.openFile(); err != nil {
+		return nil, err
+	}
+
+	go w.loop()
+	return w, nil
+}
+
+// Write 异步写入一条审计记录（非阻塞）
+func (w *AuditWriter) Write(entry AuditEntry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.entries <- entry:
+	default:
+		if n := atomic.AddInt64(&w.dropped, 1); n == 1 || n%100 == 0 {
+			log.Printf("⚠ 审计日志缓冲区已满，已丢弃 %d 条记录", n)
+		}
+	}
+}
+
+// Dropped 返回因缓冲区满而丢弃的记录数
+func (w *AuditWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// Close 写完缓冲区中的记录后关闭文件
+func (w *AuditWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.entries)
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *AuditWriter) loop() {
+	defer close(w.done)
+	for entry := range w.entries {
+		w.writeEntry(entry)
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+}
+
+// writeEntry 写入一行JSON，失败只记录日志
+func (w *AuditWriter) writeEntry(entry AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("⚠ 审计记录序列化失败: %v", err)
+		return
+	}
+	data = append(data, '\n')
+
+	if w.file == nil || w.size+int64(len(data)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			log.Printf("⚠ 审计日志轮转失败: %v", err)
+			if w.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := w.file.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		log.Printf("⚠ 写入审计日志失败: %v", err)
+	}
+}
+
+func (w *AuditWriter) openFile() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("读取审计日志信息失败: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// rotate 轮转文件：audit.jsonl -> audit.jsonl.1 -> ... -> audit.jsonl.N（最旧的被删除）
+func (w *AuditWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if _, err := os.Stat(w.path); err == nil {
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			// 无法重命名时继续追加到原文件，避免丢失记录
+			if openErr := w.openFile(); openErr != nil {
+				return openErr
+			}
+			return err
+		}
+	}
+	return w.openFile()
+}
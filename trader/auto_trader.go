@@ -1,16 +1,23 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"nofx/clock"
 	"nofx/decision"
 	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // AutoTraderConfig 自动交易配置（简化版 - AI全权决策）
@@ -21,7 +28,7 @@ type AutoTraderConfig struct {
 	AIModel string // AI模型: "qwen" 或 "deepseek"
 
 	// 交易平台选择
-	Exchange string // "binance", "hyperliquid" 或 "aster"
+	Exchange string // "binance", "hyperliquid", "aster", "okx" 或 "bybit"
 
 	// 币安API配置
 	BinanceAPIKey    string
@@ -37,6 +44,23 @@ type AutoTraderConfig struct {
 	AsterSigner     string // Aster API钱包地址
 	AsterPrivateKey string // Aster API钱包私钥
 
+	// OKX配置
+	OKXAPIKey         string
+	OKXSecretKey      string
+	OKXPassphrase     string
+	OKXPreferWSOrders bool          // 下单/撤单优先使用WebSocket通道（未连接时自动使用REST）
+	OKXRefreshPeriod  time.Duration // 后台刷新余额与持仓的间隔（0表示不启用，缓存过期时在交易周期内同步查询）
+
+	// Bybit配置（DemoTrading为true时连接测试网）
+	BybitAPIKey    string
+	BybitSecretKey string
+
+	// 模拟盘运行：交易器报告的环境（DemoTrader）与此不一致时拒绝启动，避免实盘策略误连模拟盘或反之
+	DemoTrading bool
+
+	// 预先创建的交易器（如测试中的mock.MockTrader或PaperTrader），设置时不按Exchange创建交易器
+	Trader Trader
+
 	CoinPoolAPIURL string
 
 	// AI配置
@@ -63,16 +87,66 @@ type AutoTraderConfig struct {
 	MaxDailyLoss    float64       // 最大日亏损百分比（提示）
 	MaxDrawdown     float64       // 最大回撤百分比（提示）
 	StopTradingTime time.Duration // 触发风控后暂停时长
+	SymbolCooldown  time.Duration // 平仓后同币种冷却时间，0表示不冷却
+
+	// API错误率监控（超过阈值暂停开新仓，零值使用默认配置）
+	ErrorRate ErrorRateConfig
+
+	// 交易所调用超时（零值使用默认配置：读3秒，下单5秒）
+	Timeouts TimeoutConfig
+
+	// 止损止盈的触发价类型（last/mark/index，为空时使用交易器默认值last）
+	TriggerPriceType TriggerPriceType
+
+	// 止损止盈提交前的价格校验（方向始终校验，最小距离为0时不校验距离）
+	ProtectivePriceCheck ProtectivePriceCheck
+
+	// 请求限速（零值不限速），饱和时平仓与止损止盈优先于开仓，开仓优先于查询
+	RequestLimit RequestSchedulerConfig
+
+	// 瞬时错误（限速、系统繁忙、网络错误）的重试策略（零值使用默认配置：最多3次，MaxAttempts=1表示不重试）
+	// 下单、平仓等变更类调用只在确定未执行时重试
+	Retry RetryPolicy
+
+	// 余额与持仓数据的最大允许年龄（交易器报告缓存新鲜度时生效，0表示不检查），
+	// 超过时本周期不调用AI也不交易，避免后台刷新持续失败时在旧数据上决策
+	MaxDataStaleness time.Duration
+
+	// 只读状态接口（交易器支持时在 StatusAddr 的 /status 上提供，为空时不启动），
+	// StatusToken不为空时请求需携带 Authorization: Bearer <token> 或 X-Status-Token 头
+	StatusAddr  string
+	StatusToken string
+
+	// 运行指标注册表（为空时使用SetMetricsRegisterer设置的进程级注册表，都未设置时不记录），按trader标签区分交易员
+	Metrics prometheus.Registerer
+
+	// 订单审计日志（为空时使用 audit_logs/<ID>.jsonl）
+	AuditLogPath string
+
+	// 运行状态文件（为空时使用 trader_state/<ID>.json）
+	StateFilePath string
+
+	// 时间源（为空时使用系统时钟，测试中可注入假时钟）
+	Clock clock.Clock
 
 	// 仓位模式
 	IsCrossMargin bool // true=全仓模式, false=逐仓模式
 
+	// 逐仓自动追加保证金（默认关闭，全仓持仓不生效）
+	MarginTopUp MarginTopUpPolicy
+
+	// 固定杠杆（symbol -> 杠杆），启动时批量设置，已确认的币种开仓时不再设置杠杆
+	Leverages map[string]int
+
 	// 币种配置
 	DefaultCoins []string // 默认币种列表（从数据库获取）
 	TradingCoins []string // 实际交易币种列表
 
 	// 系统提示词模板
 	SystemPromptTemplate string // 系统提示词模板名称（如 "default", "aggressive"）
+
+	// 日志与错误信息语言（zh/en，为空时不修改当前设置；进程级生效）
+	Language string
 }
 
 // AutoTrader 自动交易器
@@ -95,9 +169,23 @@ type AutoTrader struct {
 	lastResetTime         time.Time
 	stopUntil             time.Time
 	isRunning             bool
-	startTime             time.Time        // 系统启动时间
-	callCount             int              // AI调用次数
-	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	startTime             time.Time                     // 系统启动时间
+	callCount             int                           // AI调用次数
+	positionFirstSeenTime map[string]int64              // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	protectiveOrders      map[string]ProtectiveOrderIDs // 持仓对应的止损止盈订单ID (symbol_side -> IDs)
+	marginAdded           map[string]float64            // 持仓累计自动追加的保证金 (symbol_side -> USDT)
+	pausedSymbols         map[string]*SymbolPause       // 暂停交易的币种（API与交易周期共用，由pauseMu保护）
+	errorMonitor          *ErrorRateMonitor             // API错误率监控
+	pauseMu               sync.RWMutex
+	instrumented          *instrumentedTrader
+	auditWriter           *AuditWriter        // 订单审计日志
+	stateStore            *StateStore         // 运行状态持久化（重启后恢复）
+	symbolStates          *symbolStateMachine // 各币种交易生命周期状态
+	clock                 clock.Clock
+	stopRefresh           context.CancelFunc // 停止交易器的后台缓存刷新
+	refreshDone           <-chan struct{}
+	stopStatus            context.CancelFunc // 关闭状态接口
+	statusDone            <-chan struct{}
 }
 
 // NewAutoTrader 创建自动交易器
@@ -152,10 +240,6 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		config.Exchange = "binance"
 	}
 
-	// 根据配置创建对应的交易器
-	var trader Trader
-	var err error
-
 	// 记录仓位模式（通用）
 	marginModeStr := "全仓"
 	if !config.IsCrossMargin {
@@ -163,24 +247,87 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	}
 	log.Printf("📊 [%s] 仓位模式: %s", config.Name, marginModeStr)
 
-	switch config.Exchange {
-	case "binance":
-		log.Printf("🏦 [%s] 使用币安合约交易", config.Name)
-		trader = NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey)
-	case "hyperliquid":
-		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
-		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
+	// 根据配置创建对应的交易器（已注入时直接使用）
+	trader := config.Trader
+	if trader == nil {
+		var err error
+		if trader, err = NewExchangeTrader(config); err != nil {
+			return nil, err
 		}
-	case "aster":
-		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
-		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+	}
+	demo, ok := trader.(DemoTrader)
+	switch {
+	case !ok && config.DemoTrading:
+		return nil, fmt.Errorf("%s 交易器不支持模拟盘，但配置为模拟盘运行（DemoTrading=true），拒绝启动", config.Exchange)
+	case ok && demo.IsDemo() && !config.DemoTrading:
+		return nil, fmt.Errorf("%s 交易器连接的是模拟盘，但配置为实盘运行（DemoTrading=false），拒绝启动", config.Exchange)
+	case ok && !demo.IsDemo() && config.DemoTrading:
+		return nil, fmt.Errorf("%s 交易器连接的是实盘，但配置为模拟盘运行（DemoTrading=true），拒绝启动", config.Exchange)
+	}
+	if config.DemoTrading {
+		log.Printf("🧪 [%s] 模拟盘运行", config.Name)
+	}
+
+	// 止损止盈触发价类型（交易器不支持时只能使用默认的最新成交价）
+	if config.TriggerPriceType != "" {
+		tp, ok := trader.(TriggerPriceTrader)
+		if !ok {
+			return nil, fmt.Errorf("%s 不支持选择触发价类型", config.Exchange)
+		}
+		if err := tp.SetTriggerPriceType(config.TriggerPriceType); err != nil {
+			return nil, err
+		}
+		log.Printf("🎯 [%s] 止损止盈触发价类型: %s", config.Name, config.TriggerPriceType)
+	}
+
+	// 止损止盈价格校验（在当前价格错误一侧或距离过近时拒绝提交）
+	if checker, ok := trader.(ProtectivePriceChecker); ok {
+		checker.SetProtectivePriceCheck(config.ProtectivePriceCheck)
+	}
+
+	// API错误率监控：包装trader，所有调用结果都会进入滑动窗口统计
+	clk := clock.OrReal(config.Clock)
+	errorMonitor := NewErrorRateMonitorWithClock(config.ErrorRate, clk)
+	errorMonitor.OnChange(func(paused bool, rate float64) {
+		if paused {
+			log.Printf("🚨 [%s] API错误率 %.1f%% 超过阈值，已暂停开新仓（平仓与止损止盈不受影响）", config.Name, rate)
+		} else {
+			log.Printf("✅ [%s] API错误率回落至 %.1f%%，已恢复开新仓", config.Name, rate)
+		}
+	})
+	instrumented := newInstrumentedTrader(trader, errorMonitor)
+	instrumented.SetScheduler(NewRequestScheduler(config.RequestLimit, clk))
+	// 交易器自身需要观察调用结果（如OKX连续认证错误后重建客户端）
+	if observer, ok := trader.(CallObserver); ok {
+		instrumented.AddObserver(observer)
+	}
+	// 重试包在最外层：每次尝试都经过限速、错误率统计与审计
+	trader = NewRetryingTrader(instrumented, config.Retry, clk)
+
+	// 订单审计日志：失败不影响交易，仅关闭审计
+	auditPath := config.AuditLogPath
+	if auditPath == "" {
+		auditPath = fmt.Sprintf("audit_logs/%s.jsonl", config.ID)
+	}
+	auditWriter, err := NewAuditWriter(auditPath, 0, 0)
+	if err != nil {
+		log.Printf("⚠ [%s] 初始化订单审计日志失败: %v", config.Name, err)
+	}
+	instrumented.SetAuditWriter(auditWriter, config.ID)
+
+	// 交易事件发布到消息总线（进程级配置，异步发布不影响下单）
+	if publisher := defaultEventPublisher.Load(); publisher != nil {
+		instrumented.AddTradeEventSink(publisher)
+	}
+	// 交易器事件回调（如通知），事件带上交易员名称
+	if source, ok := instrumented.Trader.(TradeEventSource); ok {
+		for _, hook := range tradeEventHooks() {
+			hook := hook
+			source.RegisterHook(func(e TradeEvent) {
+				e.Trader = config.Name
+				hook(e)
+			})
 		}
-	default:
-		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
 	}
 
 	// 验证初始金额配置
@@ -192,13 +339,31 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	logDir := fmt.Sprintf("decision_logs/%s", config.ID)
 	decisionLogger := logger.NewDecisionLogger(logDir)
 
+	// 运行状态持久化：失败时仅在内存中维护状态
+	statePath := config.StateFilePath
+	if statePath == "" {
+		statePath = fmt.Sprintf("trader_state/%s.json", config.ID)
+	}
+	stateStore, err := NewStateStore(statePath)
+	if err != nil {
+		log.Printf("⚠ [%s] 初始化状态文件失败: %v", config.Name, err)
+	}
+
+	if config.MarginTopUp.Enabled && config.IsCrossMargin {
+		log.Printf("⚠ [%s] 全仓模式下不会自动追加保证金（仅对逐仓持仓生效）", config.Name)
+	}
+
+	if config.Language != "" {
+		SetLanguage(config.Language)
+	}
+
 	// 设置默认系统提示词模板
 	systemPromptTemplate := config.SystemPromptTemplate
 	if systemPromptTemplate == "" {
 		systemPromptTemplate = "default" // 默认使用 default 模板
 	}
 
-	return &AutoTrader{
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -211,23 +376,113 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		systemPromptTemplate:  systemPromptTemplate,
 		defaultCoins:          config.DefaultCoins,
 		tradingCoins:          config.TradingCoins,
-		lastResetTime:         time.Now(),
-		startTime:             time.Now(),
+		lastResetTime:         clk.Now(),
+		startTime:             clk.Now(),
 		callCount:             0,
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
-	}, nil
+		protectiveOrders:      make(map[string]ProtectiveOrderIDs),
+		marginAdded:           make(map[string]float64),
+		pausedSymbols:         make(map[string]*SymbolPause),
+		errorMonitor:          errorMonitor,
+		instrumented:          instrumented,
+		auditWriter:           auditWriter,
+		stateStore:            stateStore,
+		symbolStates:          newSymbolStateMachine(config.Name, clk, config.SymbolCooldown),
+		clock:                 clk,
+	}
+	at.restoreState()
+
+	return at, nil
+}
+
+// metricsRegisterer 交易器使用的指标注册表（配置优先，其次为进程级注册表）
+func (c AutoTraderConfig) metricsRegisterer() prometheus.Registerer {
+	if c.Metrics != nil {
+		return c.Metrics
+	}
+	return defaultMetricsRegisterer()
+}
+
+// NewExchangeTrader 根据配置创建对应交易平台的Trader（AutoTrader与命令行工具共用）
+func NewExchangeTrader(config AutoTraderConfig) (Trader, error) {
+	switch config.Exchange {
+	case "binance", "":
+		log.Printf("🏦 [%s] 使用币安合约交易", config.Name)
+		trader := NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey)
+		trader.SetTimeouts(config.Timeouts)
+		return trader, nil
+	case "hyperliquid":
+		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
+		trader, err := NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
+		}
+		return trader, nil
+	case "aster":
+		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
+		trader, err := NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+		}
+		trader.SetTimeouts(config.Timeouts)
+		return trader, nil
+	case "okx":
+		log.Printf("🏦 [%s] 使用OKX合约交易", config.Name)
+		var opts []OkxOption
+		if config.DemoTrading {
+			opts = append(opts, WithDemoServer())
+		}
+		if config.OKXRefreshPeriod > 0 {
+			opts = append(opts, WithBackgroundRefresh(config.OKXRefreshPeriod))
+		}
+		if reg := config.metricsRegisterer(); reg != nil {
+			opts = append(opts, WithMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"trader": config.ID}, reg)))
+		}
+		trader, err := NewOkxTrader(config.OKXAPIKey, config.OKXSecretKey, config.OKXPassphrase, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("初始化OKX交易器失败: %w", err)
+		}
+		trader.SetTimeouts(config.Timeouts)
+		trader.SetPreferWSOrders(config.OKXPreferWSOrders)
+		return trader, nil
+	case "bybit":
+		log.Printf("🏦 [%s] 使用Bybit合约交易", config.Name)
+		trader := NewBybitTrader(config.BybitAPIKey, config.BybitSecretKey, config.DemoTrading)
+		trader.SetTimeouts(config.Timeouts)
+		return trader, nil
+	default:
+		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
+	}
 }
 
 // Run 运行自动交易主循环
 func (at *AutoTrader) Run() error {
+	// 启动前预检：硬错误拒绝启动，仅有警告时需要 --force
+	if _, err := at.CheckPreflight(); err != nil {
+		return err
+	}
+
+	// 恢复的状态与交易所实际持仓对账
+	at.reconcileState()
+	at.configureStartupLeverage()
+
+	// 后台刷新余额与持仓（交易器支持并已启用时），Stop时结束
+	if refresher, ok := at.instrumented.Trader.(BackgroundRefresher); ok {
+		refreshCtx, cancel := context.WithCancel(context.Background())
+		at.stopRefresh = cancel
+		at.refreshDone = refresher.StartBackgroundRefresh(refreshCtx)
+	}
+
+	at.startStatusServer()
+
 	at.isRunning = true
 	log.Println("🚀 AI驱动自动交易系统启动")
 	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
 	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
 	log.Println("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
 
-	ticker := time.NewTicker(at.config.ScanInterval)
+	ticker := at.clock.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
 	// 首次立即执行
@@ -237,7 +492,7 @@ func (at *AutoTrader) Run() error {
 
 	for at.isRunning {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			if err := at.runCycle(); err != nil {
 				log.Printf("❌ 执行失败: %v", err)
 			}
@@ -250,16 +505,66 @@ func (at *AutoTrader) Run() error {
 // Stop 停止自动交易
 func (at *AutoTrader) Stop() {
 	at.isRunning = false
+	if at.stopRefresh != nil {
+		at.stopRefresh()
+		<-at.refreshDone
+	}
+	if at.stopStatus != nil {
+		at.stopStatus()
+		<-at.statusDone
+	}
+	at.saveState()
 	log.Println("⏹ 自动交易系统停止")
 }
 
+// startStatusServer 配置了StatusAddr且交易器提供状态接口时在后台监听，Stop时关闭
+func (at *AutoTrader) startStatusServer() {
+	if at.config.StatusAddr == "" {
+		return
+	}
+	provider, ok := at.instrumented.Trader.(StatusHandlerProvider)
+	if !ok {
+		log.Printf("⚠ [%s] 交易器不支持状态接口，忽略 StatusAddr", at.name)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	at.stopStatus, at.statusDone = cancel, done
+	go func() {
+		defer close(done)
+		if err := serveStatus(ctx, at.config.StatusAddr, provider.StatusHandler(at.config.StatusToken)); err != nil {
+			log.Printf("❌ [%s] %v", at.name, err)
+		}
+	}()
+	log.Printf("✓ [%s] 状态接口: http://%s/status", at.name, at.config.StatusAddr)
+}
+
+// checkDataFreshness 余额与持仓数据超过MaxDataStaleness时返回错误（未配置或交易器不报告新鲜度时不检查）
+func (at *AutoTrader) checkDataFreshness() error {
+	if at.config.MaxDataStaleness <= 0 {
+		return nil
+	}
+	reporter, ok := at.instrumented.Trader.(CacheFreshnessReporter)
+	if !ok {
+		return nil
+	}
+	if staleness := reporter.Staleness(); staleness > at.config.MaxDataStaleness {
+		if last := reporter.LastRefreshed(); !last.IsZero() {
+			return fmt.Errorf("账户数据已过期（%v 前刷新，上限 %v），本周期不交易", staleness.Round(time.Second), at.config.MaxDataStaleness)
+		}
+		return fmt.Errorf("账户数据尚未刷新，本周期不交易")
+	}
+	return nil
+}
+
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
 	at.callCount++
+	at.instrumented.SetCorrelationID(fmt.Sprintf("%s-cycle-%d", at.id, at.callCount))
 
-	log.Printf("\n" + strings.Repeat("=", 70))
-	log.Printf("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
-	log.Printf(strings.Repeat("=", 70))
+	log.Print("\n" + strings.Repeat("=", 70))
+	log.Printf("⏰ %s - AI决策周期 #%d", at.clock.Now().Format("2006-01-02 15:04:05"), at.callCount)
+	log.Print(strings.Repeat("=", 70))
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
@@ -268,19 +573,20 @@ func (at *AutoTrader) runCycle() error {
 	}
 
 	// 1. 检查是否需要停止交易
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
-		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
+	if at.clock.Now().Before(at.stopUntil) {
+		remaining := at.stopUntil.Sub(at.clock.Now())
+		log.Print(msg("log_risk_paused", remaining.Minutes()))
+		at.symbolStates.HaltAll(msg("reason_risk_halt"), at.instrumented.CorrelationID())
 		record.Success = false
-		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
+		record.ErrorMessage = msg("risk_paused", remaining.Minutes())
 		at.decisionLogger.LogDecision(record)
 		return nil
 	}
 
 	// 2. 重置日盈亏（每天重置）
-	if time.Since(at.lastResetTime) > 24*time.Hour {
+	if at.clock.Since(at.lastResetTime) > 24*time.Hour {
 		at.dailyPnL = 0
-		at.lastResetTime = time.Now()
+		at.lastResetTime = at.clock.Now()
 		log.Println("📅 日盈亏已重置")
 	}
 
@@ -293,6 +599,14 @@ func (at *AutoTrader) runCycle() error {
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
 
+	// 数据过期时不交易（后台刷新持续失败时读取返回的是旧缓存）
+	if err := at.checkDataFreshness(); err != nil {
+		record.Success = false
+		record.ErrorMessage = err.Error()
+		at.decisionLogger.LogDecision(record)
+		return err
+	}
+
 	// 保存账户状态快照
 	record.AccountState = logger.AccountSnapshot{
 		TotalBalance:          ctx.Account.TotalEquity,
@@ -346,19 +660,19 @@ func (at *AutoTrader) runCycle() error {
 		// 打印系统提示词和AI思维链（即使有错误，也要输出以便调试）
 		if decision != nil {
 			if decision.SystemPrompt != "" {
-				log.Printf("\n" + strings.Repeat("=", 70))
+				log.Print("\n" + strings.Repeat("=", 70))
 				log.Printf("📋 系统提示词 [模板: %s] (错误情况)", at.systemPromptTemplate)
 				log.Println(strings.Repeat("=", 70))
 				log.Println(decision.SystemPrompt)
-				log.Printf(strings.Repeat("=", 70) + "\n")
+				log.Print(strings.Repeat("=", 70) + "\n")
 			}
 
 			if decision.CoTTrace != "" {
-				log.Printf("\n" + strings.Repeat("-", 70))
+				log.Print("\n" + strings.Repeat("-", 70))
 				log.Println("💭 AI思维链分析（错误情况）:")
 				log.Println(strings.Repeat("-", 70))
 				log.Println(decision.CoTTrace)
-				log.Printf(strings.Repeat("-", 70) + "\n")
+				log.Print(strings.Repeat("-", 70) + "\n")
 			}
 		}
 
@@ -401,6 +715,7 @@ func (at *AutoTrader) runCycle() error {
 	log.Println()
 
 	// 执行决策并记录结果
+	balanceExhausted := false // 开仓因余额不足失败后，本周期不再尝试其他开仓
 	for _, d := range sortedDecisions {
 		actionRecord := logger.DecisionAction{
 			Action:    d.Action,
@@ -408,19 +723,30 @@ func (at *AutoTrader) runCycle() error {
 			Quantity:  0,
 			Leverage:  d.Leverage,
 			Price:     0,
-			Timestamp: time.Now(),
+			Timestamp: at.clock.Now(),
 			Success:   false,
 		}
 
+		if balanceExhausted && (d.Action == "open_long" || d.Action == "open_short") {
+			log.Printf("⏭ 跳过 %s %s：本周期已因余额不足开仓失败", d.Symbol, d.Action)
+			actionRecord.Error = ErrInsufficientBalance.Error()
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏭ %s %s 跳过: %v", d.Symbol, d.Action, ErrInsufficientBalance))
+			record.Decisions = append(record.Decisions, actionRecord)
+			continue
+		}
+
 		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
 			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+			if errors.Is(err, ErrInsufficientBalance) {
+				balanceExhausted = true
+			}
 			actionRecord.Error = err.Error()
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
 		} else {
 			actionRecord.Success = true
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
 			// 成功执行后短暂延迟
-			time.Sleep(1 * time.Second)
+			at.clock.Sleep(1 * time.Second)
 		}
 
 		record.Decisions = append(record.Decisions, actionRecord)
@@ -436,16 +762,16 @@ func (at *AutoTrader) runCycle() error {
 
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
-	// 1. 获取账户信息
-	balance, err := at.trader.GetBalance()
+	// 1. 获取账户快照（余额与持仓并发查询）
+	snapshot, err := at.GetAccountSnapshot(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("获取账户余额失败: %w", err)
+		return nil, err
 	}
+	balance, positions := snapshot.Balance, snapshot.Positions
 
 	// 获取账户字段
 	totalWalletBalance := 0.0
 	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
 
 	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
 		totalWalletBalance = wallet
@@ -453,24 +779,20 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
 		totalUnrealizedProfit = unrealized
 	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	// 逐仓保证金与挂单冻结不能用于新开仓，按全仓可用保证金定量
+	availableBalance := crossAvailableBalance(balance)
 
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
 
-	// 2. 获取持仓信息
-	positions, err := at.trader.GetPositions()
-	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
-	}
-
+	// 2. 持仓信息
 	var positionInfos []decision.PositionInfo
 	totalMarginUsed := 0.0
 
 	// 当前持仓的key集合（用于清理已平仓的记录）
 	currentPositionKeys := make(map[string]bool)
+	heldSymbols := make(map[string]bool)
+	stateChanged := false
 
 	for _, pos := range positions {
 		symbol := pos["symbol"].(string)
@@ -497,15 +819,17 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		if lev, ok := pos["leverage"].(float64); ok {
 			leverage = int(lev)
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
-		totalMarginUsed += marginUsed
+		marginUsed := marginRequired(quantity, markPrice, leverage)
+		totalMarginUsed = sumFloat64(totalMarginUsed, marginUsed)
 
 		// 跟踪持仓首次出现时间
 		posKey := symbol + "_" + side
 		currentPositionKeys[posKey] = true
+		heldSymbols[symbol] = true
 		if _, exists := at.positionFirstSeenTime[posKey]; !exists {
 			// 新持仓，记录当前时间
-			at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+			at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
+			stateChanged = true
 		}
 		updateTime := at.positionFirstSeenTime[posKey]
 
@@ -528,8 +852,16 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	for key := range at.positionFirstSeenTime {
 		if !currentPositionKeys[key] {
 			delete(at.positionFirstSeenTime, key)
+			delete(at.protectiveOrders, key)
+			delete(at.marginAdded, key)
+			stateChanged = true
 		}
 	}
+	if stateChanged {
+		at.saveState()
+	}
+	at.symbolStates.Sync(heldSymbols, at.instrumented.CorrelationID())
+	at.checkMarginTopUps(positions, availableBalance)
 
 	// 3. 获取交易员的候选币种池
 	candidateCoins, err := at.getCandidateCoins()
@@ -560,8 +892,8 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 
 	// 6. 构建上下文
 	ctx := &decision.Context{
-		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
-		RuntimeMinutes:  int(time.Since(at.startTime).Minutes()),
+		CurrentTime:     at.clock.Now().Format("2006-01-02 15:04:05"),
+		RuntimeMinutes:  int(at.clock.Since(at.startTime).Minutes()),
 		CallCount:       at.callCount,
 		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
 		AltcoinLeverage: at.config.AltcoinLeverage, // 使用配置的杠杆倍数
@@ -586,13 +918,21 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	switch decision.Action {
 	case "open_long":
-		return at.executeOpenLongWithRecord(decision, actionRecord)
+		return at.withSymbolPhase(decision, PhaseEntering, func() error {
+			return at.executeOpenLongWithRecord(decision, actionRecord)
+		})
 	case "open_short":
-		return at.executeOpenShortWithRecord(decision, actionRecord)
+		return at.withSymbolPhase(decision, PhaseEntering, func() error {
+			return at.executeOpenShortWithRecord(decision, actionRecord)
+		})
 	case "close_long":
-		return at.executeCloseLongWithRecord(decision, actionRecord)
+		return at.withSymbolPhase(decision, PhaseExiting, func() error {
+			return at.executeCloseLongWithRecord(decision, actionRecord)
+		})
 	case "close_short":
-		return at.executeCloseShortWithRecord(decision, actionRecord)
+		return at.withSymbolPhase(decision, PhaseExiting, func() error {
+			return at.executeCloseShortWithRecord(decision, actionRecord)
+		})
 	case "hold", "wait":
 		// 无需执行，仅记录
 		return nil
@@ -601,18 +941,47 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 	}
 }
 
+// withSymbolPhase 在币种状态机中执行开仓/平仓：先进入 Entering/Exiting，
+// 成功后进入 Managing/Cooldown，失败时回到执行前的状态
+func (at *AutoTrader) withSymbolPhase(decision *decision.Decision, phase SymbolPhase, execute func() error) error {
+	if err := at.checkSymbolPause(decision.Symbol, phase == PhaseEntering); err != nil {
+		return err
+	}
+	cid := at.instrumented.CorrelationID()
+	prev := at.symbolStates.Phase(decision.Symbol)
+	if err := at.symbolStates.Transition(decision.Symbol, phase, decision.Action, cid); err != nil {
+		return err
+	}
+
+	err := execute()
+	switch {
+	case phase == PhaseEntering && err == nil:
+		at.symbolStates.Transition(decision.Symbol, PhaseManaging, decision.Action+" 成交", cid)
+	case phase == PhaseEntering && prev == PhaseManaging:
+		at.symbolStates.Transition(decision.Symbol, PhaseManaging, decision.Action+" 失败", cid)
+	case phase == PhaseEntering:
+		at.symbolStates.Transition(decision.Symbol, PhaseIdle, decision.Action+" 失败", cid)
+	case err == nil:
+		at.symbolStates.Transition(decision.Symbol, PhaseCooldown, decision.Action+" 成交", cid)
+	default:
+		at.symbolStates.Transition(decision.Symbol, PhaseManaging, decision.Action+" 失败", cid)
+	}
+	return err
+}
+
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
 func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📈 开多仓: %s", decision.Symbol)
 
+	// API错误率过高时拒绝开新仓
+	if at.errorMonitor.EntriesPaused() {
+		rate, _ := at.errorMonitor.Rate()
+		return fmt.Errorf("API错误率过高(%.1f%%)，暂停开新仓", rate)
+	}
+
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
-			}
-		}
+	if _, err := at.getPosition(decision.Symbol, PositionLong); err == nil {
+		return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
 	}
 
 	// 获取当前价格
@@ -622,7 +991,7 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	quantity := quantityForNotional(decision.PositionSizeUSD, marketData.CurrentPrice)
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
 
@@ -635,43 +1004,118 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	// 开仓
 	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
-		return err
+		// 下单超时结果未知：先对账，不盲目重试
+		if !IsOutcomeUnknown(err) || !at.reconcileUnknownOpen(decision.Symbol, PositionLong, err) {
+			return err
+		}
+		order = map[string]interface{}{}
 	}
 
-	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	// 记录订单ID与成交信息
+	recordOrderResult(actionRecord, order)
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	filled := at.filledQuantity(decision.Symbol, PositionLong, order, quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], filled)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
+	// 设置止损止盈（按实际成交数量，记录订单ID并保存状态）
+	at.placeProtectiveOrders(posKey, decision.Symbol, PositionLong, filled, decision.StopLoss, decision.TakeProfit)
+
+	return nil
+}
+
+// reconcileUnknownOpen 开仓请求超时后查询实际持仓，返回订单是否已成交
+// 已成交时调用方继续设置止损止盈，避免仓位失去保护
+func (at *AutoTrader) reconcileUnknownOpen(symbol string, side PositionSide, cause error) bool {
+	log.Printf("  ⚠ %s %s 开仓请求超时，结果未知，正在对账: %v", symbol, side, cause)
+
+	if inv, ok := at.instrumented.Trader.(CacheRefresher); ok {
+		inv.InvalidateCache()
+	}
+	if _, err := at.getPosition(symbol, side); err != nil {
+		if errors.Is(err, ErrPositionNotFound) {
+			log.Printf("  ✓ 对账确认 %s %s 未开仓", symbol, side)
+		} else {
+			log.Printf("  🚨 %s 对账失败，请手动确认是否已开仓: %v", symbol, err)
+		}
+		return false
+	}
+	log.Printf("  ✓ 对账确认 %s %s 已开仓", symbol, side)
+	return true
+}
+
+// getPosition 查询单个持仓（可选接口需要在原始交易器上判断）
+func (at *AutoTrader) getPosition(symbol string, side PositionSide) (*Position, error) {
+	if getter, ok := at.instrumented.Trader.(PositionGetter); ok {
+		return getter.GetPosition(symbol, side)
+	}
+	return findPosition(at.trader, symbol, side)
+}
+
+// filledQuantity 止损止盈使用的数量：部分成交或按最小下单量提高后，实际成交数量与请求数量不同
+// 优先使用下单结果中的成交数量，没有成交信息时（如超时后对账确认已开仓）使用交易所返回的持仓数量
+func (at *AutoTrader) filledQuantity(symbol string, side PositionSide, order map[string]interface{}, requested float64) float64 {
+	if filled, ok := order["filledQty"].(float64); ok && filled > 0 {
+		return filled
+	}
+	if pos, err := at.getPosition(symbol, side); err == nil && pos.Quantity > 0 {
+		return pos.Quantity
+	}
+	return requested
+}
+
+// placeProtectiveOrders 设置止损止盈，并按持仓记录交易所返回的订单ID
+func (at *AutoTrader) placeProtectiveOrders(posKey, symbol string, positionSide PositionSide, quantity, stopLoss, takeProfit float64) {
+	var ids ProtectiveOrderIDs
+	var err error
+	if ids.StopLoss, err = at.instrumented.PlaceStopLoss(symbol, positionSide, quantity, stopLoss); err != nil {
 		log.Printf("  ⚠ 设置止损失败: %v", err)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
+	if ids.TakeProfit, err = at.instrumented.PlaceTakeProfit(symbol, positionSide, quantity, takeProfit); err != nil {
 		log.Printf("  ⚠ 设置止盈失败: %v", err)
 	}
+	at.protectiveOrders[posKey] = ids
+	at.saveState()
+}
 
-	return nil
+// recordOrderResult 将下单结果写入决策记录：订单ID，以及交易所返回的成交均价与成交数量
+func recordOrderResult(actionRecord *logger.DecisionAction, order map[string]interface{}) {
+	switch id := order["orderId"].(type) {
+	case int64:
+		actionRecord.OrderID = id
+	case string:
+		if parsed, err := strconv.ParseInt(id, 10, 64); err == nil {
+			actionRecord.OrderID = parsed
+		}
+	}
+	if avgPrice, ok := order["avgPrice"].(float64); ok && avgPrice > 0 {
+		actionRecord.Price = avgPrice
+	}
+	if filledQty, ok := order["filledQty"].(float64); ok && filledQty > 0 {
+		actionRecord.Quantity = filledQty
+	}
+	if bump, ok := order["sizeBump"].(*SizeBump); ok && bump != nil {
+		actionRecord.RequestedQuantity = bump.RequestedQty
+		actionRecord.ExtraNotional = bump.ExtraNotional
+	}
 }
 
 // executeOpenShortWithRecord 执行开空仓并记录详细信息
 func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📉 开空仓: %s", decision.Symbol)
 
+	// API错误率过高时拒绝开新仓
+	if at.errorMonitor.EntriesPaused() {
+		rate, _ := at.errorMonitor.Rate()
+		return fmt.Errorf("API错误率过高(%.1f%%)，暂停开新仓", rate)
+	}
+
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
-			}
-		}
+	if _, err := at.getPosition(decision.Symbol, PositionShort); err == nil {
+		return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
 	}
 
 	// 获取当前价格
@@ -681,7 +1125,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	quantity := quantityForNotional(decision.PositionSizeUSD, marketData.CurrentPrice)
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
 
@@ -694,27 +1138,25 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	// 开仓
 	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
-		return err
+		// 下单超时结果未知：先对账，不盲目重试
+		if !IsOutcomeUnknown(err) || !at.reconcileUnknownOpen(decision.Symbol, PositionShort, err) {
+			return err
+		}
+		order = map[string]interface{}{}
 	}
 
-	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	// 记录订单ID与成交信息
+	recordOrderResult(actionRecord, order)
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	filled := at.filledQuantity(decision.Symbol, PositionShort, order, quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], filled)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
-	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
-	}
+	// 设置止损止盈（按实际成交数量，记录订单ID并保存状态）
+	at.placeProtectiveOrders(posKey, decision.Symbol, PositionShort, filled, decision.StopLoss, decision.TakeProfit)
 
 	return nil
 }
@@ -732,14 +1174,17 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 
 	// 平仓
 	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
+	if errors.Is(err, ErrPositionNotFound) {
+		// 持仓已被止损/止盈平掉，视为平仓完成
+		log.Printf("  ✓ %s 已无多仓，无需平仓", decision.Symbol)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	// 记录订单ID与成交信息
+	recordOrderResult(actionRecord, order)
 
 	log.Printf("  ✓ 平仓成功")
 	return nil
@@ -758,14 +1203,17 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 
 	// 平仓
 	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
+	if errors.Is(err, ErrPositionNotFound) {
+		// 持仓已被止损/止盈平掉，视为平仓完成
+		log.Printf("  ✓ %s 已无空仓，无需平仓", decision.Symbol)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	// 记录订单ID与成交信息
+	recordOrderResult(actionRecord, order)
 
 	log.Printf("  ✓ 平仓成功")
 	return nil
@@ -781,6 +1229,16 @@ func (at *AutoTrader) GetName() string {
 	return at.name
 }
 
+// Trader 返回经过调用监控与审计包装的交易器（外部下单应使用它，与机器人共用同一条链路）
+func (at *AutoTrader) Trader() Trader {
+	return at.instrumented
+}
+
+// AddTradeEventSink 追加交易事件接收者
+func (at *AutoTrader) AddTradeEventSink(sink TradeEventSink) {
+	at.instrumented.AddTradeEventSink(sink)
+}
+
 // GetAIModel 获取AI模型
 func (at *AutoTrader) GetAIModel() string {
 	return at.aiModel
@@ -823,29 +1281,67 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
-	return map[string]interface{}{
+	status := map[string]interface{}{
 		"trader_id":       at.id,
 		"trader_name":     at.name,
 		"ai_model":        at.aiModel,
 		"exchange":        at.exchange,
 		"is_running":      at.isRunning,
 		"start_time":      at.startTime.Format(time.RFC3339),
-		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
+		"runtime_minutes": int(at.clock.Since(at.startTime).Minutes()),
 		"call_count":      at.callCount,
 		"initial_balance": at.initialBalance,
 		"scan_interval":   at.config.ScanInterval.String(),
 		"stop_until":      at.stopUntil.Format(time.RFC3339),
 		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
 		"ai_provider":     aiProvider,
+		"api_error_rate":  at.errorMonitor.Status(),
+		"symbols":         at.symbolStates.Status(),
+		"paused_symbols":  at.GetPausedSymbols(),
+	}
+	if reporter, ok := at.instrumented.Trader.(OrderTransportReporter); ok {
+		status["order_transport"] = reporter.OrderTransportStats()
+	}
+	if reporter, ok := at.instrumented.Trader.(CacheStatsReporter); ok {
+		status["cache_stats"] = reporter.CacheStats()
+	}
+	if reporter, ok := at.instrumented.Trader.(RateLimitReporter); ok {
+		status["rate_limits"] = reporter.RateLimitStats()
 	}
+	if reporter, ok := at.instrumented.Trader.(CacheFreshnessReporter); ok {
+		if last := reporter.LastRefreshed(); !last.IsZero() {
+			status["data_refreshed_at"] = last.Format(time.RFC3339)
+			status["data_staleness_seconds"] = reporter.Staleness().Seconds()
+		}
+	}
+	if snapshot, err := at.GetAccountSnapshot(context.Background()); err != nil {
+		status["account_error"] = err.Error()
+	} else {
+		wallet, _ := snapshot.Balance["totalWalletBalance"].(float64)
+		unrealized, _ := snapshot.Balance["totalUnrealizedProfit"].(float64)
+		status["account"] = map[string]interface{}{
+			"total_equity":   sumFloat64(wallet, unrealized),
+			"position_count": len(snapshot.Positions),
+			"timestamp":      snapshot.Timestamp.Format(time.RFC3339),
+			"skew_ms":        snapshot.Skew.Milliseconds(),
+			"skewed":         snapshot.Skewed,
+		}
+	}
+	return status
+}
+
+// GetSymbolStatus 获取各币种的交易生命周期状态
+func (at *AutoTrader) GetSymbolStatus() []SymbolStatus {
+	return at.symbolStates.Status()
 }
 
 // GetAccountInfo 获取账户信息（用于API）
 func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
-	balance, err := at.trader.GetBalance()
+	snapshot, err := at.GetAccountSnapshot(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("获取余额失败: %w", err)
+		return nil, err
 	}
+	balance, positions := snapshot.Balance, snapshot.Positions
 
 	// 获取账户字段
 	totalWalletBalance := 0.0
@@ -861,16 +1357,12 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	if avail, ok := balance["availableBalance"].(float64); ok {
 		availableBalance = avail
 	}
+	breakdown := NewMarginBreakdown(balance, positions)
 
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
 
-	// 获取持仓计算总保证金
-	positions, err := at.trader.GetPositions()
-	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
-	}
-
+	// 根据持仓计算总保证金
 	totalMarginUsed := 0.0
 	totalUnrealizedPnL := 0.0
 	for _, pos := range positions {
@@ -880,14 +1372,14 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 			quantity = -quantity
 		}
 		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		totalUnrealizedPnL += unrealizedPnl
+		totalUnrealizedPnL = sumFloat64(totalUnrealizedPnL, unrealizedPnl)
 
 		leverage := 10
 		if lev, ok := pos["leverage"].(float64); ok {
 			leverage = int(lev)
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
-		totalMarginUsed += marginUsed
+		marginUsed := marginRequired(quantity, markPrice, leverage)
+		totalMarginUsed = sumFloat64(totalMarginUsed, marginUsed)
 	}
 
 	totalPnL := totalEquity - at.initialBalance
@@ -908,6 +1400,12 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"unrealized_profit": totalUnrealizedProfit, // 未实现盈亏（从API）
 		"available_balance": availableBalance,      // 可用余额
 
+		// 全仓/逐仓拆分
+		"cross_available_balance": breakdown.CrossAvailable, // 全仓可用保证金（开仓定量使用）
+		"isolated_equity":         breakdown.IsolatedEquity, // 逐仓持仓占用的权益
+		"frozen_in_orders":        breakdown.FrozenInOrders, // 挂单冻结
+		"isolated_positions":      breakdown.Isolated,       // 各逐仓持仓的保证金
+
 		// 盈亏统计
 		"total_pnl":            totalPnL,           // 总盈亏 = equity - initial
 		"total_pnl_pct":        totalPnLPct,        // 总盈亏百分比
@@ -948,7 +1446,7 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 		}
 
 		// 计算占用保证金
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		marginUsed := marginRequired(quantity, markPrice, leverage)
 
 		// 计算盈亏百分比（基于保证金）
 		// 收益率 = 未实现盈亏 / 保证金 × 100%
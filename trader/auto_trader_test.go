@@ -0,0 +1,78 @@
+package trader
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// protectiveRecorder 记录止损止盈数量的模拟盘交易器
+type protectiveRecorder struct {
+	*PaperTrader
+	stopLossQty   []float64
+	takeProfitQty []float64
+}
+
+func (r *protectiveRecorder) PlaceStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) (string, error) {
+	r.stopLossQty = append(r.stopLossQty, quantity)
+	return "sl", nil
+}
+
+func (r *protectiveRecorder) PlaceTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) (string, error) {
+	r.takeProfitQty = append(r.takeProfitQty, quantity)
+	return "tp", nil
+}
+
+// TestProtectiveOrdersSizedFromFill 止损止盈按实际成交数量设置：部分成交或按最小下单量提高后与请求数量不同，
+// 下单结果没有成交信息时（超时后对账确认已开仓）按交易所的持仓数量设置
+func TestProtectiveOrdersSizedFromFill(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		order    map[string]interface{}
+		position float64 // 交易所已有的持仓数量（0表示无持仓）
+		want     float64
+	}{
+		{"partial fill", map[string]interface{}{"orderId": "1", "filledQty": 0.06}, 0.06, 0.06},
+		{"min size bump", map[string]interface{}{"orderId": "2", "filledQty": 0.12}, 0.12, 0.12},
+		{"unknown outcome reconciled", map[string]interface{}{}, 0.08, 0.08},
+		{"no fill information", map[string]interface{}{"orderId": "3"}, 0, 0.1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			paper, err := NewPaperTrader(NewFixedPriceSource(map[string]float64{"BTCUSDT": 50000}), PaperConfig{InitialBalance: 10000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.position > 0 {
+				if _, err := paper.OpenLong("BTCUSDT", tc.position, 5); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rec := &protectiveRecorder{PaperTrader: paper}
+			at, err := NewAutoTrader(AutoTraderConfig{
+				ID:             "protective_test",
+				Name:           "protective_test",
+				Exchange:       "paper",
+				Trader:         rec,
+				DemoTrading:    true,
+				InitialBalance: 10000,
+				StateFilePath:  filepath.Join(t.TempDir(), "state.json"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			filled := at.filledQuantity("BTCUSDT", PositionLong, tc.order, 0.1)
+			at.placeProtectiveOrders("BTCUSDT_long", "BTCUSDT", PositionLong, filled, 45000, 60000)
+
+			if len(rec.stopLossQty) != 1 || rec.stopLossQty[0] != tc.want {
+				t.Errorf("止损数量 = %v, want %v", rec.stopLossQty, tc.want)
+			}
+			if len(rec.takeProfitQty) != 1 || rec.takeProfitQty[0] != tc.want {
+				t.Errorf("止盈数量 = %v, want %v", rec.takeProfitQty, tc.want)
+			}
+			if got := at.protectiveOrders["BTCUSDT_long"]; got != (ProtectiveOrderIDs{StopLoss: "sl", TakeProfit: "tp"}) {
+				t.Errorf("止损止盈ID = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"context"
+	"time"
+)
+
+// Balance 账户余额（金额为USD）
+type Balance struct {
+	TotalWalletBalance    float64                    `json:"total_wallet_balance"`
+	AvailableBalance      float64                    `json:"available_balance"`
+	TotalUnrealizedProfit float64                    `json:"total_unrealized_profit"`
+	CrossAvailableBalance float64                    `json:"cross_available_balance"` // 全仓可用保证金
+	IsolatedEquity        float64                    `json:"isolated_equity"`         // 逐仓持仓占用的权益
+	FrozenInOrders        float64                    `json:"frozen_in_orders"`        // 挂单冻结
+	Currencies            map[string]CurrencyBalance `json:"currencies,omitempty"`    // 各币种权益（key: 币种）
+	Stale                 bool                       `json:"stale,omitempty"`         // 最新数据异常，返回的是上次的有效余额
+	StaleSince            time.Time                  `json:"stale_since,omitzero"`    // 过期余额的获取时间
+}
+
+// CurrencyBalance 单个币种的权益（币本位合约以结算币种计价）
+type CurrencyBalance struct {
+	Equity         float64 `json:"equity"`
+	EquityUSD      float64 `json:"equity_usd"`
+	Available      float64 `json:"available"`
+	UnrealizedPnL  float64 `json:"unrealized_pnl"`
+	IsolatedEquity float64 `json:"isolated_equity"`
+	OrderFrozen    float64 `json:"order_frozen"`
+}
+
+// Map 转换为Trader接口使用的余额map
+func (b *Balance) Map() map[string]interface{} {
+	result := map[string]interface{}{
+		"totalWalletBalance":    b.TotalWalletBalance,
+		"availableBalance":      b.AvailableBalance,
+		"totalUnrealizedProfit": b.TotalUnrealizedProfit,
+		"crossAvailableBalance": b.CrossAvailableBalance,
+		"isolatedEquity":        b.IsolatedEquity,
+		"frozenInOrders":        b.FrozenInOrders,
+	}
+	if b.Currencies != nil {
+		currencies := make(map[string]interface{}, len(b.Currencies))
+		for ccy, c := range b.Currencies {
+			currencies[ccy] = map[string]interface{}{
+				"equity":      c.Equity,
+				"equityUsd":   c.EquityUSD,
+				"available":   c.Available,
+				"upl":         c.UnrealizedPnL,
+				"isolatedEq":  c.IsolatedEquity,
+				"orderFrozen": c.OrderFrozen,
+			}
+		}
+		result["currencies"] = currencies
+	}
+	if b.Stale {
+		result["stale"] = true
+		result["staleSince"] = b.StaleSince
+	}
+	return result
+}
+
+// balanceFromMap 将GetBalance返回的余额map转换为Balance（未提供全仓可用时使用 availableBalance）
+func balanceFromMap(balance map[string]interface{}) *Balance {
+	b := &Balance{}
+	b.TotalWalletBalance, _ = balance["totalWalletBalance"].(float64)
+	b.AvailableBalance, _ = balance["availableBalance"].(float64)
+	b.TotalUnrealizedProfit, _ = balance["totalUnrealizedProfit"].(float64)
+	breakdown := NewMarginBreakdown(balance, nil)
+	b.CrossAvailableBalance = breakdown.CrossAvailable
+	b.IsolatedEquity = breakdown.IsolatedEquity
+	b.FrozenInOrders = breakdown.FrozenInOrders
+	b.Stale, _ = balance["stale"].(bool)
+	b.StaleSince, _ = balance["staleSince"].(time.Time)
+	return b
+}
+
+// TypedTrader 可选接口：直接返回结构体的交易器（不需要对map做类型断言）
+// Trader接口的map返回值由这些结构体的Map()生成，两者内容一致
+type TypedTrader interface {
+	Balance(ctx context.Context) (*Balance, error)
+	Positions(ctx context.Context) ([]*Position, error)
+	OpenLongOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error)
+	OpenShortOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error)
+	CloseLongOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error)
+	CloseShortOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error)
+}
+
+// GetTypedBalance 获取账户余额结构体；不支持TypedTrader的交易器从余额map转换
+func GetTypedBalance(ctx context.Context, t Trader) (*Balance, error) {
+	if typed, ok := t.(TypedTrader); ok {
+		return typed.Balance(ctx)
+	}
+	balance, err := t.GetBalance()
+	if err != nil {
+		return nil, err
+	}
+	return balanceFromMap(balance), nil
+}
+
+// GetTypedPositions 获取持仓结构体；不支持TypedTrader的交易器从持仓map转换
+func GetTypedPositions(ctx context.Context, t Trader) ([]*Position, error) {
+	if typed, ok := t.(TypedTrader); ok {
+		return typed.Positions(ctx)
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Position, 0, len(positions))
+	for _, pos := range positions {
+		result = append(result, positionFromMap(pos))
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestBinanceBalanceMalformed 余额字段为空或无法解析时返回ErrMalformedBalance，不把0当作真实余额
+func TestBinanceBalanceMalformed(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		account   string
+		malformed bool
+	}{
+		{"valid", `{"totalWalletBalance":"1000.5","availableBalance":"900","totalUnrealizedProfit":"-3.2","totalOpenOrderInitialMargin":"10","positions":[]}`, false},
+		{"missing optional frozen margin", `{"totalWalletBalance":"1000","availableBalance":"900","totalUnrealizedProfit":"0","positions":[]}`, false},
+		{"unparseable wallet", `{"totalWalletBalance":"abc","availableBalance":"900","totalUnrealizedProfit":"0","positions":[]}`, true},
+		{"empty available", `{"totalWalletBalance":"1000","availableBalance":"","totalUnrealizedProfit":"0","positions":[]}`, true},
+		{"missing unrealized", `{"totalWalletBalance":"1000","availableBalance":"900","positions":[]}`, true},
+		{"unparseable isolated wallet", `{"totalWalletBalance":"1000","availableBalance":"900","totalUnrealizedProfit":"0","positions":[{"symbol":"BTCUSDT","isolated":true,"isolatedWallet":"x"}]}`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeBinance(t)
+			f.reply("GET /fapi/v2/account", tc.account)
+			balance, err := f.trader(t).GetBalance()
+			if got := errors.Is(err, ErrMalformedBalance); got != tc.malformed {
+				t.Fatalf("GetBalance = %v, %v, want ErrMalformedBalance %v", balance, err, tc.malformed)
+			}
+			if tc.malformed {
+				if balance != nil {
+					t.Errorf("异常余额不应返回结果: %v", balance)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance["availableBalance"] != 900.0 {
+				t.Errorf("availableBalance = %v, want 900", balance["availableBalance"])
+			}
+		})
+	}
+}
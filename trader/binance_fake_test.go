@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeBinance 按 "METHOD path" 应答的币安合约REST假服务器，
+// 未注册的GET接口返回空数组，其他接口返回空对象
+type fakeBinance struct {
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	routes map[string]string
+	counts map[string]int
+}
+
+func newFakeBinance(t *testing.T) *fakeBinance {
+	t.Helper()
+	f := &fakeBinance{routes: make(map[string]string), counts: make(map[string]int)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeBinance) serve(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	f.mu.Lock()
+	f.counts[route]++
+	body, ok := f.routes[route]
+	f.mu.Unlock()
+	if !ok {
+		body = "{}"
+		if r.Method == http.MethodGet {
+			body = "[]"
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, body)
+}
+
+// reply 设置接口的响应体
+func (f *fakeBinance) reply(route, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[route] = body
+}
+
+// calls 接口被请求的次数
+func (f *fakeBinance) calls(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[route]
+}
+
+// trader 连接假服务器的币安合约交易器
+func (f *fakeBinance) trader(t *testing.T) *FuturesTrader {
+	t.Helper()
+	tr := NewFuturesTrader("key", "secret")
+	tr.client.BaseURL = f.srv.URL
+	return tr
+}
@@ -2,13 +2,19 @@ package trader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"nofx/clock"
+
+	"github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
 )
 
 // FuturesTrader 币安合约交易器
@@ -27,6 +33,29 @@ type FuturesTrader struct {
 
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
+
+	// 缓存过期时并发的余额/持仓查询只请求一次API
+	balanceFlight   flightGroup[map[string]interface{}]
+	positionsFlight flightGroup[[]map[string]interface{}]
+
+	// 各缓存的命中统计
+	cacheStats struct {
+		balance, positions cacheCounter
+	}
+
+	// 各类调用的超时时间
+	timeouts TimeoutConfig
+
+	// 交易规则缓存（LOT_SIZE/PRICE_FILTER，见symbolRules）
+	rulesCache   map[string]*binanceSymbolRules
+	rulesFetched time.Time
+	rulesMu      sync.Mutex
+
+	// 杠杆修改后的冷却期与已确认的杠杆（按币种）
+	leverage *leverageCooldown
+
+	// 时间源（缓存有效期与杠杆冷却期，测试中替换为假时钟）
+	clock clock.Clock
 }
 
 // NewFuturesTrader 创建合约交易器
@@ -35,33 +64,145 @@ func NewFuturesTrader(apiKey, secretKey string) *FuturesTrader {
 	return &FuturesTrader{
 		client:        client,
 		cacheDuration: 15 * time.Second, // 15秒缓存
+		timeouts:      DefaultTimeoutConfig(),
+		leverage:      newLeverageCooldown(binanceLeverageCooldown),
+		clock:         clock.Real(),
 	}
 }
 
+// SetTimeouts 设置各类调用的超时时间
+func (t *FuturesTrader) SetTimeouts(cfg TimeoutConfig) {
+	t.timeouts = cfg
+}
+
+// SetClock 替换时间源（测试中注入假时钟）
+func (t *FuturesTrader) SetClock(c clock.Clock) {
+	t.clock = clock.OrReal(c)
+}
+
+// InvalidateCache 清除余额和持仓缓存，下次查询直接请求API
+func (t *FuturesTrader) InvalidateCache() {
+	t.balanceCacheMutex.Lock()
+	t.cachedBalance = nil
+	t.balanceCacheMutex.Unlock()
+
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = nil
+	t.positionsCacheMutex.Unlock()
+}
+
+// RefreshBalance 跳过缓存直接查询账户余额（结果同时更新缓存）
+func (t *FuturesTrader) RefreshBalance() (map[string]interface{}, error) {
+	t.cacheStats.balance.miss()
+	// 并发未命中只请求一次，其余调用共享结果
+	result, err, _ := t.balanceFlight.Do("balance", func() (map[string]interface{}, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.balanceCacheMutex.RLock()
+		if t.cachedBalance != nil && t.clock.Since(t.balanceCacheTime) < t.cacheDuration {
+			defer t.balanceCacheMutex.RUnlock()
+			return t.cachedBalance, nil
+		}
+		t.balanceCacheMutex.RUnlock()
+		result, err := t.fetchBalance()
+		t.cacheStats.balance.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	return result, err
+}
+
+// RefreshPositions 跳过缓存直接查询持仓（结果同时更新缓存）
+func (t *FuturesTrader) RefreshPositions() ([]map[string]interface{}, error) {
+	t.cacheStats.positions.miss()
+	// 并发未命中只请求一次，其余调用共享结果
+	result, err, _ := t.positionsFlight.Do("positions", func() ([]map[string]interface{}, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.positionsCacheMutex.RLock()
+		if t.cachedPositions != nil && t.clock.Since(t.positionsCacheTime) < t.cacheDuration {
+			defer t.positionsCacheMutex.RUnlock()
+			return t.cachedPositions, nil
+		}
+		t.positionsCacheMutex.RUnlock()
+		result, err := t.fetchPositions()
+		t.cacheStats.positions.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	return result, err
+}
+
+// CacheStats 返回余额与持仓缓存的统计
+func (t *FuturesTrader) CacheStats() []CacheStat {
+	now := t.clock.Now()
+	return []CacheStat{
+		t.cacheStats.balance.stat("balance", now),
+		t.cacheStats.positions.stat("positions", now),
+	}
+}
+
+// opContext 创建对应调用类别的超时context
+func (t *FuturesTrader) opContext(class OperationClass) (context.Context, context.CancelFunc) {
+	return timeoutContext(t.timeouts, class)
+}
+
 // GetBalance 获取账户余额（带缓存）
 func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	// 先检查缓存是否有效
 	t.balanceCacheMutex.RLock()
-	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
-		cacheAge := time.Since(t.balanceCacheTime)
+	if t.cachedBalance != nil && t.clock.Since(t.balanceCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
 		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.cacheStats.balance.hit()
 		return t.cachedBalance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
+	t.cacheStats.balance.miss()
+
+	result, err := t.fetchBalance()
+	t.cacheStats.balance.refreshed(t.clock.Now(), err)
+	return result, err
+}
 
-	// 缓存过期或不存在，调用API
+// fetchBalance 调用API获取账户余额并更新缓存
+func (t *FuturesTrader) fetchBalance() (map[string]interface{}, error) {
 	log.Printf("🔄 缓存过期，正在调用币安API获取账户余额...")
-	account, err := t.client.NewGetAccountService().Do(context.Background())
+	ctx, cancel := t.opContext(OpPrivateRead)
+	defer cancel()
+	account, err := t.client.NewGetAccountService().Do(ctx)
+	err = timeoutError(ctx, OpPrivateRead, "GetBalance", err)
 	if err != nil {
 		log.Printf("❌ 币安API调用失败: %v", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
-	result := make(map[string]interface{})
-	result["totalWalletBalance"], _ = strconv.ParseFloat(account.TotalWalletBalance, 64)
-	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
-	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
+	// 解析失败时不能把0当作真实余额（会被风控误判为爆仓），直接返回 ErrMalformedBalance
+	wallet, errWallet := parseBalanceField("totalWalletBalance", account.TotalWalletBalance)
+	available, errAvail := parseBalanceField("availableBalance", account.AvailableBalance)
+	unrealized, errUpl := parseBalanceField("totalUnrealizedProfit", account.TotalUnrealizedProfit)
+	frozen, errFrozen := parseOptionalBalanceField("totalOpenOrderInitialMargin", account.TotalOpenOrderInitialMargin)
+	errs := []error{errWallet, errAvail, errUpl, errFrozen}
+
+	// 全仓/逐仓拆分：币安的availableBalance已扣除逐仓保证金与挂单占用，即全仓可用
+	isolatedEquity := 0.0
+	for _, pos := range account.Positions {
+		if pos.Isolated {
+			isoWallet, err := parseOptionalBalanceField("isolatedWallet", pos.IsolatedWallet)
+			errs = append(errs, err)
+			isolatedEquity = sumFloat64(isolatedEquity, isoWallet)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("❌ 币安余额数据异常: %v", err)
+		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"totalWalletBalance":    wallet,
+		"availableBalance":      available,
+		"totalUnrealizedProfit": unrealized,
+		"crossAvailableBalance": available,
+		"isolatedEquity":        isolatedEquity,
+		"frozenInOrders":        frozen,
+	}
 
 	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
 		account.TotalWalletBalance,
@@ -71,7 +212,7 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
 	t.cachedBalance = result
-	t.balanceCacheTime = time.Now()
+	t.balanceCacheTime = t.clock.Now()
 	t.balanceCacheMutex.Unlock()
 
 	return result, nil
@@ -81,17 +222,28 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	// 先检查缓存是否有效
 	t.positionsCacheMutex.RLock()
-	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
-		cacheAge := time.Since(t.positionsCacheTime)
+	if t.cachedPositions != nil && t.clock.Since(t.positionsCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
 		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.cacheStats.positions.hit()
 		return t.cachedPositions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
+	t.cacheStats.positions.miss()
 
-	// 缓存过期或不存在，调用API
+	result, err := t.fetchPositions()
+	t.cacheStats.positions.refreshed(t.clock.Now(), err)
+	return result, err
+}
+
+// fetchPositions 调用API获取持仓信息并更新缓存
+func (t *FuturesTrader) fetchPositions() ([]map[string]interface{}, error) {
 	log.Printf("🔄 缓存过期，正在调用币安API获取持仓信息...")
-	positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
+	ctx, cancel := t.opContext(OpPrivateRead)
+	defer cancel()
+	positions, err := t.client.NewGetPositionRiskService().Do(ctx)
+	err = timeoutError(ctx, OpPrivateRead, "GetPositions", err)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
@@ -109,8 +261,12 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 		posMap["entryPrice"], _ = strconv.ParseFloat(pos.EntryPrice, 64)
 		posMap["markPrice"], _ = strconv.ParseFloat(pos.MarkPrice, 64)
 		posMap["unRealizedProfit"], _ = strconv.ParseFloat(pos.UnRealizedProfit, 64)
-		posMap["leverage"], _ = strconv.ParseFloat(pos.Leverage, 64)
+		leverage, _ := strconv.ParseFloat(pos.Leverage, 64)
+		posMap["leverage"] = leverage
+		t.leverage.observe(pos.Symbol, int(leverage))
 		posMap["liquidationPrice"], _ = strconv.ParseFloat(pos.LiquidationPrice, 64)
+		posMap["marginMode"] = strings.ToLower(pos.MarginType)
+		posMap["isolatedMargin"], _ = strconv.ParseFloat(pos.IsolatedMargin, 64)
 
 		// 判断方向
 		if posAmt > 0 {
@@ -125,7 +281,7 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	// 更新缓存
 	t.positionsCacheMutex.Lock()
 	t.cachedPositions = result
-	t.positionsCacheTime = time.Now()
+	t.positionsCacheTime = t.clock.Now()
 	t.positionsCacheMutex.Unlock()
 
 	return result, nil
@@ -133,6 +289,7 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 
 // SetMarginMode 设置仓位模式
 func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	symbol = binanceSymbol(symbol)
 	var marginType futures.MarginType
 	if isCrossMargin {
 		marginType = futures.MarginTypeCrossed
@@ -141,10 +298,13 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	}
 
 	// 尝试设置仓位模式
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	err := t.client.NewChangeMarginTypeService().
 		Symbol(symbol).
 		MarginType(marginType).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "SetMarginMode", err)
 
 	marginModeStr := "全仓"
 	if !isCrossMargin {
@@ -157,6 +317,22 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 			log.Printf("  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
 			return nil
 		}
+		// 有挂单时需要先撤单，保护单在切换后恢复
+		if contains(err.Error(), "Margin type cannot be changed if there exists open orders") {
+			err = t.WithProtectiveOrdersSuspended(symbol, func() error {
+				ctx, cancel := t.opContext(OpMutation)
+				defer cancel()
+				err := t.client.NewChangeMarginTypeService().
+					Symbol(symbol).
+					MarginType(marginType).
+					Do(ctx)
+				return timeoutError(ctx, OpMutation, "SetMarginMode", err)
+			})
+			if err == nil {
+				log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
+				return nil
+			}
+		}
 		// 如果有持仓，无法更改仓位模式，但不影响交易
 		if contains(err.Error(), "Margin type cannot be changed if there exists position") {
 			log.Printf("  ⚠️ %s 有持仓，无法更改仓位模式，继续使用当前模式", symbol)
@@ -171,61 +347,89 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	return nil
 }
 
-// SetLeverage 设置杠杆（智能判断+冷却期）
+// binanceLeverageCooldown 修改杠杆后同一币种下单前的等待时间（避免冷却期错误）
+const binanceLeverageCooldown = 5 * time.Second
+
+// SetLeverage 设置杠杆（已是目标杠杆时不请求交易所）
 func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
-	// 先尝试获取当前杠杆（从持仓信息）
+	return t.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，同一币种刚修改过杠杆时先等待冷却期结束（ctx结束时放弃等待）
+// 修改成功后不等待：只有该币种在冷却期内下单时才等待，不阻塞其他币种
+func (t *FuturesTrader) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	symbol = binanceSymbol(symbol)
+	if t.leverage.isConfirmed(symbol, leverage) {
+		return nil
+	}
+
+	// 先尝试获取当前杠杆（从持仓信息，多空任一方向）
 	currentLeverage := 0
-	positions, err := t.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == symbol {
-				if lev, ok := pos["leverage"].(float64); ok {
-					currentLeverage = int(lev)
-					break
-				}
-			}
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		if pos, err := t.GetPosition(symbol, side); err == nil {
+			currentLeverage = pos.Leverage
+			break
 		}
 	}
 
+	// 持仓报告的杠杆与已确认的不同（例如手动修改过），清除确认
+	if currentLeverage > 0 && currentLeverage != leverage {
+		t.leverage.forget(symbol)
+	}
+
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
 		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		t.leverage.confirm(symbol, leverage, false, t.clock.Now())
 		return nil
 	}
 
+	if err := t.leverage.wait(ctx, t.clock, symbol); err != nil {
+		return err
+	}
+
 	// 切换杠杆
-	_, err = t.client.NewChangeLeverageService().
+	opCtx, cancel := t.opContext(OpMutation)
+	defer cancel()
+	_, err := t.client.NewChangeLeverageService().
 		Symbol(symbol).
 		Leverage(leverage).
-		Do(context.Background())
+		Do(opCtx)
+	err = timeoutError(opCtx, OpMutation, "SetLeverage", err)
 
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
 			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			t.leverage.confirm(symbol, leverage, false, t.clock.Now())
 			return nil
 		}
+		t.leverage.forget(symbol)
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
 	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
-
-	// 切换杠杆后等待5秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待5秒冷却期...")
-	time.Sleep(5 * time.Second)
-
+	t.leverage.confirm(symbol, leverage, true, t.clock.Now())
 	return nil
 }
 
 // OpenLong 开多仓
 func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
-	// 先取消该币种的所有委托单（清理旧的止损止盈单）
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
-	}
+	return t.OpenLongContext(context.Background(), symbol, quantity, leverage)
+}
 
-	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+// OpenLongContext 同OpenLong，等待杠杆冷却期时ctx结束则放弃开仓并返回 ErrCanceled
+func (t *FuturesTrader) OpenLongContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	symbol = binanceSymbol(symbol)
+	// 先取消该币种的所有委托单（清理旧的止损止盈单）并设置杠杆
+	// 仍有持仓的保护单会在设置杠杆后恢复，即使设置失败也不会失去保护
+	if err := t.WithProtectiveOrdersSuspended(symbol, func() error {
+		return t.SetLeverageContext(ctx, symbol, leverage)
+	}); err != nil {
+		return nil, err
+	}
+	// 刚修改过杠杆时等待冷却期结束再下单
+	if err := t.leverage.wait(ctx, t.clock, symbol); err != nil {
 		return nil, err
 	}
 
@@ -236,15 +440,22 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 	if err != nil {
 		return nil, err
 	}
+	if err := t.checkOrderNotional(symbol, quantityStr); err != nil {
+		return nil, err
+	}
 
 	// 创建市价买入订单
+	opCtx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
-		Do(context.Background())
+		Do(opCtx)
+	err = timeoutError(opCtx, OpMutation, "OpenLong", err)
+	t.InvalidateCache() // 持仓与余额已变化（或结果未知），下次查询直接请求API
 
 	if err != nil {
 		return nil, fmt.Errorf("开多仓失败: %w", err)
@@ -262,13 +473,21 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 
 // OpenShort 开空仓
 func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
-	// 先取消该币种的所有委托单（清理旧的止损止盈单）
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
-	}
+	return t.OpenShortContext(context.Background(), symbol, quantity, leverage)
+}
 
-	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+// OpenShortContext 同OpenShort，等待杠杆冷却期时ctx结束则放弃开仓并返回 ErrCanceled
+func (t *FuturesTrader) OpenShortContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	symbol = binanceSymbol(symbol)
+	// 先取消该币种的所有委托单（清理旧的止损止盈单）并设置杠杆
+	// 仍有持仓的保护单会在设置杠杆后恢复，即使设置失败也不会失去保护
+	if err := t.WithProtectiveOrdersSuspended(symbol, func() error {
+		return t.SetLeverageContext(ctx, symbol, leverage)
+	}); err != nil {
+		return nil, err
+	}
+	// 刚修改过杠杆时等待冷却期结束再下单
+	if err := t.leverage.wait(ctx, t.clock, symbol); err != nil {
 		return nil, err
 	}
 
@@ -279,15 +498,22 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 	if err != nil {
 		return nil, err
 	}
+	if err := t.checkOrderNotional(symbol, quantityStr); err != nil {
+		return nil, err
+	}
 
 	// 创建市价卖出订单
+	opCtx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
-		Do(context.Background())
+		Do(opCtx)
+	err = timeoutError(opCtx, OpMutation, "OpenShort", err)
+	t.InvalidateCache() // 持仓与余额已变化（或结果未知），下次查询直接请求API
 
 	if err != nil {
 		return nil, fmt.Errorf("开空仓失败: %w", err)
@@ -303,25 +529,54 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 	return result, nil
 }
 
+// GetPosition 获取指定币种和方向的持仓（双向持仓模式下多空分别返回）
+func (t *FuturesTrader) GetPosition(symbol string, side PositionSide) (*Position, error) {
+	symbol = binanceSymbol(symbol)
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	return matchPosition(positions, symbol, side, func(pos map[string]interface{}) bool {
+		return pos["symbol"] == symbol
+	})
+}
+
+// checkOrderNotional 按格式化后的下单数量与当前价格检查单笔名义价值上限
+func (t *FuturesTrader) checkOrderNotional(symbol, quantityStr string) error {
+	if MaxOrderNotional(symbol) <= 0 {
+		return nil
+	}
+	quantity, err := strconv.ParseFloat(quantityStr, 64)
+	if err != nil {
+		return err
+	}
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return err
+	}
+	return checkOrderNotional(symbol, notionalValue(quantity, price))
+}
+
 // CloseLong 平多仓
 func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	symbol = binanceSymbol(symbol)
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
-				break
+		pos, err := t.GetPosition(symbol, PositionLong)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 	}
 
 	// 格式化数量
@@ -331,13 +586,17 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 	}
 
 	// 创建市价卖出订单（平多）
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "CloseLong", err)
+	t.InvalidateCache() // 持仓与余额已变化（或结果未知），下次查询直接请求API
 
 	if err != nil {
 		return nil, fmt.Errorf("平多仓失败: %w", err)
@@ -359,23 +618,21 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 
 // CloseShort 平空仓
 func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	symbol = binanceSymbol(symbol)
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				quantity = -pos["positionAmt"].(float64) // 空仓数量是负的，取绝对值
-				break
+		pos, err := t.GetPosition(symbol, PositionShort)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 	}
 
 	// 格式化数量
@@ -385,13 +642,17 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 	}
 
 	// 创建市价买入订单（平空）
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "CloseShort", err)
+	t.InvalidateCache() // 持仓与余额已变化（或结果未知），下次查询直接请求API
 
 	if err != nil {
 		return nil, fmt.Errorf("平空仓失败: %w", err)
@@ -413,9 +674,13 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 
 // CancelAllOrders 取消该币种的所有挂单
 func (t *FuturesTrader) CancelAllOrders(symbol string) error {
+	symbol = binanceSymbol(symbol)
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
 	err := t.client.NewCancelAllOpenOrdersService().
 		Symbol(symbol).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "CancelAllOrders", err)
 
 	if err != nil {
 		return fmt.Errorf("取消挂单失败: %w", err)
@@ -425,9 +690,102 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 	return nil
 }
 
+// GetOpenOrders 获取挂单（symbol为空表示全部币种）
+func (t *FuturesTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	service := t.client.NewListOpenOrdersService()
+	if symbol != "" {
+		service = service.Symbol(binanceSymbol(symbol))
+	}
+	ctx, cancel := t.opContext(OpPrivateRead)
+	defer cancel()
+	orders, err := service.Do(ctx)
+	err = timeoutError(ctx, OpPrivateRead, "GetOpenOrders", err)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(orders))
+	for _, order := range orders {
+		price, _ := strconv.ParseFloat(order.Price, 64)
+		stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+		quantity, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+		activatePrice, _ := strconv.ParseFloat(order.ActivatePrice, 64)
+		callbackRate, _ := strconv.ParseFloat(order.PriceRate, 64)
+		result = append(result, OpenOrder{
+			OrderID:       strconv.FormatInt(order.OrderID, 10),
+			Symbol:        order.Symbol,
+			Side:          string(order.Side),
+			PositionSide:  string(order.PositionSide),
+			Type:          string(order.Type),
+			Price:         price,
+			StopPrice:     stopPrice,
+			Quantity:      quantity,
+			ReduceOnly:    order.ReduceOnly || order.ClosePosition,
+			ClosePosition: order.ClosePosition,
+			ActivatePrice: activatePrice,
+			CallbackRate:  callbackRate,
+			CreateTime:    time.UnixMilli(order.Time),
+		})
+	}
+	return result, nil
+}
+
+// SnapshotProtectiveOrders 记录该币种保护现有持仓的止损/止盈/追踪止损单
+func (t *FuturesTrader) SnapshotProtectiveOrders(symbol string) (*ProtectiveOrderSnapshot, error) {
+	return snapshotProtectiveOrders(t, t, symbol)
+}
+
+// RestoreProtectiveOrders 按快照重新挂出保护单
+func (t *FuturesTrader) RestoreProtectiveOrders(snapshot *ProtectiveOrderSnapshot) error {
+	return restoreProtectiveOrders(t, snapshot, t.placeTrailingStop)
+}
+
+// WithProtectiveOrdersSuspended 撤销保护单后执行fn，无论成功与否都恢复保护单
+func (t *FuturesTrader) WithProtectiveOrdersSuspended(symbol string, fn func() error) error {
+	return withProtectiveOrdersSuspended(t, t, symbol, fn)
+}
+
+// placeTrailingStop 按快照中的参数重新挂出追踪止损单
+func (t *FuturesTrader) placeTrailingStop(order OpenOrder) error {
+	quantityStr, err := t.FormatQuantity(order.Symbol, order.Quantity)
+	if err != nil {
+		return err
+	}
+
+	service := t.client.NewCreateOrderService().
+		Symbol(order.Symbol).
+		Side(futures.SideType(order.Side)).
+		PositionSide(futures.PositionSideType(order.PositionSide)).
+		Type(futures.OrderTypeTrailingStopMarket).
+		Quantity(quantityStr).
+		CallbackRate(fmt.Sprintf("%.1f", order.CallbackRate))
+	if order.ActivatePrice > 0 {
+		activation, err := t.FormatPrice(order.Symbol, order.ActivatePrice, RoundNearest)
+		if err != nil {
+			return err
+		}
+		service = service.ActivationPrice(activation)
+	}
+	if order.PositionSide == "BOTH" || order.PositionSide == "" {
+		service = service.ReduceOnly(true)
+	}
+
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
+	_, err = service.Do(ctx)
+	if err = timeoutError(ctx, OpMutation, "placeTrailingStop", err); err != nil {
+		return fmt.Errorf("设置追踪止损失败: %w", err)
+	}
+	return nil
+}
+
 // GetMarketPrice 获取市场价格
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
-	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
+	symbol = binanceSymbol(symbol)
+	ctx, cancel := t.opContext(OpPublicRead)
+	defer cancel()
+	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(ctx)
+	err = timeoutError(ctx, OpPublicRead, "GetMarketPrice", err)
 	if err != nil {
 		return 0, fmt.Errorf("获取价格失败: %w", err)
 	}
@@ -452,160 +810,240 @@ func (t *FuturesTrader) CalculatePositionSize(balance, riskPercent, price float6
 	return quantity
 }
 
+// binanceCloseSides 止损止盈单的买卖方向与双向持仓的持仓方向
+func binanceCloseSides(positionSide PositionSide) (futures.SideType, futures.PositionSideType, error) {
+	switch positionSide {
+	case PositionLong:
+		return futures.SideTypeSell, futures.PositionSideTypeLong, nil
+	case PositionShort:
+		return futures.SideTypeBuy, futures.PositionSideTypeShort, nil
+	}
+	return "", "", fmt.Errorf("无效的持仓方向: %v", positionSide)
+}
+
 // SetStopLoss 设置止损单
-func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
-	var side futures.SideType
-	var posSide futures.PositionSideType
+func (t *FuturesTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	_, err := t.PlaceStopLoss(symbol, positionSide, quantity, stopPrice)
+	return err
+}
 
-	if positionSide == "LONG" {
-		side = futures.SideTypeSell
-		posSide = futures.PositionSideTypeLong
-	} else {
-		side = futures.SideTypeBuy
-		posSide = futures.PositionSideTypeShort
+// PlaceStopLoss 设置止损单并返回订单ID
+func (t *FuturesTrader) PlaceStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) (string, error) {
+	symbol = binanceSymbol(symbol)
+	side, posSide, err := binanceCloseSides(positionSide)
+	if err != nil {
+		return "", err
 	}
 
 	// 格式化数量
 	quantityStr, err := t.FormatQuantity(symbol, quantity)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	priceStr, err := t.FormatPrice(symbol, stopPrice, stopRounding(positionSide))
+	if err != nil {
+		return "", err
 	}
 
-	_, err = t.client.NewCreateOrderService().
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
+	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		PositionSide(posSide).
 		Type(futures.OrderTypeStopMarket).
-		StopPrice(fmt.Sprintf("%.8f", stopPrice)).
+		StopPrice(priceStr).
 		Quantity(quantityStr).
 		WorkingType(futures.WorkingTypeContractPrice).
 		ClosePosition(true).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "SetStopLoss", err)
 
 	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+		return "", fmt.Errorf("设置止损失败: %w", err)
 	}
 
-	log.Printf("  止损价设置: %.4f", stopPrice)
-	return nil
+	log.Printf("  止损价设置: %s", priceStr)
+	return strconv.FormatInt(order.OrderID, 10), nil
 }
 
 // SetTakeProfit 设置止盈单
-func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
-	var side futures.SideType
-	var posSide futures.PositionSideType
+func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	_, err := t.PlaceTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	return err
+}
 
-	if positionSide == "LONG" {
-		side = futures.SideTypeSell
-		posSide = futures.PositionSideTypeLong
-	} else {
-		side = futures.SideTypeBuy
-		posSide = futures.PositionSideTypeShort
+// PlaceTakeProfit 设置止盈单并返回订单ID
+func (t *FuturesTrader) PlaceTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) (string, error) {
+	symbol = binanceSymbol(symbol)
+	side, posSide, err := binanceCloseSides(positionSide)
+	if err != nil {
+		return "", err
 	}
 
 	// 格式化数量
 	quantityStr, err := t.FormatQuantity(symbol, quantity)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	priceStr, err := t.FormatPrice(symbol, takeProfitPrice, stopRounding(positionSide))
+	if err != nil {
+		return "", err
 	}
 
-	_, err = t.client.NewCreateOrderService().
+	ctx, cancel := t.opContext(OpMutation)
+	defer cancel()
+	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		PositionSide(posSide).
 		Type(futures.OrderTypeTakeProfitMarket).
-		StopPrice(fmt.Sprintf("%.8f", takeProfitPrice)).
+		StopPrice(priceStr).
 		Quantity(quantityStr).
 		WorkingType(futures.WorkingTypeContractPrice).
 		ClosePosition(true).
-		Do(context.Background())
+		Do(ctx)
+	err = timeoutError(ctx, OpMutation, "SetTakeProfit", err)
 
 	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
+		return "", fmt.Errorf("设置止盈失败: %w", err)
 	}
 
-	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
-	return nil
+	log.Printf("  止盈价设置: %s", priceStr)
+	return strconv.FormatInt(order.OrderID, 10), nil
 }
 
 // GetSymbolPrecision 获取交易对的数量精度
 func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
-	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	symbol = binanceSymbol(symbol)
+	stepSize, err := t.lotStepSize(symbol)
 	if err != nil {
-		return 0, fmt.Errorf("获取交易规则失败: %w", err)
+		return 0, err
 	}
-
-	for _, s := range exchangeInfo.Symbols {
-		if s.Symbol == symbol {
-			// 从LOT_SIZE filter获取精度
-			for _, filter := range s.Filters {
-				if filter["filterType"] == "LOT_SIZE" {
-					stepSize := filter["stepSize"].(string)
-					precision := calculatePrecision(stepSize)
-					log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
-					return precision, nil
-				}
-			}
-		}
+	if stepSize == "" {
+		log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
+		return 3, nil // 默认精度为3
 	}
+	precision := calculatePrecision(stepSize)
+	log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
+	return precision, nil
+}
 
-	log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
-	return 3, nil // 默认精度为3
+// lotStepSize 获取交易对LOT_SIZE的stepSize（交易所返回的十进制字符串，未找到时为空）
+func (t *FuturesTrader) lotStepSize(symbol string) (string, error) {
+	rules, err := t.symbolRules(binanceSymbol(symbol))
+	if err != nil || rules == nil {
+		return "", err
+	}
+	return rules.stepSize, nil
 }
 
-// calculatePrecision 从stepSize计算精度
-func calculatePrecision(stepSize string) int {
-	// 去除尾部的0
-	stepSize = trimTrailingZeros(stepSize)
-
-	// 查找小数点
-	dotIndex := -1
-	for i := 0; i < len(stepSize); i++ {
-		if stepSize[i] == '.' {
-			dotIndex = i
-			break
+// GetInstrumentLimits 获取交易对的交易规则与最大杠杆
+func (t *FuturesTrader) GetInstrumentLimits(symbol string) (*InstrumentLimits, error) {
+	symbol = binanceSymbol(symbol)
+	ctx, cancel := t.opContext(OpPublicRead)
+	defer cancel()
+	exchangeInfo, err := t.client.NewExchangeInfoService().Do(ctx)
+	err = timeoutError(ctx, OpPublicRead, "GetInstrumentLimits", err)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	var limits *InstrumentLimits
+	for i := range exchangeInfo.Symbols {
+		s := &exchangeInfo.Symbols[i]
+		if s.Symbol != symbol {
+			continue
+		}
+		limits = &InstrumentLimits{
+			Symbol:   symbol,
+			Tradable: s.Status == "TRADING",
+		}
+		if lot := s.LotSizeFilter(); lot != nil {
+			limits.MinQuantity, _ = strconv.ParseFloat(lot.MinQuantity, 64)
+			limits.MaxQuantity, _ = strconv.ParseFloat(lot.MaxQuantity, 64)
+		}
+		if notional := s.MinNotionalFilter(); notional != nil {
+			limits.MinNotional, _ = strconv.ParseFloat(notional.Notional, 64)
 		}
+		break
+	}
+	if limits == nil {
+		return nil, fmt.Errorf("交易对 %s 不存在", symbol)
 	}
 
-	// 如果没有小数点或小数点在最后，精度为0
-	if dotIndex == -1 || dotIndex == len(stepSize)-1 {
-		return 0
+	// 杠杆分层的第一档即为最大杠杆
+	ctx, cancel = t.opContext(OpPrivateRead)
+	defer cancel()
+	brackets, err := t.client.NewGetLeverageBracketService().Symbol(symbol).Do(ctx)
+	err = timeoutError(ctx, OpPrivateRead, "GetInstrumentLimits", err)
+	if err != nil {
+		return nil, fmt.Errorf("获取杠杆分层失败: %w", err)
+	}
+	for _, b := range brackets {
+		for _, bracket := range b.Brackets {
+			if bracket.InitialLeverage > limits.MaxLeverage {
+				limits.MaxLeverage = bracket.InitialLeverage
+			}
+		}
 	}
 
-	// 返回小数点后的位数
-	return len(stepSize) - dotIndex - 1
+	return limits, nil
 }
 
-// trimTrailingZeros 去除尾部的0
-func trimTrailingZeros(s string) string {
-	// 如果没有小数点，直接返回
-	if !stringContains(s, ".") {
-		return s
+// CheckPermissions 检查API密钥是否开启了合约交易权限
+func (t *FuturesTrader) CheckPermissions() ([]string, error) {
+	spot := binance.NewClient(t.client.APIKey, t.client.SecretKey)
+	ctx, cancel := t.opContext(OpPrivateRead)
+	defer cancel()
+	perm, err := spot.NewGetAPIKeyPermission().Do(ctx)
+	err = timeoutError(ctx, OpPrivateRead, "CheckPermissions", err)
+	if err != nil {
+		return nil, fmt.Errorf("查询API权限失败: %w", err)
 	}
-
-	// 从后向前遍历，去除尾部的0
-	for len(s) > 0 && s[len(s)-1] == '0' {
-		s = s[:len(s)-1]
+	if !perm.EnableReading {
+		return nil, fmt.Errorf("API密钥未开启读取权限")
+	}
+	if !perm.EnableFutures {
+		return nil, fmt.Errorf("API密钥未开启合约交易权限")
 	}
 
-	// 如果最后一位是小数点，也去掉
-	if len(s) > 0 && s[len(s)-1] == '.' {
-		s = s[:len(s)-1]
+	var warnings []string
+	if perm.EnableWithdrawals {
+		warnings = append(warnings, "API密钥开启了提现权限，建议关闭")
 	}
+	if !perm.IPRestrict {
+		warnings = append(warnings, "API密钥未设置IP白名单")
+	}
+	return warnings, nil
+}
 
-	return s
+// calculatePrecision 从stepSize计算精度（stepSize>=1时为0，无法解析时为0）
+func calculatePrecision(stepSize string) int {
+	step, err := decimal.NewFromString(strings.TrimSpace(stepSize))
+	if err != nil {
+		return 0
+	}
+	return stepPrecision(step)
 }
 
-// FormatQuantity 格式化数量到正确的精度
+// FormatQuantity 格式化数量：向零截断到stepSize的整数倍（stepSize为10时取整到10的倍数）
 func (t *FuturesTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
-	precision, err := t.GetSymbolPrecision(symbol)
-	if err != nil {
-		// 如果获取失败，使用默认格式
-		return fmt.Sprintf("%.3f", quantity), nil
+	symbol = binanceSymbol(symbol)
+	stepSize, err := t.lotStepSize(symbol)
+	if err == nil && stepSize == "" {
+		log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
+	}
+	step, parseErr := decimal.NewFromString(strings.TrimSpace(stepSize))
+	if err != nil || parseErr != nil || !step.IsPositive() {
+		// 如果获取失败，使用默认精度
+		return floorToPrecision(quantity, 3).StringFixed(3), nil
 	}
 
-	format := fmt.Sprintf("%%.%df", precision)
-	return fmt.Sprintf(format, quantity), nil
+	precision := stepPrecision(step)
+	return toDecimal(quantity).Div(step).Truncate(0).Mul(step).StringFixed(int32(precision)), nil
 }
 
 // 辅助函数
@@ -0,0 +1,118 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nofx/testutil"
+)
+
+// binanceTestStart 测试假时钟的起始时间
+var binanceTestStart = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// binanceTestPosition 杠杆为leverage的BTCUSDT多仓（positionRisk格式）
+func binanceTestPosition(leverage string) string {
+	return `[{"symbol":"BTCUSDT","positionAmt":"0.01","entryPrice":"50000","markPrice":"50000","unRealizedProfit":"0","liquidationPrice":"40000","leverage":"` + leverage + `","marginType":"cross","isolatedMargin":"0","positionSide":"LONG"}]`
+}
+
+// TestBinanceLeverageConfirmClearedOnMismatch 持仓报告的杠杆与已确认的不同时清除确认，再次设置时请求交易所
+func TestBinanceLeverageConfirmClearedOnMismatch(t *testing.T) {
+	f := newFakeBinance(t)
+	f.reply("GET /fapi/v2/positionRisk", binanceTestPosition("10"))
+	f.reply("POST /fapi/v1/leverage", `{"symbol":"BTCUSDT","leverage":5,"maxNotionalValue":"1000000"}`)
+	tr := f.trader(t)
+	tr.SetClock(testutil.NewFakeClock(binanceTestStart))
+	tr.leverage.confirm("BTCUSDT", 5, false, binanceTestStart)
+
+	if _, err := tr.GetPositions(); err != nil {
+		t.Fatal(err)
+	}
+	if tr.leverage.isConfirmed("BTCUSDT", 5) {
+		t.Fatal("交易所报告10x后仍确认为5x")
+	}
+	if err := tr.SetLeverage("BTCUSDT", 5); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.calls("POST /fapi/v1/leverage"); n != 1 {
+		t.Errorf("设置杠杆请求次数 = %d, want 1", n)
+	}
+	if !tr.leverage.isConfirmed("BTCUSDT", 5) {
+		t.Error("设置成功后应确认为5x")
+	}
+
+	// 报告的杠杆与确认的一致时保留确认
+	tr.leverage.confirm("ETHUSDT", 3, false, binanceTestStart)
+	tr.leverage.observe("ETHUSDT", 3)
+	tr.leverage.observe("ETHUSDT", 0)
+	if !tr.leverage.isConfirmed("ETHUSDT", 3) {
+		t.Error("杠杆一致或未知时不应清除确认")
+	}
+}
+
+// TestBinanceOpenRespectsContextDuringCooldown 杠杆冷却期内开仓，ctx取消或不等待时立即返回且不下单
+func TestBinanceOpenRespectsContextDuringCooldown(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ctx  func() context.Context
+		want error
+	}{
+		{"canceled", func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}, ErrCanceled},
+		{"no wait", func() context.Context {
+			return WithRateLimitNoWait(context.Background())
+		}, ErrLeverageCooldown},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeBinance(t)
+			tr := f.trader(t)
+			tr.SetClock(testutil.NewFakeClock(binanceTestStart))
+			tr.leverage.confirm("BTCUSDT", 5, true, binanceTestStart)
+
+			// 假时间不推进，冷却期不会结束：开仓返回说明响应了ctx
+			for _, open := range []func(context.Context, string, float64, int) (map[string]interface{}, error){
+				tr.OpenLongContext, tr.OpenShortContext,
+			} {
+				if _, err := open(tc.ctx(), "BTCUSDT", 0.01, 5); !errors.Is(err, tc.want) {
+					t.Errorf("冷却期内开仓 err = %v, want %v", err, tc.want)
+				}
+			}
+			if n := f.calls("POST /fapi/v1/order"); n != 0 {
+				t.Errorf("冷却期内仍下单 %d 次", n)
+			}
+		})
+	}
+}
+
+// TestBinanceLeverageCooldownExpires 冷却期内再次修改杠杆时等待，假时间到期后才请求交易所
+func TestBinanceLeverageCooldownExpires(t *testing.T) {
+	f := newFakeBinance(t)
+	f.reply("POST /fapi/v1/leverage", `{"symbol":"BTCUSDT","leverage":10,"maxNotionalValue":"1000000"}`)
+	tr := f.trader(t)
+	fc := testutil.NewFakeClock(binanceTestStart)
+	tr.SetClock(fc)
+	tr.leverage.confirm("BTCUSDT", 5, true, binanceTestStart)
+
+	fc.Advance(binanceLeverageCooldown - time.Second)
+	done := make(chan error, 1)
+	go func() { done <- tr.SetLeverage("BTCUSDT", 10) }()
+	fc.BlockUntil(1)
+	if n := f.calls("POST /fapi/v1/leverage"); n != 0 {
+		t.Fatalf("冷却期内请求交易所 %d 次", n)
+	}
+
+	fc.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := f.calls("POST /fapi/v1/leverage"); n != 1 {
+		t.Errorf("冷却期结束后设置杠杆请求 = %d, want 1", n)
+	}
+	if !tr.leverage.isConfirmed("BTCUSDT", 10) {
+		t.Error("设置成功后应确认为10x")
+	}
+}
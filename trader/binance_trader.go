@@ -0,0 +1,92 @@
+package trader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 编译期检查：FuturesTrader（币安USDT本位合约）可替换为任意Trader使用
+var _ Trader = (*FuturesTrader)(nil)
+
+// binanceRulesTTL 交易规则缓存的有效期
+const binanceRulesTTL = time.Hour
+
+// binanceSymbolRules 交易对的下单规则（交易所返回的十进制字符串，不经过float64）
+type binanceSymbolRules struct {
+	stepSize string // LOT_SIZE 数量步长
+	tickSize string // PRICE_FILTER 价格步长
+	trading  bool   // 状态为TRADING
+}
+
+// binanceSymbol 将其他交易所格式的交易对统一为币安格式
+// BTC-USDT-SWAP / BTC-USDT / btc/usdt / BTC_USDT 均转换为 BTCUSDT
+func binanceSymbol(symbol string) string {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	s = strings.TrimSuffix(s, "-SWAP")
+	return strings.NewReplacer("-", "", "/", "", "_", "").Replace(s)
+}
+
+// symbolRules 获取交易对的下单规则（缓存binanceRulesTTL，过期后整体刷新），交易对不存在时返回nil
+func (t *FuturesTrader) symbolRules(symbol string) (*binanceSymbolRules, error) {
+	t.rulesMu.Lock()
+	defer t.rulesMu.Unlock()
+	if t.rulesCache == nil || t.clock.Since(t.rulesFetched) > binanceRulesTTL {
+		ctx, cancel := t.opContext(OpPublicRead)
+		defer cancel()
+		exchangeInfo, err := t.client.NewExchangeInfoService().Do(ctx)
+		err = timeoutError(ctx, OpPublicRead, "GetExchangeInfo", err)
+		if err != nil {
+			// 刷新失败时继续使用过期的规则（交易规则很少变化）
+			if t.rulesCache != nil {
+				if rules, ok := t.rulesCache[symbol]; ok {
+					return rules, nil
+				}
+			}
+			return nil, fmt.Errorf("获取交易规则失败: %w", err)
+		}
+
+		cache := make(map[string]*binanceSymbolRules, len(exchangeInfo.Symbols))
+		for i := range exchangeInfo.Symbols {
+			s := &exchangeInfo.Symbols[i]
+			rules := &binanceSymbolRules{trading: s.Status == "TRADING"}
+			if lot := s.LotSizeFilter(); lot != nil {
+				rules.stepSize = lot.StepSize
+			}
+			if price := s.PriceFilter(); price != nil {
+				rules.tickSize = price.TickSize
+			}
+			cache[s.Symbol] = rules
+		}
+		t.rulesCache, t.rulesFetched = cache, t.clock.Now()
+	}
+	return t.rulesCache[symbol], nil
+}
+
+// FormatPrice 将价格按PRICE_FILTER的tickSize取整并格式化为下单用字符串
+// 未找到tickSize时不取整，按最短表示格式化
+func (t *FuturesTrader) FormatPrice(symbol string, price float64, direction PriceRounding) (string, error) {
+	if price <= 0 {
+		return "", fmt.Errorf("价格必须大于0 (当前 %v)", price)
+	}
+	symbol = binanceSymbol(symbol)
+	rules, err := t.symbolRules(symbol)
+	if err != nil {
+		return "", err
+	}
+	if rules == nil || rules.tickSize == "" {
+		return strconv.FormatFloat(price, 'f', -1, 64), nil
+	}
+	tick, err := decimal.NewFromString(strings.TrimSpace(rules.tickSize))
+	if err != nil || !tick.IsPositive() {
+		return strconv.FormatFloat(price, 'f', -1, 64), nil
+	}
+	d := roundPriceToTick(price, decimalFloat(tick), direction)
+	if !d.IsPositive() {
+		return "", fmt.Errorf("%s 价格 %v 按tickSize %s 取整后为0", symbol, price, rules.tickSize)
+	}
+	return d.StringFixed(int32(stepPrecision(tick))), nil
+}
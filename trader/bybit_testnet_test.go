@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// newBybitTestnetTrader 使用测试网API Key创建交易器，未设置 BYBIT_TESTNET_API_KEY、BYBIT_TESTNET_SECRET_KEY 时跳过
+func newBybitTestnetTrader(t *testing.T) *BybitTrader {
+	t.Helper()
+	key, secret := os.Getenv("BYBIT_TESTNET_API_KEY"), os.Getenv("BYBIT_TESTNET_SECRET_KEY")
+	if key == "" || secret == "" {
+		t.Skip("未设置 BYBIT_TESTNET_API_KEY / BYBIT_TESTNET_SECRET_KEY，跳过Bybit测试网集成测试")
+	}
+	tr := NewBybitTrader(key, secret, true)
+	if !tr.IsDemo() {
+		t.Fatal("测试网交易器 IsDemo() = false")
+	}
+	return tr
+}
+
+// TestBybitTestnetReadOnly 测试网上的行情、交易规则、余额与持仓查询
+func TestBybitTestnetReadOnly(t *testing.T) {
+	tr := newBybitTestnetTrader(t)
+
+	price, err := tr.GetMarketPrice("BTCUSDT")
+	if err != nil || price <= 0 {
+		t.Fatalf("GetMarketPrice = %v, %v", price, err)
+	}
+	limits, err := tr.GetInstrumentLimits("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetInstrumentLimits: %v", err)
+	}
+	if !limits.Tradable || limits.MinQuantity <= 0 {
+		t.Errorf("交易规则 = %+v", limits)
+	}
+	if _, err := tr.FormatQuantity("BTCUSDT", limits.MinQuantity); err != nil {
+		t.Errorf("FormatQuantity(%v): %v", limits.MinQuantity, err)
+	}
+
+	balance, err := tr.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if _, ok := balance["availableBalance"]; !ok {
+		t.Errorf("余额缺少availableBalance: %v", balance)
+	}
+	if _, err := tr.GetPositions(); err != nil {
+		t.Fatalf("GetPositions: %v", err)
+	}
+}
+
+// TestBybitTestnetOpenClose 测试网上以最小数量开多再平仓，需额外设置 BYBIT_TESTNET_TRADE=1
+func TestBybitTestnetOpenClose(t *testing.T) {
+	tr := newBybitTestnetTrader(t)
+	if os.Getenv("BYBIT_TESTNET_TRADE") != "1" {
+		t.Skip("未设置 BYBIT_TESTNET_TRADE=1，跳过测试网下单")
+	}
+	const symbol = "BTCUSDT"
+	if _, err := tr.GetPosition(symbol, PositionLong); err == nil {
+		t.Skipf("测试网已有 %s 多仓，跳过以免影响现有持仓", symbol)
+	} else if !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("GetPosition: %v", err)
+	}
+
+	limits, err := tr.GetInstrumentLimits(symbol)
+	if err != nil {
+		t.Fatalf("GetInstrumentLimits: %v", err)
+	}
+	price, err := tr.GetMarketPrice(symbol)
+	if err != nil {
+		t.Fatalf("GetMarketPrice: %v", err)
+	}
+	quantity := limits.MinQuantity
+	for limits.MinNotional > 0 && quantity*price < limits.MinNotional {
+		quantity += limits.MinQuantity
+	}
+
+	if err := tr.SetLeverage(symbol, 2); err != nil {
+		t.Fatalf("SetLeverage: %v", err)
+	}
+	if _, err := tr.OpenLong(symbol, quantity, 2); err != nil {
+		t.Fatalf("OpenLong(%v): %v", quantity, err)
+	}
+	t.Cleanup(func() {
+		// 断言失败时也尽量平掉测试仓位
+		tr.InvalidateCache()
+		if _, err := tr.GetPosition(symbol, PositionLong); err == nil {
+			tr.CloseLong(symbol, 0)
+		}
+	})
+
+	tr.InvalidateCache()
+	if _, err := tr.GetPosition(symbol, PositionLong); err != nil {
+		t.Fatalf("开仓后 GetPosition: %v", err)
+	}
+	if _, err := tr.CloseLong(symbol, 0); err != nil {
+		t.Fatalf("CloseLong: %v", err)
+	}
+	tr.InvalidateCache()
+	if pos, err := tr.GetPosition(symbol, PositionLong); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("平仓后 GetPosition = %+v, %v, want ErrPositionNotFound", pos, err)
+	}
+}
@@ -0,0 +1,1156 @@
+package trader
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nofx/clock"
+
+	"github.com/shopspring/decimal"
+)
+
+// 编译期检查：BybitTrader可替换为任意Trader使用，并直接返回结构体结果
+var (
+	_ Trader      = (*BybitTrader)(nil)
+	_ TypedTrader = (*BybitTrader)(nil)
+)
+
+const (
+	bybitMainnetURL = "https://api.bybit.com"
+	bybitTestnetURL = "https://api-testnet.bybit.com"
+
+	// bybitRecvWindow 私有请求的有效期（毫秒）
+	bybitRecvWindow = "5000"
+
+	// bybitInstrumentTTL 交易规则缓存的有效期
+	bybitInstrumentTTL = time.Hour
+
+	// bybitLeverageNotModified 杠杆已是目标值
+	bybitLeverageNotModified = 110043
+	// bybitMarginModeNotModified 保证金模式已是目标值
+	bybitMarginModeNotModified = 110026
+)
+
+// BybitTrader Bybit USDT永续合约交易器（统一账户，linear品种）
+// 交易对格式与币安相同（BTCUSDT），BTC-USDT-SWAP 等格式自动转换
+type BybitTrader struct {
+	apiKey    string
+	secretKey string
+	baseURL   string
+	testnet   bool
+	client    *http.Client
+	clock     clock.Clock
+
+	// 余额缓存
+	cachedBalance     *Balance
+	balanceCacheTime  time.Time
+	balanceCacheMutex sync.RWMutex
+
+	// 持仓缓存
+	cachedPositions     []*Position
+	positionsCacheTime  time.Time
+	positionsCacheMutex sync.RWMutex
+
+	// 缓存有效期（15秒）
+	cacheDuration time.Duration
+
+	// 缓存过期时并发的余额/持仓查询只请求一次API
+	balanceFlight   flightGroup[*Balance]
+	positionsFlight flightGroup[[]*Position]
+
+	// 交易规则缓存（key: 交易对）
+	instruments      map[string]*bybitInstrument
+	instrumentsMutex sync.Mutex
+
+	// 各交易对是否为双向持仓（决定下单与止盈止损的positionIdx）
+	hedgeMode      map[string]bool
+	hedgeModeMutex sync.Mutex
+
+	// 各缓存的命中统计
+	cacheStats struct {
+		balance, positions, instruments cacheCounter
+	}
+
+	// 各类调用的超时时间
+	timeouts TimeoutConfig
+}
+
+// NewBybitTrader 创建Bybit合约交易器，testnet为true时连接测试网（需要使用测试网创建的API Key）
+func NewBybitTrader(apiKey, secretKey string, testnet bool) *BybitTrader {
+	baseURL := bybitMainnetURL
+	if testnet {
+		baseURL = bybitTestnetURL
+	}
+	return &BybitTrader{
+		apiKey:        apiKey,
+		secretKey:     secretKey,
+		baseURL:       baseURL,
+		testnet:       testnet,
+		client:        &http.Client{},
+		clock:         clock.Real(),
+		cacheDuration: 15 * time.Second, // 15秒缓存
+		instruments:   make(map[string]*bybitInstrument),
+		hedgeMode:     make(map[string]bool),
+		timeouts:      DefaultTimeoutConfig(),
+	}
+}
+
+// SetTimeouts 设置各类调用的超时时间
+func (t *BybitTrader) SetTimeouts(cfg TimeoutConfig) {
+	t.timeouts = cfg
+}
+
+// SetClock 替换时间源（测试中注入假时钟）
+func (t *BybitTrader) SetClock(c clock.Clock) {
+	t.clock = clock.OrReal(c)
+}
+
+// IsDemo 实现DemoTrader：是否连接的是测试网
+func (t *BybitTrader) IsDemo() bool {
+	return t.testnet
+}
+
+// InvalidateCache 清除余额和持仓缓存，下次查询直接请求API
+func (t *BybitTrader) InvalidateCache() {
+	t.balanceCacheMutex.Lock()
+	t.cachedBalance = nil
+	t.balanceCacheMutex.Unlock()
+
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = nil
+	t.positionsCacheMutex.Unlock()
+}
+
+// CacheStats 返回余额、持仓与交易规则缓存的统计
+func (t *BybitTrader) CacheStats() []CacheStat {
+	now := t.clock.Now()
+	return []CacheStat{
+		t.cacheStats.balance.stat("balance", now),
+		t.cacheStats.positions.stat("positions", now),
+		t.cacheStats.instruments.stat("instruments", now),
+	}
+}
+
+// BybitAPIError Bybit接口返回的业务错误（retCode非0）
+type BybitAPIError struct {
+	Op   string
+	Code int
+	Msg  string
+}
+
+func (e *BybitAPIError) Error() string {
+	return fmt.Sprintf("%s 失败 (retCode=%d): %s", e.Op, e.Code, e.Msg)
+}
+
+// bybitErrorKinds Bybit错误码对应的错误分类（同okxErrorKinds）
+var bybitErrorKinds = map[int]error{
+	10006:  ErrRateLimited,         // 请求频率过高
+	110004: ErrInsufficientBalance, // 钱包余额不足
+	110007: ErrInsufficientBalance, // 可用余额不足
+	110012: ErrInsufficientBalance, // 可用余额不足
+	110017: ErrPositionNotFound,    // 持仓为0，只减仓订单被拒绝
+	110001: ErrOrderNotFound,       // 订单不存在
+	110094: ErrInvalidSize,         // 下单金额低于最小值
+}
+
+func (e *BybitAPIError) ErrorCode() ErrorCode {
+	if kind := bybitErrorKinds[e.Code]; kind != nil {
+		return ErrorCodeOf(kind)
+	}
+	return ErrCodeExchangeRejected
+}
+
+// Is 使 errors.Is(err, ErrInsufficientBalance) 等按错误码的分类判断成立
+func (e *BybitAPIError) Is(target error) bool {
+	kind := bybitErrorKinds[e.Code]
+	return kind != nil && kind == target
+}
+
+// bybitResponse v5接口的通用响应
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// call 调用v5接口并将result解析到out（out为nil时不解析）
+// GET参数放在querystring，POST参数为JSON body；配置了API Key时请求带签名
+func (t *BybitTrader) call(class OperationClass, op, method, path string, params map[string]interface{}, out interface{}) error {
+	ctx, cancel := timeoutContext(t.timeouts, class)
+	defer cancel()
+	err := t.doCall(ctx, op, method, path, params, out)
+	return timeoutError(ctx, class, op, err)
+}
+
+func (t *BybitTrader) doCall(ctx context.Context, op, method, path string, params map[string]interface{}, out interface{}) error {
+	var payload string
+	var body io.Reader
+	target := t.baseURL + path
+	if method == http.MethodGet {
+		q := url.Values{}
+		for k, v := range params {
+			q.Set(k, fmt.Sprintf("%v", v))
+		}
+		payload = q.Encode()
+		if payload != "" {
+			target += "?" + payload
+		}
+	} else {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		payload = string(data)
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.apiKey != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set("X-BAPI-API-KEY", t.apiKey)
+		req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		req.Header.Set("X-BAPI-RECV-WINDOW", bybitRecvWindow)
+		req.Header.Set("X-BAPI-SIGN", t.sign(timestamp+t.apiKey+bybitRecvWindow+payload))
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result bybitResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("解析%s响应失败: %w", op, err)
+	}
+	if result.RetCode != 0 {
+		return &BybitAPIError{Op: op, Code: result.RetCode, Msg: result.RetMsg}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(result.Result, out); err != nil {
+		return fmt.Errorf("解析%s响应失败: %w", op, err)
+	}
+	return nil
+}
+
+// sign HMAC-SHA256签名（十六进制）
+func (t *BybitTrader) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// bybitRetCode 错误对应的retCode（非Bybit业务错误时返回0）
+func bybitRetCode(err error) int {
+	var apiErr *BybitAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+// bybitFloat 解析Bybit返回的数值字符串（空字符串为0）
+func bybitFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+// GetBalance 获取账户余额（带缓存）
+func (t *BybitTrader) GetBalance() (map[string]interface{}, error) {
+	balance, err := t.Balance(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return balance.Map(), nil
+}
+
+// Balance 实现TypedTrader：获取统一账户余额（带缓存）
+func (t *BybitTrader) Balance(ctx context.Context) (*Balance, error) {
+	return t.balance(false)
+}
+
+// RefreshBalance 跳过缓存直接查询账户余额（结果同时更新缓存）
+func (t *BybitTrader) RefreshBalance() (map[string]interface{}, error) {
+	balance, err := t.balance(true)
+	if err != nil {
+		return nil, err
+	}
+	return balance.Map(), nil
+}
+
+// balance 获取账户余额，force为true时不使用缓存
+func (t *BybitTrader) balance(force bool) (*Balance, error) {
+	// 先检查缓存是否有效
+	t.balanceCacheMutex.RLock()
+	if !force && t.cachedBalance != nil && t.clock.Since(t.balanceCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Since(t.balanceCacheTime)
+		t.balanceCacheMutex.RUnlock()
+		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.cacheStats.balance.hit()
+		balance := *t.cachedBalance
+		return &balance, nil
+	}
+	t.balanceCacheMutex.RUnlock()
+	t.cacheStats.balance.miss()
+
+	// 并发未命中只请求一次，其余调用共享结果（各自得到副本）
+	result, err, _ := t.balanceFlight.Do("balance", func() (*Balance, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.balanceCacheMutex.RLock()
+		if !force && t.cachedBalance != nil && t.clock.Since(t.balanceCacheTime) < t.cacheDuration {
+			cached := *t.cachedBalance
+			t.balanceCacheMutex.RUnlock()
+			return &cached, nil
+		}
+		t.balanceCacheMutex.RUnlock()
+		result, err := t.fetchBalance()
+		t.cacheStats.balance.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	balance := *result
+	return &balance, nil
+}
+
+// fetchBalance 调用API获取统一账户余额并更新缓存
+func (t *BybitTrader) fetchBalance() (*Balance, error) {
+	log.Printf("🔄 缓存过期，正在调用Bybit API获取账户余额...")
+	var resp struct {
+		List []struct {
+			TotalWalletBalance    string `json:"totalWalletBalance"`
+			TotalAvailableBalance string `json:"totalAvailableBalance"`
+			TotalPerpUPL          string `json:"totalPerpUPL"`
+			Coin                  []struct {
+				Coin          string `json:"coin"`
+				Equity        string `json:"equity"`
+				UsdValue      string `json:"usdValue"`
+				WalletBalance string `json:"walletBalance"`
+				UnrealisedPnl string `json:"unrealisedPnl"`
+				TotalOrderIM  string `json:"totalOrderIM"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	err := t.call(OpPrivateRead, "GetWalletBalance", http.MethodGet, "/v5/account/wallet-balance",
+		map[string]interface{}{"accountType": "UNIFIED"}, &resp)
+	if err == nil && len(resp.List) == 0 {
+		err = errors.New("返回的账户列表为空")
+	}
+	if err != nil {
+		log.Printf("❌ Bybit API调用失败: %v", err)
+		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	}
+
+	account := resp.List[0]
+	available := bybitFloat(account.TotalAvailableBalance)
+	result := &Balance{
+		TotalWalletBalance:    bybitFloat(account.TotalWalletBalance),
+		AvailableBalance:      available,
+		TotalUnrealizedProfit: bybitFloat(account.TotalPerpUPL),
+		CrossAvailableBalance: available,
+		Currencies:            make(map[string]CurrencyBalance, len(account.Coin)),
+	}
+	for _, c := range account.Coin {
+		frozen := bybitFloat(c.TotalOrderIM)
+		if equity := bybitFloat(c.Equity); equity > 0 {
+			// 挂单冻结按该币种的USD价值折算
+			result.FrozenInOrders += bybitFloat(c.UsdValue) * frozen / equity
+		}
+		result.Currencies[c.Coin] = CurrencyBalance{
+			Equity:        bybitFloat(c.Equity),
+			EquityUSD:     bybitFloat(c.UsdValue),
+			Available:     bybitFloat(c.WalletBalance) - frozen,
+			UnrealizedPnL: bybitFloat(c.UnrealisedPnl),
+			OrderFrozen:   frozen,
+		}
+	}
+
+	t.balanceCacheMutex.Lock()
+	t.cachedBalance = result
+	t.balanceCacheTime = t.clock.Now()
+	t.balanceCacheMutex.Unlock()
+
+	balance := *result
+	return &balance, nil
+}
+
+// GetPositions 获取所有持仓（带缓存）
+func (t *BybitTrader) GetPositions() ([]map[string]interface{}, error) {
+	positions, err := t.Positions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, pos := range positions {
+		result = append(result, pos.Map())
+	}
+	return result, nil
+}
+
+// Positions 实现TypedTrader：获取所有USDT永续持仓（带缓存）
+func (t *BybitTrader) Positions(ctx context.Context) ([]*Position, error) {
+	return t.positions(false)
+}
+
+// RefreshPositions 跳过缓存直接查询持仓（结果同时更新缓存）
+func (t *BybitTrader) RefreshPositions() ([]map[string]interface{}, error) {
+	positions, err := t.positions(true)
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, pos := range positions {
+		result = append(result, pos.Map())
+	}
+	return result, nil
+}
+
+// positions 获取所有持仓，force为true时不使用缓存
+func (t *BybitTrader) positions(force bool) ([]*Position, error) {
+	// 先检查缓存是否有效
+	t.positionsCacheMutex.RLock()
+	if !force && t.cachedPositions != nil && t.clock.Since(t.positionsCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Since(t.positionsCacheTime)
+		t.positionsCacheMutex.RUnlock()
+		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.cacheStats.positions.hit()
+		return copyPositions(t.cachedPositions), nil
+	}
+	t.positionsCacheMutex.RUnlock()
+	t.cacheStats.positions.miss()
+
+	// 并发未命中只请求一次，其余调用共享结果（各自得到副本）
+	result, err, _ := t.positionsFlight.Do("positions", func() ([]*Position, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.positionsCacheMutex.RLock()
+		if !force && t.cachedPositions != nil && t.clock.Since(t.positionsCacheTime) < t.cacheDuration {
+			cached := copyPositions(t.cachedPositions)
+			t.positionsCacheMutex.RUnlock()
+			return cached, nil
+		}
+		t.positionsCacheMutex.RUnlock()
+		result, err := t.fetchPositions()
+		t.cacheStats.positions.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyPositions(result), nil
+}
+
+// bybitPosition 持仓查询返回的单条持仓
+type bybitPosition struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"` // Buy / Sell，空仓为空字符串
+	Size          string `json:"size"`
+	AvgPrice      string `json:"avgPrice"`
+	MarkPrice     string `json:"markPrice"`
+	UnrealisedPnl string `json:"unrealisedPnl"`
+	Leverage      string `json:"leverage"`
+	LiqPrice      string `json:"liqPrice"`
+	TradeMode     int    `json:"tradeMode"`   // 0 全仓 / 1 逐仓
+	PositionIdx   int    `json:"positionIdx"` // 0 单向持仓 / 1 双向持仓多头 / 2 双向持仓空头
+	PositionIM    string `json:"positionIM"`
+	CreatedTime   string `json:"createdTime"`
+}
+
+// listPositions 分页查询持仓（symbol为空时查询全部USDT结算的持仓），同时记录各交易对的持仓模式
+func (t *BybitTrader) listPositions(symbol string) ([]bybitPosition, error) {
+	params := map[string]interface{}{"category": "linear", "limit": 200}
+	if symbol != "" {
+		params["symbol"] = symbol
+	} else {
+		params["settleCoin"] = "USDT"
+	}
+
+	var all []bybitPosition
+	for {
+		var resp struct {
+			List           []bybitPosition `json:"list"`
+			NextPageCursor string          `json:"nextPageCursor"`
+		}
+		if err := t.call(OpPrivateRead, "GetPositions", http.MethodGet, "/v5/position/list", params, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.List...)
+		if resp.NextPageCursor == "" || len(resp.List) == 0 {
+			break
+		}
+		params["cursor"] = resp.NextPageCursor
+	}
+
+	t.hedgeModeMutex.Lock()
+	for _, p := range all {
+		t.hedgeMode[p.Symbol] = p.PositionIdx != 0
+	}
+	t.hedgeModeMutex.Unlock()
+	return all, nil
+}
+
+// fetchPositions 调用API获取持仓并更新缓存
+func (t *BybitTrader) fetchPositions() ([]*Position, error) {
+	log.Printf("🔄 缓存过期，正在调用Bybit API获取持仓信息...")
+	list, err := t.listPositions("")
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	result := make([]*Position, 0, len(list))
+	for _, p := range list {
+		size := bybitFloat(p.Size)
+		if size == 0 || p.Side == "" {
+			continue
+		}
+		pos := &Position{
+			Symbol:           p.Symbol,
+			Side:             PositionLong,
+			Quantity:         size,
+			EntryPrice:       bybitFloat(p.AvgPrice),
+			MarkPrice:        bybitFloat(p.MarkPrice),
+			UnrealizedPnL:    bybitFloat(p.UnrealisedPnl),
+			Leverage:         int(bybitFloat(p.Leverage)),
+			LiquidationPrice: bybitFloat(p.LiqPrice),
+			MarginMode:       "cross",
+		}
+		if p.Side == "Sell" {
+			pos.Side = PositionShort
+		}
+		if p.TradeMode == 1 {
+			pos.MarginMode = "isolated"
+			pos.IsolatedMargin = bybitFloat(p.PositionIM)
+			pos.IsolatedMarginUSD = pos.IsolatedMargin
+		}
+		if ms, err := strconv.ParseInt(p.CreatedTime, 10, 64); err == nil && ms > 0 {
+			pos.OpenTime = time.UnixMilli(ms)
+		}
+		result = append(result, pos)
+	}
+
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = result
+	t.positionsCacheTime = t.clock.Now()
+	t.positionsCacheMutex.Unlock()
+
+	return copyPositions(result), nil
+}
+
+// GetPosition 获取指定币种和方向的持仓，不存在时返回 ErrPositionNotFound
+func (t *BybitTrader) GetPosition(symbol string, side PositionSide) (*Position, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	symbol = binanceSymbol(symbol)
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	return matchPosition(positions, symbol, side, func(pos map[string]interface{}) bool {
+		return pos["symbol"] == symbol
+	})
+}
+
+// positionIdx 下单与止盈止损使用的positionIdx：单向持仓为0，双向持仓多头为1、空头为2
+// 持仓模式未知时查询该交易对的持仓（空仓也会返回持仓模式）
+func (t *BybitTrader) positionIdx(symbol string, side PositionSide) (int, error) {
+	t.hedgeModeMutex.Lock()
+	hedge, ok := t.hedgeMode[symbol]
+	t.hedgeModeMutex.Unlock()
+	if !ok {
+		if _, err := t.listPositions(symbol); err != nil {
+			return 0, fmt.Errorf("查询持仓模式失败: %w", err)
+		}
+		t.hedgeModeMutex.Lock()
+		hedge = t.hedgeMode[symbol]
+		t.hedgeModeMutex.Unlock()
+	}
+	if !hedge {
+		return 0, nil
+	}
+	if side == PositionShort {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// bybitInstrument 交易规则（数量与价格步长为交易所返回的十进制字符串）
+type bybitInstrument struct {
+	symbol      string
+	trading     bool
+	qtyStep     decimal.Decimal
+	minQty      decimal.Decimal
+	minNotional float64
+	tickSize    decimal.Decimal
+	maxLeverage float64
+	fetched     time.Time
+}
+
+// getInstrument 获取交易规则（缓存bybitInstrumentTTL，刷新失败时使用过期的规则）
+func (t *BybitTrader) getInstrument(symbol string) (*bybitInstrument, error) {
+	t.instrumentsMutex.Lock()
+	defer t.instrumentsMutex.Unlock()
+	cached := t.instruments[symbol]
+	if cached != nil && t.clock.Since(cached.fetched) < bybitInstrumentTTL {
+		t.cacheStats.instruments.hit()
+		return cached, nil
+	}
+	t.cacheStats.instruments.miss()
+
+	var resp struct {
+		List []struct {
+			Symbol        string `json:"symbol"`
+			Status        string `json:"status"`
+			LotSizeFilter struct {
+				QtyStep          string `json:"qtyStep"`
+				MinOrderQty      string `json:"minOrderQty"`
+				MinNotionalValue string `json:"minNotionalValue"`
+			} `json:"lotSizeFilter"`
+			PriceFilter struct {
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+			LeverageFilter struct {
+				MaxLeverage string `json:"maxLeverage"`
+			} `json:"leverageFilter"`
+		} `json:"list"`
+	}
+	err := t.call(OpPublicRead, "GetInstrumentsInfo", http.MethodGet, "/v5/market/instruments-info",
+		map[string]interface{}{"category": "linear", "symbol": symbol}, &resp)
+	if err == nil && len(resp.List) == 0 {
+		err = fmt.Errorf("交易对 %s 不存在", symbol)
+	}
+	t.cacheStats.instruments.refreshed(t.clock.Now(), err)
+	if err != nil {
+		if cached != nil {
+			log.Printf("  ⚠ %s 刷新交易规则失败，继续使用缓存: %v", symbol, err)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	info := resp.List[0]
+	inst := &bybitInstrument{
+		symbol:      info.Symbol,
+		trading:     info.Status == "Trading",
+		minNotional: bybitFloat(info.LotSizeFilter.MinNotionalValue),
+		maxLeverage: bybitFloat(info.LeverageFilter.MaxLeverage),
+		fetched:     t.clock.Now(),
+	}
+	inst.qtyStep, _ = decimal.NewFromString(info.LotSizeFilter.QtyStep)
+	inst.minQty, _ = decimal.NewFromString(info.LotSizeFilter.MinOrderQty)
+	inst.tickSize, _ = decimal.NewFromString(info.PriceFilter.TickSize)
+	t.instruments[symbol] = inst
+	return inst, nil
+}
+
+// GetSymbolPrecision 获取数量精度（qtyStep的小数位数）
+func (t *BybitTrader) GetSymbolPrecision(symbol string) (int, error) {
+	inst, err := t.getInstrument(binanceSymbol(symbol))
+	if err != nil {
+		return 0, err
+	}
+	return stepPrecision(inst.qtyStep), nil
+}
+
+// GetInstrumentLimits 获取交易对的交易规则与最大杠杆
+func (t *BybitTrader) GetInstrumentLimits(symbol string) (*InstrumentLimits, error) {
+	inst, err := t.getInstrument(binanceSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	return &InstrumentLimits{
+		Symbol:      inst.symbol,
+		Tradable:    inst.trading,
+		MinQuantity: decimalFloat(inst.minQty),
+		MinNotional: inst.minNotional,
+		MaxLeverage: int(inst.maxLeverage),
+	}, nil
+}
+
+// FormatQuantity 格式化数量：向零截断到qtyStep的整数倍，低于最小下单数量时返回 ErrBelowMinSize
+func (t *BybitTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	symbol = binanceSymbol(symbol)
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return "", err
+	}
+	if !inst.qtyStep.IsPositive() {
+		return floorToPrecision(quantity, 3).StringFixed(3), nil
+	}
+	floored := toDecimal(quantity).Div(inst.qtyStep).Truncate(0).Mul(inst.qtyStep)
+	if floored.IsZero() || floored.LessThan(inst.minQty) {
+		return "", &MinSizeError{Symbol: symbol, Quantity: quantity, MinQuantity: decimalFloat(inst.minQty)}
+	}
+	return floored.StringFixed(int32(stepPrecision(inst.qtyStep))), nil
+}
+
+// FormatPrice 将价格按tickSize取整并格式化为下单用字符串
+func (t *BybitTrader) FormatPrice(symbol string, price float64, direction PriceRounding) (string, error) {
+	if price <= 0 {
+		return "", fmt.Errorf("价格必须大于0 (当前 %v)", price)
+	}
+	symbol = binanceSymbol(symbol)
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return "", err
+	}
+	if !inst.tickSize.IsPositive() {
+		return strconv.FormatFloat(price, 'f', -1, 64), nil
+	}
+	d := roundPriceToTick(price, decimalFloat(inst.tickSize), direction)
+	if !d.IsPositive() {
+		return "", fmt.Errorf("%s 价格 %v 按tickSize %s 取整后为0", symbol, price, inst.tickSize)
+	}
+	return d.StringFixed(int32(stepPrecision(inst.tickSize))), nil
+}
+
+// SetMarginMode 设置仓位模式
+// 统一账户的保证金模式是账户级别的（对所有交易对生效），设置失败时不影响交易
+func (t *BybitTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	mode, marginModeStr := "REGULAR_MARGIN", "全仓"
+	if !isCrossMargin {
+		mode, marginModeStr = "ISOLATED_MARGIN", "逐仓"
+	}
+	err := t.call(OpMutation, "SetMarginMode", http.MethodPost, "/v5/account/set-margin-mode",
+		map[string]interface{}{"setMarginMode": mode}, nil)
+	if err != nil {
+		if bybitRetCode(err) == bybitMarginModeNotModified {
+			log.Printf("  ✓ 账户保证金模式已是 %s", marginModeStr)
+			return nil
+		}
+		log.Printf("  ⚠️ 设置保证金模式失败（%s 继续使用当前模式）: %v", binanceSymbol(symbol), err)
+		// 不返回错误，让交易继续
+		return nil
+	}
+	log.Printf("  ✓ 账户保证金模式已设置为 %s", marginModeStr)
+	return nil
+}
+
+// SetLeverage 设置杠杆（多空同时设置）
+func (t *BybitTrader) SetLeverage(symbol string, leverage int) error {
+	symbol = binanceSymbol(symbol)
+	lev := strconv.Itoa(leverage)
+	err := t.call(OpMutation, "SetLeverage", http.MethodPost, "/v5/position/set-leverage", map[string]interface{}{
+		"category":     "linear",
+		"symbol":       symbol,
+		"buyLeverage":  lev,
+		"sellLeverage": lev,
+	}, nil)
+	if err != nil {
+		if bybitRetCode(err) == bybitLeverageNotModified {
+			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			return nil
+		}
+		return fmt.Errorf("设置杠杆失败: %w", err)
+	}
+	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	return nil
+}
+
+// OpenLong 开多仓
+func (t *BybitTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenLongOrder(context.Background(), symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenShort 开空仓
+func (t *BybitTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenShortOrder(context.Background(), symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenLongOrder 实现TypedTrader：市价开多并返回下单结果
+func (t *BybitTrader) OpenLongOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.openMarket(symbol, PositionLong, quantity, leverage)
+}
+
+// OpenShortOrder 实现TypedTrader：市价开空并返回下单结果
+func (t *BybitTrader) OpenShortOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.openMarket(symbol, PositionShort, quantity, leverage)
+}
+
+// openMarket 设置杠杆后市价开仓
+// 注意：仓位模式应该由调用方（AutoTrader）在开仓前通过 SetMarginMode 设置
+func (t *BybitTrader) openMarket(symbol string, side PositionSide, quantity float64, leverage int) (*OrderResult, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	symbol = binanceSymbol(symbol)
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkOrderNotional(symbol, quantityStr); err != nil {
+		return nil, err
+	}
+
+	result, err := t.placeOrder(symbol, side, false, quantityStr, "Market", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("开%s仓失败: %w", side, err)
+	}
+	result.Leverage = leverage
+	log.Printf("✓ 开仓成功: %s %s 数量: %s 订单ID: %s", symbol, side, quantityStr, result.OrderID)
+	return result, nil
+}
+
+// OpenLongLimit 下限价开多单，价格按tickSize向下取整，返回订单ID
+func (t *BybitTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error) {
+	return t.openLimit(symbol, PositionLong, quantity, price, leverage, tif)
+}
+
+// OpenShortLimit 下限价开空单，价格按tickSize向上取整，返回订单ID
+func (t *BybitTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error) {
+	return t.openLimit(symbol, PositionShort, quantity, price, leverage, tif)
+}
+
+// openLimit 设置杠杆后下限价开仓单
+func (t *BybitTrader) openLimit(symbol string, side PositionSide, quantity, price float64, leverage int, tif TimeInForce) (string, error) {
+	if !side.Valid() {
+		return "", fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	timeInForce, err := bybitTimeInForce(tif)
+	if err != nil {
+		return "", err
+	}
+	symbol = binanceSymbol(symbol)
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return "", err
+	}
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	priceStr, err := t.FormatPrice(symbol, price, limitRounding(side.OpenSide()))
+	if err != nil {
+		return "", err
+	}
+
+	result, err := t.placeOrder(symbol, side, false, quantityStr, "Limit", priceStr, timeInForce)
+	if err != nil {
+		return "", fmt.Errorf("下限价开仓单失败: %w", err)
+	}
+	log.Printf("✓ 限价开仓单已提交: %s %s 数量: %s 价格: %s [%s] 订单ID: %s",
+		symbol, side, quantityStr, priceStr, timeInForce, result.OrderID)
+	return result.OrderID, nil
+}
+
+// bybitTimeInForce 有效方式对应的Bybit timeInForce
+func bybitTimeInForce(tif TimeInForce) (string, error) {
+	switch tif {
+	case TimeInForceGTC, "":
+		return "GTC", nil
+	case TimeInForceIOC:
+		return "IOC", nil
+	case TimeInForceFOK:
+		return "FOK", nil
+	case TimeInForcePostOnly:
+		return "PostOnly", nil
+	}
+	return "", fmt.Errorf("不支持的有效方式: %s", tif)
+}
+
+// checkOrderNotional 按格式化后的下单数量与当前价格检查单笔名义价值上限
+func (t *BybitTrader) checkOrderNotional(symbol, quantityStr string) error {
+	if MaxOrderNotional(symbol) <= 0 {
+		return nil
+	}
+	quantity, err := strconv.ParseFloat(quantityStr, 64)
+	if err != nil {
+		return err
+	}
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return err
+	}
+	return checkOrderNotional(symbol, notionalValue(quantity, price))
+}
+
+// placeOrder 下单（closing为true时为只减仓的平仓单），成功后查询一次成交信息
+func (t *BybitTrader) placeOrder(symbol string, side PositionSide, closing bool, quantityStr, orderType, price, timeInForce string) (*OrderResult, error) {
+	idx, err := t.positionIdx(symbol, side)
+	if err != nil {
+		return nil, err
+	}
+	orderSide := side.OpenSide()
+	if closing {
+		orderSide = side.CloseSide()
+	}
+	params := map[string]interface{}{
+		"category":    "linear",
+		"symbol":      symbol,
+		"side":        bybitSide(orderSide),
+		"orderType":   orderType,
+		"qty":         quantityStr,
+		"positionIdx": idx,
+	}
+	if price != "" {
+		params["price"] = price
+	}
+	if timeInForce != "" {
+		params["timeInForce"] = timeInForce
+	}
+	if closing {
+		params["reduceOnly"] = true
+	}
+
+	var resp struct {
+		OrderID     string `json:"orderId"`
+		OrderLinkID string `json:"orderLinkId"`
+	}
+	err = t.call(OpMutation, "PlaceOrder", http.MethodPost, "/v5/order/create", params, &resp)
+	t.InvalidateCache() // 持仓与余额已变化（或结果未知），下次查询直接请求API
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrderResult{
+		OrderID:       resp.OrderID,
+		ClientOrderID: resp.OrderLinkID,
+		Symbol:        symbol,
+		Side:          strings.ToUpper(orderSide.String()),
+		PositionSide:  strings.ToUpper(side.String()),
+		Status:        "NEW",
+		Time:          t.clock.Now(),
+	}
+	if orderType == "Market" {
+		if err := t.applyOrderFill(result); err != nil {
+			log.Printf("  ⚠ 查询订单 %s 成交信息失败: %v", resp.OrderID, err)
+		}
+	}
+	return result, nil
+}
+
+// applyOrderFill 查询订单的成交数量、均价与手续费
+func (t *BybitTrader) applyOrderFill(result *OrderResult) error {
+	var resp struct {
+		List []struct {
+			OrderStatus string `json:"orderStatus"`
+			CumExecQty  string `json:"cumExecQty"`
+			AvgPrice    string `json:"avgPrice"`
+			CumExecFee  string `json:"cumExecFee"`
+		} `json:"list"`
+	}
+	err := t.call(OpPrivateRead, "GetOrder", http.MethodGet, "/v5/order/realtime", map[string]interface{}{
+		"category": "linear",
+		"symbol":   result.Symbol,
+		"orderId":  result.OrderID,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.List) == 0 {
+		return fmt.Errorf("订单 %s 不存在", result.OrderID)
+	}
+	order := resp.List[0]
+	result.Status = bybitOrderStatus(order.OrderStatus)
+	result.FilledQty = bybitFloat(order.CumExecQty)
+	result.AvgPrice = bybitFloat(order.AvgPrice)
+	result.Fee = -bybitFloat(order.CumExecFee) // Bybit的手续费为正数表示支出
+	result.FeeAsset = "USDT"
+	return nil
+}
+
+// bybitSide 买卖方向对应的Bybit side
+func bybitSide(side Side) string {
+	if side == SideBuy {
+		return "Buy"
+	}
+	return "Sell"
+}
+
+// bybitOrderStatus Bybit订单状态转换为OrderResult格式
+func bybitOrderStatus(status string) string {
+	switch status {
+	case "Filled":
+		return "FILLED"
+	case "PartiallyFilled":
+		return "PARTIALLY_FILLED"
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return "CANCELED"
+	case "Rejected":
+		return "REJECTED"
+	}
+	return "NEW"
+}
+
+// CloseLong 平多仓（quantity=0表示全部平仓）
+func (t *BybitTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	result, err := t.CloseLongOrder(context.Background(), symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CloseShort 平空仓（quantity=0表示全部平仓）
+func (t *BybitTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	result, err := t.CloseShortOrder(context.Background(), symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CloseLongOrder 实现TypedTrader：市价平多并返回下单结果
+func (t *BybitTrader) CloseLongOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.closeMarket(symbol, PositionLong, quantity)
+}
+
+// CloseShortOrder 实现TypedTrader：市价平空并返回下单结果
+func (t *BybitTrader) CloseShortOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.closeMarket(symbol, PositionShort, quantity)
+}
+
+// closeMarket 市价只减仓平仓，平仓后取消该币种的所有挂单
+func (t *BybitTrader) closeMarket(symbol string, side PositionSide, quantity float64) (*OrderResult, error) {
+	symbol = binanceSymbol(symbol)
+	// 如果数量为0，获取当前持仓数量
+	if quantity == 0 {
+		pos, err := t.GetPosition(symbol, side)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
+			}
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		quantity = pos.Quantity
+	}
+
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	result, err := t.placeOrder(symbol, side, true, quantityStr, "Market", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("平%s仓失败: %w", side, err)
+	}
+	log.Printf("✓ 平仓成功: %s %s 数量: %s", symbol, side, quantityStr)
+
+	// 平仓后取消该币种的所有挂单
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	}
+	return result, nil
+}
+
+// CancelAllOrders 取消该币种的所有挂单（持仓上的止损止盈随持仓平仓失效，不在此取消）
+func (t *BybitTrader) CancelAllOrders(symbol string) error {
+	symbol = binanceSymbol(symbol)
+	err := t.call(OpMutation, "CancelAllOrders", http.MethodPost, "/v5/order/cancel-all", map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("取消挂单失败: %w", err)
+	}
+	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	return nil
+}
+
+// GetMarketPrice 获取最新成交价
+func (t *BybitTrader) GetMarketPrice(symbol string) (float64, error) {
+	symbol = binanceSymbol(symbol)
+	var resp struct {
+		List []struct {
+			LastPrice string `json:"lastPrice"`
+		} `json:"list"`
+	}
+	err := t.call(OpPublicRead, "GetMarketPrice", http.MethodGet, "/v5/market/tickers", map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+	}, &resp)
+	if err != nil {
+		return 0, fmt.Errorf("获取价格失败: %w", err)
+	}
+	if len(resp.List) == 0 {
+		return 0, fmt.Errorf("未找到价格")
+	}
+	price := bybitFloat(resp.List[0].LastPrice)
+	if price <= 0 {
+		return 0, fmt.Errorf("%s 价格无效: %q", symbol, resp.List[0].LastPrice)
+	}
+	return price, nil
+}
+
+// SetStopLoss 设置止损（trading-stop接口，对整个持仓生效，触发后市价平仓）
+func (t *BybitTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	return t.setTradingStop(symbol, positionSide, "stopLoss", "slTriggerBy", stopPrice)
+}
+
+// SetTakeProfit 设置止盈（trading-stop接口，对整个持仓生效，触发后市价平仓）
+func (t *BybitTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	return t.setTradingStop(symbol, positionSide, "takeProfit", "tpTriggerBy", takeProfitPrice)
+}
+
+// setTradingStop 设置持仓的止损或止盈价（Full模式，新的价格覆盖旧的价格）
+func (t *BybitTrader) setTradingStop(symbol string, side PositionSide, priceField, triggerField string, price float64) error {
+	if !side.Valid() {
+		return fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	symbol = binanceSymbol(symbol)
+	priceStr, err := t.FormatPrice(symbol, price, stopRounding(side))
+	if err != nil {
+		return err
+	}
+	idx, err := t.positionIdx(symbol, side)
+	if err != nil {
+		return err
+	}
+	err = t.call(OpMutation, "SetTradingStop", http.MethodPost, "/v5/position/trading-stop", map[string]interface{}{
+		"category":    "linear",
+		"symbol":      symbol,
+		"tpslMode":    "Full",
+		"positionIdx": idx,
+		priceField:    priceStr,
+		triggerField:  "LastPrice",
+	}, nil)
+	if err != nil {
+		if priceField == "stopLoss" {
+			return fmt.Errorf("设置止损失败: %w", err)
+		}
+		return fmt.Errorf("设置止盈失败: %w", err)
+	}
+	if priceField == "stopLoss" {
+		log.Printf("  止损价设置: %s", priceStr)
+	} else {
+		log.Printf("  止盈价设置: %s", priceStr)
+	}
+	return nil
+}
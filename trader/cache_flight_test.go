@@ -0,0 +1,139 @@
+package trader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nofx/testutil"
+)
+
+// cacheFlightCallers 每个缓存有效期内并发查询的调用方数量
+const cacheFlightCallers = 100
+
+// assertOneFetchPerWindow 每个缓存有效期内cacheFlightCallers个并发调用只请求交易所一次；
+// get的参数为调用方序号，调用方修改自己拿到的结果，-race下可发现多个调用方共享同一份数据
+func assertOneFetchPerWindow(t *testing.T, clk *testutil.FakeClock, ttl time.Duration, upstream func() int, get func(i int) error) {
+	t.Helper()
+	for window := 1; window <= 3; window++ {
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < cacheFlightCallers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				if err := get(i); err != nil {
+					t.Errorf("第%d个有效期 调用方%d: %v", window, i, err)
+				}
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		if n := upstream(); n != window {
+			t.Fatalf("第%d个有效期后请求交易所 %d 次, want %d", window, n, window)
+		}
+		clk.Advance(ttl)
+	}
+}
+
+// TestOkxCacheSingleFlight OKX余额与持仓：缓存过期时并发查询合并为一次请求，各调用方得到独立副本
+func TestOkxCacheSingleFlight(t *testing.T) {
+	const ttl = 15 * time.Second
+	for _, tc := range []struct {
+		name  string
+		route string
+		body  string
+		get   func(tr *OkxTrader, i int) error
+	}{
+		{"balance", "GET /api/v5/account/balance", okxOK(okxTestBalance("1000", "900", "0")), func(tr *OkxTrader, i int) error {
+			balance, err := tr.Balance(context.Background())
+			if err == nil {
+				balance.AvailableBalance = float64(i)
+			}
+			return err
+		}},
+		{"positions", "GET /api/v5/account/positions", okxOK(okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10)), func(tr *OkxTrader, i int) error {
+			positions, err := tr.Positions(context.Background())
+			if err == nil && len(positions) == 1 {
+				positions[0].Quantity = float64(i)
+			}
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			body := tc.body
+			f.handle(tc.route, func(fakeOkxRequest) string {
+				time.Sleep(10 * time.Millisecond) // 请求进行中让其余调用方到达
+				return body
+			})
+			clk := testutil.NewFakeClock(time.Unix(1700000000, 0))
+			tr := f.trader(t, WithCacheDuration(ttl))
+			tr.SetClock(clk)
+
+			assertOneFetchPerWindow(t, clk, ttl, func() int { return f.calls(tc.route) }, func(i int) error {
+				return tc.get(tr, i)
+			})
+		})
+	}
+}
+
+// TestBybitCacheSingleFlight Bybit余额与持仓：缓存过期时并发查询合并为一次请求，各调用方得到独立副本
+func TestBybitCacheSingleFlight(t *testing.T) {
+	const ttl = 15 * time.Second
+	for _, tc := range []struct {
+		name   string
+		path   string
+		result string
+		get    func(tr *BybitTrader, i int) error
+	}{
+		{"balance", "/v5/account/wallet-balance",
+			`{"list":[{"totalWalletBalance":"1000","totalAvailableBalance":"900","totalPerpUPL":"0","coin":[]}]}`,
+			func(tr *BybitTrader, i int) error {
+				balance, err := tr.Balance(context.Background())
+				if err == nil {
+					balance.AvailableBalance = float64(i)
+				}
+				return err
+			}},
+		{"positions", "/v5/position/list",
+			`{"list":[{"symbol":"BTCUSDT","side":"Buy","size":"0.01","avgPrice":"50000","markPrice":"50000","leverage":"5","positionIdx":0}],"nextPageCursor":""}`,
+			func(tr *BybitTrader, i int) error {
+				positions, err := tr.Positions(context.Background())
+				if err == nil && len(positions) == 1 {
+					positions[0].Quantity = float64(i)
+				}
+				return err
+			}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var upstream atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.path {
+					http.NotFound(w, r)
+					return
+				}
+				upstream.Add(1)
+				time.Sleep(10 * time.Millisecond) // 请求进行中让其余调用方到达
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"retCode":0,"retMsg":"OK","result":`+tc.result+`}`)
+			}))
+			t.Cleanup(srv.Close)
+			clk := testutil.NewFakeClock(time.Unix(1700000000, 0))
+			tr := NewBybitTrader("key", "secret", true)
+			tr.baseURL = srv.URL
+			tr.cacheDuration = ttl
+			tr.SetClock(clk)
+
+			assertOneFetchPerWindow(t, clk, ttl, func() int { return int(upstream.Load()) }, func(i int) error {
+				return tc.get(tr, i)
+			})
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStat 单个缓存的运行统计
+type CacheStat struct {
+	Name        string    `json:"name"`
+	LastRefresh time.Time `json:"last_refresh"`
+	AgeSeconds  float64   `json:"age_seconds"` // 距上次刷新的秒数（从未刷新为-1）
+	Hits        int64     `json:"hits"`
+	Misses      int64     `json:"misses"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// CacheStatsReporter 可选接口：返回交易器各缓存（余额、持仓、交易规则、价格）的统计
+type CacheStatsReporter interface {
+	CacheStats() []CacheStat
+}
+
+// CacheFreshnessReporter 可选接口：余额与持仓缓存的新鲜度，AutoTrader据此拒绝在过期数据上交易
+type CacheFreshnessReporter interface {
+	LastRefreshed() time.Time // 余额与持仓中较早的一次刷新时间（从未刷新为零值）
+	Staleness() time.Duration // 距LastRefreshed的时长
+}
+
+// BackgroundRefresher 可选接口：后台定时刷新余额与持仓缓存，ctx结束时停止，
+// 返回的channel在后台goroutine退出后关闭
+type BackgroundRefresher interface {
+	StartBackgroundRefresh(ctx context.Context) <-chan struct{}
+}
+
+// cacheCounter 缓存命中统计
+// 只使用原子操作，读路径上不引入额外的锁竞争
+type cacheCounter struct {
+	hits        atomic.Int64
+	misses      atomic.Int64
+	lastRefresh atomic.Int64 // UnixNano
+	lastError   atomic.Value // string
+}
+
+func (c *cacheCounter) hit() {
+	c.hits.Add(1)
+}
+
+func (c *cacheCounter) miss() {
+	c.misses.Add(1)
+}
+
+// refreshed 记录一次刷新结果，成功时清除上次的错误
+func (c *cacheCounter) refreshed(now time.Time, err error) {
+	if err != nil {
+		c.lastError.Store(err.Error())
+		return
+	}
+	c.lastRefresh.Store(now.UnixNano())
+	c.lastError.Store("")
+}
+
+func (c *cacheCounter) stat(name string, now time.Time) CacheStat {
+	s := CacheStat{
+		Name:       name,
+		AgeSeconds: -1,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+	}
+	if ts := c.lastRefresh.Load(); ts > 0 {
+		s.LastRefresh = time.Unix(0, ts)
+		s.AgeSeconds = now.Sub(s.LastRefresh).Seconds()
+	}
+	s.LastError, _ = c.lastError.Load().(string)
+	return s
+}
@@ -0,0 +1,151 @@
+package trader
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// 数量、价格、名义价值与盈亏汇总统一使用decimal计算，避免float64误差（如0.30000000000000004）
+// 只在调用交易所API时转换为字符串或float64
+//
+// 取整约定：
+//   - 数量：向零截断到步长/精度，格式化后的下单数量不会超过请求数量
+//   - 价格：四舍五入到tick size/精度
+//   - 金额：decimal累加后再转换为float64
+
+// toDecimal float64转decimal（按最短十进制表示转换，0.1 即 0.1）
+func toDecimal(v float64) decimal.Decimal {
+	return decimal.NewFromFloat(v)
+}
+
+// decimalFloat decimal转float64（仅在API边界使用）
+func decimalFloat(d decimal.Decimal) float64 {
+	f, _ := d.Float64()
+	return f
+}
+
+// floorToStep 向零截断到step的整数倍（step<=0时原样返回）
+func floorToStep(value, step float64) decimal.Decimal {
+	v := toDecimal(value)
+	if step <= 0 {
+		return v
+	}
+	s := toDecimal(step)
+	return v.Div(s).Truncate(0).Mul(s)
+}
+
+// roundToStep 四舍五入到step的整数倍（step<=0时原样返回）
+func roundToStep(value, step float64) decimal.Decimal {
+	v := toDecimal(value)
+	if step <= 0 {
+		return v
+	}
+	s := toDecimal(step)
+	return v.Div(s).Round(0).Mul(s)
+}
+
+// ceilToStep 向上取整到step的整数倍（step<=0时原样返回）
+func ceilToStep(value, step float64) decimal.Decimal {
+	v := toDecimal(value)
+	if step <= 0 {
+		return v
+	}
+	s := toDecimal(step)
+	return v.Div(s).Ceil().Mul(s)
+}
+
+// stepPrecision 步长的小数位数（去掉末尾的0后计算，步长>=1时为0）
+// 直接使用交易所返回的十进制字符串解析的decimal，支持 0.00100000、10、1e-8 等格式
+func stepPrecision(step decimal.Decimal) int {
+	coef, exp := new(big.Int).Abs(step.Coefficient()), step.Exponent()
+	ten, q, r := big.NewInt(10), new(big.Int), new(big.Int)
+	for exp < 0 && coef.Sign() != 0 {
+		if q.QuoRem(coef, ten, r); r.Sign() != 0 {
+			break
+		}
+		coef.Set(q)
+		exp++
+	}
+	if exp >= 0 || coef.Sign() == 0 {
+		return 0
+	}
+	return int(-exp)
+}
+
+// floorToPrecision 向零截断到指定小数位
+func floorToPrecision(value float64, precision int) decimal.Decimal {
+	return toDecimal(value).Truncate(int32(precision))
+}
+
+// roundToPrecision 四舍五入到指定小数位
+func roundToPrecision(value float64, precision int) decimal.Decimal {
+	return toDecimal(value).Round(int32(precision))
+}
+
+// quantityForNotional 按名义价值和价格计算数量（price<=0时返回0）
+func quantityForNotional(notional, price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	return decimalFloat(toDecimal(notional).Div(toDecimal(price)))
+}
+
+// notionalValue 名义价值 = 数量 × 价格
+func notionalValue(quantity, price float64) float64 {
+	return decimalFloat(toDecimal(quantity).Mul(toDecimal(price)))
+}
+
+// marginRequired 占用保证金 = 数量 × 价格 / 杠杆（leverage<=0时按1倍计算）
+func marginRequired(quantity, price float64, leverage int) float64 {
+	if leverage <= 0 {
+		leverage = 1
+	}
+	return decimalFloat(toDecimal(quantity).Mul(toDecimal(price)).Div(decimal.NewFromInt(int64(leverage))))
+}
+
+// sumFloat64 decimal累加（盈亏、保证金汇总）
+func sumFloat64(values ...float64) float64 {
+	total := decimal.Zero
+	for _, v := range values {
+		total = total.Add(toDecimal(v))
+	}
+	return decimalFloat(total)
+}
+
+// ErrBelowMinSize 下单数量低于交易所最小下单数量（使用 errors.Is 判断）
+var ErrBelowMinSize = newSentinelError(ErrCodeBelowMinSize, "err_below_min_size")
+
+// MinSizeError 带最小下单量的低于最小下单量错误，调用方可据此提高数量或放弃下单
+type MinSizeError struct {
+	Symbol      string
+	Quantity    float64 // 请求数量（币）
+	MinQuantity float64 // 最小下单数量（币）
+	Notional    float64 // 请求的名义价值（USDT，未知时为0）
+	MinNotional float64 // 最小下单量对应的名义价值（USDT，未知时为0）
+}
+
+func (e *MinSizeError) Error() string {
+	return msg("err_below_min_size_detail", e.Symbol, e.Quantity, e.Notional, e.MinQuantity, e.MinNotional)
+}
+
+func (e *MinSizeError) ErrorCode() ErrorCode {
+	return ErrCodeBelowMinSize
+}
+
+// Is 使 errors.Is(err, ErrBelowMinSize) 成立
+func (e *MinSizeError) Is(target error) bool {
+	return target == ErrBelowMinSize
+}
+
+// floorToLot 向零截断到lotSz的整数倍，结果小于minSz时返回 ErrBelowMinSize
+// 适用于lotSz不是10的整数次幂的交易对（如lotSz=5时 7.3 -> 5）
+func floorToLot(quantity, lotSz, minSz float64) (decimal.Decimal, error) {
+	floored := floorToStep(quantity, lotSz)
+	if minSz > 0 && floored.LessThan(toDecimal(minSz)) {
+		return floored, fmt.Errorf("数量 %s 按步长 %s 取整后为 %s，%w %s",
+			toDecimal(quantity).String(), toDecimal(lotSz).String(), floored.String(), ErrBelowMinSize, toDecimal(minSz).String())
+	}
+	return floored, nil
+}
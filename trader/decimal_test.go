@@ -0,0 +1,154 @@
+package trader
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// 数量的取整约定：截断后（包括转换回float64与格式化为字符串后）不超过请求数量，且与请求数量相差不到一个步长
+var decimalTestSteps = []float64{0.00000001, 0.0001, 0.001, 0.01, 0.1, 0.5, 1, 5, 10, 100}
+
+func checkFloorRoundTrip(t *testing.T, quantity, step float64) {
+	t.Helper()
+	floored := floorToStep(quantity, step)
+	f := decimalFloat(floored)
+	if f > quantity {
+		t.Fatalf("floorToStep(%v, %v) = %v，超过请求数量", quantity, step, f)
+	}
+	if s, err := strconv.ParseFloat(floored.String(), 64); err != nil || s > quantity {
+		t.Fatalf("floorToStep(%v, %v) 格式化为 %q，超过请求数量", quantity, step, floored.String())
+	}
+	if gap := floored.Sub(toDecimal(quantity)).Abs(); !gap.LessThan(toDecimal(step)) {
+		t.Fatalf("floorToStep(%v, %v) = %v，与请求数量相差不小于一个步长", quantity, step, floored)
+	}
+	if !floored.Mod(toDecimal(step)).IsZero() {
+		t.Fatalf("floorToStep(%v, %v) = %v，不是步长的整数倍", quantity, step, floored)
+	}
+}
+
+func TestFloorToStepNeverExceedsQuantity(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		step := decimalTestSteps[rng.Intn(len(decimalTestSteps))]
+		// 覆盖小数量与大数量，以及float64误差典型的 0.1+0.2 之类的值
+		quantity := rng.Float64() * math.Pow(10, float64(rng.Intn(8)-3))
+		checkFloorRoundTrip(t, quantity, step)
+	}
+	for _, q := range []float64{0.1 + 0.2, 0.3, 1.0 - 0.9, 7.3, 0.30000000000000004, 2.675, 1e-9} {
+		for _, step := range decimalTestSteps {
+			checkFloorRoundTrip(t, q, step)
+		}
+	}
+}
+
+func TestFloorToPrecisionNeverExceedsQuantity(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 20000; i++ {
+		precision := rng.Intn(9)
+		quantity := rng.Float64() * math.Pow(10, float64(rng.Intn(8)-3))
+		floored := floorToPrecision(quantity, precision)
+		if f := decimalFloat(floored); f > quantity {
+			t.Fatalf("floorToPrecision(%v, %d) = %v，超过请求数量", quantity, precision, f)
+		}
+		if floored.Exponent() < -int32(precision) {
+			t.Fatalf("floorToPrecision(%v, %d) = %v，小数位超过精度", quantity, precision, floored)
+		}
+	}
+}
+
+func TestDecimalAvoidsFloatArtifacts(t *testing.T) {
+	if got := floorToStep(0.1+0.2, 0.1).String(); got != "0.3" {
+		t.Errorf("floorToStep(0.1+0.2, 0.1) = %s, want 0.3", got)
+	}
+	if got := sumFloat64(0.1, 0.2); got != 0.3 {
+		t.Errorf("sumFloat64(0.1, 0.2) = %v, want 0.3", got)
+	}
+	if got := notionalValue(0.3, 3); got != 0.9 {
+		t.Errorf("notionalValue(0.3, 3) = %v, want 0.9", got)
+	}
+}
+
+func FuzzFloorToStep(f *testing.F) {
+	f.Add(0.30000000000000004, 0.1)
+	f.Add(7.3, 5.0)
+	f.Add(123.456, 0.001)
+	f.Fuzz(func(t *testing.T, quantity, step float64) {
+		if math.IsNaN(quantity) || math.IsInf(quantity, 0) || quantity < 0 || quantity > 1e12 {
+			t.Skip()
+		}
+		if math.IsNaN(step) || step < 1e-8 || step > 1e6 {
+			t.Skip()
+		}
+		checkFloorRoundTrip(t, quantity, step)
+	})
+}
+
+func TestStepPrecision(t *testing.T) {
+	for _, tc := range []struct {
+		step string
+		want int
+	}{
+		{"0.001", 3},
+		{"0.00100000", 3},
+		{"0.1", 1},
+		{"0.5", 1},
+		{"0.25", 2},
+		{"1", 0},
+		{"1.000", 0},
+		{"10", 0},
+		{"100", 0},
+		{"1e2", 0},
+		{"1e-8", 8},
+		{"0.00000001", 8},
+		{"1E-7", 7},
+		{"5e-5", 5},
+		{"0", 0},
+	} {
+		step, err := decimal.NewFromString(tc.step)
+		if err != nil {
+			t.Fatalf("无效的步长 %q: %v", tc.step, err)
+		}
+		if got := stepPrecision(step); got != tc.want {
+			t.Errorf("stepPrecision(%s) = %d, want %d", tc.step, got, tc.want)
+		}
+	}
+}
+
+// FuzzStepPrecision 10^-k 的各种写法（定点、末尾补0、科学计数法）精度都为k
+func FuzzStepPrecision(f *testing.F) {
+	f.Add(uint8(3), uint8(0), false)
+	f.Add(uint8(8), uint8(4), true)
+	f.Add(uint8(0), uint8(2), false)
+	f.Fuzz(func(t *testing.T, k, zeros uint8, scientific bool) {
+		k, zeros = k%19, zeros%10
+		s := "1e-" + strconv.Itoa(int(k))
+		if !scientific {
+			s = "1"
+			if k > 0 {
+				s = "0." + strings.Repeat("0", int(k)-1) + "1"
+			}
+			if zeros > 0 {
+				if k == 0 {
+					s += "."
+				}
+				s += strings.Repeat("0", int(zeros))
+			}
+		}
+		step, err := decimal.NewFromString(s)
+		if err != nil {
+			t.Fatalf("无效的步长 %q: %v", s, err)
+		}
+		if got := stepPrecision(step); got != int(k) {
+			t.Fatalf("stepPrecision(%s) = %d, want %d", s, got, k)
+		}
+		// 按精度截断后恰好是步长的整数倍
+		if !floorToPrecision(1.23456789012345678, stepPrecision(step)).Mod(step).IsZero() {
+			t.Fatalf("按精度 %d 截断后不是步长 %s 的整数倍", k, s)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package trader
+
+import (
+	"errors"
+	"nofx/clock"
+	"sync"
+	"time"
+)
+
+// ErrorRateConfig API错误率监控配置
+type ErrorRateConfig struct {
+	Window          time.Duration // 滑动窗口长度（默认5分钟）
+	Threshold       float64       // 错误率超过该百分比时暂停开新仓（默认50）
+	ResumeThreshold float64       // 错误率回落到该百分比以下时恢复（默认20）
+	MinSamples      int           // 窗口内最少调用次数，不足时不做判断（默认10）
+}
+
+// DefaultErrorRateConfig 默认错误率监控配置
+func DefaultErrorRateConfig() ErrorRateConfig {
+	return ErrorRateConfig{
+		Window:          5 * time.Minute,
+		Threshold:       50,
+		ResumeThreshold: 20,
+		MinSamples:      10,
+	}
+}
+
+// errorRateBucket 单个时间桶内的调用统计
+type errorRateBucket struct {
+	start  int64 // 桶起始时间（按bucketSize取整后的UnixNano）
+	total  int
+	failed int
+}
+
+// ErrorRateMonitor 滑动窗口API错误率监控
+// 错误率过高时暂停开新仓（平仓和止损止盈管理不受影响），恢复后自动解除
+type ErrorRateMonitor struct {
+	config     ErrorRateConfig
+	bucketSize time.Duration
+	buckets    []errorRateBucket
+
+	paused      bool
+	pausedSince time.Time
+	onChange    func(paused bool, rate float64)
+
+	clock clock.Clock
+	mu    sync.Mutex
+}
+
+// NewErrorRateMonitor 创建错误率监控器，未设置的字段使用默认值
+func NewErrorRateMonitor(config ErrorRateConfig) *ErrorRateMonitor {
+	return NewErrorRateMonitorWithClock(config, clock.Real())
+}
+
+// NewErrorRateMonitorWithClock 使用指定时间源创建错误率监控器
+func NewErrorRateMonitorWithClock(config ErrorRateConfig, clk clock.Clock) *ErrorRateMonitor {
+	defaults := DefaultErrorRateConfig()
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = defaults.Threshold
+	}
+	if config.ResumeThreshold <= 0 || config.ResumeThreshold > config.Threshold {
+		config.ResumeThreshold = config.Threshold * defaults.ResumeThreshold / defaults.Threshold
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = defaults.MinSamples
+	}
+
+	// 窗口切成30个桶，桶越小统计越平滑
+	bucketSize := config.Window / 30
+	if bucketSize < time.Second {
+		bucketSize = time.Second
+	}
+
+	return &ErrorRateMonitor{
+		config:     config,
+		bucketSize: bucketSize,
+		buckets:    make([]errorRateBucket, int(config.Window/bucketSize)+1),
+		clock:      clock.OrReal(clk),
+	}
+}
+
+// OnChange 设置暂停/恢复状态变化时的回调（用于告警）
+func (m *ErrorRateMonitor) OnChange(fn func(paused bool, rate float64)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = fn
+}
+
+// ObserveCall 实现CallObserver，记录一次API调用结果
+// 平仓时持仓已不存在属于正常业务结果，不计为API错误
+func (m *ErrorRateMonitor) ObserveCall(method string, duration time.Duration, err error) {
+	m.Record(err == nil || errors.Is(err, ErrPositionNotFound))
+}
+
+// Record 记录一次调用结果
+func (m *ErrorRateMonitor) Record(success bool) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	b := m.bucketAt(now)
+	b.total++
+	if !success {
+		b.failed++
+	}
+	changed, paused, rate := m.evaluateLocked(now)
+	callback := m.onChange
+	m.mu.Unlock()
+
+	if changed && callback != nil {
+		callback(paused, rate)
+	}
+}
+
+// Rate 返回当前窗口内的错误率（百分比）和调用次数
+func (m *ErrorRateMonitor) Rate() (float64, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rateLocked(m.clock.Now())
+}
+
+// EntriesPaused 是否因错误率过高暂停开新仓
+// 即使没有新的调用，也会在窗口滑过后重新评估，保证能自动恢复
+func (m *ErrorRateMonitor) EntriesPaused() bool {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	changed, paused, rate := m.evaluateLocked(now)
+	callback := m.onChange
+	m.mu.Unlock()
+
+	if changed && callback != nil {
+		callback(paused, rate)
+	}
+	return paused
+}
+
+// Status 返回监控状态（用于API）
+func (m *ErrorRateMonitor) Status() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rate, total := m.rateLocked(m.clock.Now())
+	status := map[string]interface{}{
+		"error_rate_pct":   rate,
+		"sample_count":     total,
+		"window":           m.config.Window.String(),
+		"threshold_pct":    m.config.Threshold,
+		"resume_threshold": m.config.ResumeThreshold,
+		"entries_paused":   m.paused,
+	}
+	if m.paused {
+		status["paused_since"] = m.pausedSince.Format(time.RFC3339)
+	}
+	return status
+}
+
+// bucketAt 返回时间点所在的桶，过期桶会被重置
+func (m *ErrorRateMonitor) bucketAt(now time.Time) *errorRateBucket {
+	start := now.Truncate(m.bucketSize).UnixNano()
+	idx := int((start / int64(m.bucketSize)) % int64(len(m.buckets)))
+	b := &m.buckets[idx]
+	if b.start != start {
+		*b = errorRateBucket{start: start}
+	}
+	return b
+}
+
+// rateLocked 统计窗口内的错误率（调用方需持有锁）
+func (m *ErrorRateMonitor) rateLocked(now time.Time) (float64, int) {
+	cutoff := now.Add(-m.config.Window).UnixNano()
+	total, failed := 0, 0
+	for _, b := range m.buckets {
+		if b.start == 0 || b.start < cutoff {
+			continue
+		}
+		total += b.total
+		failed += b.failed
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(failed) / float64(total) * 100, total
+}
+
+// evaluateLocked 根据当前错误率更新暂停状态（调用方需持有锁）
+func (m *ErrorRateMonitor) evaluateLocked(now time.Time) (changed bool, paused bool, rate float64) {
+	rate, total := m.rateLocked(now)
+
+	if !m.paused && total >= m.config.MinSamples && rate > m.config.Threshold {
+		m.paused = true
+		m.pausedSince = now
+		return true, true, rate
+	}
+
+	// 样本不足时视为已恢复（说明故障期已滑出窗口）
+	if m.paused && (total < m.config.MinSamples || rate <= m.config.ResumeThreshold) {
+		m.paused = false
+		return true, false, rate
+	}
+
+	return false, m.paused, rate
+}
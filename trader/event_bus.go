@@ -0,0 +1,204 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nofx/clock"
+)
+
+// TradeEventSink 交易事件接收者（每次变更类请求产生一条与审计日志相同的记录）
+// HandleTradeEvent 在下单路径上同步调用，实现必须立即返回，不能阻塞
+type TradeEventSink interface {
+	HandleTradeEvent(entry AuditEntry)
+}
+
+// EventBusConfig 交易事件发布配置（Backend为空时不发布）
+type EventBusConfig struct {
+	Backend     string `json:"backend"`      // nats / redis
+	URL         string `json:"url"`          // nats://[user:pass@]host:4222 或 redis://[:password@]host:6379
+	TopicPrefix string `json:"topic_prefix"` // 主题前缀（默认 nofx.trade），主题为 <前缀>.<operation>
+	OutboxSize  int    `json:"outbox_size"`  // 发布失败时缓存的事件数（默认1000，满时丢弃最旧的事件）
+}
+
+// BusBackend 消息总线后端，Publish失败后下次调用时重新连接
+type BusBackend interface {
+	Publish(topic string, payload []byte) error
+	Close() error
+}
+
+const (
+	eventBusMinBackoff = time.Second
+	eventBusMaxBackoff = 30 * time.Second
+)
+
+// EventPublisher 将交易事件序列化为JSON发布到消息总线
+// 事件先进入内存outbox，由后台goroutine发布；总线短暂不可用时按退避重试，不会阻塞下单
+type EventPublisher struct {
+	backend BusBackend
+	prefix  string
+	max     int
+	clock   clock.Clock // 重试退避的时间源
+
+	mu      sync.Mutex
+	outbox  []outboxEvent
+	seq     uint64
+	closed  bool
+	notify  chan struct{}
+	done    chan struct{}
+	dropped int64
+}
+
+type outboxEvent struct {
+	seq   uint64
+	entry AuditEntry
+}
+
+// NewEventPublisher 按配置创建发布器（Backend为空时返回nil）
+func NewEventPublisher(cfg EventBusConfig) (*EventPublisher, error) {
+	var backend BusBackend
+	switch strings.ToLower(cfg.Backend) {
+	case "":
+		return nil, nil
+	case "nats":
+		backend = NewNATSBackend(cfg.URL)
+	case "redis":
+		backend = NewRedisBackend(cfg.URL)
+	default:
+		return nil, fmt.Errorf("不支持的消息总线: %s（支持 nats、redis）", cfg.Backend)
+	}
+	return NewEventPublisherWithBackend(backend, cfg.TopicPrefix, cfg.OutboxSize), nil
+}
+
+// NewEventPublisherWithBackend 使用指定后端创建发布器
+func NewEventPublisherWithBackend(backend BusBackend, prefix string, outboxSize int) *EventPublisher {
+	return newEventPublisher(backend, prefix, outboxSize, clock.Real())
+}
+
+// newEventPublisher 同NewEventPublisherWithBackend，重试退避使用clk（测试中注入假时钟）
+func newEventPublisher(backend BusBackend, prefix string, outboxSize int, clk clock.Clock) *EventPublisher {
+	if prefix == "" {
+		prefix = "nofx.trade"
+	}
+	if outboxSize <= 0 {
+		outboxSize = 1000
+	}
+	p := &EventPublisher{
+		backend: backend,
+		prefix:  strings.TrimSuffix(prefix, "."),
+		max:     outboxSize,
+		clock:   clock.OrReal(clk),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// HandleTradeEvent 实现TradeEventSink：放入outbox后立即返回，outbox满时丢弃最旧的事件
+func (p *EventPublisher) HandleTradeEvent(entry AuditEntry) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	if len(p.outbox) >= p.max {
+		p.outbox = p.outbox[1:]
+		if n := atomic.AddInt64(&p.dropped, 1); n == 1 || n%100 == 0 {
+			log.Printf("⚠ 交易事件outbox已满，已丢弃 %d 条事件", n)
+		}
+	}
+	p.seq++
+	p.outbox = append(p.outbox, outboxEvent{seq: p.seq, entry: entry})
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	p.mu.Unlock()
+}
+
+// Topic 事件的发布主题
+func (p *EventPublisher) Topic(entry AuditEntry) string {
+	return p.prefix + "." + entry.Operation
+}
+
+// Pending 返回outbox中待发布的事件数
+func (p *EventPublisher) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.outbox)
+}
+
+// Dropped 返回因outbox满而丢弃的事件数
+func (p *EventPublisher) Dropped() int64 {
+	return atomic.LoadInt64(&p.dropped)
+}
+
+// Close 停止发布并关闭后端连接（outbox中未发布的事件被丢弃）
+func (p *EventPublisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.notify)
+	p.mu.Unlock()
+	<-p.done
+	return p.backend.Close()
+}
+
+func (p *EventPublisher) loop() {
+	defer close(p.done)
+	backoff := eventBusMinBackoff
+	for range p.notify {
+		for {
+			p.mu.Lock()
+			if p.closed || len(p.outbox) == 0 {
+				p.mu.Unlock()
+				break
+			}
+			next := p.outbox[0]
+			p.mu.Unlock()
+
+			if err := p.publish(next.entry); err != nil {
+				log.Printf("⚠ 发布交易事件失败，%v 后重试（待发布 %d 条）: %v", backoff, p.Pending(), err)
+				p.clock.Sleep(backoff)
+				if backoff *= 2; backoff > eventBusMaxBackoff {
+					backoff = eventBusMaxBackoff
+				}
+				continue
+			}
+			backoff = eventBusMinBackoff
+
+			p.mu.Lock()
+			// 发布期间outbox可能因已满丢弃了队首，只在队首仍是该事件时移除
+			if len(p.outbox) > 0 && p.outbox[0].seq == next.seq {
+				p.outbox = p.outbox[1:]
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// publish 序列化并发布单个事件（序列化失败的事件直接丢弃）
+func (p *EventPublisher) publish(entry AuditEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("⚠ 交易事件序列化失败: %v", err)
+		return nil
+	}
+	return p.backend.Publish(p.Topic(entry), payload)
+}
+
+var defaultEventPublisher atomic.Pointer[EventPublisher]
+
+// SetEventPublisher 设置进程级的交易事件发布器，之后创建的AutoTrader会发布交易事件
+func SetEventPublisher(p *EventPublisher) {
+	defaultEventPublisher.Store(p)
+}
@@ -0,0 +1,232 @@
+package trader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"nofx/testutil"
+)
+
+// fakeBus 消息总线假服务器：第一个连接在收到第一条发布命令时直接断开（发布中途断线），之后的连接正常接收
+type fakeBus struct {
+	ln net.Listener
+
+	mu        sync.Mutex
+	conns     int
+	published []string // "主题 消息体"
+}
+
+func newFakeBus(t *testing.T, serve func(f *fakeBus, conn net.Conn, first bool)) *fakeBus {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeBus{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			first := f.conns == 1
+			f.mu.Unlock()
+			go func() {
+				defer conn.Close()
+				serve(f, conn, first)
+			}()
+		}
+	}()
+	return f
+}
+
+func (f *fakeBus) record(topic string, payload []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, topic+" "+string(payload))
+}
+
+// waitPublished 等待服务端收到n条消息
+func (f *fakeBus) waitPublished(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		f.mu.Lock()
+		got := append([]string(nil), f.published...)
+		f.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("服务端收到 %d 条消息, want %d", len(got), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// serveNATS NATS核心协议：INFO/CONNECT/PING/PUB
+func serveNATS(f *fakeBus, conn net.Conn, first bool) {
+	port := f.ln.Addr().(*net.TCPAddr).Port
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"host\":\"127.0.0.1\",\"port\":%d,\"max_payload\":1048576}\r\n", port)
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "PUB":
+			if first {
+				return // 发布中途断开：不读消息体、不回复PONG
+			}
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			f.record(fields[1], payload[:size])
+		}
+	}
+}
+
+// serveRedis RESP2：HELLO返回未知命令（客户端回退到RESP2），PUBLISH回复订阅者数量，其他命令回复OK
+func serveRedis(f *fakeBus, conn net.Conn, first bool) {
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		if strings.EqualFold(args[0], "HELLO") {
+			io.WriteString(conn, "-ERR unknown command 'HELLO'\r\n")
+			continue
+		}
+		if strings.EqualFold(args[0], "PUBLISH") && len(args) == 3 {
+			if first {
+				return // 发布中途断开：不回复
+			}
+			f.record(args[1], []byte(args[2]))
+			io.WriteString(conn, ":1\r\n")
+			continue
+		}
+		io.WriteString(conn, "+OK\r\n")
+	}
+}
+
+// readRESPCommand 读取一条RESP数组命令
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("无效的命令: %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// TestEventPublisherReplaysAfterDrop 发布中途连接断开：事件留在outbox中，重连后按顺序重发
+func TestEventPublisherReplaysAfterDrop(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		serve   func(f *fakeBus, conn net.Conn, first bool)
+		backend func(addr string) BusBackend
+	}{
+		{"nats", serveNATS, func(addr string) BusBackend {
+			return NewNATSBackend("nats://"+addr, nats.ReconnectWait(10*time.Millisecond))
+		}},
+		{"redis", serveRedis, func(addr string) BusBackend {
+			return NewRedisBackend("redis://" + addr)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeBus(t, tc.serve)
+			fc := testutil.NewFakeClock(time.Unix(1700000000, 0))
+			pub := newEventPublisher(tc.backend(f.ln.Addr().String()), "", 10, fc)
+
+			// 发布失败后的退避按假时间推进
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					case <-time.After(time.Millisecond):
+						if fc.Waiters() > 0 {
+							fc.Advance(eventBusMaxBackoff)
+						}
+					}
+				}
+			}()
+			defer func() {
+				close(stop)
+				wg.Wait()
+			}()
+
+			pub.HandleTradeEvent(AuditEntry{Operation: "open_long", Params: map[string]interface{}{"symbol": "BTCUSDT"}})
+			pub.HandleTradeEvent(AuditEntry{Operation: "close_long", Params: map[string]interface{}{"symbol": "BTCUSDT"}})
+
+			got := f.waitPublished(t, 2)
+			if len(got) != 2 || !strings.HasPrefix(got[0], "nofx.trade.open_long ") || !strings.HasPrefix(got[1], "nofx.trade.close_long ") {
+				t.Fatalf("收到的消息 = %v, want open_long、close_long 各一条且按顺序", got)
+			}
+			var entry AuditEntry
+			if err := json.Unmarshal([]byte(strings.SplitN(got[0], " ", 2)[1]), &entry); err != nil || entry.Params["symbol"] != "BTCUSDT" {
+				t.Errorf("消息体 = %q (%v)", got[0], err)
+			}
+			f.mu.Lock()
+			conns := f.conns
+			f.mu.Unlock()
+			if conns < 2 {
+				t.Errorf("连接数 = %d, want 断线后重新连接", conns)
+			}
+			for deadline := time.Now().Add(5 * time.Second); pub.Pending() != 0; time.Sleep(time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatalf("outbox 仍有 %d 条待发布", pub.Pending())
+				}
+			}
+			if err := pub.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sonirico/go-hyperliquid"
+)
+
+func TestHyperliquidCloseMissingPosition(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		actions []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Type   string `json:"type"`
+			Action struct {
+				Type    string `json:"type"`
+				Cancels []struct {
+					Asset int   `json:"a"`
+					Oid   int64 `json:"o"`
+				} `json:"cancels"`
+			} `json:"action"`
+		}
+		_ = json.Unmarshal(body, &req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/info" && req.Type == "clearinghouseState":
+			// 持仓已被止损平掉
+			_, _ = io.WriteString(w, `{"assetPositions":[],"marginSummary":{},"crossMarginSummary":{},"withdrawable":"0"}`)
+		case r.URL.Path == "/info" && req.Type == "openOrders":
+			_, _ = io.WriteString(w, `[{"coin":"BTC","oid":11,"side":"A","limitPx":"58000","sz":"0.1","timestamp":1},{"coin":"ETH","oid":22,"side":"A","limitPx":"3000","sz":"1","timestamp":1}]`)
+		case r.URL.Path == "/exchange":
+			mu.Lock()
+			for _, c := range req.Action.Cancels {
+				actions = append(actions, req.Action.Type+":"+hyperliquidTestCoin(c.Asset))
+			}
+			mu.Unlock()
+			_, _ = io.WriteString(w, `{"status":"ok","response":{"type":"cancel","data":{"statuses":["success"]}}}`)
+		default:
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &hyperliquid.Meta{Universe: []hyperliquid.AssetInfo{{Name: "BTC", SzDecimals: 5}, {Name: "ETH", SzDecimals: 4}}}
+	ctx := context.Background()
+	tr := &HyperliquidTrader{
+		exchange:      hyperliquid.NewExchange(ctx, key, srv.URL, meta, "", "0xwallet", &hyperliquid.SpotMeta{}),
+		ctx:           ctx,
+		walletAddr:    "0xwallet",
+		meta:          meta,
+		isCrossMargin: true,
+	}
+
+	_, err = tr.CloseLong("BTCUSDT", 0)
+	if !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("err = %v, want ErrPositionNotFound", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(actions) != 1 || actions[0] != "cancel:BTC" {
+		t.Errorf("exchange actions = %v, want [cancel:BTC]（只取消该币种的挂单，不下平仓单）", actions)
+	}
+}
+
+// hyperliquidTestCoin 测试meta中资产序号对应的币种
+func hyperliquidTestCoin(asset int) string {
+	return []string{"BTC", "ETH"}[asset]
+}
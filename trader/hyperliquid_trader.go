@@ -3,6 +3,7 @@ package trader
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -95,11 +96,12 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 	totalMarginUsed, _ := strconv.ParseFloat(accountState.MarginSummary.TotalMarginUsed, 64)
 
 	// ⚠️ 关键修复：从所有持仓中累加真正的未实现盈亏
-	totalUnrealizedPnl := 0.0
+	unrealizedPnls := make([]float64, 0, len(accountState.AssetPositions))
 	for _, assetPos := range accountState.AssetPositions {
 		unrealizedPnl, _ := strconv.ParseFloat(assetPos.Position.UnrealizedPnl, 64)
-		totalUnrealizedPnl += unrealizedPnl
+		unrealizedPnls = append(unrealizedPnls, unrealizedPnl)
 	}
+	totalUnrealizedPnl := sumFloat64(unrealizedPnls...)
 
 	// ✅ 正确理解Hyperliquid字段：
 	// AccountValue = 总账户净值（已包含空闲资金+持仓价值+未实现盈亏）
@@ -335,23 +337,21 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 
 // CloseLong 平多仓
 func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
-	// 如果数量为0，获取当前持仓数量
-	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
-				break
+	// 如果数量为0，获取当前持仓数量（全部平仓）
+	fullClose := quantity == 0
+	if fullClose {
+		pos, err := findPosition(t, symbol, PositionLong)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 	}
 
 	// Hyperliquid symbol格式
@@ -392,9 +392,11 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 
 	log.Printf("✓ 平多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
 
-	// 平仓后取消该币种的所有挂单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	// 全部平仓后取消该币种的所有挂单（止损止盈单），部分平仓保留保护单
+	if fullClose {
+		if err := t.CancelAllOrders(symbol); err != nil {
+			log.Printf("  ⚠ 取消挂单失败: %v", err)
+		}
 	}
 
 	result := make(map[string]interface{})
@@ -407,23 +409,21 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 
 // CloseShort 平空仓
 func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
-	// 如果数量为0，获取当前持仓数量
-	if quantity == 0 {
-		positions, err := t.GetPositions()
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				quantity = pos["positionAmt"].(float64)
-				break
+	// 如果数量为0，获取当前持仓数量（全部平仓）
+	fullClose := quantity == 0
+	if fullClose {
+		pos, err := findPosition(t, symbol, PositionShort)
+		if errors.Is(err, ErrPositionNotFound) {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrders(symbol); cancelErr != nil {
+				log.Printf("  ⚠ 取消挂单失败: %v", cancelErr)
 			}
+			return nil, err
 		}
-
-		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+		if err != nil {
+			return nil, err
 		}
+		quantity = pos.Quantity
 	}
 
 	// Hyperliquid symbol格式
@@ -464,9 +464,11 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 
 	log.Printf("✓ 平空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
 
-	// 平仓后取消该币种的所有挂单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	// 全部平仓后取消该币种的所有挂单（止损止盈单），部分平仓保留保护单
+	if fullClose {
+		if err := t.CancelAllOrders(symbol); err != nil {
+			log.Printf("  ⚠ 取消挂单失败: %v", err)
+		}
 	}
 
 	result := make(map[string]interface{})
@@ -524,10 +526,10 @@ func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
 }
 
 // SetStopLoss 设置止损单
-func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
 	coin := convertSymbolToHyperliquid(symbol)
 
-	isBuy := positionSide == "SHORT" // 空仓止损=买入，多仓止损=卖出
+	isBuy := positionSide.CloseSide() == SideBuy // 空仓止损=买入，多仓止损=卖出
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
@@ -561,10 +563,10 @@ func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quan
 }
 
 // SetTakeProfit 设置止盈单
-func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
 	coin := convertSymbolToHyperliquid(symbol)
 
-	isBuy := positionSide == "SHORT" // 空仓止盈=买入，多仓止盈=卖出
+	isBuy := positionSide.CloseSide() == SideBuy // 空仓止盈=买入，多仓止盈=卖出
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
@@ -602,9 +604,8 @@ func (t *HyperliquidTrader) FormatQuantity(symbol string, quantity float64) (str
 	coin := convertSymbolToHyperliquid(symbol)
 	szDecimals := t.getSzDecimals(coin)
 
-	// 使用szDecimals格式化数量
-	formatStr := fmt.Sprintf("%%.%df", szDecimals)
-	return fmt.Sprintf(formatStr, quantity), nil
+	// 按szDecimals向下截断
+	return floorToPrecision(quantity, szDecimals).StringFixed(int32(szDecimals)), nil
 }
 
 // getSzDecimals 获取币种的数量精度
@@ -625,18 +626,10 @@ func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 	return 4 // 默认精度
 }
 
-// roundToSzDecimals 将数量四舍五入到正确的精度
+// roundToSzDecimals 将数量向下截断到正确的精度（不超过请求数量）
 func (t *HyperliquidTrader) roundToSzDecimals(coin string, quantity float64) float64 {
 	szDecimals := t.getSzDecimals(coin)
-
-	// 计算倍数（10^szDecimals）
-	multiplier := 1.0
-	for i := 0; i < szDecimals; i++ {
-		multiplier *= 10.0
-	}
-
-	// 四舍五入
-	return float64(int(quantity*multiplier+0.5)) / multiplier
+	return decimalFloat(floorToPrecision(quantity, szDecimals))
 }
 
 // roundPriceToSigfigs 将价格四舍五入到5位有效数字
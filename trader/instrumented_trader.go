@@ -0,0 +1,352 @@
+package trader
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CallObserver 交易所API调用观察者
+// 每次通过Trader接口调用交易所后都会收到一次回调（指标、错误率监控等共用）
+type CallObserver interface {
+	ObserveCall(method string, duration time.Duration, err error)
+}
+
+// instrumentedTrader 为Trader接口的每次调用打点，并通知所有观察者
+// 设置审计日志后，变更类请求（下单、平仓、撤单、杠杆/保证金设置）还会写入审计记录
+type instrumentedTrader struct {
+	Trader
+	observers []CallObserver
+
+	audit         *AuditWriter
+	auditTraderID string
+	eventSinks    []TradeEventSink
+	scheduler     *RequestScheduler
+	correlationID string
+	mu            sync.RWMutex
+}
+
+// newInstrumentedTrader 包装Trader，调用结果会分发给observers
+func newInstrumentedTrader(t Trader, observers ...CallObserver) *instrumentedTrader {
+	return &instrumentedTrader{Trader: t, observers: observers}
+}
+
+// AddObserver 追加调用观察者
+func (t *instrumentedTrader) AddObserver(o CallObserver) {
+	t.observers = append(t.observers, o)
+}
+
+// SetAuditWriter 设置审计日志写入器（w为nil时只设置交易事件中的交易员ID）
+func (t *instrumentedTrader) SetAuditWriter(w *AuditWriter, traderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.audit = w
+	t.auditTraderID = traderID
+}
+
+// SetScheduler 设置请求调度器（nil表示不限速）
+func (t *instrumentedTrader) SetScheduler(s *RequestScheduler) {
+	t.scheduler = s
+}
+
+// AddTradeEventSink 追加交易事件接收者（如消息总线发布器）
+func (t *instrumentedTrader) AddTradeEventSink(sink TradeEventSink) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.eventSinks = append(t.eventSinks, sink)
+}
+
+// SetCorrelationID 设置后续请求的关联ID（如决策周期编号）
+func (t *instrumentedTrader) SetCorrelationID(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.correlationID = id
+}
+
+// CorrelationID 返回当前的关联ID
+func (t *instrumentedTrader) CorrelationID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.correlationID
+}
+
+// record 写入一条审计记录（尽力而为，不影响调用结果）
+func (t *instrumentedTrader) record(operation string, params map[string]interface{}, result map[string]interface{}, start time.Time, err error) {
+	t.mu.RLock()
+	w, traderID, correlationID, sinks := t.audit, t.auditTraderID, t.correlationID, t.eventSinks
+	t.mu.RUnlock()
+	if w == nil && len(sinks) == 0 {
+		return
+	}
+
+	entry := AuditEntry{
+		Timestamp:     start,
+		TraderID:      traderID,
+		Operation:     operation,
+		Params:        params,
+		ResponseCode:  "OK",
+		CorrelationID: correlationID,
+		DurationMs:    time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.ResponseCode = "ERROR"
+		entry.Error = err.Error()
+		entry.ErrorCode = ErrorCodeOf(err)
+	}
+	for _, key := range []string{"orderId", "clientOrderId", "algoId"} {
+		if v, ok := result[key]; ok {
+			if entry.ExchangeIDs == nil {
+				entry.ExchangeIDs = make(map[string]interface{})
+			}
+			entry.ExchangeIDs[key] = v
+		}
+	}
+	if w != nil {
+		w.Write(entry)
+	}
+	for _, sink := range sinks {
+		sink.HandleTradeEvent(entry)
+	}
+}
+
+func (t *instrumentedTrader) observe(method string, start time.Time, err error) {
+	d := time.Since(start)
+	for _, o := range t.observers {
+		o.ObserveCall(method, d, err)
+	}
+}
+
+func (t *instrumentedTrader) GetBalance() (map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityRead)
+	start := time.Now()
+	result, err := t.Trader.GetBalance()
+	t.observe("GetBalance", start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) GetPositions() ([]map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityRead)
+	start := time.Now()
+	result, err := t.Trader.GetPositions()
+	t.observe("GetPositions", start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityEntry)
+	start := time.Now()
+	result, err := t.Trader.OpenLong(symbol, quantity, leverage)
+	t.observe("OpenLong", start, err)
+	t.record("open_long", map[string]interface{}{"symbol": symbol, "quantity": quantity, "leverage": leverage}, result, start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityEntry)
+	start := time.Now()
+	result, err := t.Trader.OpenShort(symbol, quantity, leverage)
+	t.observe("OpenShort", start, err)
+	t.record("open_short", map[string]interface{}{"symbol": symbol, "quantity": quantity, "leverage": leverage}, result, start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	result, err := t.Trader.CloseLong(symbol, quantity)
+	t.observe("CloseLong", start, err)
+	t.record("close_long", map[string]interface{}{"symbol": symbol, "quantity": quantity}, result, start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	result, err := t.Trader.CloseShort(symbol, quantity)
+	t.observe("CloseShort", start, err)
+	t.record("close_short", map[string]interface{}{"symbol": symbol, "quantity": quantity}, result, start, err)
+	return result, err
+}
+
+func (t *instrumentedTrader) SetLeverage(symbol string, leverage int) error {
+	t.scheduler.Acquire(PriorityEntry)
+	start := time.Now()
+	err := t.Trader.SetLeverage(symbol, leverage)
+	t.observe("SetLeverage", start, err)
+	t.record("set_leverage", map[string]interface{}{"symbol": symbol, "leverage": leverage}, nil, start, err)
+	return err
+}
+
+func (t *instrumentedTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	t.scheduler.Acquire(PriorityEntry)
+	start := time.Now()
+	err := t.Trader.SetMarginMode(symbol, isCrossMargin)
+	t.observe("SetMarginMode", start, err)
+	t.record("set_margin_mode", map[string]interface{}{"symbol": symbol, "cross_margin": isCrossMargin}, nil, start, err)
+	return err
+}
+
+func (t *instrumentedTrader) GetMarketPrice(symbol string) (float64, error) {
+	t.scheduler.Acquire(PriorityRead)
+	start := time.Now()
+	price, err := t.Trader.GetMarketPrice(symbol)
+	t.observe("GetMarketPrice", start, err)
+	return price, err
+}
+
+func (t *instrumentedTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := t.Trader.SetStopLoss(symbol, positionSide, quantity, stopPrice)
+	t.observe("SetStopLoss", start, err)
+	t.record("set_stop_loss", t.protectiveParams(symbol, positionSide, quantity, "stop_price", stopPrice, ""), nil, start, err)
+	return err
+}
+
+func (t *instrumentedTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := t.Trader.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	t.observe("SetTakeProfit", start, err)
+	t.record("set_take_profit", t.protectiveParams(symbol, positionSide, quantity, "take_profit_price", takeProfitPrice, ""), nil, start, err)
+	return err
+}
+
+func (t *instrumentedTrader) CancelAllOrders(symbol string) error {
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := t.Trader.CancelAllOrders(symbol)
+	t.observe("CancelAllOrders", start, err)
+	t.record("cancel_all_orders", map[string]interface{}{"symbol": symbol}, nil, start, err)
+	return err
+}
+
+// CancelAllOrdersAllSymbols 撤销所有币种的挂单（交易器不支持时返回错误）
+func (t *instrumentedTrader) CancelAllOrdersAllSymbols() error {
+	canceller, ok := t.Trader.(AllSymbolsCanceller)
+	if !ok {
+		return errors.New("交易器不支持撤销所有币种的挂单")
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := canceller.CancelAllOrdersAllSymbols()
+	t.observe("CancelAllOrdersAllSymbols", start, err)
+	t.record("cancel_all_orders_all_symbols", map[string]interface{}{}, nil, start, err)
+	return err
+}
+
+// CancelOrder 撤销单个普通委托（交易器不支持时返回错误）
+func (t *instrumentedTrader) CancelOrder(symbol, orderID string) error {
+	canceller, ok := t.Trader.(OrderCanceller)
+	if !ok {
+		return errors.New("交易器不支持按订单ID撤单")
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := canceller.CancelOrder(symbol, orderID)
+	t.observe("CancelOrder", start, err)
+	t.record("cancel_order", map[string]interface{}{"symbol": symbol, "orderId": orderID}, nil, start, err)
+	return err
+}
+
+// CancelAlgoOrder 撤销单个条件单（交易器不支持时返回错误）
+func (t *instrumentedTrader) CancelAlgoOrder(symbol, algoID string) error {
+	canceller, ok := t.Trader.(OrderCanceller)
+	if !ok {
+		return errors.New("交易器不支持按订单ID撤单")
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := canceller.CancelAlgoOrder(symbol, algoID)
+	t.observe("CancelAlgoOrder", start, err)
+	t.record("cancel_algo_order", map[string]interface{}{"symbol": symbol, "algoId": algoID}, nil, start, err)
+	return err
+}
+
+// AdjustMargin 调整逐仓保证金（交易器不支持时返回错误）
+func (t *instrumentedTrader) AdjustMargin(symbol string, side PositionSide, amount float64) error {
+	adjuster, ok := t.Trader.(MarginAdjuster)
+	if !ok {
+		return errors.New("交易器不支持调整保证金")
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	err := adjuster.AdjustMargin(symbol, side, amount)
+	t.observe("AdjustMargin", start, err)
+	t.record("adjust_margin", map[string]interface{}{"symbol": symbol, "side": side, "amount": amount}, nil, start, err)
+	return err
+}
+
+// PlaceStopLoss 设置止损单并返回订单ID（交易器不支持返回ID时ID为空）
+func (t *instrumentedTrader) PlaceStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) (string, error) {
+	placer, ok := t.Trader.(ProtectiveOrderPlacer)
+	if !ok {
+		return "", t.SetStopLoss(symbol, positionSide, quantity, stopPrice)
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	id, err := placer.PlaceStopLoss(symbol, positionSide, quantity, stopPrice)
+	t.observe("SetStopLoss", start, err)
+	t.record("set_stop_loss", t.protectiveParams(symbol, positionSide, quantity, "stop_price", stopPrice, ""), map[string]interface{}{"orderId": id}, start, err)
+	return id, err
+}
+
+// PlaceTakeProfit 设置止盈单并返回订单ID（交易器不支持返回ID时ID为空）
+func (t *instrumentedTrader) PlaceTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) (string, error) {
+	placer, ok := t.Trader.(ProtectiveOrderPlacer)
+	if !ok {
+		return "", t.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	id, err := placer.PlaceTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	t.observe("SetTakeProfit", start, err)
+	t.record("set_take_profit", t.protectiveParams(symbol, positionSide, quantity, "take_profit_price", takeProfitPrice, ""), map[string]interface{}{"orderId": id}, start, err)
+	return id, err
+}
+
+// PlaceStopLossTriggeredBy 按指定触发价类型设置止损单（交易器不支持选择触发价类型时，只接受空类型）
+func (t *instrumentedTrader) PlaceStopLossTriggeredBy(symbol string, positionSide PositionSide, quantity, stopPrice float64, typ TriggerPriceType) (string, error) {
+	placer, ok := t.Trader.(TriggerPriceTrader)
+	if !ok {
+		if typ != "" {
+			return "", errors.New("交易器不支持选择触发价类型")
+		}
+		return t.PlaceStopLoss(symbol, positionSide, quantity, stopPrice)
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	id, err := placer.PlaceStopLossTriggeredBy(symbol, positionSide, quantity, stopPrice, typ)
+	t.observe("SetStopLoss", start, err)
+	t.record("set_stop_loss", t.protectiveParams(symbol, positionSide, quantity, "stop_price", stopPrice, typ), map[string]interface{}{"orderId": id}, start, err)
+	return id, err
+}
+
+// PlaceTakeProfitTriggeredBy 按指定触发价类型设置止盈单（交易器不支持选择触发价类型时，只接受空类型）
+func (t *instrumentedTrader) PlaceTakeProfitTriggeredBy(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64, typ TriggerPriceType) (string, error) {
+	placer, ok := t.Trader.(TriggerPriceTrader)
+	if !ok {
+		if typ != "" {
+			return "", errors.New("交易器不支持选择触发价类型")
+		}
+		return t.PlaceTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	}
+	t.scheduler.Acquire(PriorityCritical)
+	start := time.Now()
+	id, err := placer.PlaceTakeProfitTriggeredBy(symbol, positionSide, quantity, takeProfitPrice, typ)
+	t.observe("SetTakeProfit", start, err)
+	t.record("set_take_profit", t.protectiveParams(symbol, positionSide, quantity, "take_profit_price", takeProfitPrice, typ), map[string]interface{}{"orderId": id}, start, err)
+	return id, err
+}
+
+// protectiveParams 止损止盈的审计参数，交易器支持选择触发价类型时记录实际使用的类型
+func (t *instrumentedTrader) protectiveParams(symbol string, positionSide PositionSide, quantity float64, priceKey string, price float64, typ TriggerPriceType) map[string]interface{} {
+	params := map[string]interface{}{"symbol": symbol, "position_side": positionSide, "quantity": quantity, priceKey: price}
+	if tp, ok := t.Trader.(TriggerPriceTrader); ok {
+		if typ == "" {
+			typ = tp.TriggerPriceType()
+		}
+		params["trigger_price_type"] = typ
+	}
+	return params
+}
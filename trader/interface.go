@@ -1,5 +1,12 @@
 package trader
 
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
 // Trader 交易器统一接口
 // 支持多个交易平台（币安、Hyperliquid等）
 type Trader interface {
@@ -16,9 +23,10 @@ type Trader interface {
 	OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
 
 	// CloseLong 平多仓（quantity=0表示全部平仓）
+	// quantity=0且已无持仓时仍会取消该币种挂单，并返回 ErrPositionNotFound（调用方可视为已平仓）
 	CloseLong(symbol string, quantity float64) (map[string]interface{}, error)
 
-	// CloseShort 平空仓（quantity=0表示全部平仓）
+	// CloseShort 平空仓（quantity=0表示全部平仓，无持仓时同CloseLong）
 	CloseShort(symbol string, quantity float64) (map[string]interface{}, error)
 
 	// SetLeverage 设置杠杆
@@ -31,14 +39,280 @@ type Trader interface {
 	GetMarketPrice(symbol string) (float64, error)
 
 	// SetStopLoss 设置止损单
-	SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error
+	SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error
 
 	// SetTakeProfit 设置止盈单
-	SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error
+	SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error
 
 	// CancelAllOrders 取消该币种的所有挂单
 	CancelAllOrders(symbol string) error
 
 	// FormatQuantity 格式化数量到正确的精度
+	// 数量按交易所步长/精度向零截断（decimal计算），返回的数量不会超过请求数量
 	FormatQuantity(symbol string, quantity float64) (string, error)
 }
+
+// OpenOrder 挂单信息（普通委托与止损止盈等条件单）
+type OpenOrder struct {
+	OrderID       string    `json:"order_id"`
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"`          // BUY / SELL
+	PositionSide  string    `json:"position_side"` // LONG / SHORT / BOTH
+	Type          string    `json:"type"`          // LIMIT / STOP_MARKET / TAKE_PROFIT_MARKET ...
+	Price         float64   `json:"price"`
+	StopPrice     float64   `json:"stop_price"`
+	Quantity      float64   `json:"quantity"`
+	ReduceOnly    bool      `json:"reduce_only"`
+	ClosePosition bool      `json:"close_position"`           // 触发后平掉整个仓位
+	ActivatePrice float64   `json:"activate_price,omitempty"` // 追踪止损激活价
+	CallbackRate  float64   `json:"callback_rate,omitempty"`  // 追踪止损回调比例（%）
+	CreateTime    time.Time `json:"create_time"`
+}
+
+// OpenOrderLister 可选接口：支持查询挂单的交易器
+type OpenOrderLister interface {
+	// GetOpenOrders 获取挂单（symbol为空表示全部币种）
+	GetOpenOrders(symbol string) ([]OpenOrder, error)
+}
+
+// PendingAlgoOrderLister 可选接口：止损止盈单独立于普通挂单的交易器（如OKX的策略委托）
+// 用于发现手动平仓后遗留在交易所的止损止盈单
+type PendingAlgoOrderLister interface {
+	// GetPendingAlgoOrders 获取未触发的止损止盈与追踪止损单（symbol为空表示全部币种）
+	GetPendingAlgoOrders(symbol string) ([]OpenOrder, error)
+}
+
+// InstrumentLimits 交易对在交易所的限制（启动预检使用）
+type InstrumentLimits struct {
+	Symbol      string
+	Tradable    bool    // 是否可交易
+	MaxLeverage int     // 最大杠杆（0表示未知）
+	MinQuantity float64 // 最小下单数量
+	MaxQuantity float64 // 最大下单数量（0表示未知）
+	MinNotional float64 // 最小名义价值（USDT）
+}
+
+// InstrumentLimitsProvider 可查询交易对限制的交易器
+type InstrumentLimitsProvider interface {
+	GetInstrumentLimits(symbol string) (*InstrumentLimits, error)
+}
+
+// PermissionChecker 可自检API密钥权限的交易器
+// 返回的warnings为不影响运行的风险提示，error表示权限不足
+type PermissionChecker interface {
+	CheckPermissions() (warnings []string, err error)
+}
+
+// OrderTransportReporter 可选接口：支持多种下单通道（REST/WebSocket）的交易器，返回各通道的延迟统计
+type OrderTransportReporter interface {
+	OrderTransportStats() map[string]interface{}
+}
+
+// OrderResult 下单结果（成交均价、成交数量、手续费等）
+type OrderResult struct {
+	OrderID       string    `json:"order_id"`
+	ClientOrderID string    `json:"client_order_id,omitempty"`
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"`          // BUY / SELL
+	PositionSide  string    `json:"position_side"` // LONG / SHORT
+	Status        string    `json:"status"`        // FILLED / PARTIALLY_FILLED / NEW ...
+	FilledQty     float64   `json:"filled_qty"`    // 成交数量（币）
+	AvgPrice      float64   `json:"avg_price"`     // 成交均价
+	Fee           float64   `json:"fee"`           // 手续费（负数为支出）
+	FeeAsset      string    `json:"fee_asset,omitempty"`
+	RealizedPnL   float64   `json:"realized_pnl"` // 平仓单的已实现盈亏
+	Leverage      int       `json:"leverage,omitempty"`
+	MarginMode    string    `json:"margin_mode,omitempty"` // cross / isolated
+	SizeBump      *SizeBump `json:"size_bump,omitempty"`   // 数量被提高到最小下单量时的记录
+	Time          time.Time `json:"time"`
+}
+
+// Final 订单是否已进入最终状态（不会再有新的成交）
+func (r *OrderResult) Final() bool {
+	switch r.Status {
+	case "FILLED", "CANCELED", "MMP_CANCELED":
+		return true
+	}
+	return false
+}
+
+// SizeBump 下单数量低于最小下单量、按策略提高到最小下单量的记录
+type SizeBump struct {
+	RequestedQty  float64 `json:"requested_qty"`  // 原始数量（币）
+	BumpedQty     float64 `json:"bumped_qty"`     // 实际下单数量（币）
+	ExtraPct      float64 `json:"extra_pct"`      // 超出原始数量的百分比
+	ExtraNotional float64 `json:"extra_notional"` // 额外承担的名义价值（USDT）
+}
+
+// Map 转换为Trader接口使用的结果map
+func (r *OrderResult) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"orderId":       r.OrderID,
+		"clientOrderId": r.ClientOrderID,
+		"symbol":        r.Symbol,
+		"side":          r.Side,
+		"positionSide":  r.PositionSide,
+		"status":        r.Status,
+		"filledQty":     r.FilledQty,
+		"avgPrice":      r.AvgPrice,
+		"fee":           r.Fee,
+		"feeAsset":      r.FeeAsset,
+		"realizedPnl":   r.RealizedPnL,
+		"leverage":      r.Leverage,
+		"marginMode":    r.MarginMode,
+		"sizeBump":      r.SizeBump,
+		"time":          r.Time,
+		"result":        r,
+	}
+}
+
+// CacheRefresher 可选接口：带余额/持仓缓存的交易器
+// 开平仓成功后缓存会自动清除；需要实时数据的调用方可以直接跳过缓存查询
+type CacheRefresher interface {
+	// InvalidateCache 清除余额和持仓缓存
+	InvalidateCache()
+	// RefreshBalance 跳过缓存查询账户余额（结果同时更新缓存）
+	RefreshBalance() (map[string]interface{}, error)
+	// RefreshPositions 跳过缓存查询持仓（结果同时更新缓存）
+	RefreshPositions() ([]map[string]interface{}, error)
+}
+
+// PartialCloser 可选接口：按比例平仓（部分平仓保留剩余持仓的止损止盈）
+type PartialCloser interface {
+	ClosePositionPercent(symbol string, side PositionSide, pct float64) (map[string]interface{}, error)
+}
+
+// AllSymbolsCanceller 可选接口：撤销所有币种的挂单（CancelAllOrders只撤销指定币种）
+type AllSymbolsCanceller interface {
+	CancelAllOrdersAllSymbols() error
+}
+
+// CancelSummaryReporter 可选接口：撤销币种挂单并返回普通委托与条件单各自的结果
+type CancelSummaryReporter interface {
+	CancelAllOrdersWithSummary(symbol string) (*CancelSummary, error)
+}
+
+// ProtectiveOrderPlacer 可选接口：设置止损/止盈并返回交易所订单ID（OKX为algoId）
+// AutoTrader按持仓记录这些ID，用于后续修改、撤销与核对保护单
+type ProtectiveOrderPlacer interface {
+	PlaceStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) (string, error)
+	PlaceTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) (string, error)
+}
+
+// TrailingStopPlacer 可选接口：支持追踪止损单的交易器
+type TrailingStopPlacer interface {
+	// SetTrailingStop 下只减仓追踪止损单并返回订单ID（callbackRatio为回调比例，0.01表示1%；activationPrice为0时立即生效）
+	SetTrailingStop(symbol string, positionSide PositionSide, quantity, callbackRatio, activationPrice float64) (string, error)
+	// CancelTrailingStop 撤销追踪止损单，订单已结束时返回 ErrAlreadyGone
+	CancelTrailingStop(symbol, orderID string) error
+}
+
+// BracketResult 附带止盈止损的开仓结果
+type BracketResult struct {
+	Entry        *OrderResult `json:"entry"`
+	AttachAlgoID string       `json:"attach_algo_id,omitempty"` // 随开仓单附带的止盈止损ID（一个ID同时对应止损和止盈）
+	StopLossID   string       `json:"stop_loss_id,omitempty"`   // 附带失败后单独补挂的止损单ID
+	TakeProfitID string       `json:"take_profit_id,omitempty"` // 附带失败后单独补挂的止盈单ID
+}
+
+// BracketOrderPlacer 可选接口：开仓与止损止盈在同一请求中原子提交
+type BracketOrderPlacer interface {
+	OpenLongBracket(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) (*BracketResult, error)
+	OpenShortBracket(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) (*BracketResult, error)
+}
+
+// StopLossModifier 可选接口：修改已有止损单的触发价（不影响同币种的止盈单）
+type StopLossModifier interface {
+	// ModifyStopLoss 修改止损触发价并返回修改后的订单ID（撤单重下时ID会变化）
+	ModifyStopLoss(symbol, orderID string, newTriggerPrice float64) (string, error)
+}
+
+// OrderCanceller 可选接口：按订单ID撤销单个挂单（不影响同币种的其他挂单）
+// 订单已结束时返回 ErrAlreadyGone，并可用 ErrOrderFilled / ErrOrderCancelled / ErrOrderNotFound 区分原因
+type OrderCanceller interface {
+	CancelOrder(symbol, orderID string) error
+	// CancelAlgoOrder 撤销条件单（止损、止盈、追踪止损），已触发时返回 ErrOrderFilled
+	CancelAlgoOrder(symbol, algoID string) error
+}
+
+// OrderQuerier 可选接口：按订单ID查询成交情况（真实成交均价与手续费，用于交易记录）
+type OrderQuerier interface {
+	GetOrder(symbol, orderID string) (*OrderResult, error)
+	// WaitForFill 等待订单进入最终状态，超时返回最后一次查询的结果与 ErrTimeout
+	WaitForFill(ctx context.Context, symbol, orderID string, timeout time.Duration) (*OrderResult, error)
+}
+
+// FlattenResult 紧急平仓中单个币种的结果
+type FlattenResult struct {
+	Symbol    string         `json:"symbol"`
+	Closed    []*OrderResult `json:"closed,omitempty"`    // 平仓成交（双向持仓时可能有多空两笔）
+	Cancelled *CancelSummary `json:"cancelled,omitempty"` // 撤单结果
+	Err       error          `json:"-"`                   // 该币种的所有失败，为nil表示已完成
+	Error     string         `json:"error,omitempty"`
+}
+
+// fail 记录失败（同一币种的多个失败合并）
+func (r *FlattenResult) fail(err error) {
+	r.Err = errors.Join(r.Err, err)
+	r.Error = r.Err.Error()
+}
+
+// PositionFlattener 可选接口：一次调用平掉所有持仓并撤销所有挂单（风控熔断使用）
+type PositionFlattener interface {
+	// CloseAllPositions 单个币种失败不会中断其余操作，有失败时返回按币种的结果与汇总错误
+	CloseAllPositions(ctx context.Context) ([]*FlattenResult, error)
+}
+
+// ReduceOnlyCloser 可选接口：只减仓的部分平仓与限价平仓
+// reduceOnly为true时数量超过当前持仓返回 ErrReduceOnlyExceeded，避免单向持仓模式下反向开仓
+type ReduceOnlyCloser interface {
+	ClosePartial(ctx context.Context, symbol string, side PositionSide, quantity float64, reduceOnly bool) (*OrderResult, error)
+	CloseLongLimit(symbol string, quantity, price float64, tif TimeInForce, reduceOnly bool) (string, error)
+	CloseShortLimit(symbol string, quantity, price float64, tif TimeInForce, reduceOnly bool) (string, error)
+}
+
+// DemoTrader 可选接口：报告交易器连接的是模拟盘还是实盘（AutoTrader启动时与配置核对）
+type DemoTrader interface {
+	IsDemo() bool
+}
+
+// NotionalOpener 可选接口：按USDT名义价值开仓（合约以张为单位下单时避免币的数量与张数来回换算的误差）
+type NotionalOpener interface {
+	OpenLongNotional(ctx context.Context, symbol string, notionalUSDT float64, leverage int) (*OrderResult, error)
+	OpenShortNotional(ctx context.Context, symbol string, notionalUSDT float64, leverage int) (*OrderResult, error)
+}
+
+// OrderSizeValidator 可选接口：下单前按交易规则校验数量与名义价值
+// 低于最小下单量时返回 *MinSizeError（包含最小下单数量），调用方可提高数量或放弃下单
+type OrderSizeValidator interface {
+	ValidateOrderSize(symbol string, quantity, price float64) error
+}
+
+// StatusHandlerProvider 可选接口：只读的运行状态HTTP处理器（余额、持仓、挂单与API健康状况），
+// token不为空时请求需携带该token
+type StatusHandlerProvider interface {
+	StatusHandler(token string) http.Handler
+}
+
+// PositionChangeNotifier 可选接口：持仓变化（开仓、平仓、数量或强平价变化）时立即回调，
+// 用于风控及时响应强平或在交易所App中的手动平仓
+type PositionChangeNotifier interface {
+	OnPositionChange(fn func(PositionChange)) error
+}
+
+// EquityChangeNotifier 可选接口：账户权益变化超过阈值（百分比）时立即回调，用于回撤熔断
+type EquityChangeNotifier interface {
+	OnEquityChange(thresholdPct float64, fn func(EquityChange)) error
+}
+
+// CandleProvider 可选接口：获取K线（按时间从旧到新）
+type CandleProvider interface {
+	GetCandles(symbol, bar string, limit int) ([]Candle, error)
+	GetCandlesRange(symbol, bar string, from, to time.Time) ([]Candle, error)
+}
+
+// OrderBookProvider 可选接口：订单簿快照与市价单冲击估算（用于下单前的价差/滑点检查）
+type OrderBookProvider interface {
+	GetOrderBook(symbol string, depth int) (*OrderBook, error)
+	EstimateMarketImpact(symbol string, side Side, quantity float64) (*MarketImpact, error)
+}
@@ -0,0 +1,57 @@
+package trader
+
+import (
+	"log"
+	"sort"
+)
+
+// LeverageConfigurer 可选接口：启动时批量设置固定币种的杠杆
+type LeverageConfigurer interface {
+	// ConfigureLeverage 查询各币种当前杠杆，只修改不一致的币种（双向持仓时检查多空两侧），按币种返回结果
+	// 已确认的杠杆会被记住，之后开仓时不再调用交易所设置杠杆
+	ConfigureLeverage(leverages map[string]int) []LeverageResult
+}
+
+// LeverageResult 单个币种的杠杆设置结果
+type LeverageResult struct {
+	Symbol   string         `json:"symbol"`
+	Target   int            `json:"target"`
+	Previous map[string]int `json:"previous,omitempty"` // 修改前的杠杆（按持仓方向，全仓单向时key为空字符串）
+	Changed  bool           `json:"changed"`            // 是否调用了交易所修改杠杆
+	Err      error          `json:"-"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// sortedLeverageSymbols 按币种排序，保证每次启动的设置顺序一致
+func sortedLeverageSymbols(leverages map[string]int) []string {
+	symbols := make([]string, 0, len(leverages))
+	for symbol := range leverages {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// configureStartupLeverage 启动时按配置批量设置杠杆（交易器不支持时跳过，开仓时再设置）
+func (at *AutoTrader) configureStartupLeverage() {
+	if len(at.config.Leverages) == 0 {
+		return
+	}
+	configurer, ok := at.instrumented.Trader.(LeverageConfigurer)
+	if !ok {
+		log.Printf("⚠ [%s] 交易器不支持批量设置杠杆，将在开仓时设置", at.name)
+		return
+	}
+
+	failed := 0
+	for _, r := range configurer.ConfigureLeverage(at.config.Leverages) {
+		switch {
+		case r.Err != nil:
+			failed++
+			log.Printf("❌ [%s] %s 设置杠杆 %dx 失败（开仓时重试）: %v", at.name, r.Symbol, r.Target, r.Err)
+		case r.Changed:
+			log.Printf("⚙️  [%s] %s 杠杆 %v → %dx", at.name, r.Symbol, r.Previous, r.Target)
+		}
+	}
+	log.Printf("⚙️  [%s] 已检查 %d 个币种的杠杆，失败 %d 个", at.name, len(at.config.Leverages), failed)
+}
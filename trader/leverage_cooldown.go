@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// LeverageCooldownError 杠杆刚修改过，冷却期内下单或再次修改（RetryAfter后可重试）
+type LeverageCooldownError struct {
+	Symbol     string
+	RetryAfter time.Duration
+}
+
+func (e *LeverageCooldownError) Error() string {
+	return msg("err_leverage_cooldown_detail", e.Symbol, e.RetryAfter)
+}
+
+func (e *LeverageCooldownError) ErrorCode() ErrorCode {
+	return ErrCodeLeverageCooldown
+}
+
+// Is 使 errors.Is(err, ErrLeverageCooldown) 成立
+func (e *LeverageCooldownError) Is(target error) bool {
+	return target == ErrLeverageCooldown
+}
+
+// leverageCooldown 按币种记录最近一次修改杠杆的时间与已确认的杠杆
+// 只有同一币种在冷却期内下单时才需要等待，其他币种不受影响
+type leverageCooldown struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	changed   map[string]time.Time
+	confirmed map[string]int
+}
+
+func newLeverageCooldown(window time.Duration) *leverageCooldown {
+	return &leverageCooldown{
+		window:    window,
+		changed:   make(map[string]time.Time),
+		confirmed: make(map[string]int),
+	}
+}
+
+// isConfirmed 该币种的杠杆已确认为leverage（开仓与单独设置杠杆不重复请求）
+func (c *leverageCooldown) isConfirmed(symbol string, leverage int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return leverage > 0 && c.confirmed[symbol] == leverage
+}
+
+// confirm 记录已确认的杠杆，changed为true时同时开始冷却期
+func (c *leverageCooldown) confirm(symbol string, leverage int, changed bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.confirmed[symbol] = leverage
+	if changed {
+		c.changed[symbol] = now
+	}
+}
+
+// forget 清除已确认的杠杆（设置失败或状态未知时，下次重新设置）
+func (c *leverageCooldown) forget(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.confirmed, symbol)
+}
+
+// observe 交易所报告的杠杆与已确认的不同时（例如在网页端手动修改过）清除确认，下次开仓重新设置
+func (c *leverageCooldown) observe(symbol string, leverage int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if confirmed, ok := c.confirmed[symbol]; ok && leverage > 0 && confirmed != leverage {
+		delete(c.confirmed, symbol)
+	}
+}
+
+// wait 冷却期内等待到期（ctx结束时返回CanceledError），
+// ctx由 WithRateLimitNoWait 创建时不等待，直接返回 LeverageCooldownError
+func (c *leverageCooldown) wait(ctx context.Context, clk clock.Clock, symbol string) error {
+	c.mu.Lock()
+	changedAt, ok := c.changed[symbol]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	remaining := c.window - clk.Since(changedAt)
+	if remaining <= 0 {
+		return nil
+	}
+	if rateLimitNoWait(ctx) {
+		return &LeverageCooldownError{Symbol: symbol, RetryAfter: remaining}
+	}
+	if err := sleepContext(ctx, clk, remaining); err != nil {
+		return &CanceledError{Op: "LeverageCooldown", Class: OpMutation, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package trader
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// textLogHandler 把结构化日志格式化为一行文本，经标准库log.Logger输出
+// 未注入slog.Logger时使用，输出位置与格式（级别符号 + 消息 + key=value）与之前的Printf日志保持一致
+type textLogHandler struct {
+	out    *log.Logger
+	level  slog.Leveler
+	attrs  string // WithAttrs预先格式化的字段
+	prefix string // WithGroup的分组前缀
+}
+
+// newTextLogger 创建经out输出的slog.Logger，低于level的日志不输出
+func newTextLogger(out *log.Logger, level slog.Leveler) *slog.Logger {
+	return slog.New(&textLogHandler{out: out, level: level})
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textLogHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("❌ ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("⚠ ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("· ")
+	}
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeLogAttr(&b, h.prefix, a)
+		return true
+	})
+	return h.out.Output(0, b.String())
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		writeLogAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs = b.String()
+	return &h2
+}
+
+func (h *textLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// writeLogAttr 追加 " key=value"（分组展开为 group.key，含空格或引号的值加引号）
+func writeLogAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeLogAttr(b, prefix, ga)
+		}
+		return
+	}
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " =\"") {
+		value = strconv.Quote(value)
+	}
+	b.WriteString(" ")
+	b.WriteString(prefix)
+	b.WriteString(a.Key)
+	b.WriteString("=")
+	b.WriteString(value)
+}
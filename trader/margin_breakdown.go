@@ -0,0 +1,55 @@
+package trader
+
+// MarginBreakdown 全仓/逐仓权益拆分（金额为USD）
+// 逐仓持仓的保证金和挂单冻结的金额不能用于新开仓，开仓定量应使用全仓可用保证金
+type MarginBreakdown struct {
+	CrossAvailable float64                  `json:"cross_available"`  // 全仓可用保证金
+	IsolatedEquity float64                  `json:"isolated_equity"`  // 逐仓持仓占用的权益合计
+	FrozenInOrders float64                  `json:"frozen_in_orders"` // 挂单冻结
+	Isolated       []IsolatedPositionMargin `json:"isolated_positions,omitempty"`
+}
+
+// IsolatedPositionMargin 单个逐仓持仓的保证金
+type IsolatedPositionMargin struct {
+	Symbol string       `json:"symbol"`
+	Side   PositionSide `json:"side"`
+	Margin float64      `json:"margin"`
+}
+
+// NewMarginBreakdown 从GetBalance与GetPositions的结果中拆分全仓/逐仓权益
+// 交易器在余额中提供 crossAvailableBalance、isolatedEquity、frozenInOrders，持仓中提供 isolatedMargin(Usd)；
+// 没有提供拆分的交易器，全仓可用等于 availableBalance，逐仓权益按持仓汇总
+func NewMarginBreakdown(balance map[string]interface{}, positions []map[string]interface{}) MarginBreakdown {
+	b := MarginBreakdown{}
+	if cross, ok := balance["crossAvailableBalance"].(float64); ok {
+		b.CrossAvailable = cross
+	} else {
+		b.CrossAvailable, _ = balance["availableBalance"].(float64)
+	}
+	b.FrozenInOrders, _ = balance["frozenInOrders"].(float64)
+
+	positionsEquity := 0.0
+	for _, pos := range positions {
+		p := positionFromMap(pos)
+		if p.MarginMode != "isolated" {
+			continue
+		}
+		margin, ok := pos["isolatedMarginUsd"].(float64)
+		if !ok {
+			margin, _ = pos["isolatedMargin"].(float64)
+		}
+		b.Isolated = append(b.Isolated, IsolatedPositionMargin{Symbol: p.Symbol, Side: p.Side, Margin: margin})
+		positionsEquity = sumFloat64(positionsEquity, margin)
+	}
+	if iso, ok := balance["isolatedEquity"].(float64); ok {
+		b.IsolatedEquity = iso
+	} else {
+		b.IsolatedEquity = positionsEquity
+	}
+	return b
+}
+
+// crossAvailableBalance 余额中的全仓可用保证金（交易器未提供时为 availableBalance）
+func crossAvailableBalance(balance map[string]interface{}) float64 {
+	return NewMarginBreakdown(balance, nil).CrossAvailable
+}
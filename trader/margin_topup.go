@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+)
+
+// MarginAdjuster 可选接口：支持调整逐仓持仓保证金的交易器
+type MarginAdjuster interface {
+	// AdjustMargin 调整逐仓持仓的保证金（amount>0为追加，<0为减少，单位为结算币种），全仓持仓返回错误
+	AdjustMargin(symbol string, side PositionSide, amount float64) error
+}
+
+// MarginTopUpPolicy 逐仓持仓自动追加保证金策略（默认关闭，全仓持仓不生效）
+type MarginTopUpPolicy struct {
+	Enabled        bool
+	WarnRatio      float64 // 保证金率警戒线（OKX的mgnRatio，如3表示300%，低于1时强平）
+	Increment      float64 // 每次追加的保证金（USDT）
+	MaxPerPosition float64 // 单个持仓累计追加上限（USDT，0表示不限制）
+	MinFreeBalance float64 // 追加后可用余额不能低于该值（USDT）
+}
+
+// marginTopUpAmount 计算本次追加的金额，不满足条件时返回原因
+func (p MarginTopUpPolicy) marginTopUpAmount(added, free float64) (float64, error) {
+	amount := p.Increment
+	if p.MaxPerPosition > 0 {
+		remaining := decimalFloat(toDecimal(p.MaxPerPosition).Sub(toDecimal(added)))
+		if remaining <= 0 {
+			return 0, fmt.Errorf("已累计追加 %.2f USDT，达到单个持仓上限", added)
+		}
+		if remaining < amount {
+			amount = remaining
+		}
+	}
+	if decimalFloat(toDecimal(free).Sub(toDecimal(amount))) < p.MinFreeBalance {
+		return 0, fmt.Errorf("可用余额 %.2f USDT，追加 %.2f 后将低于保留下限 %.2f", free, amount, p.MinFreeBalance)
+	}
+	return amount, nil
+}
+
+// checkMarginTopUps 逐仓持仓的保证金率低于警戒线时，从可用余额追加保证金
+// 追加请求经instrumentedTrader写入审计日志；累计追加金额随运行状态持久化，持仓平仓后清零
+func (at *AutoTrader) checkMarginTopUps(positions []map[string]interface{}, availableBalance float64) {
+	policy := at.config.MarginTopUp
+	if !policy.Enabled || policy.WarnRatio <= 0 || policy.Increment <= 0 {
+		return
+	}
+	if _, ok := at.instrumented.Trader.(MarginAdjuster); !ok {
+		return
+	}
+
+	free := availableBalance
+	changed := false
+	for _, pos := range positions {
+		p := positionFromMap(pos)
+		ratio, _ := pos["marginRatio"].(float64)
+		if p.MarginMode != "isolated" || ratio <= 0 || ratio >= policy.WarnRatio {
+			continue
+		}
+
+		if at.checkSymbolPause(p.Symbol, false) != nil {
+			continue // 暂停且冻结持仓管理
+		}
+
+		posKey := p.Symbol + "_" + p.Side.String()
+		added := at.marginAdded[posKey]
+		amount, err := policy.marginTopUpAmount(added, free)
+		if err != nil {
+			log.Printf("⚠ [%s] %s 保证金率 %.2f 低于警戒线 %.2f，未追加保证金: %v", at.name, posKey, ratio, policy.WarnRatio, err)
+			continue
+		}
+		if err := at.instrumented.AdjustMargin(p.Symbol, p.Side, amount); err != nil {
+			log.Printf("❌ [%s] %s 追加保证金 %.2f USDT 失败: %v", at.name, posKey, amount, err)
+			continue
+		}
+
+		at.marginAdded[posKey] = sumFloat64(added, amount)
+		free = sumFloat64(free, -amount)
+		changed = true
+		log.Printf("🛟 [%s] %s 保证金率 %.2f 低于警戒线 %.2f，已追加保证金 %.2f USDT（累计 %.2f，可用余额 %.2f）",
+			at.name, posKey, ratio, policy.WarnRatio, amount, at.marginAdded[posKey], free)
+	}
+	if changed {
+		at.saveState()
+	}
+}
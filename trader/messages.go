@@ -0,0 +1,336 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Language 日志与错误信息的语言
+type Language string
+
+const (
+	LangZH Language = "zh" // 中文（默认）
+	LangEN Language = "en" // English
+)
+
+var currentLanguage atomic.Value // Language
+
+// SetLanguage 设置日志与错误信息的语言（zh/en，无法识别时使用中文）
+// 语言为进程级设置，对所有交易器生效；错误码不随语言变化
+func SetLanguage(lang string) {
+	currentLanguage.Store(parseLanguage(lang))
+}
+
+// CurrentLanguage 返回当前语言
+func CurrentLanguage() Language {
+	if lang, ok := currentLanguage.Load().(Language); ok {
+		return lang
+	}
+	return LangZH
+}
+
+func parseLanguage(lang string) Language {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "en" || strings.HasPrefix(lang, "en-") || strings.HasPrefix(lang, "en_") {
+		return LangEN
+	}
+	return LangZH
+}
+
+// messageCatalog 面向用户的信息（key -> 语言 -> fmt格式串）
+// 新增key时必须同时提供所有语言的版本
+var messageCatalog = map[string]map[Language]string{
+	// 错误
+	"err_timeout": {
+		LangZH: "交易所调用超时",
+		LangEN: "exchange call timed out",
+	},
+	"err_timeout_detail": {
+		LangZH: "%s 超时 (%s, %v)",
+		LangEN: "%s timed out (%s, %v)",
+	},
+	"err_insufficient_balance": {
+		LangZH: "余额或保证金不足",
+		LangEN: "insufficient balance or margin",
+	},
+	"err_invalid_size": {
+		LangZH: "下单数量不符合交易规则",
+		LangEN: "order size violates exchange rules",
+	},
+	"err_leverage_cooldown": {
+		LangZH: "杠杆暂时无法修改",
+		LangEN: "leverage cannot be changed right now",
+	},
+	"err_leverage_cooldown_detail": {
+		LangZH: "%s 杠杆刚修改过，%v 后可重试",
+		LangEN: "%s leverage was just changed, retry after %v",
+	},
+	"err_rate_limited": {
+		LangZH: "客户端限速，请求未发出",
+		LangEN: "client-side rate limit reached, request not sent",
+	},
+	"err_rate_limited_detail": {
+		LangZH: "%s 被客户端限速 (%s)，%v 后可重试",
+		LangEN: "%s rate limited client-side (%s), retry after %v",
+	},
+	"err_stale_price": {
+		LangZH: "行情推送已过期",
+		LangEN: "streamed price is stale",
+	},
+	"err_stale_price_detail": {
+		LangZH: "%s 的行情推送已 %v 未更新（上限 %v）",
+		LangEN: "no price update for %s in %v (limit %v)",
+	},
+	"err_insufficient_depth": {
+		LangZH: "订单簿深度不足",
+		LangEN: "insufficient order book depth",
+	},
+	"err_insufficient_depth_detail": {
+		LangZH: "%s 订单簿前 %d 档只能成交 %v（需要 %v）",
+		LangEN: "%s order book top %d levels can fill only %v (need %v)",
+	},
+	"err_canceled": {
+		LangZH: "交易所调用已取消",
+		LangEN: "exchange call canceled",
+	},
+	"err_canceled_detail": {
+		LangZH: "%s 已取消 (%s): %v",
+		LangEN: "%s canceled (%s): %v",
+	},
+	"err_order_too_large": {
+		LangZH: "单笔订单名义价值超过上限",
+		LangEN: "order notional exceeds the per-order limit",
+	},
+	"err_order_too_large_detail": {
+		LangZH: "%s 订单名义价值 %.2f USDT 超过单笔上限 %.2f USDT",
+		LangEN: "%s order notional %.2f USDT exceeds the per-order limit of %.2f USDT",
+	},
+	"err_position_not_found": {
+		LangZH: "持仓不存在",
+		LangEN: "position not found",
+	},
+	"err_position_not_found_detail": {
+		LangZH: "没有找到 %s 的%s",
+		LangEN: "no %[2]s position found for %[1]s",
+	},
+	"err_reduce_only_exceeded": {
+		LangZH: "只减仓订单数量超过当前持仓",
+		LangEN: "reduce-only order size exceeds the current position",
+	},
+	"err_reduce_only_exceeded_detail": {
+		LangZH: "%s %s 只减仓数量 %.8g 超过当前持仓 %.8g",
+		LangEN: "%s %s reduce-only size %.8g exceeds the current position %.8g",
+	},
+	"err_margin_mode_conflict": {
+		LangZH: "同一币种不能混用全仓与逐仓",
+		LangEN: "cross and isolated margin cannot be mixed on the same symbol",
+	},
+	"err_margin_mode_conflict_detail": {
+		LangZH: "%s 已有保证金模式为 %[3]s 的 %[2]s 持仓，不能按 %[4]s 下单，请先平仓后再切换保证金模式",
+		LangEN: "%s already has a %[3]s %[2]s position; cannot trade it as %[4]s until it is closed",
+	},
+	"err_below_min_size": {
+		LangZH: "低于最小下单数量",
+		LangEN: "below the minimum order size",
+	},
+	"err_below_min_size_detail": {
+		LangZH: "%s 下单数量 %.8g（约 %.2f USDT）低于最小下单数量 %.8g（约 %.2f USDT）",
+		LangEN: "%s order size %.8g (~%.2f USDT) is below the minimum order size %.8g (~%.2f USDT)",
+	},
+	"err_malformed_balance": {
+		LangZH: "余额数据格式异常",
+		LangEN: "malformed balance data",
+	},
+	"err_invalid_credentials": {
+		LangZH: "API Key、Secret或Passphrase无效",
+		LangEN: "invalid API key, secret or passphrase",
+	},
+	"err_okx_order_rejected": {
+		LangZH: "OKX %s 订单被拒绝: sCode=%d %s",
+		LangEN: "OKX %s order rejected: sCode=%d %s",
+	},
+	"err_okx_request": {
+		LangZH: "OKX %s 失败: code=%d",
+		LangEN: "OKX %s failed: code=%d",
+	},
+	"err_okx_empty_response": {
+		LangZH: "响应数据为空",
+		LangEN: "empty response data",
+	},
+	"err_already_gone": {
+		LangZH: "订单已结束（已成交、已撤销或不存在）",
+		LangEN: "order already gone (filled, cancelled or not found)",
+	},
+	"err_order_filled": {
+		LangZH: "订单已成交",
+		LangEN: "order already filled",
+	},
+	"err_order_cancelled": {
+		LangZH: "订单已撤销",
+		LangEN: "order already cancelled",
+	},
+	"err_order_not_found": {
+		LangZH: "订单不存在",
+		LangEN: "order not found",
+	},
+	"err_order_gone_detail": {
+		LangZH: "订单 %s 已结束 (%s)",
+		LangEN: "order %s already gone (%s)",
+	},
+	"err_invalid_stop_price": {
+		LangZH: "止损触发价无效",
+		LangEN: "invalid stop-loss trigger price",
+	},
+	"err_invalid_take_profit_price": {
+		LangZH: "止盈触发价无效",
+		LangEN: "invalid take-profit trigger price",
+	},
+	"err_protective_price_wrong_side": {
+		LangZH: "%s %s %s触发价 %.8g 在当前%s价 %.8g 的错误一侧，提交后会立即触发",
+		LangEN: "%s %s %s trigger %.8g is on the wrong side of the current %s price %.8g and would trigger immediately",
+	},
+	"err_protective_price_too_close": {
+		LangZH: "%s %s %s触发价 %.8g 距当前%s价 %.8g 不足 %.2f%%",
+		LangEN: "%s %s %s trigger %.8g is within %[7].2f%% of the current %[5]s price %[6].8g",
+	},
+	"err_illegal_transition": {
+		LangZH: "%s 当前状态 %s，不能转换为 %s",
+		LangEN: "%s is %s and cannot move to %s",
+	},
+
+	// 持仓方向
+	"side_long": {
+		LangZH: "多仓",
+		LangEN: "long",
+	},
+	"side_short": {
+		LangZH: "空仓",
+		LangEN: "short",
+	},
+
+	// 保护单类型
+	"kind_stop_loss": {
+		LangZH: "止损",
+		LangEN: "stop-loss",
+	},
+	"kind_take_profit": {
+		LangZH: "止盈",
+		LangEN: "take-profit",
+	},
+
+	// 币种状态机
+	"log_symbol_transition": {
+		LangZH: "🔀 [%s] %s 状态: %s → %s (%s) [%s]",
+		LangEN: "🔀 [%s] %s state: %s → %s (%s) [%s]",
+	},
+	"reason_cooldown_over": {
+		LangZH: "冷却结束",
+		LangEN: "cooldown over",
+	},
+	"reason_position_detected": {
+		LangZH: "检测到持仓",
+		LangEN: "position detected",
+	},
+	"reason_position_gone": {
+		LangZH: "持仓已被平仓（止损/止盈或手动）",
+		LangEN: "position closed (stop loss/take profit or manual)",
+	},
+	"reason_halt_over": {
+		LangZH: "风控暂停结束",
+		LangEN: "risk halt over",
+	},
+	"reason_risk_halt": {
+		LangZH: "风险控制暂停",
+		LangEN: "risk control halt",
+	},
+
+	// AutoTrader
+	"log_risk_paused": {
+		LangZH: "⏸ 风险控制：暂停交易中，剩余 %.0f 分钟",
+		LangEN: "⏸ Risk control: trading paused, %.0f minutes remaining",
+	},
+	"risk_paused": {
+		LangZH: "风险控制暂停中，剩余 %.0f 分钟",
+		LangEN: "risk control pause, %.0f minutes remaining",
+	},
+}
+
+// msg 按当前语言格式化信息，缺少当前语言时使用中文，未知key原样返回
+func msg(key string, args ...interface{}) string {
+	variants, ok := messageCatalog[key]
+	if !ok {
+		return key
+	}
+	format, ok := variants[CurrentLanguage()]
+	if !ok {
+		format = variants[LangZH]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// ErrorCode 稳定的机器可读错误码（不随语言变化，告警规则应匹配错误码而不是错误信息）
+type ErrorCode string
+
+const (
+	ErrCodeTimeout          ErrorCode = "EXCHANGE_TIMEOUT"
+	ErrCodeExchangeRejected ErrorCode = "EXCHANGE_REJECTED"
+	ErrCodeOrderTooLarge    ErrorCode = "ORDER_TOO_LARGE"
+	ErrCodePositionNotFound ErrorCode = "POSITION_NOT_FOUND"
+	ErrCodeBelowMinSize     ErrorCode = "BELOW_MIN_SIZE"
+	ErrCodeMalformedBalance ErrorCode = "MALFORMED_BALANCE"
+	ErrCodeAlreadyGone      ErrorCode = "ORDER_ALREADY_GONE"
+
+	ErrCodeInvalidStopPrice       ErrorCode = "INVALID_STOP_PRICE"
+	ErrCodeInvalidTakeProfitPrice ErrorCode = "INVALID_TAKE_PROFIT_PRICE"
+	ErrCodeInvalidCredentials     ErrorCode = "INVALID_CREDENTIALS"
+	ErrCodeCanceled               ErrorCode = "EXCHANGE_CALL_CANCELED"
+	ErrCodeOrderFilled            ErrorCode = "ORDER_FILLED"
+	ErrCodeOrderCancelled         ErrorCode = "ORDER_CANCELLED"
+	ErrCodeOrderNotFound          ErrorCode = "ORDER_NOT_FOUND"
+	ErrCodeReduceOnlyExceeded     ErrorCode = "REDUCE_ONLY_EXCEEDS_POSITION"
+	ErrCodeMarginModeConflict     ErrorCode = "MARGIN_MODE_CONFLICT"
+	ErrCodeRateLimited            ErrorCode = "RATE_LIMITED"
+	ErrCodeInsufficientBalance    ErrorCode = "INSUFFICIENT_BALANCE"
+	ErrCodeInvalidSize            ErrorCode = "INVALID_SIZE"
+	ErrCodeLeverageCooldown       ErrorCode = "LEVERAGE_COOLDOWN"
+	ErrCodeStalePrice             ErrorCode = "STALE_PRICE"
+	ErrCodeInsufficientDepth      ErrorCode = "INSUFFICIENT_DEPTH"
+)
+
+// CodedError 带错误码的错误
+type CodedError interface {
+	error
+	ErrorCode() ErrorCode
+}
+
+// ErrorCodeOf 返回错误链中第一个错误码，没有错误码时返回空字符串
+func ErrorCodeOf(err error) ErrorCode {
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return ""
+}
+
+// sentinelError 带错误码的哨兵错误，信息按当前语言输出
+type sentinelError struct {
+	code ErrorCode
+	key  string
+}
+
+func newSentinelError(code ErrorCode, key string) error {
+	return &sentinelError{code: code, key: key}
+}
+
+func (e *sentinelError) Error() string {
+	return msg(e.key)
+}
+
+func (e *sentinelError) ErrorCode() ErrorCode {
+	return e.code
+}
@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 格式串中的占位符，支持 %[n]d 形式的显式参数序号（不同语言的参数顺序可以不同）
+var formatVerb = regexp.MustCompile(`%[-+# 0]*(?:\[([0-9]+)\])?[0-9]*(?:\.[0-9]+)?([a-zA-Z%])`)
+
+// formatArgs 格式串使用的参数序号 -> 占位符类型
+func formatArgs(format string) map[int]string {
+	args := make(map[int]string)
+	next := 1
+	for _, m := range formatVerb.FindAllStringSubmatch(format, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			next, _ = strconv.Atoi(m[1])
+		}
+		args[next] = m[2]
+		next++
+	}
+	return args
+}
+
+// TestMessageCatalogComplete 每个key都有中英文两个版本，且两种语言的占位符一致
+func TestMessageCatalogComplete(t *testing.T) {
+	for key, variants := range messageCatalog {
+		zh, en := strings.TrimSpace(variants[LangZH]), strings.TrimSpace(variants[LangEN])
+		if zh == "" || en == "" {
+			t.Errorf("%s: 缺少翻译 (zh=%q en=%q)", key, zh, en)
+			continue
+		}
+		if len(variants) != 2 {
+			t.Errorf("%s: 未知语言 %v", key, variants)
+		}
+		if zv, ev := formatArgs(zh), formatArgs(en); !maps.Equal(zv, ev) {
+			t.Errorf("%s: 占位符不一致 zh=%v en=%v", key, zv, ev)
+		}
+	}
+}
+
+// TestMessageKeysExist 源码中使用的每个key都在信息目录中（未知key会原样输出给用户）
+func TestMessageKeysExist(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := regexp.MustCompile(`\bmsg\("([a-z0-9_]+)"`)
+	seen := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range used.FindAllStringSubmatch(string(src), -1) {
+			seen++
+			if _, ok := messageCatalog[m[1]]; !ok {
+				t.Errorf("%s: 信息目录中没有 %q", file, m[1])
+			}
+		}
+	}
+	if seen == 0 {
+		t.Fatal("没有找到任何 msg(...) 调用")
+	}
+}
+
+func TestMsgLanguageSwitch(t *testing.T) {
+	t.Cleanup(func() { SetLanguage("zh") })
+	SetLanguage("en-US")
+	if got := msg("err_okx_request", "PlaceOrder", 1); got != "OKX PlaceOrder failed: code=1" {
+		t.Errorf("en: %q", got)
+	}
+	if got := ErrInsufficientBalance.Error(); got != messageCatalog["err_insufficient_balance"][LangEN] {
+		t.Errorf("en sentinel: %q", got)
+	}
+	SetLanguage("fr")
+	if CurrentLanguage() != LangZH {
+		t.Errorf("无法识别的语言应使用中文, got %s", CurrentLanguage())
+	}
+	if got := msg("no_such_key"); got != "no_such_key" {
+		t.Errorf("未知key应原样返回, got %q", got)
+	}
+}
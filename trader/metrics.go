@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 交易器运行指标（计数器、仪表、直方图）使用Prometheus客户端库，注册到调用方提供的 prometheus.Registerer，
+// 由 promhttp.HandlerFor 输出；多个交易器共用一个注册表时，
+// 用 prometheus.WrapRegistererWith(prometheus.Labels{"trader": id}, reg) 区分
+
+// DefaultLatencyBuckets 请求耗时直方图的默认分桶（秒）
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// registerCollector 注册指标并返回实际使用的collector：同名同标签的指标已注册时
+// （如交易员重启后重新创建交易器）沿用已注册的；类型或标签不一致时panic（编程错误）
+func registerCollector[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	err := reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	panic(fmt.Sprintf("注册指标失败: %v", err))
+}
+
+var defaultMetrics struct {
+	sync.RWMutex
+	reg prometheus.Registerer
+}
+
+// SetMetricsRegisterer 设置进程级的指标注册表，之后创建的交易器（AutoTraderConfig.Metrics为空时）注册到该注册表
+func SetMetricsRegisterer(reg prometheus.Registerer) {
+	defaultMetrics.Lock()
+	defer defaultMetrics.Unlock()
+	defaultMetrics.reg = reg
+}
+
+// defaultMetricsRegisterer 进程级的指标注册表（未设置时为nil）
+func defaultMetricsRegisterer() prometheus.Registerer {
+	defaultMetrics.RLock()
+	defer defaultMetrics.RUnlock()
+	return defaultMetrics.reg
+}
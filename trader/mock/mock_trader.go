@@ -0,0 +1,285 @@
+// Package mock 提供用于单元测试的可编排交易器，不连接任何交易所
+//
+// 用法：
+//
+//	m := mock.New()
+//	m.SetPrice("BTCUSDT", 60000)
+//	m.FailNext("SetStopLoss", errors.New("timeout")) // 第一次设置止损失败，第二次使用默认结果成功
+//	m.Enqueue("OpenLong", map[string]interface{}{"orderId": "1"}, nil)
+//	at, _ := trader.NewAutoTrader(trader.AutoTraderConfig{Trader: m, InitialBalance: 1000})
+//	...
+//	calls := m.CallsTo("SetStopLoss") // 断言调用次数与参数
+package mock
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"nofx/trader"
+)
+
+// 编译期检查：MockTrader实现Trader接口
+var _ trader.Trader = (*MockTrader)(nil)
+
+// Call 一次方法调用的记录
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// response 预设的返回值（value为nil时使用默认结果）
+type response struct {
+	value interface{}
+	err   error
+}
+
+// MockTrader 可编排返回值的交易器
+// 每个方法优先使用Enqueue/FailNext预设的返回值（按先进先出消费），没有预设时返回默认结果：
+// 余额为Balance、持仓为Positions、价格为SetPrice设置的价格，下单与设置类方法成功
+type MockTrader struct {
+	mu        sync.Mutex
+	calls     []Call
+	queued    map[string][]response
+	prices    map[string]float64
+	balance   map[string]interface{}
+	positions []map[string]interface{}
+	orderSeq  int
+}
+
+// New 创建MockTrader（默认余额1000 USDT，无持仓）
+func New() *MockTrader {
+	return &MockTrader{
+		queued: make(map[string][]response),
+		prices: make(map[string]float64),
+		balance: map[string]interface{}{
+			"totalWalletBalance":    1000.0,
+			"availableBalance":      1000.0,
+			"totalUnrealizedProfit": 0.0,
+		},
+	}
+}
+
+// Enqueue 为method预设一次返回值（value类型需与方法的返回值一致，value为nil时使用默认结果）
+func (m *MockTrader) Enqueue(method string, value interface{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queued[method] = append(m.queued[method], response{value: value, err: err})
+}
+
+// FailNext 让method的下一次调用返回err
+func (m *MockTrader) FailNext(method string, err error) {
+	m.Enqueue(method, nil, err)
+}
+
+// SetPrice 设置GetMarketPrice的默认价格
+func (m *MockTrader) SetPrice(symbol string, price float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prices[symbol] = price
+}
+
+// SetBalance 设置GetBalance的默认结果
+func (m *MockTrader) SetBalance(balance map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.balance = balance
+}
+
+// SetPositions 设置GetPositions的默认结果
+func (m *MockTrader) SetPositions(positions []map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.positions = positions
+}
+
+// Calls 返回所有调用记录（副本）
+func (m *MockTrader) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo 返回对method的调用记录
+func (m *MockTrader) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []Call
+	for _, c := range m.calls {
+		if c.Method == method {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Reset 清除调用记录与预设的返回值
+func (m *MockTrader) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.queued = make(map[string][]response)
+}
+
+// record 记录调用并取出预设的返回值（没有预设时ok为false）
+func (m *MockTrader) record(method string, args ...interface{}) (resp response, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	queue := m.queued[method]
+	if len(queue) == 0 {
+		return response{}, false
+	}
+	m.queued[method] = queue[1:]
+	return queue[0], true
+}
+
+// orderResult 下单类方法的默认结果
+func (m *MockTrader) orderResult(symbol string) map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orderSeq++
+	return map[string]interface{}{
+		"orderId": "mock-" + strconv.Itoa(m.orderSeq),
+		"symbol":  symbol,
+		"status":  "FILLED",
+	}
+}
+
+// mapResult 将预设的返回值转换为map结果
+func mapResult(method string, resp response) (map[string]interface{}, error) {
+	if resp.err != nil || resp.value == nil {
+		return nil, resp.err
+	}
+	v, ok := resp.value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mock: %s 的预设返回值类型错误: %T", method, resp.value)
+	}
+	return v, nil
+}
+
+// GetBalance 实现trader.Trader
+func (m *MockTrader) GetBalance() (map[string]interface{}, error) {
+	if resp, ok := m.record("GetBalance"); ok && (resp.err != nil || resp.value != nil) {
+		return mapResult("GetBalance", resp)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]interface{}, len(m.balance))
+	for k, v := range m.balance {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// GetPositions 实现trader.Trader
+func (m *MockTrader) GetPositions() ([]map[string]interface{}, error) {
+	if resp, ok := m.record("GetPositions"); ok && (resp.err != nil || resp.value != nil) {
+		if resp.err != nil {
+			return nil, resp.err
+		}
+		v, ok := resp.value.([]map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("mock: GetPositions 的预设返回值类型错误: %T", resp.value)
+		}
+		return v, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]map[string]interface{}(nil), m.positions...), nil
+}
+
+// order 下单类方法：有预设时返回预设值，否则返回默认的成交结果
+func (m *MockTrader) order(method, symbol string, args ...interface{}) (map[string]interface{}, error) {
+	if resp, ok := m.record(method, append([]interface{}{symbol}, args...)...); ok && (resp.err != nil || resp.value != nil) {
+		return mapResult(method, resp)
+	}
+	return m.orderResult(symbol), nil
+}
+
+// OpenLong 实现trader.Trader
+func (m *MockTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.order("OpenLong", symbol, quantity, leverage)
+}
+
+// OpenShort 实现trader.Trader
+func (m *MockTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.order("OpenShort", symbol, quantity, leverage)
+}
+
+// CloseLong 实现trader.Trader
+func (m *MockTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.order("CloseLong", symbol, quantity)
+}
+
+// CloseShort 实现trader.Trader
+func (m *MockTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.order("CloseShort", symbol, quantity)
+}
+
+// errorOnly 只返回error的方法：有预设时返回预设的错误，否则成功
+func (m *MockTrader) errorOnly(method string, args ...interface{}) error {
+	resp, _ := m.record(method, args...)
+	return resp.err
+}
+
+// SetLeverage 实现trader.Trader
+func (m *MockTrader) SetLeverage(symbol string, leverage int) error {
+	return m.errorOnly("SetLeverage", symbol, leverage)
+}
+
+// SetMarginMode 实现trader.Trader
+func (m *MockTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return m.errorOnly("SetMarginMode", symbol, isCrossMargin)
+}
+
+// SetStopLoss 实现trader.Trader
+func (m *MockTrader) SetStopLoss(symbol string, positionSide trader.PositionSide, quantity, stopPrice float64) error {
+	return m.errorOnly("SetStopLoss", symbol, positionSide, quantity, stopPrice)
+}
+
+// SetTakeProfit 实现trader.Trader
+func (m *MockTrader) SetTakeProfit(symbol string, positionSide trader.PositionSide, quantity, takeProfitPrice float64) error {
+	return m.errorOnly("SetTakeProfit", symbol, positionSide, quantity, takeProfitPrice)
+}
+
+// CancelAllOrders 实现trader.Trader
+func (m *MockTrader) CancelAllOrders(symbol string) error {
+	return m.errorOnly("CancelAllOrders", symbol)
+}
+
+// GetMarketPrice 实现trader.Trader：没有预设时返回SetPrice设置的价格，未设置时返回错误
+func (m *MockTrader) GetMarketPrice(symbol string) (float64, error) {
+	if resp, ok := m.record("GetMarketPrice", symbol); ok && (resp.err != nil || resp.value != nil) {
+		if resp.err != nil {
+			return 0, resp.err
+		}
+		v, ok := resp.value.(float64)
+		if !ok {
+			return 0, fmt.Errorf("mock: GetMarketPrice 的预设返回值类型错误: %T", resp.value)
+		}
+		return v, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	price, ok := m.prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("mock: %s 没有设置价格", symbol)
+	}
+	return price, nil
+}
+
+// FormatQuantity 实现trader.Trader：没有预设时按3位小数格式化
+func (m *MockTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	if resp, ok := m.record("FormatQuantity", symbol, quantity); ok && (resp.err != nil || resp.value != nil) {
+		if resp.err != nil {
+			return "", resp.err
+		}
+		v, ok := resp.value.(string)
+		if !ok {
+			return "", fmt.Errorf("mock: FormatQuantity 的预设返回值类型错误: %T", resp.value)
+		}
+		return v, nil
+	}
+	return strconv.FormatFloat(quantity, 'f', 3, 64), nil
+}
@@ -0,0 +1,110 @@
+package mock_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"nofx/trader"
+	"nofx/trader/mock"
+)
+
+// newMockAutoTrader 使用MockTrader创建AutoTrader（决策日志等相对路径写入临时目录）
+func newMockAutoTrader(t *testing.T, m *mock.MockTrader) *trader.AutoTrader {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	dir := t.TempDir()
+	at, err := trader.NewAutoTrader(trader.AutoTraderConfig{
+		ID:             "mock_flow",
+		Name:           "mock_flow",
+		Exchange:       "mock",
+		Trader:         m,
+		InitialBalance: 1000,
+		StateFilePath:  filepath.Join(dir, "state.json"),
+		AuditLogPath:   filepath.Join(dir, "audit.jsonl"),
+	})
+	if err != nil {
+		t.Fatalf("NewAutoTrader: %v", err)
+	}
+	return at
+}
+
+// mockLongPosition 交易所返回的多仓（GetPositions的结果格式）
+func mockLongPosition(quantity, entryPrice float64) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":           "BTCUSDT",
+		"side":             "long",
+		"positionAmt":      quantity,
+		"entryPrice":       entryPrice,
+		"markPrice":        entryPrice,
+		"unRealizedProfit": 0.0,
+		"liquidationPrice": 40000.0,
+		"leverage":         5.0,
+	}
+}
+
+// TestAutoTraderOpenStopTakeClose 通过AutoTrader的交易器（调用监控、限速与审计包装）驱动MockTrader：
+// 开多、设置止损（第一次被限速）、设置止盈、平仓，按顺序检查交易所收到的调用与参数
+func TestAutoTraderOpenStopTakeClose(t *testing.T) {
+	m := mock.New()
+	m.SetPrice("BTCUSDT", 50000)
+	at := newMockAutoTrader(t, m)
+	tr := at.Trader()
+
+	order, err := tr.OpenLong("BTCUSDT", 0.01, 5)
+	if err != nil {
+		t.Fatalf("OpenLong: %v", err)
+	}
+	if order["orderId"] != "mock-1" || order["status"] != "FILLED" {
+		t.Errorf("开仓结果 = %v", order)
+	}
+	m.SetPositions([]map[string]interface{}{mockLongPosition(0.01, 50000)})
+
+	m.FailNext("SetStopLoss", trader.ErrRateLimited)
+	if err := tr.SetStopLoss("BTCUSDT", trader.PositionLong, 0.01, 48000); !errors.Is(err, trader.ErrRateLimited) {
+		t.Fatalf("第一次 SetStopLoss = %v, want ErrRateLimited", err)
+	}
+	if err := tr.SetStopLoss("BTCUSDT", trader.PositionLong, 0.01, 48000); err != nil {
+		t.Fatalf("SetStopLoss: %v", err)
+	}
+	if err := tr.SetTakeProfit("BTCUSDT", trader.PositionLong, 0.01, 55000); err != nil {
+		t.Fatalf("SetTakeProfit: %v", err)
+	}
+
+	positions, err := at.GetPositions()
+	if err != nil {
+		t.Fatalf("GetPositions: %v", err)
+	}
+	if len(positions) != 1 || positions[0]["quantity"] != 0.01 || positions[0]["leverage"] != 5 {
+		t.Fatalf("开仓后持仓 = %v", positions)
+	}
+
+	if _, err := tr.CloseLong("BTCUSDT", 0); err != nil {
+		t.Fatalf("CloseLong: %v", err)
+	}
+	m.SetPositions(nil)
+	if positions, err := at.GetPositions(); err != nil || len(positions) != 0 {
+		t.Fatalf("平仓后持仓 = %v, %v", positions, err)
+	}
+
+	// 变更类调用按顺序到达交易所，被限速的止损再次提交时参数不变
+	var got []mock.Call
+	for _, call := range m.Calls() {
+		switch call.Method {
+		case "GetPositions", "GetBalance", "GetMarketPrice":
+			continue
+		}
+		got = append(got, call)
+	}
+	want := []mock.Call{
+		{Method: "OpenLong", Args: []interface{}{"BTCUSDT", 0.01, 5}},
+		{Method: "SetStopLoss", Args: []interface{}{"BTCUSDT", trader.PositionLong, 0.01, 48000.0}},
+		{Method: "SetStopLoss", Args: []interface{}{"BTCUSDT", trader.PositionLong, 0.01, 48000.0}},
+		{Method: "SetTakeProfit", Args: []interface{}{"BTCUSDT", trader.PositionLong, 0.01, 55000.0}},
+		{Method: "CloseLong", Args: []interface{}{"BTCUSDT", 0.0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("交易所收到的调用 = %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	"github.com/Benjmmi/okx/responses"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// okxAmendAlgoOrder 修改策略委托请求（SDK未提供amend-algos接口）
+type okxAmendAlgoOrder struct {
+	InstID             string  `json:"instId"`
+	AlgoID             string  `json:"algoId"`
+	NewSlTriggerPx     float64 `json:"newSlTriggerPx,string"`
+	NewSlOrdPx         float64 `json:"newSlOrdPx,string"`
+	NewSlTriggerPxType string  `json:"newSlTriggerPxType,omitempty"`
+}
+
+// okxAmendAlgoResponse 修改策略委托响应
+type okxAmendAlgoResponse struct {
+	responses.Basic
+	Orders []struct {
+		AlgoID string        `json:"algoId"`
+		SCode  okx.JSONInt64 `json:"sCode"`
+		SMsg   string        `json:"sMsg"`
+	} `json:"data"`
+}
+
+// ModifyStopLoss 实现StopLossModifier：把止损单的触发价改为newTriggerPrice，返回修改后的algoId
+// 条件单与OCO单直接在交易所修改（algoId不变，止盈不受影响）；其他类型或交易所拒绝修改时
+// 撤销原止损单后按新触发价重新下单，新单下单失败时按原触发价恢复，保证持仓始终有止损
+// 持仓已不存在时返回 ErrPositionNotFound，止损单已触发或已撤销时返回 ErrAlreadyGone
+func (t *OkxTrader) ModifyStopLoss(symbol, algoID string, newTriggerPrice float64) (string, error) {
+	ctx := context.Background()
+	if newTriggerPrice <= 0 {
+		return "", fmt.Errorf("止损触发价必须大于0 (当前 %v)", newTriggerPrice)
+	}
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return "", err
+	}
+	algo, err := t.pendingAlgoOrder(ctx, instID, algoID)
+	if err != nil {
+		return "", err
+	}
+	if algo.SlTriggerPx <= 0 {
+		return "", fmt.Errorf("策略委托 %s（%s）不是止损单", algoID, algo.OrdType)
+	}
+
+	side := okxAlgoPositionSide(algo)
+	if _, err := t.GetPositionContext(ctx, symbol, side); err != nil {
+		return "", fmt.Errorf("修改止损失败: %w", err)
+	}
+	typ, err := t.resolveTriggerType(TriggerPriceType(algo.SlTriggerPxType))
+	if err != nil {
+		return "", err
+	}
+	if newTriggerPrice, err = t.roundPrice(symbol, newTriggerPrice, stopRounding(side)); err != nil {
+		return "", err
+	}
+	if err := t.checkProtectivePrice(false, symbol, side, newTriggerPrice, typ); err != nil {
+		return "", err
+	}
+
+	if algo.OrdType == okx.AlgoOrderConditional || algo.OrdType == okx.AlgoOrderOCO {
+		err := t.amendStopLoss(ctx, okxAmendAlgoOrder{
+			InstID:             instID,
+			AlgoID:             algoID,
+			NewSlTriggerPx:     newTriggerPrice,
+			NewSlOrdPx:         -1, // -1 表示触发后市价成交
+			NewSlTriggerPxType: string(typ),
+		})
+		var okxErr *OkxError
+		switch {
+		case err == nil:
+			t.logger.Info("止损已修改", "symbol", symbol, "from", float64(algo.SlTriggerPx), "to", newTriggerPrice, "triggerPxType", typ, "algoId", algoID)
+			return algoID, nil
+		case isOkxAlreadyGone(err):
+			return "", &OrderGoneError{OrderID: algoID, Reason: "止损单已触发或已撤销"}
+		case !errors.As(err, &okxErr):
+			// 超时、网络错误等结果未知，不能再撤单重下
+			return "", fmt.Errorf("修改止损失败: %w", err)
+		}
+		t.logger.Warn("交易所拒绝修改止损单，改为撤单重下", "symbol", symbol, "algoId", algoID, "err", err)
+	}
+	return t.replaceStopLoss(ctx, symbol, algo, newTriggerPrice, typ)
+}
+
+// replaceStopLoss 撤销原止损单并按新触发价重新下单，失败时按原订单参数恢复
+func (t *OkxTrader) replaceStopLoss(ctx context.Context, symbol string, algo *tradeModel.AlgoOrder, newTriggerPrice float64, typ TriggerPriceType) (string, error) {
+	summary := &CancelSummary{Symbol: symbol}
+	t.cancelAlgoOrders(ctx, []tradeReq.CancelAlgoOrder{{InstID: algo.InstID, AlgoID: algo.AlgoID}}, summary)
+	if err := summary.Err(); err != nil {
+		return "", fmt.Errorf("撤销原止损单失败: %w", err)
+	}
+	if summary.Algos.AlreadyGone > 0 {
+		return "", &OrderGoneError{OrderID: algo.AlgoID, Reason: "止损单已触发或已撤销"}
+	}
+
+	// 按原订单的数量、方向与止盈部分重下，只替换止损触发价
+	req := tradeReq.PlaceAlgoOrder{
+		InstID:     algo.InstID,
+		TdMode:     algo.TdMode,
+		Side:       algo.Side,
+		PosSide:    algo.PosSide,
+		OrdType:    algo.OrdType,
+		Sz:         float64(algo.Sz),
+		ReduceOnly: true,
+		StopOrder: tradeReq.StopOrder{
+			TpTriggerPx:     float64(algo.TpTriggerPx),
+			TpOrdPx:         float64(algo.TpOrdPx),
+			TpTriggerPxType: algo.TpTriggerPxType,
+			SlTriggerPx:     newTriggerPrice,
+			SlOrdPx:         -1,
+			SlTriggerPxType: string(typ),
+		},
+	}
+	if req.OrdType != okx.AlgoOrderOCO {
+		req.OrdType = okx.AlgoOrderConditional
+	}
+	newID, err := t.placeAlgoOrder(ctx, req)
+	if err == nil {
+		t.logger.Info("止损已重下", "symbol", symbol, "from", float64(algo.SlTriggerPx), "to", newTriggerPrice, "triggerPxType", typ, "oldAlgoId", algo.AlgoID, "algoId", newID)
+		return newID, nil
+	}
+
+	// 新止损下单失败：按原触发价恢复，不能让持仓失去止损
+	t.logger.Error("新止损单下单失败，按原触发价恢复", "symbol", symbol, "triggerPx", float64(algo.SlTriggerPx), "err", err)
+	req.SlTriggerPx = float64(algo.SlTriggerPx)
+	req.SlOrdPx = float64(algo.SlOrdPx)
+	req.SlTriggerPxType = algo.SlTriggerPxType
+	restoredID, restoreErr := t.placeAlgoOrder(context.WithoutCancel(ctx), req)
+	if restoreErr != nil {
+		t.logger.Error("恢复原止损单失败，持仓当前没有止损，请立即手动处理", "symbol", symbol, "err", restoreErr)
+		return "", fmt.Errorf("修改止损失败: %w（恢复原止损单也失败，持仓没有止损: %v）", err, restoreErr)
+	}
+	t.logger.Info("已恢复原止损单", "symbol", symbol, "algoId", restoredID)
+	return "", fmt.Errorf("修改止损失败，已恢复原止损单 %s: %w", restoredID, err)
+}
+
+// amendStopLoss 调用amend-algos修改止损触发价
+func (t *OkxTrader) amendStopLoss(ctx context.Context, req okxAmendAlgoOrder) error {
+	resp, err := okxCall(ctx, t, OpMutation, "AmendAlgoOrder", func() (okxAmendAlgoResponse, error) {
+		var resp okxAmendAlgoResponse
+		res, err := t.api().Rest.DoBatch("/api/v5/trade/amend-algos", req)
+		if err != nil {
+			return resp, err
+		}
+		defer res.Body.Close()
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Orders) == 0 {
+		if err := okxResponseError("AmendAlgoOrder", resp.Code, resp.Msg, 0, ""); err != nil {
+			return err
+		}
+		return okxEmptyResponse("AmendAlgoOrder")
+	}
+	t.InvalidateCache()
+	return okxResponseError("AmendAlgoOrder", resp.Code, resp.Msg, int64(resp.Orders[0].SCode), resp.Orders[0].SMsg)
+}
+
+// pendingAlgoOrder 按algoId查询未触发的策略委托，不存在时返回 ErrAlreadyGone
+func (t *OkxTrader) pendingAlgoOrder(ctx context.Context, instID, algoID string) (*tradeModel.AlgoOrder, error) {
+	for _, ordType := range append([]okx.AlgoOrderType{okx.AlgoOrderOCO}, okxPendingAlgoTypes...) {
+		filter := tradeReq.AlgoOrderList{InstID: instID, AlgoID: algoID, OrdType: ordType}
+		algos, err := okxCall(ctx, t, OpPrivateRead, "GetAlgoOrderList", func() (tradeResp.AlgoOrderList, error) {
+			return t.api().Rest.Trade.GetAlgoOrderList(filter, false)
+		})
+		if err == nil {
+			err = okxResponseError("GetAlgoOrderList", algos.Code, algos.Msg, 0, "")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询策略委托 %s 失败: %w", algoID, err)
+		}
+		for _, algo := range algos.AlgoOrders {
+			if algo.AlgoID == algoID {
+				return algo, nil
+			}
+		}
+	}
+	return nil, &OrderGoneError{OrderID: algoID, Reason: "策略委托不存在或已触发"}
+}
+
+// okxAlgoPositionSide 策略委托对应的持仓方向（单向持仓模式按平仓方向推断）
+func okxAlgoPositionSide(algo *tradeModel.AlgoOrder) PositionSide {
+	switch algo.PosSide {
+	case okx.PositionShortSide:
+		return PositionShort
+	case okx.PositionLongSide:
+		return PositionLong
+	}
+	if algo.Side == okx.OrderBuy {
+		return PositionShort
+	}
+	return PositionLong
+}
@@ -0,0 +1,110 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 编译期检查：OkxTrader支持后台刷新与缓存新鲜度查询
+var (
+	_ BackgroundRefresher    = (*OkxTrader)(nil)
+	_ CacheFreshnessReporter = (*OkxTrader)(nil)
+)
+
+// WithBackgroundRefresh 设置后台刷新余额与持仓的间隔（需调用StartBackgroundRefresh启动）
+// 刷新运行期间读取直接返回缓存（可能略微过期），通过Staleness判断数据是否可用于交易
+func WithBackgroundRefresh(interval time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if interval <= 0 {
+			return fmt.Errorf("后台刷新间隔必须大于0: %v", interval)
+		}
+		t.refreshInterval = interval
+		return nil
+	}
+}
+
+// StartBackgroundRefresh 实现BackgroundRefresher：立即刷新一次，之后按间隔刷新，ctx结束时停止
+// 未设置WithBackgroundRefresh时返回已关闭的channel；已在运行时返回运行中的goroutine的channel
+func (t *OkxTrader) StartBackgroundRefresh(ctx context.Context) <-chan struct{} {
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+	if t.refreshDone != nil {
+		return t.refreshDone
+	}
+	done := make(chan struct{})
+	if t.refreshInterval <= 0 {
+		close(done)
+		return done
+	}
+	t.refreshDone = done
+	t.refreshRunning.Store(true)
+	t.logger.Info("OKX 后台刷新余额与持仓", "interval", t.refreshInterval)
+
+	ticker := t.clock.NewTicker(t.refreshInterval)
+	go func() {
+		defer func() {
+			ticker.Stop()
+			// 停止后恢复按有效期使用缓存，读取不会一直返回越来越旧的数据
+			t.refreshRunning.Store(false)
+			t.refreshMu.Lock()
+			t.refreshDone = nil
+			t.refreshMu.Unlock()
+			close(done)
+		}()
+		t.refreshCaches(ctx)
+		for {
+			select {
+			case <-ticker.C():
+				t.refreshCaches(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// refreshCaches 跳过缓存查询余额与持仓（成功时更新缓存，失败时保留上次的缓存）
+func (t *OkxTrader) refreshCaches(ctx context.Context) {
+	if _, err := t.balance(ctx, true); err != nil && ctx.Err() == nil {
+		t.logger.Warn("后台刷新账户余额失败，继续使用缓存", "err", err)
+	}
+	if _, err := t.positions(ctx, true); err != nil && ctx.Err() == nil {
+		t.logger.Warn("后台刷新持仓失败，继续使用缓存", "err", err)
+	}
+}
+
+// cacheUsable 缓存是否可直接使用：后台刷新运行中（由后台保持更新），或未超过有效期
+func (t *OkxTrader) cacheUsable(cachedAt time.Time) bool {
+	return t.refreshRunning.Load() || t.clock.Since(cachedAt) < t.cacheDuration
+}
+
+// LastRefreshed 实现CacheFreshnessReporter：余额与持仓缓存中较早的一次刷新时间（任一缓存为空时返回零值）
+func (t *OkxTrader) LastRefreshed() time.Time {
+	t.balanceCacheMutex.RLock()
+	balanceAt, hasBalance := t.balanceCacheTime, t.cachedBalance != nil
+	t.balanceCacheMutex.RUnlock()
+
+	t.positionsCacheMutex.RLock()
+	positionsAt, hasPositions := t.positionsCacheTime, t.cachedPositions != nil
+	t.positionsCacheMutex.RUnlock()
+
+	if !hasBalance || !hasPositions {
+		return time.Time{}
+	}
+	if positionsAt.Before(balanceAt) {
+		return positionsAt
+	}
+	return balanceAt
+}
+
+// Staleness 实现CacheFreshnessReporter：缓存数据的年龄（缓存为空时返回最大时长）
+func (t *OkxTrader) Staleness() time.Duration {
+	last := t.LastRefreshed()
+	if last.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+	return t.clock.Since(last)
+}
@@ -0,0 +1,164 @@
+package trader
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	accountModel "github.com/Benjmmi/okx/models/account"
+)
+
+// EquityChange 账户权益（TotalWalletBalance）相对参照余额的变化超过阈值时的回调参数
+type EquityChange struct {
+	Time      time.Time `json:"time"`
+	Previous  Balance   `json:"previous"`   // 参照余额：注册时的缓存余额（没有时为第一次推送），之后为上一次回调时的余额
+	Current   Balance   `json:"current"`    // 本次推送的余额
+	ChangePct float64   `json:"change_pct"` // 权益变化百分比（下跌为负）
+}
+
+// okxEquityWatcher 一个权益变化回调及其参照余额
+type okxEquityWatcher struct {
+	thresholdPct float64
+	fn           func(EquityChange)
+	ref          *Balance
+}
+
+// okxBalanceFeed 私有WebSocket的account频道：每次推送后替换余额缓存，并检查各回调的权益变化
+type okxBalanceFeed struct {
+	hooks streamHooks[func()] // 权益回调在分发goroutine中调用
+
+	mu         sync.Mutex
+	currencies map[string]CurrencyBalance // 推送的各币种权益（事件推送只包含有变化的币种）
+	generation int64                      // 已收到全量推送的连接（与okxStream.generation相同时缓存为实时数据）
+	watchers   []*okxEquityWatcher
+}
+
+// SubscribeBalance 通过私有WebSocket订阅账户余额推送（第一次调用时登录并建立连接，断开后自动重连并重新订阅）
+// 连接正常时余额缓存随推送实时更新，GetBalance直接返回推送的数据；连接断开时恢复为REST查询与缓存
+func (t *OkxTrader) SubscribeBalance() error {
+	t.balanceFeed(true)
+	return t.privateStream(true).subscribe(map[string]string{"channel": "account"})
+}
+
+// OnEquityChange 注册权益变化回调并订阅余额推送：权益相对参照余额变化超过thresholdPct（百分比，如5表示5%）时调用，
+// 之后以本次余额为新的参照；fn在单独的goroutine中调用，可用于回撤熔断
+func (t *OkxTrader) OnEquityChange(thresholdPct float64, fn func(EquityChange)) error {
+	if thresholdPct <= 0 || math.IsNaN(thresholdPct) {
+		return errors.New("权益变化阈值必须大于0")
+	}
+	if fn == nil {
+		return errors.New("权益变化回调不能为空")
+	}
+	watcher := &okxEquityWatcher{thresholdPct: thresholdPct, fn: fn}
+	t.balanceCacheMutex.RLock()
+	if t.cachedBalance != nil && !t.cachedBalance.Stale {
+		ref := *t.cachedBalance
+		watcher.ref = &ref
+	}
+	t.balanceCacheMutex.RUnlock()
+
+	feed := t.balanceFeed(true)
+	feed.mu.Lock()
+	feed.watchers = append(feed.watchers, watcher)
+	feed.mu.Unlock()
+	return t.SubscribeBalance()
+}
+
+// BalanceStreamLive 余额缓存当前是否由WebSocket推送实时维护
+func (t *OkxTrader) BalanceStreamLive() bool {
+	feed, stream := t.balanceFeed(false), t.privateStream(false)
+	if feed == nil || stream == nil || !stream.isConnected() {
+		return false
+	}
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	return feed.generation == stream.generation.Load()
+}
+
+// balanceFeed 返回余额推送，create为true且尚未创建时创建
+func (t *OkxTrader) balanceFeed(create bool) *okxBalanceFeed {
+	t.privateMu.Lock()
+	defer t.privateMu.Unlock()
+	if t.balancesFeed == nil && create {
+		feed := &okxBalanceFeed{hooks: streamHooks[func()]{name: "余额推送"}}
+		feed.hooks.register(func(call func()) { call() })
+		t.balancesFeed = feed
+	}
+	return t.balancesFeed
+}
+
+// liveBalance 余额推送实时有效时返回缓存的余额，否则ok为false
+func (t *OkxTrader) liveBalance() (*Balance, bool) {
+	if !t.BalanceStreamLive() {
+		return nil, false
+	}
+	t.balanceCacheMutex.RLock()
+	defer t.balanceCacheMutex.RUnlock()
+	// InvalidateCache清除后先用REST重新查询一次
+	if t.cachedBalance == nil {
+		return nil, false
+	}
+	balance := *t.cachedBalance
+	return &balance, true
+}
+
+// applyBalance 处理account频道的推送：每次连接的第一条推送包含全部币种，之后的推送只更新其中的币种
+func (t *OkxTrader) applyBalance(feed *okxBalanceFeed, push okxStreamPush, generation int64, now time.Time) {
+	var rows []*accountModel.Balance
+	if err := json.Unmarshal(push.Data, &rows); err != nil || len(rows) == 0 {
+		return
+	}
+
+	feed.mu.Lock()
+	var base map[string]CurrencyBalance
+	if feed.generation == generation {
+		base = feed.currencies
+	}
+	balance, err := okxBalance(rows[0], base)
+	if err != nil {
+		// 与REST相同，不把异常数据当作真实余额；在下一次全量推送之前使用REST
+		feed.generation = 0
+		feed.mu.Unlock()
+		t.logger.Warn("余额推送数据异常，暂时改用REST查询", "err", err)
+		return
+	}
+	feed.currencies = balance.Currencies
+	feed.generation = generation
+
+	type notice struct {
+		fn     func(EquityChange)
+		change EquityChange
+	}
+	var notices []notice
+	for _, w := range feed.watchers {
+		if w.ref == nil || w.ref.TotalWalletBalance <= 0 {
+			ref := *balance
+			w.ref = &ref
+			continue
+		}
+		pct := (balance.TotalWalletBalance - w.ref.TotalWalletBalance) / w.ref.TotalWalletBalance * 100
+		if math.Abs(pct) < w.thresholdPct {
+			continue
+		}
+		notices = append(notices, notice{w.fn, EquityChange{Time: now, Previous: *w.ref, Current: *balance, ChangePct: pct}})
+		ref := *balance
+		w.ref = &ref
+	}
+	feed.mu.Unlock()
+
+	t.balanceCacheMutex.Lock()
+	t.cachedBalance = balance
+	t.balanceCacheTime = now
+	t.balanceCacheMutex.Unlock()
+
+	for _, n := range notices {
+		t.logger.Warn("账户权益变化超过阈值", "previous", n.change.Previous.TotalWalletBalance,
+			"current", n.change.Current.TotalWalletBalance, "changePct", n.change.ChangePct)
+		fn, change := n.fn, n.change
+		feed.hooks.emit(func() { fn(change) })
+	}
+}
+
+var _ EquityChangeNotifier = (*OkxTrader)(nil)
@@ -0,0 +1,70 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// okxTestBalance 账户余额数据（details为空）
+func okxTestBalance(totalEq, availEq, upl string) string {
+	return fmt.Sprintf(`{"totalEq":%q,"availEq":%q,"upl":%q,"isoEq":"0","ordFroz":"0","uTime":"1700000000000","details":[]}`, totalEq, availEq, upl)
+}
+
+// TestOkxBalanceRejectsMalformedPayload 余额字段为空或无法解析时返回错误，不把0当作真实余额
+func TestOkxBalanceRejectsMalformedPayload(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		payload   string
+		malformed bool // 是否应为 ErrMalformedBalance
+	}{
+		{"empty totalEq", okxOK(okxTestBalance("", "100", "0")), true},
+		{"blank availEq", okxOK(okxTestBalance("100", "  ", "0")), true},
+		{"garbage upl", okxOK(okxTestBalance("100", "100", "abc")), true},
+		{"NaN totalEq", okxOK(okxTestBalance("NaN", "100", "0")), true},
+		{"Inf availEq", okxOK(okxTestBalance("100", "+Inf", "0")), true},
+		{"garbage isoEq", okxOK(`{"totalEq":"100","availEq":"100","upl":"0","isoEq":"x","details":[]}`), true},
+		{"missing fields", okxOK(`{}`), true},
+		{"empty data", okxOK(), false},
+		{"data not an array", `{"code":"0","msg":"","data":"oops"}`, false},
+		{"not JSON", `<html>502 Bad Gateway</html>`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			payload := tc.payload
+			f.handle("GET /api/v5/account/balance", func(fakeOkxRequest) string { return payload })
+
+			balance, err := f.trader(t).Balance(t.Context())
+			if err == nil {
+				t.Fatalf("Balance() = %+v, want error", balance)
+			}
+			if got := errors.Is(err, ErrMalformedBalance); got != tc.malformed {
+				t.Errorf("errors.Is(%v, ErrMalformedBalance) = %v, want %v", err, got, tc.malformed)
+			}
+		})
+	}
+}
+
+// TestOkxBalanceFallsBackToCacheOnGarbage 已有有效余额时，异常数据返回上次的余额并标记为过期
+func TestOkxBalanceFallsBackToCacheOnGarbage(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/balance", okxTestBalance("1000", "800", "5"))
+	tr := f.trader(t)
+
+	good, err := tr.Balance(t.Context())
+	if err != nil || good.TotalWalletBalance != 1000 || good.Stale {
+		t.Fatalf("Balance() = %+v, %v", good, err)
+	}
+
+	f.reply("GET /api/v5/account/balance", okxTestBalance("", "", ""))
+	stale, err := tr.balance(t.Context(), true)
+	if err != nil {
+		t.Fatalf("有缓存时不应返回错误: %v", err)
+	}
+	if !stale.Stale || stale.TotalWalletBalance != 1000 || stale.AvailableBalance != 800 {
+		t.Errorf("Balance() = %+v, want 缓存的余额且 Stale=true", stale)
+	}
+	if n := f.calls("GET /api/v5/account/balance"); n != 2 {
+		t.Errorf("余额查询次数 = %d, want 2", n)
+	}
+}
@@ -0,0 +1,261 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	"github.com/Benjmmi/okx/responses"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// okxAttachAlgoOrd 随下单附带的止盈止损（SDK的PlaceOrder不支持attachAlgoOrds）
+type okxAttachAlgoOrd struct {
+	AttachAlgoClOrdID string  `json:"attachAlgoClOrdId,omitempty"`
+	TpTriggerPx       float64 `json:"tpTriggerPx,string,omitempty"`
+	TpOrdPx           float64 `json:"tpOrdPx,string,omitempty"`
+	TpTriggerPxType   string  `json:"tpTriggerPxType,omitempty"`
+	SlTriggerPx       float64 `json:"slTriggerPx,string,omitempty"`
+	SlOrdPx           float64 `json:"slOrdPx,string,omitempty"`
+	SlTriggerPxType   string  `json:"slTriggerPxType,omitempty"`
+}
+
+// okxBracketOrder 带附带止盈止损的下单请求
+type okxBracketOrder struct {
+	tradeReq.PlaceOrder
+	AttachAlgoOrds []okxAttachAlgoOrd `json:"attachAlgoOrds"`
+}
+
+// okxAttachedAlgo 订单详情中附带止盈止损的状态
+type okxAttachedAlgo struct {
+	AttachAlgoID      string `json:"attachAlgoId"`
+	AttachAlgoClOrdID string `json:"attachAlgoClOrdId"`
+	TpTriggerPx       string `json:"tpTriggerPx"`
+	SlTriggerPx       string `json:"slTriggerPx"`
+	FailCode          string `json:"failCode"`
+	FailReason        string `json:"failReason"`
+}
+
+// okxOrderAttachments 订单详情（只解析附带止盈止损）
+type okxOrderAttachments struct {
+	responses.Basic
+	Orders []struct {
+		OrdID          string            `json:"ordId"`
+		AttachAlgoOrds []okxAttachedAlgo `json:"attachAlgoOrds"`
+	} `json:"data"`
+}
+
+// OpenLongBracket 实现BracketOrderPlacer：市价开多并在同一请求中附带止损和止盈
+func (t *OkxTrader) OpenLongBracket(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) (*BracketResult, error) {
+	return t.openBracket(context.Background(), symbol, PositionLong, quantity, leverage, stopLoss, takeProfit)
+}
+
+// OpenShortBracket 实现BracketOrderPlacer：市价开空并在同一请求中附带止损和止盈
+func (t *OkxTrader) OpenShortBracket(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) (*BracketResult, error) {
+	return t.openBracket(context.Background(), symbol, PositionShort, quantity, leverage, stopLoss, takeProfit)
+}
+
+// openBracket 开仓单附带止盈止损（attachAlgoOrds），成交后由交易所自动挂出，开仓与保护之间没有空窗
+// 开仓后查询订单详情确认附带的止盈止损已被接受；附带失败时单独补挂止损止盈
+func (t *OkxTrader) openBracket(ctx context.Context, symbol string, side PositionSide, quantity float64, leverage int, stopLoss, takeProfit float64) (*BracketResult, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	if stopLoss <= 0 || takeProfit <= 0 {
+		return nil, fmt.Errorf("止损价和止盈价必须大于0 (止损 %v, 止盈 %v)", stopLoss, takeProfit)
+	}
+	if (side == PositionLong && stopLoss >= takeProfit) || (side == PositionShort && stopLoss <= takeProfit) {
+		return nil, fmt.Errorf("%s 止损价 %v 与止盈价 %v 方向错误", side, stopLoss, takeProfit)
+	}
+	var err error
+	if stopLoss, err = t.roundPrice(symbol, stopLoss, stopRounding(side)); err != nil {
+		return nil, err
+	}
+	if takeProfit, err = t.roundPrice(symbol, takeProfit, stopRounding(side)); err != nil {
+		return nil, err
+	}
+	if err := t.ValidateOrderSize(symbol, quantity, 0); err != nil {
+		return nil, err
+	}
+	typ := t.TriggerPriceType()
+	if err := t.checkProtectivePrice(false, symbol, side, stopLoss, typ); err != nil {
+		return nil, err
+	}
+	if err := t.checkProtectivePrice(true, symbol, side, takeProfit, typ); err != nil {
+		return nil, err
+	}
+	if err := t.checkPositionTier(symbol, side, quantity, leverage); err != nil {
+		return nil, err
+	}
+	if err := t.checkSymbolMarginMode(ctx, symbol); err != nil {
+		return nil, err
+	}
+
+	// 先取消该币种的所有委托单（清理旧的止损止盈单）
+	if err := t.CancelAllOrdersContext(ctx, symbol); err != nil {
+		t.logger.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
+	}
+	if err := t.SetLeverageContext(ctx, symbol, leverage); err != nil {
+		return nil, err
+	}
+
+	instID, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkOrderNotional(symbol, contracts); err != nil {
+		return nil, err
+	}
+
+	orderSide, posSide := t.okxSides(ctx, side, false)
+	req := okxBracketOrder{
+		PlaceOrder: tradeReq.PlaceOrder{
+			InstID:  instID,
+			ClOrdID: t.newOkxClOrdID(),
+			TdMode:  t.tradeMode(instID),
+			Side:    orderSide,
+			PosSide: posSide,
+			OrdType: okx.OrderMarket,
+			Sz:      contracts,
+		},
+		AttachAlgoOrds: []okxAttachAlgoOrd{{
+			AttachAlgoClOrdID: t.newOkxClOrdID(),
+			TpTriggerPx:       takeProfit,
+			TpOrdPx:           -1, // -1 表示触发后市价成交
+			TpTriggerPxType:   string(typ),
+			SlTriggerPx:       stopLoss,
+			SlOrdPx:           -1,
+			SlTriggerPxType:   string(typ),
+		}},
+	}
+	start := t.clock.Now()
+	ordID, err := t.submitBracketOrder(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("开仓（附带止盈止损）失败: %w", err)
+	}
+
+	entry := &OrderResult{
+		OrderID:       ordID,
+		ClientOrderID: req.ClOrdID,
+		Symbol:        symbol,
+		Side:          strings.ToUpper(string(orderSide)),
+		PositionSide:  strings.ToUpper(string(posSide)),
+		Status:        "NEW",
+		MarginMode:    string(req.TdMode),
+		Leverage:      leverage,
+		Time:          t.clock.Now(),
+	}
+	if detail, err := t.waitForFill(ctx, instID, ordID); err != nil {
+		t.logger.Warn("查询订单成交信息失败", "symbol", symbol, "orderId", ordID, "err", err)
+	} else {
+		t.applyFill(entry, instID, detail)
+	}
+	t.InvalidateCache()
+	t.logger.Info("开仓成功（附带止盈止损）", "symbol", symbol, "side", side, "size", entry.FilledQty,
+		"avgPx", entry.AvgPrice, "orderId", ordID, "latency", t.clock.Since(start))
+	t.emitFillEvent(EventPositionOpened, side, entry, 0)
+
+	result := &BracketResult{Entry: entry}
+	attached, err := t.attachedAlgo(ctx, instID, ordID)
+	if err == nil {
+		result.AttachAlgoID = attached.AttachAlgoID
+		t.logger.Info("止盈止损已随开仓单提交", "symbol", symbol, "stopLoss", stopLoss, "takeProfit", takeProfit, "attachAlgoId", attached.AttachAlgoID)
+		return result, nil
+	}
+
+	// 附带的止盈止损未被接受：持仓没有保护，单独补挂
+	t.logger.Error("附带止盈止损未确认，单独补挂", "symbol", symbol, "err", err)
+	qty := entry.FilledQty
+	if qty <= 0 {
+		qty = quantity
+	}
+	var slErr, tpErr error
+	result.StopLossID, slErr = t.placeStopLoss(ctx, symbol, side, qty, stopLoss, typ)
+	result.TakeProfitID, tpErr = t.placeTakeProfit(ctx, symbol, side, qty, takeProfit, typ)
+	if slErr != nil || tpErr != nil {
+		return result, fmt.Errorf("附带止盈止损失败（%v），补挂失败: 止损 %v, 止盈 %v", err, slErr, tpErr)
+	}
+	return result, nil
+}
+
+// submitBracketOrder 提交带附带止盈止损的订单，返回ordId
+// 结果未知时按clOrdId对账，确认未提交时不重发，由调用方决定是否重试
+func (t *OkxTrader) submitBracketOrder(ctx context.Context, req okxBracketOrder) (ordID string, err error) {
+	defer func() {
+		t.metrics.order(req.InstID, string(req.Side), err)
+		t.emitOrderEvent(req.PlaceOrder, ordID, err)
+	}()
+	resp, err := okxCall(ctx, t, OpMutation, "PlaceOrder", func() (tradeResp.PlaceOrder, error) {
+		var resp tradeResp.PlaceOrder
+		res, err := t.api().Rest.DoBatch("/api/v5/trade/order", req)
+		if err != nil {
+			return resp, err
+		}
+		defer res.Body.Close()
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		return resp, err
+	})
+	if err == nil {
+		if len(resp.PlaceOrders) > 0 {
+			err = okxResponseError("PlaceOrder", resp.Code, resp.Msg, int64(resp.PlaceOrders[0].SCode), resp.PlaceOrders[0].SMsg)
+		} else if err = okxResponseError("PlaceOrder", resp.Code, resp.Msg, 0, ""); err == nil {
+			err = okxEmptyResponse("PlaceOrder")
+		}
+	}
+	if err == nil {
+		t.InvalidateCache()
+		return resp.PlaceOrders[0].OrdID, nil
+	}
+	if !IsOutcomeUnknown(err) {
+		return "", err
+	}
+
+	t.InvalidateCache()
+	t.logger.Warn("下单结果未知，按clOrdId对账", "instId", req.InstID, "clOrdId", req.ClOrdID, "err", err)
+	existing, lookupErr := t.lookupOrder(context.WithoutCancel(ctx), tradeReq.OrderDetails{InstID: req.InstID, ClOrdID: req.ClOrdID})
+	switch {
+	case lookupErr != nil:
+		t.logger.Error("订单对账失败，请手动确认是否已下单", "instId", req.InstID, "clOrdId", req.ClOrdID, "err", lookupErr)
+		return "", fmt.Errorf("%w（对账失败: %v）", err, lookupErr)
+	case existing != nil:
+		t.logger.Info("对账确认订单已提交", "instId", req.InstID, "clOrdId", req.ClOrdID, "orderId", existing.OrdID, "state", existing.State)
+		return existing.OrdID, nil
+	}
+	return "", fmt.Errorf("%w（对账确认订单未提交）", err)
+}
+
+// attachedAlgo 查询订单详情，确认附带的止盈止损已被接受
+func (t *OkxTrader) attachedAlgo(ctx context.Context, instID, ordID string) (*okxAttachedAlgo, error) {
+	resp, err := okxCall(ctx, t, OpPrivateRead, "GetOrderDetail", func() (okxOrderAttachments, error) {
+		var resp okxOrderAttachments
+		res, err := t.api().Rest.Do(http.MethodGet, "/api/v5/trade/order", true, map[string]string{"instId": instID, "ordId": ordID})
+		if err != nil {
+			return resp, err
+		}
+		defer res.Body.Close()
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		return resp, err
+	})
+	if err == nil {
+		err = okxCheck("GetOrderDetail", resp.Basic, len(resp.Orders))
+	}
+	if err != nil {
+		return nil, err
+	}
+	attached := resp.Orders[0].AttachAlgoOrds
+	if len(attached) == 0 {
+		return nil, fmt.Errorf("订单 %s 没有附带的止盈止损", ordID)
+	}
+	a := attached[0]
+	if a.FailCode != "" && a.FailCode != "0" {
+		return nil, fmt.Errorf("附带止盈止损被拒绝 (%s): %s", a.FailCode, a.FailReason)
+	}
+	if a.SlTriggerPx == "" || a.TpTriggerPx == "" {
+		return nil, fmt.Errorf("订单 %s 附带的止盈止损不完整 (止损 %q, 止盈 %q)", ordID, a.SlTriggerPx, a.TpTriggerPx)
+	}
+	return &a, nil
+}
@@ -0,0 +1,96 @@
+package trader
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestOkxFirstReadAfterOpenIsFresh 开仓后的第一次持仓、余额查询直接请求API：
+// 等待成交期间的查询把成交前的（空）持仓写入了缓存，成交确认后必须清掉
+func TestOkxFirstReadAfterOpenIsFresh(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		open func(tr *OkxTrader) error
+	}{
+		{"market", func(tr *OkxTrader) error {
+			_, err := tr.OpenLong("BTCUSDT", 0.1, 10)
+			return err
+		}},
+		{"bracket", func(tr *OkxTrader) error {
+			_, err := tr.OpenLongBracket("BTCUSDT", 0.1, 10, 48000, 55000)
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			tr := f.trader(t, WithCacheDuration(time.Hour))
+			var filled atomic.Bool
+			f.handle("GET /api/v5/account/positions", func(fakeOkxRequest) string {
+				if !filled.Load() {
+					return okxOK()
+				}
+				return okxOK(okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10))
+			})
+			f.handle("GET /api/v5/account/balance", func(fakeOkxRequest) string {
+				if !filled.Load() {
+					return okxOK(okxTestBalance("1000", "1000", "0"))
+				}
+				return okxOK(okxTestBalance("1000", "950", "0"))
+			})
+			f.reply("POST /api/v5/trade/order", `{"ordId":"1","clOrdId":"","sCode":"0","sMsg":""}`)
+			f.handle("GET /api/v5/trade/order", func(fakeOkxRequest) string {
+				// 等待成交期间有其他查询：缓存成交前的持仓与余额
+				if !filled.Load() {
+					if _, err := tr.GetPositions(); err != nil {
+						t.Errorf("等待成交期间 GetPositions: %v", err)
+					}
+					if _, err := tr.GetBalance(); err != nil {
+						t.Errorf("等待成交期间 GetBalance: %v", err)
+					}
+					filled.Store(true)
+				}
+				row := okxTestFilledOrder("1", "", "buy", "long", "10", "50000")
+				return okxOK(row[:len(row)-1] + `,"attachAlgoOrds":[{"attachAlgoId":"a1","tpTriggerPx":"55000","slTriggerPx":"48000"}]}`)
+			})
+
+			if err := tc.open(tr); err != nil {
+				t.Fatalf("开仓: %v", err)
+			}
+
+			positionCalls := f.calls("GET /api/v5/account/positions")
+			positions, err := tr.GetPositions()
+			if err != nil {
+				t.Fatalf("GetPositions: %v", err)
+			}
+			if n := f.calls("GET /api/v5/account/positions") - positionCalls; n != 1 {
+				t.Errorf("开仓后第一次 GetPositions 请求API %d 次, want 1", n)
+			}
+			if len(positions) != 1 {
+				t.Errorf("开仓后持仓 = %v, want 刚开的持仓", positions)
+			}
+
+			balanceCalls := f.calls("GET /api/v5/account/balance")
+			balance, err := tr.GetBalance()
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			if n := f.calls("GET /api/v5/account/balance") - balanceCalls; n != 1 {
+				t.Errorf("开仓后第一次 GetBalance 请求API %d 次, want 1", n)
+			}
+			if got := fmt.Sprint(balance["availableBalance"]); got != "950" {
+				t.Errorf("availableBalance = %s, want 950（成交后的余额）", got)
+			}
+
+			// 之后的查询使用缓存
+			positionCalls = f.calls("GET /api/v5/account/positions")
+			if _, err := tr.GetPositions(); err != nil {
+				t.Fatal(err)
+			}
+			if n := f.calls("GET /api/v5/account/positions") - positionCalls; n != 0 {
+				t.Errorf("缓存有效期内再次查询请求API %d 次, want 0", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	marketModel "github.com/Benjmmi/okx/models/market"
+	marketReq "github.com/Benjmmi/okx/requests/rest/market"
+	marketResp "github.com/Benjmmi/okx/responses/market"
+)
+
+const (
+	okxCandlesPageLimit        = 300   // /market/candles 单页最大条数（只提供最近1440根）
+	okxHistoryCandlesPageLimit = 100   // /market/history-candles 单页最大条数
+	okxMaxCandles              = 10000 // 单次查询最多返回的K线数量（避免范围过大时无限翻页）
+)
+
+// Candle K线（Time为开盘时间）
+type Candle struct {
+	Time        time.Time `json:"time"`
+	Open        float64   `json:"open"`
+	High        float64   `json:"high"`
+	Low         float64   `json:"low"`
+	Close       float64   `json:"close"`
+	Volume      float64   `json:"volume"`       // 成交量（币，合约已由张数换算）
+	QuoteVolume float64   `json:"quote_volume"` // 成交额（计价币种）
+	Confirmed   bool      `json:"confirmed"`    // K线已收盘（最新一根通常未收盘）
+}
+
+// okxCandleBars 支持的K线周期（区分大小写：1m为分钟，1M为月；utc后缀为UTC时区对齐），值为周期长度（月以30天估算）
+var okxCandleBars = map[string]time.Duration{
+	"1m": time.Minute, "3m": 3 * time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute, "30m": 30 * time.Minute,
+	"1H": time.Hour, "2H": 2 * time.Hour, "4H": 4 * time.Hour,
+	"6H": 6 * time.Hour, "12H": 12 * time.Hour, "1D": 24 * time.Hour, "2D": 48 * time.Hour, "3D": 72 * time.Hour,
+	"1W": 7 * 24 * time.Hour, "1M": 30 * 24 * time.Hour, "3M": 90 * 24 * time.Hour,
+	"6Hutc": 6 * time.Hour, "12Hutc": 12 * time.Hour, "1Dutc": 24 * time.Hour, "2Dutc": 48 * time.Hour,
+	"3Dutc": 72 * time.Hour, "1Wutc": 7 * 24 * time.Hour, "1Mutc": 30 * 24 * time.Hour, "3Mutc": 90 * 24 * time.Hour,
+}
+
+// validateCandleBar 校验K线周期，返回周期长度
+func validateCandleBar(bar string) (time.Duration, error) {
+	d, ok := okxCandleBars[bar]
+	if !ok {
+		return 0, fmt.Errorf("无效的K线周期: %q（如 1m/5m/15m/1H/4H/1D/1W，区分大小写）", bar)
+	}
+	return d, nil
+}
+
+// GetCandles 获取最近limit根K线（按时间从旧到新，最后一根可能未收盘），超过单页条数时自动翻页
+func (t *OkxTrader) GetCandles(symbol, bar string, limit int) ([]Candle, error) {
+	return t.GetCandlesContext(context.Background(), symbol, bar, limit)
+}
+
+// GetCandlesContext 同GetCandles，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetCandlesContext(ctx context.Context, symbol, bar string, limit int) ([]Candle, error) {
+	if _, err := validateCandleBar(bar); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > okxMaxCandles {
+		return nil, fmt.Errorf("K线数量必须在1到%d之间: %d", okxMaxCandles, limit)
+	}
+	return t.candles(ctx, symbol, bar, time.Time{}, time.Time{}, limit)
+}
+
+// GetCandlesRange 获取开盘时间在[from, to)内的K线（按时间从旧到新），自动翻页并去除重复
+func (t *OkxTrader) GetCandlesRange(symbol, bar string, from, to time.Time) ([]Candle, error) {
+	return t.GetCandlesRangeContext(context.Background(), symbol, bar, from, to)
+}
+
+// GetCandlesRangeContext 同GetCandlesRange，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetCandlesRangeContext(ctx context.Context, symbol, bar string, from, to time.Time) ([]Candle, error) {
+	period, err := validateCandleBar(bar)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return nil, errors.New("K线时间范围无效：from必须早于to")
+	}
+	if n := to.Sub(from) / period; n > okxMaxCandles {
+		return nil, fmt.Errorf("K线时间范围过大：约%d根，最多%d根", n, okxMaxCandles)
+	}
+	return t.candles(ctx, symbol, bar, from, to, okxMaxCandles)
+}
+
+// candles 从to（为零时从最新）向前翻页，直到取满limit根或早于from；
+// /market/candles 没有更早的数据时改用 /market/history-candles
+func (t *OkxTrader) candles(ctx context.Context, symbol, bar string, from, to time.Time, limit int) ([]Candle, error) {
+	instID, instType, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+	spot := instType == okx.SpotInstrument
+
+	var after int64 // 返回早于该时间（毫秒）的K线，0表示从最新开始
+	if !to.IsZero() {
+		after = to.UnixMilli()
+	}
+	seen := make(map[int64]bool)
+	result := make([]Candle, 0)
+	history := false
+	for len(result) < limit {
+		page, err := t.candlePage(ctx, instID, bar, after, history)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s K线失败: %w", symbol, err)
+		}
+		if len(page) == 0 {
+			if history {
+				break
+			}
+			history = true
+			continue
+		}
+
+		oldest := after
+		reachedFrom := false
+		for _, c := range page {
+			ts := time.Time(c.TS)
+			ms := ts.UnixMilli()
+			if oldest == 0 || ms < oldest {
+				oldest = ms
+			}
+			if !from.IsZero() && ts.Before(from) {
+				reachedFrom = true
+				continue
+			}
+			if (after != 0 && ms >= after) || seen[ms] {
+				continue // 两个接口的分页边界可能重叠
+			}
+			seen[ms] = true
+			candle := Candle{
+				Time:        ts,
+				Open:        c.O,
+				High:        c.H,
+				Low:         c.L,
+				Close:       c.C,
+				Volume:      c.VolCcy,
+				QuoteVolume: c.VolCcyQuote,
+				Confirmed:   c.Confirm == 1,
+			}
+			// 现货的vol为币的数量、volCcy为计价币种；合约的vol为张数
+			if spot {
+				candle.Volume, candle.QuoteVolume = c.Vol, c.VolCcy
+			}
+			result = append(result, candle)
+		}
+		if reachedFrom || (after != 0 && oldest >= after) {
+			break
+		}
+		after = oldest
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	if len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	t.logger.Debug("已获取K线", "instId", instID, "bar", bar, "count", len(result))
+	return result, nil
+}
+
+// candlePage 查询一页K线（按时间从新到旧）
+func (t *OkxTrader) candlePage(ctx context.Context, instID, bar string, after int64, history bool) ([]*marketModel.Candlesticks, error) {
+	op, limit := "Candlesticks", okxCandlesPageLimit
+	if history {
+		op, limit = "CandlesticksHistory", okxHistoryCandlesPageLimit
+	}
+	req := marketReq.Candlesticks{InstID: instID, Bar: okx.BarSize(bar), After: after, Limit: int64(limit)}
+	resp, err := okxCall(ctx, t, OpPublicRead, op, func() (marketResp.Candlesticks, error) {
+		if history {
+			return t.api().Rest.Market.CandlesticksHistory(req)
+		}
+		return t.api().Rest.Market.Candlesticks(req)
+	})
+	if err == nil {
+		err = okxCheck(op, resp.Basic, -1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Candlesticks, nil
+}
+
+var _ CandleProvider = (*OkxTrader)(nil)
@@ -0,0 +1,85 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CloseAllPositions 实现PositionFlattener：紧急平仓，市价只减仓平掉所有持仓并撤销所有币种的普通委托与策略委托
+// 持仓强制从交易所刷新；单个持仓或撤单失败不会中断其余操作，按币种返回结果，
+// 有失败时同时返回汇总错误，调用方可对失败的币种重试
+func (t *OkxTrader) CloseAllPositions(ctx context.Context) ([]*FlattenResult, error) {
+	t.logger.Warn("紧急平仓：平掉所有持仓并撤销所有挂单")
+	results := make(map[string]*FlattenResult)
+	resultFor := func(symbol string) *FlattenResult {
+		r, ok := results[symbol]
+		if !ok {
+			r = &FlattenResult{Symbol: symbol}
+			results[symbol] = r
+		}
+		return r
+	}
+
+	var errs []error
+	positions, err := t.positions(ctx, true)
+	if err != nil {
+		// 无法获取持仓时仍撤销挂单
+		errs = append(errs, err)
+	}
+	for _, pos := range positions {
+		r := resultFor(pos.Symbol)
+		order, err := t.closePositionOrder(ctx, pos.Symbol, pos.Side, 0)
+		switch {
+		case errors.Is(err, ErrPositionNotFound):
+			t.logger.Info("持仓已不存在", "symbol", pos.Symbol, "side", pos.Side)
+		case err != nil:
+			r.fail(fmt.Errorf("%s %s: %w", pos.Symbol, pos.Side, err))
+		default:
+			r.Closed = append(r.Closed, order)
+		}
+	}
+
+	// 撤销所有币种的挂单（包括没有持仓的币种，如未成交的开仓单与残留的止损止盈单）
+	symbols := make(map[string]bool)
+	for symbol := range results {
+		symbols[symbol] = true
+	}
+	orders, err := t.ListOpenOrders(ctx, OkxOrderFilter{})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	algos, err := t.ListPendingAlgoOrders(ctx, OkxOrderFilter{})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, order := range append(orders, algos...) {
+		symbols[order.Symbol] = true
+	}
+	for symbol := range symbols {
+		r := resultFor(symbol)
+		summary, err := t.cancelAllOrders(ctx, symbol)
+		r.Cancelled = summary
+		if err != nil {
+			r.fail(fmt.Errorf("%s 撤单: %w", symbol, err))
+		}
+	}
+
+	list := make([]*FlattenResult, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+
+	t.InvalidateCache()
+	if err := errors.Join(errs...); err != nil {
+		t.logger.Error("紧急平仓未全部完成", "err", err)
+		return list, err
+	}
+	t.logger.Info("紧急平仓完成", "symbols", len(list))
+	return list, nil
+}
@@ -0,0 +1,265 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newCloseTestOkx 无持仓、但还残留一个普通委托和一个止损止盈条件单的假服务器
+func newCloseTestOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/trade/orders-pending", `{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"111","ordType":"limit","state":"live"}`)
+	f.handle("GET /api/v5/trade/orders-algo-pending", func(req fakeOkxRequest) string {
+		if req.Query.Get("ordType") != "conditional" {
+			return okxOK()
+		}
+		return okxOK(`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"222","ordType":"conditional","state":"live"}`)
+	})
+	f.reply("POST /api/v5/trade/cancel-order", `{"ordId":"111","sCode":"0","sMsg":""}`)
+	f.reply("POST /api/v5/trade/cancel-algos", `{"algoId":"222","sCode":"0","sMsg":""}`)
+	return f
+}
+
+// TestOkxCloseMissingPosition 全部平仓时已无持仓：不下单，返回 ErrPositionNotFound，残留挂单仍被撤销
+func TestOkxCloseMissingPosition(t *testing.T) {
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		t.Run(side.String(), func(t *testing.T) {
+			f := newCloseTestOkx(t)
+			tr := f.trader(t)
+			closeFn := tr.CloseLong
+			if side == PositionShort {
+				closeFn = tr.CloseShort
+			}
+
+			order, err := closeFn("BTCUSDT", 0)
+			if !errors.Is(err, ErrPositionNotFound) || order != nil {
+				t.Fatalf("close = %v, %v, want ErrPositionNotFound", order, err)
+			}
+			var notFound *PositionNotFoundError
+			if !errors.As(err, &notFound) || notFound.Symbol != "BTCUSDT" || notFound.Side != side {
+				t.Errorf("err = %#v, want PositionNotFoundError(BTCUSDT %s)", err, side)
+			}
+			if n := f.calls("POST /api/v5/trade/order"); n != 0 {
+				t.Errorf("无持仓时下单 %d 次", n)
+			}
+			if n := f.calls("POST /api/v5/trade/cancel-order"); n != 1 {
+				t.Errorf("普通委托撤单次数 = %d, want 1", n)
+			}
+			if n := f.calls("POST /api/v5/trade/cancel-algos"); n != 1 {
+				t.Errorf("条件单撤单次数 = %d, want 1", n)
+			}
+		})
+	}
+}
+
+// TestOkxPartialCloseMissingPosition 部分平仓时已无持仓：返回 ErrPositionNotFound，不撤销挂单
+func TestOkxPartialCloseMissingPosition(t *testing.T) {
+	f := newCloseTestOkx(t)
+	if _, err := f.trader(t).CloseLong("BTCUSDT", 0.05); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("err = %v, want ErrPositionNotFound", err)
+	}
+	for _, route := range []string{"POST /api/v5/trade/order", "POST /api/v5/trade/cancel-order", "POST /api/v5/trade/cancel-algos"} {
+		if n := f.calls(route); n != 0 {
+			t.Errorf("%s 被调用 %d 次, want 0", route, n)
+		}
+	}
+}
+
+// TestErrorRateIgnoresPositionNotFound 平仓时持仓已不存在属于正常结果，不计入API错误率
+func TestErrorRateIgnoresPositionNotFound(t *testing.T) {
+	m := NewErrorRateMonitor(DefaultErrorRateConfig())
+	for i := 0; i < 10; i++ {
+		m.ObserveCall("CloseLong", time.Millisecond, &PositionNotFoundError{Symbol: "BTCUSDT", Side: PositionLong})
+	}
+	if rate, n := m.Rate(); rate != 0 || n != 10 {
+		t.Errorf("Rate() = %v%%, %d, want 0%% over 10 calls", rate, n)
+	}
+	m.ObserveCall("CloseLong", time.Millisecond, errors.New("timeout"))
+	if rate, _ := m.Rate(); rate == 0 {
+		t.Error("其他错误应计入错误率")
+	}
+}
+
+// newOpenCloseOkx 有状态的假服务器：开仓单成交后出现持仓，平仓单成交后持仓消失
+func newOpenCloseOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	var (
+		mu       sync.Mutex
+		position string // 当前持仓（空表示无持仓）
+		last     string // 最近一笔订单的成交详情
+		orders   int
+	)
+	f.handle("POST /api/v5/trade/order", func(req fakeOkxRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		orders++
+		ordID := strconv.Itoa(orders)
+		side, _ := jsonField(t, req.Body, "side").(string)
+		posSide, _ := jsonField(t, req.Body, "posSide").(string)
+		sz, _ := jsonField(t, req.Body, "sz").(string)
+		clOrdID, _ := jsonField(t, req.Body, "clOrdId").(string)
+		last = okxTestFilledOrder(ordID, clOrdID, side, posSide, sz, "50000")
+		if opening := (side == "buy") == (posSide == "long"); opening {
+			position = okxTestPosition("BTC-USDT-SWAP", posSide, sz, "50000", 10)
+		} else {
+			position = ""
+		}
+		return okxOK(fmt.Sprintf(`{"ordId":%q,"clOrdId":%q,"sCode":"0","sMsg":""}`, ordID, clOrdID))
+	})
+	f.handle("GET /api/v5/trade/order", func(fakeOkxRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return okxOK(last)
+	})
+	f.handle("GET /api/v5/account/positions", func(fakeOkxRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if position == "" {
+			return okxOK()
+		}
+		return okxOK(position)
+	})
+	return f
+}
+
+// TestOkxOpenThenCloseBySymbol 用GetPositions返回的symbol平仓：持仓的instId映射回系统格式，平掉的正是刚开的持仓
+func TestOkxOpenThenCloseBySymbol(t *testing.T) {
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		t.Run(side.String(), func(t *testing.T) {
+			f := newOpenCloseOkx(t)
+			tr := f.trader(t)
+			openFn, closeFn := tr.OpenLong, tr.CloseLong
+			if side == PositionShort {
+				openFn, closeFn = tr.OpenShort, tr.CloseShort
+			}
+
+			if _, err := openFn("BTCUSDT", 0.1, 10); err != nil {
+				t.Fatalf("开仓: %v", err)
+			}
+			positions, err := tr.GetPositions()
+			if err != nil {
+				t.Fatalf("GetPositions: %v", err)
+			}
+			if len(positions) != 1 {
+				t.Fatalf("持仓数 = %d, want 1", len(positions))
+			}
+			symbol, _ := positions[0]["symbol"].(string)
+			if symbol != "BTCUSDT" || positions[0]["side"] != side.String() {
+				t.Fatalf("持仓 symbol=%v side=%v, want BTCUSDT %s", positions[0]["symbol"], positions[0]["side"], side)
+			}
+
+			if _, err := closeFn(symbol, 0); err != nil {
+				t.Fatalf("按symbol %q 平仓: %v", symbol, err)
+			}
+			reqs := f.requestsTo("POST /api/v5/trade/order")
+			if len(reqs) != 2 {
+				t.Fatalf("下单次数 = %d, want 2（开仓、平仓）", len(reqs))
+			}
+			closeReq := reqs[1].Body
+			wantSide := "sell"
+			if side == PositionShort {
+				wantSide = "buy"
+			}
+			if jsonField(t, closeReq, "instId") != "BTC-USDT-SWAP" || jsonField(t, closeReq, "side") != wantSide ||
+				jsonField(t, closeReq, "posSide") != side.String() || jsonField(t, closeReq, "sz") != "10" {
+				t.Errorf("平仓请求 = %s, want BTC-USDT-SWAP %s %s 10张", closeReq, wantSide, side)
+			}
+
+			positions, err = tr.GetPositions()
+			if err != nil || len(positions) != 0 {
+				t.Errorf("平仓后 GetPositions = %v, %v, want 空", positions, err)
+			}
+		})
+	}
+}
+
+// newHeldPositionOkx 持有10张BTC多仓、挂着止损止盈条件单的假服务器，平仓单按请求的张数成交（持仓不随之变化）
+func newHeldPositionOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10))
+	var (
+		mu   sync.Mutex
+		last string
+	)
+	f.handle("POST /api/v5/trade/order", func(req fakeOkxRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		clOrdID, _ := jsonField(t, req.Body, "clOrdId").(string)
+		sz, _ := jsonField(t, req.Body, "sz").(string)
+		last = okxTestFilledOrder("9", clOrdID, "sell", "long", sz, "50000")
+		return okxOK(fmt.Sprintf(`{"ordId":"9","clOrdId":%q,"sCode":"0","sMsg":""}`, clOrdID))
+	})
+	f.handle("GET /api/v5/trade/order", func(fakeOkxRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return okxOK(last)
+	})
+	f.handle("GET /api/v5/trade/orders-algo-pending", func(req fakeOkxRequest) string {
+		if req.Query.Get("ordType") != "conditional" {
+			return okxOK()
+		}
+		return okxOK(
+			`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"sl-1","ordType":"conditional","state":"live"}`,
+			`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"tp-1","ordType":"conditional","state":"live"}`,
+		)
+	})
+	f.handle("POST /api/v5/trade/cancel-algos", func(req fakeOkxRequest) string {
+		var rows []string
+		for _, algoID := range batchField(t, req.Body, "algoId") {
+			rows = append(rows, `{"algoId":"`+algoID+`","sCode":"0","sMsg":""}`)
+		}
+		return okxOK(rows...)
+	})
+	return f
+}
+
+// TestOkxCloseKeepsProtectiveOrdersOnPartial 部分平仓只下对应张数的只减仓单、保留止损止盈；全部平仓后撤销止损止盈
+func TestOkxCloseKeepsProtectiveOrdersOnPartial(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		close      func(*OkxTrader) error
+		wantSz     string
+		wantCancel bool
+	}{
+		{"partial", func(tr *OkxTrader) error { _, err := tr.CloseLong("BTCUSDT", 0.05); return err }, "5", false},
+		{"percent", func(tr *OkxTrader) error { _, err := tr.ClosePositionPercent("BTCUSDT", PositionLong, 30); return err }, "3", false},
+		{"full", func(tr *OkxTrader) error { _, err := tr.CloseLong("BTCUSDT", 0); return err }, "10", true},
+		{"whole quantity", func(tr *OkxTrader) error { _, err := tr.CloseLong("BTCUSDT", 0.1); return err }, "10", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newHeldPositionOkx(t)
+			if err := tc.close(f.trader(t)); err != nil {
+				t.Fatalf("平仓: %v", err)
+			}
+			orders := f.requestsTo("POST /api/v5/trade/order")
+			if len(orders) != 1 {
+				t.Fatalf("下单次数 = %d, want 1", len(orders))
+			}
+			if body := orders[0].Body; jsonField(t, body, "sz") != tc.wantSz || jsonField(t, body, "side") != "sell" ||
+				jsonField(t, body, "posSide") != "long" || jsonField(t, body, "reduceOnly") != true {
+				t.Errorf("平仓请求 = %s, want 只减仓卖出 %s 张", body, tc.wantSz)
+			}
+
+			cancels := f.requestsTo("POST /api/v5/trade/cancel-algos")
+			if !tc.wantCancel {
+				if len(cancels) != 0 {
+					t.Errorf("部分平仓撤销了条件单: %v", cancels)
+				}
+				return
+			}
+			if len(cancels) != 1 {
+				t.Fatalf("cancel-algos 被调用 %d 次, want 1", len(cancels))
+			}
+			if got := batchField(t, cancels[0].Body, "algoId"); len(got) != 2 || got[0] != "sl-1" || got[1] != "tp-1" {
+				t.Errorf("撤销的条件单 = %v, want [sl-1 tp-1]", got)
+			}
+		})
+	}
+}
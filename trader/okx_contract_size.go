@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx/models/publicdata"
+)
+
+// ContractSize 币的数量/名义价值与下单张数之间的换算结果
+type ContractSize struct {
+	InstID       string  `json:"inst_id"`
+	Contracts    float64 `json:"contracts"`     // 下单张数（已按lotSz向下取整）
+	ContractsStr string  `json:"contracts_str"` // 下单用字符串
+	Coin         float64 `json:"coin"`          // 取整后对应的币的数量
+	Notional     float64 `json:"notional"`      // 取整后的实际名义价值（USD）
+	MarkPrice    float64 `json:"mark_price"`    // 换算使用的标记价格
+}
+
+// ContractsFromNotional 按USDT名义价值换算下单张数，向下取整到lotSz，低于最小下单量时返回 ErrBelowMinSize
+// 正向合约：张数 = 名义价值 / (标记价格 × ctVal × ctMult)；反向合约：张数 = 名义价值 / (ctVal × ctMult)
+func (t *OkxTrader) ContractsFromNotional(symbol string, notionalUSDT float64) (*ContractSize, error) {
+	if notionalUSDT <= 0 {
+		return nil, fmt.Errorf("名义价值必须大于0 (当前 %.2f)", notionalUSDT)
+	}
+	inst, markPrice, err := t.instrumentAndMark(symbol)
+	if err != nil {
+		return nil, err
+	}
+	contracts := toDecimal(notionalUSDT).Div(contractNotionalUSD(inst, markPrice))
+	size, err := contractSize(inst, decimalFloat(contracts), markPrice)
+	if err != nil {
+		return nil, fmt.Errorf("%s 名义价值 %.2f USDT 不足: %w", inst.InstID, notionalUSDT, err)
+	}
+	return size, nil
+}
+
+// ContractsFromCoin 按币的数量换算下单张数，向下取整到lotSz，低于最小下单量时返回 ErrBelowMinSize
+// 正向合约：张数 = 数量 / (ctVal × ctMult)；反向合约：张数 = 数量 × 标记价格 / (ctVal × ctMult)
+func (t *OkxTrader) ContractsFromCoin(symbol string, coin float64) (*ContractSize, error) {
+	if coin <= 0 {
+		return nil, fmt.Errorf("数量必须大于0 (当前 %v)", coin)
+	}
+	inst, markPrice, err := t.instrumentAndMark(symbol)
+	if err != nil {
+		return nil, err
+	}
+	size, err := contractSize(inst, decimalFloat(coinToContracts(inst, coin, markPrice)), markPrice)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inst.InstID, err)
+	}
+	return size, nil
+}
+
+// CoinFromContracts 张数换算为币的数量（按标记价格，不取整）
+func (t *OkxTrader) CoinFromContracts(symbol string, contracts float64) (float64, error) {
+	inst, markPrice, err := t.instrumentAndMark(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return decimalFloat(contractsToCoin(inst, contracts, markPrice)), nil
+}
+
+// NotionalFromContracts 张数换算为名义价值（USD，按标记价格，不取整）
+func (t *OkxTrader) NotionalFromContracts(symbol string, contracts float64) (float64, error) {
+	inst, markPrice, err := t.instrumentAndMark(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return decimalFloat(contractNotionalUSD(inst, markPrice).Mul(toDecimal(contracts))), nil
+}
+
+// OpenLongNotional 按USDT名义价值市价开多（张数直接由名义价值换算，不经过币的数量）
+func (t *OkxTrader) OpenLongNotional(ctx context.Context, symbol string, notionalUSDT float64, leverage int) (*OrderResult, error) {
+	size, err := t.ContractsFromNotional(symbol, notionalUSDT)
+	if err != nil {
+		return nil, err
+	}
+	return t.openMarket(ctx, symbol, PositionLong, size.Coin, size.Contracts, leverage)
+}
+
+// OpenShortNotional 按USDT名义价值市价开空（张数直接由名义价值换算，不经过币的数量）
+func (t *OkxTrader) OpenShortNotional(ctx context.Context, symbol string, notionalUSDT float64, leverage int) (*OrderResult, error) {
+	size, err := t.ContractsFromNotional(symbol, notionalUSDT)
+	if err != nil {
+		return nil, err
+	}
+	return t.openMarket(ctx, symbol, PositionShort, size.Coin, size.Contracts, leverage)
+}
+
+// instrumentAndMark 获取交易规则（缓存）与标记价格
+func (t *OkxTrader) instrumentAndMark(symbol string) (*publicdata.Instrument, float64, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	markPrice, err := t.getMarkPrice(symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	if markPrice <= 0 {
+		return nil, 0, fmt.Errorf("%s 标记价格无效: %v", inst.InstID, markPrice)
+	}
+	return inst, markPrice, nil
+}
+
+// contractSize 张数向下取整到lotSz，并计算取整后的币的数量与名义价值
+func contractSize(inst *publicdata.Instrument, contracts, markPrice float64) (*ContractSize, error) {
+	floored, err := floorToLot(contracts, float64(inst.LotSz), float64(inst.MinSz))
+	if err != nil {
+		return nil, err
+	}
+	return &ContractSize{
+		InstID:       inst.InstID,
+		Contracts:    decimalFloat(floored),
+		ContractsStr: floored.String(),
+		Coin:         decimalFloat(contractsToCoin(inst, decimalFloat(floored), markPrice)),
+		Notional:     decimalFloat(floored.Mul(contractNotionalUSD(inst, markPrice))),
+		MarkPrice:    markPrice,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"strings"
+
+	"github.com/Benjmmi/okx"
+	"github.com/Benjmmi/okx/models/publicdata"
+	"github.com/shopspring/decimal"
+)
+
+// OKX合约按结算方式分为：
+//   - 正向合约（linear）：USDT/USDC结算，面值ctVal以币计（如BTC-USDT-SWAP每张0.01 BTC）
+//   - 反向合约（inverse）：币本位结算，面值ctVal以USD计（如BTC-USD-SWAP每张100 USD）
+// 下单数量统一以张为单位，盈亏以结算币种计价；汇总时USDT/USDC按1:1折算为USD，币本位按标记价格折算
+
+// okxSymbol 将OKX永续合约ID转换为系统内的币种格式（BTC-USDT-SWAP -> BTCUSDT）
+// 交割合约没有对应格式，保留完整ID
+func okxSymbol(instID string) string {
+	parts := strings.Split(instID, "-")
+	if len(parts) == 3 && parts[2] == "SWAP" {
+		return parts[0] + parts[1]
+	}
+	return instID
+}
+
+// isInverseContract 是否为币本位反向合约
+func isInverseContract(inst *publicdata.Instrument) bool {
+	if inst.CtType != "" {
+		return inst.CtType == okx.ContractInverseType
+	}
+	// 交易规则未返回ctType时，结算币种与面值币种不同（面值以USD计）即为反向合约
+	return inst.SettleCcy != "" && inst.CtValCcy != "" && inst.SettleCcy != inst.CtValCcy &&
+		!strings.HasPrefix(inst.SettleCcy, "USD")
+}
+
+// contractFace 每张合约面值（ctVal × ctMult，缺失时按1计算）
+func contractFace(inst *publicdata.Instrument) decimal.Decimal {
+	ctVal := toDecimal(float64(inst.CtVal))
+	if ctVal.IsZero() {
+		ctVal = decimal.NewFromInt(1)
+	}
+	if inst.CtMult > 0 {
+		ctVal = ctVal.Mul(toDecimal(float64(inst.CtMult)))
+	}
+	return ctVal
+}
+
+// contractNotionalUSD 每张合约的名义价值（USD）
+func contractNotionalUSD(inst *publicdata.Instrument, price float64) decimal.Decimal {
+	if isInverseContract(inst) {
+		return contractFace(inst)
+	}
+	return contractFace(inst).Mul(toDecimal(price))
+}
+
+// contractsToCoin 张数转换为币的数量
+func contractsToCoin(inst *publicdata.Instrument, contracts, price float64) decimal.Decimal {
+	if isInverseContract(inst) {
+		if price <= 0 {
+			return decimal.Zero
+		}
+		return toDecimal(contracts).Mul(contractFace(inst)).Div(toDecimal(price))
+	}
+	return toDecimal(contracts).Mul(contractFace(inst))
+}
+
+// coinToContracts 币的数量转换为张数（未按lotSz取整）
+func coinToContracts(inst *publicdata.Instrument, coin, price float64) decimal.Decimal {
+	if isInverseContract(inst) {
+		return toDecimal(coin).Mul(toDecimal(price)).Div(contractFace(inst))
+	}
+	return toDecimal(coin).Div(contractFace(inst))
+}
+
+// settleToUSD 结算币种金额折算为USD（正向合约USDT/USDC按1:1）
+func settleToUSD(inst *publicdata.Instrument, amount, price float64) float64 {
+	if isInverseContract(inst) {
+		return decimalFloat(toDecimal(amount).Mul(toDecimal(price)))
+	}
+	return amount
+}
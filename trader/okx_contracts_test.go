@@ -0,0 +1,127 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// okxConformanceInstruments 三种结算方式的合约（标记价格均为50000）
+//   - BTC-USDT-SWAP：USDT本位正向合约，1张=0.01 BTC
+//   - BTC-USDC-SWAP：USDC本位正向合约，1张=0.0001 BTC
+//   - BTC-USD-SWAP：币本位反向合约，1张=100 USD，以BTC结算
+var okxConformanceInstruments = map[string]string{
+	"BTC-USDT-SWAP": `{"instId":"BTC-USDT-SWAP","instType":"SWAP","ctType":"linear","ctVal":"0.01","ctValCcy":"BTC","settleCcy":"USDT","lotSz":"1","minSz":"1","tickSz":"0.1","lever":"100","state":"live"}`,
+	"BTC-USDC-SWAP": `{"instId":"BTC-USDC-SWAP","instType":"SWAP","ctType":"linear","ctVal":"0.0001","ctValCcy":"BTC","settleCcy":"USDC","lotSz":"1","minSz":"1","tickSz":"0.1","lever":"100","state":"live"}`,
+	"BTC-USD-SWAP":  `{"instId":"BTC-USD-SWAP","instType":"SWAP","ctType":"inverse","ctVal":"100","ctValCcy":"USD","settleCcy":"BTC","lotSz":"1","minSz":"1","tickSz":"0.1","lever":"100","state":"live"}`,
+}
+
+// newConformanceOkx 按instId返回对应交易规则与标记价格的假服务器
+func newConformanceOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.handle("GET /api/v5/public/instruments", func(req fakeOkxRequest) string {
+		return okxOK(okxConformanceInstruments[req.Query.Get("instId")])
+	})
+	f.handle("GET /api/v5/public/mark-price", func(req fakeOkxRequest) string {
+		return okxOK(fmt.Sprintf(`{"instId":%q,"markPx":"50000"}`, req.Query.Get("instId")))
+	})
+	return f
+}
+
+func TestOkxContractConversionsConformance(t *testing.T) {
+	for _, tc := range []struct {
+		instID    string
+		coin      float64 // 下单的币的数量
+		contracts float64 // 对应张数
+		notional  float64 // 名义价值（USD）
+		inverse   bool
+	}{
+		{"BTC-USDT-SWAP", 0.1, 10, 5000, false},
+		{"BTC-USDC-SWAP", 0.1, 1000, 5000, false},
+		{"BTC-USD-SWAP", 0.1, 50, 5000, true},
+	} {
+		t.Run(tc.instID, func(t *testing.T) {
+			tr := newConformanceOkx(t).trader(t)
+
+			inst, err := tr.getInstrument(tc.instID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isInverseContract(inst); got != tc.inverse {
+				t.Fatalf("isInverseContract = %v, want %v", got, tc.inverse)
+			}
+
+			size, err := tr.ContractsFromCoin(tc.instID, tc.coin)
+			if err != nil {
+				t.Fatalf("ContractsFromCoin: %v", err)
+			}
+			if size.Contracts != tc.contracts || size.Coin != tc.coin || size.Notional != tc.notional {
+				t.Errorf("ContractsFromCoin(%v) = %+v, want %v张 %v币 %v USD", tc.coin, size, tc.contracts, tc.coin, tc.notional)
+			}
+
+			size, err = tr.ContractsFromNotional(tc.instID, tc.notional)
+			if err != nil {
+				t.Fatalf("ContractsFromNotional: %v", err)
+			}
+			if size.Contracts != tc.contracts {
+				t.Errorf("ContractsFromNotional(%v) = %v张, want %v", tc.notional, size.Contracts, tc.contracts)
+			}
+
+			if coin, err := tr.CoinFromContracts(tc.instID, tc.contracts); err != nil || coin != tc.coin {
+				t.Errorf("CoinFromContracts(%v) = %v, %v, want %v", tc.contracts, coin, err, tc.coin)
+			}
+			if notional, err := tr.NotionalFromContracts(tc.instID, tc.contracts); err != nil || notional != tc.notional {
+				t.Errorf("NotionalFromContracts(%v) = %v, %v, want %v", tc.contracts, notional, err, tc.notional)
+			}
+		})
+	}
+}
+
+// TestOkxPositionsConformance 持仓数量统一换算为币，盈亏与保证金统一折算为USD（币本位按标记价格）
+func TestOkxPositionsConformance(t *testing.T) {
+	f := newConformanceOkx(t)
+	row := func(instID, pos, upl, margin string) string {
+		return fmt.Sprintf(`{"instId":%q,"instType":"SWAP","posSide":"long","pos":%q,"avgPx":"50000","markPx":"50000","lever":"10","mgnMode":"isolated","upl":%q,"margin":%q,"cTime":"1700000000000","uTime":"1700000000000"}`,
+			instID, pos, upl, margin)
+	}
+	f.reply("GET /api/v5/account/positions",
+		row("BTC-USDT-SWAP", "10", "25", "500"),
+		row("BTC-USDC-SWAP", "1000", "25", "500"),
+		row("BTC-USD-SWAP", "50", "0.0005", "0.01"),
+	)
+	positions, err := f.trader(t).Positions(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 3 {
+		t.Fatalf("持仓数 = %d, want 3", len(positions))
+	}
+	want := map[string]struct {
+		symbol    string
+		settleCcy string
+		uplCcy    float64
+	}{
+		"BTC-USDT-SWAP": {"BTCUSDT", "USDT", 25},
+		"BTC-USDC-SWAP": {"BTCUSDC", "USDC", 25},
+		"BTC-USD-SWAP":  {"BTCUSD", "BTC", 0.0005},
+	}
+	for _, pos := range positions {
+		w, ok := want[pos.InstID]
+		if !ok {
+			t.Fatalf("未知持仓 %s", pos.InstID)
+		}
+		if pos.Symbol != w.symbol || pos.SettleCcy != w.settleCcy {
+			t.Errorf("%s: symbol=%s settleCcy=%s, want %s %s", pos.InstID, pos.Symbol, pos.SettleCcy, w.symbol, w.settleCcy)
+		}
+		if pos.Quantity != 0.1 {
+			t.Errorf("%s: Quantity = %v, want 0.1 BTC", pos.InstID, pos.Quantity)
+		}
+		if math.Abs(pos.UnrealizedPnL-25) > 1e-9 || pos.UnrealizedPnLCcy != w.uplCcy {
+			t.Errorf("%s: UnrealizedPnL = %v (%v %s), want 25 USD", pos.InstID, pos.UnrealizedPnL, pos.UnrealizedPnLCcy, w.settleCcy)
+		}
+		if math.Abs(pos.IsolatedMarginUSD-500) > 1e-9 {
+			t.Errorf("%s: IsolatedMarginUSD = %v, want 500", pos.InstID, pos.IsolatedMarginUSD)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestOkxEmptyResponses 交易所返回成功但data为空时，各方法返回描述性的OkxError而不是越界panic
+func TestOkxEmptyResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		route  string
+		wantOp string
+		call   func(tr *OkxTrader) error
+	}{
+		{"balance", "GET /api/v5/account/balance", "GetBalance", func(tr *OkxTrader) error {
+			_, err := tr.GetBalance()
+			return err
+		}},
+		{"order placement", "POST /api/v5/trade/order", "PlaceOrder", func(tr *OkxTrader) error {
+			_, err := tr.OpenLong("BTCUSDT", 0.1, 10)
+			return err
+		}},
+		{"algo order placement", "POST /api/v5/trade/order-algo", "PlaceAlgoOrder", func(tr *OkxTrader) error {
+			return tr.SetStopLoss("BTCUSDT", PositionLong, 0.1, 48000)
+		}},
+		{"instruments", "GET /api/v5/public/instruments", "GetInstruments", func(tr *OkxTrader) error {
+			_, err := tr.FormatQuantity("BTCUSDT", 0.1)
+			return err
+		}},
+		{"market price", "GET /api/v5/market/ticker", "GetTicker", func(tr *OkxTrader) error {
+			_, err := tr.GetMarketPrice("BTCUSDT")
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			f.reply(tc.route) // code为0，data为[]
+			err := tc.call(f.trader(t))
+
+			var okxErr *OkxError
+			if !errors.As(err, &okxErr) {
+				t.Fatalf("err = %v, want OkxError", err)
+			}
+			if okxErr.Op != tc.wantOp || okxErr.Code != 0 || okxErr.SCode != 0 || okxErr.Msg != msg("err_okx_empty_response") {
+				t.Errorf("OkxError = %+v, want %s 空响应", okxErr, tc.wantOp)
+			}
+			if IsOutcomeUnknown(err) {
+				t.Errorf("空响应不应视为结果未知: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Benjmmi/okx/responses"
+)
+
+// ErrMalformedBalance 交易所返回的余额字段为空或无法解析
+var ErrMalformedBalance = newSentinelError(ErrCodeMalformedBalance, "err_malformed_balance")
+
+// 交易所拒绝原因的分类，交易所错误（OkxError、BybitAPIError）按错误码映射，
+// 使用 errors.Is(err, ErrInsufficientBalance) 等判断，原始错误码与信息通过 errors.As 取得
+var (
+	ErrInsufficientBalance = newSentinelError(ErrCodeInsufficientBalance, "err_insufficient_balance") // 余额或保证金不足
+	ErrInvalidSize         = newSentinelError(ErrCodeInvalidSize, "err_invalid_size")                 // 下单数量不符合交易规则（步长、最小/最大数量）
+	ErrLeverageCooldown    = newSentinelError(ErrCodeLeverageCooldown, "err_leverage_cooldown")       // 杠杆暂时无法修改（有挂单/持仓或刚修改过）
+)
+
+// okxErrorKinds OKX错误码（sCode优先，其次顶层code）对应的错误分类
+var okxErrorKinds = map[int64]error{
+	50011: ErrRateLimited, // 请求频率过高
+	50061: ErrRateLimited, // 订单请求频率过高
+
+	51008: ErrInsufficientBalance, // 可用余额/保证金不足
+	51119: ErrInsufficientBalance, // 保证金不足
+	51131: ErrInsufficientBalance, // 余额不足
+	59200: ErrInsufficientBalance, // 账户余额不足
+
+	51020: ErrInvalidSize, // 下单数量小于最小下单量
+	51120: ErrInvalidSize, // 下单数量小于最小下单量
+	51121: ErrInvalidSize, // 下单数量不是lotSz的整数倍
+	51201: ErrInvalidSize, // 市价单下单金额超过上限
+	51202: ErrInvalidSize, // 市价单下单数量超过上限
+	51203: ErrInvalidSize, // 下单数量超过上限
+
+	51023: ErrPositionNotFound, // 持仓不存在
+	51169: ErrPositionNotFound, // 该方向没有可平的持仓
+
+	51603: ErrOrderNotFound, // 订单不存在
+
+	59000: ErrLeverageCooldown, // 有持仓或挂单，设置失败
+}
+
+// OkxError OKX接口返回的业务错误
+// 批量/下单类接口整体code为1（全部失败）或2（部分失败）时，具体原因在每个订单的sCode/sMsg中
+type OkxError struct {
+	Op    string // 调用的接口，如 PlaceOrder
+	Code  int    // 顶层code（0为成功）
+	Msg   string // 顶层msg
+	SCode int64  // 单个订单的sCode（0为成功）
+	SMsg  string // 单个订单的sMsg
+}
+
+func (e *OkxError) Error() string {
+	// 顶层code为0而订单被拒绝（如保证金不足）时，以订单的sCode/sMsg作为错误信息
+	if e.Code == 0 && e.SCode != 0 {
+		return msg("err_okx_order_rejected", e.Op, e.SCode, e.SMsg)
+	}
+	text := msg("err_okx_request", e.Op, e.Code)
+	if e.Msg != "" {
+		text += " " + e.Msg
+	}
+	if e.SCode != 0 || e.SMsg != "" {
+		text += fmt.Sprintf(" (sCode=%d %s)", e.SCode, e.SMsg)
+	}
+	return text
+}
+
+// ErrorCode 已分类的错误返回分类的错误码，其他返回 ErrCodeExchangeRejected
+func (e *OkxError) ErrorCode() ErrorCode {
+	if kind := e.kind(); kind != nil {
+		return ErrorCodeOf(kind)
+	}
+	return ErrCodeExchangeRejected
+}
+
+// Is 使 errors.Is(err, ErrInsufficientBalance) 等按错误码的分类判断成立
+func (e *OkxError) Is(target error) bool {
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+// kind 错误码对应的分类（sCode优先），未分类时返回nil
+func (e *OkxError) kind() error {
+	if kind, ok := okxErrorKinds[e.SCode]; ok {
+		return kind
+	}
+	return okxErrorKinds[int64(e.Code)]
+}
+
+// okxAlreadyGoneCodes 撤单时订单已成交、已撤销或不存在（列出挂单与撤单之间订单已结束）
+var okxAlreadyGoneCodes = map[int64]bool{
+	51400: true, // 撤单失败，订单已成交、已撤销或不存在
+	51401: true, // 订单已撤销
+	51402: true, // 订单已完成
+	51410: true, // 订单已处于最终状态
+	51603: true, // 订单不存在
+}
+
+// okxGoneState 根据撤单错误码判断订单结束原因，无法判断时返回空
+func okxGoneState(err error) GoneState {
+	var okxErr *OkxError
+	if !errors.As(err, &okxErr) {
+		return ""
+	}
+	code := okxErr.SCode
+	if code == 0 {
+		code = int64(okxErr.Code)
+	}
+	switch code {
+	case 51401:
+		return GoneCancelled
+	case 51402:
+		return GoneFilled
+	case 51603:
+		return GoneNotFound
+	}
+	return ""
+}
+
+// isOkxAlreadyGone 撤单错误是否表示订单已经结束
+func isOkxAlreadyGone(err error) bool {
+	var okxErr *OkxError
+	if !errors.As(err, &okxErr) {
+		return false
+	}
+	return okxAlreadyGoneCodes[okxErr.SCode] || (okxErr.SCode == 0 && okxAlreadyGoneCodes[int64(okxErr.Code)])
+}
+
+// okxResponseError 根据顶层code与单个订单的sCode生成错误，都为0时返回nil
+func okxResponseError(op string, code int, msg string, sCode int64, sMsg string) error {
+	if code == 0 && sCode == 0 {
+		return nil
+	}
+	return &OkxError{Op: op, Code: code, Msg: msg, SCode: sCode, SMsg: sMsg}
+}
+
+// okxCheck 统一的响应检查：code非0返回OkxError，要求有数据(n为数据条数，-1表示不检查)但为空时返回空响应错误
+// OKX成功的code为0（不是HTTP的200），所有REST响应都应通过这里判断
+func okxCheck(op string, basic responses.Basic, n int) error {
+	if basic.Code != 0 {
+		return &OkxError{Op: op, Code: basic.Code, Msg: basic.Msg}
+	}
+	if n == 0 {
+		return okxEmptyResponse(op)
+	}
+	return nil
+}
+
+// okxEmptyResponse 接口返回成功但没有数据
+func okxEmptyResponse(op string) error {
+	return &OkxError{Op: op, Msg: msg("err_okx_empty_response")}
+}
@@ -0,0 +1,177 @@
+package trader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Benjmmi/okx/responses"
+)
+
+// TestOkxOrderRejectionCarriesReason 交易所拒绝下单时，最终返回给调用方的错误包含交易所给出的原因，并可按错误码分类
+func TestOkxOrderRejectionCarriesReason(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		reason   string
+		wantKind error
+		sCode    int64
+	}{
+		{
+			name:     "sCode in data",
+			payload:  okxFail(1, "All operations failed", `{"clOrdId":"","ordId":"","sCode":"51008","sMsg":"Order failed. Insufficient USDT margin in account","tag":""}`),
+			reason:   "Insufficient USDT margin in account",
+			wantKind: ErrInsufficientBalance,
+			sCode:    51008,
+		},
+		{
+			name:     "lot size",
+			payload:  okxFail(1, "All operations failed", `{"clOrdId":"","ordId":"","sCode":"51121","sMsg":"Order quantity must be a multiple of the lot size.","tag":""}`),
+			reason:   "Order quantity must be a multiple of the lot size.",
+			wantKind: ErrInvalidSize,
+			sCode:    51121,
+		},
+		{
+			name:    "top-level code only",
+			payload: okxFail(51000, "Parameter sz error"),
+			reason:  "Parameter sz error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			payload := tc.payload
+			f.handle("POST /api/v5/trade/order", func(fakeOkxRequest) string { return payload })
+
+			_, err := f.trader(t).OpenLong("BTCUSDT", 0.1, 10)
+			if err == nil {
+				t.Fatal("被拒绝的下单应返回错误")
+			}
+			if !strings.Contains(err.Error(), tc.reason) {
+				t.Errorf("错误信息 %q 不包含交易所原因 %q", err, tc.reason)
+			}
+			if tc.wantKind != nil && !errors.Is(err, tc.wantKind) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tc.wantKind)
+			}
+			var okxErr *OkxError
+			if !errors.As(err, &okxErr) || okxErr.SCode != tc.sCode {
+				t.Errorf("errors.As OkxError = %+v, want sCode %d", okxErr, tc.sCode)
+			}
+			if IsOutcomeUnknown(err) {
+				t.Error("明确的拒绝不应视为结果未知")
+			}
+			if n := f.calls("POST /api/v5/trade/order"); n != 1 {
+				t.Errorf("下单请求次数 = %d, want 1", n)
+			}
+		})
+	}
+}
+
+// TestOkxZeroCodeRowRejection 顶层code为0、订单行sCode为51008（保证金不足）时仍是被拒绝的下单：
+// 返回可分类的OkxError，错误信息为订单的sMsg，不按已下单等待成交
+func TestOkxZeroCodeRowRejection(t *testing.T) {
+	const reason = "Order failed. Insufficient USDT margin in account"
+	for _, tc := range []struct {
+		name  string
+		route string
+		row   string
+		call  func(tr *OkxTrader) error
+	}{
+		{"PlaceOrder", "POST /api/v5/trade/order",
+			`{"clOrdId":"","ordId":"","sCode":"51008","sMsg":"` + reason + `","tag":""}`,
+			func(tr *OkxTrader) error {
+				_, err := tr.OpenLong("BTCUSDT", 0.1, 10)
+				return err
+			}},
+		{"PlaceAlgoOrder", "POST /api/v5/trade/order-algo",
+			`{"algoId":"","clOrdId":"","sCode":"51008","sMsg":"` + reason + `"}`,
+			func(tr *OkxTrader) error {
+				return tr.SetStopLoss("BTCUSDT", PositionLong, 0.1, 48000)
+			}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			f.reply(tc.route, tc.row) // okxOK：顶层code为0
+			err := tc.call(f.trader(t))
+
+			var okxErr *OkxError
+			if !errors.As(err, &okxErr) {
+				t.Fatalf("err = %v, want OkxError", err)
+			}
+			if okxErr.Op != tc.name || okxErr.Code != 0 || okxErr.SCode != 51008 || okxErr.SMsg != reason {
+				t.Errorf("OkxError = %+v", okxErr)
+			}
+			if !errors.Is(err, ErrInsufficientBalance) || ErrorCodeOf(err) != ErrCodeInsufficientBalance {
+				t.Errorf("err = %v, want ErrInsufficientBalance", err)
+			}
+			if !strings.Contains(err.Error(), reason) || !strings.Contains(err.Error(), "51008") {
+				t.Errorf("错误信息 %q 应包含sCode与交易所原因", err)
+			}
+			if IsOutcomeUnknown(err) {
+				t.Error("明确的拒绝不应视为结果未知")
+			}
+			if n := f.calls(tc.route); n != 1 {
+				t.Errorf("请求次数 = %d, want 1（被拒绝的下单不重试）", n)
+			}
+			if n := f.calls("GET /api/v5/trade/order"); n != 0 {
+				t.Errorf("被拒绝的下单不应查询成交，实际 %d 次", n)
+			}
+		})
+	}
+}
+
+// TestOkxResponseError 顶层code与订单sCode都为0时成功，任一非0时返回带分类的OkxError
+func TestOkxResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		code     int
+		sCode    int64
+		wantErr  bool
+		wantKind error
+	}{
+		{"success", 0, 0, false, nil},
+		{"row rejected with zero code", 0, 51008, true, ErrInsufficientBalance},
+		{"all failed", 1, 51008, true, ErrInsufficientBalance},
+		{"partial failure", 2, 51121, true, ErrInvalidSize},
+		{"top-level only", 50011, 0, true, ErrRateLimited},
+		{"unclassified", 0, 51000, true, nil},
+	} {
+		err := okxResponseError("PlaceOrder", tc.code, "msg", tc.sCode, "smsg")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: okxResponseError = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if tc.wantKind != nil && !errors.Is(err, tc.wantKind) {
+			t.Errorf("%s: errors.Is(%v, %v) = false", tc.name, err, tc.wantKind)
+		}
+		if tc.wantErr && tc.wantKind == nil && ErrorCodeOf(err) != ErrCodeExchangeRejected {
+			t.Errorf("%s: 未分类错误码 = %v, want ErrCodeExchangeRejected", tc.name, ErrorCodeOf(err))
+		}
+	}
+}
+
+// TestOkxCheck 顶层code非0返回OkxError，要求有数据但为空时返回空响应错误
+// （okxCheck只检查顶层code，下单类接口的订单行sCode由okxResponseError检查）
+func TestOkxCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		code    int
+		n       int
+		wantErr bool
+	}{
+		{"success with data", 0, 1, false},
+		{"success without count check", 0, -1, false},
+		{"empty data", 0, 0, true},
+		{"failure", 51000, 1, true},
+		{"failure without data", 50011, 0, true},
+	} {
+		err := okxCheck("GetBalance", responses.Basic{Code: tc.code, Msg: "msg"}, tc.n)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: okxCheck = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		var okxErr *OkxError
+		if tc.wantErr && (!errors.As(err, &okxErr) || okxErr.Code != tc.code || okxErr.Op != "GetBalance") {
+			t.Errorf("%s: err = %#v, want OkxError code %d", tc.name, err, tc.code)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeOkxRequest 假交易所收到的一次请求
+type fakeOkxRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// fakeOkxHandler 返回完整的响应体（code/msg/data）
+type fakeOkxHandler func(req fakeOkxRequest) string
+
+// fakeOkx 按 "METHOD path" 应答的OKX REST假服务器，未注册的接口返回空的成功响应
+type fakeOkx struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]fakeOkxHandler
+	requests []fakeOkxRequest
+}
+
+// okxTestInstrument BTC-USDT-SWAP的交易规则（1张=0.01 BTC）
+const okxTestInstrument = `{"instId":"BTC-USDT-SWAP","instType":"SWAP","ctType":"linear","ctVal":"0.01","ctValCcy":"BTC","settleCcy":"USDT","lotSz":"1","minSz":"1","tickSz":"0.1","lever":"100","state":"live"}`
+
+// okxTestTiers BTC-USDT-SWAP的阶梯保证金（张）：第1档 (0, 2000] 最大100倍，第2档 (2000, 5000] 最大50倍，第3档 (5000, 10000] 最大20倍
+var okxTestTiers = []string{
+	`{"instId":"BTC-USDT-SWAP","instType":"SWAP","tier":"1","minSz":"0","maxSz":"2000","mmr":"0.004","imr":"0.01","maxLever":"100"}`,
+	`{"instId":"BTC-USDT-SWAP","instType":"SWAP","tier":"2","minSz":"2000","maxSz":"5000","mmr":"0.01","imr":"0.02","maxLever":"50"}`,
+	`{"instId":"BTC-USDT-SWAP","instType":"SWAP","tier":"3","minSz":"5000","maxSz":"10000","mmr":"0.025","imr":"0.05","maxLever":"20"}`,
+}
+
+// okxTestInstrumentWith 指定下单步长、最小下单量与价格步长的BTC-USDT-SWAP交易规则
+func okxTestInstrumentWith(lotSz, minSz, tickSz string) string {
+	return fmt.Sprintf(`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ctType":"linear","ctVal":"0.01","ctValCcy":"BTC","settleCcy":"USDT","lotSz":%q,"minSz":%q,"tickSz":%q,"lever":"100","state":"live"}`,
+		lotSz, minSz, tickSz)
+}
+
+func newFakeOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := &fakeOkx{routes: make(map[string]fakeOkxHandler)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	f.reply("GET /api/v5/public/instruments", okxTestInstrument)
+	f.reply("GET /api/v5/public/mark-price", `{"instId":"BTC-USDT-SWAP","markPx":"50000"}`)
+	f.reply("GET /api/v5/market/ticker", `{"instId":"BTC-USDT-SWAP","last":"50000","askPx":"50000.1","bidPx":"49999.9"}`)
+	f.reply("GET /api/v5/account/config", `{"uid":"1","acctLv":"2","posMode":"long_short_mode"}`)
+	f.reply("GET /api/v5/public/position-tiers", okxTestTiers...)
+	f.handle("POST /api/v5/account/set-leverage", func(req fakeOkxRequest) string {
+		return okxOK(req.Body)
+	})
+	return f
+}
+
+func (f *fakeOkx) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := fakeOkxRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: string(body)}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	h := f.routes[r.Method+" "+r.URL.Path]
+	f.mu.Unlock()
+	resp := okxOK()
+	if h != nil {
+		resp = h(req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, resp)
+}
+
+// handle 注册接口的应答函数（覆盖之前的注册）
+func (f *fakeOkx) handle(route string, h fakeOkxHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[route] = h
+}
+
+// reply 注册固定的成功应答，rows为data数组中的JSON对象
+func (f *fakeOkx) reply(route string, rows ...string) {
+	resp := okxOK(rows...)
+	f.handle(route, func(fakeOkxRequest) string { return resp })
+}
+
+// calls 某接口被请求的次数
+func (f *fakeOkx) calls(route string) int {
+	return len(f.requestsTo(route))
+}
+
+// requestsTo 某接口收到的请求
+func (f *fakeOkx) requestsTo(route string) []fakeOkxRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeOkxRequest
+	for _, r := range f.requests {
+		if r.Method+" "+r.Path == route {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// trader 创建连接到假服务器的交易器（不限速）
+func (f *fakeOkx) trader(t *testing.T, opts ...OkxOption) *OkxTrader {
+	t.Helper()
+	opts = append([]OkxOption{WithBaseURL(f.srv.URL), WithRateLimits(OkxRateLimits{})}, opts...)
+	tr, err := NewOkxTrader("key", "secret", "pass", opts...)
+	if err != nil {
+		t.Fatalf("NewOkxTrader: %v", err)
+	}
+	t.Cleanup(tr.clientCancel)
+	return tr
+}
+
+// okxOK 成功响应
+func okxOK(rows ...string) string {
+	return `{"code":"0","msg":"","data":[` + strings.Join(rows, ",") + `]}`
+}
+
+// okxFail 失败响应（data中可以带sCode/sMsg）
+func okxFail(code int, message string, rows ...string) string {
+	return fmt.Sprintf(`{"code":"%d","msg":%q,"data":[%s]}`, code, message, strings.Join(rows, ","))
+}
+
+// okxTestPosition 持仓的推送/查询数据
+func okxTestPosition(instID, posSide, pos, avgPx string, lever int) string {
+	return fmt.Sprintf(`{"instId":%q,"instType":"SWAP","posId":"%s-%s","posSide":%q,"pos":%q,"avgPx":%q,"markPx":%q,"lever":"%d","mgnMode":"cross","upl":"0","liqPx":"","margin":"0","notionalUsd":"0","cTime":"1700000000000","uTime":"1700000000000"}`,
+		instID, instID, posSide, posSide, pos, avgPx, avgPx, lever)
+}
+
+// okxTestFilledOrder 已完全成交的订单详情
+func okxTestFilledOrder(ordID, clOrdID, side, posSide, sz, avgPx string) string {
+	return fmt.Sprintf(`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":%q,"clOrdId":%q,"side":%q,"posSide":%q,"ordType":"market","sz":%q,"accFillSz":%q,"avgPx":%q,"state":"filled","lever":"10","fee":"-0.1","feeCcy":"USDT","pnl":"0","uTime":"1700000000000","cTime":"1700000000000"}`,
+		ordID, clOrdID, side, posSide, sz, sz, avgPx)
+}
+
+// jsonField 读取请求体（对象或只有一个对象的数组）中的字段
+func jsonField(t *testing.T, body, field string) interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if strings.HasPrefix(strings.TrimSpace(body), "[") {
+		var arr []map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &arr); err != nil || len(arr) == 0 {
+			t.Fatalf("无法解析请求体 %s: %v", body, err)
+		}
+		obj = arr[0]
+	} else if err := json.Unmarshal([]byte(body), &obj); err != nil {
+		t.Fatalf("无法解析请求体 %s: %v", body, err)
+	}
+	return obj[field]
+}
+
+// batchField 读取批量请求体（对象数组）中每个对象的字符串字段
+func batchField(t *testing.T, body, field string) []string {
+	t.Helper()
+	var arr []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &arr); err != nil {
+		t.Fatalf("无法解析批量请求体 %s: %v", body, err)
+	}
+	out := make([]string, 0, len(arr))
+	for _, obj := range arr {
+		v, _ := obj[field].(string)
+		out = append(out, v)
+	}
+	return out
+}
+
+// mustFloat 解析测试用例中的十进制字符串
+func mustFloat(t *testing.T, s string) float64 {
+	t.Helper()
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatalf("无效的数字 %q: %v", s, err)
+	}
+	return v
+}
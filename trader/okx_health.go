@@ -0,0 +1,62 @@
+package trader
+
+import (
+	"sync"
+	"time"
+)
+
+// OkxHealth REST调用的健康状况（经okxCall的所有请求，不含客户端限速等待失败）
+type OkxHealth struct {
+	LastSuccess       time.Time        `json:"last_success,omitzero"`
+	LastError         time.Time        `json:"last_error,omitzero"`
+	LastErrorOp       string           `json:"last_error_op,omitempty"`
+	LastErrorMessage  string           `json:"last_error_message,omitempty"`
+	Requests          int64            `json:"requests"`
+	Errors            int64            `json:"errors"`
+	ConsecutiveErrors int64            `json:"consecutive_errors"` // 最近一次成功之后的连续失败次数
+	ErrorsByOp        map[string]int64 `json:"errors_by_op,omitempty"`
+}
+
+// okxHealth 记录REST调用结果
+type okxHealth struct {
+	mu     sync.Mutex
+	health OkxHealth
+}
+
+func (h *okxHealth) record(now time.Time, op string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.health.Requests++
+	if err == nil {
+		h.health.LastSuccess = now
+		h.health.ConsecutiveErrors = 0
+		return
+	}
+	h.health.Errors++
+	h.health.ConsecutiveErrors++
+	h.health.LastError = now
+	h.health.LastErrorOp = op
+	h.health.LastErrorMessage = err.Error()
+	if h.health.ErrorsByOp == nil {
+		h.health.ErrorsByOp = make(map[string]int64)
+	}
+	h.health.ErrorsByOp[op]++
+}
+
+func (h *okxHealth) snapshot() OkxHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.health
+	if len(h.health.ErrorsByOp) > 0 {
+		s.ErrorsByOp = make(map[string]int64, len(h.health.ErrorsByOp))
+		for op, n := range h.health.ErrorsByOp {
+			s.ErrorsByOp[op] = n
+		}
+	}
+	return s
+}
+
+// Health 返回REST调用的健康状况
+func (t *OkxTrader) Health() OkxHealth {
+	return t.health.snapshot()
+}
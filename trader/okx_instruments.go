@@ -0,0 +1,307 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	"github.com/Benjmmi/okx/models/publicdata"
+	marketReq "github.com/Benjmmi/okx/requests/rest/market"
+	"github.com/Benjmmi/okx/responses"
+	marketResp "github.com/Benjmmi/okx/responses/market"
+	"github.com/shopspring/decimal"
+)
+
+// resolveOkxInstID 解析交易对ID与品种类型
+//   - 完整的OKX ID按后缀推断：BTC-USDT-SWAP为永续，BTC-USD-250328为交割，BTC-USDT为现货
+//   - 系统内的币种格式（BTCUSDT）按defaultType转换：永续为BTC-USDT-SWAP，现货为BTC-USDT
+func resolveOkxInstID(symbol string, defaultType okx.InstrumentType) (string, okx.InstrumentType, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	if strings.Contains(symbol, "-") {
+		parts := strings.Split(symbol, "-")
+		switch {
+		case len(parts) == 3 && parts[2] == "SWAP":
+			return symbol, okx.SwapInstrument, nil
+		case len(parts) == 3 && isOkxExpiry(parts[2]):
+			return symbol, okx.FuturesInstrument, nil
+		case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+			return symbol, okx.SpotInstrument, nil
+		}
+		return "", "", fmt.Errorf("无法识别交易对 %s 的品种类型（支持 SWAP/FUTURES/SPOT）", symbol)
+	}
+
+	var base, quote string
+	for _, q := range []string{"USDT", "USDC", "USD"} {
+		if strings.HasSuffix(symbol, q) && len(symbol) > len(q) {
+			base, quote = strings.TrimSuffix(symbol, q), q
+			break
+		}
+	}
+	if base == "" {
+		return "", "", fmt.Errorf("无法识别交易对 %s 的计价币种", symbol)
+	}
+
+	switch defaultType {
+	case "", okx.SwapInstrument:
+		return base + "-" + quote + "-SWAP", okx.SwapInstrument, nil
+	case okx.SpotInstrument:
+		return base + "-" + quote, okx.SpotInstrument, nil
+	case okx.FuturesInstrument:
+		return "", "", fmt.Errorf("交割合约 %s 需要使用完整合约ID（如 %s-%s-250328）", symbol, base, quote)
+	}
+	return "", "", fmt.Errorf("不支持的品种类型 %s", defaultType)
+}
+
+// isOkxExpiry 交割合约到期日后缀（YYMMDD）
+func isOkxExpiry(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetInstType 设置系统币种格式（如BTCUSDT）默认对应的品种类型，默认为永续合约
+// 完整的OKX ID（如BTC-USD-250328）总是按后缀推断
+func (t *OkxTrader) SetInstType(instType okx.InstrumentType) {
+	t.instType = instType
+}
+
+// resolveInstID 按当前配置解析交易对
+func (t *OkxTrader) resolveInstID(symbol string) (string, okx.InstrumentType, error) {
+	return resolveOkxInstID(symbol, t.instType)
+}
+
+// okxInstrumentTTL 交易规则缓存的默认有效期（交易规则很少变化）
+const okxInstrumentTTL = time.Hour
+
+// okxInstrumentEntry 交易规则缓存项
+type okxInstrumentEntry struct {
+	inst     *publicdata.Instrument
+	maxLmtSz float64         // 限价单单笔最大张数（0表示未知）
+	maxMktSz float64         // 市价单单笔最大张数（0表示未知）
+	lotSz    decimal.Decimal // 交易所返回的下单数量步长（十进制字符串解析，不经过float64）
+	fetched  time.Time
+}
+
+// okxInstrumentInfo 交易规则（SDK的Instrument缺少单笔最大数量字段）
+type okxInstrumentInfo struct {
+	publicdata.Instrument
+	MaxLmtSz okx.JSONFloat64 `json:"maxLmtSz"`
+	MaxMktSz okx.JSONFloat64 `json:"maxMktSz"`
+	// LotSz 覆盖Instrument.LotSz，按十进制字符串解析（解码后回填到Instrument.LotSz）
+	LotSz okxDecimal `json:"lotSz"`
+}
+
+// okxDecimal 交易所返回的十进制字符串（空字符串解析为0）
+type okxDecimal struct{ decimal.Decimal }
+
+func (d *okxDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == `""` || string(data) == "null" {
+		d.Decimal = decimal.Zero
+		return nil
+	}
+	return d.Decimal.UnmarshalJSON(data)
+}
+
+// okxInstrumentsResponse 交易规则查询响应
+type okxInstrumentsResponse struct {
+	responses.Basic
+	Instruments []*okxInstrumentInfo `json:"data"`
+}
+
+// getInstrument 获取交易对规则（带缓存）
+func (t *OkxTrader) getInstrument(symbol string) (*publicdata.Instrument, error) {
+	entry, err := t.instrumentEntry(context.Background(), symbol)
+	if err != nil {
+		return nil, err
+	}
+	return entry.inst, nil
+}
+
+// instrumentEntry 获取交易规则缓存项，过期或不存在时查询交易所（同一交易对的并发查询只发一次请求）
+// 过期后查询失败时继续使用旧的交易规则
+func (t *OkxTrader) instrumentEntry(ctx context.Context, symbol string) (*okxInstrumentEntry, error) {
+	instID, instType, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	t.instrumentsMutex.RLock()
+	entry, ok := t.instruments[instID]
+	t.instrumentsMutex.RUnlock()
+	if ok && (t.instrumentTTL <= 0 || t.clock.Since(entry.fetched) < t.instrumentTTL) {
+		t.cacheStats.instruments.hit()
+		t.metrics.cache("instruments", true)
+		return entry, nil
+	}
+	t.cacheStats.instruments.miss()
+	t.metrics.cache("instruments", false)
+
+	entries, err := t.loadInstruments(ctx, instType, instID)
+	if err != nil {
+		if ok {
+			t.logger.Warn("刷新交易规则失败，继续使用缓存", "instId", instID, "err", err)
+			return entry, nil
+		}
+		return nil, fmt.Errorf("获取 %s 交易规则失败: %w", instID, err)
+	}
+	for _, e := range entries {
+		if e.inst.InstID == instID {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("未找到交易对 %s (%s) 的交易规则: %w", instID, instType, okxEmptyResponse("GetInstruments"))
+}
+
+// RefreshInstruments 重新加载交易规则：每种品种类型一次请求加载全部交易对，替换缓存中的规则
+// 加载的品种类型为默认品种类型与缓存中已有的类型
+func (t *OkxTrader) RefreshInstruments(ctx context.Context) error {
+	types := map[okx.InstrumentType]bool{t.instType: true}
+	t.instrumentsMutex.RLock()
+	for _, entry := range t.instruments {
+		types[entry.inst.InstType] = true
+	}
+	t.instrumentsMutex.RUnlock()
+
+	var errs []error
+	for instType := range types {
+		entries, err := t.loadInstruments(ctx, instType, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("加载 %s 交易规则失败: %w", instType, err))
+			continue
+		}
+		t.logger.Debug("已加载交易规则", "instType", instType, "count", len(entries))
+	}
+	return errors.Join(errs...)
+}
+
+// loadInstruments 查询交易规则并写入缓存（instID为空时查询该类型的全部交易对）
+// 相同的查询同时只发一次请求，其余调用等待并共享结果
+func (t *OkxTrader) loadInstruments(ctx context.Context, instType okx.InstrumentType, instID string) ([]*okxInstrumentEntry, error) {
+	key := string(instType) + "/" + instID
+	entries, err, _ := t.instrumentFlight.Do(key, func() ([]*okxInstrumentEntry, error) {
+		params := map[string]string{"instType": string(instType)}
+		if instID != "" {
+			params["instId"] = instID
+		}
+		resp, err := okxCall(ctx, t, OpPublicRead, "GetInstruments", func() (okxInstrumentsResponse, error) {
+			var resp okxInstrumentsResponse
+			res, err := t.api().Rest.Do(http.MethodGet, "/api/v5/public/instruments", false, params)
+			if err != nil {
+				return resp, err
+			}
+			defer res.Body.Close()
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			return resp, err
+		})
+		if err == nil {
+			err = okxCheck("GetInstruments", resp.Basic, len(resp.Instruments))
+		}
+		now := t.clock.Now()
+		t.cacheStats.instruments.refreshed(now, err)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]*okxInstrumentEntry, 0, len(resp.Instruments))
+		t.instrumentsMutex.Lock()
+		if t.instruments == nil {
+			t.instruments = make(map[string]*okxInstrumentEntry)
+		}
+		for _, info := range resp.Instruments {
+			inst := info.Instrument
+			inst.LotSz = okx.JSONFloat64(info.LotSz.InexactFloat64())
+			entry := &okxInstrumentEntry{
+				inst:     &inst,
+				maxLmtSz: float64(info.MaxLmtSz),
+				maxMktSz: float64(info.MaxMktSz),
+				lotSz:    info.LotSz.Decimal,
+				fetched:  now,
+			}
+			t.instruments[inst.InstID] = entry
+			entries = append(entries, entry)
+		}
+		t.instrumentsMutex.Unlock()
+		return entries, nil
+	})
+	return entries, err
+}
+
+// RoundQuantity 将张数向下取整到lotSz的整数倍，返回数值与下单用的字符串
+// 取整后低于minSz时返回错误
+func (t *OkxTrader) RoundQuantity(symbol string, quantity float64) (float64, string, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return 0, "", err
+	}
+	floored, err := floorToLot(quantity, float64(inst.LotSz), float64(inst.MinSz))
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", inst.InstID, err)
+	}
+	return decimalFloat(floored), floored.String(), nil
+}
+
+// FormatQuantity 将币的数量转换为下单张数并向下取整到lotSz的整数倍（OKX合约以张为单位下单）
+func (t *OkxTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	_, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	return toDecimal(contracts).String(), nil
+}
+
+// GetSymbolPrecision 获取数量精度（lotSz的小数位数，lotSz>=1时为0）
+func (t *OkxTrader) GetSymbolPrecision(symbol string) (int, error) {
+	entry, err := t.instrumentEntry(context.Background(), symbol)
+	if err != nil {
+		return 0, err
+	}
+	return stepPrecision(entry.lotSz), nil
+}
+
+// GetMarketPrice 获取最新成交价（SubscribeTicker订阅的币种读取WebSocket推送的价格）
+func (t *OkxTrader) GetMarketPrice(symbol string) (float64, error) {
+	return t.GetMarketPriceContext(context.Background(), symbol)
+}
+
+// GetMarketPriceContext 同GetMarketPrice，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetMarketPriceContext(ctx context.Context, symbol string) (float64, error) {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if price, ok, err := t.streamPrice(instID, false); ok {
+		if err != nil {
+			return 0, err
+		}
+		t.cacheStats.prices.hit()
+		t.metrics.cache("prices", true)
+		return price, nil
+	}
+	t.cacheStats.prices.miss()
+	t.metrics.cache("prices", false)
+	resp, err := okxCall(ctx, t, OpPublicRead, "GetMarketPrice", func() (marketResp.Ticker, error) {
+		return t.api().Rest.Market.GetTicker(marketReq.GetTicker{InstId: instID})
+	})
+	if err != nil {
+		t.cacheStats.prices.refreshed(t.clock.Now(), err)
+		return 0, fmt.Errorf("获取价格失败: %w", err)
+	}
+	err = okxCheck("GetTicker", resp.Basic, len(resp.Tickers))
+	t.cacheStats.prices.refreshed(t.clock.Now(), err)
+	if err != nil {
+		return 0, fmt.Errorf("未找到 %s 的价格: %w", instID, err)
+	}
+	return float64(resp.Tickers[0].Last), nil
+}
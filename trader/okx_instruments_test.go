@@ -0,0 +1,80 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/Benjmmi/okx"
+)
+
+func TestResolveOkxInstID(t *testing.T) {
+	for _, tc := range []struct {
+		symbol      string
+		defaultType okx.InstrumentType
+		wantID      string
+		wantType    okx.InstrumentType // 为空表示应返回错误
+	}{
+		// 完整ID按后缀推断，与默认类型无关
+		{"BTC-USDT-SWAP", okx.SpotInstrument, "BTC-USDT-SWAP", okx.SwapInstrument},
+		{"btc-usd-swap", "", "BTC-USD-SWAP", okx.SwapInstrument},
+		{"BTC-USD-250328", okx.SwapInstrument, "BTC-USD-250328", okx.FuturesInstrument},
+		{"ETH-USDT-241227", "", "ETH-USDT-241227", okx.FuturesInstrument},
+		{"BTC-USDT", okx.SwapInstrument, "BTC-USDT", okx.SpotInstrument},
+		{" eth-usdc ", "", "ETH-USDC", okx.SpotInstrument},
+		// 系统币种格式按默认类型转换
+		{"BTCUSDT", "", "BTC-USDT-SWAP", okx.SwapInstrument},
+		{"BTCUSDT", okx.SwapInstrument, "BTC-USDT-SWAP", okx.SwapInstrument},
+		{"ETHUSDC", okx.SwapInstrument, "ETH-USDC-SWAP", okx.SwapInstrument},
+		{"BTCUSD", okx.SwapInstrument, "BTC-USD-SWAP", okx.SwapInstrument},
+		{"BTCUSDT", okx.SpotInstrument, "BTC-USDT", okx.SpotInstrument},
+		// 交割合约必须使用完整ID；无法识别的格式返回错误
+		{"BTCUSDT", okx.FuturesInstrument, "", ""},
+		{"BTC-USD-2503", "", "", ""},
+		{"BTC-USDT-SWAP-X", "", "", ""},
+		{"BTC-", "", "", ""},
+		{"BTCEUR", "", "", ""},
+		{"USDT", "", "", ""},
+	} {
+		id, instType, err := resolveOkxInstID(tc.symbol, tc.defaultType)
+		if tc.wantType == "" {
+			if err == nil {
+				t.Errorf("resolveOkxInstID(%q, %q) = %s %s, want error", tc.symbol, tc.defaultType, id, instType)
+			}
+			continue
+		}
+		if err != nil || id != tc.wantID || instType != tc.wantType {
+			t.Errorf("resolveOkxInstID(%q, %q) = %s %s %v, want %s %s", tc.symbol, tc.defaultType, id, instType, err, tc.wantID, tc.wantType)
+		}
+	}
+}
+
+// TestOkxInstrumentLookupUsesInferredType 查询交易规则时使用从交易对推断出的instType
+func TestOkxInstrumentLookupUsesInferredType(t *testing.T) {
+	for _, tc := range []struct {
+		symbol   string
+		wantID   string
+		wantType string
+	}{
+		{"BTCUSDT", "BTC-USDT-SWAP", "SWAP"},
+		{"BTC-USD-250328", "BTC-USD-250328", "FUTURES"},
+		{"BTC-USDT", "BTC-USDT", "SPOT"},
+	} {
+		f := newFakeOkx(t)
+		f.handle("GET /api/v5/public/instruments", func(req fakeOkxRequest) string {
+			return okxOK(`{"instId":"` + req.Query.Get("instId") + `","instType":"` + req.Query.Get("instType") + `","ctVal":"0.01","lotSz":"1","minSz":"1","tickSz":"0.1","state":"live"}`)
+		})
+		inst, err := f.trader(t).getInstrument(tc.symbol)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.symbol, err)
+		}
+		reqs := f.requestsTo("GET /api/v5/public/instruments")
+		if len(reqs) != 1 {
+			t.Fatalf("%s: 交易规则查询次数 = %d, want 1", tc.symbol, len(reqs))
+		}
+		if q := reqs[0].Query; q.Get("instId") != tc.wantID || q.Get("instType") != tc.wantType {
+			t.Errorf("%s: 查询参数 instId=%s instType=%s, want %s %s", tc.symbol, q.Get("instId"), q.Get("instType"), tc.wantID, tc.wantType)
+		}
+		if inst.InstID != tc.wantID {
+			t.Errorf("%s: InstID = %s, want %s", tc.symbol, inst.InstID, tc.wantID)
+		}
+	}
+}
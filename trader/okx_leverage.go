@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	account2 "github.com/Benjmmi/okx/requests/rest/account"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+)
+
+// okxLeverageConfigInterval 批量设置杠杆时两次修改之间的间隔（OKX设置杠杆限速为20次/2秒）
+const okxLeverageConfigInterval = 200 * time.Millisecond
+
+// leverageConfirmed 返回已确认的杠杆，未确认时返回0
+func (t *OkxTrader) leverageConfirmed(instID string, mgnMode okx.MarginMode) int {
+	t.leverageMu.Lock()
+	defer t.leverageMu.Unlock()
+	return t.confirmedLeverage[instID+"_"+string(mgnMode)]
+}
+
+// confirmLeverage 记录已确认的杠杆（leverage为0时清除，下次开仓重新设置）
+func (t *OkxTrader) confirmLeverage(instID string, mgnMode okx.MarginMode, leverage int) {
+	t.leverageMu.Lock()
+	defer t.leverageMu.Unlock()
+	key := instID + "_" + string(mgnMode)
+	if leverage <= 0 {
+		delete(t.confirmedLeverage, key)
+		return
+	}
+	if t.confirmedLeverage == nil {
+		t.confirmedLeverage = make(map[string]int)
+	}
+	t.confirmedLeverage[key] = leverage
+}
+
+// getLeverage 查询当前杠杆（按持仓方向，全仓或单向持仓时只有一条，方向为空）
+func (t *OkxTrader) getLeverage(ctx context.Context, instID string, mgnMode okx.MarginMode) (map[string]int, error) {
+	resp, err := okxCall(ctx, t, OpPrivateRead, "GetLeverage", func() (accountResp.Leverage, error) {
+		return t.api().Rest.Account.GetLeverage(account2.GetLeverage{InstID: []string{instID}, MgnMode: mgnMode})
+	})
+	if err == nil {
+		err = okxCheck("GetLeverage", resp.Basic, len(resp.Leverages))
+	}
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]int, len(resp.Leverages))
+	for _, l := range resp.Leverages {
+		current[string(l.PosSide)] = int(l.Lever)
+	}
+	return current, nil
+}
+
+// ConfigureLeverage 实现LeverageConfigurer：逐个币种查询当前杠杆，只修改不一致的方向
+// 两次修改之间间隔okxLeverageConfigInterval，避免触发限速
+func (t *OkxTrader) ConfigureLeverage(leverages map[string]int) []LeverageResult {
+	return t.ConfigureLeverageContext(context.Background(), leverages)
+}
+
+// ConfigureLeverageContext 同ConfigureLeverage，ctx结束后（包括等待限速间隔时）剩余币种直接记为 ErrCanceled
+func (t *OkxTrader) ConfigureLeverageContext(ctx context.Context, leverages map[string]int) []LeverageResult {
+	results := make([]LeverageResult, 0, len(leverages))
+	lastChange := time.Time{}
+
+	for _, symbol := range sortedLeverageSymbols(leverages) {
+		r := LeverageResult{Symbol: symbol, Target: leverages[symbol]}
+		r.Err = t.configureSymbolLeverage(ctx, &r, &lastChange)
+		if r.Err != nil {
+			r.Error = r.Err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// configureSymbolLeverage 按该币种的保证金模式设置杠杆，结果写入r
+func (t *OkxTrader) configureSymbolLeverage(ctx context.Context, r *LeverageResult, lastChange *time.Time) error {
+	if r.Target <= 0 {
+		return fmt.Errorf("杠杆必须大于0 (当前 %d)", r.Target)
+	}
+	instID, _, err := t.resolveInstID(r.Symbol)
+	if err != nil {
+		return err
+	}
+	mgnMode := t.marginMode(instID)
+	current, err := t.getLeverage(ctx, instID, mgnMode)
+	if err != nil {
+		return fmt.Errorf("查询 %s 杠杆失败: %w", r.Symbol, err)
+	}
+	r.Previous = current
+
+	var posSides []okx.PositionSide
+	for posSide, lever := range current {
+		if lever == r.Target {
+			continue
+		}
+		if posSide == "net" {
+			posSide = "" // 单向持仓设置杠杆时不传方向
+		}
+		posSides = append(posSides, okx.PositionSide(posSide))
+	}
+	if len(posSides) == 0 {
+		t.confirmLeverage(instID, mgnMode, r.Target)
+		return nil
+	}
+
+	if wait := okxLeverageConfigInterval - t.clock.Since(*lastChange); !lastChange.IsZero() && wait > 0 {
+		if err := sleepContext(ctx, t.clock, wait); err != nil {
+			return &CanceledError{Op: "SetLeverage", Class: OpMutation, Err: err}
+		}
+	}
+	err = t.setLeverageSides(ctx, instID, r.Target, mgnMode, posSides)
+	*lastChange = t.clock.Now()
+	if err != nil {
+		return fmt.Errorf("设置 %s 杠杆 %dx 失败: %w", r.Symbol, r.Target, err)
+	}
+	r.Changed = true
+	return nil
+}
@@ -0,0 +1,173 @@
+package trader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestOkxOpenStopsOnLeverageFailure 设置杠杆失败时开仓返回描述清楚的错误，且不下单
+func TestOkxOpenStopsOnLeverageFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		reason   string
+		wantKind error
+	}{
+		{
+			name:     "open orders block leverage change",
+			payload:  okxFail(59000, "Setting failed. Cancel any open orders, close positions, and stop trading bots first."),
+			reason:   "Cancel any open orders",
+			wantKind: ErrLeverageCooldown,
+		},
+		{
+			name:    "leverage above instrument max",
+			payload: okxFail(51000, "Parameter lever error"),
+			reason:  "Parameter lever error",
+		},
+		{
+			name:    "success code without data",
+			payload: okxOK(),
+			reason:  "SetLeverage",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			payload := tc.payload
+			f.handle("POST /api/v5/account/set-leverage", func(fakeOkxRequest) string { return payload })
+
+			_, err := f.trader(t).OpenLong("BTCUSDT", 0.1, 10)
+			if err == nil {
+				t.Fatal("设置杠杆失败时开仓应返回错误")
+			}
+			for _, want := range []string{"BTCUSDT", "10x", tc.reason} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("错误信息 %q 不包含 %q", err, want)
+				}
+			}
+			if tc.wantKind != nil && !errors.Is(err, tc.wantKind) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tc.wantKind)
+			}
+			if n := f.calls("POST /api/v5/account/set-leverage"); n != 1 {
+				t.Errorf("设置杠杆请求次数 = %d, want 1", n)
+			}
+			if n := f.calls("POST /api/v5/trade/order"); n != 0 {
+				t.Errorf("设置杠杆失败后仍下单 %d 次", n)
+			}
+		})
+	}
+}
+
+// TestOkxSetLeverage 设置成功后记为已确认，相同杠杆不再请求；逐仓双向持仓时多空分别设置
+func TestOkxSetLeverage(t *testing.T) {
+	t.Run("cross", func(t *testing.T) {
+		f := newFakeOkx(t)
+		tr := f.trader(t)
+		if err := tr.SetLeverage("BTCUSDT", 10); err != nil {
+			t.Fatalf("SetLeverage: %v", err)
+		}
+		reqs := f.requestsTo("POST /api/v5/account/set-leverage")
+		if len(reqs) != 1 {
+			t.Fatalf("设置杠杆请求次数 = %d, want 1", len(reqs))
+		}
+		body := reqs[0].Body
+		if jsonField(t, body, "instId") != "BTC-USDT-SWAP" || jsonField(t, body, "lever") != "10" || jsonField(t, body, "mgnMode") != "cross" {
+			t.Errorf("请求 = %s, want BTC-USDT-SWAP 10x cross", body)
+		}
+		if posSide := jsonField(t, body, "posSide"); posSide != nil && posSide != "" {
+			t.Errorf("全仓不应指定posSide: %v", posSide)
+		}
+
+		if err := tr.SetLeverage("BTCUSDT", 10); err != nil {
+			t.Fatalf("SetLeverage: %v", err)
+		}
+		if n := f.calls("POST /api/v5/account/set-leverage"); n != 1 {
+			t.Errorf("已确认的杠杆重复设置，请求次数 = %d, want 1", n)
+		}
+		if err := tr.SetLeverage("BTCUSDT", 20); err != nil {
+			t.Fatalf("SetLeverage: %v", err)
+		}
+		if n := f.calls("POST /api/v5/account/set-leverage"); n != 2 {
+			t.Errorf("杠杆变化后请求次数 = %d, want 2", n)
+		}
+	})
+
+	t.Run("isolated hedge", func(t *testing.T) {
+		f := newFakeOkx(t)
+		tr := f.trader(t)
+		if err := tr.SetMarginMode("BTCUSDT", false); err != nil {
+			t.Fatalf("SetMarginMode: %v", err)
+		}
+		if err := tr.SetLeverage("BTCUSDT", 5); err != nil {
+			t.Fatalf("SetLeverage: %v", err)
+		}
+		reqs := f.requestsTo("POST /api/v5/account/set-leverage")
+		if len(reqs) != 2 {
+			t.Fatalf("设置杠杆请求次数 = %d, want 2（多空各一）", len(reqs))
+		}
+		for i, want := range []string{"long", "short"} {
+			body := reqs[i].Body
+			if jsonField(t, body, "posSide") != want || jsonField(t, body, "mgnMode") != "isolated" || jsonField(t, body, "lever") != "5" {
+				t.Errorf("第%d个请求 = %s, want %s isolated 5x", i+1, body, want)
+			}
+		}
+	})
+
+	t.Run("position already at leverage", func(t *testing.T) {
+		f := newFakeOkx(t)
+		f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10))
+		if err := f.trader(t).SetLeverage("BTCUSDT", 10); err != nil {
+			t.Fatalf("SetLeverage: %v", err)
+		}
+		if n := f.calls("POST /api/v5/account/set-leverage"); n != 0 {
+			t.Errorf("持仓杠杆已是目标杠杆，仍请求 %d 次", n)
+		}
+	})
+}
+
+// TestOkxSetLeverageErrors 失败时返回带交易对与杠杆的错误，并清除已确认状态以便重试
+func TestOkxSetLeverageErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		reason   string
+		wantKind error
+	}{
+		{"cooldown", okxFail(59000, "Setting failed. Cancel any open orders, close positions, and stop trading bots first."), "Cancel any open orders", ErrLeverageCooldown},
+		{"invalid lever", okxFail(51000, "Parameter lever error"), "Parameter lever error", nil},
+		{"empty data", okxOK(), "SetLeverage", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			tr := f.trader(t)
+			if err := tr.SetLeverage("BTCUSDT", 10); err != nil {
+				t.Fatalf("首次 SetLeverage: %v", err)
+			}
+
+			payload := tc.payload
+			f.handle("POST /api/v5/account/set-leverage", func(fakeOkxRequest) string { return payload })
+			err := tr.SetLeverage("BTCUSDT", 20)
+			if err == nil {
+				t.Fatal("SetLeverage 应返回错误")
+			}
+			for _, want := range []string{"BTCUSDT", "20x", tc.reason} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("错误信息 %q 不包含 %q", err, want)
+				}
+			}
+			if tc.wantKind != nil && !errors.Is(err, tc.wantKind) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tc.wantKind)
+			}
+
+			// 失败后不再认为10x已确认：恢复后设置10x会重新请求
+			f.handle("POST /api/v5/account/set-leverage", func(req fakeOkxRequest) string { return okxOK(req.Body) })
+			before := f.calls("POST /api/v5/account/set-leverage")
+			if err := tr.SetLeverage("BTCUSDT", 10); err != nil {
+				t.Fatalf("恢复后 SetLeverage: %v", err)
+			}
+			if n := f.calls("POST /api/v5/account/set-leverage") - before; n != 1 {
+				t.Errorf("失败后重新设置请求次数 = %d, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// PlaceLimitEntry 下限价开仓单，返回订单ID
+// side为long/short；ttl>0时订单到期未完全成交会撤销剩余部分（需先调用EnableOrderExpiry）
+// OKX的expTime只控制请求的有效期，不是订单有效期，因此到期撤单由本地调度器完成
+func (t *OkxTrader) PlaceLimitEntry(symbol string, side PositionSide, quantity, price float64, leverage int, ttl time.Duration) (string, error) {
+	return t.placeLimitEntry(symbol, side, quantity, price, leverage, TimeInForceGTC, ttl)
+}
+
+// OpenLongLimit 实现LimitOrderPlacer：下限价开多单，价格按tickSz取整，返回订单ID
+func (t *OkxTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error) {
+	return t.placeLimitEntry(symbol, PositionLong, quantity, price, leverage, tif, 0)
+}
+
+// OpenShortLimit 实现LimitOrderPlacer：下限价开空单，价格按tickSz取整，返回订单ID
+func (t *OkxTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error) {
+	return t.placeLimitEntry(symbol, PositionShort, quantity, price, leverage, tif, 0)
+}
+
+// okxLimitOrderType 有效方式对应的OKX订单类型
+func okxLimitOrderType(tif TimeInForce) (okx.OrderType, error) {
+	switch tif {
+	case TimeInForceGTC, "":
+		return okx.OrderLimit, nil
+	case TimeInForceIOC:
+		return okx.OrderIOC, nil
+	case TimeInForceFOK:
+		return okx.OrderFOK, nil
+	case TimeInForcePostOnly:
+		return okx.OrderPostOnly, nil
+	}
+	return "", fmt.Errorf("无效的订单有效方式: %q（可选 GTC/IOC/FOK/POST_ONLY）", tif)
+}
+
+// placeLimitEntry 下限价开仓单（ttl只对GTC/POST_ONLY有意义，IOC/FOK下单后立即结束）
+func (t *OkxTrader) placeLimitEntry(symbol string, side PositionSide, quantity, price float64, leverage int, tif TimeInForce, ttl time.Duration) (string, error) {
+	if !side.Valid() {
+		return "", fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	if price <= 0 {
+		return "", fmt.Errorf("限价必须大于0 (当前 %v)", price)
+	}
+	ordType, err := okxLimitOrderType(tif)
+	if err != nil {
+		return "", err
+	}
+	if ttl > 0 && t.orderExpiry == nil {
+		return "", fmt.Errorf("订单有效期需要先启用到期调度器（EnableOrderExpiry）")
+	}
+	if err := t.ValidateOrderSize(symbol, quantity, price); err != nil {
+		return "", err
+	}
+	if err := t.checkPositionTier(symbol, side, quantity, leverage); err != nil {
+		return "", err
+	}
+	if err := t.checkSymbolMarginMode(context.Background(), symbol); err != nil {
+		return "", err
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return "", err
+	}
+
+	instID, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	if err := t.checkOrderNotional(symbol, contracts); err != nil {
+		return "", err
+	}
+	px, err := t.roundPrice(symbol, price, limitRounding(side.OpenSide()))
+	if err != nil {
+		return "", err
+	}
+
+	orderSide, posSide := t.okxSides(context.Background(), side, false)
+	order, err := t.submitOrder(context.Background(), tradeReq.PlaceOrder{
+		InstID:  instID,
+		TdMode:  t.tradeMode(instID),
+		Side:    orderSide,
+		PosSide: posSide,
+		OrdType: ordType,
+		Sz:      contracts,
+		Px:      px,
+	})
+	if err != nil {
+		return "", fmt.Errorf("下限价开仓单失败: %w", err)
+	}
+	t.logger.Info("限价开仓单已提交", "symbol", symbol, "side", side, "size", quantity, "price", px, "ordType", ordType, "orderId", order.OrdID)
+
+	if ttl > 0 {
+		deadline := t.clock.Now().Add(ttl)
+		if err := t.orderExpiry.Track(symbol, order.OrdID, quantity, deadline); err != nil {
+			// 订单已提交，到期文件写入失败时仍在内存中跟踪
+			t.logger.Warn("保存订单到期时间失败（重启后不会自动撤单）", "symbol", symbol, "orderId", order.OrdID, "err", err)
+		}
+		t.logger.Info("限价单到期时间", "symbol", symbol, "orderId", order.OrdID, "deadline", deadline.Format("2006-01-02 15:04:05"))
+	}
+	return order.OrdID, nil
+}
+
+// CancelOrder 实现OrderCanceller：撤销单个普通委托；订单已结束时返回 ErrAlreadyGone，
+// 并可用 ErrOrderFilled / ErrOrderCancelled / ErrOrderNotFound 区分原因
+func (t *OkxTrader) CancelOrder(symbol, orderID string) error {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+	err = t.cancelOrderSafe(context.Background(), tradeReq.CancelOrder{InstID: instID, OrdID: orderID})
+	if err != nil && !errors.Is(err, ErrAlreadyGone) {
+		return fmt.Errorf("撤销订单 %s 失败: %w", orderID, err)
+	}
+	if t.orderExpiry != nil {
+		t.orderExpiry.Untrack(orderID)
+	}
+	return err
+}
+
+// CancelAlgoOrder 实现OrderCanceller：撤销单个策略委托（止损止盈条件单、追踪止损单），不影响同币种的其他挂单
+// 订单已结束时返回 ErrAlreadyGone，并可用 ErrOrderFilled（已触发）/ ErrOrderCancelled / ErrOrderNotFound 区分原因
+func (t *OkxTrader) CancelAlgoOrder(symbol, algoID string) error {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	resp, err := okxCall(ctx, t, OpMutation, "CancelAlgoOrder", func() (tradeResp.CancelAlgoOrder, error) {
+		return t.api().Rest.Trade.CancelAlgoOrder([]tradeReq.CancelAlgoOrder{{InstID: instID, AlgoID: algoID}})
+	})
+	if err == nil {
+		if len(resp.CancelAlgoOrders) > 0 {
+			err = okxResponseError("CancelAlgoOrder", resp.Code, resp.Msg, int64(resp.CancelAlgoOrders[0].SCode), resp.CancelAlgoOrders[0].SMsg)
+		} else if err = okxResponseError("CancelAlgoOrder", resp.Code, resp.Msg, 0, ""); err == nil {
+			err = okxEmptyResponse("CancelAlgoOrder")
+		}
+	}
+	if isOkxAlreadyGone(err) {
+		gone := &OrderGoneError{OrderID: algoID, Reason: err.Error(), State: okxGoneState(err)}
+		if gone.State == "" {
+			gone.State = t.algoGoneState(ctx, instID, algoID)
+		}
+		return gone
+	}
+	if err != nil {
+		return fmt.Errorf("撤销策略委托 %s 失败: %w", algoID, err)
+	}
+	t.logger.Info("已撤销策略委托", "symbol", symbol, "algoId", algoID)
+	return nil
+}
+
+// algoGoneState 查询策略委托历史，判断已结束的策略委托是已触发还是已撤销，查询失败时返回空
+func (t *OkxTrader) algoGoneState(ctx context.Context, instID, algoID string) GoneState {
+	ctx = context.WithoutCancel(ctx)
+	for _, ordType := range append([]okx.AlgoOrderType{okx.AlgoOrderOCO}, okxPendingAlgoTypes...) {
+		filter := tradeReq.AlgoOrderList{InstID: instID, AlgoID: algoID, OrdType: ordType}
+		algos, err := okxCall(ctx, t, OpPrivateRead, "GetAlgoOrderHistory", func() (tradeResp.AlgoOrderList, error) {
+			return t.api().Rest.Trade.GetAlgoOrderList(filter, true)
+		})
+		if err == nil {
+			err = okxResponseError("GetAlgoOrderHistory", algos.Code, algos.Msg, 0, "")
+		}
+		if err != nil {
+			t.logger.Warn("查询策略委托最终状态失败", "algoId", algoID, "err", err)
+			return ""
+		}
+		for _, algo := range algos.AlgoOrders {
+			switch {
+			case algo.AlgoID != algoID:
+			case algo.State == okx.OrderEffective:
+				return GoneFilled
+			case algo.State == okx.OrderCancel:
+				return GoneCancelled
+			default:
+				return ""
+			}
+		}
+	}
+	return GoneNotFound
+}
+
+// GetOrderStatus 查询订单状态与成交信息（同GetOrder，供LimitOrderPlacer与到期撤单使用）
+func (t *OkxTrader) GetOrderStatus(symbol, orderID string) (*OrderResult, error) {
+	return t.getOrder(context.Background(), symbol, orderID)
+}
+
+// EnableOrderExpiry 启用限价单本地到期调度（path为待到期订单的保存文件，重启后继续跟踪）
+func (t *OkxTrader) EnableOrderExpiry(path string, onExpired func(EntryExpiredEvent)) error {
+	scheduler, err := NewOrderExpiryScheduler("OKX", t, path, t.clock)
+	if err != nil {
+		return err
+	}
+	scheduler.OnExpired(onExpired)
+	scheduler.Start(0)
+	t.orderExpiry = scheduler
+	return nil
+}
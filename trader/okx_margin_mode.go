@@ -0,0 +1,103 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+)
+
+// okxSimpleAccountLevel 简单交易模式（只能交易现货，不支持合约保证金交易）
+const okxSimpleAccountLevel = "1"
+
+// okxTradeMode 保证金模式对应的tdMode（空值按全仓）
+func okxTradeMode(mode okx.MarginMode) okx.TradeMode {
+	if mode == okx.MarginIsolatedMode {
+		return okx.TradeIsolatedMode
+	}
+	return okx.TradeCrossMode
+}
+
+// okxMarginModeName 保证金模式的中文名称（用于日志与错误信息）
+func okxMarginModeName(mode okx.MarginMode) string {
+	if mode == okx.MarginIsolatedMode {
+		return "逐仓"
+	}
+	return "全仓"
+}
+
+// checkAccountMarginSupport 校验账户模式支持合约的全仓/逐仓保证金交易（查询成功后缓存账户模式）
+func (t *OkxTrader) checkAccountMarginSupport(ctx context.Context) error {
+	t.marginMu.RLock()
+	level := t.acctLevel
+	t.marginMu.RUnlock()
+
+	if level == "" {
+		if err := t.loadAccountConfig(ctx); err != nil {
+			return fmt.Errorf("查询账户模式失败: %w", err)
+		}
+		t.marginMu.RLock()
+		level = t.acctLevel
+		t.marginMu.RUnlock()
+	}
+
+	if level == okxSimpleAccountLevel {
+		return fmt.Errorf("OKX账户为简单交易模式，不支持合约保证金交易，请在OKX切换为单币种或跨币种保证金模式")
+	}
+	return nil
+}
+
+// loadAccountConfig 查询账户配置并缓存账户模式与持仓模式
+func (t *OkxTrader) loadAccountConfig(ctx context.Context) error {
+	resp, err := okxCall(ctx, t, OpPrivateRead, "GetConfig", func() (accountResp.GetConfig, error) {
+		return t.api().Rest.Account.GetConfig()
+	})
+	if err == nil {
+		err = okxCheck("GetConfig", resp.Basic, len(resp.Configs))
+	}
+	if err != nil {
+		return err
+	}
+	t.marginMu.Lock()
+	t.acctLevel = resp.Configs[0].AcctLv
+	t.posMode = resp.Configs[0].PosMode
+	t.marginMu.Unlock()
+	return nil
+}
+
+// checkMarginConflict 该币种已有其他保证金模式的持仓时返回 MarginModeConflictError
+func (t *OkxTrader) checkMarginConflict(ctx context.Context, symbol, instID string, mode okx.MarginMode) error {
+	positions, err := t.Positions(ctx)
+	if err != nil {
+		return fmt.Errorf("检查 %s 保证金模式失败: %w", symbol, err)
+	}
+	for _, pos := range positions {
+		if pos.InstID == instID && pos.MarginMode != "" && okx.MarginMode(pos.MarginMode) != mode {
+			return &MarginModeConflictError{
+				Symbol:   symbol,
+				Side:     pos.Side,
+				Existing: pos.MarginMode,
+				Wanted:   string(mode),
+			}
+		}
+	}
+	return nil
+}
+
+// checkSymbolMarginMode 开仓前校验该币种的持仓与当前设置的保证金模式一致
+func (t *OkxTrader) checkSymbolMarginMode(ctx context.Context, symbol string) error {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+	return t.checkMarginConflict(ctx, symbol, instID, t.marginMode(instID))
+}
+
+// positionTradeMode 平仓与保护单使用的tdMode：有持仓时按持仓自身的保证金模式，否则按当前设置
+func (t *OkxTrader) positionTradeMode(ctx context.Context, symbol, instID string, side PositionSide) okx.TradeMode {
+	if pos, err := t.GetPositionContext(ctx, symbol, side); err == nil && pos.MarginMode != "" {
+		return okxTradeMode(okx.MarginMode(pos.MarginMode))
+	}
+	return t.tradeMode(instID)
+}
@@ -0,0 +1,120 @@
+package trader
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// okxMetrics OkxTrader记录的运行指标（WithMetrics启用，为nil时所有记录为空操作）
+type okxMetrics struct {
+	ordersPlaced  *prometheus.CounterVec
+	ordersFailed  *prometheus.CounterVec
+	cancels       *prometheus.CounterVec
+	cacheHits     *prometheus.CounterVec
+	cacheMisses   *prometheus.CounterVec
+	restLatency   *prometheus.HistogramVec
+	restErrors    *prometheus.CounterVec
+	openPositions prometheus.Gauge
+	unrealizedPnL prometheus.Gauge
+}
+
+func newOkxMetrics(reg prometheus.Registerer) *okxMetrics {
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels))
+	}
+	gauge := func(name, help string) prometheus.Gauge {
+		return registerCollector(reg, prometheus.NewGauge(prometheus.GaugeOpts{Name: name, Help: help}))
+	}
+	return &okxMetrics{
+		ordersPlaced: counter("nofx_okx_orders_placed_total", "Orders accepted by OKX", "symbol", "side"),
+		ordersFailed: counter("nofx_okx_orders_failed_total", "Orders rejected or failed (including unknown outcome)", "symbol", "side"),
+		cancels:      counter("nofx_okx_order_cancels_total", "Order cancellations by type (order/algo) and result (cancelled/gone/failed)", "symbol", "type", "result"),
+		cacheHits:    counter("nofx_okx_cache_hits_total", "Reads served from cache", "cache"),
+		cacheMisses:  counter("nofx_okx_cache_misses_total", "Reads that queried the API", "cache"),
+		restLatency: registerCollector(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nofx_okx_rest_request_duration_seconds",
+			Help:    "OKX REST request latency (excluding client rate-limit wait)",
+			Buckets: DefaultLatencyBuckets,
+		}, []string{"endpoint"})),
+		restErrors:    counter("nofx_okx_rest_errors_total", "OKX REST requests that returned an error (transport or API code)", "endpoint"),
+		openPositions: gauge("nofx_okx_open_positions", "Open positions as of the last GetPositions"),
+		unrealizedPnL: gauge("nofx_okx_unrealized_pnl_usd", "Total unrealized PnL (USD) as of the last GetPositions"),
+	}
+}
+
+// WithMetrics 把下单、撤单、缓存命中、REST耗时与持仓指标注册到reg
+// 多个交易器共用一个注册表时，用 prometheus.WrapRegistererWith(prometheus.Labels{"trader": id}, reg) 区分
+func WithMetrics(reg prometheus.Registerer) OkxOption {
+	return func(t *OkxTrader) error {
+		if reg == nil {
+			return errors.New("指标注册表不能为空")
+		}
+		t.metrics = newOkxMetrics(reg)
+		return nil
+	}
+}
+
+// order 记录一次下单结果
+func (m *okxMetrics) order(instID, side string, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.ordersFailed.WithLabelValues(okxSymbol(instID), side).Inc()
+		return
+	}
+	m.ordersPlaced.WithLabelValues(okxSymbol(instID), side).Inc()
+}
+
+// cancel 记录一次撤单结果（typ为order或algo）
+func (m *okxMetrics) cancel(instID, typ string, err error) {
+	if m == nil {
+		return
+	}
+	result := "cancelled"
+	switch {
+	case errors.Is(err, ErrAlreadyGone):
+		result = "gone"
+	case err != nil:
+		result = "failed"
+	}
+	m.cancels.WithLabelValues(okxSymbol(instID), typ, result).Inc()
+}
+
+// cache 记录一次缓存读取
+func (m *okxMetrics) cache(name string, hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.cacheHits.WithLabelValues(name).Inc()
+		return
+	}
+	m.cacheMisses.WithLabelValues(name).Inc()
+}
+
+// rest 记录一次REST请求的耗时与结果
+func (m *okxMetrics) rest(op string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.restLatency.WithLabelValues(op).Observe(d.Seconds())
+	if err != nil {
+		m.restErrors.WithLabelValues(op).Inc()
+	}
+}
+
+// positions 按最新的持仓更新持仓数与未实现盈亏
+func (m *okxMetrics) positions(list []*Position) {
+	if m == nil {
+		return
+	}
+	upnl := 0.0
+	for _, p := range list {
+		upnl = sumFloat64(upnl, p.UnrealizedPnL)
+	}
+	m.openPositions.Set(float64(len(list)))
+	m.unrealizedPnL.Set(upnl)
+}
@@ -0,0 +1,124 @@
+package trader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+
+	"nofx/testutil"
+)
+
+// scrapeMetrics 通过promhttp抓取注册表，按指标名返回指标族
+func scrapeMetrics(t *testing.T, reg prometheus.Gatherer) map[string]*dto.MetricFamily {
+	t.Helper()
+	srv := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("抓取 /metrics 状态码 %d", resp.StatusCode)
+	}
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatalf("解析指标: %v", err)
+	}
+	return families
+}
+
+// findMetric 指标族中标签完全匹配的序列
+func findMetric(t *testing.T, families map[string]*dto.MetricFamily, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	family, ok := families[name]
+	if !ok {
+		t.Fatalf("缺少指标 %s", name)
+	}
+	for _, m := range family.GetMetric() {
+		got := make(map[string]string)
+		for _, l := range m.GetLabel() {
+			got[l.GetName()] = l.GetValue()
+		}
+		if len(got) != len(labels) {
+			continue
+		}
+		match := true
+		for k, v := range labels {
+			if got[k] != v {
+				match = false
+			}
+		}
+		if match {
+			return m
+		}
+	}
+	t.Fatalf("指标 %s 没有标签为 %v 的序列", name, labels)
+	return nil
+}
+
+// TestOkxMetricsScrape 假交易所上的调用记录到注入的注册表，promhttp输出的计数器、仪表与直方图取值正确
+func TestOkxMetricsScrape(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/balance", okxTestBalance("1000", "900", "0"))
+	f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10))
+
+	reg := prometheus.NewRegistry()
+	tr := f.trader(t, WithCacheDuration(time.Hour),
+		WithMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"trader": "t1"}, reg)))
+	tr.SetClock(testutil.NewFakeClock(time.Unix(1700000000, 0)))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := tr.Balance(ctx); err != nil {
+			t.Fatalf("Balance: %v", err)
+		}
+	}
+	if _, err := tr.Positions(ctx); err != nil {
+		t.Fatalf("Positions: %v", err)
+	}
+	f.handle("GET /api/v5/account/balance", func(fakeOkxRequest) string { return "<html>502 Bad Gateway</html>" })
+	if _, err := tr.RefreshBalance(); err == nil {
+		t.Fatal("RefreshBalance 应返回错误")
+	}
+
+	families := scrapeMetrics(t, reg)
+	for _, tc := range []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"nofx_okx_cache_misses_total", map[string]string{"trader": "t1", "cache": "balance"}, 2},
+		{"nofx_okx_cache_hits_total", map[string]string{"trader": "t1", "cache": "balance"}, 1},
+		{"nofx_okx_cache_misses_total", map[string]string{"trader": "t1", "cache": "positions"}, 1},
+		{"nofx_okx_rest_errors_total", map[string]string{"trader": "t1", "endpoint": "GetBalance"}, 1},
+	} {
+		if got := findMetric(t, families, tc.name, tc.labels).GetCounter().GetValue(); got != tc.want {
+			t.Errorf("%s%v = %v, want %v", tc.name, tc.labels, got, tc.want)
+		}
+	}
+	if got := findMetric(t, families, "nofx_okx_open_positions", map[string]string{"trader": "t1"}).GetGauge().GetValue(); got != 1 {
+		t.Errorf("nofx_okx_open_positions = %v, want 1", got)
+	}
+
+	// 假时钟不前进，耗时都落在第一个桶（文本格式的分桶含+Inf）
+	h := findMetric(t, families, "nofx_okx_rest_request_duration_seconds", map[string]string{"trader": "t1", "endpoint": "GetBalance"}).GetHistogram()
+	if h.GetSampleCount() != 2 || h.GetSampleSum() != 0 {
+		t.Errorf("GetBalance 耗时直方图 count=%d sum=%v, want 2 与 0", h.GetSampleCount(), h.GetSampleSum())
+	}
+	if buckets := h.GetBucket(); len(buckets) != len(DefaultLatencyBuckets)+1 || buckets[0].GetUpperBound() != DefaultLatencyBuckets[0] || buckets[0].GetCumulativeCount() != 2 {
+		t.Errorf("GetBalance 耗时分桶 = %v", buckets)
+	}
+
+	// 交易员重启后以相同标签重新创建交易器：沿用已注册的指标，不panic
+	f.trader(t, WithMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"trader": "t1"}, reg)))
+}
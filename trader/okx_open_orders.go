@@ -0,0 +1,178 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// okxListedAlgoTypes 查询策略委托时包含的类型（止损止盈与追踪止损）
+var okxListedAlgoTypes = []okx.AlgoOrderType{okx.AlgoOrderConditional, okx.AlgoOrderOCO, okx.AlgoOrderTrailing}
+
+// OkxOrderFilter 挂单查询条件
+// Symbol为空时查询InstType下的全部币种；InstType为空时使用交易器的默认品种类型
+// InstType为FUTURES且Symbol为BTCUSDT这类格式时，查询该标的所有到期日的交割合约
+type OkxOrderFilter struct {
+	Symbol   string
+	InstType okx.InstrumentType
+}
+
+// GetOpenOrders 实现OpenOrderLister：获取未成交的普通委托（symbol为空表示全部币种），没有挂单时返回空切片
+// OKX的止损止盈是策略委托，不在此列表中，使用GetPendingAlgoOrders查询
+func (t *OkxTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	return t.ListOpenOrders(context.Background(), OkxOrderFilter{Symbol: symbol})
+}
+
+// GetPendingAlgoOrders 实现PendingAlgoOrderLister：获取未触发的止损止盈与追踪止损单，没有挂单时返回空切片
+func (t *OkxTrader) GetPendingAlgoOrders(symbol string) ([]OpenOrder, error) {
+	return t.ListPendingAlgoOrders(context.Background(), OkxOrderFilter{Symbol: symbol})
+}
+
+// ListOpenOrders 按条件获取未成交的普通委托（单次最多返回100条）
+func (t *OkxTrader) ListOpenOrders(ctx context.Context, filter OkxOrderFilter) ([]OpenOrder, error) {
+	instID, uly, instType, err := t.resolveOrderFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := okxCall(ctx, t, OpPrivateRead, "GetOrderList", func() (tradeResp.OrderList, error) {
+		return t.api().Rest.Trade.GetOrderList(tradeReq.OrderList{InstID: instID, Uly: uly, InstType: instType})
+	})
+	if err == nil {
+		err = okxResponseError("GetOrderList", resp.Code, resp.Msg, 0, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(resp.Orders))
+	for _, order := range resp.Orders {
+		result = append(result, t.okxOpenOrder(order))
+	}
+	return result, nil
+}
+
+// ListPendingAlgoOrders 按条件获取未触发的策略委托（每种类型单次最多返回100条）
+// OCO单的止盈与止损分别作为一条记录返回，两条记录的OrderID相同
+func (t *OkxTrader) ListPendingAlgoOrders(ctx context.Context, filter OkxOrderFilter) ([]OpenOrder, error) {
+	instID, uly, instType, err := t.resolveOrderFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]OpenOrder, 0)
+	// 查询策略委托时每次只能指定一种类型
+	for _, ordType := range okxListedAlgoTypes {
+		query := tradeReq.AlgoOrderList{InstID: instID, Uly: uly, InstType: instType, OrdType: ordType}
+		resp, err := okxCall(ctx, t, OpPrivateRead, "GetAlgoOrderList", func() (tradeResp.AlgoOrderList, error) {
+			return t.api().Rest.Trade.GetAlgoOrderList(query, false)
+		})
+		if err == nil {
+			err = okxResponseError("GetAlgoOrderList", resp.Code, resp.Msg, 0, "")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("获取%s策略委托失败: %w", ordType, err)
+		}
+		for _, algo := range resp.AlgoOrders {
+			result = append(result, t.okxAlgoOpenOrders(algo)...)
+		}
+	}
+	return result, nil
+}
+
+// resolveOrderFilter 将查询条件转换为OKX的instId/uly/instType（只设置其中一个）
+func (t *OkxTrader) resolveOrderFilter(filter OkxOrderFilter) (instID, uly string, instType okx.InstrumentType, err error) {
+	instType = filter.InstType
+	if instType == "" {
+		instType = t.instType
+	}
+	if filter.Symbol == "" {
+		return "", "", instType, nil
+	}
+	if instType == okx.FuturesInstrument && !strings.Contains(filter.Symbol, "-") {
+		swapID, _, err := resolveOkxInstID(filter.Symbol, okx.SwapInstrument)
+		if err != nil {
+			return "", "", "", err
+		}
+		return "", strings.TrimSuffix(swapID, "-SWAP"), "", nil
+	}
+	instID, _, err = resolveOkxInstID(filter.Symbol, instType)
+	return instID, "", "", err
+}
+
+// okxOpenOrder 普通委托转换为OpenOrder（数量由张转换为币）
+func (t *OkxTrader) okxOpenOrder(order *tradeModel.Order) OpenOrder {
+	return OpenOrder{
+		OrderID:      order.OrdID,
+		Symbol:       okxSymbol(order.InstID),
+		Side:         strings.ToUpper(string(order.Side)),
+		PositionSide: okxOpenOrderPositionSide(order.PosSide),
+		Type:         strings.ToUpper(string(order.OrdType)),
+		Price:        float64(order.Px),
+		Quantity:     t.contractsToQty(order.InstID, float64(order.Sz), float64(order.Px)),
+		ReduceOnly:   order.ReduceOnly == "true",
+		CreateTime:   time.Time(order.CTime),
+	}
+}
+
+// okxAlgoOpenOrders 策略委托转换为OpenOrder，同时设置止盈止损的委托拆分为两条
+func (t *OkxTrader) okxAlgoOpenOrders(algo *tradeModel.AlgoOrder) []OpenOrder {
+	base := OpenOrder{
+		OrderID:      algo.AlgoID,
+		Symbol:       okxSymbol(algo.InstID),
+		Side:         strings.ToUpper(string(algo.Side)),
+		PositionSide: okxOpenOrderPositionSide(algo.PosSide),
+		ReduceOnly:   algo.ReduceOnly == "true",
+		// 按比例全部平仓的委托没有数量
+		ClosePosition: algo.Sz == 0,
+		CreateTime:    time.Time(algo.CTime),
+	}
+
+	if algo.OrdType == okx.AlgoOrderTrailing {
+		base.Type = "TRAILING_STOP_MARKET"
+		base.ActivatePrice = float64(algo.ActivePx)
+		base.CallbackRate = float64(algo.CallbackRatio) * 100
+		base.Quantity = t.contractsToQty(algo.InstID, float64(algo.Sz), float64(algo.ActivePx))
+		return []OpenOrder{base}
+	}
+
+	var orders []OpenOrder
+	leg := func(stopType, limitType string, trigger, ordPx float64) {
+		order := base
+		order.Type, order.StopPrice = stopType, trigger
+		if ordPx > 0 {
+			order.Type, order.Price = limitType, ordPx
+		}
+		order.Quantity = t.contractsToQty(algo.InstID, float64(algo.Sz), trigger)
+		orders = append(orders, order)
+	}
+	if algo.SlTriggerPx > 0 {
+		leg("STOP_MARKET", "STOP", float64(algo.SlTriggerPx), float64(algo.SlOrdPx))
+	}
+	if algo.TpTriggerPx > 0 {
+		leg("TAKE_PROFIT_MARKET", "TAKE_PROFIT", float64(algo.TpTriggerPx), float64(algo.TpOrdPx))
+	}
+	return orders
+}
+
+// contractsToQty 张数转换为币的数量，交易规则获取失败时返回张数
+func (t *OkxTrader) contractsToQty(instID string, contracts, price float64) float64 {
+	inst, err := t.getInstrument(instID)
+	if err != nil {
+		return contracts
+	}
+	return decimalFloat(contractsToCoin(inst, contracts, price))
+}
+
+// okxOpenOrderPositionSide OKX持仓方向转换为OpenOrder格式（单向持仓为BOTH）
+func okxOpenOrderPositionSide(posSide okx.PositionSide) string {
+	switch posSide {
+	case okx.PositionLongSide, okx.PositionShortSide:
+		return strings.ToUpper(string(posSide))
+	}
+	return "BOTH"
+}
@@ -0,0 +1,196 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Benjmmi/okx"
+)
+
+// OkxOption NewOkxTrader的可选配置，在创建客户端与第一次调用API之前生效
+type OkxOption func(*OkxTrader) error
+
+// okxEndpoint 客户端连接的服务器与HTTP配置（客户端重建时沿用）
+type okxEndpoint struct {
+	demo        bool          // 模拟盘：使用模拟盘WebSocket地址，REST请求带 x-simulated-trading 头
+	restURL     okx.BaseURL   // 自定义REST地址，为空时使用服务器默认地址
+	proxy       *url.URL      // REST请求使用的HTTP代理
+	httpTimeout time.Duration // 单次HTTP请求的超时（0表示不限制）
+	recvWindow  time.Duration // 私有请求的有效期（expTime头，0表示不设置）
+}
+
+// destination 对应的SDK服务器
+func (e okxEndpoint) destination() okx.Destination {
+	if e.demo {
+		return okx.DemoServer
+	}
+	return okx.NormalServer
+}
+
+// urls REST与WebSocket（私有/公共）地址
+func (e okxEndpoint) urls() (rest, wsPrivate, wsPublic okx.BaseURL) {
+	rest, wsPrivate, wsPublic = okx.RestURL, okx.PrivateWsURL, okx.PublicWsURL
+	if e.demo {
+		rest, wsPrivate, wsPublic = okx.DemoRestURL, okx.DemoPrivateWsURL, okx.DemoPublicWsURL
+	}
+	if e.restURL != "" {
+		rest = e.restURL
+	}
+	return rest, wsPrivate, wsPublic
+}
+
+// httpClient REST请求使用的HTTP客户端，全部为默认配置时返回nil（使用SDK默认客户端）
+// now为计算expTime使用的当前时间（交易器的时钟，SetClock之后同样生效）
+func (e okxEndpoint) httpClient(now func() time.Time) *http.Client {
+	if e.proxy == nil && e.httpTimeout == 0 && e.recvWindow == 0 {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if e.proxy != nil {
+		transport.Proxy = http.ProxyURL(e.proxy)
+	}
+	var rt http.RoundTripper = transport
+	if e.recvWindow > 0 {
+		rt = &okxExpTimeTransport{next: transport, window: e.recvWindow, now: now}
+	}
+	return &http.Client{Transport: rt, Timeout: e.httpTimeout}
+}
+
+// okxExpTimeTransport 为私有请求加上expTime头，交易所在该时间之后收到的请求直接拒绝（不会执行）
+type okxExpTimeTransport struct {
+	next   http.RoundTripper
+	window time.Duration
+	now    func() time.Time
+}
+
+func (rt *okxExpTimeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("OK-ACCESS-KEY") == "" {
+		return rt.next.RoundTrip(req)
+	}
+	// RoundTripper不能修改原请求
+	req = req.Clone(req.Context())
+	req.Header.Set("expTime", strconv.FormatInt(rt.now().Add(rt.window).UnixMilli(), 10))
+	return rt.next.RoundTrip(req)
+}
+
+// WithDemoServer 连接OKX模拟盘（需要使用模拟盘创建的API Key）
+func WithDemoServer() OkxOption {
+	return func(t *OkxTrader) error {
+		t.endpoint.demo = true
+		return nil
+	}
+}
+
+// WithBaseURL 使用自定义的REST地址（如 https://aws.okx.com），不影响WebSocket地址
+func WithBaseURL(baseURL string) OkxOption {
+	return func(t *OkxTrader) error {
+		u, err := url.Parse(strings.TrimSpace(baseURL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的 OKX REST 地址: %q", baseURL)
+		}
+		t.endpoint.restURL = okx.BaseURL(strings.TrimSuffix(u.String(), "/"))
+		return nil
+	}
+}
+
+// WithProxy REST请求通过HTTP代理发送（如 http://127.0.0.1:7890）
+// SDK的WebSocket连接不支持单独设置代理，使用 HTTPS_PROXY 环境变量
+func WithProxy(proxyURL string) OkxOption {
+	return func(t *OkxTrader) error {
+		u, err := url.Parse(strings.TrimSpace(proxyURL))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("无效的代理地址: %q", proxyURL)
+		}
+		t.endpoint.proxy = u
+		return nil
+	}
+}
+
+// WithCacheDuration 设置余额与持仓的缓存有效期（默认15秒，0表示不缓存）
+func WithCacheDuration(d time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if d < 0 {
+			return fmt.Errorf("缓存有效期不能为负数: %v", d)
+		}
+		t.cacheDuration = d
+		return nil
+	}
+}
+
+// WithInstrumentTTL 设置交易规则缓存的有效期（默认1小时，0表示不过期，只能通过RefreshInstruments刷新）
+func WithInstrumentTTL(d time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if d < 0 {
+			return fmt.Errorf("交易规则缓存有效期不能为负数: %v", d)
+		}
+		t.instrumentTTL = d
+		return nil
+	}
+}
+
+// WithLogger 设置交易器的文本日志输出（默认标准库的全局logger），级别由WithLogLevel控制
+func WithLogger(l *log.Logger) OkxOption {
+	return func(t *OkxTrader) error {
+		if l == nil {
+			return errors.New("logger不能为空")
+		}
+		t.logger = newTextLogger(l, t.logLevel)
+		return nil
+	}
+}
+
+// WithSlogLogger 使用结构化日志（字段包括symbol、side、size、orderId、latency等），
+// 级别过滤由l的Handler决定，WithLogLevel不再生效
+func WithSlogLogger(l *slog.Logger) OkxOption {
+	return func(t *OkxTrader) error {
+		if l == nil {
+			return errors.New("logger不能为空")
+		}
+		t.logger = l
+		return nil
+	}
+}
+
+// WithLogLevel 设置默认文本日志的级别（默认Info；Debug输出缓存命中、余额查询等日志）
+func WithLogLevel(level slog.Level) OkxOption {
+	return func(t *OkxTrader) error {
+		t.logLevel.Set(level)
+		return nil
+	}
+}
+
+// WithHTTPTimeout 设置单次HTTP请求的超时（默认不限制）
+// 与SetTimeouts不同，超时后请求本身被中止，而不是在后台继续执行
+func WithHTTPTimeout(d time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if d < 0 {
+			return fmt.Errorf("HTTP超时不能为负数: %v", d)
+		}
+		t.endpoint.httpTimeout = d
+		return nil
+	}
+}
+
+// WithRecvWindow 设置私有请求的有效期：请求带上expTime头，超过发送时间+d才到达交易所的请求被拒绝
+// 网络拥堵时避免过期的下单请求在行情变化后才被执行（默认不设置，0表示不设置）
+func WithRecvWindow(d time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if d < 0 {
+			return fmt.Errorf("请求有效期不能为负数: %v", d)
+		}
+		t.endpoint.recvWindow = d
+		return nil
+	}
+}
+
+// IsDemo 实现DemoTrader：是否连接的是模拟盘
+func (t *OkxTrader) IsDemo() bool {
+	return t.endpoint.demo
+}
@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"nofx/testutil"
+)
+
+// TestOkxRecvWindowUsesTraderClock expTime按交易器的时钟计算（SetClock在创建客户端之后同样生效）
+func TestOkxRecvWindowUsesTraderClock(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/balance", okxTestBalance("1000", "1000", "0"))
+	tr := f.trader(t, WithRecvWindow(5*time.Second))
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tr.SetClock(testutil.NewFakeClock(start))
+
+	if _, err := tr.RefreshBalance(); err != nil {
+		t.Fatalf("RefreshBalance: %v", err)
+	}
+	reqs := f.requestsTo("GET /api/v5/account/balance")
+	if len(reqs) != 1 {
+		t.Fatalf("余额请求 = %d, want 1", len(reqs))
+	}
+	want := strconv.FormatInt(start.Add(5*time.Second).UnixMilli(), 10)
+	if got := reqs[0].Header.Get("expTime"); got != want {
+		t.Errorf("expTime = %q, want %q", got, want)
+	}
+}
+
+// TestNewOkxTraderInvalidOptions 无效的选项返回错误（不退出进程），不创建客户端
+func TestNewOkxTraderInvalidOptions(t *testing.T) {
+	for name, opt := range map[string]OkxOption{
+		"base url":       WithBaseURL("ftp://okx.com"),
+		"proxy":          WithProxy("127.0.0.1"),
+		"cache":          WithCacheDuration(-time.Second),
+		"instrument ttl": WithInstrumentTTL(-time.Second),
+		"logger":         WithSlogLogger((*slog.Logger)(nil)),
+		"http timeout":   WithHTTPTimeout(-time.Second),
+		"recv window":    WithRecvWindow(-time.Second),
+		"rate limits":    WithRateLimits(OkxRateLimits{Trade: OkxRateLimit{Requests: 10}}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, newTrader := range []func(string, string, string, ...OkxOption) (*OkxTrader, error){NewOkxTrader, NewOkxTraderValidated} {
+				tr, err := newTrader("key", "secret", "pass", opt)
+				if err == nil || tr != nil {
+					t.Errorf("创建交易器 = %v, %v, want 错误", tr, err)
+				}
+			}
+		})
+	}
+}
+
+// TestOkxValidateCredentials 账户配置接口返回认证错误码时 NewOkxTraderValidated 返回 ErrInvalidCredentials，其他错误不归为凭证无效
+func TestOkxValidateCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		resp    string
+		wantErr error
+	}{
+		{"valid", okxOK(`{"uid":"1","acctLv":"2","posMode":"long_short_mode"}`), nil},
+		{"50111", okxFail(50111, "Invalid OK-ACCESS-KEY"), ErrInvalidCredentials},
+		{"50113", okxFail(50113, "Invalid Sign"), ErrInvalidCredentials},
+		{"50001", okxFail(50001, "Service temporarily unavailable"), nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			f.handle("GET /api/v5/account/config", func(fakeOkxRequest) string { return tc.resp })
+			tr, err := NewOkxTraderValidated("key", "secret", "pass", WithBaseURL(f.srv.URL), WithRateLimits(OkxRateLimits{}))
+			if tr != nil {
+				t.Cleanup(tr.clientCancel)
+			}
+			if n := f.calls("GET /api/v5/account/config"); n != 1 {
+				t.Errorf("账户配置请求 = %d, want 1", n)
+			}
+			switch {
+			case tc.name == "valid":
+				if err != nil || tr == nil {
+					t.Fatalf("NewOkxTraderValidated = %v, %v, want 成功", tr, err)
+				}
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) || tr != nil {
+					t.Errorf("err = %v, want ErrInvalidCredentials", err)
+				}
+			default:
+				if err == nil || errors.Is(err, ErrInvalidCredentials) || tr != nil {
+					t.Errorf("err = %v, want 非凭证错误", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	marketReq "github.com/Benjmmi/okx/requests/rest/market"
+	marketResp "github.com/Benjmmi/okx/responses/market"
+)
+
+const (
+	okxMaxBookDepth     = 400 // /market/books 最多返回的档位数
+	okxDefaultBookDepth = 50  // EstimateMarketImpact 查询的档位数
+)
+
+// ErrInsufficientDepth 订单簿（查询的档位内）不足以成交全部数量
+var ErrInsufficientDepth = newSentinelError(ErrCodeInsufficientDepth, "err_insufficient_depth")
+
+// InsufficientDepthError 带币种与可成交数量的深度不足错误
+type InsufficientDepthError struct {
+	Symbol    string
+	Levels    int
+	Requested float64
+	Available float64
+}
+
+func (e *InsufficientDepthError) Error() string {
+	return msg("err_insufficient_depth_detail", e.Symbol, e.Levels, e.Available, e.Requested)
+}
+
+func (e *InsufficientDepthError) ErrorCode() ErrorCode {
+	return ErrCodeInsufficientDepth
+}
+
+// Is 使 errors.Is(err, ErrInsufficientDepth) 成立
+func (e *InsufficientDepthError) Is(target error) bool {
+	return target == ErrInsufficientDepth
+}
+
+// BookLevel 订单簿的一档
+type BookLevel struct {
+	Price     float64 `json:"price"`
+	Contracts float64 `json:"contracts"` // 张数（现货为币的数量）
+	Quantity  float64 `json:"quantity"`  // 币的数量
+	Notional  float64 `json:"notional"`  // 计价币种金额
+	Orders    int     `json:"orders"`    // 该档的订单数
+}
+
+// OrderBook 订单簿快照（Bids按价格从高到低，Asks按价格从低到高）
+type OrderBook struct {
+	Symbol string      `json:"symbol"`
+	InstID string      `json:"inst_id"`
+	Time   time.Time   `json:"time"`
+	Bids   []BookLevel `json:"bids"`
+	Asks   []BookLevel `json:"asks"`
+}
+
+// Mid 买一与卖一的中间价，任一侧为空时返回0
+func (b *OrderBook) Mid() float64 {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return 0
+	}
+	return decimalFloat(toDecimal(b.Bids[0].Price).Add(toDecimal(b.Asks[0].Price)).Div(toDecimal(2)))
+}
+
+// SpreadBps 买卖价差（相对中间价的基点），任一侧为空时返回0
+func (b *OrderBook) SpreadBps() float64 {
+	mid := b.Mid()
+	if mid <= 0 {
+		return 0
+	}
+	return (b.Asks[0].Price - b.Bids[0].Price) / mid * 1e4
+}
+
+// MarketImpact 市价单按当前订单簿逐档成交的估算
+type MarketImpact struct {
+	Symbol      string  `json:"symbol"`
+	Side        Side    `json:"side"`
+	Quantity    float64 `json:"quantity"`     // 请求的数量（币）
+	Filled      float64 `json:"filled"`       // 订单簿可成交的数量（深度不足时小于Quantity）
+	AvgPrice    float64 `json:"avg_price"`    // 预计成交均价
+	WorstPrice  float64 `json:"worst_price"`  // 成交到的最差一档价格
+	Mid         float64 `json:"mid"`          // 中间价
+	SlippageBps float64 `json:"slippage_bps"` // 均价相对中间价的滑点（基点，不利方向为正）
+	SpreadBps   float64 `json:"spread_bps"`
+	Levels      int     `json:"levels"` // 消耗的档位数
+}
+
+// GetOrderBook 获取订单簿快照（depth为每侧档位数，1~400），数量按交易规则由张数换算为币与计价金额
+func (t *OkxTrader) GetOrderBook(symbol string, depth int) (*OrderBook, error) {
+	return t.GetOrderBookContext(context.Background(), symbol, depth)
+}
+
+// GetOrderBookContext 同GetOrderBook，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetOrderBookContext(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	if depth <= 0 || depth > okxMaxBookDepth {
+		return nil, fmt.Errorf("订单簿档位数必须在1到%d之间: %d", okxMaxBookDepth, depth)
+	}
+	instID, instType, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := okxCall(ctx, t, OpPublicRead, "GetOrderBook", func() (marketResp.OrderBook, error) {
+		return t.api().Rest.Market.GetOrderBook(marketReq.GetOrderBook{InstID: instID, Sz: depth})
+	})
+	if err == nil {
+		err = okxCheck("GetOrderBook", resp.Basic, len(resp.OrderBooks))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 订单簿失败: %w", symbol, err)
+	}
+
+	spot := instType == okx.SpotInstrument
+	inst, err := t.getInstrument(instID)
+	if err != nil && !spot {
+		return nil, err
+	}
+	raw := resp.OrderBooks[0]
+	book := &OrderBook{
+		Symbol: okxSymbol(instID),
+		InstID: instID,
+		Time:   time.Time(raw.TS),
+		Bids:   make([]BookLevel, 0, len(raw.Bids)),
+		Asks:   make([]BookLevel, 0, len(raw.Asks)),
+	}
+	level := func(price, size float64, orders int) BookLevel {
+		qty := size
+		if !spot {
+			qty = decimalFloat(contractsToCoin(inst, size, price))
+		}
+		return BookLevel{
+			Price:     price,
+			Contracts: size,
+			Quantity:  qty,
+			Notional:  decimalFloat(toDecimal(qty).Mul(toDecimal(price))),
+			Orders:    orders,
+		}
+	}
+	for _, e := range raw.Bids {
+		book.Bids = append(book.Bids, level(e.DepthPrice, e.Size, e.OrderNumbers))
+	}
+	for _, e := range raw.Asks {
+		book.Asks = append(book.Asks, level(e.DepthPrice, e.Size, e.OrderNumbers))
+	}
+	return book, nil
+}
+
+// EstimateMarketImpact 按当前订单簿（前50档）估算市价单的成交均价与相对中间价的滑点，quantity为币的数量
+// 深度不足时返回已能成交部分的估算与 ErrInsufficientDepth
+func (t *OkxTrader) EstimateMarketImpact(symbol string, side Side, quantity float64) (*MarketImpact, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的买卖方向: %v", side)
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("数量必须大于0: %v", quantity)
+	}
+	book, err := t.GetOrderBook(symbol, okxDefaultBookDepth)
+	if err != nil {
+		return nil, err
+	}
+	return book.Impact(side, quantity)
+}
+
+// Impact 按订单簿逐档估算市价单的成交（买单吃卖盘，卖单吃买盘），深度不足时返回已能成交部分的估算与 ErrInsufficientDepth
+func (b *OrderBook) Impact(side Side, quantity float64) (*MarketImpact, error) {
+	levels := b.Asks
+	if side == SideSell {
+		levels = b.Bids
+	}
+	mid := b.Mid()
+	if len(levels) == 0 || mid <= 0 {
+		return nil, &InsufficientDepthError{Symbol: b.Symbol, Requested: quantity}
+	}
+
+	impact := &MarketImpact{Symbol: b.Symbol, Side: side, Quantity: quantity, Mid: mid, SpreadBps: b.SpreadBps()}
+	remaining, filled, cost := toDecimal(quantity), toDecimal(0), toDecimal(0)
+	for _, l := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		take := toDecimal(l.Quantity)
+		if take.GreaterThan(remaining) {
+			take = remaining
+		}
+		filled = filled.Add(take)
+		cost = cost.Add(take.Mul(toDecimal(l.Price)))
+		remaining = remaining.Sub(take)
+		impact.WorstPrice = l.Price
+		impact.Levels++
+	}
+	impact.Filled = decimalFloat(filled)
+	if filled.IsPositive() {
+		impact.AvgPrice = decimalFloat(cost.Div(filled))
+	}
+	impact.SlippageBps = (impact.AvgPrice - mid) / mid * 1e4
+	if side == SideSell {
+		impact.SlippageBps = -impact.SlippageBps
+	}
+	if remaining.IsPositive() {
+		return impact, &InsufficientDepthError{Symbol: b.Symbol, Levels: len(levels), Requested: quantity, Available: impact.Filled}
+	}
+	return impact, nil
+}
+
+var _ OrderBookProvider = (*OkxTrader)(nil)
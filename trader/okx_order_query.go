@@ -0,0 +1,77 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// okxWaitFillInterval WaitForFill查询订单状态的间隔
+const okxWaitFillInterval = 500 * time.Millisecond
+
+// GetOrder 实现OrderQuerier：查询订单状态、成交数量（币）、成交均价与手续费
+func (t *OkxTrader) GetOrder(symbol, orderID string) (*OrderResult, error) {
+	return t.getOrder(context.Background(), symbol, orderID)
+}
+
+// GetOrderContext 同GetOrder，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetOrderContext(ctx context.Context, symbol, orderID string) (*OrderResult, error) {
+	return t.getOrder(ctx, symbol, orderID)
+}
+
+func (t *OkxTrader) getOrder(ctx context.Context, symbol, orderID string) (*OrderResult, error) {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := okxCall(ctx, t, OpPrivateRead, "GetOrderDetail", func() (tradeResp.OrderList, error) {
+		return t.api().Rest.Trade.GetOrderDetail(tradeReq.OrderDetails{InstID: instID, OrdID: orderID})
+	})
+	if err == nil {
+		err = okxCheck("GetOrderDetail", resp.Basic, len(resp.Orders))
+	}
+	if isOkxOrderNotExist(err) {
+		return nil, &OrderGoneError{OrderID: orderID, Reason: err.Error(), State: GoneNotFound}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询订单 %s 失败: %w", orderID, err)
+	}
+
+	detail := resp.Orders[0]
+	result := &OrderResult{
+		OrderID:       detail.OrdID,
+		ClientOrderID: detail.ClOrdID,
+		Symbol:        symbol,
+		Side:          strings.ToUpper(string(detail.Side)),
+		PositionSide:  strings.ToUpper(string(detail.PosSide)),
+		MarginMode:    string(detail.TdMode),
+	}
+	t.applyFill(result, instID, detail)
+	return result, nil
+}
+
+// WaitForFill 实现OrderQuerier：轮询订单直到进入最终状态（已成交或已撤销），返回最终的成交信息
+// timeout内未结束时返回最后一次查询到的订单与 ErrTimeout；ctx结束时返回 ErrCanceled
+func (t *OkxTrader) WaitForFill(ctx context.Context, symbol, orderID string, timeout time.Duration) (*OrderResult, error) {
+	deadline := t.clock.Now().Add(timeout)
+	for {
+		order, err := t.getOrder(ctx, symbol, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if order.Final() {
+			return order, nil
+		}
+		remaining := deadline.Sub(t.clock.Now())
+		if remaining <= 0 {
+			return order, &TimeoutError{Op: "WaitForFill", Class: OpPrivateRead, Timeout: timeout}
+		}
+		if err := sleepContext(ctx, t.clock, min(remaining, okxWaitFillInterval)); err != nil {
+			return order, &CanceledError{Op: "WaitForFill", Class: OpPrivateRead, Err: err}
+		}
+	}
+}
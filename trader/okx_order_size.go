@@ -0,0 +1,71 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	"github.com/Benjmmi/okx/models/publicdata"
+)
+
+// ValidateOrderSize 实现OrderSizeValidator：下单前按交易规则（缓存）校验数量，不请求下单接口
+// price为0表示市价单，按需使用标记价格（正向合约且未设置单笔上限时不查询价格）
+// 交易对不可交易或张数超过交易所单笔上限（maxMktSz/maxLmtSz）时返回错误；
+// 数量按lotSz向下取整后为0或低于minSz时返回 *MinSizeError（满足 errors.Is(err, ErrBelowMinSize)），
+// 名义价值超过单笔上限时返回 *OrderTooLargeError。不考虑MinSizeBumpPolicy
+func (t *OkxTrader) ValidateOrderSize(symbol string, quantity, price float64) error {
+	entry, err := t.instrumentEntry(context.Background(), symbol)
+	if err != nil {
+		return err
+	}
+	inst := entry.inst
+	if inst.State != "" && inst.State != okx.InstrumentLive {
+		return fmt.Errorf("交易对 %s 当前状态为 %s，不可下单", inst.InstID, inst.State)
+	}
+	maxSz, kind := entry.maxMktSz, "市价"
+	if price > 0 {
+		maxSz, kind = entry.maxLmtSz, "限价"
+	}
+	if price <= 0 && (isInverseContract(inst) || MaxOrderNotional(symbol) > 0) {
+		if price, err = t.getMarkPrice(symbol); err != nil {
+			return err
+		}
+	}
+	contracts, err := t.lotContracts(symbol, inst, quantity, price)
+	if err != nil {
+		return err
+	}
+	if maxSz > 0 && contracts > maxSz {
+		return fmt.Errorf("%s 下单张数 %v 超过交易所%s单笔上限 %v 张", inst.InstID, contracts, kind, maxSz)
+	}
+	if price <= 0 {
+		return nil
+	}
+	return checkOrderNotional(symbol, decimalFloat(contractNotionalUSD(inst, price).Mul(toDecimal(contracts))))
+}
+
+// lotContracts 币的数量转换为张数并向下取整到lotSz，取整后为0或低于minSz时返回 *MinSizeError
+// price只有反向合约换算时必需，为0时错误中的名义价值按标记价格补全
+func (t *OkxTrader) lotContracts(symbol string, inst *publicdata.Instrument, quantity, price float64) (float64, error) {
+	lotSz, minSz := float64(inst.LotSz), float64(inst.MinSz)
+	floored, err := floorToLot(decimalFloat(coinToContracts(inst, quantity, price)), lotSz, minSz)
+	if err == nil && floored.IsPositive() {
+		return decimalFloat(floored), nil
+	}
+
+	minContracts := minSz
+	if minContracts <= 0 {
+		minContracts = lotSz
+	}
+	if price <= 0 {
+		// 只用于错误信息，获取失败时名义价值为0
+		price, _ = t.getMarkPrice(symbol)
+	}
+	return 0, &MinSizeError{
+		Symbol:      inst.InstID,
+		Quantity:    quantity,
+		MinQuantity: decimalFloat(contractsToCoin(inst, minContracts, price)),
+		Notional:    notionalValue(quantity, price),
+		MinNotional: decimalFloat(contractNotionalUSD(inst, price).Mul(toDecimal(minContracts))),
+	}
+}
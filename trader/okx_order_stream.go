@@ -0,0 +1,205 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
+)
+
+const (
+	// okxOrderStreamWait 通过推送等待订单完成的最长时间，超时后改为REST查询
+	okxOrderStreamWait = 2 * time.Second
+	// okxOrderRecentTTL 最近推送的订单保留时间（下单返回前推送已到达时，waitForFill从这里读取）
+	okxOrderRecentTTL = time.Minute
+)
+
+// OrderUpdate 私有WebSocket orders频道推送的订单状态变化（数量已由张数转换为币的数量）
+type OrderUpdate struct {
+	Time           time.Time `json:"time"` // 订单更新时间
+	Symbol         string    `json:"symbol"`
+	InstID         string    `json:"inst_id"`
+	OrderID        string    `json:"order_id"`
+	ClientOrderID  string    `json:"client_order_id,omitempty"`
+	Status         string    `json:"status"` // NEW / PARTIALLY_FILLED / FILLED / CANCELED
+	Side           string    `json:"side"`   // BUY / SELL
+	PositionSide   string    `json:"position_side"`
+	Type           string    `json:"type"` // OKX订单类型（market / limit / post_only 等）
+	Price          float64   `json:"price"`
+	Quantity       float64   `json:"quantity"`
+	FilledQuantity float64   `json:"filled_quantity"`         // 累计成交数量
+	LastFillQty    float64   `json:"last_fill_qty,omitempty"` // 本次推送对应的成交数量（非成交推送为0）
+	LastFillPrice  float64   `json:"last_fill_price,omitempty"`
+	AvgPrice       float64   `json:"avg_price"`
+	Fee            float64   `json:"fee"` // 累计手续费（负数为扣除）
+	FeeAsset       string    `json:"fee_asset"`
+	RealizedPnL    float64   `json:"realized_pnl"`
+	Leverage       int       `json:"leverage,omitempty"`
+	ReduceOnly     bool      `json:"reduce_only"`
+}
+
+// okxRecentOrder 最近推送的订单状态
+type okxRecentOrder struct {
+	order *tradeModel.Order
+	at    time.Time
+}
+
+// okxOrderFeed 私有WebSocket的orders频道：记录最近的订单状态供waitForFill使用，并异步分发给回调
+type okxOrderFeed struct {
+	hooks streamHooks[OrderUpdate]
+
+	mu      sync.Mutex
+	waiters map[string][]chan *tradeModel.Order
+	recent  map[string]okxRecentOrder // key: ordId
+}
+
+// SubscribeOrderUpdates 通过私有WebSocket订阅订单推送（第一次调用时用交易器的API凭证登录并建立连接，断开后自动重连、重新登录并重新订阅）
+// fn不为nil时在单独的goroutine中按推送顺序调用，不阻塞连接；fn为nil时只启用推送，WaitForFill优先使用推送确认成交
+func (t *OkxTrader) SubscribeOrderUpdates(fn func(OrderUpdate)) error {
+	t.orderFeed(true).hooks.register(fn)
+	return t.privateStream(true).subscribe(map[string]string{"channel": "orders", "instType": "ANY"})
+}
+
+// OrderStreamConnected 订单推送是否可用（已订阅且私有WebSocket已登录）
+func (t *OkxTrader) OrderStreamConnected() bool {
+	return t.orderFeed(false) != nil && t.PrivateStreamConnected()
+}
+
+// orderFeed 返回订单推送，create为true且尚未创建时创建
+func (t *OkxTrader) orderFeed(create bool) *okxOrderFeed {
+	t.privateMu.Lock()
+	defer t.privateMu.Unlock()
+	if t.orders == nil && create {
+		t.orders = &okxOrderFeed{
+			hooks:   streamHooks[OrderUpdate]{name: "订单推送"},
+			waiters: make(map[string][]chan *tradeModel.Order),
+			recent:  make(map[string]okxRecentOrder),
+		}
+	}
+	return t.orders
+}
+
+// apply 记录推送的订单状态，通知等待该订单的waitForFill，并将推送分发给回调
+func (f *okxOrderFeed) apply(push okxStreamPush, now time.Time, convert func(*tradeModel.Order) OrderUpdate) {
+	var orders []*tradeModel.Order
+	if err := json.Unmarshal(push.Data, &orders); err != nil {
+		return
+	}
+	active := f.hooks.active()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, r := range f.recent {
+		if now.Sub(r.at) > okxOrderRecentTTL {
+			delete(f.recent, id)
+		}
+	}
+	for _, order := range orders {
+		if order == nil || order.OrdID == "" {
+			continue
+		}
+		f.recent[order.OrdID] = okxRecentOrder{order: order, at: now}
+		if okxOrderDone(order) {
+			for _, ch := range f.waiters[order.OrdID] {
+				ch <- order
+			}
+			delete(f.waiters, order.OrdID)
+		}
+		if active {
+			f.hooks.emit(convert(order))
+		}
+	}
+}
+
+// wait 返回订单完成时收到推送的channel（推送已到达时立即可读）与取消等待的函数
+func (f *okxOrderFeed) wait(ordID string) (<-chan *tradeModel.Order, func()) {
+	ch := make(chan *tradeModel.Order, 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.recent[ordID]; ok && okxOrderDone(r.order) {
+		ch <- r.order
+		return ch, func() {}
+	}
+	f.waiters[ordID] = append(f.waiters[ordID], ch)
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		waiters := f.waiters[ordID]
+		for i, w := range waiters {
+			if w == ch {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(f.waiters, ordID)
+		} else {
+			f.waiters[ordID] = waiters
+		}
+	}
+}
+
+// waitForFillStream 订单推送可用时等待订单完成的推送，ok为false表示推送不可用或超时，调用方应使用REST查询
+func (t *OkxTrader) waitForFillStream(ctx context.Context, ordID string) (detail *tradeModel.Order, ok bool) {
+	feed := t.orderFeed(false)
+	if feed == nil || !t.PrivateStreamConnected() {
+		return nil, false
+	}
+	ch, cancel := feed.wait(ordID)
+	defer cancel()
+	select {
+	case detail := <-ch:
+		return detail, true
+	case <-t.clock.After(okxOrderStreamWait):
+		t.logger.Debug("订单推送超时，改为REST查询", "ordId", ordID)
+	case <-ctx.Done():
+	}
+	return nil, false
+}
+
+// okxOrderDone 订单已完成（全部成交或已撤销）
+func okxOrderDone(order *tradeModel.Order) bool {
+	switch order.State {
+	case okx.OrderFilled, okx.OrderCancel, okx.OrderState("mmp_canceled"):
+		return true
+	}
+	return false
+}
+
+// orderUpdate 推送的订单转换为OrderUpdate
+func (t *OkxTrader) orderUpdate(order *tradeModel.Order) OrderUpdate {
+	price := float64(order.AvgPx)
+	if price <= 0 {
+		price = float64(order.Px)
+	}
+	u := OrderUpdate{
+		Time:          time.Time(order.UTime),
+		Symbol:        okxSymbol(order.InstID),
+		InstID:        order.InstID,
+		OrderID:       order.OrdID,
+		ClientOrderID: order.ClOrdID,
+		Status:        okxOrderStatus(order.State),
+		Side:          strings.ToUpper(string(order.Side)),
+		PositionSide:  okxOpenOrderPositionSide(order.PosSide),
+		Type:          string(order.OrdType),
+		Price:         float64(order.Px),
+		AvgPrice:      float64(order.AvgPx),
+		LastFillPrice: float64(order.FillPx),
+		Fee:           float64(order.Fee),
+		FeeAsset:      order.FeeCcy,
+		RealizedPnL:   float64(order.Pnl),
+		Leverage:      int(order.Lever),
+		ReduceOnly:    order.ReduceOnly == "true",
+	}
+	if order.InstType == okx.SpotInstrument {
+		u.Quantity, u.FilledQuantity, u.LastFillQty = float64(order.Sz), float64(order.AccFillSz), float64(order.FillSz)
+	} else {
+		u.Quantity = t.contractsToQty(order.InstID, float64(order.Sz), price)
+		u.FilledQuantity = t.contractsToQty(order.InstID, float64(order.AccFillSz), price)
+		u.LastFillQty = t.contractsToQty(order.InstID, float64(order.FillSz), float64(order.FillPx))
+	}
+	return u
+}
@@ -0,0 +1,572 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	"github.com/Benjmmi/okx/api/ws"
+	"github.com/Benjmmi/okx/events"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+const (
+	okxTransportREST = "rest"
+	okxTransportWS   = "ws"
+)
+
+// errOkxWSUnavailable WebSocket未连接或请求未发出，可以安全地改用REST
+var errOkxWSUnavailable = errors.New("OKX WebSocket交易通道不可用")
+
+// orderTransportStats 单个下单通道的调用次数与延迟统计
+type orderTransportStats struct {
+	Count  int64
+	Errors int64
+	Total  time.Duration
+	Last   time.Duration
+	Max    time.Duration
+}
+
+func (s *orderTransportStats) snapshot() map[string]interface{} {
+	avg := 0.0
+	if s.Count > 0 {
+		avg = float64(s.Total.Milliseconds()) / float64(s.Count)
+	}
+	return map[string]interface{}{
+		"count":           s.Count,
+		"errors":          s.Errors,
+		"avg_latency_ms":  avg,
+		"last_latency_ms": s.Last.Milliseconds(),
+		"max_latency_ms":  s.Max.Milliseconds(),
+	}
+}
+
+// okxWSReply WebSocket交易请求的响应
+type okxWSReply struct {
+	code int64
+	msg  string
+	data []*events.Argument
+}
+
+// okxWSTrade 基于已登录WebSocket的下单/撤单通道，按请求ID关联响应
+type okxWSTrade struct {
+	ws      *ws.ClientWs
+	seq     uint64
+	mu      sync.Mutex
+	pending map[string]chan okxWSReply
+	logger  *slog.Logger
+}
+
+// newOkxWSTrade 创建WebSocket交易通道并在后台建立连接、登录
+func newOkxWSTrade(client *ws.ClientWs, logger *slog.Logger) *okxWSTrade {
+	w := &okxWSTrade{
+		ws:      client,
+		pending: make(map[string]chan okxWSReply),
+		logger:  logger,
+	}
+	errCh := make(chan *events.Error, 16)
+	successCh := make(chan *events.Success, 16)
+	client.SetChannels(errCh, nil, nil, nil, successCh)
+	go w.dispatch(errCh, successCh)
+
+	go func() {
+		if err := client.Connect(true); err != nil {
+			w.logger.Warn("OKX WebSocket连接失败，下单使用REST", "err", err)
+			return
+		}
+		if err := client.Login(); err != nil {
+			w.logger.Warn("OKX WebSocket登录失败，下单使用REST", "err", err)
+			return
+		}
+		w.logger.Info("OKX WebSocket交易通道已连接")
+	}()
+	return w
+}
+
+// available 连接已建立且已登录
+func (w *okxWSTrade) available() bool {
+	return w.ws.CheckConnect(true) && w.ws.Authorized
+}
+
+// dispatch 将响应分发给等待中的请求
+func (w *okxWSTrade) dispatch(errCh chan *events.Error, successCh chan *events.Success) {
+	for {
+		select {
+		case s := <-successCh:
+			w.deliver(s.ID, okxWSReply{code: int64(s.Code), msg: s.Msg, data: s.Data})
+		case e := <-errCh:
+			if e.ID == "" {
+				w.logger.Warn("OKX WebSocket错误", "code", e.Code, "msg", e.Msg)
+				continue
+			}
+			w.deliver(e.ID, okxWSReply{code: int64(e.Code), msg: e.Msg, data: e.Data})
+		}
+	}
+}
+
+func (w *okxWSTrade) deliver(id string, reply okxWSReply) {
+	w.mu.Lock()
+	ch, ok := w.pending[id]
+	delete(w.pending, id)
+	w.mu.Unlock()
+	if ok {
+		ch <- reply
+	}
+}
+
+// call 发送请求并等待对应ID的响应
+// 请求发出前失败返回errOkxWSUnavailable；发出后超时或ctx结束返回TimeoutError/CanceledError（结果未知，不能改用REST重发）
+func (w *okxWSTrade) call(ctx context.Context, op string, timeout time.Duration, send func(id string) error) (okxWSReply, error) {
+	if err := ctx.Err(); err != nil {
+		return okxWSReply{}, &CanceledError{Op: op, Class: OpMutation, Err: err}
+	}
+	id := fmt.Sprintf("nofx%d", atomic.AddUint64(&w.seq, 1))
+	replyCh := make(chan okxWSReply, 1)
+	w.mu.Lock()
+	w.pending[id] = replyCh
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+	}()
+
+	sendErr := make(chan error, 1)
+	go func() { sendErr <- send(id) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case err := <-sendErr:
+			if err != nil {
+				return okxWSReply{}, fmt.Errorf("%w: %v", errOkxWSUnavailable, err)
+			}
+			sendErr = nil
+		case reply := <-replyCh:
+			return reply, nil
+		case <-timer.C:
+			return okxWSReply{}, &TimeoutError{Op: op, Class: OpMutation, Timeout: timeout}
+		case <-ctx.Done():
+			return okxWSReply{}, &CanceledError{Op: op, Class: OpMutation, Err: ctx.Err(), sent: true}
+		}
+	}
+}
+
+// argString 读取WebSocket响应字段
+func argString(arg *events.Argument, key string) string {
+	if arg == nil {
+		return ""
+	}
+	v, ok := arg.Get(key)
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// placeOrder 通过WebSocket下单
+func (w *okxWSTrade) placeOrder(ctx context.Context, req tradeReq.PlaceOrder, timeout time.Duration) (*tradeModel.PlaceOrder, error) {
+	reply, err := w.call(ctx, "PlaceOrder", timeout, func(id string) error {
+		// 不用SDK的Trade.PlaceOrder：其参数转换会把reduceOnly等布尔字段变成空字符串
+		args, err := okxStringParams(req)
+		if err != nil {
+			return err
+		}
+		return w.ws.Send(true, okx.OrderOperation, []map[string]string{args}, map[string]string{"id": id})
+	})
+	if err != nil {
+		return nil, err
+	}
+	var order *tradeModel.PlaceOrder
+	if len(reply.data) > 0 {
+		sCode, _ := strconv.ParseInt(argString(reply.data[0], "sCode"), 10, 64)
+		order = &tradeModel.PlaceOrder{
+			OrdID:   argString(reply.data[0], "ordId"),
+			ClOrdID: argString(reply.data[0], "clOrdId"),
+			Tag:     argString(reply.data[0], "tag"),
+			SMsg:    argString(reply.data[0], "sMsg"),
+			SCode:   okx.JSONInt64(sCode),
+		}
+	}
+	var sCode int64
+	var sMsg string
+	if order != nil {
+		sCode, sMsg = int64(order.SCode), order.SMsg
+	}
+	if err := okxResponseError("PlaceOrder", int(reply.code), reply.msg, sCode, sMsg); err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, okxEmptyResponse("PlaceOrder")
+	}
+	return order, nil
+}
+
+// cancelOrder 通过WebSocket撤单
+func (w *okxWSTrade) cancelOrder(ctx context.Context, req tradeReq.CancelOrder, timeout time.Duration) error {
+	reply, err := w.call(ctx, "CancelOrder", timeout, func(id string) error {
+		req.ID = id
+		return w.ws.Trade.CancelOrder(req)
+	})
+	if err != nil {
+		return err
+	}
+	var sCode int64
+	var sMsg string
+	if len(reply.data) > 0 {
+		sCode, _ = strconv.ParseInt(argString(reply.data[0], "sCode"), 10, 64)
+		sMsg = argString(reply.data[0], "sMsg")
+	}
+	return okxResponseError("CancelOrder", int(reply.code), reply.msg, sCode, sMsg)
+}
+
+// SetPreferWSOrders 设置下单/撤单是否优先使用WebSocket通道
+// WebSocket未连接时自动使用REST
+func (t *OkxTrader) SetPreferWSOrders(prefer bool) {
+	t.transportMu.Lock()
+	defer t.transportMu.Unlock()
+	t.preferWS = prefer
+	if client := t.api(); prefer && t.wsTrade == nil && client.Ws != nil {
+		t.wsTrade = newOkxWSTrade(client.Ws, t.logger)
+	}
+}
+
+// wsTradeAvailable 返回可用的WebSocket交易通道（未启用或未连接时为nil）
+func (t *OkxTrader) wsTradeAvailable() *okxWSTrade {
+	t.transportMu.Lock()
+	defer t.transportMu.Unlock()
+	if !t.preferWS || t.wsTrade == nil || !t.wsTrade.available() {
+		return nil
+	}
+	return t.wsTrade
+}
+
+// recordTransport 记录一次下单通道调用的延迟
+func (t *OkxTrader) recordTransport(transport string, start time.Time, err error) {
+	latency := t.clock.Since(start)
+	t.transportMu.Lock()
+	defer t.transportMu.Unlock()
+	if t.transportStats == nil {
+		t.transportStats = make(map[string]*orderTransportStats)
+	}
+	stats, ok := t.transportStats[transport]
+	if !ok {
+		stats = &orderTransportStats{}
+		t.transportStats[transport] = stats
+	}
+	stats.Count++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.Total += latency
+	stats.Last = latency
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+}
+
+// OrderTransportStats 各下单通道的延迟统计
+func (t *OkxTrader) OrderTransportStats() map[string]interface{} {
+	t.transportMu.Lock()
+	defer t.transportMu.Unlock()
+	preferred := okxTransportREST
+	if t.preferWS {
+		preferred = okxTransportWS
+	}
+	result := map[string]interface{}{
+		"preferred":    preferred,
+		"ws_connected": t.wsTrade != nil && t.wsTrade.available(),
+	}
+	for _, transport := range []string{okxTransportREST, okxTransportWS} {
+		stats, ok := t.transportStats[transport]
+		if !ok {
+			stats = &orderTransportStats{}
+		}
+		result[transport] = stats.snapshot()
+	}
+	return result
+}
+
+// placeOrder 下单：优先WebSocket（已启用且已连接），否则使用REST
+// 下单成功或结果未知时清除余额与持仓缓存，下次查询直接请求API
+func (t *OkxTrader) placeOrder(ctx context.Context, req tradeReq.PlaceOrder) (*tradeModel.PlaceOrder, error) {
+	order, err := t.sendOrder(ctx, req)
+	if err == nil || IsOutcomeUnknown(err) {
+		t.InvalidateCache()
+	}
+	return order, err
+}
+
+// okxOrderPriority 下单请求的限速优先级：只减仓单与双向持仓的平仓单为最高，开仓单次之
+func okxOrderPriority(req tradeReq.PlaceOrder) RequestPriority {
+	closing := req.ReduceOnly ||
+		(req.PosSide == okx.PositionLongSide && req.Side == okx.OrderSell) ||
+		(req.PosSide == okx.PositionShortSide && req.Side == okx.OrderBuy)
+	if closing {
+		return PriorityCritical
+	}
+	return PriorityEntry
+}
+
+// sendOrder 通过WebSocket或REST发送下单请求
+func (t *OkxTrader) sendOrder(ctx context.Context, req tradeReq.PlaceOrder) (*tradeModel.PlaceOrder, error) {
+	ctx = withRequestPriority(ctx, okxOrderPriority(req))
+	if w := t.wsTradeAvailable(); w != nil {
+		// WebSocket下单与REST共用交易所的下单限额
+		if err := t.rateLimiter.acquire(ctx, "PlaceOrder", OpMutation); err != nil {
+			return nil, err
+		}
+		start := t.clock.Now()
+		order, err := w.placeOrder(ctx, req, t.timeouts.For(OpMutation))
+		if !errors.Is(err, errOkxWSUnavailable) {
+			t.recordTransport(okxTransportWS, start, err)
+			return order, err
+		}
+		t.logger.Warn("WebSocket下单失败，改用REST下单", "err", err)
+	}
+
+	start := t.clock.Now()
+	resp, err := okxCall(ctx, t, OpMutation, "PlaceOrder", func() (tradeResp.PlaceOrder, error) {
+		var resp tradeResp.PlaceOrder
+		err := t.postOkxJSON("/api/v5/trade/order", req, &resp)
+		return resp, err
+	})
+	if err == nil {
+		if len(resp.PlaceOrders) > 0 {
+			err = okxResponseError("PlaceOrder", resp.Code, resp.Msg, int64(resp.PlaceOrders[0].SCode), resp.PlaceOrders[0].SMsg)
+		} else if err = okxResponseError("PlaceOrder", resp.Code, resp.Msg, 0, ""); err == nil {
+			err = okxEmptyResponse("PlaceOrder")
+		}
+	}
+	t.recordTransport(okxTransportREST, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.PlaceOrders[0], nil
+}
+
+// postOkxJSON 以JSON请求体提交POST请求并解析响应
+// SDK的Trade.PlaceOrder/PlaceAlgoOrder先把请求转成map[string]string，布尔字段（reduceOnly）会丢失为空字符串，
+// 下单与条件单因此直接按请求结构体的JSON提交
+func (t *OkxTrader) postOkxJSON(path string, req, resp interface{}) error {
+	res, err := t.api().Rest.DoBatch(path, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(resp)
+}
+
+// okxStringParams 请求结构体转为WebSocket参数（值均为字符串，布尔值为"true"/"false"）
+func okxStringParams(req interface{}) (map[string]string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	params := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			params[k] = v
+		case bool:
+			params[k] = strconv.FormatBool(v)
+		case float64:
+			params[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+		default:
+			return nil, fmt.Errorf("不支持的下单参数 %s: %T", k, v)
+		}
+	}
+	return params, nil
+}
+
+// cancelOrder 撤单：优先WebSocket（已启用且已连接），否则使用REST
+func (t *OkxTrader) cancelOrder(ctx context.Context, req tradeReq.CancelOrder) error {
+	if w := t.wsTradeAvailable(); w != nil {
+		// WebSocket下单与REST共用交易所的下单限额
+		if err := t.rateLimiter.acquire(ctx, "CancelOrder", OpMutation); err != nil {
+			return err
+		}
+		start := t.clock.Now()
+		err := w.cancelOrder(ctx, req, t.timeouts.For(OpMutation))
+		if !errors.Is(err, errOkxWSUnavailable) {
+			t.recordTransport(okxTransportWS, start, err)
+			return err
+		}
+		t.logger.Warn("WebSocket撤单失败，改用REST撤单", "err", err)
+	}
+
+	start := t.clock.Now()
+	resp, err := okxCall(ctx, t, OpMutation, "CancelOrder", func() (tradeResp.CancelOrder, error) {
+		return t.api().Rest.Trade.CancelOrder([]tradeReq.CancelOrder{req})
+	})
+	if err == nil {
+		var sCode int64
+		var sMsg string
+		if len(resp.CancelOrders) > 0 {
+			sCode, sMsg = int64(resp.CancelOrders[0].SCode), resp.CancelOrders[0].SMsg
+		}
+		err = okxResponseError("CancelOrder", resp.Code, resp.Msg, sCode, sMsg)
+	}
+	t.recordTransport(okxTransportREST, start, err)
+	return err
+}
+
+// okxCancelBatchSize OKX单次批量撤单的上限
+const okxCancelBatchSize = 20
+
+// cancelOrders 批量撤销普通委托，每个订单的结果记入summary（已结束的订单计为成功）
+func (t *OkxTrader) cancelOrders(ctx context.Context, reqs []tradeReq.CancelOrder, summary *CancelSummary) {
+	for len(reqs) > okxCancelBatchSize {
+		t.cancelOrderBatch(ctx, reqs[:okxCancelBatchSize], summary)
+		reqs = reqs[okxCancelBatchSize:]
+	}
+	if len(reqs) > 0 {
+		t.cancelOrderBatch(ctx, reqs, summary)
+	}
+}
+
+// cancelOrderBatch 通过批量撤单接口撤销一批（不超过okxCancelBatchSize个）普通委托
+// 只有一个订单，或整批请求结果未知（超时）时逐个走cancelOrderSafe（可用WebSocket，结果未知时对账）
+func (t *OkxTrader) cancelOrderBatch(ctx context.Context, reqs []tradeReq.CancelOrder, summary *CancelSummary) {
+	cancelEach := func() {
+		for _, req := range reqs {
+			summary.record(req.OrdID, t.cancelOrderSafe(ctx, req))
+		}
+	}
+	if len(reqs) == 1 {
+		cancelEach()
+		return
+	}
+
+	resp, err := okxCall(ctx, t, OpMutation, "CancelBatchOrders", func() (tradeResp.CancelOrder, error) {
+		return t.api().Rest.Trade.CancelOrder(reqs)
+	})
+	if IsOutcomeUnknown(err) {
+		t.logger.Warn("批量撤单结果未知，逐个撤单", "count", len(reqs), "err", err)
+		cancelEach()
+		return
+	}
+	byID := make(map[string]tradeReq.CancelOrder, len(reqs))
+	for _, req := range reqs {
+		byID[req.OrdID] = req
+	}
+	record := func(ordID string, err error) {
+		summary.record(ordID, err)
+		t.metrics.cancel(byID[ordID].InstID, "order", err)
+	}
+	if err == nil && len(resp.CancelOrders) == 0 {
+		err = okxResponseError("CancelBatchOrders", resp.Code, resp.Msg, 0, "")
+		if err == nil {
+			err = okxEmptyResponse("CancelBatchOrders")
+		}
+	}
+	if err != nil {
+		for _, req := range reqs {
+			record(req.OrdID, err)
+		}
+		return
+	}
+	// 部分失败时顶层code非0，以每个订单的sCode为准
+	for _, order := range resp.CancelOrders {
+		err := okxResponseError("CancelOrder", 0, "", int64(order.SCode), order.SMsg)
+		if isOkxAlreadyGone(err) {
+			gone := &OrderGoneError{OrderID: order.OrdID, Reason: order.SMsg, State: okxGoneState(err)}
+			if gone.State == "" {
+				gone.State = t.orderGoneState(ctx, byID[order.OrdID])
+			}
+			err = gone
+		}
+		record(order.OrdID, err)
+	}
+}
+
+// placeAlgoOrder 下策略委托（止损/止盈等条件单），返回algoId
+func (t *OkxTrader) placeAlgoOrder(ctx context.Context, req tradeReq.PlaceAlgoOrder) (string, error) {
+	resp, err := okxCall(ctx, t, OpMutation, "PlaceAlgoOrder", func() (tradeResp.PlaceAlgoOrder, error) {
+		var resp tradeResp.PlaceAlgoOrder
+		err := t.postOkxJSON("/api/v5/trade/order-algo", req, &resp)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.PlaceAlgoOrders) == 0 {
+		if err := okxResponseError("PlaceAlgoOrder", resp.Code, resp.Msg, 0, ""); err != nil {
+			return "", err
+		}
+		return "", okxEmptyResponse("PlaceAlgoOrder")
+	}
+	order := resp.PlaceAlgoOrders[0]
+	if err := okxResponseError("PlaceAlgoOrder", resp.Code, resp.Msg, int64(order.SCode), order.SMsg); err != nil {
+		return "", err
+	}
+	return order.AlgoID, nil
+}
+
+// okxCancelAlgoBatchSize OKX单次批量撤销策略委托的上限
+const okxCancelAlgoBatchSize = 10
+
+// cancelAlgoOrders 批量撤销策略委托，每个订单的结果记入summary（已结束的订单计为成功）
+func (t *OkxTrader) cancelAlgoOrders(ctx context.Context, reqs []tradeReq.CancelAlgoOrder, summary *CancelSummary) {
+	for len(reqs) > okxCancelAlgoBatchSize {
+		t.cancelAlgoBatch(ctx, reqs[:okxCancelAlgoBatchSize], summary)
+		reqs = reqs[okxCancelAlgoBatchSize:]
+	}
+	if len(reqs) > 0 {
+		t.cancelAlgoBatch(ctx, reqs, summary)
+	}
+}
+
+// cancelAlgoBatch 撤销一批（不超过okxCancelAlgoBatchSize个）策略委托
+func (t *OkxTrader) cancelAlgoBatch(ctx context.Context, reqs []tradeReq.CancelAlgoOrder, summary *CancelSummary) {
+	resp, err := okxCall(ctx, t, OpMutation, "CancelAlgoOrder", func() (tradeResp.CancelAlgoOrder, error) {
+		return t.api().Rest.Trade.CancelAlgoOrder(reqs)
+	})
+	instIDs := make(map[string]string, len(reqs)) // algoId -> instId（指标标签）
+	for _, req := range reqs {
+		instIDs[req.AlgoID] = req.InstID
+	}
+	record := func(algoID string, err error) {
+		summary.recordAlgo(algoID, err)
+		t.metrics.cancel(instIDs[algoID], "algo", err)
+	}
+	if err != nil {
+		for _, req := range reqs {
+			record(req.AlgoID, err)
+		}
+		return
+	}
+	if len(resp.CancelAlgoOrders) == 0 {
+		err := okxResponseError("CancelAlgoOrder", resp.Code, resp.Msg, 0, "")
+		for _, req := range reqs {
+			record(req.AlgoID, err)
+		}
+		return
+	}
+	// 部分失败时顶层code非0，以每个订单的sCode为准
+	for _, order := range resp.CancelAlgoOrders {
+		err := okxResponseError("CancelAlgoOrder", 0, "", int64(order.SCode), order.SMsg)
+		if isOkxAlreadyGone(err) {
+			err = &OrderGoneError{OrderID: order.AlgoID, Reason: order.SMsg, State: okxGoneState(err)}
+		}
+		record(order.AlgoID, err)
+	}
+}
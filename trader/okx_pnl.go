@@ -0,0 +1,171 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	accountModel "github.com/Benjmmi/okx/models/account"
+	account2 "github.com/Benjmmi/okx/requests/rest/account"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	okxBillsPageLimit = 100 // 账单每页条数（OKX上限100）
+	okxBillsMaxPages  = 10  // 单次统计最多翻页数，避免长周期持仓拉取过多
+)
+
+// SymbolPnL 单个币种当前持仓周期的盈亏（均已折算为USD）
+// Net = Realized + Unrealized + Fees + Funding（手续费与资金费支出为负数）
+type SymbolPnL struct {
+	Symbol     string    `json:"symbol"`
+	Since      time.Time `json:"since"`    // 统计起点：持仓开仓时间，无持仓时为当日0点(UTC)
+	Realized   float64   `json:"realized"` // 成交产生的已实现盈亏
+	Unrealized float64   `json:"unrealized"`
+	Fees       float64   `json:"fees"`
+	Funding    float64   `json:"funding"`
+	Net        float64   `json:"net"`
+}
+
+// GetSymbolPnL 获取币种当前持仓周期的净盈亏：账单中的成交盈亏、手续费、资金费 + 持仓未实现盈亏
+func (t *OkxTrader) GetSymbolPnL(symbol string) (*SymbolPnL, error) {
+	all, err := t.collectPnL(map[string]bool{symbol: true})
+	if err != nil {
+		return nil, err
+	}
+	return all[symbol], nil
+}
+
+// GetAllPnL 获取所有持仓币种的净盈亏（账单只拉取一次）
+func (t *OkxTrader) GetAllPnL() (map[string]*SymbolPnL, error) {
+	return t.collectPnL(nil)
+}
+
+// collectPnL 统计指定币种（nil表示全部持仓币种）的盈亏
+func (t *OkxTrader) collectPnL(symbols map[string]bool) (map[string]*SymbolPnL, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.clock.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	// instId -> 统计结果；未实现盈亏直接取持仓（已折算USD）
+	byInst := make(map[string]*SymbolPnL)
+	unrealized := make(map[string]decimal.Decimal)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		if symbols != nil && !symbols[symbol] {
+			continue
+		}
+		instID, _ := pos["instId"].(string)
+		pnl, ok := byInst[instID]
+		if !ok {
+			pnl = &SymbolPnL{Symbol: symbol, Since: dayStart}
+			byInst[instID] = pnl
+		}
+		// 双向持仓时取较早的开仓时间
+		if openTime, _ := pos["openTime"].(int64); openTime > 0 && (!ok || time.UnixMilli(openTime).Before(pnl.Since)) {
+			pnl.Since = time.UnixMilli(openTime).UTC()
+		}
+		upl, _ := pos["unRealizedProfit"].(float64)
+		unrealized[instID] = unrealized[instID].Add(toDecimal(upl))
+	}
+	// 指定了币种但当前无持仓：统计当日已实现部分
+	for symbol := range symbols {
+		instID, _, err := t.resolveInstID(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byInst[instID]; !ok {
+			byInst[instID] = &SymbolPnL{Symbol: symbol, Since: dayStart}
+		}
+	}
+
+	since := now
+	for _, pnl := range byInst {
+		if pnl.Since.Before(since) {
+			since = pnl.Since
+		}
+	}
+	bills, err := t.fetchBills(since)
+	if err != nil {
+		return nil, err
+	}
+
+	realized := make(map[string]decimal.Decimal)
+	fees := make(map[string]decimal.Decimal)
+	funding := make(map[string]decimal.Decimal)
+	for _, bill := range bills {
+		pnl, ok := byInst[bill.InstID]
+		if !ok || time.Time(bill.TS).Before(pnl.Since) {
+			continue
+		}
+		switch bill.Type {
+		case okx.BillFundingFeeType:
+			funding[bill.InstID] = funding[bill.InstID].Add(toDecimal(float64(bill.BalChg)))
+		default:
+			realized[bill.InstID] = realized[bill.InstID].Add(toDecimal(float64(bill.Pnl)))
+			fees[bill.InstID] = fees[bill.InstID].Add(toDecimal(float64(bill.Fee)))
+		}
+	}
+
+	result := make(map[string]*SymbolPnL, len(byInst))
+	for instID, pnl := range byInst {
+		inst, err := t.getInstrument(instID)
+		if err != nil {
+			return nil, err
+		}
+		// 账单金额为结算币种，币本位合约按标记价格折算为USD
+		price := 0.0
+		if isInverseContract(inst) {
+			if price, err = t.getMarkPrice(pnl.Symbol); err != nil {
+				return nil, err
+			}
+		}
+		pnl.Realized = settleToUSD(inst, decimalFloat(realized[instID]), price)
+		pnl.Fees = settleToUSD(inst, decimalFloat(fees[instID]), price)
+		pnl.Funding = settleToUSD(inst, decimalFloat(funding[instID]), price)
+		pnl.Unrealized = decimalFloat(unrealized[instID])
+		pnl.Net = sumFloat64(pnl.Realized, pnl.Unrealized, pnl.Fees, pnl.Funding)
+		result[pnl.Symbol] = pnl
+	}
+	return result, nil
+}
+
+// fetchBills 拉取since之后的合约账单（按时间倒序翻页）
+func (t *OkxTrader) fetchBills(since time.Time) ([]*accountModel.Bill, error) {
+	var bills []*accountModel.Bill
+	req := account2.GetBills{InstType: t.instType, Limit: okxBillsPageLimit}
+	for page := 0; page < okxBillsMaxPages; page++ {
+		resp, err := okxCall(context.Background(), t, OpPrivateRead, "GetBills", func() (accountResp.GetBills, error) {
+			return t.api().Rest.Account.GetBills(req, false)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("获取账单失败: %w", err)
+		}
+		if err := okxCheck("GetBills", resp.Basic, -1); err != nil {
+			return nil, fmt.Errorf("获取账单失败: %w", err)
+		}
+
+		for _, bill := range resp.Bills {
+			if time.Time(bill.TS).Before(since) {
+				return bills, nil
+			}
+			bills = append(bills, bill)
+		}
+		if len(resp.Bills) < okxBillsPageLimit {
+			return bills, nil
+		}
+		last, err := strconv.ParseInt(resp.Bills[len(resp.Bills)-1].BillID, 10, 64)
+		if err != nil {
+			return bills, nil
+		}
+		req.After = last
+	}
+	return bills, nil
+}
@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	accountReq "github.com/Benjmmi/okx/requests/rest/account"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+)
+
+// accountPositionMode 账户的持仓模式（long_short_mode双向持仓 / net_mode单向持仓，查询成功后缓存）
+func (t *OkxTrader) accountPositionMode(ctx context.Context) (okx.PositionType, error) {
+	t.marginMu.RLock()
+	mode := t.posMode
+	t.marginMu.RUnlock()
+	if mode != "" {
+		return mode, nil
+	}
+	if err := t.loadAccountConfig(ctx); err != nil {
+		return "", fmt.Errorf("查询持仓模式失败: %w", err)
+	}
+	t.marginMu.RLock()
+	defer t.marginMu.RUnlock()
+	return t.posMode, nil
+}
+
+// GetAccountPositionMode 查询账户的持仓模式，hedge为true表示双向持仓（开平仓需指定多空方向）
+func (t *OkxTrader) GetAccountPositionMode() (hedge bool, err error) {
+	mode, err := t.accountPositionMode(context.Background())
+	if err != nil {
+		return false, err
+	}
+	return mode == okx.PositionLongShortMode, nil
+}
+
+// SetPositionMode 切换账户的持仓模式（hedge为true切换为双向持仓，false为单向持仓）
+// 有持仓时OKX不允许切换，此时直接拒绝；已是目标模式时不请求交易所
+func (t *OkxTrader) SetPositionMode(hedge bool) error {
+	ctx := context.Background()
+	target := okx.PositionNetMode
+	if hedge {
+		target = okx.PositionLongShortMode
+	}
+	if current, err := t.accountPositionMode(ctx); err == nil && current == target {
+		return nil
+	}
+
+	positions, err := t.positions(ctx, true)
+	if err != nil {
+		return fmt.Errorf("切换持仓模式前查询持仓失败: %w", err)
+	}
+	if len(positions) > 0 {
+		return fmt.Errorf("当前有 %d 个持仓，平仓后才能切换持仓模式", len(positions))
+	}
+
+	resp, err := okxCall(ctx, t, OpMutation, "SetPositionMode", func() (accountResp.SetPositionMode, error) {
+		return t.api().Rest.Account.SetPositionMode(accountReq.SetPositionMode{PosMode: target})
+	})
+	if err == nil {
+		err = okxCheck("SetPositionMode", resp.Basic, -1)
+	}
+	if err != nil {
+		return fmt.Errorf("切换持仓模式失败: %w", err)
+	}
+
+	t.marginMu.Lock()
+	t.posMode = target
+	t.marginMu.Unlock()
+	// 双向/单向持仓的杠杆按不同方向设置，切换后重新确认
+	t.leverageMu.Lock()
+	t.confirmedLeverage = nil
+	t.leverageMu.Unlock()
+	t.logger.Info("OKX 持仓模式已切换", "posMode", target)
+	return nil
+}
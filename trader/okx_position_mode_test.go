@@ -0,0 +1,69 @@
+package trader
+
+import (
+	"testing"
+)
+
+// TestOkxNetModePosSide 单向持仓账户：开仓、平仓、设置杠杆与条件单的请求不带long/short方向（posSide为net或不传）
+func TestOkxNetModePosSide(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/config", `{"uid":"1","acctLv":"2","posMode":"net_mode"}`)
+	f.reply("POST /api/v5/trade/order", `{"ordId":"1","clOrdId":"","sCode":"0","sMsg":""}`)
+	f.reply("GET /api/v5/trade/order", okxTestFilledOrder("1", "", "buy", "net", "10", "50000"))
+	f.reply("POST /api/v5/trade/order-algo", `{"algoId":"sl-1","sCode":"0","sMsg":""}`)
+	tr := f.trader(t)
+
+	if hedge, err := tr.GetAccountPositionMode(); err != nil || hedge {
+		t.Fatalf("GetAccountPositionMode = %v, %v, want 单向持仓", hedge, err)
+	}
+	netOrOmitted := func(what, body string) {
+		t.Helper()
+		if posSide := jsonField(t, body, "posSide"); posSide != nil && posSide != "" && posSide != "net" {
+			t.Errorf("%s posSide = %v, want net 或不传: %s", what, posSide, body)
+		}
+	}
+
+	// 逐仓：双向持仓时多空分别设置杠杆，单向持仓只设置一次
+	if err := tr.SetMarginMode("BTCUSDT", false); err != nil {
+		t.Fatalf("SetMarginMode: %v", err)
+	}
+	if _, err := tr.OpenLong("BTCUSDT", 0.1, 5); err != nil {
+		t.Fatalf("OpenLong: %v", err)
+	}
+	leverage := f.requestsTo("POST /api/v5/account/set-leverage")
+	if len(leverage) != 1 {
+		t.Fatalf("设置杠杆请求 = %d, want 1", len(leverage))
+	}
+	netOrOmitted("设置杠杆", leverage[0].Body)
+
+	if _, err := tr.PlaceStopLoss("BTCUSDT", PositionLong, 0.1, 48000); err != nil {
+		t.Fatalf("PlaceStopLoss: %v", err)
+	}
+	algos := f.requestsTo("POST /api/v5/trade/order-algo")
+	if len(algos) != 1 {
+		t.Fatalf("条件单请求 = %d, want 1", len(algos))
+	}
+	netOrOmitted("止损单", algos[0].Body)
+	if jsonField(t, algos[0].Body, "side") != "sell" {
+		t.Errorf("多仓止损单 side = %v, want sell", jsonField(t, algos[0].Body, "side"))
+	}
+
+	f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "net", "10", "50000", 5))
+	tr.InvalidateCache()
+	if _, err := tr.CloseLong("BTCUSDT", 0); err != nil {
+		t.Fatalf("CloseLong: %v", err)
+	}
+	orders := f.requestsTo("POST /api/v5/trade/order")
+	if len(orders) != 2 {
+		t.Fatalf("下单请求 = %d, want 2（开仓、平仓）", len(orders))
+	}
+	for i, want := range []struct{ what, side string }{{"开仓单", "buy"}, {"平仓单", "sell"}} {
+		netOrOmitted(want.what, orders[i].Body)
+		if jsonField(t, orders[i].Body, "side") != want.side {
+			t.Errorf("%s side = %v, want %s", want.what, jsonField(t, orders[i].Body, "side"), want.side)
+		}
+	}
+	if jsonField(t, orders[1].Body, "reduceOnly") != true {
+		t.Errorf("单向持仓的平仓单必须只减仓: %s", orders[1].Body)
+	}
+}
@@ -0,0 +1,216 @@
+package trader
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	accountModel "github.com/Benjmmi/okx/models/account"
+)
+
+// 持仓数据来源（Position.Source）
+const (
+	PositionSourceWS   = "ws"   // 私有WebSocket实时推送
+	PositionSourceREST = "rest" // REST查询（可能来自缓存）
+)
+
+// PositionChange 私有WebSocket positions频道推送的持仓变化（开仓、平仓、数量或强平价变化）
+type PositionChange struct {
+	Time     time.Time    `json:"time"`
+	Symbol   string       `json:"symbol"`
+	Side     PositionSide `json:"side"`
+	Previous *Position    `json:"previous,omitempty"` // 变化前的持仓，nil表示新开仓
+	Current  *Position    `json:"current,omitempty"`  // 变化后的持仓，nil表示已平仓（包括强平与在OKX App中手动平仓）
+}
+
+// okxPositionFeed 私有WebSocket的positions频道：按posId维护全部持仓，每次推送后整体替换持仓缓存
+type okxPositionFeed struct {
+	hooks streamHooks[PositionChange]
+
+	mu         sync.Mutex
+	positions  map[string]*Position // key: posId
+	generation int64                // 已收到全量推送的连接（与okxStream.generation相同时缓存为实时数据）
+	synced     bool                 // 曾收到过全量推送，之后的全量推送与上次的持仓比较得出变化
+}
+
+// SubscribePositions 通过私有WebSocket订阅持仓推送（第一次调用时登录并建立连接，断开后自动重连并重新订阅）
+// 连接正常时持仓缓存随推送实时更新，GetPositions直接返回推送的数据（source为ws）；连接断开时恢复为REST查询与缓存
+func (t *OkxTrader) SubscribePositions() error {
+	t.positionFeed(true)
+	return t.privateStream(true).subscribe(map[string]string{"channel": "positions", "instType": "ANY"})
+}
+
+// OnPositionChange 注册持仓变化回调（开仓、平仓、数量或强平价变化时调用），并订阅持仓推送
+// fn在单独的goroutine中按推送顺序调用；断线期间的变化在重连后的全量推送中补发
+func (t *OkxTrader) OnPositionChange(fn func(PositionChange)) error {
+	t.positionFeed(true).hooks.register(fn)
+	return t.SubscribePositions()
+}
+
+// PositionStreamLive 持仓缓存当前是否由WebSocket推送实时维护
+func (t *OkxTrader) PositionStreamLive() bool {
+	feed, stream := t.positionFeed(false), t.privateStream(false)
+	if feed == nil || stream == nil || !stream.isConnected() {
+		return false
+	}
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	return feed.generation == stream.generation.Load()
+}
+
+// positionFeed 返回持仓推送，create为true且尚未创建时创建
+func (t *OkxTrader) positionFeed(create bool) *okxPositionFeed {
+	t.privateMu.Lock()
+	defer t.privateMu.Unlock()
+	if t.positionsFeed == nil && create {
+		t.positionsFeed = &okxPositionFeed{
+			hooks:     streamHooks[PositionChange]{name: "持仓推送"},
+			positions: make(map[string]*Position),
+		}
+	}
+	return t.positionsFeed
+}
+
+// livePositions 持仓推送实时有效时返回缓存的持仓（已标记source），否则ok为false
+func (t *OkxTrader) livePositions() ([]*Position, bool) {
+	if !t.PositionStreamLive() {
+		return nil, false
+	}
+	t.positionsCacheMutex.RLock()
+	defer t.positionsCacheMutex.RUnlock()
+	// InvalidateCache清除后先用REST重新查询一次
+	if t.cachedPositions == nil {
+		return nil, false
+	}
+	return withPositionSource(copyPositions(t.cachedPositions), PositionSourceWS), true
+}
+
+// applyPositions 处理positions频道的推送：每次连接的第一条推送为全量持仓，之后为有变化的持仓（pos为0表示已平仓）
+func (t *OkxTrader) applyPositions(feed *okxPositionFeed, push okxStreamPush, generation int64, now time.Time) {
+	var rows []*accountModel.Position
+	if err := json.Unmarshal(push.Data, &rows); err != nil {
+		t.logger.Warn("无法解析持仓推送", "err", err)
+		return
+	}
+
+	feed.mu.Lock()
+	snapshot := feed.generation != generation
+	prev := feed.positions
+	next := make(map[string]*Position, len(prev))
+	if !snapshot {
+		for id, p := range prev {
+			next[id] = p
+		}
+	}
+	live := true
+	for _, row := range rows {
+		if row == nil || row.PosID == "" {
+			continue
+		}
+		p, err := t.okxPosition(row)
+		if err != nil {
+			// 转换失败时推送的持仓不完整，在下一次全量推送之前使用REST
+			t.logger.Warn("持仓推送转换失败，暂时改用REST查询", "instId", row.InstID, "err", err)
+			live = false
+			if old, ok := prev[row.PosID]; ok {
+				next[row.PosID] = old
+			}
+			continue
+		}
+		if p == nil {
+			delete(next, row.PosID)
+			continue
+		}
+		next[row.PosID] = p
+	}
+	var changes []PositionChange
+	if feed.synced || !snapshot {
+		changes = positionChanges(prev, next, now)
+	}
+	feed.positions = next
+	feed.synced = true
+	feed.generation = generation
+	if !live {
+		feed.generation = 0
+	}
+	feed.mu.Unlock()
+
+	result := make([]*Position, 0, len(next))
+	for _, p := range next {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].InstID != result[j].InstID {
+			return result[i].InstID < result[j].InstID
+		}
+		return result[i].Side < result[j].Side
+	})
+	t.metrics.positions(result)
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = result
+	t.positionsCacheTime = now
+	t.positionsCacheMutex.Unlock()
+
+	for _, c := range changes {
+		t.logger.Info("持仓变化", "symbol", c.Symbol, "side", c.Side, "opened", c.Previous == nil, "closed", c.Current == nil)
+		feed.hooks.emit(c)
+	}
+}
+
+// positionChanges 比较两次持仓，返回开仓、平仓、张数或强平价有变化的持仓
+func positionChanges(prev, next map[string]*Position, now time.Time) []PositionChange {
+	var changes []PositionChange
+	for id, cur := range next {
+		old := prev[id]
+		if old != nil && old.Side == cur.Side && old.Contracts == cur.Contracts && old.LiquidationPrice == cur.LiquidationPrice {
+			continue
+		}
+		if old != nil && old.Side != cur.Side {
+			// 单向持仓反手：原方向平仓，新方向开仓
+			changes = append(changes, positionChange(old, nil, now))
+			old = nil
+		}
+		changes = append(changes, positionChange(old, cur, now))
+	}
+	for id, old := range prev {
+		if _, ok := next[id]; !ok {
+			changes = append(changes, positionChange(old, nil, now))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Symbol != changes[j].Symbol {
+			return changes[i].Symbol < changes[j].Symbol
+		}
+		return changes[i].Side < changes[j].Side
+	})
+	return changes
+}
+
+func positionChange(prev, cur *Position, now time.Time) PositionChange {
+	c := PositionChange{Time: now}
+	ref := cur
+	if ref == nil {
+		ref = prev
+	}
+	c.Symbol, c.Side = ref.Symbol, ref.Side
+	if prev != nil {
+		p := *prev
+		c.Previous = &p
+	}
+	if cur != nil {
+		p := *cur
+		c.Current = &p
+	}
+	return c
+}
+
+// withPositionSource 标记持仓的数据来源
+func withPositionSource(positions []*Position, source string) []*Position {
+	for _, p := range positions {
+		p.Source = source
+	}
+	return positions
+}
+
+var _ PositionChangeNotifier = (*OkxTrader)(nil)
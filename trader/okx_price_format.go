@@ -0,0 +1,78 @@
+package trader
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceRounding 价格按tickSz取整的方向
+type PriceRounding int
+
+const (
+	RoundNearest PriceRounding = iota // 四舍五入
+	RoundDown                         // 向下取整
+	RoundUp                           // 向上取整
+)
+
+// stopRounding 止损/止盈触发价的取整方向：多仓向下、空仓向上
+// 止损离当前价更远一个tick之内，不会因取整被提前触发；止盈离当前价更近，不会因取整错过
+func stopRounding(side PositionSide) PriceRounding {
+	if side == PositionShort {
+		return RoundUp
+	}
+	return RoundDown
+}
+
+// limitRounding 限价单价格的取整方向：买单向下、卖单向上，成交价不会差于请求的价格
+func limitRounding(side Side) PriceRounding {
+	if side == SideBuy {
+		return RoundDown
+	}
+	return RoundUp
+}
+
+// FormatPrice 将价格按交易对的tickSz取整并格式化为下单用字符串
+func (t *OkxTrader) FormatPrice(symbol string, price float64, direction PriceRounding) (string, error) {
+	d, err := t.tickPrice(symbol, price, direction)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+// roundPrice 同FormatPrice，返回下单请求使用的float64
+func (t *OkxTrader) roundPrice(symbol string, price float64, direction PriceRounding) (float64, error) {
+	d, err := t.tickPrice(symbol, price, direction)
+	if err != nil {
+		return 0, err
+	}
+	return decimalFloat(d), nil
+}
+
+// tickPrice 按tickSz取整，取整后为0时返回错误
+func (t *OkxTrader) tickPrice(symbol string, price float64, direction PriceRounding) (decimal.Decimal, error) {
+	if price <= 0 {
+		return decimal.Zero, fmt.Errorf("价格必须大于0 (当前 %v)", price)
+	}
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	d := roundPriceToTick(price, float64(inst.TickSz), direction)
+	if !d.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%s 价格 %v 按tickSz %v 取整后为0", inst.InstID, price, float64(inst.TickSz))
+	}
+	return d, nil
+}
+
+// roundPriceToTick 按方向取整到tick的整数倍（tick<=0时原样返回）
+func roundPriceToTick(price, tick float64, direction PriceRounding) decimal.Decimal {
+	switch direction {
+	case RoundDown:
+		return floorToStep(price, tick)
+	case RoundUp:
+		return ceilToStep(price, tick)
+	}
+	return roundToStep(price, tick)
+}
@@ -0,0 +1,73 @@
+package trader
+
+import "testing"
+
+// TestOkxFormatPrice 按交易所返回的tickSz取整，三种方向分别检查，结果不带浮点误差
+func TestOkxFormatPrice(t *testing.T) {
+	for _, tc := range []struct {
+		tickSz  string
+		price   float64
+		nearest string
+		down    string
+		up      string
+	}{
+		{"0.1", 50000.06, "50000.1", "50000", "50000.1"},
+		{"0.1", 50000.04, "50000", "50000", "50000.1"},
+		{"0.1", 50000.3, "50000.3", "50000.3", "50000.3"},
+		{"0.1", 0.1 + 0.2, "0.3", "0.3", "0.3"},
+		{"0.01", 3012.345, "3012.35", "3012.34", "3012.35"},
+		{"0.01", 3012.3449, "3012.34", "3012.34", "3012.35"},
+		{"0.01", 1.005, "1.01", "1", "1.01"},
+		{"0.0001", 0.123456, "0.1235", "0.1234", "0.1235"},
+		{"0.0001", 0.12344, "0.1234", "0.1234", "0.1235"},
+		{"0.0001", 1.1, "1.1", "1.1", "1.1"},
+		{"5", 50003, "50005", "50000", "50005"},
+		{"5", 50002.4, "50000", "50000", "50005"},
+		{"5", 50010, "50010", "50010", "50010"},
+		{"5", 7.6, "10", "5", "10"},
+	} {
+		f := newFakeOkx(t)
+		f.reply("GET /api/v5/public/instruments", okxTestInstrumentWith("1", "1", tc.tickSz))
+		tr := f.trader(t)
+		for _, d := range []struct {
+			direction PriceRounding
+			want      string
+		}{
+			{RoundNearest, tc.nearest},
+			{RoundDown, tc.down},
+			{RoundUp, tc.up},
+		} {
+			got, err := tr.FormatPrice("BTCUSDT", tc.price, d.direction)
+			if err != nil || got != d.want {
+				t.Errorf("tickSz %s FormatPrice(%v, %d) = %q, %v, want %s", tc.tickSz, tc.price, d.direction, got, err, d.want)
+			}
+			if n, err := tr.roundPrice("BTCUSDT", tc.price, d.direction); err != nil || n != mustFloat(t, d.want) {
+				t.Errorf("tickSz %s roundPrice(%v, %d) = %v, %v, want %s", tc.tickSz, tc.price, d.direction, n, err, d.want)
+			}
+		}
+	}
+}
+
+// TestOkxFormatPriceRejectsZero 价格非正或取整后为0时返回错误
+func TestOkxFormatPriceRejectsZero(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/public/instruments", okxTestInstrumentWith("1", "1", "5"))
+	tr := f.trader(t)
+	for _, tc := range []struct {
+		price     float64
+		direction PriceRounding
+	}{
+		{0, RoundNearest},
+		{-1, RoundUp},
+		{2, RoundNearest}, // 不足半个tick
+		{4.9, RoundDown},
+	} {
+		if got, err := tr.FormatPrice("BTCUSDT", tc.price, tc.direction); err == nil {
+			t.Errorf("FormatPrice(%v, %d) = %q, want error", tc.price, tc.direction, got)
+		}
+	}
+	// 向上取整到第一个tick
+	if got, err := tr.FormatPrice("BTCUSDT", 2, RoundUp); err != nil || got != "5" {
+		t.Errorf("FormatPrice(2, up) = %q, %v, want 5", got, err)
+	}
+}
@@ -0,0 +1,175 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// streamHookQueue 私有推送回调的队列长度，回调处理不过来时丢弃
+const streamHookQueue = 256
+
+// streamHooks 私有推送的回调：在单独的goroutine中按推送顺序调用，不阻塞WebSocket读取
+type streamHooks[T any] struct {
+	name string // 日志中的推送名称
+
+	mu      sync.Mutex
+	hooks   []func(T) // 注册后不修改，追加时整体替换
+	queue   chan T
+	dropped atomic.Int64
+}
+
+// register 追加回调（fn为nil时忽略），第一次注册时启动分发goroutine
+func (h *streamHooks[T]) register(fn func(T)) {
+	if fn == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hooks := make([]func(T), 0, len(h.hooks)+1)
+	h.hooks = append(append(hooks, h.hooks...), fn)
+	if h.queue == nil {
+		h.queue = make(chan T, streamHookQueue)
+		go h.loop(h.queue)
+	}
+}
+
+// active 是否已注册回调（未注册时调用方可以跳过转换）
+func (h *streamHooks[T]) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.queue != nil
+}
+
+// emit 入队后立即返回，队列满时丢弃
+func (h *streamHooks[T]) emit(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.queue == nil {
+		return
+	}
+	select {
+	case h.queue <- v:
+	default:
+		if n := h.dropped.Add(1); n == 1 || n%100 == 0 {
+			log.Printf("⚠ %s回调队列已满，已丢弃 %d 条推送", h.name, n)
+		}
+	}
+}
+
+// stop 停止分发goroutine（已入队的推送仍会分发完），之后的推送不再分发
+func (h *streamHooks[T]) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.queue != nil {
+		close(h.queue)
+		h.queue = nil
+	}
+}
+
+func (h *streamHooks[T]) loop(queue <-chan T) {
+	for v := range queue {
+		h.mu.Lock()
+		hooks := h.hooks
+		h.mu.Unlock()
+		for _, fn := range hooks {
+			h.call(fn, v)
+		}
+	}
+}
+
+// call 调用单个回调，回调panic时记录日志，不影响其他回调与后续推送
+func (h *streamHooks[T]) call(fn func(T), v T) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ %s回调异常: %v", h.name, r)
+		}
+	}()
+	fn(v)
+}
+
+// privateStream 返回私有WebSocket连接（登录后订阅账户频道），create为true且尚未创建时创建
+// 推送按频道分发给对应的订阅（orders、positions与account频道）
+func (t *OkxTrader) privateStream(create bool) *okxStream {
+	t.privateMu.Lock()
+	defer t.privateMu.Unlock()
+	if t.private == nil && create {
+		_, wsPrivate, _ := t.endpoint.urls()
+		var stream *okxStream
+		stream = newOkxStream("账户", string(wsPrivate), t.logger, func(push okxStreamPush) {
+			switch push.Arg["channel"] {
+			case "orders":
+				if feed := t.orderFeed(false); feed != nil {
+					feed.apply(push, t.clock.Now(), t.orderUpdate)
+				}
+			case "positions":
+				if feed := t.positionFeed(false); feed != nil {
+					t.applyPositions(feed, push, stream.generation.Load(), t.clock.Now())
+				}
+			case "account":
+				if feed := t.balanceFeed(false); feed != nil {
+					t.applyBalance(feed, push, stream.generation.Load(), t.clock.Now())
+				}
+			}
+		})
+		stream.login = t.wsLoginArgs
+		t.private = stream
+	}
+	return t.private
+}
+
+// wsLoginArgs 私有WebSocket的登录参数（每次连接时读取最新凭证并签名）
+// sign = Base64(HMAC-SHA256(secretKey, timestamp + "GET" + "/users/self/verify"))
+func (t *OkxTrader) wsLoginArgs() (map[string]string, error) {
+	t.clientMu.RLock()
+	provider := t.credentials
+	t.clientMu.RUnlock()
+	creds, err := provider()
+	if err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(t.clock.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(creds.SecretKey))
+	mac.Write([]byte(ts + "GET" + "/users/self/verify"))
+	return map[string]string{
+		"apiKey":     creds.APIKey,
+		"passphrase": creds.Passphrase,
+		"timestamp":  ts,
+		"sign":       base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// ClosePrivateStream 关闭私有WebSocket连接并停止订单、持仓与余额推送（之后可以重新订阅），WaitForFill、持仓与余额恢复为REST查询
+func (t *OkxTrader) ClosePrivateStream() {
+	t.privateMu.Lock()
+	stream := t.private
+	t.private = nil
+	t.privateMu.Unlock()
+	if stream != nil {
+		stream.close()
+	}
+
+	t.privateMu.Lock()
+	orders, positions, balance := t.orders, t.positionsFeed, t.balancesFeed
+	t.orders, t.positionsFeed, t.balancesFeed = nil, nil, nil
+	t.privateMu.Unlock()
+	if orders != nil {
+		orders.hooks.stop()
+	}
+	if positions != nil {
+		positions.hooks.stop()
+	}
+	if balance != nil {
+		balance.hooks.stop()
+	}
+}
+
+// PrivateStreamConnected 私有WebSocket是否已登录并发送订阅
+func (t *OkxTrader) PrivateStreamConnected() bool {
+	stream := t.privateStream(false)
+	return stream != nil && stream.isConnected()
+}
@@ -0,0 +1,176 @@
+package trader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+func TestValidateStopOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		takeProfit bool
+		stop       tradeReq.StopOrder
+		wantErr    bool
+	}{
+		{"止损", false, tradeReq.StopOrder{SlTriggerPx: 48000, SlOrdPx: -1, SlTriggerPxType: "mark"}, false},
+		{"止盈", true, tradeReq.StopOrder{TpTriggerPx: 55000, TpOrdPx: -1, TpTriggerPxType: "last"}, false},
+		// 止损单带止盈字段、止盈单带止损字段
+		{"止损带止盈触发价", false, tradeReq.StopOrder{SlTriggerPx: 48000, SlOrdPx: -1, SlTriggerPxType: "mark", TpTriggerPx: 55000}, true},
+		{"止损带止盈触发价类型", false, tradeReq.StopOrder{SlTriggerPx: 48000, SlOrdPx: -1, SlTriggerPxType: "mark", TpTriggerPxType: "mark"}, true},
+		{"止盈带止损委托价", true, tradeReq.StopOrder{TpTriggerPx: 55000, TpOrdPx: -1, TpTriggerPxType: "last", SlOrdPx: -1}, true},
+		{"止盈带止损触发价类型", true, tradeReq.StopOrder{TpTriggerPx: 55000, TpOrdPx: -1, TpTriggerPxType: "last", SlTriggerPxType: "last"}, true},
+		// 只设置了另一侧的字段
+		{"止损只有止盈字段", false, tradeReq.StopOrder{TpTriggerPx: 55000, TpOrdPx: -1, TpTriggerPxType: "last"}, true},
+		{"止盈只有止损字段", true, tradeReq.StopOrder{SlTriggerPx: 48000, SlOrdPx: -1, SlTriggerPxType: "mark"}, true},
+		// 触发价或触发价类型缺失、无效
+		{"止损触发价为0", false, tradeReq.StopOrder{SlOrdPx: -1, SlTriggerPxType: "mark"}, true},
+		{"止盈触发价为负", true, tradeReq.StopOrder{TpTriggerPx: -1, TpOrdPx: -1, TpTriggerPxType: "last"}, true},
+		{"止损缺少触发价类型", false, tradeReq.StopOrder{SlTriggerPx: 48000, SlOrdPx: -1}, true},
+		{"止盈触发价类型无效", true, tradeReq.StopOrder{TpTriggerPx: 55000, TpOrdPx: -1, TpTriggerPxType: "bid"}, true},
+	} {
+		if err := validateStopOrder(tc.takeProfit, tc.stop); (err != nil) != tc.wantErr {
+			t.Errorf("%s: validateStopOrder = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+// stopFieldKeys 请求体中设置了的止盈(tp)与止损(sl)字段
+func stopFieldKeys(t *testing.T, obj map[string]interface{}) (tp, sl []string) {
+	t.Helper()
+	for key := range obj {
+		switch {
+		case strings.HasPrefix(key, "tp"):
+			tp = append(tp, key)
+		case strings.HasPrefix(key, "sl"):
+			sl = append(sl, key)
+		}
+	}
+	return tp, sl
+}
+
+func decodeAlgoBody(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &obj); err != nil {
+		t.Fatalf("无法解析条件单请求 %s: %v", body, err)
+	}
+	return obj
+}
+
+// checkAlgoSides 止损单只有sl*字段，止盈单只有tp*字段，触发后市价成交
+func checkAlgoSides(t *testing.T, reqs []fakeOkxRequest, wantSL, wantTP string) {
+	t.Helper()
+	if len(reqs) != 2 {
+		t.Fatalf("条件单请求数 = %d, want 2（止损、止盈各一）", len(reqs))
+	}
+	var sawSL, sawTP bool
+	for _, req := range reqs {
+		obj := decodeAlgoBody(t, req.Body)
+		tp, sl := stopFieldKeys(t, obj)
+		switch {
+		case len(sl) > 0 && len(tp) == 0:
+			sawSL = true
+			if obj["slTriggerPx"] != wantSL || obj["slOrdPx"] != "-1" || obj["slTriggerPxType"] == nil {
+				t.Errorf("止损单字段 = %v", obj)
+			}
+		case len(tp) > 0 && len(sl) == 0:
+			sawTP = true
+			if obj["tpTriggerPx"] != wantTP || obj["tpOrdPx"] != "-1" || obj["tpTriggerPxType"] == nil {
+				t.Errorf("止盈单字段 = %v", obj)
+			}
+		default:
+			t.Errorf("条件单同时或都未设置止盈止损字段 (tp %v, sl %v): %s", tp, sl, req.Body)
+		}
+		if obj["ordType"] != "conditional" {
+			t.Errorf("ordType = %v, want conditional", obj["ordType"])
+		}
+	}
+	if !sawSL || !sawTP {
+		t.Errorf("止损单 %v 止盈单 %v, want 各一", sawSL, sawTP)
+	}
+}
+
+// TestOkxProtectiveOrderFields 单独设置的止损单只带止损字段，止盈单只带止盈字段
+func TestOkxProtectiveOrderFields(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("POST /api/v5/trade/order-algo", `{"algoId":"a1","sCode":"0","sMsg":""}`)
+	tr := f.trader(t)
+
+	if err := tr.SetStopLoss("BTCUSDT", PositionLong, 0.1, 48000); err != nil {
+		t.Fatalf("SetStopLoss: %v", err)
+	}
+	if err := tr.SetTakeProfit("BTCUSDT", PositionLong, 0.1, 55000); err != nil {
+		t.Fatalf("SetTakeProfit: %v", err)
+	}
+	checkAlgoSides(t, f.requestsTo("POST /api/v5/trade/order-algo"), "48000", "55000")
+}
+
+// newBracketOkx 开仓单成交，订单详情中的附带止盈止损由attached给出
+func newBracketOkx(t *testing.T, attached string) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.reply("POST /api/v5/trade/order", `{"ordId":"1","clOrdId":"","sCode":"0","sMsg":""}`)
+	f.reply("POST /api/v5/trade/order-algo", `{"algoId":"a1","sCode":"0","sMsg":""}`)
+	filled := okxTestFilledOrder("1", "", "buy", "long", "10", "50000")
+	if attached != "" {
+		filled = strings.TrimSuffix(filled, "}") + `,"attachAlgoOrds":[` + attached + `]}`
+	}
+	f.reply("GET /api/v5/trade/order", filled)
+	return f
+}
+
+// TestOkxBracketAttachAlgoFields 开仓单附带的止盈止损在同一个attachAlgoOrds对象中，开仓单本身不带止盈止损字段
+func TestOkxBracketAttachAlgoFields(t *testing.T) {
+	f := newBracketOkx(t, `{"attachAlgoId":"att1","tpTriggerPx":"55000","slTriggerPx":"48000","failCode":"","failReason":""}`)
+	result, err := f.trader(t).OpenLongBracket("BTCUSDT", 0.1, 10, 48000, 55000)
+	if err != nil {
+		t.Fatalf("OpenLongBracket: %v", err)
+	}
+	if result.AttachAlgoID != "att1" {
+		t.Errorf("AttachAlgoID = %q, want att1", result.AttachAlgoID)
+	}
+
+	reqs := f.requestsTo("POST /api/v5/trade/order")
+	if len(reqs) != 1 {
+		t.Fatalf("下单请求数 = %d, want 1", len(reqs))
+	}
+	order := decodeAlgoBody(t, reqs[0].Body)
+	if tp, sl := stopFieldKeys(t, order); len(tp) > 0 || len(sl) > 0 {
+		t.Errorf("开仓单本身不应带止盈止损字段 (tp %v, sl %v)", tp, sl)
+	}
+	algos, _ := order["attachAlgoOrds"].([]interface{})
+	if len(algos) != 1 {
+		t.Fatalf("attachAlgoOrds = %v, want 1个", order["attachAlgoOrds"])
+	}
+	algo := algos[0].(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"tpTriggerPx": "55000", "tpOrdPx": "-1",
+		"slTriggerPx": "48000", "slOrdPx": "-1",
+	} {
+		if algo[key] != want {
+			t.Errorf("attachAlgoOrds[0].%s = %v, want %v", key, algo[key], want)
+		}
+	}
+	if algo["tpTriggerPxType"] == nil || algo["tpTriggerPxType"] != algo["slTriggerPxType"] {
+		t.Errorf("触发价类型 tp=%v sl=%v, want 相同且已设置", algo["tpTriggerPxType"], algo["slTriggerPxType"])
+	}
+	if n := f.calls("POST /api/v5/trade/order-algo"); n != 0 {
+		t.Errorf("附带确认成功后不应补挂条件单，实际 %d 次", n)
+	}
+}
+
+// TestOkxBracketFallbackSplitsSides 附带止盈止损被拒绝时分别补挂，止损单与止盈单各自只带本侧字段
+func TestOkxBracketFallbackSplitsSides(t *testing.T) {
+	f := newBracketOkx(t, `{"attachAlgoId":"att1","tpTriggerPx":"55000","slTriggerPx":"48000","failCode":"1","failReason":"rejected"}`)
+	result, err := f.trader(t).OpenLongBracket("BTCUSDT", 0.1, 10, 48000, 55000)
+	if err != nil {
+		t.Fatalf("OpenLongBracket: %v", err)
+	}
+	if result.AttachAlgoID != "" || result.StopLossID != "a1" || result.TakeProfitID != "a1" {
+		t.Errorf("BracketResult = %+v, want 补挂的止损止盈ID", result)
+	}
+	checkAlgoSides(t, f.requestsTo("POST /api/v5/trade/order-algo"), "48000", "55000")
+}
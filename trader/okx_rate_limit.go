@@ -0,0 +1,311 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// OKX按接口分组限速（同一API Key），超过后返回HTTP 429 / 50011，之后的请求全部失败
+// 客户端按接口族（账户、交易、条件单、公共行情、公共数据）各用一个令牌桶，限额取该族中最严格接口的文档值，
+// 请求在发出前等待令牌，因此多个goroutine同时查询持仓、交易规则与下单时不会触发交易所限速；
+// 令牌不足时平仓、止损止盈与撤单先于排队中的查询和开仓发出
+
+// OkxRateFamily 限速的接口族
+type OkxRateFamily string
+
+const (
+	OkxRateAccount    OkxRateFamily = "account"     // 余额、持仓、账户配置、杠杆、保证金
+	OkxRateTrade      OkxRateFamily = "trade"       // 下单、撤单、改单与订单查询
+	OkxRateAlgo       OkxRateFamily = "algo"        // 条件单（止损止盈）的下单、撤单、改单与查询
+	OkxRatePublic     OkxRateFamily = "public"      // 交易规则、行情、指数价格
+	OkxRatePublicData OkxRateFamily = "public-data" // 标记价格、仓位档位
+)
+
+// OkxRateLimit 一个接口族的限额：Per时间内最多Requests个请求（Requests<=0表示不限速）
+type OkxRateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// OkxRateLimits 各接口族的限额
+type OkxRateLimits struct {
+	Account    OkxRateLimit
+	Trade      OkxRateLimit
+	Algo       OkxRateLimit
+	Public     OkxRateLimit
+	PublicData OkxRateLimit
+}
+
+// DefaultOkxRateLimits OKX文档中的限额（账户类10次/2秒，普通订单60次/2秒，条件单20次/2秒，
+// 公共行情20次/2秒，标记价格与仓位档位10次/2秒）
+func DefaultOkxRateLimits() OkxRateLimits {
+	return OkxRateLimits{
+		Account:    OkxRateLimit{Requests: 10, Per: 2 * time.Second},
+		Trade:      OkxRateLimit{Requests: 60, Per: 2 * time.Second},
+		Algo:       OkxRateLimit{Requests: 20, Per: 2 * time.Second},
+		Public:     OkxRateLimit{Requests: 20, Per: 2 * time.Second},
+		PublicData: OkxRateLimit{Requests: 10, Per: 2 * time.Second},
+	}
+}
+
+// okxOpFamilies 不按类别归族的接口（其他私有接口归入账户族，公共查询归入公共行情族）
+var okxOpFamilies = map[string]OkxRateFamily{
+	"PlaceOrder":          OkxRateTrade,
+	"CancelOrder":         OkxRateTrade,
+	"CancelBatchOrders":   OkxRateTrade,
+	"AmendOrder":          OkxRateTrade,
+	"GetOrderDetail":      OkxRateTrade,
+	"GetOrderList":        OkxRateTrade,
+	"PlaceAlgoOrder":      OkxRateAlgo,
+	"CancelAlgoOrder":     OkxRateAlgo,
+	"AmendAlgoOrder":      OkxRateAlgo,
+	"GetAlgoOrderList":    OkxRateAlgo,
+	"GetAlgoOrderHistory": OkxRateAlgo,
+	"GetMarkPrice":        OkxRatePublicData,
+	"GetPositionTiers":    OkxRatePublicData,
+}
+
+// okxRateFamilies 统计与配置中接口族的顺序
+var okxRateFamilies = []OkxRateFamily{OkxRateAccount, OkxRateTrade, OkxRateAlgo, OkxRatePublic, OkxRatePublicData}
+
+// okxRateFamilyOf 接口所属的接口族
+func okxRateFamilyOf(op string, class OperationClass) OkxRateFamily {
+	if family, ok := okxOpFamilies[op]; ok {
+		return family
+	}
+	switch {
+	case class == OpPublicRead:
+		return OkxRatePublic
+	default:
+		return OkxRateAccount
+	}
+}
+
+// ErrRateLimited 客户端限速：调用方要求不等待（WithRateLimitNoWait）且没有可用令牌，请求没有发出
+var ErrRateLimited = newSentinelError(ErrCodeRateLimited, "err_rate_limited")
+
+// RateLimitedError 带接口信息的限速错误
+type RateLimitedError struct {
+	Op         string
+	Family     OkxRateFamily
+	RetryAfter time.Duration // 预计多久后有可用令牌
+}
+
+func (e *RateLimitedError) Error() string {
+	return msg("err_rate_limited_detail", e.Op, e.Family, e.RetryAfter)
+}
+
+func (e *RateLimitedError) ErrorCode() ErrorCode {
+	return ErrCodeRateLimited
+}
+
+// Is 使 errors.Is(err, ErrRateLimited) 成立
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+type rateLimitNoWaitKey struct{}
+
+// WithRateLimitNoWait 返回的ctx用于调用时，没有可用令牌直接返回 ErrRateLimited 而不是等待
+// （杠杆冷却期内同样不等待，返回 LeverageCooldownError）
+func WithRateLimitNoWait(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rateLimitNoWaitKey{}, true)
+}
+
+func rateLimitNoWait(ctx context.Context) bool {
+	noWait, _ := ctx.Value(rateLimitNoWaitKey{}).(bool)
+	return noWait
+}
+
+// RateLimitStat 一个接口族的限速统计
+type RateLimitStat struct {
+	Family    OkxRateFamily `json:"family"`
+	Allowed   uint64        `json:"allowed"`   // 立即放行的请求数
+	Throttled uint64        `json:"throttled"` // 等待令牌后放行的请求数
+	Rejected  uint64        `json:"rejected"`  // 不等待而直接返回ErrRateLimited、或等待中ctx结束的请求数
+	Waited    time.Duration `json:"waited"`    // 累计等待时长
+}
+
+// RateLimitReporter 可选接口：返回客户端限速的统计
+type RateLimitReporter interface {
+	RateLimitStats() []RateLimitStat
+}
+
+// okxTokenBucket 一个接口族的令牌桶：令牌不足时请求按优先级排队（见RequestScheduler），
+// 平仓、止损止盈与撤单越过排队中的查询与开仓，同优先级先到先得
+type okxTokenBucket struct {
+	scheduler *RequestScheduler
+	clock     clock.Clock
+
+	mu   sync.Mutex
+	stat RateLimitStat
+}
+
+func newOkxTokenBucket(family OkxRateFamily, limit OkxRateLimit, clk clock.Clock) *okxTokenBucket {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return nil
+	}
+	return &okxTokenBucket{
+		scheduler: NewRequestScheduler(RequestSchedulerConfig{
+			RatePerSecond: float64(limit.Requests) / limit.Per.Seconds(),
+			Burst:         limit.Requests,
+		}, clk),
+		clock: clk,
+		stat:  RateLimitStat{Family: family},
+	}
+}
+
+// acquire 按优先级获取一个令牌，必要时排队等待（桶为nil时立即返回）
+func (b *okxTokenBucket) acquire(ctx context.Context, op string, class OperationClass, priority RequestPriority) error {
+	if b == nil {
+		return nil
+	}
+	if rateLimitNoWait(ctx) {
+		ok, wait := b.scheduler.tryAcquire(priority)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if ok {
+			b.stat.Allowed++
+			return nil
+		}
+		b.stat.Rejected++
+		return &RateLimitedError{Op: op, Family: b.stat.Family, RetryAfter: wait}
+	}
+
+	start := b.clock.Now()
+	queued, err := b.scheduler.acquire(ctx, priority)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case err != nil:
+		b.stat.Rejected++
+		return &CanceledError{Op: op, Class: class, Err: err}
+	case queued:
+		b.stat.Throttled++
+		b.stat.Waited += b.clock.Since(start)
+	default:
+		b.stat.Allowed++
+	}
+	return nil
+}
+
+func (b *okxTokenBucket) snapshot() RateLimitStat {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stat
+}
+
+// okxCriticalOps 按最高优先级限速的接口：撤单与止损止盈条件单（平仓单见okxOrderPriority）
+var okxCriticalOps = map[string]bool{
+	"CancelOrder":     true,
+	"CancelAlgoOrder": true,
+	"PlaceAlgoOrder":  true,
+	"AmendAlgoOrder":  true,
+}
+
+type requestPriorityKey struct{}
+
+// withRequestPriority 指定ctx中请求的限速优先级（覆盖按接口推断的优先级）
+func withRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return context.WithValue(ctx, requestPriorityKey{}, priority)
+}
+
+// okxRequestPriority 请求的限速优先级：ctx指定时使用指定值，否则撤单与条件单最高，
+// 其他变更类（开仓、杠杆、保证金）次之，查询最低
+func okxRequestPriority(ctx context.Context, op string, class OperationClass) RequestPriority {
+	if p, ok := ctx.Value(requestPriorityKey{}).(RequestPriority); ok {
+		return p
+	}
+	switch {
+	case okxCriticalOps[op]:
+		return PriorityCritical
+	case class == OpMutation:
+		return PriorityEntry
+	default:
+		return PriorityRead
+	}
+}
+
+// okxRateLimiter 各接口族的令牌桶（为nil或某族为nil时不限速）
+type okxRateLimiter struct {
+	buckets map[OkxRateFamily]*okxTokenBucket
+}
+
+func newOkxRateLimiter(limits OkxRateLimits, clk clock.Clock) *okxRateLimiter {
+	l := &okxRateLimiter{buckets: make(map[OkxRateFamily]*okxTokenBucket)}
+	for _, family := range okxRateFamilies {
+		if b := newOkxTokenBucket(family, limits.of(family), clk); b != nil {
+			l.buckets[family] = b
+		}
+	}
+	return l
+}
+
+// of 接口族的限额
+func (l OkxRateLimits) of(family OkxRateFamily) OkxRateLimit {
+	switch family {
+	case OkxRateAccount:
+		return l.Account
+	case OkxRateTrade:
+		return l.Trade
+	case OkxRateAlgo:
+		return l.Algo
+	case OkxRatePublic:
+		return l.Public
+	case OkxRatePublicData:
+		return l.PublicData
+	}
+	return OkxRateLimit{}
+}
+
+func (l *okxRateLimiter) acquire(ctx context.Context, op string, class OperationClass) error {
+	if l == nil {
+		return nil
+	}
+	return l.buckets[okxRateFamilyOf(op, class)].acquire(ctx, op, class, okxRequestPriority(ctx, op, class))
+}
+
+// okxCall OkxTrader的REST调用入口：先按接口族获取限速令牌，再按类别超时执行fn，并记录耗时指标
+// 所有REST请求都应通过这里发出，新增的接口自动受限速保护
+func okxCall[T any](ctx context.Context, t *OkxTrader, class OperationClass, op string, fn func() (T, error)) (T, error) {
+	if err := t.rateLimiter.acquire(ctx, op, class); err != nil {
+		var zero T
+		return zero, err
+	}
+	start := t.clock.Now()
+	result, err := callWithContext(ctx, t.timeouts, class, op, fn)
+	t.metrics.rest(op, t.clock.Since(start), err)
+	t.health.record(t.clock.Now(), op, err)
+	return result, err
+}
+
+// WithRateLimits 设置客户端限速（默认DefaultOkxRateLimits，某族Requests<=0表示该族不限速）
+func WithRateLimits(limits OkxRateLimits) OkxOption {
+	return func(t *OkxTrader) error {
+		for _, family := range okxRateFamilies {
+			if l := limits.of(family); l.Requests > 0 && l.Per <= 0 {
+				return fmt.Errorf("限速周期必须大于0: %+v", l)
+			}
+		}
+		t.rateLimits = limits
+		return nil
+	}
+}
+
+// RateLimitStats 返回各接口族的限速统计
+func (t *OkxTrader) RateLimitStats() []RateLimitStat {
+	if t.rateLimiter == nil {
+		return nil
+	}
+	var stats []RateLimitStat
+	for _, family := range okxRateFamilies {
+		if b := t.rateLimiter.buckets[family]; b != nil {
+			stats = append(stats, b.snapshot())
+		}
+	}
+	return stats
+}
@@ -0,0 +1,280 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+
+	"nofx/testutil"
+)
+
+// rateTestStart 限速测试假时钟的起始时间
+var rateTestStart = time.Unix(1700000000, 0)
+
+// newTestBucket 每period一个令牌、突发1个的令牌桶，时间由返回的假时钟推进
+func newTestBucket(period time.Duration) (*okxTokenBucket, *testutil.FakeClock) {
+	fc := testutil.NewFakeClock(rateTestStart)
+	return newOkxTokenBucket(OkxRateAccount, OkxRateLimit{Requests: 1, Per: period}, fc), fc
+}
+
+// advanceTokens 等待排队的请求开始计时后推进一个令牌周期，共n次
+func advanceTokens(fc *testutil.FakeClock, period time.Duration, n int) {
+	for i := 0; i < n; i++ {
+		fc.BlockUntil(1)
+		fc.Advance(period)
+	}
+}
+
+// TestOkxBucketCriticalBeforeQueuedReads 令牌耗尽时，后到的平仓先于排队中的查询获得令牌
+func TestOkxBucketCriticalBeforeQueuedReads(t *testing.T) {
+	const period = 100 * time.Millisecond
+	b, fc := newTestBucket(period)
+	if err := b.acquire(t.Context(), "GetBalance", OpPrivateRead, PriorityRead); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	acquire := func(op string, class OperationClass, priority RequestPriority) {
+		defer wg.Done()
+		if err := b.acquire(t.Context(), op, class, priority); err != nil {
+			t.Errorf("%s: %v", op, err)
+			return
+		}
+		mu.Lock()
+		order = append(order, op)
+		mu.Unlock()
+	}
+	const reads = 3
+	for i := 0; i < reads; i++ {
+		wg.Add(1)
+		go acquire("GetPositions", OpPrivateRead, PriorityRead)
+	}
+	waitPending(t, b.scheduler, PriorityRead, reads)
+	wg.Add(2)
+	go acquire("SetLeverage", OpMutation, PriorityEntry)
+	waitPending(t, b.scheduler, PriorityEntry, 1)
+	go acquire("PlaceOrder", OpMutation, PriorityCritical)
+	waitPending(t, b.scheduler, PriorityCritical, 1)
+	advanceTokens(fc, period, reads+2)
+	wg.Wait()
+
+	if len(order) != reads+2 || order[0] != "PlaceOrder" || order[1] != "SetLeverage" {
+		t.Fatalf("获得令牌的顺序 = %v, want 平仓、开仓级请求在排队的查询之前", order)
+	}
+}
+
+// TestOkxBucketStats 立即放行、等待后放行、不等待被拒绝与等待中取消分别计数
+func TestOkxBucketStats(t *testing.T) {
+	const period = 50 * time.Millisecond
+	b, fc := newTestBucket(period)
+	ctx := t.Context()
+
+	if err := b.acquire(ctx, "GetBalance", OpPrivateRead, PriorityRead); err != nil {
+		t.Fatal(err)
+	}
+	if stat := b.snapshot(); stat.Allowed != 1 || stat.Throttled != 0 || stat.Rejected != 0 {
+		t.Fatalf("立即放行后 = %+v", stat)
+	}
+
+	// 不等待：没有令牌时返回RateLimitedError，带预计等待时间
+	err := b.acquire(WithRateLimitNoWait(ctx), "GetPositions", OpPrivateRead, PriorityRead)
+	var rlErr *RateLimitedError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rlErr) || rlErr.RetryAfter <= 0 || rlErr.Family != OkxRateAccount {
+		t.Fatalf("不等待 acquire = %#v, want RateLimitedError", err)
+	}
+	if stat := b.snapshot(); stat.Rejected != 1 {
+		t.Fatalf("不等待被拒绝后 = %+v", stat)
+	}
+
+	// 等待令牌后放行：计入Throttled与Waited
+	go advanceTokens(fc, period, 1)
+	if err := b.acquire(ctx, "GetPositions", OpPrivateRead, PriorityRead); err != nil {
+		t.Fatal(err)
+	}
+	stat := b.snapshot()
+	if stat.Throttled != 1 || stat.Waited != period {
+		t.Fatalf("等待后放行 = %+v", stat)
+	}
+
+	// 等待中取消：计入Rejected，返回CanceledError，不消耗令牌
+	waitCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- b.acquire(waitCtx, "GetConfig", OpPrivateRead, PriorityRead) }()
+	waitPending(t, b.scheduler, PriorityRead, 1)
+	cancel()
+	err = <-done
+	var cErr *CanceledError
+	if !errors.As(err, &cErr) || !errors.Is(cErr.Err, context.Canceled) || cErr.Op != "GetConfig" {
+		t.Fatalf("取消后 acquire = %#v, want CanceledError", err)
+	}
+	if stat := b.snapshot(); stat.Allowed != 1 || stat.Throttled != 1 || stat.Rejected != 2 {
+		t.Fatalf("取消后 = %+v, want Allowed 1 Throttled 1 Rejected 2", stat)
+	}
+	if pending := b.scheduler.Pending(); len(pending) != 0 {
+		t.Errorf("取消后仍有排队请求: %v", pending)
+	}
+}
+
+// TestOkxBucketNoWaitRespectsQueue 不等待的请求不越过排队中同级或更高优先级的请求，但高优先级可以越过低优先级
+func TestOkxBucketNoWaitRespectsQueue(t *testing.T) {
+	const period = 200 * time.Millisecond
+	b, fc := newTestBucket(period)
+	if err := b.acquire(t.Context(), "GetBalance", OpPrivateRead, PriorityRead); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.acquire(t.Context(), "SetLeverage", OpMutation, PriorityEntry) }()
+	waitPending(t, b.scheduler, PriorityEntry, 1)
+
+	// 令牌补足前：同级请求需排在队列之后
+	if err := b.acquire(WithRateLimitNoWait(t.Context()), "SetLeverage", OpMutation, PriorityEntry); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("排队中有同级请求时不等待 acquire = %v, want ErrRateLimited", err)
+	}
+	advanceTokens(fc, period, 1)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOkxRequestPriority 按接口与下单方向推断的限速优先级
+func TestOkxRequestPriority(t *testing.T) {
+	for _, tc := range []struct {
+		op    string
+		class OperationClass
+		want  RequestPriority
+	}{
+		{"GetBalance", OpPrivateRead, PriorityRead},
+		{"GetInstruments", OpPublicRead, PriorityRead},
+		{"GetOrderDetail", OpPrivateRead, PriorityRead},
+		{"SetLeverage", OpMutation, PriorityEntry},
+		{"PlaceOrder", OpMutation, PriorityEntry},
+		{"CancelOrder", OpMutation, PriorityCritical},
+		{"CancelAlgoOrder", OpMutation, PriorityCritical},
+		{"PlaceAlgoOrder", OpMutation, PriorityCritical},
+		{"AmendAlgoOrder", OpMutation, PriorityCritical},
+	} {
+		if got := okxRequestPriority(context.Background(), tc.op, tc.class); got != tc.want {
+			t.Errorf("okxRequestPriority(%s) = %s, want %s", tc.op, got, tc.want)
+		}
+	}
+	// ctx指定的优先级覆盖推断结果
+	ctx := withRequestPriority(context.Background(), PriorityCritical)
+	if got := okxRequestPriority(ctx, "PlaceOrder", OpMutation); got != PriorityCritical {
+		t.Errorf("ctx指定优先级 = %s, want critical", got)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  tradeReq.PlaceOrder
+		want RequestPriority
+	}{
+		{"open long", tradeReq.PlaceOrder{Side: okx.OrderBuy, PosSide: okx.PositionLongSide}, PriorityEntry},
+		{"open short", tradeReq.PlaceOrder{Side: okx.OrderSell, PosSide: okx.PositionShortSide}, PriorityEntry},
+		{"close long", tradeReq.PlaceOrder{Side: okx.OrderSell, PosSide: okx.PositionLongSide}, PriorityCritical},
+		{"close short", tradeReq.PlaceOrder{Side: okx.OrderBuy, PosSide: okx.PositionShortSide}, PriorityCritical},
+		{"net open", tradeReq.PlaceOrder{Side: okx.OrderBuy, PosSide: okx.PositionNetSide}, PriorityEntry},
+		{"net reduce only", tradeReq.PlaceOrder{Side: okx.OrderSell, PosSide: okx.PositionNetSide, ReduceOnly: true}, PriorityCritical},
+	} {
+		if got := okxOrderPriority(tc.req); got != tc.want {
+			t.Errorf("okxOrderPriority(%s) = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestOkxRateLimitStatsThroughTrader 交易器的REST调用经过令牌桶，RateLimitStats按接口族报告计数
+func TestOkxRateLimitStatsThroughTrader(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/balance", okxTestBalance("1000", "1000", "0"))
+	const period = 50 * time.Millisecond
+	tr := f.trader(t, WithRateLimits(OkxRateLimits{
+		Account: OkxRateLimit{Requests: 1, Per: period},
+	}))
+	fc := testutil.NewFakeClock(rateTestStart)
+	tr.SetClock(fc)
+
+	go advanceTokens(fc, period, 2)
+	for i := 0; i < 3; i++ {
+		if _, err := tr.RefreshBalance(); err != nil {
+			t.Fatalf("RefreshBalance: %v", err)
+		}
+	}
+	stats := tr.RateLimitStats()
+	if len(stats) != 1 || stats[0].Family != OkxRateAccount {
+		t.Fatalf("RateLimitStats = %+v, want 只有账户族", stats)
+	}
+	if stats[0].Allowed != 1 || stats[0].Throttled != 2 || stats[0].Rejected != 0 || stats[0].Waited != 2*period {
+		t.Errorf("账户族统计 = %+v, want Allowed 1 Throttled 2", stats[0])
+	}
+	if n := f.calls("GET /api/v5/account/balance"); n != 3 {
+		t.Errorf("余额请求 = %d, want 3", n)
+	}
+}
+
+// TestOkxAlgoBurstDefaultLimits 默认限额下条件单与标记价格各用自己的桶：
+// 20个条件单后第21个等待令牌，普通下单不受影响；标记价格与仓位档位共用10次/2秒
+func TestOkxAlgoBurstDefaultLimits(t *testing.T) {
+	fc := testutil.NewFakeClock(rateTestStart)
+	l := newOkxRateLimiter(DefaultOkxRateLimits(), fc)
+	noWait := WithRateLimitNoWait(t.Context())
+
+	burst := func(op string, class OperationClass, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if err := l.acquire(noWait, op, class); err != nil {
+				t.Fatalf("%s 第%d次: %v", op, i+1, err)
+			}
+		}
+	}
+	wantLimited := func(op string, class OperationClass, family OkxRateFamily) {
+		t.Helper()
+		var rlErr *RateLimitedError
+		if err := l.acquire(noWait, op, class); !errors.As(err, &rlErr) || rlErr.Family != family {
+			t.Fatalf("%s = %v, want %s 族限速", op, err, family)
+		}
+	}
+
+	burst("PlaceAlgoOrder", OpMutation, 10)
+	burst("AmendAlgoOrder", OpMutation, 5)
+	burst("GetAlgoOrderList", OpPrivateRead, 5)
+	wantLimited("PlaceAlgoOrder", OpMutation, OkxRateAlgo)
+	burst("PlaceOrder", OpMutation, 30)
+
+	burst("GetMarkPrice", OpPublicRead, 5)
+	burst("GetPositionTiers", OpPublicRead, 5)
+	wantLimited("GetMarkPrice", OpPublicRead, OkxRatePublicData)
+	burst("GetInstruments", OpPublicRead, 20)
+
+	// 等待令牌：条件单每秒补充10个，假时间推进100ms后放行
+	done := make(chan error, 1)
+	go func() { done <- l.acquire(t.Context(), "PlaceAlgoOrder", OpMutation) }()
+	waitPending(t, l.buckets[OkxRateAlgo].scheduler, PriorityCritical, 1)
+	fc.BlockUntil(1)
+	fc.Advance(50 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("令牌补足前放行: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	fc.Advance(50 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	stats := map[OkxRateFamily]RateLimitStat{}
+	for _, family := range okxRateFamilies {
+		stats[family] = l.buckets[family].snapshot()
+	}
+	if s := stats[OkxRateAlgo]; s.Allowed != 20 || s.Rejected != 1 || s.Throttled != 1 || s.Waited != 100*time.Millisecond {
+		t.Errorf("条件单统计 = %+v", s)
+	}
+	if s := stats[OkxRateTrade]; s.Allowed != 30 || s.Rejected != 0 {
+		t.Errorf("交易族统计 = %+v", s)
+	}
+}
@@ -0,0 +1,197 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Benjmmi/okx/api"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+)
+
+// OkxCredentials OKX API凭证
+type OkxCredentials struct {
+	APIKey     string
+	SecretKey  string
+	Passphrase string
+}
+
+// OkxCredentialsProvider 重建客户端时读取最新凭证（如从配置数据库读取，支持轮换密钥）
+type OkxCredentialsProvider func() (OkxCredentials, error)
+
+// OkxClientRebuildEvent 客户端重建事件
+type OkxClientRebuildEvent struct {
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason"`  // 触发重建的认证错误
+	Attempt int       `json:"attempt"` // 连续重建次数（成功调用后清零）
+	Err     string    `json:"error,omitempty"`
+}
+
+const (
+	okxAuthErrorThreshold = 3                // 连续认证错误达到该次数后重建客户端
+	okxRebuildMinBackoff  = 30 * time.Second // 两次重建之间的最小间隔，连续重建时翻倍
+	okxRebuildMaxBackoff  = 30 * time.Minute
+)
+
+// okxAuthErrorCodes OKX认证类错误码（签名、时间戳、API Key、Passphrase等）
+var okxAuthErrorCodes = map[int]bool{
+	50100: true, 50101: true, 50102: true, 50103: true, 50104: true, 50105: true,
+	50106: true, 50107: true, 50111: true, 50112: true, 50113: true, 50114: true,
+}
+
+// isOkxAuthError 是否为认证类错误
+func isOkxAuthError(err error) bool {
+	var okxErr *OkxError
+	return errors.As(err, &okxErr) && (okxAuthErrorCodes[okxErr.Code] || okxAuthErrorCodes[int(okxErr.SCode)])
+}
+
+// okxAuthState 认证错误计数与重建退避
+type okxAuthState struct {
+	consecutive int       // 连续认证错误次数
+	rebuilds    int       // 连续重建次数
+	nextRebuild time.Time // 下次允许重建的时间
+	rebuilding  bool
+	onRebuild   func(OkxClientRebuildEvent)
+}
+
+// api 返回当前的OKX客户端（重建时会被替换）
+func (t *OkxTrader) api() *api.Client {
+	t.clientMu.RLock()
+	defer t.clientMu.RUnlock()
+	return t.client
+}
+
+// newOkxClient 按endpoint创建OKX客户端，返回的cancel用于关闭其WebSocket连接，now用于计算请求的expTime
+func newOkxClient(creds OkxCredentials, endpoint okxEndpoint, now func() time.Time) (*api.Client, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	restURL, wsPrivate, wsPublic := endpoint.urls()
+	client, err := api.NewClientWithUrl(ctx, creds.APIKey, creds.SecretKey, creds.Passphrase, endpoint.destination(), restURL, wsPrivate, wsPublic)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if httpClient := endpoint.httpClient(now); httpClient != nil {
+		client.Rest.Client = httpClient
+	}
+	return client, cancel, nil
+}
+
+// ErrInvalidCredentials API Key、Secret或Passphrase无效（使用 errors.Is(err, ErrInvalidCredentials) 判断）
+var ErrInvalidCredentials = newSentinelError(ErrCodeInvalidCredentials, "err_invalid_credentials")
+
+// ValidateCredentials 请求账户配置校验凭证，认证类错误返回 ErrInvalidCredentials，其他错误原样返回
+func (t *OkxTrader) ValidateCredentials() error {
+	resp, err := okxCall(context.Background(), t, OpPrivateRead, "GetConfig", func() (accountResp.GetConfig, error) {
+		return t.api().Rest.Account.GetConfig()
+	})
+	if err == nil {
+		err = okxCheck("GetConfig", resp.Basic, len(resp.Configs))
+	}
+	if isOkxAuthError(err) {
+		return fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if err != nil {
+		return fmt.Errorf("校验 OKX 凭证失败: %w", err)
+	}
+	return nil
+}
+
+// SetCredentialsProvider 设置重建客户端时读取凭证的方式（默认使用创建时的凭证）
+func (t *OkxTrader) SetCredentialsProvider(provider OkxCredentialsProvider) {
+	t.clientMu.Lock()
+	defer t.clientMu.Unlock()
+	t.credentials = provider
+}
+
+// OnClientRebuild 设置客户端重建后的回调
+func (t *OkxTrader) OnClientRebuild(fn func(OkxClientRebuildEvent)) {
+	t.clientMu.Lock()
+	defer t.clientMu.Unlock()
+	t.auth.onRebuild = fn
+}
+
+// ObserveCall 实现CallObserver：连续出现认证错误时重建客户端，其他结果清零计数
+func (t *OkxTrader) ObserveCall(method string, duration time.Duration, err error) {
+	if err != nil && !isOkxAuthError(err) {
+		return
+	}
+
+	t.clientMu.Lock()
+	if err == nil {
+		t.auth.consecutive = 0
+		t.auth.rebuilds = 0
+		t.clientMu.Unlock()
+		return
+	}
+	t.auth.consecutive++
+	if t.auth.consecutive < okxAuthErrorThreshold || t.auth.rebuilding || t.clock.Now().Before(t.auth.nextRebuild) {
+		t.clientMu.Unlock()
+		return
+	}
+	t.auth.rebuilding = true
+	t.auth.rebuilds++
+	attempt := t.auth.rebuilds
+	t.clientMu.Unlock()
+
+	reason := fmt.Sprintf("%s 连续 %d 次认证错误: %v", method, okxAuthErrorThreshold, err)
+	go t.rebuildClient(reason, attempt)
+}
+
+// rebuildClient 重新读取凭证并创建客户端，WebSocket交易通道使用新客户端重新登录
+// 缓存、交易规则与各币种状态都保存在OkxTrader上，不受影响
+func (t *OkxTrader) rebuildClient(reason string, attempt int) {
+	t.logger.Warn("OKX 正在重建客户端", "reason", reason, "attempt", attempt)
+	event := OkxClientRebuildEvent{Time: t.clock.Now(), Reason: reason, Attempt: attempt}
+
+	t.clientMu.RLock()
+	provider := t.credentials
+	t.clientMu.RUnlock()
+
+	creds, err := provider()
+	var client *api.Client
+	var cancel context.CancelFunc
+	if err == nil {
+		client, cancel, err = newOkxClient(creds, t.endpoint, t.now)
+	}
+
+	backoff := okxRebuildMinBackoff << (attempt - 1)
+	if backoff > okxRebuildMaxBackoff || backoff <= 0 {
+		backoff = okxRebuildMaxBackoff
+	}
+
+	t.clientMu.Lock()
+	if err == nil {
+		oldCancel := t.clientCancel
+		t.client, t.clientCancel = client, cancel
+		if oldCancel != nil {
+			oldCancel()
+		}
+	}
+	t.auth.consecutive = 0
+	t.auth.rebuilding = false
+	t.auth.nextRebuild = t.clock.Now().Add(backoff)
+	onRebuild := t.auth.onRebuild
+	t.clientMu.Unlock()
+
+	if err != nil {
+		event.Err = err.Error()
+		t.logger.Error("OKX 客户端重建失败", "retryAfter", backoff, "err", err)
+	} else {
+		t.reconnectWSTrade()
+		t.logger.Info("OKX 客户端已重建")
+	}
+	if onRebuild != nil {
+		onRebuild(event)
+	}
+}
+
+// reconnectWSTrade 使用当前客户端重新建立WebSocket交易通道（未启用时不处理）
+func (t *OkxTrader) reconnectWSTrade() {
+	client := t.api()
+	t.transportMu.Lock()
+	defer t.transportMu.Unlock()
+	if t.preferWS && client.Ws != nil {
+		t.wsTrade = newOkxWSTrade(client.Ws, t.logger)
+	}
+}
@@ -0,0 +1,92 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+// ClosePartial 实现ReduceOnlyCloser：市价部分平仓
+// reduceOnly为true时按交易所最新持仓校验，数量超过持仓返回 ErrReduceOnlyExceeded 而不下单；
+// 为false时数量超过持仓按全部平仓处理（同CloseLong/CloseShort）。两种情况下单都带reduceOnly
+func (t *OkxTrader) ClosePartial(ctx context.Context, symbol string, side PositionSide, quantity float64, reduceOnly bool) (*OrderResult, error) {
+	if reduceOnly {
+		if _, err := t.reduceOnlyPosition(ctx, symbol, side, quantity); err != nil {
+			return nil, err
+		}
+	}
+	return t.closePositionOrder(ctx, symbol, side, quantity)
+}
+
+// CloseLongLimit 实现ReduceOnlyCloser：下限价平多单，返回订单ID
+func (t *OkxTrader) CloseLongLimit(symbol string, quantity, price float64, tif TimeInForce, reduceOnly bool) (string, error) {
+	return t.placeLimitClose(context.Background(), symbol, PositionLong, quantity, price, tif, reduceOnly)
+}
+
+// CloseShortLimit 实现ReduceOnlyCloser：下限价平空单，返回订单ID
+func (t *OkxTrader) CloseShortLimit(symbol string, quantity, price float64, tif TimeInForce, reduceOnly bool) (string, error) {
+	return t.placeLimitClose(context.Background(), symbol, PositionShort, quantity, price, tif, reduceOnly)
+}
+
+// placeLimitClose 下限价平仓单，reduceOnly映射到OKX的reduceOnly字段，并在下单前校验数量不超过持仓
+// 不带reduceOnly的平仓单在单向持仓模式下成交量超过持仓时会反向开仓
+func (t *OkxTrader) placeLimitClose(ctx context.Context, symbol string, side PositionSide, quantity, price float64, tif TimeInForce, reduceOnly bool) (string, error) {
+	if !side.Valid() {
+		return "", fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	if price <= 0 {
+		return "", fmt.Errorf("限价必须大于0 (当前 %v)", price)
+	}
+	ordType, err := okxLimitOrderType(tif)
+	if err != nil {
+		return "", err
+	}
+	if reduceOnly {
+		if _, err := t.reduceOnlyPosition(ctx, symbol, side, quantity); err != nil {
+			return "", err
+		}
+	}
+
+	instID, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	px, err := t.roundPrice(symbol, price, limitRounding(side.CloseSide()))
+	if err != nil {
+		return "", err
+	}
+
+	orderSide, posSide := t.okxSides(ctx, side, true)
+	order, err := t.submitOrder(ctx, tradeReq.PlaceOrder{
+		InstID:     instID,
+		TdMode:     t.positionTradeMode(ctx, symbol, instID, side),
+		Side:       orderSide,
+		PosSide:    posSide,
+		OrdType:    ordType,
+		Sz:         contracts,
+		Px:         px,
+		ReduceOnly: reduceOnly,
+	})
+	if err != nil {
+		return "", fmt.Errorf("下限价平仓单失败: %w", err)
+	}
+	t.logger.Info("限价平仓单已提交", "symbol", symbol, "side", side, "size", quantity, "price", px,
+		"ordType", ordType, "reduceOnly", reduceOnly, "orderId", order.OrdID)
+	return order.OrdID, nil
+}
+
+// reduceOnlyPosition 强制刷新持仓并校验只减仓数量，持仓不存在时返回 ErrPositionNotFound
+func (t *OkxTrader) reduceOnlyPosition(ctx context.Context, symbol string, side PositionSide, quantity float64) (*Position, error) {
+	if _, err := t.positions(ctx, true); err != nil {
+		return nil, err
+	}
+	pos, err := t.GetPositionContext(ctx, symbol, side)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReduceOnly(pos, quantity); err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
@@ -0,0 +1,153 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+// newNetModeOkx 单向持仓账户，持有0.1 BTC（10张）多仓
+func newNetModeOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/config", `{"uid":"1","acctLv":"2","posMode":"net_mode"}`)
+	f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "net", "10", "50000", 10))
+	f.reply("POST /api/v5/trade/order", `{"ordId":"1","clOrdId":"","sCode":"0","sMsg":""}`)
+	f.reply("GET /api/v5/trade/order", okxTestFilledOrder("1", "", "sell", "net", "10", "50000"))
+	return f
+}
+
+// TestOkxReduceOnlyRejectsFlip 单向持仓下只减仓数量超过持仓（会反向开空）时拒绝，不下单
+func TestOkxReduceOnlyRejectsFlip(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		close func(tr *OkxTrader) error
+	}{
+		{"partial market", func(tr *OkxTrader) error {
+			_, err := tr.ClosePartial(t.Context(), "BTCUSDT", PositionLong, 0.2, true)
+			return err
+		}},
+		{"limit", func(tr *OkxTrader) error {
+			_, err := tr.CloseLongLimit("BTCUSDT", 0.2, 51000, TimeInForceGTC, true)
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newNetModeOkx(t)
+			err := tc.close(f.trader(t))
+			if !errors.Is(err, ErrReduceOnlyExceeded) {
+				t.Fatalf("err = %v, want ErrReduceOnlyExceeded", err)
+			}
+			var roErr *ReduceOnlyError
+			if !errors.As(err, &roErr) || roErr.Quantity != 0.2 || roErr.Position != 0.1 || roErr.Side != PositionLong {
+				t.Errorf("err = %#v, want ReduceOnlyError(long 0.2 > 0.1)", err)
+			}
+			if n := f.calls("POST /api/v5/trade/order"); n != 0 {
+				t.Errorf("超过持仓仍下单 %d 次", n)
+			}
+		})
+	}
+}
+
+// TestOkxReduceOnlyFlagSent 平仓单按布尔值提交reduceOnly，交易所据此拒绝会反向开仓的成交
+func TestOkxReduceOnlyFlagSent(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		close      func(tr *OkxTrader) error
+		wantSz     string
+		wantReduce interface{} // nil表示请求中不带reduceOnly
+	}{
+		{"full market close", func(tr *OkxTrader) error {
+			_, err := tr.CloseLong("BTCUSDT", 0)
+			return err
+		}, "10", true},
+		{"partial within position", func(tr *OkxTrader) error {
+			_, err := tr.ClosePartial(t.Context(), "BTCUSDT", PositionLong, 0.05, true)
+			return err
+		}, "5", true},
+		{"partial exactly position", func(tr *OkxTrader) error {
+			_, err := tr.ClosePartial(t.Context(), "BTCUSDT", PositionLong, 0.1, true)
+			return err
+		}, "10", true},
+		// 不校验时超过持仓按全部平仓，下单数量不超过持仓
+		{"partial oversize without check", func(tr *OkxTrader) error {
+			_, err := tr.ClosePartial(t.Context(), "BTCUSDT", PositionLong, 0.2, false)
+			return err
+		}, "10", true},
+		{"limit reduce only", func(tr *OkxTrader) error {
+			_, err := tr.CloseLongLimit("BTCUSDT", 0.1, 51000, TimeInForceGTC, true)
+			return err
+		}, "10", true},
+		{"limit without reduce only", func(tr *OkxTrader) error {
+			_, err := tr.CloseLongLimit("BTCUSDT", 0.1, 51000, TimeInForceGTC, false)
+			return err
+		}, "10", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newNetModeOkx(t)
+			if err := tc.close(f.trader(t)); err != nil {
+				t.Fatalf("平仓: %v", err)
+			}
+			reqs := f.requestsTo("POST /api/v5/trade/order")
+			if len(reqs) != 1 {
+				t.Fatalf("下单次数 = %d, want 1", len(reqs))
+			}
+			body := reqs[0].Body
+			if got := jsonField(t, body, "reduceOnly"); got != tc.wantReduce {
+				t.Errorf("reduceOnly = %#v, want %#v (请求 %s)", got, tc.wantReduce, body)
+			}
+			if jsonField(t, body, "sz") != tc.wantSz || jsonField(t, body, "side") != "sell" || jsonField(t, body, "posSide") != "net" {
+				t.Errorf("请求 = %s, want sell net %s张", body, tc.wantSz)
+			}
+		})
+	}
+}
+
+// TestOkxProtectiveOrderReduceOnly 止损止盈条件单带reduceOnly
+func TestOkxProtectiveOrderReduceOnly(t *testing.T) {
+	f := newNetModeOkx(t)
+	f.reply("POST /api/v5/trade/order-algo", `{"algoId":"a1","sCode":"0","sMsg":""}`)
+	if err := f.trader(t).SetStopLoss("BTCUSDT", PositionLong, 0.1, 48000); err != nil {
+		t.Fatalf("SetStopLoss: %v", err)
+	}
+	reqs := f.requestsTo("POST /api/v5/trade/order-algo")
+	if len(reqs) != 1 {
+		t.Fatalf("条件单请求数 = %d, want 1", len(reqs))
+	}
+	if got := jsonField(t, reqs[0].Body, "reduceOnly"); got != true {
+		t.Errorf("reduceOnly = %#v, want true (请求 %s)", got, reqs[0].Body)
+	}
+}
+
+// TestOkxStringParams WebSocket下单参数保留布尔值与数量
+func TestOkxStringParams(t *testing.T) {
+	params, err := okxStringParams(tradeReq.PlaceOrder{
+		ID:         "ignored",
+		InstID:     "BTC-USDT-SWAP",
+		TdMode:     okx.TradeCrossMode,
+		Side:       okx.OrderSell,
+		PosSide:    okx.PositionNetSide,
+		OrdType:    okx.OrderMarket,
+		Sz:         10,
+		ReduceOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"instId": "BTC-USDT-SWAP", "tdMode": "cross", "side": "sell", "posSide": "net",
+		"ordType": "market", "sz": "10", "reduceOnly": "true",
+	} {
+		if params[key] != want {
+			t.Errorf("%s = %q, want %q", key, params[key], want)
+		}
+	}
+	if _, ok := params["px"]; ok {
+		t.Errorf("未设置的px不应出现: %v", params)
+	}
+	if len(params) != 7 {
+		t.Errorf("参数 = %v, want 7个", params)
+	}
+}
@@ -0,0 +1,123 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	publicReq "github.com/Benjmmi/okx/requests/rest/public"
+	publicResp "github.com/Benjmmi/okx/responses/public_data"
+	"github.com/shopspring/decimal"
+)
+
+// OrderSizing 按名义价值计算出的下单数量
+type OrderSizing struct {
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`     // 下单数量（张，已按lotSz取整）
+	QuantityStr string  `json:"quantity_str"` // 下单用字符串
+	MarkPrice   float64 `json:"mark_price"`
+	Notional    float64 `json:"notional"` // 取整后的实际名义价值（USDT）
+	Margin      float64 `json:"margin"`   // 实际占用保证金（USDT）
+}
+
+// getMarkPrice 获取标记价格
+func (t *OkxTrader) getMarkPrice(symbol string) (float64, error) {
+	instID, instType, err := t.resolveInstID(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if instType == okx.SpotInstrument {
+		// 现货没有标记价格，使用最新成交价
+		return t.GetMarketPrice(instID)
+	}
+	if price, ok, err := t.streamPrice(instID, true); ok {
+		return price, err
+	}
+	resp, err := okxCall(context.Background(), t, OpPublicRead, "GetMarkPrice", func() (publicResp.GetMarkPrice, error) {
+		return t.api().Rest.PublicData.GetMarkPrice(publicReq.GetMarkPrice{
+			InstType: instType,
+			InstID:   instID,
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取 %s 标记价格失败: %w", instID, err)
+	}
+	if err := okxCheck("GetMarkPrice", resp.Basic, len(resp.MarkPrices)); err != nil {
+		return 0, fmt.Errorf("获取 %s 标记价格失败: %w", instID, err)
+	}
+	return float64(resp.MarkPrices[0].MarkPx), nil
+}
+
+// CalculateQuantityFromNotional 按USDT名义价值计算下单张数
+// 正向合约：张数 = 名义价值 / (标记价格 × 合约面值)；反向合约：张数 = 名义价值 / 合约面值(USD)
+// 向下取整到lotSz，返回取整后实际的名义价值与保证金（USD）
+func (t *OkxTrader) CalculateQuantityFromNotional(symbol string, notionalUSDT float64, leverage int) (*OrderSizing, error) {
+	if notionalUSDT <= 0 {
+		return nil, fmt.Errorf("名义价值必须大于0 (当前 %.2f)", notionalUSDT)
+	}
+	if leverage <= 0 {
+		return nil, fmt.Errorf("杠杆必须大于0 (当前 %d)", leverage)
+	}
+
+	size, err := t.ContractsFromNotional(symbol, notionalUSDT)
+	if err != nil {
+		return nil, err
+	}
+	margin := toDecimal(size.Notional).Div(decimal.NewFromInt(int64(leverage)))
+	return &OrderSizing{
+		Symbol:      size.InstID,
+		Quantity:    size.Contracts,
+		QuantityStr: size.ContractsStr,
+		MarkPrice:   size.MarkPrice,
+		Notional:    size.Notional,
+		Margin:      decimalFloat(margin),
+	}, nil
+}
+
+// MinSizeBumpPolicy 下单数量低于最小下单量时的处理策略
+// 默认关闭（返回 ErrBelowMinSize）；开启后提高到最小下单量，但超出原始数量不能超过 MaxOverPct
+type MinSizeBumpPolicy struct {
+	Enabled    bool    `json:"enabled"`
+	MaxOverPct float64 `json:"max_over_pct"` // 允许超出原始数量的百分比（如20表示最多多开20%）
+}
+
+// SetMinSizeBumpPolicy 设置低于最小下单量时的处理策略（只作用于开仓）
+func (t *OkxTrader) SetMinSizeBumpPolicy(policy MinSizeBumpPolicy) {
+	t.minSizeBump = policy
+}
+
+// bumpToMinSize 按策略将开仓数量提高到最小下单量，策略关闭或超出上限时返回原错误
+func (t *OkxTrader) bumpToMinSize(symbol string, quantity float64, cause error) (string, float64, *SizeBump, error) {
+	policy := t.minSizeBump
+	if !policy.Enabled || quantity <= 0 {
+		return "", 0, nil, cause
+	}
+
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	price, err := t.getMarkPrice(symbol)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	minContracts := float64(inst.MinSz)
+	bumped := contractsToCoin(inst, minContracts, price)
+	requested := toDecimal(quantity)
+	extraPct := bumped.Sub(requested).Div(requested).Mul(decimal.NewFromInt(100))
+	if extraPct.GreaterThan(toDecimal(policy.MaxOverPct)) {
+		return "", 0, nil, fmt.Errorf("%w：提高到最小下单量 %s 需多开 %s%%，超过允许的 %.4g%%",
+			cause, bumped.String(), extraPct.StringFixed(2), policy.MaxOverPct)
+	}
+
+	bump := &SizeBump{
+		RequestedQty:  quantity,
+		BumpedQty:     decimalFloat(bumped),
+		ExtraPct:      decimalFloat(extraPct.Round(4)),
+		ExtraNotional: decimalFloat(bumped.Sub(requested).Mul(toDecimal(price))),
+	}
+	t.logger.Warn("数量低于最小下单量，已提高", "symbol", symbol, "size", bump.RequestedQty, "bumpedSize", bump.BumpedQty,
+		"extraPct", bump.ExtraPct, "extraNotional", bump.ExtraNotional)
+	return inst.InstID, minContracts, bump, nil
+}
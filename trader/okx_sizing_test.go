@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+var lotTestCases = []struct {
+	name     string
+	lotSz    string
+	minSz    string
+	quantity float64
+	want     string // 为空表示低于最小下单量
+}{
+	{"lot 0.001", "0.001", "0.001", 1.23456, "1.234"},
+	{"lot 0.001 below min", "0.001", "0.001", 0.0009, ""},
+	{"lot 0.01", "0.01", "0.01", 0.129, "0.12"},
+	{"lot 0.01 float artifact", "0.01", "0.01", 0.1 + 0.2, "0.3"},
+	{"lot 0.1", "0.1", "0.1", 0.35, "0.3"},
+	{"lot 0.1 below min", "0.1", "0.1", 0.05, ""},
+	{"lot 0.5", "0.5", "0.5", 7.3, "7"},
+	{"lot 0.5 exact", "0.5", "0.5", 7.5, "7.5"},
+	{"lot 0.5 below min", "0.5", "0.5", 0.4, ""},
+	{"lot 1", "1", "1", 7.9, "7"},
+	{"lot 1 below min", "1", "1", 0.9, ""},
+	{"lot 1 min 3", "1", "3", 2.5, ""},
+	{"lot 5", "5", "5", 7.3, "5"},
+	{"lot 5 two lots", "5", "5", 12, "10"},
+	{"lot 5 below min", "5", "5", 4.9, ""},
+	{"lot 10", "10", "10", 123, "120"},
+	{"lot 10 below min", "10", "10", 7.3, ""},
+	{"lot 100", "100", "100", 1234, "1200"},
+	{"lot 100 below min", "100", "100", 99, ""},
+	{"lot 1e-8", "0.00000001", "0.00000001", 0.123456789, "0.12345678"},
+}
+
+func TestFloorToLot(t *testing.T) {
+	for _, tc := range lotTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := floorToLot(tc.quantity, mustFloat(t, tc.lotSz), mustFloat(t, tc.minSz))
+			if tc.want == "" {
+				if !errors.Is(err, ErrBelowMinSize) {
+					t.Fatalf("floorToLot(%v) err = %v, want ErrBelowMinSize", tc.quantity, err)
+				}
+				return
+			}
+			if err != nil || got.String() != tc.want {
+				t.Fatalf("floorToLot(%v) = %s, %v, want %s", tc.quantity, got, err, tc.want)
+			}
+		})
+	}
+}
+
+// TestRoundQuantity 按交易所返回的交易规则取整，同时返回数值与字符串
+func TestRoundQuantity(t *testing.T) {
+	for _, tc := range lotTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			f.reply("GET /api/v5/public/instruments", okxTestInstrumentWith(tc.lotSz, tc.minSz, "0.1"))
+			tr := f.trader(t)
+
+			n, s, err := tr.RoundQuantity("BTC-USDT-SWAP", tc.quantity)
+			if tc.want == "" {
+				if !errors.Is(err, ErrBelowMinSize) {
+					t.Fatalf("RoundQuantity(%v) err = %v, want ErrBelowMinSize", tc.quantity, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RoundQuantity(%v): %v", tc.quantity, err)
+			}
+			if s != tc.want || n != mustFloat(t, tc.want) {
+				t.Fatalf("RoundQuantity(%v) = %v, %q, want %s", tc.quantity, n, s, tc.want)
+			}
+		})
+	}
+}
+
+// TestFormatQuantityContracts 币的数量按合约面值换算为张数后取整（ctVal=0.01：0.073 BTC = 7.3张）
+func TestFormatQuantityContracts(t *testing.T) {
+	for _, tc := range []struct {
+		lotSz, minSz string
+		quantity     float64
+		want         string
+	}{
+		{"1", "1", 0.073, "7"},
+		{"5", "5", 0.073, "5"},
+		{"0.1", "0.1", 0.0735, "7.3"},
+		{"10", "10", 0.073, ""},
+	} {
+		f := newFakeOkx(t)
+		f.reply("GET /api/v5/public/instruments", okxTestInstrumentWith(tc.lotSz, tc.minSz, "0.1"))
+		got, err := f.trader(t).FormatQuantity("BTC-USDT-SWAP", tc.quantity)
+		if tc.want == "" {
+			var minErr *MinSizeError
+			if !errors.As(err, &minErr) || minErr.MinQuantity != 0.1 {
+				t.Errorf("lotSz %s: FormatQuantity(%v) err = %v, want MinSizeError(min 0.1)", tc.lotSz, tc.quantity, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("lotSz %s: FormatQuantity(%v) = %q, %v, want %s", tc.lotSz, tc.quantity, got, err, tc.want)
+		}
+	}
+}
+
+// TestOkxSymbolPrecision 数量精度直接由交易所返回的lotSz字符串推导（不经过float64格式化）
+func TestOkxSymbolPrecision(t *testing.T) {
+	for _, tc := range []struct {
+		lotSz string
+		want  int
+	}{
+		{"0.001", 3},
+		{"0.1", 1},
+		{"1", 0},
+		{"10", 0},
+		{"100", 0},
+		{"1e-8", 8},
+		{"0.00000001", 8},
+		{"0.0000001", 7},
+		{"0.01000000", 2},
+	} {
+		f := newFakeOkx(t)
+		f.reply("GET /api/v5/public/instruments", okxTestInstrumentWith(tc.lotSz, tc.lotSz, "0.1"))
+		if got, err := f.trader(t).GetSymbolPrecision("BTCUSDT"); err != nil || got != tc.want {
+			t.Errorf("lotSz %s: GetSymbolPrecision = %d, %v, want %d", tc.lotSz, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+// SpotSizeUnit 现货市价单数量的单位
+type SpotSizeUnit string
+
+const (
+	SpotSizeBase  SpotSizeUnit = "base"  // 按交易货币数量下单（如 0.0031 BTC）
+	SpotSizeQuote SpotSizeUnit = "quote" // 按计价货币金额下单（如 200 USDT），对应OKX的 tgtCcy=quote_ccy
+)
+
+// BuySpot 现货市价买入
+// unit为SpotSizeQuote时amount为计价货币金额，实际买到的数量以成交数据为准（返回结果中的filledQty/netQty）
+func (t *OkxTrader) BuySpot(symbol string, amount float64, unit SpotSizeUnit) (map[string]interface{}, error) {
+	return t.placeSpotMarketOrder(symbol, amount, unit, okx.OrderBuy)
+}
+
+// SellSpot 现货市价卖出
+func (t *OkxTrader) SellSpot(symbol string, amount float64, unit SpotSizeUnit) (map[string]interface{}, error) {
+	return t.placeSpotMarketOrder(symbol, amount, unit, okx.OrderSell)
+}
+
+// placeSpotMarketOrder 下现货市价单（非杠杆现货，tdMode=cash）
+func (t *OkxTrader) placeSpotMarketOrder(symbol string, amount float64, unit SpotSizeUnit, side okx.OrderSide) (map[string]interface{}, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("下单数量必须大于0 (当前 %v)", amount)
+	}
+	instID, instType, err := resolveOkxInstID(symbol, okx.SpotInstrument)
+	if err != nil {
+		return nil, err
+	}
+	// OKX仅币币市价单支持tgtCcy，合约只能按张数下单
+	if instType != okx.SpotInstrument {
+		return nil, fmt.Errorf("%s 为 %s，OKX仅现货市价单支持按计价货币金额或交易货币数量下单", instID, instType)
+	}
+
+	inst, err := t.getInstrument(instID)
+	if err != nil {
+		return nil, err
+	}
+	price, err := t.GetMarketPrice(instID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sz, notional float64
+	var tgtCcy okx.QuantityType
+	switch unit {
+	case SpotSizeQuote:
+		// 计价货币金额按tickSz的精度截断，折算的交易货币数量不能低于minSz
+		floored := floorToStep(amount, float64(inst.TickSz))
+		if price > 0 && inst.MinSz > 0 && floored.Div(toDecimal(price)).LessThan(toDecimal(float64(inst.MinSz))) {
+			return nil, fmt.Errorf("%s: 金额 %s %s 约合 %s %s，%w %v", instID, floored.String(), inst.QuoteCcy,
+				floored.Div(toDecimal(price)).Round(8).String(), inst.BaseCcy, ErrBelowMinSize, float64(inst.MinSz))
+		}
+		sz, notional, tgtCcy = decimalFloat(floored), decimalFloat(floored), okx.QuantityQuoteCcy
+	case SpotSizeBase, "":
+		floored, err := floorToLot(amount, float64(inst.LotSz), float64(inst.MinSz))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", instID, err)
+		}
+		sz, notional, tgtCcy = decimalFloat(floored), decimalFloat(floored.Mul(toDecimal(price))), okx.QuantityBaseCcy
+	default:
+		return nil, fmt.Errorf("不支持的现货数量单位: %s", unit)
+	}
+	if side == okx.OrderBuy {
+		if err := checkOrderNotional(instID, notional); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := t.submitOrder(context.Background(), tradeReq.PlaceOrder{
+		InstID:  instID,
+		TdMode:  okx.TradeCashMode,
+		Side:    side,
+		OrdType: okx.OrderMarket,
+		Sz:      sz,
+		TgtCcy:  tgtCcy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.logger.Info("现货市价单已提交", "instId", instID, "side", side, "size", sz, "tgtCcy", tgtCcy, "orderId", order.OrdID)
+
+	result := &OrderResult{
+		OrderID:       order.OrdID,
+		ClientOrderID: order.ClOrdID,
+		Symbol:        instID,
+		Side:          strings.ToUpper(string(side)),
+		Status:        "NEW",
+		MarginMode:    string(okx.TradeCashMode),
+		Time:          t.clock.Now(),
+	}
+	detail, err := t.waitForFill(context.Background(), instID, order.OrdID)
+	if err != nil {
+		t.logger.Warn("查询订单成交信息失败", "instId", instID, "orderId", order.OrdID, "err", err)
+	} else {
+		// 现货的成交数量始终为交易货币数量（按金额下单时也是）
+		t.applyFill(result, instID, detail)
+	}
+
+	fill := result.Map()
+	fill["sizeUnit"] = string(unit)
+	fill["requestedSize"] = sz
+	// 买入的手续费以交易货币扣除，实际到账数量 = 成交数量 + 手续费（手续费为负）
+	netQty := result.FilledQty
+	if side == okx.OrderBuy && result.FeeAsset == inst.BaseCcy {
+		netQty = sumFloat64(result.FilledQty, result.Fee)
+	}
+	fill["netQty"] = netQty
+	return fill, nil
+}
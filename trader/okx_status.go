@@ -0,0 +1,216 @@
+package trader
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultStatusOrdersTTL 状态接口中止损止盈挂单的缓存时间（挂单没有交易器缓存，按此间隔查询）
+const defaultStatusOrdersTTL = 30 * time.Second
+
+// statusRequestTimeout 单次状态请求（缓存过期时需查询OKX）的超时时间
+const statusRequestTimeout = 10 * time.Second
+
+// OkxStatus 状态接口返回的运行状态
+type OkxStatus struct {
+	Time                  time.Time           `json:"time"`
+	Balance               *Balance            `json:"balance,omitempty"`
+	BalanceError          string              `json:"balance_error,omitempty"`
+	Positions             []OkxStatusPosition `json:"positions"`
+	PositionsError        string              `json:"positions_error,omitempty"`
+	ProtectiveOrders      []OpenOrder         `json:"protective_orders"`             // 未触发的止损止盈与追踪止损单
+	ProtectiveOrdersAt    time.Time           `json:"protective_orders_at,omitzero"` // 挂单列表的查询时间
+	ProtectiveOrdersError string              `json:"protective_orders_error,omitempty"`
+	DataRefreshedAt       time.Time           `json:"data_refreshed_at,omitzero"` // 余额与持仓中较早的一次刷新时间
+	Health                OkxHealth           `json:"health"`
+	Caches                []CacheStat         `json:"caches"`
+}
+
+// OkxStatusPosition 状态接口中的持仓
+type OkxStatusPosition struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"` // long / short
+	Size             float64 `json:"size"`
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+	Leverage         int     `json:"leverage"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+}
+
+// OkxStatusServer 只读的运行状态接口（余额、持仓、止损止盈挂单与API健康状况）
+// 余额与持仓使用交易器缓存（过期时并发请求只查询一次），挂单按ordersTTL缓存，抓取频率不影响OKX请求量
+// Handler可挂载到已有的mux，ListenAndServe单独监听并在 /status 提供服务
+type OkxStatusServer struct {
+	trader    *OkxTrader
+	token     string
+	ordersTTL time.Duration
+
+	ordersMu     sync.Mutex
+	orders       []OpenOrder
+	ordersAt     time.Time
+	ordersErr    error
+	ordersFlight flightGroup[[]OpenOrder]
+}
+
+// NewOkxStatusServer 创建状态接口，token不为空时请求需携带 Authorization: Bearer <token> 或 X-Status-Token 头
+// ordersTTL<=0时挂单缓存30秒
+func NewOkxStatusServer(t *OkxTrader, token string, ordersTTL time.Duration) *OkxStatusServer {
+	if ordersTTL <= 0 {
+		ordersTTL = defaultStatusOrdersTTL
+	}
+	return &OkxStatusServer{trader: t, token: token, ordersTTL: ordersTTL}
+}
+
+// StatusHandler 实现StatusHandlerProvider
+func (t *OkxTrader) StatusHandler(token string) http.Handler {
+	return NewOkxStatusServer(t, token, 0).Handler()
+}
+
+// Snapshot 汇总当前状态，部分数据获取失败时在对应的 *_error 字段中说明
+func (s *OkxStatusServer) Snapshot(ctx context.Context) OkxStatus {
+	t := s.trader
+	status := OkxStatus{
+		Time:             t.clock.Now(),
+		Positions:        []OkxStatusPosition{},
+		ProtectiveOrders: []OpenOrder{},
+	}
+	if balance, err := t.balance(ctx, false); err != nil {
+		status.BalanceError = err.Error()
+	} else {
+		status.Balance = balance
+	}
+	if positions, err := t.positions(ctx, false); err != nil {
+		status.PositionsError = err.Error()
+	} else {
+		for _, p := range positions {
+			status.Positions = append(status.Positions, OkxStatusPosition{
+				Symbol:           p.Symbol,
+				Side:             p.Side.String(),
+				Size:             p.Quantity,
+				EntryPrice:       p.EntryPrice,
+				MarkPrice:        p.MarkPrice,
+				UnrealizedPnL:    p.UnrealizedPnL,
+				Leverage:         p.Leverage,
+				LiquidationPrice: p.LiquidationPrice,
+			})
+		}
+	}
+	orders, at, err := s.protectiveOrders(ctx)
+	if orders != nil {
+		status.ProtectiveOrders = orders
+	}
+	status.ProtectiveOrdersAt = at
+	if err != nil {
+		status.ProtectiveOrdersError = err.Error()
+	}
+	// 放在查询之后，包含本次请求触发的刷新
+	status.DataRefreshedAt = t.LastRefreshed()
+	status.Health = t.Health()
+	status.Caches = t.CacheStats()
+	return status
+}
+
+// protectiveOrders 返回缓存的止损止盈挂单，超过ordersTTL时重新查询（并发请求只查询一次）
+// 查询失败时返回上次的列表与错误
+func (s *OkxStatusServer) protectiveOrders(ctx context.Context) ([]OpenOrder, time.Time, error) {
+	now := s.trader.clock.Now()
+	s.ordersMu.Lock()
+	if !s.ordersAt.IsZero() && now.Sub(s.ordersAt) < s.ordersTTL {
+		orders, at, err := s.orders, s.ordersAt, s.ordersErr
+		s.ordersMu.Unlock()
+		return orders, at, err
+	}
+	s.ordersMu.Unlock()
+
+	orders, err, _ := s.ordersFlight.Do("", func() ([]OpenOrder, error) {
+		orders, err := s.trader.ListPendingAlgoOrders(ctx, OkxOrderFilter{})
+		s.ordersMu.Lock()
+		defer s.ordersMu.Unlock()
+		// 失败时也记录查询时间，ordersTTL内不重复请求
+		s.ordersAt, s.ordersErr = s.trader.clock.Now(), err
+		if err == nil {
+			s.orders = orders
+		}
+		return orders, err
+	})
+	s.ordersMu.Lock()
+	defer s.ordersMu.Unlock()
+	if err != nil {
+		return s.orders, s.ordersAt, err
+	}
+	return orders, s.ordersAt, nil
+}
+
+// Handler 返回状态接口的HTTP处理器（只接受GET，任意路径均返回状态JSON，由调用方决定挂载位置）
+func (s *OkxStatusServer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), statusRequestTimeout)
+		defer cancel()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(s.Snapshot(ctx)); err != nil {
+			s.trader.logger.Warn("输出状态失败", "err", err)
+		}
+	})
+}
+
+// authorized 未配置token时不校验
+func (s *OkxStatusServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	got := r.Header.Get("X-Status-Token")
+	if auth := r.Header.Get("Authorization"); got == "" && strings.HasPrefix(auth, "Bearer ") {
+		got = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+// ListenAndServe 在addr上监听并在 /status 提供状态接口，ctx结束时关闭
+func (s *OkxStatusServer) ListenAndServe(ctx context.Context, addr string) error {
+	return serveStatus(ctx, addr, s.Handler())
+}
+
+// serveStatus 在addr的 /status 上提供handler，ctx结束时关闭，返回监听失败的错误（正常关闭返回nil）
+func serveStatus(ctx context.Context, addr string, handler http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle("/status", handler)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("状态接口监听失败: %w", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠ 关闭状态接口失败: %v", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("状态接口异常退出: %w", err)
+	}
+	return nil
+}
+
+var _ StatusHandlerProvider = (*OkxTrader)(nil)
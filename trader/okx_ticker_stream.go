@@ -0,0 +1,215 @@
+package trader
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Benjmmi/okx"
+)
+
+// defaultTickerMaxAge WebSocket价格的默认最大允许年龄
+const defaultTickerMaxAge = 15 * time.Second
+
+// ErrStalePrice 已订阅WebSocket行情，但最近一次推送超过了最大允许年龄（连接可能已断开）
+var ErrStalePrice = newSentinelError(ErrCodeStalePrice, "err_stale_price")
+
+// StalePriceError 带品种与年龄的过期价格错误
+type StalePriceError struct {
+	InstID string
+	Age    time.Duration
+	MaxAge time.Duration
+}
+
+func (e *StalePriceError) Error() string {
+	return msg("err_stale_price_detail", e.InstID, e.Age.Round(time.Millisecond), e.MaxAge)
+}
+
+func (e *StalePriceError) ErrorCode() ErrorCode {
+	return ErrCodeStalePrice
+}
+
+// Is 使 errors.Is(err, ErrStalePrice) 成立
+func (e *StalePriceError) Is(target error) bool {
+	return target == ErrStalePrice
+}
+
+// okxTick 单个品种最近推送的价格（at为收到推送的本地时间）
+type okxTick struct {
+	last, mark     float64
+	lastAt, markAt time.Time
+}
+
+// okxTickerFeed 公共WebSocket的tickers与mark-price频道，维护各品种的最新价与标记价格
+type okxTickerFeed struct {
+	stream *okxStream
+
+	mu         sync.RWMutex
+	ticks      map[string]*okxTick // key: instId
+	subscribed map[string]bool
+}
+
+// WithTickerMaxAge 设置WebSocket价格的最大允许年龄（默认15秒），超过时GetMarketPrice返回 ErrStalePrice
+func WithTickerMaxAge(d time.Duration) OkxOption {
+	return func(t *OkxTrader) error {
+		if d <= 0 {
+			return errors.New("价格最大年龄必须大于0")
+		}
+		t.tickerMaxAge = d
+		return nil
+	}
+}
+
+// SubscribeTicker 通过公共WebSocket订阅币种的最新价与标记价格（第一次调用时建立连接，断开后自动重连并重新订阅）
+// 订阅后GetMarketPrice与标记价格读取推送的缓存；推送超过最大年龄时返回 ErrStalePrice，尚未收到推送时仍使用REST
+func (t *OkxTrader) SubscribeTicker(symbols ...string) error {
+	args, instIDs, err := t.tickerArgs(symbols)
+	if err != nil {
+		return err
+	}
+	feed := t.tickerFeed(true)
+	feed.mu.Lock()
+	for _, instID := range instIDs {
+		feed.subscribed[instID] = true
+	}
+	feed.mu.Unlock()
+	return feed.stream.subscribe(args...)
+}
+
+// UnsubscribeTicker 取消订阅，之后这些币种的价格重新使用REST查询
+func (t *OkxTrader) UnsubscribeTicker(symbols ...string) error {
+	feed := t.tickerFeed(false)
+	if feed == nil {
+		return nil
+	}
+	args, instIDs, err := t.tickerArgs(symbols)
+	if err != nil {
+		return err
+	}
+	feed.mu.Lock()
+	for _, instID := range instIDs {
+		delete(feed.subscribed, instID)
+		delete(feed.ticks, instID)
+	}
+	feed.mu.Unlock()
+	return feed.stream.unsubscribe(args...)
+}
+
+// CloseTickerStream 关闭行情WebSocket并清空价格缓存（之后可以重新SubscribeTicker）
+func (t *OkxTrader) CloseTickerStream() {
+	t.tickerMu.Lock()
+	feed := t.tickers
+	t.tickers = nil
+	t.tickerMu.Unlock()
+	if feed != nil {
+		feed.stream.close()
+	}
+}
+
+// TickerStreamConnected 行情WebSocket是否已连接
+func (t *OkxTrader) TickerStreamConnected() bool {
+	feed := t.tickerFeed(false)
+	return feed != nil && feed.stream.isConnected()
+}
+
+// tickerFeed 返回行情订阅，create为true且尚未创建时创建
+func (t *OkxTrader) tickerFeed(create bool) *okxTickerFeed {
+	t.tickerMu.Lock()
+	defer t.tickerMu.Unlock()
+	if t.tickers == nil && create {
+		_, _, wsPublic := t.endpoint.urls()
+		feed := &okxTickerFeed{
+			ticks:      make(map[string]*okxTick),
+			subscribed: make(map[string]bool),
+		}
+		feed.stream = newOkxStream("行情", string(wsPublic), t.logger, func(push okxStreamPush) {
+			feed.apply(push, t.clock.Now())
+		})
+		t.tickers = feed
+	}
+	return t.tickers
+}
+
+// tickerArgs 币种对应的订阅参数（现货没有标记价格频道）
+func (t *OkxTrader) tickerArgs(symbols []string) ([]map[string]string, []string, error) {
+	var args []map[string]string
+	var instIDs []string
+	for _, symbol := range symbols {
+		instID, instType, err := t.resolveInstID(symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		instIDs = append(instIDs, instID)
+		args = append(args, map[string]string{"channel": "tickers", "instId": instID})
+		if instType != okx.SpotInstrument {
+			args = append(args, map[string]string{"channel": "mark-price", "instId": instID})
+		}
+	}
+	return args, instIDs, nil
+}
+
+// apply 更新推送的价格（未订阅或已取消订阅的品种忽略）
+func (f *okxTickerFeed) apply(push okxStreamPush, now time.Time) {
+	var rows []struct {
+		InstID string `json:"instId"`
+		Last   string `json:"last"`
+		MarkPx string `json:"markPx"`
+	}
+	if err := json.Unmarshal(push.Data, &rows); err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range rows {
+		if !f.subscribed[row.InstID] {
+			continue
+		}
+		tick := f.ticks[row.InstID]
+		if tick == nil {
+			tick = &okxTick{}
+			f.ticks[row.InstID] = tick
+		}
+		switch push.Arg["channel"] {
+		case "tickers":
+			if v, err := strconv.ParseFloat(row.Last, 64); err == nil && v > 0 {
+				tick.last, tick.lastAt = v, now
+			}
+		case "mark-price":
+			if v, err := strconv.ParseFloat(row.MarkPx, 64); err == nil && v > 0 {
+				tick.mark, tick.markAt = v, now
+			}
+		}
+	}
+}
+
+// streamPrice 从WebSocket缓存读取最新价（mark为true时读取标记价格，现货没有标记价格时使用最新价）
+// ok为false表示未订阅该品种或尚未收到推送，调用方应使用REST
+func (t *OkxTrader) streamPrice(instID string, mark bool) (price float64, ok bool, err error) {
+	feed := t.tickerFeed(false)
+	if feed == nil {
+		return 0, false, nil
+	}
+	feed.mu.RLock()
+	defer feed.mu.RUnlock()
+	tick := feed.ticks[instID]
+	if !feed.subscribed[instID] || tick == nil {
+		return 0, false, nil
+	}
+	price, at := tick.last, tick.lastAt
+	if mark && !tick.markAt.IsZero() {
+		price, at = tick.mark, tick.markAt
+	}
+	if at.IsZero() {
+		return 0, false, nil
+	}
+	maxAge := t.tickerMaxAge
+	if maxAge <= 0 {
+		maxAge = defaultTickerMaxAge
+	}
+	if age := t.clock.Since(at); age > maxAge {
+		return 0, true, &StalePriceError{InstID: instID, Age: age, MaxAge: maxAge}
+	}
+	return price, true, nil
+}
@@ -0,0 +1,168 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Benjmmi/okx"
+	"github.com/Benjmmi/okx/models/publicdata"
+	publicReq "github.com/Benjmmi/okx/requests/rest/public"
+	publicResp "github.com/Benjmmi/okx/responses/public_data"
+	"github.com/shopspring/decimal"
+)
+
+// okxTierCacheTTL 阶梯保证金缓存有效期（档位调整很少发生）
+const okxTierCacheTTL = time.Hour
+
+type okxTierCache struct {
+	tiers     []*publicdata.PositionTier
+	fetchedAt time.Time
+}
+
+// GetPositionTiers 获取交易对的阶梯保证金档位（按档位升序，带缓存）
+// 档位的minSz/maxSz为合约张数，随持仓增大维持保证金率(mmr)提高、最大杠杆降低
+func (t *OkxTrader) GetPositionTiers(symbol string) ([]*publicdata.PositionTier, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if inst.InstType != okx.SwapInstrument && inst.InstType != okx.FuturesInstrument {
+		return nil, fmt.Errorf("%s 不是合约，没有阶梯保证金", inst.InstID)
+	}
+	tdMode := t.tradeMode(inst.InstID)
+	key := inst.Uly + "|" + string(tdMode)
+
+	t.tiersMutex.Lock()
+	cached, ok := t.positionTiers[key]
+	t.tiersMutex.Unlock()
+	if ok && t.clock.Since(cached.fetchedAt) < okxTierCacheTTL {
+		return cached.tiers, nil
+	}
+
+	resp, err := okxCall(context.Background(), t, OpPublicRead, "GetPositionTiers", func() (publicResp.GetPositionTiers, error) {
+		return t.api().Rest.PublicData.GetPositionTiers(publicReq.GetPositionTiers{
+			InstType: inst.InstType,
+			TdMode:   tdMode,
+			Uly:      inst.Uly,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 阶梯保证金失败: %w", inst.InstID, err)
+	}
+	if err := okxCheck("GetPositionTiers", resp.Basic, len(resp.PositionTiers)); err != nil {
+		return nil, fmt.Errorf("获取 %s 阶梯保证金失败: %w", inst.InstID, err)
+	}
+
+	tiers := resp.PositionTiers
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Tier < tiers[j].Tier })
+
+	t.tiersMutex.Lock()
+	if t.positionTiers == nil {
+		t.positionTiers = make(map[string]*okxTierCache)
+	}
+	t.positionTiers[key] = &okxTierCache{tiers: tiers, fetchedAt: t.clock.Now()}
+	t.tiersMutex.Unlock()
+
+	return tiers, nil
+}
+
+// tierForContracts 返回持仓张数所在的档位（minSz < contracts <= maxSz），超过最高档时返回错误
+func tierForContracts(tiers []*publicdata.PositionTier, contracts float64) (*publicdata.PositionTier, error) {
+	size := toDecimal(contracts)
+	for _, tier := range tiers {
+		if size.LessThanOrEqual(toDecimal(float64(tier.MaxSz))) {
+			return tier, nil
+		}
+	}
+	if len(tiers) == 0 {
+		return nil, errors.New("没有阶梯保证金档位")
+	}
+	last := tiers[len(tiers)-1]
+	return nil, fmt.Errorf("持仓 %.8g 张超过最高档位上限 %.8g 张", contracts, float64(last.MaxSz))
+}
+
+// PositionTier 获取指定持仓数量（币）所在的档位
+func (t *OkxTrader) PositionTier(symbol string, quantity float64) (*publicdata.PositionTier, error) {
+	tiers, err := t.GetPositionTiers(symbol)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := t.tierContracts(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return tierForContracts(tiers, contracts)
+}
+
+// tierContracts 币的数量换算为张数（不按最小下单量截断，用于档位判断）
+func (t *OkxTrader) tierContracts(symbol string, quantity float64) (float64, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return 0, err
+	}
+	price := 0.0
+	if isInverseContract(inst) {
+		if price, err = t.getMarkPrice(symbol); err != nil {
+			return 0, err
+		}
+	}
+	return decimalFloat(coinToContracts(inst, quantity, price)), nil
+}
+
+// checkPositionTier 下单前检查：加仓后的持仓所在档位必须允许当前杠杆
+func (t *OkxTrader) checkPositionTier(symbol string, side PositionSide, quantity float64, leverage int) error {
+	total := quantity
+	if pos, err := t.GetPosition(symbol, side); err == nil {
+		total = sumFloat64(total, pos.Quantity)
+	} else if !errors.Is(err, ErrPositionNotFound) {
+		return err
+	}
+
+	tier, err := t.PositionTier(symbol, total)
+	if err != nil {
+		return fmt.Errorf("%s 阶梯保证金检查失败: %w", symbol, err)
+	}
+	if maxLever := float64(tier.MaxLever); maxLever > 0 && float64(leverage) > maxLever {
+		return fmt.Errorf("%s 持仓 %.8g 处于第 %d 档，最大杠杆 %.4gx，当前 %dx",
+			symbol, total, int64(tier.Tier), maxLever, leverage)
+	}
+	if tier.Tier > 1 {
+		t.logger.Info("持仓处于高档位", "symbol", symbol, "size", total, "tier", int64(tier.Tier),
+			"mmr", float64(tier.Mmr), "maxLever", float64(tier.MaxLever))
+	}
+	return nil
+}
+
+// EstimateLiquidationPrice 按所在档位的维持保证金率估算逐仓强平价（不含手续费与资金费）
+//   - 多仓: entry × (1 - 1/leverage + mmr)
+//   - 空仓: entry × (1 + 1/leverage - mmr)
+func (t *OkxTrader) EstimateLiquidationPrice(symbol string, side PositionSide, quantity, entryPrice float64, leverage int) (float64, error) {
+	if leverage <= 0 || entryPrice <= 0 {
+		return 0, fmt.Errorf("无效的杠杆或开仓价: %dx %.8g", leverage, entryPrice)
+	}
+	if !side.Valid() {
+		return 0, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	tier, err := t.PositionTier(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	return liquidationPrice(side, entryPrice, leverage, float64(tier.Mmr)), nil
+}
+
+// liquidationPrice 逐仓强平价公式
+func liquidationPrice(side PositionSide, entryPrice float64, leverage int, mmr float64) float64 {
+	margin := decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(leverage)))
+	factor := decimal.NewFromInt(1).Sub(margin).Add(toDecimal(mmr))
+	if side == PositionShort {
+		factor = decimal.NewFromInt(1).Add(margin).Sub(toDecimal(mmr))
+	}
+	price := toDecimal(entryPrice).Mul(factor)
+	if price.IsNegative() {
+		return 0
+	}
+	return decimalFloat(price)
+}
@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"strings"
+	"testing"
+)
+
+// okxTestTiers 的档位边界（ctVal=0.01，20 BTC = 2000张）
+func TestOkxPositionTierBoundaries(t *testing.T) {
+	tr := newFakeOkx(t).trader(t)
+	for _, tc := range []struct {
+		quantity float64
+		tier     int64 // 0表示超过最高档
+	}{
+		{0.01, 1},
+		{20, 1},
+		{20.01, 2},
+		{50, 2},
+		{50.01, 3},
+		{100, 3},
+		{100.01, 0},
+	} {
+		tier, err := tr.PositionTier("BTCUSDT", tc.quantity)
+		if tc.tier == 0 {
+			if err == nil {
+				t.Errorf("PositionTier(%v) = 第%d档, want 超过最高档的错误", tc.quantity, int64(tier.Tier))
+			}
+			continue
+		}
+		if err != nil || int64(tier.Tier) != tc.tier {
+			t.Errorf("PositionTier(%v) = %+v, %v, want 第%d档", tc.quantity, tier, err, tc.tier)
+		}
+	}
+}
+
+// TestOkxCheckPositionTierLeverage 按加仓后的持仓所在档位检查杠杆上限
+func TestOkxCheckPositionTierLeverage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing string // 已有持仓张数（空表示无持仓）
+		quantity float64
+		leverage int
+		wantErr  string
+	}{
+		{"tier 1 at max leverage", "", 20, 100, ""},
+		{"just over tier 1", "", 20.01, 100, "第 2 档"},
+		{"tier 2 at its max", "", 20.01, 50, ""},
+		{"existing position pushes into tier 2", "1500", 6, 100, "第 2 档"},
+		{"existing position stays in tier 1", "1500", 5, 100, ""},
+		{"tier 3 at its max", "", 100, 20, ""},
+		{"beyond last tier", "", 100.01, 1, "超过最高档位"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeOkx(t)
+			if tc.existing != "" {
+				f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "long", tc.existing, "50000", 10))
+			}
+			err := f.trader(t).checkPositionTier("BTCUSDT", PositionLong, tc.quantity, tc.leverage)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkPositionTier: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("checkPositionTier err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestOkxOpenRejectedAboveTierLeverage 超过档位杠杆上限时不撤单、不改杠杆、不下单
+func TestOkxOpenRejectedAboveTierLeverage(t *testing.T) {
+	f := newFakeOkx(t)
+	if _, err := f.trader(t).OpenLong("BTCUSDT", 25, 100); err == nil {
+		t.Fatal("超过档位杠杆上限的开仓应被拒绝")
+	}
+	for _, route := range []string{"POST /api/v5/account/set-leverage", "POST /api/v5/trade/order", "GET /api/v5/trade/orders-pending"} {
+		if n := f.calls(route); n != 0 {
+			t.Errorf("%s 被调用 %d 次, want 0", route, n)
+		}
+	}
+}
+
+func TestOkxEstimateLiquidationPriceUsesTierMMR(t *testing.T) {
+	tr := newFakeOkx(t).trader(t)
+	for _, tc := range []struct {
+		side     PositionSide
+		quantity float64
+		want     float64
+	}{
+		{PositionLong, 1, 45200},  // 第1档 mmr 0.004：50000 × (1 - 0.1 + 0.004)
+		{PositionShort, 1, 54800}, // 50000 × (1 + 0.1 - 0.004)
+		{PositionLong, 30, 45500}, // 第2档 mmr 0.01
+	} {
+		got, err := tr.EstimateLiquidationPrice("BTCUSDT", tc.side, tc.quantity, 50000, 10)
+		if err != nil || got != tc.want {
+			t.Errorf("EstimateLiquidationPrice(%s, %v) = %v, %v, want %v", tc.side, tc.quantity, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+// 编译期检查：OkxTrader可注册交易事件回调
+var _ TradeEventSource = (*OkxTrader)(nil)
+
+// WithEventQueueSize 设置交易事件队列长度（默认256），回调处理不过来时超出的事件被丢弃
+func WithEventQueueSize(n int) OkxOption {
+	return func(t *OkxTrader) error {
+		if n <= 0 {
+			return fmt.Errorf("事件队列长度必须大于0: %d", n)
+		}
+		t.events = newTradeEventHub(n)
+		return nil
+	}
+}
+
+// RegisterHook 实现TradeEventSource：注册交易事件回调（下单、开平仓、止损止盈、撤单）
+// 回调在后台goroutine中按顺序调用，不会阻塞下单；未注册回调时不产生事件
+func (t *OkxTrader) RegisterHook(fn func(TradeEvent)) {
+	t.events.register(fn)
+}
+
+// DroppedEvents 实现TradeEventSource：因队列满而丢弃的事件数
+func (t *OkxTrader) DroppedEvents() int64 {
+	return t.events.dropped.Load()
+}
+
+// emitEvent 补全时间后发出事件（未注册回调时直接返回）
+func (t *OkxTrader) emitEvent(e TradeEvent) {
+	e.Time = t.clock.Now()
+	t.events.emit(e)
+}
+
+// emitOrderEvent 下单结果事件（err不为nil时为OrderRejected）
+func (t *OkxTrader) emitOrderEvent(req tradeReq.PlaceOrder, ordID string, err error) {
+	if !t.events.active() {
+		return
+	}
+	e := TradeEvent{
+		Type:          EventOrderPlaced,
+		Symbol:        okxSymbol(req.InstID),
+		Side:          okxEventSide(req),
+		OrderSide:     string(req.Side),
+		Size:          t.eventSize(req),
+		Price:         req.Px,
+		OrderID:       ordID,
+		ClientOrderID: req.ClOrdID,
+	}
+	if err != nil {
+		e.Type = EventOrderRejected
+		e.Error = err.Error()
+	}
+	t.emitEvent(e)
+}
+
+// emitFillEvent 市价开仓/平仓成交事件（entryPrice为0时使用成交均价）
+func (t *OkxTrader) emitFillEvent(typ TradeEventType, side PositionSide, result *OrderResult, entryPrice float64) {
+	if entryPrice == 0 {
+		entryPrice = result.AvgPrice
+	}
+	t.emitEvent(TradeEvent{
+		Type:          typ,
+		Symbol:        result.Symbol,
+		Side:          side.String(),
+		OrderSide:     strings.ToLower(result.Side),
+		Size:          result.FilledQty,
+		Price:         result.AvgPrice,
+		EntryPrice:    entryPrice,
+		Leverage:      result.Leverage,
+		OrderID:       result.OrderID,
+		ClientOrderID: result.ClientOrderID,
+		RealizedPnL:   result.RealizedPnL,
+	})
+}
+
+// okxEventSide 订单对应的持仓方向（单向持仓按买卖方向与是否只减仓推断）
+func okxEventSide(req tradeReq.PlaceOrder) string {
+	switch req.PosSide {
+	case okx.PositionLongSide:
+		return PositionLong.String()
+	case okx.PositionShortSide:
+		return PositionShort.String()
+	case okx.PositionNetSide:
+		if (req.Side == okx.OrderBuy) != req.ReduceOnly {
+			return PositionLong.String()
+		}
+		return PositionShort.String()
+	}
+	return ""
+}
+
+// eventSize 订单数量换算为币（合约按面值换算，现货与换算失败时为下单数量）
+func (t *OkxTrader) eventSize(req tradeReq.PlaceOrder) float64 {
+	inst, err := t.getInstrument(req.InstID)
+	if err != nil || inst.InstType == okx.SpotInstrument {
+		return req.Sz
+	}
+	return decimalFloat(contractsToCoin(inst, req.Sz, req.Px))
+}
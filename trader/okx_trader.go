@@ -2,81 +2,1506 @@ package trader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"math"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"nofx/clock"
+
 	"github.com/Benjmmi/okx"
 	"github.com/Benjmmi/okx/api"
+	accountModel "github.com/Benjmmi/okx/models/account"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
 	account2 "github.com/Benjmmi/okx/requests/rest/account"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	accountResp "github.com/Benjmmi/okx/responses/account"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// 编译期检查：OkxTrader可替换为任意Trader使用，并直接返回结构体结果
+var (
+	_ Trader      = (*OkxTrader)(nil)
+	_ TypedTrader = (*OkxTrader)(nil)
 )
 
 // OkxTrader Okx合约交易器
 type OkxTrader struct {
-	client *api.Client
+	// 客户端在连续认证错误后会被重建，通过api()访问
+	client       *api.Client
+	clientCancel context.CancelFunc
+	credentials  OkxCredentialsProvider
+	auth         okxAuthState
+	clientMu     sync.RWMutex
+
+	// 连接的服务器（实盘/模拟盘、REST地址与代理），创建后不再修改
+	endpoint okxEndpoint
 
 	// 余额缓存
-	cachedBalance     map[string]interface{}
+	cachedBalance     *Balance
 	balanceCacheTime  time.Time
 	balanceCacheMutex sync.RWMutex
 
 	// 持仓缓存
-	cachedPositions     []map[string]interface{}
+	cachedPositions     []*Position
 	positionsCacheTime  time.Time
 	positionsCacheMutex sync.RWMutex
 
-	// 缓存有效期（15秒）
+	// 缓存有效期（默认15秒，WithCacheDuration设置）
 	cacheDuration time.Duration
+
+	// 缓存过期时并发的余额/持仓查询只请求一次API
+	balanceFlight   flightGroup[*Balance]
+	positionsFlight flightGroup[[]*Position]
+
+	// 后台刷新（WithBackgroundRefresh设置间隔，StartBackgroundRefresh启动），
+	// 运行期间读取余额/持仓直接使用缓存（即使已超过有效期），不在交易决策中等待REST请求
+	refreshInterval time.Duration
+	refreshRunning  atomic.Bool
+	refreshDone     chan struct{}
+	refreshMu       sync.Mutex
+
+	// 结构化日志（WithSlogLogger注入；默认经标准库的全局logger输出文本，WithLogger替换输出位置），
+	// logLevel为默认文本输出的级别（WithLogLevel设置，默认Info，缓存命中等Debug日志不输出）
+	logger   *slog.Logger
+	logLevel *slog.LevelVar
+
+	// 各缓存的命中统计
+	cacheStats struct {
+		balance, positions, instruments, prices cacheCounter
+	}
+
+	// 交易规则缓存（key: instId），超过instrumentTTL后重新查询，并发未命中只查询一次
+	instruments      map[string]*okxInstrumentEntry
+	instrumentsMutex sync.RWMutex
+	instrumentTTL    time.Duration
+	instrumentFlight flightGroup[[]*okxInstrumentEntry]
+
+	// 阶梯保证金缓存（key: uly+tdMode）
+	positionTiers map[string]*okxTierCache
+	tiersMutex    sync.Mutex
+
+	// 系统币种格式（BTCUSDT）对应的品种类型，默认永续合约
+	instType okx.InstrumentType
+
+	// 持仓数量低于 minSz×dustRatio 时，HasPosition/PositionSize 视为无持仓（0表示不过滤）
+	dustRatio float64
+
+	// 低于最小下单量时的处理策略（默认拒绝）
+	minSizeBump MinSizeBumpPolicy
+
+	// 下单使用的保证金模式：isolated为未单独设置的币种的默认模式（默认全仓），
+	// marginModes为SetMarginMode按币种设置的模式（key: instId）
+	isolated    bool
+	marginModes map[string]okx.MarginMode
+	marginMu    sync.RWMutex
+
+	// 账户模式（acctLv）与持仓模式（posMode），查询成功后缓存，简单交易模式不支持合约保证金交易
+	acctLevel string
+	posMode   okx.PositionType
+
+	// 已确认的杠杆（key: instId+保证金模式），与目标一致时开仓不再设置杠杆
+	confirmedLeverage map[string]int
+	leverageMu        sync.Mutex
+
+	// 时间源（缓存过期、冷却期判断）
+	clock clock.Clock
+
+	// 各类调用的超时时间（OKX SDK不支持context，超时后后台调用继续执行）
+	timeouts TimeoutConfig
+
+	// 下单通道：可选优先使用WebSocket，未连接时使用REST
+	wsTrade        *okxWSTrade
+	preferWS       bool
+	transportStats map[string]*orderTransportStats
+	transportMu    sync.Mutex
+
+	// 限价单本地到期调度（EnableOrderExpiry启用）
+	orderExpiry *OrderExpiryScheduler
+
+	// 止损止盈的默认触发价类型（为空时使用最新成交价）与提交前的价格校验
+	triggerPxType TriggerPriceType
+	priceCheck    ProtectivePriceCheck
+	triggerMu     sync.RWMutex
+
+	// 客户端限速（按接口族的令牌桶，WithRateLimits设置），所有REST调用通过okxCall获取令牌
+	rateLimits  OkxRateLimits
+	rateLimiter *okxRateLimiter
+
+	// 运行指标（WithMetrics启用，为nil时不记录）
+	metrics *okxMetrics
+
+	// 交易事件回调（RegisterHook注册，异步分发）
+	events *tradeEventHub
+
+	// REST调用的健康状况（最近成功时间与错误计数，Health返回）
+	health okxHealth
+
+	// WebSocket行情（SubscribeTicker创建），订阅的币种优先使用推送的价格，超过tickerMaxAge视为过期
+	tickers      *okxTickerFeed
+	tickerMu     sync.Mutex
+	tickerMaxAge time.Duration
+
+	// 私有WebSocket（SubscribeOrderUpdates/SubscribePositions/SubscribeBalance创建，登录后订阅orders、positions与account频道），
+	// waitForFill优先使用订单推送，持仓与余额推送实时有效时缓存随推送更新
+	private       *okxStream
+	orders        *okxOrderFeed
+	positionsFeed *okxPositionFeed
+	balancesFeed  *okxBalanceFeed
+	privateMu     sync.Mutex
 }
 
-// NewOkxTrader 创建合约交易器
-func NewOkxTrader(apiKey, secretKey, passphrase string) *OkxTrader {
-	client, err := api.NewClient(context.Background(), apiKey, secretKey, passphrase, okx.NormalServer)
+// NewOkxTrader 创建合约交易器（不请求交易所，凭证错误要到第一次私有调用时才会发现）
+// opts在创建客户端之前应用，任一选项无效时返回错误
+func NewOkxTrader(apiKey, secretKey, passphrase string, opts ...OkxOption) (*OkxTrader, error) {
+	creds := OkxCredentials{APIKey: apiKey, SecretKey: secretKey, Passphrase: passphrase}
+	t := &OkxTrader{
+		credentials:   func() (OkxCredentials, error) { return creds, nil },
+		cacheDuration: 15 * time.Second, // 15秒缓存
+		logLevel:      new(slog.LevelVar),
+		instrumentTTL: okxInstrumentTTL,
+		instType:      okx.SwapInstrument,
+		dustRatio:     1,
+		clock:         clock.Real(),
+		timeouts:      DefaultTimeoutConfig(),
+		rateLimits:    DefaultOkxRateLimits(),
+		events:        newTradeEventHub(0),
+	}
+	t.logger = newTextLogger(log.Default(), t.logLevel)
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	t.rateLimiter = newOkxRateLimiter(t.rateLimits, t.clock)
+	client, cancel, err := newOkxClient(creds, t.endpoint, t.now)
 	if err != nil {
-		log.Fatal("获取 OKX 链接失败")
+		return nil, fmt.Errorf("创建 OKX 客户端失败: %w", err)
 	}
-	return &OkxTrader{
-		client:        client,
-		cacheDuration: 15 * time.Second, // 15秒缓存
+	t.client, t.clientCancel = client, cancel
+	if t.endpoint.demo {
+		t.logger.Info("OKX 交易器连接模拟盘")
+	}
+	return t, nil
+}
+
+// NewOkxTraderValidated 创建合约交易器并立即校验凭证，凭证无效时返回 ErrInvalidCredentials
+func NewOkxTraderValidated(apiKey, secretKey, passphrase string, opts ...OkxOption) (*OkxTrader, error) {
+	t, err := NewOkxTrader(apiKey, secretKey, passphrase, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.ValidateCredentials(); err != nil {
+		t.clientCancel()
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetTimeouts 设置各类调用的超时时间
+func (t *OkxTrader) SetTimeouts(cfg TimeoutConfig) {
+	t.timeouts = cfg
+}
+
+// InvalidateCache 清除余额和持仓缓存，下次查询直接请求API
+func (t *OkxTrader) InvalidateCache() {
+	t.balanceCacheMutex.Lock()
+	t.cachedBalance = nil
+	t.balanceCacheMutex.Unlock()
+
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = nil
+	t.positionsCacheMutex.Unlock()
+}
+
+// CacheStats 返回余额、持仓、交易规则与价格缓存的统计（价格不缓存，每次查询计为未命中）
+func (t *OkxTrader) CacheStats() []CacheStat {
+	now := t.clock.Now()
+	return []CacheStat{
+		t.cacheStats.balance.stat("balance", now),
+		t.cacheStats.positions.stat("positions", now),
+		t.cacheStats.instruments.stat("instruments", now),
+		t.cacheStats.prices.stat("prices", now),
 	}
 }
 
+// SetDustThreshold 设置残仓阈值（最小下单张数minSz的倍数，默认1，0表示不过滤）
+func (t *OkxTrader) SetDustThreshold(ratio float64) {
+	if ratio < 0 {
+		ratio = 0
+	}
+	t.dustRatio = ratio
+}
+
+// SetClock 替换时间源（测试中注入假时钟）
+func (t *OkxTrader) SetClock(c clock.Clock) {
+	t.clock = clock.OrReal(c)
+	t.rateLimiter = newOkxRateLimiter(t.rateLimits, t.clock)
+}
+
+// now 交易器时钟的当前时间
+func (t *OkxTrader) now() time.Time {
+	return t.clock.Now()
+}
+
 // GetBalance 获取账户余额（带缓存）
 func (t *OkxTrader) GetBalance() (map[string]interface{}, error) {
+	return t.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext 同GetBalance，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetBalanceContext(ctx context.Context) (map[string]interface{}, error) {
+	balance, err := t.Balance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return balance.Map(), nil
+}
+
+// Balance 实现TypedTrader：获取账户余额（带缓存）
+func (t *OkxTrader) Balance(ctx context.Context) (*Balance, error) {
+	return t.balance(ctx, false)
+}
+
+// RefreshBalance 跳过缓存直接查询账户余额（结果同时更新缓存）
+func (t *OkxTrader) RefreshBalance() (map[string]interface{}, error) {
+	balance, err := t.balance(context.Background(), true)
+	if err != nil {
+		return nil, err
+	}
+	return balance.Map(), nil
+}
+
+// balance 获取账户余额，force为true时不使用缓存（余额推送实时有效时直接返回推送维护的缓存）
+func (t *OkxTrader) balance(ctx context.Context, force bool) (*Balance, error) {
+	if !force {
+		if live, ok := t.liveBalance(); ok {
+			t.cacheStats.balance.hit()
+			t.metrics.cache("balance", true)
+			return live, nil
+		}
+	}
+
 	// 先检查缓存是否有效
 	t.balanceCacheMutex.RLock()
-	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
-		cacheAge := time.Since(t.balanceCacheTime)
+	if !force && t.cachedBalance != nil && t.cacheUsable(t.balanceCacheTime) {
+		cacheAge := t.clock.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
-		return t.cachedBalance, nil
+		t.logger.Debug("使用缓存的账户余额", "age", cacheAge)
+		t.cacheStats.balance.hit()
+		t.metrics.cache("balance", true)
+		balance := *t.cachedBalance
+		return &balance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
+	t.cacheStats.balance.miss()
+	t.metrics.cache("balance", false)
 
-	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用OkxAPI获取账户余额...")
-	balance, err := t.client.Rest.Account.GetBalance(account2.GetBalance{})
-	if err != nil || balance.Balances == nil {
-		log.Printf("❌ OkxAPI调用失败: %v", err)
-		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	// 并发未命中只请求一次，其余调用共享结果（各自得到副本）
+	result, err, _ := t.balanceFlight.Do("balance", func() (*Balance, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.balanceCacheMutex.RLock()
+		if !force && t.cachedBalance != nil && t.cacheUsable(t.balanceCacheTime) {
+			cached := *t.cachedBalance
+			t.balanceCacheMutex.RUnlock()
+			return &cached, nil
+		}
+		t.balanceCacheMutex.RUnlock()
+		result, err := t.fetchBalance(ctx)
+		t.cacheStats.balance.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	if err != nil {
+		return nil, err
 	}
-	a := balance.Balances[0]
+	balance := *result
+	return &balance, nil
+}
 
-	result := make(map[string]interface{})
-	result["totalWalletBalance"], _ = strconv.ParseFloat(a.TotalEq, 64)
-	result["availableBalance"], _ = strconv.ParseFloat(a.AvailEq, 64)
-	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(a.Upl, 64)
+// fetchBalance 调用API获取账户余额并更新缓存
+func (t *OkxTrader) fetchBalance(ctx context.Context) (*Balance, error) {
+	start := t.clock.Now()
+	balance, err := okxCall(ctx, t, OpPrivateRead, "GetBalance", func() (accountResp.GetBalance, error) {
+		return t.api().Rest.Account.GetBalance(account2.GetBalance{})
+	})
+	if err != nil {
+		t.logger.Error("获取账户余额失败", "latency", t.clock.Since(start), "err", err)
+		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	}
+	if err := okxCheck("GetBalance", balance.Basic, len(balance.Balances)); err != nil {
+		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	}
+	// 解析失败时不能把0当作真实余额（会被风控误判为爆仓），优先返回上次的有效余额并标记为过期
+	result, err := okxBalance(balance.Balances[0], nil)
+	if err != nil {
+		return t.staleBalance(err)
+	}
 
-	log.Printf("✓ OkxAPI返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
-		a.TotalEq, a.AvailEq, a.Upl)
+	t.logger.Debug("已获取账户余额", "totalEq", result.TotalWalletBalance, "availEq", result.AvailableBalance,
+		"upl", result.TotalUnrealizedProfit, "crossAvail", result.CrossAvailableBalance, "isoEq", result.IsolatedEquity,
+		"ordFroz", result.FrozenInOrders, "latency", t.clock.Since(start))
 
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
 	t.cachedBalance = result
-	t.balanceCacheTime = time.Now()
+	t.balanceCacheTime = t.clock.Now()
 	t.balanceCacheMutex.Unlock()
 
+	balanceCopy := *result
+	return &balanceCopy, nil
+}
+
+// okxBalance OKX账户余额转换为Balance，账户级字段异常时返回 ErrMalformedBalance
+// base不为nil时，a.Details只包含有变化的币种（WebSocket推送），其余币种沿用base
+func okxBalance(a *accountModel.Balance, base map[string]CurrencyBalance) (*Balance, error) {
+	totalEq, errTotal := parseBalanceField("totalEq", a.TotalEq)
+	availEq, errAvail := parseBalanceField("availEq", a.AvailEq)
+	upl, errUpl := parseBalanceField("upl", a.Upl)
+	isoEq, errIso := parseOptionalBalanceField("isoEq", a.IsoEq)
+	ordFroz, errFroz := parseOptionalBalanceField("ordFroz", a.OrdFroz)
+	if err := errors.Join(errTotal, errAvail, errUpl, errIso, errFroz); err != nil {
+		return nil, err
+	}
+
+	result := &Balance{
+		TotalWalletBalance:    totalEq,
+		AvailableBalance:      availEq,
+		TotalUnrealizedProfit: upl,
+		Currencies:            make(map[string]CurrencyBalance, len(base)+len(a.Details)),
+	}
+	for ccy, c := range base {
+		result.Currencies[ccy] = c
+	}
+
+	// 各币种权益（币本位合约以结算币种计价，eqUsd为折算后的USD）
+	for _, d := range a.Details {
+		result.Currencies[d.Ccy] = CurrencyBalance{
+			Equity:         float64(d.Eq),
+			EquityUSD:      float64(d.EqUsd),
+			Available:      float64(d.AvailEq),
+			UnrealizedPnL:  float64(d.Upl),
+			IsolatedEquity: float64(d.IsoEq),
+			OrderFrozen:    float64(d.OrdFrozen),
+		}
+	}
+	detailFrozen := 0.0
+	for _, c := range result.Currencies {
+		frozen := c.OrderFrozen
+		if c.Equity != 0 {
+			frozen = decimalFloat(toDecimal(frozen).Mul(toDecimal(c.EquityUSD)).Div(toDecimal(c.Equity)))
+		}
+		detailFrozen = sumFloat64(detailFrozen, frozen)
+	}
+
+	// 全仓/逐仓拆分：账户级ordFroz只在跨币种保证金模式下返回，其他模式按币种汇总
+	if a.OrdFroz == "" {
+		ordFroz = detailFrozen
+	}
+	crossAvail := decimalFloat(toDecimal(totalEq).Sub(toDecimal(isoEq)).Sub(toDecimal(ordFroz)))
+	if availEq < crossAvail {
+		crossAvail = availEq
+	}
+	if crossAvail < 0 {
+		crossAvail = 0
+	}
+	result.IsolatedEquity = isoEq
+	result.FrozenInOrders = ordFroz
+	result.CrossAvailableBalance = crossAvail
+	return result, nil
+}
+
+// parseBalanceField 解析余额字段，空字符串、非数字或NaN/Inf返回 ErrMalformedBalance
+func parseBalanceField(name, value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%w: %s=%q", ErrMalformedBalance, name, value)
+	}
+	return v, nil
+}
+
+// parseOptionalBalanceField 解析可能不返回的余额字段（空字符串为0），非数字返回 ErrMalformedBalance
+func parseOptionalBalanceField(name, value string) (float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	return parseBalanceField(name, value)
+}
+
+// staleBalance 余额数据异常时返回上次缓存的有效余额（stale=true），没有缓存时返回错误
+func (t *OkxTrader) staleBalance(cause error) (*Balance, error) {
+	t.balanceCacheMutex.RLock()
+	cached, cachedAt := t.cachedBalance, t.balanceCacheTime
+	t.balanceCacheMutex.RUnlock()
+	if cached == nil {
+		t.logger.Error("OKX余额数据异常且无可用缓存", "err", cause)
+		return nil, cause
+	}
+
+	t.logger.Warn("OKX余额数据异常，使用缓存余额", "age", t.clock.Since(cachedAt), "err", cause)
+	result := *cached
+	result.Stale = true
+	result.StaleSince = cachedAt
+	return &result, nil
+}
+
+// GetPositions 获取所有持仓（带缓存）
+// positionAmt为币的数量（空仓为负），contracts为张数；盈亏统一折算为USD，原始结算币种盈亏见unRealizedProfitCcy
+func (t *OkxTrader) GetPositions() ([]map[string]interface{}, error) {
+	return t.GetPositionsContext(context.Background())
+}
+
+// GetPositionsContext 同GetPositions，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetPositionsContext(ctx context.Context) ([]map[string]interface{}, error) {
+	positions, err := t.Positions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, pos := range positions {
+		result = append(result, pos.Map())
+	}
+	return result, nil
+}
+
+// Positions 实现TypedTrader：获取所有持仓（带缓存，Quantity为币的数量）
+func (t *OkxTrader) Positions(ctx context.Context) ([]*Position, error) {
+	return t.positions(ctx, false)
+}
+
+// RefreshPositions 跳过缓存直接查询持仓（结果同时更新缓存）
+func (t *OkxTrader) RefreshPositions() ([]map[string]interface{}, error) {
+	positions, err := t.positions(context.Background(), true)
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, pos := range positions {
+		result = append(result, pos.Map())
+	}
+	return result, nil
+}
+
+// positions 获取所有持仓，force为true时不使用缓存
+// 持仓推送实时有效时直接返回推送维护的缓存（source为ws），否则使用REST与缓存（source为rest）
+func (t *OkxTrader) positions(ctx context.Context, force bool) ([]*Position, error) {
+	if !force {
+		if live, ok := t.livePositions(); ok {
+			t.cacheStats.positions.hit()
+			t.metrics.cache("positions", true)
+			return live, nil
+		}
+	}
+
+	// 先检查缓存是否有效
+	t.positionsCacheMutex.RLock()
+	if !force && t.cachedPositions != nil && t.cacheUsable(t.positionsCacheTime) {
+		cacheAge := t.clock.Since(t.positionsCacheTime)
+		t.positionsCacheMutex.RUnlock()
+		t.logger.Debug("使用缓存的持仓信息", "age", cacheAge)
+		t.cacheStats.positions.hit()
+		t.metrics.cache("positions", true)
+		return withPositionSource(copyPositions(t.cachedPositions), PositionSourceREST), nil
+	}
+	t.positionsCacheMutex.RUnlock()
+	t.cacheStats.positions.miss()
+	t.metrics.cache("positions", false)
+
+	// 并发未命中只请求一次，其余调用共享结果（各自得到副本）
+	result, err, _ := t.positionsFlight.Do("positions", func() ([]*Position, error) {
+		// 上一次合并的查询可能刚刚写入缓存
+		t.positionsCacheMutex.RLock()
+		if !force && t.cachedPositions != nil && t.cacheUsable(t.positionsCacheTime) {
+			cached := copyPositions(t.cachedPositions)
+			t.positionsCacheMutex.RUnlock()
+			return cached, nil
+		}
+		t.positionsCacheMutex.RUnlock()
+		result, err := t.fetchPositions(ctx)
+		t.cacheStats.positions.refreshed(t.clock.Now(), err)
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withPositionSource(copyPositions(result), PositionSourceREST), nil
+}
+
+// fetchPositions 调用API获取持仓并更新缓存
+func (t *OkxTrader) fetchPositions(ctx context.Context) ([]*Position, error) {
+	start := t.clock.Now()
+	positions, err := okxCall(ctx, t, OpPrivateRead, "GetPositions", func() (accountResp.GetPositions, error) {
+		return t.api().Rest.Account.GetPositions(account2.GetPositions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	if err := okxCheck("GetPositions", positions.Basic, -1); err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	// 无持仓时也是非nil的空列表，否则缓存被视为未填充，每次查询都会请求API
+	result := make([]*Position, 0, len(positions.Positions))
+	for _, pos := range positions.Positions {
+		p, err := t.okxPosition(pos)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			result = append(result, p)
+		}
+	}
+
+	t.logger.Debug("已获取持仓", "count", len(result), "latency", t.clock.Since(start))
+	t.metrics.positions(result)
+
+	// 更新缓存
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = result
+	t.positionsCacheTime = t.clock.Now()
+	t.positionsCacheMutex.Unlock()
+
+	return copyPositions(result), nil
+}
+
+// okxPosition OKX持仓转换为Position，无持仓或非合约持仓返回nil
+func (t *OkxTrader) okxPosition(pos *accountModel.Position) (*Position, error) {
+	contracts := float64(pos.Pos)
+	if contracts == 0 {
+		return nil, nil // 跳过无持仓的
+	}
+	if pos.InstType != okx.SwapInstrument && pos.InstType != okx.FuturesInstrument {
+		return nil, nil // 只处理永续与交割合约
+	}
+
+	inst, err := t.getInstrument(pos.InstID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 判断方向（双向持仓看posSide，单向持仓看数量正负）
+	side := PositionLong
+	if pos.PosSide == okx.PositionShortSide || (pos.PosSide != okx.PositionLongSide && contracts < 0) {
+		side = PositionShort
+	}
+	if contracts < 0 {
+		contracts = -contracts
+	}
+
+	markPrice := float64(pos.MarkPx)
+	return &Position{
+		Symbol:            okxSymbol(pos.InstID),
+		Side:              side,
+		Quantity:          decimalFloat(contractsToCoin(inst, contracts, markPrice)),
+		EntryPrice:        float64(pos.AvgPx),
+		MarkPrice:         markPrice,
+		UnrealizedPnL:     settleToUSD(inst, float64(pos.Upl), markPrice),
+		Leverage:          int(pos.Lever),
+		LiquidationPrice:  float64(pos.LiqPx),
+		MarginMode:        string(pos.MgnMode),
+		MarginRatio:       float64(pos.MgnRatio),
+		InstID:            pos.InstID,
+		InstType:          string(pos.InstType),
+		Contracts:         contracts,
+		UnrealizedPnLCcy:  float64(pos.Upl),
+		SettleCcy:         inst.SettleCcy,
+		IsolatedMargin:    float64(pos.Margin),
+		IsolatedMarginUSD: settleToUSD(inst, float64(pos.Margin), markPrice),
+		OpenTime:          time.Time(pos.CTime),
+	}, nil
+}
+
+// copyPositions 复制持仓列表，调用方修改返回值不会影响缓存
+func copyPositions(positions []*Position) []*Position {
+	result := make([]*Position, 0, len(positions))
+	for _, pos := range positions {
+		p := *pos
+		result = append(result, &p)
+	}
+	return result
+}
+
+// AdjustMargin 追加或减少逐仓持仓的保证金（amount为结算币种数量，>0追加，<0减少）
+func (t *OkxTrader) AdjustMargin(symbol string, side PositionSide, amount float64) error {
+	if amount == 0 {
+		return nil
+	}
+	pos, err := t.GetPosition(symbol, side)
+	if err != nil {
+		return err
+	}
+	if pos.MarginMode != string(okx.MarginIsolatedMode) {
+		return fmt.Errorf("%s %s 为%s持仓，只能调整逐仓持仓的保证金", symbol, side, pos.MarginMode)
+	}
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+
+	_, posSide := t.okxSides(context.Background(), pos.Side, true)
+	action := okx.CountIncrease
+	if amount < 0 {
+		action, amount = okx.CountDecrease, -amount
+	}
+	resp, err := okxCall(context.Background(), t, OpMutation, "IncreaseDecreaseMargin", func() (accountResp.IncreaseDecreaseMargin, error) {
+		return t.api().Rest.Account.IncreaseDecreaseMargin(account2.IncreaseDecreaseMargin{
+			InstID:     instID,
+			Amt:        amount,
+			PosSide:    posSide,
+			ActionType: action,
+		})
+	})
+	if err == nil {
+		err = okxCheck("IncreaseDecreaseMargin", resp.Basic, len(resp.MarginBalanceAmounts))
+	}
+	if err != nil {
+		return fmt.Errorf("调整 %s %s 保证金失败: %w", symbol, side, err)
+	}
+
+	t.InvalidateCache()
+	t.logger.Info("逐仓保证金已调整", "symbol", symbol, "side", side, "action", action, "amount", amount)
+	return nil
+}
+
+// SetMarginMode 设置该币种后续下单使用的保证金模式
+// OKX的保证金模式随订单（tdMode）指定，这里校验账户模式支持合约保证金交易，
+// 且该币种没有其他模式的持仓（同一币种混用全仓与逐仓返回 ErrMarginModeConflict），通过后记录该币种的模式
+func (t *OkxTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return t.SetMarginModeContext(context.Background(), symbol, isCrossMargin)
+}
+
+// SetMarginModeContext 同SetMarginMode，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) SetMarginModeContext(ctx context.Context, symbol string, isCrossMargin bool) error {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+	mode := okx.MarginCrossMode
+	if !isCrossMargin {
+		mode = okx.MarginIsolatedMode
+	}
+	if err := t.checkAccountMarginSupport(ctx); err != nil {
+		return err
+	}
+	if err := t.checkMarginConflict(ctx, symbol, instID, mode); err != nil {
+		return err
+	}
+
+	t.marginMu.Lock()
+	if t.marginModes == nil {
+		t.marginModes = make(map[string]okx.MarginMode)
+	}
+	previous, ok := t.marginModes[instID]
+	t.marginModes[instID] = mode
+	t.marginMu.Unlock()
+
+	if !ok || previous != mode {
+		t.logger.Info("仓位模式已设置", "symbol", symbol, "marginMode", okxMarginModeName(mode))
+	}
+	return nil
+}
+
+// marginMode 该交易对下单使用的保证金模式（未单独设置时使用默认模式）
+func (t *OkxTrader) marginMode(instID string) okx.MarginMode {
+	t.marginMu.RLock()
+	defer t.marginMu.RUnlock()
+	if mode, ok := t.marginModes[instID]; ok {
+		return mode
+	}
+	if t.isolated {
+		return okx.MarginIsolatedMode
+	}
+	return okx.MarginCrossMode
+}
+
+// tradeMode 该交易对下单使用的tdMode
+func (t *OkxTrader) tradeMode(instID string) okx.TradeMode {
+	return okxTradeMode(t.marginMode(instID))
+}
+
+// SetLeverage 设置杠杆
+func (t *OkxTrader) SetLeverage(symbol string, leverage int) error {
+	return t.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return err
+	}
+
+	mgnMode := t.marginMode(instID)
+	if t.leverageConfirmed(instID, mgnMode) == leverage {
+		return nil
+	}
+
+	// 先尝试获取当前杠杆（从持仓信息，多空任一方向）
+	currentLeverage := 0
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		if pos, err := t.GetPositionContext(ctx, symbol, side); err == nil {
+			currentLeverage = pos.Leverage
+			break
+		}
+	}
+
+	// 如果当前杠杆已经是目标杠杆，跳过
+	if currentLeverage == leverage && currentLeverage > 0 {
+		t.logger.Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
+		return nil
+	}
+
+	// 双向持仓的逐仓模式下多空杠杆分别设置，其他情况一次设置
+	posSides := []okx.PositionSide{""}
+	if mode, _ := t.accountPositionMode(ctx); mgnMode == okx.MarginIsolatedMode && mode != okx.PositionNetMode {
+		posSides = []okx.PositionSide{okx.PositionLongSide, okx.PositionShortSide}
+	}
+	if err := t.setLeverageSides(ctx, instID, leverage, mgnMode, posSides); err != nil {
+		return fmt.Errorf("设置 %s 杠杆 %dx 失败: %w", symbol, leverage, err)
+	}
+
+	t.logger.Info("杠杆已切换", "symbol", symbol, "leverage", leverage)
+	return nil
+}
+
+// setLeverageSides 按持仓方向设置杠杆（全仓传入空方向），全部成功后记为已确认
+func (t *OkxTrader) setLeverageSides(ctx context.Context, instID string, leverage int, mgnMode okx.MarginMode, posSides []okx.PositionSide) error {
+	for _, posSide := range posSides {
+		req := account2.SetLeverage{
+			InstID:  instID,
+			Lever:   int64(leverage),
+			MgnMode: mgnMode,
+			PosSide: posSide,
+		}
+		resp, err := okxCall(ctx, t, OpMutation, "SetLeverage", func() (accountResp.Leverage, error) {
+			return t.api().Rest.Account.SetLeverage(req)
+		})
+		if err == nil {
+			err = okxCheck("SetLeverage", resp.Basic, len(resp.Leverages))
+		}
+		if err != nil {
+			t.confirmLeverage(instID, mgnMode, 0)
+			if posSide != "" {
+				return fmt.Errorf("%s方向: %w", posSide, err)
+			}
+			return err
+		}
+	}
+	t.confirmLeverage(instID, mgnMode, leverage)
+	return nil
+}
+
+// toContracts 将币的数量转换为下单张数（向下取整到lotSz），低于最小下单量时返回 *MinSizeError
+func (t *OkxTrader) toContracts(symbol string, quantity float64) (string, float64, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return "", 0, err
+	}
+	price := 0.0
+	if isInverseContract(inst) {
+		if price, err = t.getMarkPrice(symbol); err != nil {
+			return "", 0, err
+		}
+	}
+	contracts, err := t.lotContracts(symbol, inst, quantity, price)
+	if err != nil {
+		return "", 0, err
+	}
+	return inst.InstID, contracts, nil
+}
+
+// checkOrderNotional 按提交的张数与标记价格检查单笔名义价值上限
+func (t *OkxTrader) checkOrderNotional(symbol string, contracts float64) error {
+	if MaxOrderNotional(symbol) <= 0 {
+		return nil
+	}
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return err
+	}
+	price, err := t.getMarkPrice(symbol)
+	if err != nil {
+		return err
+	}
+	notional := contractNotionalUSD(inst, price).Mul(toDecimal(contracts))
+	return checkOrderNotional(symbol, decimalFloat(notional))
+}
+
+// placeMarketOrder 下市价单并查询成交结果，tdMode为空时使用该币种设置的保证金模式
+// contracts大于0时直接按该张数下单（已按lotSz取整），否则由币的数量quantity换算
+func (t *OkxTrader) placeMarketOrder(ctx context.Context, symbol string, quantity, contracts float64, side okx.OrderSide, posSide okx.PositionSide, reduceOnly bool, tdMode okx.TradeMode) (*OrderResult, error) {
+	var instID string
+	var err error
+	if contracts > 0 {
+		instID, _, err = t.resolveInstID(symbol)
+	} else {
+		instID, contracts, err = t.toContracts(symbol, quantity)
+	}
+	var bump *SizeBump
+	if errors.Is(err, ErrBelowMinSize) && !reduceOnly {
+		instID, contracts, bump, err = t.bumpToMinSize(symbol, quantity, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !reduceOnly {
+		if err := t.checkOrderNotional(symbol, contracts); err != nil {
+			return nil, err
+		}
+	}
+	if tdMode == "" {
+		tdMode = t.tradeMode(instID)
+	}
+
+	order, err := t.submitOrder(ctx, tradeReq.PlaceOrder{
+		InstID:     instID,
+		TdMode:     tdMode,
+		Side:       side,
+		PosSide:    posSide,
+		OrdType:    okx.OrderMarket,
+		Sz:         contracts,
+		ReduceOnly: reduceOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrderResult{
+		OrderID:       order.OrdID,
+		ClientOrderID: order.ClOrdID,
+		Symbol:        symbol,
+		Side:          strings.ToUpper(string(side)),
+		PositionSide:  strings.ToUpper(string(posSide)),
+		Status:        "NEW",
+		MarginMode:    string(tdMode),
+		SizeBump:      bump,
+		Time:          t.clock.Now(),
+	}
+
+	// 市价单通常立即成交，查询订单详情获取成交均价、数量与手续费
+	// 确认成交后再清一次缓存，避免等待期间的查询把成交前的持仓写回缓存
+	detail, err := t.waitForFill(ctx, instID, order.OrdID)
+	t.InvalidateCache()
+	if err != nil {
+		t.logger.Warn("查询订单成交信息失败", "symbol", symbol, "orderId", order.OrdID, "err", err)
+		return result, nil
+	}
+	t.applyFill(result, instID, detail)
+	return result, nil
+}
+
+// okxFillPollAttempts 成交确认的查询次数与间隔
+const (
+	okxFillPollAttempts = 5
+	okxFillPollInterval = 200 * time.Millisecond
+)
+
+// waitForFill 等待订单完成（成交或撤销）：已订阅订单推送时等待推送，否则（或推送超时）查询订单详情，超过查询次数时返回最后一次的结果
+func (t *OkxTrader) waitForFill(ctx context.Context, instID, ordID string) (*tradeModel.Order, error) {
+	if detail, ok := t.waitForFillStream(ctx, ordID); ok {
+		return detail, nil
+	}
+	var detail *tradeModel.Order
+	for attempt := 0; attempt < okxFillPollAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, t.clock, okxFillPollInterval); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := okxCall(ctx, t, OpPrivateRead, "GetOrderDetail", func() (tradeResp.OrderList, error) {
+			return t.api().Rest.Trade.GetOrderDetail(tradeReq.OrderDetails{InstID: instID, OrdID: ordID})
+		})
+		if err == nil {
+			err = okxResponseError("GetOrderDetail", resp.Code, resp.Msg, 0, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Orders) == 0 {
+			continue
+		}
+		detail = resp.Orders[0]
+		if detail.State == okx.OrderFilled || detail.State == okx.OrderCancel {
+			return detail, nil
+		}
+	}
+	if detail == nil {
+		return nil, okxEmptyResponse("GetOrderDetail")
+	}
+	return detail, nil
+}
+
+// applyFill 将订单详情中的成交信息写入下单结果
+func (t *OkxTrader) applyFill(result *OrderResult, instID string, detail *tradeModel.Order) {
+	result.Status = okxOrderStatus(detail.State)
+	result.AvgPrice = float64(detail.AvgPx)
+	result.Fee = float64(detail.Fee)
+	result.FeeAsset = detail.FeeCcy
+	result.RealizedPnL = float64(detail.Pnl)
+	if detail.Lever > 0 {
+		result.Leverage = int(detail.Lever)
+	}
+	if !time.Time(detail.UTime).IsZero() {
+		result.Time = time.Time(detail.UTime)
+	}
+	// 成交数量以张为单位，转换为币的数量
+	result.FilledQty = float64(detail.AccFillSz)
+	if inst, err := t.getInstrument(instID); err == nil {
+		result.FilledQty = decimalFloat(contractsToCoin(inst, float64(detail.AccFillSz), result.AvgPrice))
+	}
+}
+
+// okxOrderStatus 将OKX订单状态转换为统一格式
+func okxOrderStatus(state okx.OrderState) string {
+	switch state {
+	case okx.OrderFilled:
+		return "FILLED"
+	case okx.OrderPartiallyFilled:
+		return "PARTIALLY_FILLED"
+	case okx.OrderLive:
+		return "NEW"
+	case okx.OrderCancel:
+		return "CANCELED"
+	}
+	return strings.ToUpper(string(state))
+}
+
+// OpenLong 开多仓
+func (t *OkxTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.OpenLongContext(context.Background(), symbol, quantity, leverage)
+}
+
+// OpenLongContext 同OpenLong，ctx结束时放弃等待并返回 ErrCanceled
+// 下单请求已发出后取消时按clOrdId对账，返回的错误满足 IsOutcomeUnknown 时订单可能已成交
+func (t *OkxTrader) OpenLongContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenLongOrder(ctx, symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenLongOrder 实现TypedTrader：开多仓并返回下单结果
+func (t *OkxTrader) OpenLongOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.openMarket(ctx, symbol, PositionLong, quantity, 0, leverage)
+}
+
+// OpenShort 开空仓
+func (t *OkxTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.OpenShortContext(context.Background(), symbol, quantity, leverage)
+}
+
+// OpenShortContext 同OpenShort，ctx结束时放弃等待并返回 ErrCanceled
+// 下单请求已发出后取消时按clOrdId对账，返回的错误满足 IsOutcomeUnknown 时订单可能已成交
+func (t *OkxTrader) OpenShortContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenShortOrder(ctx, symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenShortOrder 实现TypedTrader：开空仓并返回下单结果
+func (t *OkxTrader) OpenShortOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.openMarket(ctx, symbol, PositionShort, quantity, 0, leverage)
+}
+
+// openMarket 市价开仓，contracts大于0时按张数下单（quantity为对应的币的数量，用于阶梯保证金检查）
+func (t *OkxTrader) openMarket(ctx context.Context, symbol string, side PositionSide, quantity, contracts float64, leverage int) (*OrderResult, error) {
+	label := "开多仓"
+	if side == PositionShort {
+		label = "开空仓"
+	}
+
+	// 撤单与设置杠杆之前校验数量，低于最小下单量且未开启自动提高时直接拒绝
+	if contracts <= 0 {
+		if err := t.ValidateOrderSize(symbol, quantity, 0); err != nil && !(errors.Is(err, ErrBelowMinSize) && t.minSizeBump.Enabled) {
+			return nil, fmt.Errorf("%s失败: %w", label, err)
+		}
+	}
+
+	// 按加仓后的持仓规模检查阶梯杠杆上限
+	if err := t.checkPositionTier(symbol, side, quantity, leverage); err != nil {
+		return nil, err
+	}
+	if err := t.checkSymbolMarginMode(ctx, symbol); err != nil {
+		return nil, err
+	}
+
+	// 先取消该币种的所有委托单（清理旧的止损止盈单）
+	if err := t.CancelAllOrdersContext(ctx, symbol); err != nil {
+		t.logger.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
+	}
+
+	// 设置杠杆
+	if err := t.SetLeverageContext(ctx, symbol, leverage); err != nil {
+		return nil, err
+	}
+
+	orderSide, posSide := t.okxSides(ctx, side, false)
+	start := t.clock.Now()
+	result, err := t.placeMarketOrder(ctx, symbol, quantity, contracts, orderSide, posSide, false, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s失败: %w", label, err)
+	}
+	if result.Leverage == 0 {
+		result.Leverage = leverage
+	}
+
+	t.logger.Info(label+"成功", "symbol", symbol, "side", side, "size", result.FilledQty, "avgPx", result.AvgPrice,
+		"fee", result.Fee, "feeCcy", result.FeeAsset, "orderId", result.OrderID, "latency", t.clock.Since(start))
+	t.emitFillEvent(EventPositionOpened, side, result, 0)
+	return result, nil
+}
+
+// GetPosition 获取指定币种和方向的持仓（按instId匹配，兼容双向持仓同时持有多空）
+func (t *OkxTrader) GetPosition(symbol string, side PositionSide) (*Position, error) {
+	return t.GetPositionContext(context.Background(), symbol, side)
+}
+
+// GetPositionContext 同GetPosition，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) GetPositionContext(ctx context.Context, symbol string, side PositionSide) (*Position, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := t.Positions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		if pos.InstID == instID && pos.Side == side && pos.Quantity > 0 {
+			return pos, nil
+		}
+	}
+	return nil, &PositionNotFoundError{Symbol: symbol, Side: side}
+}
+
+// PositionSize 获取指定方向的持仓数量（币），无持仓或残仓返回0
+// refresh为true时忽略缓存直接查询交易所
+func (t *OkxTrader) PositionSize(symbol string, side PositionSide, refresh bool) (float64, error) {
+	if refresh {
+		t.InvalidateCache()
+	}
+	pos, err := t.GetPosition(symbol, side)
+	if errors.Is(err, ErrPositionNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if t.dustRatio > 0 {
+		inst, err := t.getInstrument(symbol)
+		if err != nil {
+			return 0, err
+		}
+		dust := contractsToCoin(inst, float64(inst.MinSz)*t.dustRatio, pos.MarkPrice)
+		if toDecimal(pos.Quantity).LessThan(dust) {
+			t.logger.Debug("持仓低于残仓阈值，视为无持仓", "symbol", symbol, "side", pos.Side, "size", pos.Quantity, "dust", dust)
+			return 0, nil
+		}
+	}
+	return pos.Quantity, nil
+}
+
+// HasPosition 是否持有指定方向的仓位（残仓视为无持仓）
+func (t *OkxTrader) HasPosition(symbol string, side PositionSide, refresh bool) (bool, error) {
+	size, err := t.PositionSize(symbol, side, refresh)
+	if err != nil {
+		return false, err
+	}
+	return size > 0, nil
+}
+
+// CloseLong 平多仓（quantity为0时平掉全部多仓）
+func (t *OkxTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(context.Background(), symbol, PositionLong, quantity)
+}
+
+// CloseLongContext 同CloseLong，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) CloseLongContext(ctx context.Context, symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(ctx, symbol, PositionLong, quantity)
+}
+
+// CloseLongOrder 实现TypedTrader：平多仓并返回下单结果
+func (t *OkxTrader) CloseLongOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.closePositionOrder(ctx, symbol, PositionLong, quantity)
+}
+
+// CloseShort 平空仓（quantity为0时平掉全部空仓）
+func (t *OkxTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(context.Background(), symbol, PositionShort, quantity)
+}
+
+// CloseShortContext 同CloseShort，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) CloseShortContext(ctx context.Context, symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.closePosition(ctx, symbol, PositionShort, quantity)
+}
+
+// CloseShortOrder 实现TypedTrader：平空仓并返回下单结果
+func (t *OkxTrader) CloseShortOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.closePositionOrder(ctx, symbol, PositionShort, quantity)
+}
+
+// ClosePositionPercent 按比例平仓（pct为1-100的百分比，100等同于全部平仓）
+// 部分平仓的数量按下单精度向下取整，剩余持仓的止损止盈单保留
+func (t *OkxTrader) ClosePositionPercent(symbol string, side PositionSide, pct float64) (map[string]interface{}, error) {
+	return t.ClosePositionPercentContext(context.Background(), symbol, side, pct)
+}
+
+// ClosePositionPercentContext 同ClosePositionPercent，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) ClosePositionPercentContext(ctx context.Context, symbol string, side PositionSide, pct float64) (map[string]interface{}, error) {
+	if pct <= 0 || pct > 100 {
+		return nil, fmt.Errorf("无效的平仓比例: %v（应在 0-100 之间）", pct)
+	}
+	if pct == 100 {
+		return t.closePosition(ctx, symbol, side, 0)
+	}
+	pos, err := t.GetPositionContext(ctx, symbol, side)
+	if err != nil {
+		return nil, err
+	}
+	return t.closePosition(ctx, symbol, side, decimalFloat(toDecimal(pos.Quantity).Mul(toDecimal(pct)).Div(toDecimal(100))))
+}
+
+// closePosition 市价只减仓平仓
+// quantity为0（或不小于持仓数量）时全部平仓并撤销该币种的挂单；部分平仓只下指定数量的只减仓单，
+// 不撤销挂单，剩余持仓的止损止盈继续有效
+// 已无持仓时不下单并返回 ErrPositionNotFound：全部平仓仍撤销该币种的挂单（残留的止损止盈），部分平仓不撤单
+func (t *OkxTrader) closePosition(ctx context.Context, symbol string, side PositionSide, quantity float64) (map[string]interface{}, error) {
+	result, err := t.closePositionOrder(ctx, symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// closePositionOrder 同closePosition，返回下单结果
+func (t *OkxTrader) closePositionOrder(ctx context.Context, symbol string, side PositionSide, quantity float64) (*OrderResult, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	label := "平多仓"
+	if side == PositionShort {
+		label = "平空仓"
+	}
+
+	full := quantity == 0
+	pos, err := t.GetPositionContext(ctx, symbol, side)
+	if errors.Is(err, ErrPositionNotFound) {
+		if full {
+			// 持仓已被止损/止盈平掉：仍清理残留挂单，返回可识别的 ErrPositionNotFound
+			if cancelErr := t.CancelAllOrdersContext(ctx, symbol); cancelErr != nil {
+				t.logger.Warn("取消挂单失败", "symbol", symbol, "err", cancelErr)
+			}
+		}
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	} else if full || quantity >= pos.Quantity {
+		full = true
+		quantity = pos.Quantity
+	}
+
+	// 按持仓自身的保证金模式平仓（与当前设置不同时，用设置的模式下单会被拒绝）
+	orderSide, posSide := t.okxSides(ctx, side, true)
+	start := t.clock.Now()
+	result, err := t.placeMarketOrder(ctx, symbol, quantity, 0, orderSide, posSide, true, okxTradeMode(okx.MarginMode(pos.MarginMode)))
+	if err != nil {
+		return nil, fmt.Errorf("%s失败: %w", label, err)
+	}
+
+	if !full {
+		t.logger.Info("部分"+label+"成功（保留止损止盈）", "symbol", symbol, "side", side, "size", result.FilledQty,
+			"avgPx", result.AvgPrice, "realizedPnl", result.RealizedPnL, "orderId", result.OrderID, "latency", t.clock.Since(start))
+		t.emitFillEvent(EventPositionClosed, side, result, pos.EntryPrice)
+		return result, nil
+	}
+	t.logger.Info(label+"成功", "symbol", symbol, "side", side, "size", result.FilledQty,
+		"avgPx", result.AvgPrice, "realizedPnl", result.RealizedPnL, "orderId", result.OrderID, "latency", t.clock.Since(start))
+	t.emitFillEvent(EventPositionClosed, side, result, pos.EntryPrice)
+
+	// 全部平仓后取消该币种的所有挂单（止损止盈单）
+	if err := t.CancelAllOrdersContext(ctx, symbol); err != nil {
+		t.logger.Warn("取消挂单失败", "symbol", symbol, "err", err)
+	}
 	return result, nil
 }
+
+// okxSides 持仓方向对应的OKX买卖方向与持仓方向（closing为true时为平仓方向）
+// 双向持仓模式下posSide为long/short，单向持仓模式下为net（账户持仓模式查询失败时按双向持仓）
+func (t *OkxTrader) okxSides(ctx context.Context, positionSide PositionSide, closing bool) (okx.OrderSide, okx.PositionSide) {
+	side := positionSide.OpenSide()
+	if closing {
+		side = positionSide.CloseSide()
+	}
+	orderSide := okx.OrderBuy
+	if side == SideSell {
+		orderSide = okx.OrderSell
+	}
+	if mode, err := t.accountPositionMode(ctx); err != nil {
+		t.logger.Warn("查询持仓模式失败，按双向持仓下单", "err", err)
+	} else if mode == okx.PositionNetMode {
+		return orderSide, okx.PositionNetSide
+	}
+	if positionSide == PositionShort {
+		return orderSide, okx.PositionShortSide
+	}
+	return orderSide, okx.PositionLongSide
+}
+
+// validateStopOrder 校验条件单只设置了对应一侧的字段：止损单只设置sl*字段，止盈单只设置tp*字段，
+// 且触发价与触发价类型都已设置（避免止盈单误设slTriggerPxType导致被拒绝或使用非预期的默认值）
+func validateStopOrder(takeProfit bool, stop tradeReq.StopOrder) error {
+	own, other := "止损", "止盈"
+	triggerPx, triggerType := stop.SlTriggerPx, stop.SlTriggerPxType
+	otherSet := stop.TpTriggerPx != 0 || stop.TpOrdPx != 0 || stop.TpTriggerPxType != ""
+	if takeProfit {
+		own, other = other, own
+		triggerPx, triggerType = stop.TpTriggerPx, stop.TpTriggerPxType
+		otherSet = stop.SlTriggerPx != 0 || stop.SlOrdPx != 0 || stop.SlTriggerPxType != ""
+	}
+	switch {
+	case otherSet:
+		return fmt.Errorf("%s单不能设置%s字段: %+v", own, other, stop)
+	case triggerPx <= 0:
+		return fmt.Errorf("%s单触发价必须大于0 (当前 %v)", own, triggerPx)
+	case !TriggerPriceType(triggerType).Valid():
+		return fmt.Errorf("%s单触发价类型无效: %q", own, triggerType)
+	}
+	return nil
+}
+
+// placeProtectiveOrder 下止损/止盈条件单（触发后市价平仓）
+func (t *OkxTrader) placeProtectiveOrder(ctx context.Context, symbol string, positionSide PositionSide, quantity float64, takeProfit bool, stop tradeReq.StopOrder) (string, error) {
+	if !positionSide.Valid() {
+		return "", fmt.Errorf("无效的持仓方向: %v", positionSide)
+	}
+	if err := validateStopOrder(takeProfit, stop); err != nil {
+		return "", err
+	}
+	instID, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+
+	side, posSide := t.okxSides(ctx, positionSide, true)
+
+	return t.placeAlgoOrder(ctx, tradeReq.PlaceAlgoOrder{
+		InstID:     instID,
+		TdMode:     t.positionTradeMode(ctx, symbol, instID, positionSide),
+		Side:       side,
+		PosSide:    posSide,
+		OrdType:    okx.AlgoOrderConditional,
+		Sz:         contracts,
+		ReduceOnly: true,
+		StopOrder:  stop,
+	})
+}
+
+// SetStopLoss 设置止损单
+func (t *OkxTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	_, err := t.PlaceStopLoss(symbol, positionSide, quantity, stopPrice)
+	return err
+}
+
+// SetStopLossContext 同SetStopLoss，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) SetStopLossContext(ctx context.Context, symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	_, err := t.placeStopLoss(ctx, symbol, positionSide, quantity, stopPrice, "")
+	return err
+}
+
+// PlaceStopLoss 设置止损单并返回algoId（使用默认触发价类型）
+func (t *OkxTrader) PlaceStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) (string, error) {
+	return t.PlaceStopLossTriggeredBy(symbol, positionSide, quantity, stopPrice, "")
+}
+
+// PlaceStopLossTriggeredBy 按指定触发价类型设置止损单并返回algoId（typ为空时使用默认类型）
+func (t *OkxTrader) PlaceStopLossTriggeredBy(symbol string, positionSide PositionSide, quantity, stopPrice float64, typ TriggerPriceType) (string, error) {
+	return t.placeStopLoss(context.Background(), symbol, positionSide, quantity, stopPrice, typ)
+}
+
+// placeStopLoss 校验并提交止损条件单
+func (t *OkxTrader) placeStopLoss(ctx context.Context, symbol string, positionSide PositionSide, quantity, stopPrice float64, typ TriggerPriceType) (string, error) {
+	typ, err := t.resolveTriggerType(typ)
+	if err != nil {
+		return "", err
+	}
+	if stopPrice, err = t.roundPrice(symbol, stopPrice, stopRounding(positionSide)); err != nil {
+		return "", err
+	}
+	if err := t.checkProtectivePrice(false, symbol, positionSide, stopPrice, typ); err != nil {
+		return "", err
+	}
+	algoID, err := t.placeProtectiveOrder(ctx, symbol, positionSide, quantity, false, tradeReq.StopOrder{
+		SlTriggerPx:     stopPrice,
+		SlOrdPx:         -1, // -1 表示触发后市价成交
+		SlTriggerPxType: string(typ),
+	})
+	if err != nil {
+		t.emitEvent(TradeEvent{Type: EventStopLossFailed, Symbol: symbol, Side: positionSide.String(), Size: quantity, Price: stopPrice, Error: err.Error()})
+		return "", fmt.Errorf("设置止损失败: %w", err)
+	}
+
+	t.logger.Info("止损已设置", "symbol", symbol, "side", positionSide, "size", quantity, "triggerPx", stopPrice, "triggerPxType", typ, "algoId", algoID)
+	t.emitEvent(TradeEvent{Type: EventStopLossSet, Symbol: symbol, Side: positionSide.String(), Size: quantity, Price: stopPrice, AlgoID: algoID})
+	return algoID, nil
+}
+
+// SetTakeProfit 设置止盈单
+func (t *OkxTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	_, err := t.PlaceTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	return err
+}
+
+// SetTakeProfitContext 同SetTakeProfit，ctx结束时放弃等待并返回 ErrCanceled
+func (t *OkxTrader) SetTakeProfitContext(ctx context.Context, symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	_, err := t.placeTakeProfit(ctx, symbol, positionSide, quantity, takeProfitPrice, "")
+	return err
+}
+
+// PlaceTakeProfit 设置止盈单并返回algoId（使用默认触发价类型）
+func (t *OkxTrader) PlaceTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) (string, error) {
+	return t.PlaceTakeProfitTriggeredBy(symbol, positionSide, quantity, takeProfitPrice, "")
+}
+
+// PlaceTakeProfitTriggeredBy 按指定触发价类型设置止盈单并返回algoId（typ为空时使用默认类型）
+func (t *OkxTrader) PlaceTakeProfitTriggeredBy(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64, typ TriggerPriceType) (string, error) {
+	return t.placeTakeProfit(context.Background(), symbol, positionSide, quantity, takeProfitPrice, typ)
+}
+
+// placeTakeProfit 校验并提交止盈条件单
+func (t *OkxTrader) placeTakeProfit(ctx context.Context, symbol string, positionSide PositionSide, quantity, takeProfitPrice float64, typ TriggerPriceType) (string, error) {
+	typ, err := t.resolveTriggerType(typ)
+	if err != nil {
+		return "", err
+	}
+	if takeProfitPrice, err = t.roundPrice(symbol, takeProfitPrice, stopRounding(positionSide)); err != nil {
+		return "", err
+	}
+	if err := t.checkProtectivePrice(true, symbol, positionSide, takeProfitPrice, typ); err != nil {
+		return "", err
+	}
+	algoID, err := t.placeProtectiveOrder(ctx, symbol, positionSide, quantity, true, tradeReq.StopOrder{
+		TpTriggerPx:     takeProfitPrice,
+		TpOrdPx:         -1, // -1 表示触发后市价成交
+		TpTriggerPxType: string(typ),
+	})
+	if err != nil {
+		t.emitEvent(TradeEvent{Type: EventTakeProfitFailed, Symbol: symbol, Side: positionSide.String(), Size: quantity, Price: takeProfitPrice, Error: err.Error()})
+		return "", fmt.Errorf("设置止盈失败: %w", err)
+	}
+
+	t.logger.Info("止盈已设置", "symbol", symbol, "side", positionSide, "size", quantity, "triggerPx", takeProfitPrice, "triggerPxType", typ, "algoId", algoID)
+	t.emitEvent(TradeEvent{Type: EventTakeProfitSet, Symbol: symbol, Side: positionSide.String(), Size: quantity, Price: takeProfitPrice, AlgoID: algoID})
+	return algoID, nil
+}
+
+// CancelAllOrders 取消该币种的所有挂单（普通委托、止损止盈条件单与追踪止损单），其他币种的挂单不受影响
+// 单个订单失败不会中断其余订单的撤销，已结束的订单计为成功；有订单失败时返回汇总错误
+func (t *OkxTrader) CancelAllOrders(symbol string) error {
+	_, err := t.CancelAllOrdersWithSummary(symbol)
+	return err
+}
+
+// CancelAllOrdersContext 同CancelAllOrders，ctx结束后不再撤销剩余订单并返回 ErrCanceled
+func (t *OkxTrader) CancelAllOrdersContext(ctx context.Context, symbol string) error {
+	_, err := t.cancelAllOrders(ctx, symbol)
+	return err
+}
+
+// CancelAllOrdersWithSummary 同CancelAllOrders，返回普通委托与条件单各自的撤销计数及失败订单
+func (t *OkxTrader) CancelAllOrdersWithSummary(symbol string) (*CancelSummary, error) {
+	return t.cancelAllOrders(context.Background(), symbol)
+}
+
+// cancelAllOrders 撤销该币种的普通委托与止损止盈条件单
+func (t *OkxTrader) cancelAllOrders(ctx context.Context, symbol string) (*CancelSummary, error) {
+	instID, _, err := t.resolveInstID(symbol)
+	if err != nil {
+		return nil, err
+	}
+	summary := &CancelSummary{Symbol: symbol}
+	err = t.cancelPending(ctx, summary,
+		tradeReq.OrderList{InstID: instID},
+		tradeReq.AlgoOrderList{InstID: instID})
+	return summary, err
+}
+
+// CancelAllOrdersAllSymbols 取消当前品种类型（默认永续合约）下所有币种的挂单，包括其他持仓的止损止盈
+func (t *OkxTrader) CancelAllOrdersAllSymbols() error {
+	_, err := t.CancelAllOrdersAllSymbolsWithSummary()
+	return err
+}
+
+// CancelAllOrdersAllSymbolsWithSummary 同CancelAllOrdersAllSymbols，返回普通委托与条件单各自的撤销计数及失败订单
+func (t *OkxTrader) CancelAllOrdersAllSymbolsWithSummary() (*CancelSummary, error) {
+	summary := &CancelSummary{Symbol: "全部" + string(t.instType)}
+	err := t.cancelPending(context.Background(), summary,
+		tradeReq.OrderList{InstType: t.instType},
+		tradeReq.AlgoOrderList{InstType: t.instType})
+	return summary, err
+}
+
+// okxPendingAlgoTypes 撤销挂单时清理的策略委托类型
+var okxPendingAlgoTypes = []okx.AlgoOrderType{okx.AlgoOrderConditional, okx.AlgoOrderTrailing}
+
+// cancelPending 按过滤条件查询并撤销普通委托与条件单
+// 普通委托通过批量撤单接口撤销，条件单通过策略委托接口批量撤销（条件单没有ordId，普通委托没有algoId）
+func (t *OkxTrader) cancelPending(ctx context.Context, summary *CancelSummary, orderFilter tradeReq.OrderList, algoFilter tradeReq.AlgoOrderList) error {
+	// 普通委托
+	orders, err := okxCall(ctx, t, OpPrivateRead, "GetOrderList", func() (tradeResp.OrderList, error) {
+		return t.api().Rest.Trade.GetOrderList(orderFilter)
+	})
+	if err == nil {
+		err = okxResponseError("GetOrderList", orders.Code, orders.Msg, 0, "")
+	}
+	if err != nil {
+		return fmt.Errorf("获取挂单失败: %w", err)
+	}
+	cancelOrders := make([]tradeReq.CancelOrder, 0, len(orders.Orders))
+	for _, order := range orders.Orders {
+		cancelOrders = append(cancelOrders, tradeReq.CancelOrder{InstID: order.InstID, OrdID: order.OrdID})
+	}
+	t.cancelOrders(ctx, cancelOrders, summary)
+
+	// 止损止盈条件单与追踪止损单（查询策略委托时每次只能指定一种类型）
+	var cancels []tradeReq.CancelAlgoOrder
+	for _, ordType := range okxPendingAlgoTypes {
+		filter := algoFilter
+		filter.OrdType = ordType
+		algos, err := okxCall(ctx, t, OpPrivateRead, "GetAlgoOrderList", func() (tradeResp.AlgoOrderList, error) {
+			return t.api().Rest.Trade.GetAlgoOrderList(filter, false)
+		})
+		if err == nil {
+			err = okxResponseError("GetAlgoOrderList", algos.Code, algos.Msg, 0, "")
+		}
+		if err != nil {
+			t.logger.Warn("取消挂单未全部完成", "symbol", summary.Symbol, "summary", summary.String())
+			return errors.Join(summary.Err(), fmt.Errorf("获取%s策略委托失败: %w", ordType, err))
+		}
+		for _, algo := range algos.AlgoOrders {
+			cancels = append(cancels, tradeReq.CancelAlgoOrder{InstID: algo.InstID, AlgoID: algo.AlgoID})
+		}
+	}
+	t.cancelAlgoOrders(ctx, cancels, summary)
+	if n := summary.Cancelled(); n > 0 {
+		t.emitEvent(TradeEvent{Type: EventOrdersCancelled, Symbol: summary.Symbol, Count: n})
+	}
+
+	if err := summary.Err(); err != nil {
+		t.logger.Warn("取消挂单未全部完成", "symbol", summary.Symbol, "summary", summary.String())
+		return err
+	}
+	t.logger.Info("已取消所有挂单", "symbol", summary.Symbol, "summary", summary.String())
+	return nil
+}
@@ -0,0 +1,71 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Benjmmi/okx"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+)
+
+// OKX追踪止损回调比例的取值范围（0.001表示0.1%）
+const (
+	okxMinCallbackRatio = 0.001
+	okxMaxCallbackRatio = 1.0
+)
+
+// SetTrailingStop 实现TrailingStopPlacer：下只减仓追踪止损单（move_order_stop）并返回algoId
+// 数量超过当前持仓（或为0）时按持仓数量下单，避免触发后反向开仓
+func (t *OkxTrader) SetTrailingStop(symbol string, positionSide PositionSide, quantity, callbackRatio, activationPrice float64) (string, error) {
+	if callbackRatio < okxMinCallbackRatio || callbackRatio > okxMaxCallbackRatio {
+		return "", fmt.Errorf("追踪止损回调比例 %v 超出范围 [%v, %v]（0.01表示1%%）", callbackRatio, okxMinCallbackRatio, okxMaxCallbackRatio)
+	}
+	if activationPrice < 0 {
+		return "", fmt.Errorf("激活价不能为负数 (当前 %v)", activationPrice)
+	}
+	pos, err := t.GetPosition(symbol, positionSide)
+	if err != nil {
+		return "", err
+	}
+	if quantity <= 0 || quantity > pos.Quantity {
+		if quantity > pos.Quantity {
+			t.logger.Warn("追踪止损数量超过持仓，按持仓数量下单", "symbol", symbol, "side", positionSide, "size", quantity, "positionSize", pos.Quantity)
+		}
+		quantity = pos.Quantity
+	}
+	instID, contracts, err := t.toContracts(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	if activationPrice > 0 {
+		if activationPrice, err = t.roundPrice(symbol, activationPrice, RoundNearest); err != nil {
+			return "", err
+		}
+	}
+
+	side, posSide := t.okxSides(context.Background(), positionSide, true)
+	algoID, err := t.placeAlgoOrder(context.Background(), tradeReq.PlaceAlgoOrder{
+		InstID:     instID,
+		TdMode:     okxTradeMode(okx.MarginMode(pos.MarginMode)),
+		Side:       side,
+		PosSide:    posSide,
+		OrdType:    okx.AlgoOrderTrailing,
+		Sz:         contracts,
+		ReduceOnly: true,
+		TrailingStopOrder: tradeReq.TrailingStopOrder{
+			CallbackRatio: callbackRatio,
+			ActivePx:      activationPrice,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("设置追踪止损失败: %w", err)
+	}
+
+	t.logger.Info("追踪止损已设置", "symbol", symbol, "side", positionSide, "callbackRatio", callbackRatio, "activePx", activationPrice, "size", quantity, "algoId", algoID)
+	return algoID, nil
+}
+
+// CancelTrailingStop 实现TrailingStopPlacer：撤销追踪止损单，订单已触发或已撤销时返回 ErrAlreadyGone
+func (t *OkxTrader) CancelTrailingStop(symbol, algoID string) error {
+	return t.CancelAlgoOrder(symbol, algoID)
+}
@@ -0,0 +1,119 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Benjmmi/okx"
+	marketReq "github.com/Benjmmi/okx/requests/rest/market"
+	marketResp "github.com/Benjmmi/okx/responses/market"
+)
+
+// SetTriggerPriceType 设置止损/止盈的默认触发价类型
+func (t *OkxTrader) SetTriggerPriceType(typ TriggerPriceType) error {
+	if !typ.Valid() {
+		return fmt.Errorf("无效的触发价类型: %q（可选 last/mark/index）", typ)
+	}
+	t.triggerMu.Lock()
+	defer t.triggerMu.Unlock()
+	t.triggerPxType = typ
+	return nil
+}
+
+// TriggerPriceType 当前默认触发价类型（未设置时为last）
+func (t *OkxTrader) TriggerPriceType() TriggerPriceType {
+	t.triggerMu.RLock()
+	defer t.triggerMu.RUnlock()
+	if t.triggerPxType == "" {
+		return TriggerLast
+	}
+	return t.triggerPxType
+}
+
+// SetProtectivePriceCheck 设置止损止盈提交前的价格校验（默认只校验方向）
+func (t *OkxTrader) SetProtectivePriceCheck(check ProtectivePriceCheck) {
+	t.triggerMu.Lock()
+	defer t.triggerMu.Unlock()
+	t.priceCheck = check
+}
+
+// checkProtectivePrice 按触发价类型取参考价，校验触发价的方向与距离
+// 参考价获取失败时不阻止提交（止损单宁可提交也不能缺失），只记录日志
+func (t *OkxTrader) checkProtectivePrice(takeProfit bool, symbol string, side PositionSide, trigger float64, typ TriggerPriceType) error {
+	t.triggerMu.RLock()
+	check := t.priceCheck
+	t.triggerMu.RUnlock()
+	if check.AllowImmediateTrigger {
+		return nil
+	}
+	reference, err := t.ReferencePrice(symbol, typ)
+	if err != nil {
+		t.logger.Warn("获取参考价失败，跳过止损止盈价格校验", "symbol", symbol, "triggerPxType", typ, "err", err)
+		return nil
+	}
+	return check.check(takeProfit, symbol, side, trigger, reference, typ)
+}
+
+// resolveTriggerType 空值使用默认类型，非法值返回错误
+func (t *OkxTrader) resolveTriggerType(typ TriggerPriceType) (TriggerPriceType, error) {
+	if typ == "" {
+		return t.TriggerPriceType(), nil
+	}
+	if !typ.Valid() {
+		return "", fmt.Errorf("无效的触发价类型: %q（可选 last/mark/index）", typ)
+	}
+	return typ, nil
+}
+
+// ReferencePrice 按触发价类型获取当前价格：last为最新成交价，mark为标记价格，index为指数价格
+func (t *OkxTrader) ReferencePrice(symbol string, typ TriggerPriceType) (float64, error) {
+	typ, err := t.resolveTriggerType(typ)
+	if err != nil {
+		return 0, err
+	}
+	switch typ {
+	case TriggerMark:
+		return t.getMarkPrice(symbol)
+	case TriggerIndex:
+		return t.getIndexPrice(symbol)
+	default:
+		return t.GetMarketPrice(symbol)
+	}
+}
+
+// fetchIndexTicker 请求指数行情
+// SDK的Market.GetIndexTickers请求的是/market/ticker，这里直接请求/market/index-tickers
+func (t *OkxTrader) fetchIndexTicker(indexID string) (marketResp.IndexTicker, error) {
+	var resp marketResp.IndexTicker
+	res, err := t.api().Rest.Do(http.MethodGet, "/api/v5/market/index-tickers", false, okx.S2M(marketReq.GetIndexTickers{InstID: indexID}))
+	if err != nil {
+		return resp, err
+	}
+	defer res.Body.Close()
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	return resp, err
+}
+
+// getIndexPrice 获取指数价格（指数ID为合约的标的，如BTC-USDT）
+func (t *OkxTrader) getIndexPrice(symbol string) (float64, error) {
+	inst, err := t.getInstrument(symbol)
+	if err != nil {
+		return 0, err
+	}
+	indexID := inst.Uly
+	if indexID == "" {
+		indexID = inst.InstID
+	}
+	resp, err := okxCall(context.Background(), t, OpPublicRead, "GetIndexTickers", func() (marketResp.IndexTicker, error) {
+		return t.fetchIndexTicker(indexID)
+	})
+	if err == nil {
+		err = okxCheck("GetIndexTickers", resp.Basic, len(resp.IndexTickers))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("获取 %s 指数价格失败: %w", indexID, err)
+	}
+	return float64(resp.IndexTickers[0].IdxPx), nil
+}
@@ -0,0 +1,147 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/Benjmmi/okx"
+	tradeModel "github.com/Benjmmi/okx/models/trade"
+	tradeReq "github.com/Benjmmi/okx/requests/rest/trade"
+	tradeResp "github.com/Benjmmi/okx/responses/trade"
+)
+
+// okxOrderNotExistCode OKX订单不存在的错误码
+const okxOrderNotExistCode = 51603
+
+var okxClOrdIDSeq atomic.Uint64
+
+// newOkxClOrdID 生成客户端订单ID（字母数字，不超过32位），超时后按它查询订单是否已提交
+func (t *OkxTrader) newOkxClOrdID() string {
+	return "nofx" + strconv.FormatInt(t.clock.Now().UnixMilli(), 36) + strconv.FormatUint(okxClOrdIDSeq.Add(1), 36)
+}
+
+// isOkxOrderNotExist 错误是否表示订单不存在
+func isOkxOrderNotExist(err error) bool {
+	var okxErr *OkxError
+	return errors.As(err, &okxErr) && (okxErr.Code == okxOrderNotExistCode || okxErr.SCode == okxOrderNotExistCode)
+}
+
+// lookupOrder 查询订单（按ordId或clOrdId），订单不存在时返回 (nil, nil)
+// 下单刚超时时订单可能尚未可查，按成交确认的节奏多查几次
+func (t *OkxTrader) lookupOrder(ctx context.Context, query tradeReq.OrderDetails) (*tradeModel.Order, error) {
+	var lastErr error
+	for attempt := 0; attempt < okxFillPollAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, t.clock, okxFillPollInterval); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := okxCall(ctx, t, OpPrivateRead, "GetOrderDetail", func() (tradeResp.OrderList, error) {
+			return t.api().Rest.Trade.GetOrderDetail(query)
+		})
+		if err == nil {
+			err = okxCheck("GetOrderDetail", resp.Basic, -1)
+		}
+		switch {
+		case err == nil && len(resp.Orders) > 0:
+			return resp.Orders[0], nil
+		case err == nil || isOkxOrderNotExist(err):
+			lastErr = nil
+		default:
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// submitOrder 下单；变更类请求超时（结果未知）时按clOrdId对账，不会重新下单：
+//   - 订单已存在：沿用该订单
+//   - 未查到订单或对账失败：返回结果未知的错误，由调用方按持仓对账
+//
+// 未查到订单不代表没有提交：超时后原请求仍在后台执行，可能在对账之后才到达交易所，
+// 而交易所只在订单挂单期间按clOrdId去重（市价单立即成交后同一clOrdId可以再次下单），重发可能导致重复开仓
+// ctx在请求发出后结束同样视为结果未知，对账不受ctx取消影响
+func (t *OkxTrader) submitOrder(ctx context.Context, req tradeReq.PlaceOrder) (order *tradeModel.PlaceOrder, err error) {
+	if req.ClOrdID == "" {
+		req.ClOrdID = t.newOkxClOrdID()
+	}
+	defer func() {
+		t.metrics.order(req.InstID, string(req.Side), err)
+		ordID := ""
+		if order != nil {
+			ordID = order.OrdID
+		}
+		t.emitOrderEvent(req, ordID, err)
+	}()
+	order, err = t.placeOrder(ctx, req)
+	if err == nil || !IsOutcomeUnknown(err) {
+		return order, err
+	}
+
+	t.logger.Warn("下单结果未知，按clOrdId对账", "instId", req.InstID, "clOrdId", req.ClOrdID, "err", err)
+	existing, lookupErr := t.lookupOrder(context.WithoutCancel(ctx), tradeReq.OrderDetails{InstID: req.InstID, ClOrdID: req.ClOrdID})
+	switch {
+	case lookupErr != nil:
+		t.logger.Error("订单对账失败，请手动确认是否已下单", "instId", req.InstID, "clOrdId", req.ClOrdID, "err", lookupErr)
+		return nil, fmt.Errorf("%w（对账失败: %v）", err, lookupErr)
+	case existing != nil:
+		t.logger.Info("对账确认订单已提交", "instId", req.InstID, "clOrdId", req.ClOrdID, "orderId", existing.OrdID, "state", existing.State)
+		return &tradeModel.PlaceOrder{OrdID: existing.OrdID, ClOrdID: existing.ClOrdID, Tag: existing.Tag}, nil
+	}
+
+	t.logger.Error("对账未查到订单，原请求可能仍在途中，不重新下单", "instId", req.InstID, "clOrdId", req.ClOrdID)
+	return nil, fmt.Errorf("%w（对账未查到订单 clOrdId=%s）", err, req.ClOrdID)
+}
+
+// cancelOrderSafe 撤单；订单已结束时返回 ErrAlreadyGone；超时（结果未知）时查询订单状态后再决定：
+// 已撤销视为成功，仍在挂单时重新撤单一次，已成交或不存在时返回 ErrAlreadyGone，查询失败时返回错误
+func (t *OkxTrader) cancelOrderSafe(ctx context.Context, req tradeReq.CancelOrder) (err error) {
+	defer func() { t.metrics.cancel(req.InstID, "order", err) }()
+	err = t.cancelOrder(ctx, req)
+	if isOkxAlreadyGone(err) {
+		gone := &OrderGoneError{OrderID: req.OrdID, Reason: err.Error(), State: okxGoneState(err)}
+		if gone.State == "" {
+			gone.State = t.orderGoneState(ctx, req)
+		}
+		return gone
+	}
+	if err == nil || !IsOutcomeUnknown(err) {
+		return err
+	}
+
+	t.logger.Warn("撤销订单结果未知，查询订单状态", "instId", req.InstID, "orderId", req.OrdID, "err", err)
+	order, lookupErr := t.lookupOrder(context.WithoutCancel(ctx), tradeReq.OrderDetails{InstID: req.InstID, OrdID: req.OrdID, ClOrdID: req.ClOrdID})
+	switch {
+	case lookupErr != nil:
+		return fmt.Errorf("%w（对账失败: %v）", err, lookupErr)
+	case order == nil:
+		return &OrderGoneError{OrderID: req.OrdID, Reason: "对账确认订单不存在", State: GoneNotFound}
+	case order.State == okx.OrderCancel:
+		t.logger.Info("对账确认订单已撤销", "instId", req.InstID, "orderId", req.OrdID)
+		return nil
+	case order.State == okx.OrderFilled:
+		return &OrderGoneError{OrderID: req.OrdID, Reason: "对账确认订单已成交", State: GoneFilled}
+	}
+	t.logger.Info("订单仍在挂单，重新撤单", "instId", req.InstID, "orderId", req.OrdID)
+	return t.cancelOrder(ctx, req)
+}
+
+// orderGoneState 查询已结束订单的最终状态（交易所撤单错误码无法区分时使用），查询失败时返回空
+func (t *OkxTrader) orderGoneState(ctx context.Context, req tradeReq.CancelOrder) GoneState {
+	order, err := t.lookupOrder(context.WithoutCancel(ctx), tradeReq.OrderDetails{InstID: req.InstID, OrdID: req.OrdID, ClOrdID: req.ClOrdID})
+	switch {
+	case err != nil:
+		t.logger.Warn("查询订单最终状态失败", "instId", req.InstID, "orderId", req.OrdID, "err", err)
+		return ""
+	case order == nil:
+		return GoneNotFound
+	case order.State == okx.OrderFilled:
+		return GoneFilled
+	case order.State == okx.OrderCancel:
+		return GoneCancelled
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package trader
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowOrderOkx 下单请求到达交易所后响应迟迟不返回（超时），landed表示订单已在交易所成交
+type slowOrderOkx struct {
+	*fakeOkx
+	release chan struct{}
+	once    sync.Once
+	landed  atomic.Bool
+	clOrdID atomic.Value // string
+}
+
+// newSlowOrderOkx landOnArrival为true时订单在请求到达时即成交，否则在release之后才成交（请求仍在途中）
+func newSlowOrderOkx(t *testing.T, landOnArrival bool) *slowOrderOkx {
+	t.Helper()
+	f := &slowOrderOkx{fakeOkx: newFakeOkx(t), release: make(chan struct{})}
+	t.Cleanup(f.releaseOrder)
+
+	f.handle("POST /api/v5/trade/order", func(req fakeOkxRequest) string {
+		clOrdID, _ := jsonField(t, req.Body, "clOrdId").(string)
+		f.clOrdID.Store(clOrdID)
+		if landOnArrival {
+			f.landed.Store(true)
+		}
+		<-f.release
+		f.landed.Store(true)
+		return okxOK(`{"ordId":"9001","clOrdId":"` + clOrdID + `","sCode":"0","sMsg":""}`)
+	})
+	f.handle("GET /api/v5/trade/order", func(req fakeOkxRequest) string {
+		if !f.landed.Load() {
+			return okxFail(51603, "Order does not exist")
+		}
+		clOrdID, _ := f.clOrdID.Load().(string)
+		return okxOK(okxTestFilledOrder("9001", clOrdID, "buy", "long", "10", "50010"))
+	})
+	return f
+}
+
+// releaseOrder 让在途的下单请求到达交易所并返回
+func (f *slowOrderOkx) releaseOrder() {
+	f.once.Do(func() { close(f.release) })
+}
+
+func (f *slowOrderOkx) trader(t *testing.T) *OkxTrader {
+	tr := f.fakeOkx.trader(t)
+	tr.SetTimeouts(TimeoutConfig{PublicRead: time.Second, PrivateRead: time.Second, Mutation: 50 * time.Millisecond})
+	return tr
+}
+
+// TestOkxOpenTimedOutButFilled 下单超时但订单实际已成交：按clOrdId对账后沿用该订单，不重复下单
+func TestOkxOpenTimedOutButFilled(t *testing.T) {
+	f := newSlowOrderOkx(t, true)
+	order, err := f.trader(t).OpenLongOrder(t.Context(), "BTCUSDT", 0.1, 10)
+	if err != nil {
+		t.Fatalf("对账确认已成交时应返回成交结果: %v", err)
+	}
+	if order.OrderID != "9001" || order.FilledQty != 0.1 || order.AvgPrice != 50010 {
+		t.Errorf("OpenLongOrder = %+v, want 9001 0.1 @ 50010", order)
+	}
+	if n := f.calls("POST /api/v5/trade/order"); n != 1 {
+		t.Errorf("下单请求次数 = %d, want 1", n)
+	}
+	lookups := f.requestsTo("GET /api/v5/trade/order")
+	if len(lookups) == 0 || lookups[0].Query.Get("clOrdId") != f.clOrdID.Load() {
+		t.Errorf("超时后应按clOrdId对账, got %+v", lookups)
+	}
+}
+
+// TestOkxOpenTimedOutLookupMissDoesNotResend 下单超时且对账时订单还查不到（原请求仍在途中）：
+// 返回结果未知的错误而不是重新下单，原请求随后成交也只有一笔订单
+func TestOkxOpenTimedOutLookupMissDoesNotResend(t *testing.T) {
+	f := newSlowOrderOkx(t, false)
+	_, err := f.trader(t).OpenLong("BTCUSDT", 0.1, 10)
+	if !IsOutcomeUnknown(err) {
+		t.Fatalf("err = %v, want 结果未知", err)
+	}
+
+	// 在途的原请求到达交易所并成交
+	f.releaseOrder()
+	deadline := time.Now().Add(2 * time.Second)
+	for !f.landed.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := f.calls("POST /api/v5/trade/order"); n != 1 {
+		t.Fatalf("下单请求次数 = %d, want 1（对账未查到订单时不应重发）", n)
+	}
+}
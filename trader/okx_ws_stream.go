@@ -0,0 +1,351 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	okxWSPingInterval  = 20 * time.Second // OKX在30秒内没有收到数据时断开连接
+	okxWSReadTimeout   = 40 * time.Second // 超过该时间没有任何消息（包括pong）视为连接已断开
+	okxWSWriteTimeout  = 5 * time.Second
+	okxWSDialTimeout   = 10 * time.Second
+	okxWSLoginTimeout  = 10 * time.Second
+	okxWSMinBackoff    = time.Second
+	okxWSMaxBackoff    = 30 * time.Second
+	okxWSStableSession = time.Minute // 连接保持超过该时间后，下次断开从最小间隔开始重连
+)
+
+// okxStreamPush 频道推送的数据
+type okxStreamPush struct {
+	Arg    map[string]string `json:"arg"`
+	Action string            `json:"action,omitempty"` // 深度频道：snapshot / update
+	Data   json.RawMessage   `json:"data"`
+}
+
+// okxStreamEvent 订阅、登录与错误等事件消息
+type okxStreamEvent struct {
+	Event string            `json:"event"`
+	Code  string            `json:"code"`
+	Msg   string            `json:"msg"`
+	Arg   map[string]string `json:"arg"`
+}
+
+// okxStream OKX WebSocket订阅连接（SDK的WebSocket客户端断开后不能重连，行情与账户推送使用独立的连接）
+// 记录所有订阅，断开后按退避间隔重连并重新订阅；handle在读取goroutine中按推送顺序调用，不能阻塞
+// login不为nil时为私有连接：每次连接（包括重连）先登录，登录成功后才发送订阅
+type okxStream struct {
+	name   string // 日志中的连接名称
+	url    string
+	handle func(push okxStreamPush)
+	login  func() (map[string]string, error) // 返回登录参数（每次登录重新签名）
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	subs    map[string]map[string]string // key: okxStreamArgKey
+	conn    *websocket.Conn
+	started bool
+	closed  bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	writeMu    sync.Mutex
+	connected  atomic.Bool
+	reconnects atomic.Int64
+	generation atomic.Int64 // 每次连接成功加1，推送处理据此判断是否为本次连接的第一条推送
+}
+
+func newOkxStream(name, url string, logger *slog.Logger, handle func(push okxStreamPush)) *okxStream {
+	return &okxStream{
+		name:   name,
+		url:    url,
+		handle: handle,
+		logger: logger,
+		subs:   make(map[string]map[string]string),
+		done:   make(chan struct{}),
+	}
+}
+
+// okxStreamArgKey 订阅参数的唯一标识（按key排序）
+func okxStreamArgKey(arg map[string]string) string {
+	keys := make([]string, 0, len(arg))
+	for k := range arg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(arg[k])
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// subscribe 记录订阅（重连后自动重新订阅），已连接时立即发送；第一次订阅时在后台建立连接
+func (s *okxStream) subscribe(args ...map[string]string) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("%s已关闭", s.name)
+	}
+	var added []map[string]string
+	for _, arg := range args {
+		key := okxStreamArgKey(arg)
+		if _, ok := s.subs[key]; !ok {
+			s.subs[key] = arg
+			added = append(added, arg)
+		}
+	}
+	if !s.started {
+		s.started = true
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		go s.run(ctx)
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil || len(added) == 0 {
+		return nil
+	}
+	return s.write(conn, map[string]any{"op": "subscribe", "args": added})
+}
+
+// unsubscribe 取消订阅，已连接时立即发送
+func (s *okxStream) unsubscribe(args ...map[string]string) error {
+	s.mu.Lock()
+	var removed []map[string]string
+	for _, arg := range args {
+		key := okxStreamArgKey(arg)
+		if _, ok := s.subs[key]; ok {
+			delete(s.subs, key)
+			removed = append(removed, arg)
+		}
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil || len(removed) == 0 {
+		return nil
+	}
+	return s.write(conn, map[string]any{"op": "unsubscribe", "args": removed})
+}
+
+// close 关闭连接并等待后台goroutine退出，之后不能再订阅
+func (s *okxStream) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	started, cancel, conn := s.started, s.cancel, s.conn
+	s.mu.Unlock()
+
+	if !started {
+		return
+	}
+	cancel()
+	if conn != nil {
+		conn.Close()
+	}
+	<-s.done
+}
+
+// isConnected 连接已建立（且订阅请求已发送）
+func (s *okxStream) isConnected() bool {
+	return s.connected.Load()
+}
+
+// run 连接断开后按退避间隔重连，直到ctx结束
+func (s *okxStream) run(ctx context.Context) {
+	defer close(s.done)
+	backoff := okxWSMinBackoff
+	for {
+		start := time.Now()
+		err := s.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > okxWSStableSession {
+			backoff = okxWSMinBackoff
+		}
+		s.reconnects.Add(1)
+		s.logger.Warn("OKX WebSocket连接断开，稍后重连", "stream", s.name, "retryIn", backoff, "err", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > okxWSMaxBackoff {
+			backoff = okxWSMaxBackoff
+		}
+	}
+}
+
+// session 建立一次连接、发送所有订阅并读取推送，连接断开时返回原因
+func (s *okxStream) session(ctx context.Context) error {
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment, HandshakeTimeout: okxWSDialTimeout}
+	dialCtx, cancel := context.WithTimeout(ctx, okxWSDialTimeout)
+	conn, _, err := dialer.DialContext(dialCtx, s.url, nil)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	// ctx结束时关闭连接，使阻塞的读取返回
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	if s.login != nil {
+		if err := s.authenticate(conn); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	args := make([]map[string]string, 0, len(s.subs))
+	for _, arg := range s.subs {
+		args = append(args, arg)
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	defer func() {
+		s.connected.Store(false)
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
+	if len(args) > 0 {
+		if err := s.write(conn, map[string]any{"op": "subscribe", "args": args}); err != nil {
+			return err
+		}
+	}
+	s.generation.Add(1)
+	s.connected.Store(true)
+	s.logger.Info("OKX WebSocket已连接", "stream", s.name, "subscriptions", len(args))
+
+	go s.keepalive(conn, stop)
+	return s.read(conn)
+}
+
+// authenticate 发送登录请求并等待登录结果（登录前不会收到推送）
+func (s *okxStream) authenticate(conn *websocket.Conn) error {
+	arg, err := s.login()
+	if err != nil {
+		return fmt.Errorf("读取登录凭证失败: %w", err)
+	}
+	if err := s.write(conn, map[string]any{"op": "login", "args": []map[string]string{arg}}); err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(okxWSLoginTimeout))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("等待登录结果失败: %w", err)
+		}
+		var event okxStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		switch event.Event {
+		case "login":
+			if event.Code != "" && event.Code != "0" {
+				return fmt.Errorf("登录失败: %s %s", event.Code, event.Msg)
+			}
+			return nil
+		case "error":
+			return fmt.Errorf("登录失败: %s %s", event.Code, event.Msg)
+		}
+	}
+}
+
+// keepalive 定时发送ping（OKX返回文本pong）
+func (s *okxStream) keepalive(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(okxWSPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.writeText(conn, []byte("ping")); err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// read 读取并分发消息，直到连接出错
+func (s *okxStream) read(conn *websocket.Conn) error {
+	for {
+		conn.SetReadDeadline(time.Now().Add(okxWSReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if string(data) == "pong" {
+			continue
+		}
+		var event okxStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			s.logger.Warn("无法解析OKX WebSocket消息", "stream", s.name, "err", err)
+			continue
+		}
+		if event.Event != "" {
+			s.handleEvent(event)
+			continue
+		}
+		var push okxStreamPush
+		if err := json.Unmarshal(data, &push); err != nil || push.Arg == nil {
+			continue
+		}
+		s.handle(push)
+	}
+}
+
+func (s *okxStream) handleEvent(event okxStreamEvent) {
+	switch event.Event {
+	case "error":
+		s.logger.Warn("OKX WebSocket返回错误", "stream", s.name, "code", event.Code, "msg", event.Msg)
+	case "subscribe", "unsubscribe":
+		s.logger.Debug("OKX WebSocket订阅已确认", "stream", s.name, "event", event.Event, "channel", event.Arg["channel"], "instId", event.Arg["instId"])
+	}
+}
+
+// write 发送JSON请求（gorilla/websocket不支持并发写）
+func (s *okxStream) write(conn *websocket.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.writeText(conn, data)
+}
+
+func (s *okxStream) writeText(conn *websocket.Conn, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(okxWSWriteTimeout))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
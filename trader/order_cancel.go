@@ -0,0 +1,127 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAlreadyGone 撤单时订单已成交、已撤销或不存在（使用 errors.Is(err, ErrAlreadyGone) 判断）
+// 撤单的目的已经达到，批量撤单将其计为成功
+var ErrAlreadyGone = newSentinelError(ErrCodeAlreadyGone, "err_already_gone")
+
+// 订单已结束的具体原因，errors.Is(err, ErrAlreadyGone) 同样成立
+var (
+	ErrOrderFilled    = newSentinelError(ErrCodeOrderFilled, "err_order_filled")
+	ErrOrderCancelled = newSentinelError(ErrCodeOrderCancelled, "err_order_cancelled")
+	ErrOrderNotFound  = newSentinelError(ErrCodeOrderNotFound, "err_order_not_found")
+)
+
+// GoneState 订单结束的原因（交易所未说明且查询不到时为空）
+type GoneState string
+
+const (
+	GoneFilled    GoneState = "filled"    // 已成交（条件单为已触发）
+	GoneCancelled GoneState = "cancelled" // 已撤销
+	GoneNotFound  GoneState = "not_found" // 订单不存在
+)
+
+// OrderGoneError 带订单ID的订单已结束错误
+type OrderGoneError struct {
+	OrderID string
+	Reason  string    // 交易所返回的原因或对账得到的订单状态
+	State   GoneState // 为空时只能判断订单已结束
+}
+
+func (e *OrderGoneError) Error() string {
+	return msg("err_order_gone_detail", e.OrderID, e.Reason)
+}
+
+func (e *OrderGoneError) ErrorCode() ErrorCode {
+	return ErrCodeAlreadyGone
+}
+
+// Is 使 errors.Is(err, ErrAlreadyGone) 成立，State非空时对应的 ErrOrderFilled 等也成立
+func (e *OrderGoneError) Is(target error) bool {
+	switch target {
+	case ErrAlreadyGone:
+		return true
+	case ErrOrderFilled:
+		return e.State == GoneFilled
+	case ErrOrderCancelled:
+		return e.State == GoneCancelled
+	case ErrOrderNotFound:
+		return e.State == GoneNotFound
+	}
+	return false
+}
+
+// CancelCounts 一类订单的撤销计数
+type CancelCounts struct {
+	Cancelled   int `json:"cancelled"`    // 撤销成功
+	AlreadyGone int `json:"already_gone"` // 订单已结束，计为成功
+	Failed      int `json:"failed"`
+}
+
+// CancelFailure 单个订单的撤销失败
+type CancelFailure struct {
+	OrderID string `json:"order_id"`
+	Algo    bool   `json:"algo"` // 是否为条件单（止损止盈）
+	Err     error  `json:"-"`
+}
+
+// CancelSummary 批量撤单结果（单个订单失败不会中断其余订单的撤销）
+// 普通委托与条件单分别计数，失败按订单列出
+type CancelSummary struct {
+	Symbol   string          `json:"symbol"`
+	Orders   CancelCounts    `json:"orders"`      // 普通委托
+	Algos    CancelCounts    `json:"algo_orders"` // 止损止盈条件单
+	Failures []CancelFailure `json:"failures,omitempty"`
+}
+
+// record 记录单个普通委托的撤销结果
+func (s *CancelSummary) record(orderID string, err error) {
+	s.add(&s.Orders, orderID, false, err)
+}
+
+// recordAlgo 记录单个条件单的撤销结果
+func (s *CancelSummary) recordAlgo(algoID string, err error) {
+	s.add(&s.Algos, algoID, true, err)
+}
+
+func (s *CancelSummary) add(counts *CancelCounts, orderID string, algo bool, err error) {
+	switch {
+	case err == nil:
+		counts.Cancelled++
+	case errors.Is(err, ErrAlreadyGone):
+		counts.AlreadyGone++
+	default:
+		counts.Failed++
+		s.Failures = append(s.Failures, CancelFailure{OrderID: orderID, Algo: algo, Err: err})
+	}
+}
+
+// Cancelled 撤销成功（含已结束）的订单总数
+func (s *CancelSummary) Cancelled() int {
+	return s.Orders.Cancelled + s.Orders.AlreadyGone + s.Algos.Cancelled + s.Algos.AlreadyGone
+}
+
+// Err 所有失败订单的错误，全部成功时返回nil
+func (s *CancelSummary) Err() error {
+	if len(s.Failures) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(s.Failures))
+	errs := make([]error, 0, len(s.Failures))
+	for _, f := range s.Failures {
+		ids = append(ids, f.OrderID)
+		errs = append(errs, fmt.Errorf("订单 %s: %w", f.OrderID, f.Err))
+	}
+	return fmt.Errorf("%s 有 %d 个订单撤销失败 (%s): %w", s.Symbol, len(s.Failures), strings.Join(ids, ","), errors.Join(errs...))
+}
+
+func (s *CancelSummary) String() string {
+	return fmt.Sprintf("%s 撤单: 普通委托 成功 %d/已结束 %d/失败 %d，条件单 成功 %d/已结束 %d/失败 %d",
+		s.Symbol, s.Orders.Cancelled, s.Orders.AlreadyGone, s.Orders.Failed,
+		s.Algos.Cancelled, s.Algos.AlreadyGone, s.Algos.Failed)
+}
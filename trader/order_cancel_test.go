@@ -0,0 +1,248 @@
+package trader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newListThenGoneOkx 列出挂单之后、撤单之前，订单222成交、条件单a2触发（撤单返回订单已完成）
+func newListThenGoneOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/trade/orders-pending",
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"111","ordType":"limit","state":"live"}`,
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"222","ordType":"limit","state":"live"}`,
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"333","ordType":"limit","state":"live"}`,
+	)
+	f.handle("GET /api/v5/trade/orders-algo-pending", func(req fakeOkxRequest) string {
+		if req.Query.Get("ordType") != "conditional" {
+			return okxOK()
+		}
+		return okxOK(
+			`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"a1","ordType":"conditional","state":"live"}`,
+			`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"a2","ordType":"conditional","state":"live"}`,
+		)
+	})
+	f.handle("POST /api/v5/trade/cancel-order", func(req fakeOkxRequest) string {
+		ordID, _ := jsonField(t, req.Body, "ordId").(string)
+		if ordID == "222" {
+			return okxFail(1, "All operations failed", `{"ordId":"222","sCode":"51402","sMsg":"Order has been completed"}`)
+		}
+		return okxOK(`{"ordId":"` + ordID + `","sCode":"0","sMsg":""}`)
+	})
+	f.handle("POST /api/v5/trade/cancel-batch-orders", func(req fakeOkxRequest) string {
+		var rows []string
+		for _, ordID := range batchField(t, req.Body, "ordId") {
+			if ordID == "222" {
+				rows = append(rows, `{"ordId":"222","sCode":"51402","sMsg":"Order has been completed"}`)
+			} else {
+				rows = append(rows, `{"ordId":"`+ordID+`","sCode":"0","sMsg":""}`)
+			}
+		}
+		return okxFail(2, "Bulk operation partially succeeded", rows...)
+	})
+	f.reply("GET /api/v5/trade/order", okxTestFilledOrder("222", "", "buy", "long", "1", "50000"))
+	f.handle("POST /api/v5/trade/cancel-algos", func(fakeOkxRequest) string {
+		return okxFail(2, "Bulk operation partially succeeded",
+			`{"algoId":"a1","sCode":"0","sMsg":""}`,
+			`{"algoId":"a2","sCode":"51400","sMsg":"Cancellation failed as the order has been filled, canceled or does not exist"}`)
+	})
+	return f
+}
+
+// TestOkxCancelAllListThenGone 列出与撤销之间订单已成交：计为已结束而不是失败，其余订单照常撤销
+func TestOkxCancelAllListThenGone(t *testing.T) {
+	f := newListThenGoneOkx(t)
+	summary, err := f.trader(t).CancelAllOrdersWithSummary("BTCUSDT")
+	if err != nil {
+		t.Fatalf("已结束的订单不应导致撤单失败: %v", err)
+	}
+	if summary.Orders != (CancelCounts{Cancelled: 2, AlreadyGone: 1}) {
+		t.Errorf("普通委托 = %+v, want 撤销2 已结束1", summary.Orders)
+	}
+	if summary.Algos != (CancelCounts{Cancelled: 1, AlreadyGone: 1}) {
+		t.Errorf("条件单 = %+v, want 撤销1 已结束1", summary.Algos)
+	}
+	if len(summary.Failures) != 0 {
+		t.Errorf("Failures = %+v", summary.Failures)
+	}
+	if n := f.calls("POST /api/v5/trade/cancel-batch-orders"); n != 1 {
+		t.Errorf("批量撤单请求次数 = %d, want 1", n)
+	}
+}
+
+// TestOkxCancelOrderAlreadyFilled 单独撤销已成交的订单返回 ErrAlreadyGone 与 ErrOrderFilled
+func TestOkxCancelOrderAlreadyFilled(t *testing.T) {
+	f := newListThenGoneOkx(t)
+	err := f.trader(t).CancelOrder("BTCUSDT", "222")
+	if !errors.Is(err, ErrAlreadyGone) || !errors.Is(err, ErrOrderFilled) {
+		t.Fatalf("err = %v, want ErrAlreadyGone + ErrOrderFilled", err)
+	}
+	var gone *OrderGoneError
+	if !errors.As(err, &gone) || gone.OrderID != "222" || !strings.Contains(gone.Reason, "completed") {
+		t.Errorf("OrderGoneError = %+v", gone)
+	}
+}
+
+// TestOkxCancelAllRealFailure 其他撤单错误仍计为失败并返回错误
+func TestOkxCancelAllRealFailure(t *testing.T) {
+	f := newListThenGoneOkx(t)
+	f.handle("POST /api/v5/trade/cancel-batch-orders", func(fakeOkxRequest) string {
+		return okxFail(50001, "Service temporarily unavailable")
+	})
+	summary, err := f.trader(t).CancelAllOrdersWithSummary("BTCUSDT")
+	if err == nil {
+		t.Fatal("撤单失败时应返回错误")
+	}
+	if summary.Orders.Failed != 3 || len(summary.Failures) != 3 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+// newTwoSymbolOkx BTC与ETH各有一个普通委托和一个止损条件单的假服务器，挂单查询按instId过滤（与交易所一致）
+func newTwoSymbolOkx(t *testing.T) *fakeOkx {
+	t.Helper()
+	f := newFakeOkx(t)
+	orders := []string{
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"btc-1","ordType":"limit","state":"live"}`,
+		`{"instId":"ETH-USDT-SWAP","instType":"SWAP","ordId":"eth-1","ordType":"limit","state":"live"}`,
+	}
+	algos := []string{
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"btc-sl","ordType":"conditional","state":"live"}`,
+		`{"instId":"ETH-USDT-SWAP","instType":"SWAP","algoId":"eth-sl","ordType":"conditional","state":"live"}`,
+	}
+	byInstID := func(req fakeOkxRequest, rows []string) string {
+		var out []string
+		for _, row := range rows {
+			if instID := req.Query.Get("instId"); instID == "" || jsonField(t, row, "instId") == instID {
+				out = append(out, row)
+			}
+		}
+		return okxOK(out...)
+	}
+	f.handle("GET /api/v5/trade/orders-pending", func(req fakeOkxRequest) string { return byInstID(req, orders) })
+	f.handle("GET /api/v5/trade/orders-algo-pending", func(req fakeOkxRequest) string {
+		if req.Query.Get("ordType") != "conditional" {
+			return okxOK()
+		}
+		return byInstID(req, algos)
+	})
+	f.handle("POST /api/v5/trade/cancel-order", func(req fakeOkxRequest) string {
+		return okxOK(`{"ordId":"` + jsonField(t, req.Body, "ordId").(string) + `","sCode":"0","sMsg":""}`)
+	})
+	f.handle("POST /api/v5/trade/cancel-algos", func(req fakeOkxRequest) string {
+		return okxOK(`{"algoId":"` + jsonField(t, req.Body, "algoId").(string) + `","sCode":"0","sMsg":""}`)
+	})
+	return f
+}
+
+// TestOkxCancelAllOrdersOnlySymbol 撤销BTC的挂单：查询按instId过滤，ETH的普通委托与止损单不被撤销
+func TestOkxCancelAllOrdersOnlySymbol(t *testing.T) {
+	f := newTwoSymbolOkx(t)
+	summary, err := f.trader(t).CancelAllOrdersWithSummary("BTCUSDT")
+	if err != nil {
+		t.Fatalf("CancelAllOrders: %v", err)
+	}
+	if summary.Orders != (CancelCounts{Cancelled: 1}) || summary.Algos != (CancelCounts{Cancelled: 1}) {
+		t.Errorf("summary = %s, want 普通委托1、条件单1", summary)
+	}
+
+	for _, route := range []string{"GET /api/v5/trade/orders-pending", "GET /api/v5/trade/orders-algo-pending"} {
+		for _, req := range f.requestsTo(route) {
+			if got := req.Query.Get("instId"); got != "BTC-USDT-SWAP" {
+				t.Errorf("%s instId = %q, want BTC-USDT-SWAP", route, got)
+			}
+		}
+	}
+	var cancelled []string
+	for _, req := range f.requestsTo("POST /api/v5/trade/cancel-order") {
+		cancelled = append(cancelled, jsonField(t, req.Body, "ordId").(string))
+	}
+	for _, req := range f.requestsTo("POST /api/v5/trade/cancel-algos") {
+		cancelled = append(cancelled, jsonField(t, req.Body, "algoId").(string))
+	}
+	if strings.Join(cancelled, ",") != "btc-1,btc-sl" {
+		t.Errorf("撤销的订单 = %v, want [btc-1 btc-sl]（ETH的挂单保留）", cancelled)
+	}
+}
+
+// TestOkxCancelAllSymbolsEndpoints 撤销所有币种的挂单：普通委托走批量撤单接口，条件单与追踪止损单走策略委托撤单接口，
+// 部分订单的sCode失败时其余订单照常撤销，汇总按类型计数并列出失败订单
+func TestOkxCancelAllSymbolsEndpoints(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/trade/orders-pending",
+		`{"instId":"BTC-USDT-SWAP","instType":"SWAP","ordId":"o1","ordType":"limit","state":"live"}`,
+		`{"instId":"ETH-USDT-SWAP","instType":"SWAP","ordId":"o2","ordType":"limit","state":"live"}`,
+		`{"instId":"SOL-USDT-SWAP","instType":"SWAP","ordId":"o3","ordType":"limit","state":"live"}`,
+	)
+	f.handle("GET /api/v5/trade/orders-algo-pending", func(req fakeOkxRequest) string {
+		switch req.Query.Get("ordType") {
+		case "conditional":
+			return okxOK(
+				`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"a1","ordType":"conditional","state":"live"}`,
+				`{"instId":"ETH-USDT-SWAP","instType":"SWAP","algoId":"a2","ordType":"conditional","state":"live"}`,
+			)
+		case "move_order_stop":
+			return okxOK(`{"instId":"BTC-USDT-SWAP","instType":"SWAP","algoId":"a3","ordType":"move_order_stop","state":"live"}`)
+		}
+		return okxOK()
+	})
+	f.reply("POST /api/v5/trade/cancel-batch-orders",
+		`{"ordId":"o1","sCode":"0","sMsg":""}`,
+		`{"ordId":"o2","sCode":"51001","sMsg":"Instrument ID does not exist"}`,
+		`{"ordId":"o3","sCode":"0","sMsg":""}`,
+	)
+	f.handle("POST /api/v5/trade/cancel-algos", func(fakeOkxRequest) string {
+		return okxFail(2, "Bulk operation partially succeeded",
+			`{"algoId":"a1","sCode":"0","sMsg":""}`,
+			`{"algoId":"a2","sCode":"0","sMsg":""}`,
+			`{"algoId":"a3","sCode":"51001","sMsg":"Instrument ID does not exist"}`)
+	})
+
+	summary, err := f.trader(t).CancelAllOrdersAllSymbolsWithSummary()
+	if err == nil || !strings.Contains(err.Error(), "o2") || !strings.Contains(err.Error(), "a3") {
+		t.Errorf("err = %v, want 列出失败的订单 o2、a3", err)
+	}
+	if summary.Orders != (CancelCounts{Cancelled: 2, Failed: 1}) {
+		t.Errorf("普通委托 = %+v, want 撤销2 失败1", summary.Orders)
+	}
+	if summary.Algos != (CancelCounts{Cancelled: 2, Failed: 1}) {
+		t.Errorf("条件单 = %+v, want 撤销2 失败1", summary.Algos)
+	}
+	if len(summary.Failures) != 2 || summary.Failures[0].OrderID != "o2" || summary.Failures[0].Algo ||
+		summary.Failures[1].OrderID != "a3" || !summary.Failures[1].Algo {
+		t.Errorf("Failures = %+v, want o2（普通委托）与 a3（条件单）", summary.Failures)
+	}
+
+	for _, route := range []string{"GET /api/v5/trade/orders-pending", "GET /api/v5/trade/orders-algo-pending"} {
+		for _, req := range f.requestsTo(route) {
+			if req.Query.Get("instType") != "SWAP" || req.Query.Get("instId") != "" {
+				t.Errorf("%s query = %v, want 只按instType=SWAP过滤", route, req.Query)
+			}
+		}
+	}
+	if n := f.calls("POST /api/v5/trade/cancel-order"); n != 0 {
+		t.Errorf("cancel-order 被调用 %d 次, want 0（普通委托批量撤销）", n)
+	}
+	batches := f.requestsTo("POST /api/v5/trade/cancel-batch-orders")
+	if len(batches) != 1 {
+		t.Fatalf("cancel-batch-orders 被调用 %d 次, want 1", len(batches))
+	}
+	if got := strings.Join(batchField(t, batches[0].Body, "ordId"), ","); got != "o1,o2,o3" {
+		t.Errorf("批量撤单 ordId = %s, want o1,o2,o3", got)
+	}
+	if got := strings.Join(batchField(t, batches[0].Body, "instId"), ","); got != "BTC-USDT-SWAP,ETH-USDT-SWAP,SOL-USDT-SWAP" {
+		t.Errorf("批量撤单 instId = %s", got)
+	}
+	algoCancels := f.requestsTo("POST /api/v5/trade/cancel-algos")
+	if len(algoCancels) != 1 {
+		t.Fatalf("cancel-algos 被调用 %d 次, want 1", len(algoCancels))
+	}
+	if got := strings.Join(batchField(t, algoCancels[0].Body, "algoId"), ","); got != "a1,a2,a3" {
+		t.Errorf("撤销的策略委托 algoId = %s, want a1,a2,a3", got)
+	}
+	if got := strings.Join(batchField(t, algoCancels[0].Body, "ordId"), ""); got != "" {
+		t.Errorf("策略委托撤单请求不应带ordId: %s", algoCancels[0].Body)
+	}
+}
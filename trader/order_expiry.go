@@ -0,0 +1,232 @@
+package trader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// ExpiringOrderCanceler 到期撤单需要的交易器能力
+type ExpiringOrderCanceler interface {
+	// CancelOrder 撤销单个普通委托
+	CancelOrder(symbol, orderID string) error
+	// GetOrderStatus 查询订单状态与成交数量
+	GetOrderStatus(symbol, orderID string) (*OrderResult, error)
+}
+
+// EntryExpiredEvent 限价开仓单到期未完全成交
+// 已成交部分成为普通持仓，按正常流程管理；只撤销未成交的剩余部分
+type EntryExpiredEvent struct {
+	Symbol    string    `json:"symbol"`
+	OrderID   string    `json:"order_id"`
+	Deadline  time.Time `json:"deadline"`
+	Quantity  float64   `json:"quantity"`   // 下单数量（币）
+	FilledQty float64   `json:"filled_qty"` // 到期时已成交数量（币）
+}
+
+// expiringOrder 待到期的订单（持久化到文件，重启后继续跟踪）
+type expiringOrder struct {
+	Symbol   string    `json:"symbol"`
+	OrderID  string    `json:"order_id"`
+	Quantity float64   `json:"quantity"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// OrderExpiryScheduler 限价单本地到期调度器
+// 交易所不支持订单有效期时，由本地定时检查并撤销到期未成交的订单
+type OrderExpiryScheduler struct {
+	name   string
+	trader ExpiringOrderCanceler
+	clock  clock.Clock
+	path   string // 为空时只在内存中跟踪
+
+	mu        sync.Mutex
+	orders    map[string]*expiringOrder // key: orderID
+	onExpired func(EntryExpiredEvent)
+	stop      chan struct{}
+}
+
+// NewOrderExpiryScheduler 创建到期调度器，path不为空时加载之前保存的待到期订单
+func NewOrderExpiryScheduler(name string, t ExpiringOrderCanceler, path string, clk clock.Clock) (*OrderExpiryScheduler, error) {
+	s := &OrderExpiryScheduler{
+		name:   name,
+		trader: t,
+		clock:  clock.OrReal(clk),
+		path:   path,
+		orders: make(map[string]*expiringOrder),
+	}
+	if path == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建订单到期文件目录失败: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取订单到期文件失败: %w", err)
+	}
+	var orders []*expiringOrder
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return nil, fmt.Errorf("解析订单到期文件失败: %w", err)
+	}
+	for _, order := range orders {
+		s.orders[order.OrderID] = order
+	}
+	if len(orders) > 0 {
+		log.Printf("📂 [%s] 已恢复 %d 个待到期的限价单", name, len(orders))
+	}
+	return s, nil
+}
+
+// OnExpired 设置订单到期撤单后的回调（策略可据此调整）
+func (s *OrderExpiryScheduler) OnExpired(fn func(EntryExpiredEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpired = fn
+}
+
+// Track 跟踪订单，到期后未完全成交则撤销剩余部分
+func (s *OrderExpiryScheduler) Track(symbol, orderID string, quantity float64, deadline time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[orderID] = &expiringOrder{Symbol: symbol, OrderID: orderID, Quantity: quantity, Deadline: deadline}
+	return s.saveLocked()
+}
+
+// Untrack 停止跟踪订单（订单已被手动撤销或已完全成交时调用）
+func (s *OrderExpiryScheduler) Untrack(orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[orderID]; !ok {
+		return nil
+	}
+	delete(s.orders, orderID)
+	return s.saveLocked()
+}
+
+// saveLocked 保存待到期订单（写入临时文件后rename）
+func (s *OrderExpiryScheduler) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	orders := make([]*expiringOrder, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Deadline.Before(orders[j].Deadline) })
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化订单到期文件失败: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入订单到期文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("替换订单到期文件失败: %w", err)
+	}
+	return nil
+}
+
+// Start 启动定时检查（interval<=0时使用5秒）
+func (s *OrderExpiryScheduler) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+
+	ticker := s.clock.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		s.CheckExpired()
+		for {
+			select {
+			case <-ticker.C():
+				s.CheckExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时检查
+func (s *OrderExpiryScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// CheckExpired 撤销所有已到期的订单
+// 撤单失败的订单保留，下次检查时重试；订单已结束（成交或撤销）时直接停止跟踪
+func (s *OrderExpiryScheduler) CheckExpired() {
+	now := s.clock.Now()
+	s.mu.Lock()
+	var due []*expiringOrder
+	for _, order := range s.orders {
+		if !now.Before(order.Deadline) {
+			due = append(due, order)
+		}
+	}
+	onExpired := s.onExpired
+	s.mu.Unlock()
+
+	for _, order := range due {
+		status, err := s.trader.GetOrderStatus(order.Symbol, order.OrderID)
+		if err != nil {
+			log.Printf("⚠ [%s] 查询到期订单 %s %s 失败: %v", s.name, order.Symbol, order.OrderID, err)
+			continue
+		}
+		if status.Status == "FILLED" || status.Status == "CANCELED" {
+			s.Untrack(order.OrderID)
+			continue
+		}
+
+		if err := s.trader.CancelOrder(order.Symbol, order.OrderID); errors.Is(err, ErrAlreadyGone) {
+			s.Untrack(order.OrderID) // 查询后订单已成交或被撤销
+			continue
+		} else if err != nil {
+			log.Printf("⚠ [%s] 撤销到期订单 %s %s 失败，稍后重试: %v", s.name, order.Symbol, order.OrderID, err)
+			continue
+		}
+		// 撤单后重新查询成交数量（撤单前可能还有成交）
+		filled := status.FilledQty
+		if final, err := s.trader.GetOrderStatus(order.Symbol, order.OrderID); err == nil {
+			filled = final.FilledQty
+		}
+		s.Untrack(order.OrderID)
+
+		log.Printf("⌛ [%s] %s 限价开仓单 %s 到期未完全成交，已撤销剩余部分（已成交 %.8g / %.8g）",
+			s.name, order.Symbol, order.OrderID, filled, order.Quantity)
+		if onExpired != nil {
+			onExpired(EntryExpiredEvent{
+				Symbol:    order.Symbol,
+				OrderID:   order.OrderID,
+				Deadline:  order.Deadline,
+				Quantity:  order.Quantity,
+				FilledQty: filled,
+			})
+		}
+	}
+}
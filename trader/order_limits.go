@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"strings"
+	"sync"
+)
+
+// ErrOrderTooLarge 单笔订单名义价值超过上限（使用 errors.Is(err, ErrOrderTooLarge) 判断）
+var ErrOrderTooLarge = newSentinelError(ErrCodeOrderTooLarge, "err_order_too_large")
+
+// OrderTooLargeError 带订单信息的超限错误
+type OrderTooLargeError struct {
+	Symbol   string
+	Notional float64
+	Limit    float64
+}
+
+func (e *OrderTooLargeError) Error() string {
+	return msg("err_order_too_large_detail", e.Symbol, e.Notional, e.Limit)
+}
+
+func (e *OrderTooLargeError) ErrorCode() ErrorCode {
+	return ErrCodeOrderTooLarge
+}
+
+// Is 使 errors.Is(err, ErrOrderTooLarge) 成立
+func (e *OrderTooLargeError) Is(target error) bool {
+	return target == ErrOrderTooLarge
+}
+
+var (
+	maxOrderNotional          float64            // 全局单笔上限（USDT，0表示不限制）
+	maxOrderNotionalPerSymbol map[string]float64 // 币种单独上限，优先于全局上限
+	maxOrderNotionalMu        sync.RWMutex
+)
+
+// SetMaxOrderNotional 设置单笔订单名义价值上限（USDT）
+// 由交易器在下单前强制检查，策略层无法绕过；perSymbol 的 key 为币种（如 BTCUSDT）
+func SetMaxOrderNotional(defaultLimit float64, perSymbol map[string]float64) {
+	limits := make(map[string]float64, len(perSymbol))
+	for symbol, limit := range perSymbol {
+		limits[notionalLimitKey(symbol)] = limit
+	}
+
+	maxOrderNotionalMu.Lock()
+	defer maxOrderNotionalMu.Unlock()
+	maxOrderNotional = defaultLimit
+	maxOrderNotionalPerSymbol = limits
+}
+
+// MaxOrderNotional 返回币种的单笔订单名义价值上限（0表示不限制）
+func MaxOrderNotional(symbol string) float64 {
+	maxOrderNotionalMu.RLock()
+	defer maxOrderNotionalMu.RUnlock()
+	if limit, ok := maxOrderNotionalPerSymbol[notionalLimitKey(symbol)]; ok {
+		return limit
+	}
+	return maxOrderNotional
+}
+
+// notionalLimitKey 币种上限的key（大写，与交易器使用的币种格式一致）
+func notionalLimitKey(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// checkOrderNotional 按实际提交的订单名义价值（USDT）检查单笔上限
+func checkOrderNotional(symbol string, notional float64) error {
+	limit := MaxOrderNotional(symbol)
+	if limit <= 0 {
+		return nil
+	}
+	if toDecimal(notional).GreaterThan(toDecimal(limit)) {
+		return &OrderTooLargeError{Symbol: notionalLimitKey(symbol), Notional: notional, Limit: limit}
+	}
+	return nil
+}
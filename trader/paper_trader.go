@@ -0,0 +1,656 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// 编译期检查：PaperTrader可替换为任意Trader使用，并直接返回结构体结果
+var (
+	_ Trader      = (*PaperTrader)(nil)
+	_ TypedTrader = (*PaperTrader)(nil)
+)
+
+// PriceSource 模拟盘成交使用的价格来源（OkxTrader、FuturesTrader等交易器均可直接使用）
+type PriceSource interface {
+	GetMarketPrice(symbol string) (float64, error)
+}
+
+// PriceSourceFunc 函数形式的价格来源
+type PriceSourceFunc func(symbol string) (float64, error)
+
+// GetMarketPrice 实现PriceSource
+func (f PriceSourceFunc) GetMarketPrice(symbol string) (float64, error) {
+	return f(symbol)
+}
+
+// FixedPriceSource 固定价格的价格来源（测试夹具），价格可随时通过Set修改
+type FixedPriceSource struct {
+	mu     sync.RWMutex
+	prices map[string]float64
+}
+
+// NewFixedPriceSource 创建固定价格的价格来源
+func NewFixedPriceSource(prices map[string]float64) *FixedPriceSource {
+	s := &FixedPriceSource{prices: make(map[string]float64, len(prices))}
+	for symbol, price := range prices {
+		s.prices[symbol] = price
+	}
+	return s
+}
+
+// Set 设置交易对的价格
+func (s *FixedPriceSource) Set(symbol string, price float64) {
+	s.mu.Lock()
+	s.prices[symbol] = price
+	s.mu.Unlock()
+}
+
+// GetMarketPrice 实现PriceSource，未设置价格的交易对返回错误
+func (s *FixedPriceSource) GetMarketPrice(symbol string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	price, ok := s.prices[symbol]
+	if !ok || price <= 0 {
+		return 0, fmt.Errorf("%s 没有价格", symbol)
+	}
+	return price, nil
+}
+
+// PaperConfig 模拟盘配置
+type PaperConfig struct {
+	InitialBalance float64 // 初始余额（USDT）
+	TakerFeeBps    float64 // 吃单手续费（基点，5 = 0.05%）
+	SlippageBps    float64 // 市价单滑点（基点，买入价格上浮、卖出价格下浮）
+	QuantityStep   float64 // 数量步长（0表示按8位小数截断）
+}
+
+// PaperFill 模拟成交记录
+type PaperFill struct {
+	OrderID      string       `json:"order_id"`
+	Time         time.Time    `json:"time"`
+	Symbol       string       `json:"symbol"`
+	Side         Side         `json:"side"`
+	PositionSide PositionSide `json:"position_side"`
+	Quantity     float64      `json:"quantity"`
+	Price        float64      `json:"price"` // 含滑点的成交价
+	Fee          float64      `json:"fee"`   // 手续费（正数为支出）
+	RealizedPnL  float64      `json:"realized_pnl"`
+	Reason       string       `json:"reason"` // open / close / stop_loss / take_profit
+}
+
+// PaperPosition 模拟持仓
+type PaperPosition struct {
+	Symbol     string       `json:"symbol"`
+	Side       PositionSide `json:"side"`
+	Quantity   float64      `json:"quantity"`
+	EntryPrice float64      `json:"entry_price"`
+	Leverage   int          `json:"leverage"`
+	StopLoss   float64      `json:"stop_loss,omitempty"`   // 0表示未设置
+	TakeProfit float64      `json:"take_profit,omitempty"` // 0表示未设置
+	OpenTime   time.Time    `json:"open_time"`
+}
+
+// PaperSnapshot 模拟盘的完整状态（用于测试断言）
+type PaperSnapshot struct {
+	Time          time.Time          `json:"time"`
+	WalletBalance float64            `json:"wallet_balance"` // 已实现余额（不含未实现盈亏）
+	Positions     []PaperPosition    `json:"positions"`
+	Leverage      map[string]int     `json:"leverage"`
+	LastPrices    map[string]float64 `json:"last_prices"`
+	Fills         []PaperFill        `json:"fills"`
+}
+
+// PaperTrader 模拟盘交易器：内存中维护余额与持仓，按PriceSource的价格成交，不连接任何交易所
+// 价格穿过止损/止盈价时（查询价格、余额或持仓，或调用OnPrice时检查）按触发价市价平仓
+type PaperTrader struct {
+	source PriceSource
+	config PaperConfig
+	clock  clock.Clock
+
+	mu         sync.Mutex
+	wallet     float64
+	positions  map[string]*PaperPosition // key: 交易对 + 持仓方向
+	leverage   map[string]int
+	lastPrices map[string]float64
+	fills      []PaperFill
+	orderSeq   int
+}
+
+// NewPaperTrader 创建模拟盘交易器
+func NewPaperTrader(source PriceSource, config PaperConfig) (*PaperTrader, error) {
+	if source == nil {
+		return nil, fmt.Errorf("模拟盘必须提供价格来源")
+	}
+	if config.InitialBalance <= 0 {
+		return nil, fmt.Errorf("模拟盘初始余额必须大于0 (当前 %v)", config.InitialBalance)
+	}
+	if config.TakerFeeBps < 0 || config.SlippageBps < 0 || config.QuantityStep < 0 {
+		return nil, fmt.Errorf("模拟盘手续费、滑点与数量步长不能为负数")
+	}
+	return &PaperTrader{
+		source:     source,
+		config:     config,
+		clock:      clock.Real(),
+		wallet:     config.InitialBalance,
+		positions:  make(map[string]*PaperPosition),
+		leverage:   make(map[string]int),
+		lastPrices: make(map[string]float64),
+	}, nil
+}
+
+// SetClock 替换时间源（测试中注入假时钟）
+func (t *PaperTrader) SetClock(c clock.Clock) {
+	t.clock = clock.OrReal(c)
+}
+
+// IsDemo 实现DemoTrader：模拟盘始终为true
+func (t *PaperTrader) IsDemo() bool {
+	return true
+}
+
+// paperKey 持仓的key
+func paperKey(symbol string, side PositionSide) string {
+	return symbol + "/" + side.String()
+}
+
+// price 从价格来源获取价格，并检查该交易对的止损止盈
+func (t *PaperTrader) price(symbol string) (float64, error) {
+	price, err := t.source.GetMarketPrice(symbol)
+	if err != nil {
+		return 0, fmt.Errorf("获取价格失败: %w", err)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("%s 价格无效: %v", symbol, price)
+	}
+	t.OnPrice(symbol, price)
+	return price, nil
+}
+
+// refreshPrices 更新所有持仓交易对的价格（获取失败时沿用上次的价格）
+func (t *PaperTrader) refreshPrices() {
+	t.mu.Lock()
+	symbols := make(map[string]bool, len(t.positions))
+	for _, pos := range t.positions {
+		symbols[pos.Symbol] = true
+	}
+	t.mu.Unlock()
+	for symbol := range symbols {
+		if _, err := t.price(symbol); err != nil {
+			log.Printf("  ⚠ [模拟盘] %s 更新价格失败，沿用上次价格: %v", symbol, err)
+		}
+	}
+}
+
+// OnPrice 输入最新价格：价格穿过止损/止盈价时按触发价（含滑点）市价平仓
+func (t *PaperTrader) OnPrice(symbol string, price float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastPrices[symbol] = price
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		pos := t.positions[paperKey(symbol, side)]
+		if pos == nil {
+			continue
+		}
+		var trigger float64
+		var reason string
+		switch {
+		case pos.StopLoss > 0 && ((side == PositionLong && price <= pos.StopLoss) || (side == PositionShort && price >= pos.StopLoss)):
+			trigger, reason = pos.StopLoss, "stop_loss"
+		case pos.TakeProfit > 0 && ((side == PositionLong && price >= pos.TakeProfit) || (side == PositionShort && price <= pos.TakeProfit)):
+			trigger, reason = pos.TakeProfit, "take_profit"
+		default:
+			continue
+		}
+		fill := t.closeLocked(pos, pos.Quantity, trigger, reason)
+		log.Printf("  🎯 [模拟盘] %s %s 触发%s @ %.8g，盈亏 %.2f USDT", symbol, side, reason, fill.Price, fill.RealizedPnL)
+	}
+}
+
+// fillPrice 含滑点的市价成交价
+func (t *PaperTrader) fillPrice(price float64, side Side) float64 {
+	slip := t.config.SlippageBps / 10000
+	if side == SideBuy {
+		return price * (1 + slip)
+	}
+	return price * (1 - slip)
+}
+
+// fee 成交手续费（正数为支出）
+func (t *PaperTrader) fee(quantity, price float64) float64 {
+	return notionalValue(quantity, price) * t.config.TakerFeeBps / 10000
+}
+
+// nextOrderID 生成模拟订单ID
+func (t *PaperTrader) nextOrderID() string {
+	t.orderSeq++
+	return "paper-" + strconv.Itoa(t.orderSeq)
+}
+
+// usedMarginLocked 所有持仓占用的保证金（按开仓价计算）
+func (t *PaperTrader) usedMarginLocked() float64 {
+	used := 0.0
+	for _, pos := range t.positions {
+		used += marginRequired(pos.Quantity, pos.EntryPrice, pos.Leverage)
+	}
+	return used
+}
+
+// unrealizedLocked 持仓按最新价格计算的未实现盈亏
+func (t *PaperTrader) unrealizedLocked(pos *PaperPosition) float64 {
+	price, ok := t.lastPrices[pos.Symbol]
+	if !ok {
+		price = pos.EntryPrice
+	}
+	pnl := (price - pos.EntryPrice) * pos.Quantity
+	if pos.Side == PositionShort {
+		pnl = -pnl
+	}
+	return pnl
+}
+
+// GetBalance 获取模拟账户余额
+func (t *PaperTrader) GetBalance() (map[string]interface{}, error) {
+	balance, err := t.Balance(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return balance.Map(), nil
+}
+
+// Balance 实现TypedTrader：获取模拟账户余额（未实现盈亏按最新价格计算）
+func (t *PaperTrader) Balance(ctx context.Context) (*Balance, error) {
+	t.refreshPrices()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	unrealized := 0.0
+	for _, pos := range t.positions {
+		unrealized += t.unrealizedLocked(pos)
+	}
+	available := t.wallet + unrealized - t.usedMarginLocked()
+	return &Balance{
+		TotalWalletBalance:    t.wallet,
+		AvailableBalance:      available,
+		TotalUnrealizedProfit: unrealized,
+		CrossAvailableBalance: available,
+	}, nil
+}
+
+// GetPositions 获取模拟持仓
+func (t *PaperTrader) GetPositions() ([]map[string]interface{}, error) {
+	positions, err := t.Positions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, pos := range positions {
+		result = append(result, pos.Map())
+	}
+	return result, nil
+}
+
+// Positions 实现TypedTrader：获取模拟持仓（按交易对与方向排序）
+func (t *PaperTrader) Positions(ctx context.Context) ([]*Position, error) {
+	t.refreshPrices()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make([]*Position, 0, len(t.positions))
+	for _, pos := range t.positions {
+		mark, ok := t.lastPrices[pos.Symbol]
+		if !ok {
+			mark = pos.EntryPrice
+		}
+		result = append(result, &Position{
+			Symbol:        pos.Symbol,
+			Side:          pos.Side,
+			Quantity:      pos.Quantity,
+			EntryPrice:    pos.EntryPrice,
+			MarkPrice:     mark,
+			UnrealizedPnL: t.unrealizedLocked(pos),
+			Leverage:      pos.Leverage,
+			MarginMode:    "cross",
+			OpenTime:      pos.OpenTime,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol != result[j].Symbol {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].Side < result[j].Side
+	})
+	return result, nil
+}
+
+// OpenLong 开多仓
+func (t *PaperTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenLongOrder(context.Background(), symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenShort 开空仓
+func (t *PaperTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	result, err := t.OpenShortOrder(context.Background(), symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// OpenLongOrder 实现TypedTrader：模拟市价开多
+func (t *PaperTrader) OpenLongOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.open(symbol, PositionLong, quantity, leverage)
+}
+
+// OpenShortOrder 实现TypedTrader：模拟市价开空
+func (t *PaperTrader) OpenShortOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*OrderResult, error) {
+	return t.open(symbol, PositionShort, quantity, leverage)
+}
+
+// open 按最新价格（含滑点）模拟开仓，同方向已有持仓时按加权均价合并
+func (t *PaperTrader) open(symbol string, side PositionSide, quantity float64, leverage int) (*OrderResult, error) {
+	if leverage <= 0 {
+		return nil, fmt.Errorf("杠杆必须大于0 (当前 %d)", leverage)
+	}
+	qty, err := t.formatQuantity(quantity)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+	market, err := t.price(symbol)
+	if err != nil {
+		return nil, err
+	}
+	price := t.fillPrice(market, side.OpenSide())
+	if err := checkOrderNotional(symbol, notionalValue(qty, price)); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fee := t.fee(qty, price)
+	unrealized := 0.0
+	for _, pos := range t.positions {
+		unrealized += t.unrealizedLocked(pos)
+	}
+	available := t.wallet + unrealized - t.usedMarginLocked()
+	if need := marginRequired(qty, price, leverage) + fee; need > available {
+		return nil, fmt.Errorf("[模拟盘] %w: 需要 %.2f USDT，可用 %.2f USDT", ErrInsufficientBalance, need, available)
+	}
+
+	now := t.clock.Now()
+	key := paperKey(symbol, side)
+	pos := t.positions[key]
+	if pos == nil {
+		pos = &PaperPosition{Symbol: symbol, Side: side, OpenTime: now}
+		t.positions[key] = pos
+	}
+	total := toDecimal(pos.Quantity).Add(toDecimal(qty))
+	pos.EntryPrice = decimalFloat(toDecimal(pos.EntryPrice).Mul(toDecimal(pos.Quantity)).
+		Add(toDecimal(price).Mul(toDecimal(qty))).Div(total))
+	pos.Quantity = decimalFloat(total)
+	pos.Leverage = leverage
+	t.wallet -= fee
+
+	fill := PaperFill{
+		OrderID:      t.nextOrderID(),
+		Time:         now,
+		Symbol:       symbol,
+		Side:         side.OpenSide(),
+		PositionSide: side,
+		Quantity:     qty,
+		Price:        price,
+		Fee:          fee,
+		Reason:       "open",
+	}
+	t.fills = append(t.fills, fill)
+	log.Printf("✓ [模拟盘] 开仓成功: %s %s 数量: %.8g 价格: %.8g 手续费: %.4f", symbol, side, qty, price, fee)
+	result := paperOrderResult(fill)
+	result.Leverage = leverage
+	return result, nil
+}
+
+// CloseLong 平多仓（quantity=0表示全部平仓）
+func (t *PaperTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	result, err := t.CloseLongOrder(context.Background(), symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CloseShort 平空仓（quantity=0表示全部平仓）
+func (t *PaperTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	result, err := t.CloseShortOrder(context.Background(), symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CloseLongOrder 实现TypedTrader：模拟市价平多
+func (t *PaperTrader) CloseLongOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.close(symbol, PositionLong, quantity)
+}
+
+// CloseShortOrder 实现TypedTrader：模拟市价平空
+func (t *PaperTrader) CloseShortOrder(ctx context.Context, symbol string, quantity float64) (*OrderResult, error) {
+	return t.close(symbol, PositionShort, quantity)
+}
+
+// close 按最新价格（含滑点）模拟平仓，数量超过持仓时全部平仓；无持仓时清除止损止盈并返回 ErrPositionNotFound
+func (t *PaperTrader) close(symbol string, side PositionSide, quantity float64) (*OrderResult, error) {
+	market, err := t.price(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pos := t.positions[paperKey(symbol, side)]
+	if pos == nil {
+		return nil, ErrPositionNotFound
+	}
+	qty := pos.Quantity
+	if quantity > 0 && quantity < pos.Quantity {
+		if qty, err = t.formatQuantity(quantity); err != nil {
+			return nil, err
+		}
+	}
+	fill := t.closeLocked(pos, qty, t.fillPrice(market, side.CloseSide()), "close")
+	log.Printf("✓ [模拟盘] 平仓成功: %s %s 数量: %.8g 价格: %.8g 盈亏: %.2f USDT", symbol, side, qty, fill.Price, fill.RealizedPnL)
+	return paperOrderResult(fill), nil
+}
+
+// closeLocked 按成交价平掉持仓的qty数量，记录成交并结算盈亏（调用方持有锁）
+func (t *PaperTrader) closeLocked(pos *PaperPosition, qty, price float64, reason string) PaperFill {
+	if reason != "close" {
+		// 止损止盈是触发后的市价单，同样承担滑点
+		price = t.fillPrice(price, pos.Side.CloseSide())
+	}
+	pnl := decimalFloat(toDecimal(price).Sub(toDecimal(pos.EntryPrice)).Mul(toDecimal(qty)))
+	if pos.Side == PositionShort {
+		pnl = -pnl
+	}
+	fee := t.fee(qty, price)
+	t.wallet += pnl - fee
+
+	pos.Quantity = decimalFloat(toDecimal(pos.Quantity).Sub(toDecimal(qty)))
+	if pos.Quantity <= 0 {
+		delete(t.positions, paperKey(pos.Symbol, pos.Side))
+	}
+
+	fill := PaperFill{
+		OrderID:      t.nextOrderID(),
+		Time:         t.clock.Now(),
+		Symbol:       pos.Symbol,
+		Side:         pos.Side.CloseSide(),
+		PositionSide: pos.Side,
+		Quantity:     qty,
+		Price:        price,
+		Fee:          fee,
+		RealizedPnL:  pnl,
+		Reason:       reason,
+	}
+	t.fills = append(t.fills, fill)
+	return fill
+}
+
+// paperOrderResult 成交记录转换为OrderResult
+func paperOrderResult(fill PaperFill) *OrderResult {
+	return &OrderResult{
+		OrderID:      fill.OrderID,
+		Symbol:       fill.Symbol,
+		Side:         strings.ToUpper(fill.Side.String()),
+		PositionSide: strings.ToUpper(fill.PositionSide.String()),
+		Status:       "FILLED",
+		FilledQty:    fill.Quantity,
+		AvgPrice:     fill.Price,
+		Fee:          -fill.Fee,
+		FeeAsset:     "USDT",
+		RealizedPnL:  fill.RealizedPnL,
+		MarginMode:   "cross",
+		Time:         fill.Time,
+	}
+}
+
+// SetLeverage 设置杠杆（只影响之后的开仓）
+func (t *PaperTrader) SetLeverage(symbol string, leverage int) error {
+	if leverage <= 0 {
+		return fmt.Errorf("杠杆必须大于0 (当前 %d)", leverage)
+	}
+	t.mu.Lock()
+	t.leverage[symbol] = leverage
+	t.mu.Unlock()
+	return nil
+}
+
+// SetMarginMode 设置仓位模式（模拟盘只支持全仓，设置逐仓时记录日志后忽略）
+func (t *PaperTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	if !isCrossMargin {
+		log.Printf("  ⚠ [模拟盘] 不支持逐仓，%s 按全仓模拟", symbol)
+	}
+	return nil
+}
+
+// GetMarketPrice 获取价格来源的最新价格（同时检查止损止盈）
+func (t *PaperTrader) GetMarketPrice(symbol string) (float64, error) {
+	return t.price(symbol)
+}
+
+// SetStopLoss 设置模拟止损（对整个持仓生效，quantity被忽略）
+func (t *PaperTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	return t.setTrigger(symbol, positionSide, stopPrice, true)
+}
+
+// SetTakeProfit 设置模拟止盈（对整个持仓生效，quantity被忽略）
+func (t *PaperTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	return t.setTrigger(symbol, positionSide, takeProfitPrice, false)
+}
+
+// setTrigger 设置持仓的止损或止盈价，持仓不存在时返回 ErrPositionNotFound
+func (t *PaperTrader) setTrigger(symbol string, side PositionSide, price float64, stopLoss bool) error {
+	if !side.Valid() {
+		return fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	if price <= 0 {
+		return fmt.Errorf("触发价必须大于0 (当前 %v)", price)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pos := t.positions[paperKey(symbol, side)]
+	if pos == nil {
+		return ErrPositionNotFound
+	}
+	if stopLoss {
+		pos.StopLoss = price
+		log.Printf("  [模拟盘] 止损价设置: %.8g", price)
+	} else {
+		pos.TakeProfit = price
+		log.Printf("  [模拟盘] 止盈价设置: %.8g", price)
+	}
+	return nil
+}
+
+// CancelAllOrders 清除该币种持仓的止损止盈
+func (t *PaperTrader) CancelAllOrders(symbol string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, side := range []PositionSide{PositionLong, PositionShort} {
+		if pos := t.positions[paperKey(symbol, side)]; pos != nil {
+			pos.StopLoss, pos.TakeProfit = 0, 0
+		}
+	}
+	return nil
+}
+
+// FormatQuantity 格式化数量（按QuantityStep或8位小数向零截断）
+func (t *PaperTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	qty, err := t.formatQuantity(quantity)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(qty, 'f', -1, 64), nil
+}
+
+// formatQuantity 按QuantityStep或8位小数向零截断，结果为0时返回 ErrBelowMinSize
+func (t *PaperTrader) formatQuantity(quantity float64) (float64, error) {
+	d := floorToPrecision(quantity, 8)
+	if t.config.QuantityStep > 0 {
+		d = floorToStep(quantity, t.config.QuantityStep)
+	}
+	if !d.IsPositive() {
+		return 0, fmt.Errorf("%w: 数量 %v", ErrBelowMinSize, quantity)
+	}
+	return decimalFloat(d), nil
+}
+
+// Fills 返回所有模拟成交记录
+func (t *PaperTrader) Fills() []PaperFill {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PaperFill(nil), t.fills...)
+}
+
+// Snapshot 返回模拟盘的完整状态（持仓按交易对与方向排序，均为副本）
+func (t *PaperTrader) Snapshot() *PaperSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot := &PaperSnapshot{
+		Time:          t.clock.Now(),
+		WalletBalance: t.wallet,
+		Positions:     make([]PaperPosition, 0, len(t.positions)),
+		Leverage:      make(map[string]int, len(t.leverage)),
+		LastPrices:    make(map[string]float64, len(t.lastPrices)),
+		Fills:         append([]PaperFill(nil), t.fills...),
+	}
+	for _, pos := range t.positions {
+		snapshot.Positions = append(snapshot.Positions, *pos)
+	}
+	sort.Slice(snapshot.Positions, func(i, j int) bool {
+		a, b := snapshot.Positions[i], snapshot.Positions[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Side < b.Side
+	})
+	for symbol, lev := range t.leverage {
+		snapshot.Leverage[symbol] = lev
+	}
+	for symbol, price := range t.lastPrices {
+		snapshot.LastPrices[symbol] = price
+	}
+	return snapshot
+}
@@ -0,0 +1,115 @@
+package trader
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SymbolExposure 单个持仓的风险敞口（金额均为USD）
+type SymbolExposure struct {
+	Symbol           string       `json:"symbol"`
+	Side             PositionSide `json:"side"` // long / short
+	Quantity         float64      `json:"quantity"`
+	MarkPrice        float64      `json:"mark_price"`
+	Notional         float64      `json:"notional"` // 数量 × 标记价格
+	Leverage         int          `json:"leverage"`
+	MarginUsed       float64      `json:"margin_used"`
+	UnrealizedPnL    float64      `json:"unrealized_pnl"`
+	LiquidationPrice float64      `json:"liquidation_price"`
+	LiqDistancePct   float64      `json:"liq_distance_pct"`      // 标记价格距强平价的百分比（无强平价时为0）
+	MarginMode       string       `json:"margin_mode,omitempty"` // isolated / cross（交易器未提供时为空）
+}
+
+// PortfolioSummary 账户风险概览
+// 净敞口 = 多仓名义价值 - 空仓名义价值；总敞口为两者之和
+type PortfolioSummary struct {
+	Timestamp     time.Time        `json:"timestamp"`
+	TotalEquity   float64          `json:"total_equity"`
+	MarginUsed    float64          `json:"margin_used"`
+	MarginFree    float64          `json:"margin_free"`
+	GrossExposure float64          `json:"gross_exposure"`
+	NetExposure   float64          `json:"net_exposure"`
+	Positions     []SymbolExposure `json:"positions"`
+	Skewed        bool             `json:"skewed"` // 余额与持仓获取时间相差过大
+	Margin        MarginBreakdown  `json:"margin"` // 全仓/逐仓拆分，MarginFree即全仓可用保证金
+}
+
+// BuildPortfolioSummary 根据账户快照计算风险概览
+// 各交易器返回的持仓数量为币、价格与盈亏为USD（OKX币本位合约已折算），这里直接按USD汇总
+func BuildPortfolioSummary(snapshot *AccountSnapshot) *PortfolioSummary {
+	wallet, _ := snapshot.Balance["totalWalletBalance"].(float64)
+	unrealized, _ := snapshot.Balance["totalUnrealizedProfit"].(float64)
+	breakdown := NewMarginBreakdown(snapshot.Balance, snapshot.Positions)
+
+	summary := &PortfolioSummary{
+		Timestamp:   snapshot.Timestamp,
+		TotalEquity: sumFloat64(wallet, unrealized),
+		MarginFree:  breakdown.CrossAvailable,
+		Skewed:      snapshot.Skewed,
+		Positions:   make([]SymbolExposure, 0, len(snapshot.Positions)),
+		Margin:      breakdown,
+	}
+
+	marginUsed, gross, net := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, pos := range snapshot.Positions {
+		p := positionFromMap(pos)
+		notional := toDecimal(p.Quantity).Mul(toDecimal(p.MarkPrice))
+		margin := marginRequired(p.Quantity, p.MarkPrice, p.Leverage)
+
+		exposure := SymbolExposure{
+			Symbol:           p.Symbol,
+			Side:             p.Side,
+			Quantity:         p.Quantity,
+			MarkPrice:        p.MarkPrice,
+			Notional:         decimalFloat(notional),
+			Leverage:         p.Leverage,
+			MarginUsed:       margin,
+			UnrealizedPnL:    p.UnrealizedPnL,
+			LiquidationPrice: p.LiquidationPrice,
+			MarginMode:       p.MarginMode,
+		}
+		if p.LiquidationPrice > 0 && p.MarkPrice > 0 {
+			distance := toDecimal(p.MarkPrice).Sub(toDecimal(p.LiquidationPrice)).Abs()
+			exposure.LiqDistancePct = decimalFloat(distance.Div(toDecimal(p.MarkPrice)).Mul(decimal.NewFromInt(100)).Round(4))
+		}
+		summary.Positions = append(summary.Positions, exposure)
+
+		marginUsed = marginUsed.Add(toDecimal(margin))
+		gross = gross.Add(notional)
+		if p.Side == PositionShort {
+			net = net.Sub(notional)
+		} else {
+			net = net.Add(notional)
+		}
+	}
+
+	// 按名义价值从大到小排列
+	sort.Slice(summary.Positions, func(i, j int) bool {
+		return summary.Positions[i].Notional > summary.Positions[j].Notional
+	})
+	summary.MarginUsed = decimalFloat(marginUsed)
+	summary.GrossExposure = decimalFloat(gross)
+	summary.NetExposure = decimalFloat(net)
+	return summary
+}
+
+// GetPortfolioSummary 获取交易器的账户风险概览（命令行工具等不经过AutoTrader的场景使用）
+func GetPortfolioSummary(ctx context.Context, t Trader) (*PortfolioSummary, error) {
+	snapshot, err := fetchAccountSnapshot(ctx, t, nil)
+	if err != nil {
+		return nil, err
+	}
+	return BuildPortfolioSummary(snapshot), nil
+}
+
+// GetPortfolioSummary 获取账户风险概览
+func (at *AutoTrader) GetPortfolioSummary() (*PortfolioSummary, error) {
+	snapshot, err := at.GetAccountSnapshot(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return BuildPortfolioSummary(snapshot), nil
+}
@@ -0,0 +1,211 @@
+package trader
+
+import (
+	"fmt"
+	"time"
+)
+
+// Position 单个持仓（symbol + 方向）
+type Position struct {
+	Symbol           string       `json:"symbol"`
+	Side             PositionSide `json:"side"`     // long / short
+	Quantity         float64      `json:"quantity"` // 持仓数量（币，始终为正数）
+	EntryPrice       float64      `json:"entry_price"`
+	MarkPrice        float64      `json:"mark_price"`
+	UnrealizedPnL    float64      `json:"unrealized_pnl"`
+	Leverage         int          `json:"leverage"`
+	LiquidationPrice float64      `json:"liquidation_price"`
+	MarginMode       string       `json:"margin_mode,omitempty"`  // cross / isolated
+	MarginRatio      float64      `json:"margin_ratio,omitempty"` // 保证金率（OKX的mgnRatio，未提供时为0）
+
+	// 交易所提供的附加信息（未提供时为零值）
+	InstID            string    `json:"inst_id,omitempty"`
+	InstType          string    `json:"inst_type,omitempty"`
+	Contracts         float64   `json:"contracts,omitempty"`           // 持仓张数
+	UnrealizedPnLCcy  float64   `json:"unrealized_pnl_ccy,omitempty"`  // 以结算币种计的未实现盈亏
+	SettleCcy         string    `json:"settle_ccy,omitempty"`          // 结算币种
+	IsolatedMargin    float64   `json:"isolated_margin,omitempty"`     // 逐仓保证金（结算币种）
+	IsolatedMarginUSD float64   `json:"isolated_margin_usd,omitempty"` // 逐仓保证金（USD）
+	OpenTime          time.Time `json:"open_time,omitzero"`
+	Source            string    `json:"source,omitempty"` // 数据来源：ws（WebSocket实时推送）/ rest（REST查询或缓存）
+}
+
+// Map 转换为Trader接口使用的持仓map（positionAmt空仓为负数）
+func (p *Position) Map() map[string]interface{} {
+	amount := p.Quantity
+	if p.Side == PositionShort {
+		amount = -amount
+	}
+	result := map[string]interface{}{
+		"symbol":           p.Symbol,
+		"side":             p.Side.String(),
+		"positionAmt":      amount,
+		"entryPrice":       p.EntryPrice,
+		"markPrice":        p.MarkPrice,
+		"unRealizedProfit": p.UnrealizedPnL,
+		"leverage":         float64(p.Leverage),
+		"liquidationPrice": p.LiquidationPrice,
+		"marginMode":       p.MarginMode,
+		"marginRatio":      p.MarginRatio,
+	}
+	if p.InstID != "" {
+		result["instId"] = p.InstID
+		result["instType"] = p.InstType
+		result["contracts"] = p.Contracts
+		result["unRealizedProfitCcy"] = p.UnrealizedPnLCcy
+		result["settleCcy"] = p.SettleCcy
+		result["isolatedMargin"] = p.IsolatedMargin
+		result["isolatedMarginUsd"] = p.IsolatedMarginUSD
+	}
+	if !p.OpenTime.IsZero() {
+		result["openTime"] = p.OpenTime.UnixMilli()
+	}
+	if p.Source != "" {
+		result["source"] = p.Source
+	}
+	return result
+}
+
+// PositionGetter 可选接口：支持按币种和方向查询单个持仓的交易器
+type PositionGetter interface {
+	// GetPosition 获取指定方向的持仓，不存在时返回 ErrPositionNotFound
+	GetPosition(symbol string, side PositionSide) (*Position, error)
+}
+
+// ErrPositionNotFound 持仓不存在（使用 errors.Is(err, ErrPositionNotFound) 判断）
+var ErrPositionNotFound = newSentinelError(ErrCodePositionNotFound, "err_position_not_found")
+
+// PositionNotFoundError 带币种与方向的持仓不存在错误
+type PositionNotFoundError struct {
+	Symbol string
+	Side   PositionSide
+}
+
+func (e *PositionNotFoundError) Error() string {
+	sideName := msg("side_long")
+	if e.Side == PositionShort {
+		sideName = msg("side_short")
+	}
+	return msg("err_position_not_found_detail", e.Symbol, sideName)
+}
+
+func (e *PositionNotFoundError) ErrorCode() ErrorCode {
+	return ErrCodePositionNotFound
+}
+
+// Is 使 errors.Is(err, ErrPositionNotFound) 成立
+func (e *PositionNotFoundError) Is(target error) bool {
+	return target == ErrPositionNotFound
+}
+
+// ErrReduceOnlyExceeded 只减仓订单的数量超过当前持仓（使用 errors.Is(err, ErrReduceOnlyExceeded) 判断）
+// 单向持仓模式下超出部分会反向开仓，因此在下单前拒绝
+var ErrReduceOnlyExceeded = newSentinelError(ErrCodeReduceOnlyExceeded, "err_reduce_only_exceeded")
+
+// ReduceOnlyError 带数量信息的只减仓超限错误
+type ReduceOnlyError struct {
+	Symbol   string
+	Side     PositionSide
+	Quantity float64 // 请求数量
+	Position float64 // 当前持仓数量
+}
+
+func (e *ReduceOnlyError) Error() string {
+	return msg("err_reduce_only_exceeded_detail", e.Symbol, e.Side, e.Quantity, e.Position)
+}
+
+func (e *ReduceOnlyError) ErrorCode() ErrorCode {
+	return ErrCodeReduceOnlyExceeded
+}
+
+// Is 使 errors.Is(err, ErrReduceOnlyExceeded) 成立
+func (e *ReduceOnlyError) Is(target error) bool {
+	return target == ErrReduceOnlyExceeded
+}
+
+// checkReduceOnly 校验只减仓数量不超过持仓（decimal比较，避免浮点误差误判）
+func checkReduceOnly(pos *Position, quantity float64) error {
+	if quantity <= 0 {
+		return fmt.Errorf("只减仓数量必须大于0 (当前 %v)", quantity)
+	}
+	if toDecimal(quantity).GreaterThan(toDecimal(pos.Quantity)) {
+		return &ReduceOnlyError{Symbol: pos.Symbol, Side: pos.Side, Quantity: quantity, Position: pos.Quantity}
+	}
+	return nil
+}
+
+// ErrMarginModeConflict 同一币种混用全仓与逐仓（使用 errors.Is(err, ErrMarginModeConflict) 判断）
+var ErrMarginModeConflict = newSentinelError(ErrCodeMarginModeConflict, "err_margin_mode_conflict")
+
+// MarginModeConflictError 币种已有其他保证金模式的持仓
+type MarginModeConflictError struct {
+	Symbol   string
+	Side     PositionSide
+	Existing string // 现有持仓的保证金模式（cross / isolated）
+	Wanted   string // 请求的保证金模式
+}
+
+func (e *MarginModeConflictError) Error() string {
+	return msg("err_margin_mode_conflict_detail", e.Symbol, e.Side, e.Existing, e.Wanted)
+}
+
+func (e *MarginModeConflictError) ErrorCode() ErrorCode {
+	return ErrCodeMarginModeConflict
+}
+
+// Is 使 errors.Is(err, ErrMarginModeConflict) 成立
+func (e *MarginModeConflictError) Is(target error) bool {
+	return target == ErrMarginModeConflict
+}
+
+// positionFromMap 将GetPositions返回的持仓map转换为Position
+// 各交易所空仓的positionAmt可能为负数，这里统一取绝对值
+func positionFromMap(pos map[string]interface{}) *Position {
+	p := &Position{}
+	p.Symbol, _ = pos["symbol"].(string)
+	side, _ := pos["side"].(string)
+	p.Side, _ = ParsePositionSide(side)
+	p.Quantity, _ = pos["positionAmt"].(float64)
+	if p.Quantity < 0 {
+		p.Quantity = -p.Quantity
+	}
+	p.EntryPrice, _ = pos["entryPrice"].(float64)
+	p.MarkPrice, _ = pos["markPrice"].(float64)
+	p.UnrealizedPnL, _ = pos["unRealizedProfit"].(float64)
+	if lev, ok := pos["leverage"].(float64); ok {
+		p.Leverage = int(lev)
+	}
+	p.LiquidationPrice, _ = pos["liquidationPrice"].(float64)
+	p.MarginMode, _ = pos["marginMode"].(string)
+	p.MarginRatio, _ = pos["marginRatio"].(float64)
+	return p
+}
+
+// matchPosition 在持仓列表中查找指定方向的持仓，match判断持仓是否属于该币种
+func matchPosition(positions []map[string]interface{}, symbol string, side PositionSide, match func(pos map[string]interface{}) bool) (*Position, error) {
+	for _, pos := range positions {
+		if pos["side"] == side.String() && match(pos) {
+			if p := positionFromMap(pos); p.Quantity > 0 {
+				return p, nil
+			}
+		}
+	}
+	return nil, &PositionNotFoundError{Symbol: symbol, Side: side}
+}
+
+// findPosition 从GetPositions结果中查找单个持仓（不支持PositionGetter的交易器使用）
+func findPosition(t Trader, symbol string, side PositionSide) (*Position, error) {
+	if getter, ok := t.(PositionGetter); ok {
+		return getter.GetPosition(symbol, side)
+	}
+	if !side.Valid() {
+		return nil, fmt.Errorf("无效的持仓方向: %v", side)
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	return matchPosition(positions, symbol, side, func(pos map[string]interface{}) bool {
+		return pos["symbol"] == symbol
+	})
+}
@@ -0,0 +1,63 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestOkxGetPositionHedged 双向持仓同时持有多空时，按方向分别返回对应的持仓
+func TestOkxGetPositionHedged(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/positions",
+		okxTestPosition("BTC-USDT-SWAP", "long", "10", "50000", 10),
+		okxTestPosition("BTC-USDT-SWAP", "short", "5", "51000", 20),
+	)
+	tr := f.trader(t)
+
+	for _, tc := range []struct {
+		side     PositionSide
+		quantity float64
+		entry    float64
+		leverage int
+	}{
+		{PositionLong, 0.1, 50000, 10},
+		{PositionShort, 0.05, 51000, 20},
+	} {
+		pos, err := tr.GetPosition("BTCUSDT", tc.side)
+		if err != nil {
+			t.Fatalf("GetPosition(%s): %v", tc.side, err)
+		}
+		if pos.Side != tc.side || pos.Quantity != tc.quantity || pos.EntryPrice != tc.entry || pos.Leverage != tc.leverage {
+			t.Errorf("GetPosition(%s) = %+v, want %v @ %v %dx", tc.side, pos, tc.quantity, tc.entry, tc.leverage)
+		}
+	}
+
+	// 通用路径（GetPositions的map）同样区分两个方向
+	positions, err := tr.GetPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := matchPosition(positions, "BTCUSDT", PositionShort, func(pos map[string]interface{}) bool {
+		return pos["symbol"] == "BTCUSDT"
+	})
+	if err != nil || short.Quantity != 0.05 {
+		t.Errorf("matchPosition(short) = %+v, %v, want 0.05", short, err)
+	}
+}
+
+// TestOkxGetPositionNetMode 单向持仓按数量正负判断方向，另一方向返回 ErrPositionNotFound
+func TestOkxGetPositionNetMode(t *testing.T) {
+	f := newFakeOkx(t)
+	f.reply("GET /api/v5/account/positions", okxTestPosition("BTC-USDT-SWAP", "net", "-7", "50000", 5))
+	tr := f.trader(t)
+
+	pos, err := tr.GetPosition("BTCUSDT", PositionShort)
+	if err != nil || pos.Quantity != 0.07 {
+		t.Fatalf("GetPosition(short) = %+v, %v, want 0.07", pos, err)
+	}
+	_, err = tr.GetPosition("BTCUSDT", PositionLong)
+	var notFound *PositionNotFoundError
+	if !errors.Is(err, ErrPositionNotFound) || !errors.As(err, &notFound) || notFound.Side != PositionLong {
+		t.Fatalf("GetPosition(long) err = %v, want PositionNotFoundError(long)", err)
+	}
+}
@@ -0,0 +1,251 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PreflightSeverity 预检问题级别
+type PreflightSeverity string
+
+const (
+	PreflightError   PreflightSeverity = "error"   // 硬错误，拒绝启动
+	PreflightWarning PreflightSeverity = "warning" // 警告，需要 --force 才能启动
+)
+
+// PreflightIssue 预检发现的问题
+type PreflightIssue struct {
+	Severity PreflightSeverity `json:"severity"`
+	Check    string            `json:"check"` // symbol, leverage, risk, credentials...
+	Message  string            `json:"message"`
+}
+
+// PreflightReport 启动前配置预检报告
+type PreflightReport struct {
+	TraderName string           `json:"trader_name"`
+	CheckedAt  time.Time        `json:"checked_at"`
+	Issues     []PreflightIssue `json:"issues"`
+}
+
+var (
+	preflightForce   bool
+	preflightForceMu sync.RWMutex
+)
+
+// SetPreflightForce 设置是否在仅有预检警告时强制启动（命令行 --force）
+func SetPreflightForce(force bool) {
+	preflightForceMu.Lock()
+	defer preflightForceMu.Unlock()
+	preflightForce = force
+}
+
+func isPreflightForced() bool {
+	preflightForceMu.RLock()
+	defer preflightForceMu.RUnlock()
+	return preflightForce
+}
+
+func (r *PreflightReport) add(severity PreflightSeverity, check, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, PreflightIssue{
+		Severity: severity,
+		Check:    check,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *PreflightReport) count(severity PreflightSeverity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// HasErrors 是否存在硬错误
+func (r *PreflightReport) HasErrors() bool {
+	return r.count(PreflightError) > 0
+}
+
+// HasWarnings 是否存在警告
+func (r *PreflightReport) HasWarnings() bool {
+	return r.count(PreflightWarning) > 0
+}
+
+// Err 根据预检结果决定是否允许启动：硬错误总是拒绝，警告在force时放行
+func (r *PreflightReport) Err(force bool) error {
+	if r.HasErrors() {
+		return fmt.Errorf("配置预检失败: %d 个错误, %d 个警告", r.count(PreflightError), r.count(PreflightWarning))
+	}
+	if r.HasWarnings() && !force {
+		return fmt.Errorf("配置预检存在 %d 个警告，确认无误后使用 --force 启动", r.count(PreflightWarning))
+	}
+	return nil
+}
+
+// String 生成汇总报告
+func (r *PreflightReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 [%s] 启动预检: %d 个错误, %d 个警告\n",
+		r.TraderName, r.count(PreflightError), r.count(PreflightWarning)))
+	if len(r.Issues) == 0 {
+		sb.WriteString("  ✓ 全部检查通过\n")
+		return sb.String()
+	}
+	for _, issue := range r.Issues {
+		icon := "⚠"
+		if issue.Severity == PreflightError {
+			icon = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("  %s [%s] %s\n", icon, issue.Check, issue.Message))
+	}
+	return sb.String()
+}
+
+// RunPreflight 使用交易所实时数据校验完整配置
+func RunPreflight(config AutoTraderConfig, t Trader) *PreflightReport {
+	report := &PreflightReport{
+		TraderName: config.Name,
+		CheckedAt:  time.Now(),
+	}
+
+	// 可选接口需要在原始交易器上判断
+	if w, ok := t.(*instrumentedTrader); ok {
+		t = w.Trader
+	}
+
+	checkRiskParams(report, config)
+	balance := checkCredentials(report, t)
+	checkSymbols(report, config, t, balance)
+
+	return report
+}
+
+// checkRiskParams 风险参数合理性检查（百分比参数以百分数填写，如5表示5%）
+func checkRiskParams(report *PreflightReport, config AutoTraderConfig) {
+	if config.InitialBalance <= 0 {
+		report.add(PreflightError, "risk", "初始金额必须大于0 (当前 %.2f)", config.InitialBalance)
+	}
+	if config.ScanInterval <= 0 {
+		report.add(PreflightError, "risk", "扫描间隔必须大于0")
+	} else if config.ScanInterval < time.Minute {
+		report.add(PreflightWarning, "risk", "扫描间隔 %v 过短，可能触发交易所限频", config.ScanInterval)
+	}
+
+	checkPercent := func(name string, value float64) {
+		switch {
+		case value < 0 || value > 100:
+			report.add(PreflightError, "risk", "%s %.4g 超出范围 (0-100%%)", name, value)
+		case value > 0 && value < 1:
+			report.add(PreflightWarning, "risk", "%s %.4g 看起来是小数写法（该参数以百分数填写，如5表示5%%）", name, value)
+		case value > 50:
+			report.add(PreflightWarning, "risk", "%s %.4g%% 过高", name, value)
+		}
+	}
+	checkPercent("最大日亏损", config.MaxDailyLoss)
+	checkPercent("最大回撤", config.MaxDrawdown)
+
+	if config.BTCETHLeverage <= 0 {
+		report.add(PreflightError, "leverage", "BTC/ETH杠杆必须大于0 (当前 %d)", config.BTCETHLeverage)
+	}
+	if config.AltcoinLeverage <= 0 {
+		report.add(PreflightError, "leverage", "山寨币杠杆必须大于0 (当前 %d)", config.AltcoinLeverage)
+	}
+}
+
+// checkCredentials 凭证与权限自检，返回账户总权益（失败时为0）
+func checkCredentials(report *PreflightReport, t Trader) float64 {
+	if checker, ok := t.(PermissionChecker); ok {
+		warnings, err := checker.CheckPermissions()
+		if err != nil {
+			report.add(PreflightError, "credentials", "%v", err)
+			return 0
+		}
+		for _, w := range warnings {
+			report.add(PreflightWarning, "credentials", "%s", w)
+		}
+	}
+
+	balance, err := t.GetBalance()
+	if err != nil {
+		report.add(PreflightError, "credentials", "获取账户余额失败: %v", err)
+		return 0
+	}
+	total, _ := balance["totalWalletBalance"].(float64)
+	if total <= 0 {
+		report.add(PreflightWarning, "credentials", "账户余额为0")
+	}
+	return total
+}
+
+// checkSymbols 检查每个配置币种是否为可交易合约，杠杆与最小下单量是否满足交易所限制
+func checkSymbols(report *PreflightReport, config AutoTraderConfig, t Trader, balance float64) {
+	coins := config.TradingCoins
+	if len(coins) == 0 {
+		coins = config.DefaultCoins
+	}
+	if len(coins) == 0 {
+		return // 使用币种池，运行时动态获取
+	}
+
+	provider, hasLimits := t.(InstrumentLimitsProvider)
+	for _, coin := range coins {
+		symbol := normalizeSymbol(coin)
+
+		leverage := config.AltcoinLeverage
+		if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
+			leverage = config.BTCETHLeverage
+		}
+
+		if !hasLimits {
+			// 不支持查询规则的交易所，至少确认能取到价格
+			if _, err := t.GetMarketPrice(symbol); err != nil {
+				report.add(PreflightError, "symbol", "%s 无法获取价格: %v", symbol, err)
+			}
+			continue
+		}
+
+		limits, err := provider.GetInstrumentLimits(symbol)
+		if err != nil {
+			report.add(PreflightError, "symbol", "%s: %v", symbol, err)
+			continue
+		}
+		if !limits.Tradable {
+			report.add(PreflightError, "symbol", "%s 当前不可交易", symbol)
+			continue
+		}
+		if limits.MaxLeverage > 0 && leverage > limits.MaxLeverage {
+			report.add(PreflightError, "leverage", "%s 配置杠杆 %dx 超过交易所上限 %dx", symbol, leverage, limits.MaxLeverage)
+		}
+
+		// 按初始金额满仓也达不到最小名义价值时无法开仓
+		capital := config.InitialBalance
+		if balance > 0 && balance < capital {
+			capital = balance
+		}
+		if limits.MinNotional > 0 && capital > 0 && leverage > 0 && capital*float64(leverage) < limits.MinNotional {
+			report.add(PreflightWarning, "size", "%s 最小下单价值 %.2f USDT，资金 %.2f × %dx 杠杆不足",
+				symbol, limits.MinNotional, capital, leverage)
+		}
+	}
+}
+
+// Preflight 对当前配置执行启动预检
+func (at *AutoTrader) Preflight() *PreflightReport {
+	report := RunPreflight(at.config, at.trader)
+	report.CheckedAt = at.clock.Now()
+	return report
+}
+
+// CheckPreflight 执行预检并输出报告，返回是否允许启动
+func (at *AutoTrader) CheckPreflight() (*PreflightReport, error) {
+	report := at.Preflight()
+	for _, line := range strings.Split(strings.TrimRight(report.String(), "\n"), "\n") {
+		log.Print(line)
+	}
+	return report, report.Err(isPreflightForced())
+}
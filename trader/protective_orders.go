@@ -0,0 +1,138 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ProtectiveOrderSnapshot 某个币种当前生效的止损/止盈/追踪止损单快照
+type ProtectiveOrderSnapshot struct {
+	Symbol  string      `json:"symbol"`
+	Orders  []OpenOrder `json:"orders"`
+	TakenAt time.Time   `json:"taken_at"`
+}
+
+// ProtectiveOrderManager 可选接口：支持保护单快照与恢复的交易器
+// 用于切换仓位模式、调整杠杆等需要先撤单的操作，保证操作失败后仓位不会失去保护
+type ProtectiveOrderManager interface {
+	SnapshotProtectiveOrders(symbol string) (*ProtectiveOrderSnapshot, error)
+	RestoreProtectiveOrders(snapshot *ProtectiveOrderSnapshot) error
+	WithProtectiveOrdersSuspended(symbol string, fn func() error) error
+}
+
+// isProtectiveOrderType 是否为止损/止盈/追踪止损类型的订单
+func isProtectiveOrderType(orderType string) bool {
+	switch strings.ToUpper(orderType) {
+	case "STOP", "STOP_MARKET", "TAKE_PROFIT", "TAKE_PROFIT_MARKET", "TRAILING_STOP_MARKET":
+		return true
+	}
+	return false
+}
+
+// protectedPositionSide 订单保护的持仓方向，单向持仓模式（BOTH）根据买卖方向推断
+func protectedPositionSide(order OpenOrder) PositionSide {
+	if side, err := ParsePositionSide(order.PositionSide); err == nil {
+		return side
+	}
+	if strings.ToUpper(order.Side) == "SELL" {
+		return PositionLong
+	}
+	return PositionShort
+}
+
+// snapshotProtectiveOrders 记录保护现有持仓的保护单
+// 没有对应持仓的残留保护单不会被记录，恢复时也不会重新挂出
+func snapshotProtectiveOrders(t Trader, lister OpenOrderLister, symbol string) (*ProtectiveOrderSnapshot, error) {
+	orders, err := lister.GetOpenOrders(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 挂单失败: %w", symbol, err)
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	// 持仓方向 -> 持仓数量
+	liveSides := make(map[PositionSide]float64)
+	for _, pos := range positions {
+		if pos["symbol"] == symbol {
+			p := positionFromMap(pos)
+			liveSides[p.Side] = p.Quantity
+		}
+	}
+
+	snapshot := &ProtectiveOrderSnapshot{Symbol: symbol, TakenAt: time.Now()}
+	for _, order := range orders {
+		amt, live := liveSides[protectedPositionSide(order)]
+		if !isProtectiveOrderType(order.Type) || !live {
+			continue
+		}
+		// closePosition类型的订单没有数量，恢复时按当前持仓数量挂单
+		if order.Quantity == 0 {
+			order.Quantity = amt
+		}
+		snapshot.Orders = append(snapshot.Orders, order)
+	}
+	return snapshot, nil
+}
+
+// restoreProtectiveOrders 按快照重新挂出保护单，placeTrailing 用于交易所特有的追踪止损单
+// 单个订单失败不影响其余订单，返回汇总错误
+func restoreProtectiveOrders(t Trader, snapshot *ProtectiveOrderSnapshot, placeTrailing func(OpenOrder) error) error {
+	if snapshot == nil {
+		return nil
+	}
+
+	var failed []string
+	for _, order := range snapshot.Orders {
+		posSide := protectedPositionSide(order)
+		var err error
+		switch strings.ToUpper(order.Type) {
+		case "STOP", "STOP_MARKET":
+			err = t.SetStopLoss(order.Symbol, posSide, order.Quantity, order.StopPrice)
+		case "TAKE_PROFIT", "TAKE_PROFIT_MARKET":
+			err = t.SetTakeProfit(order.Symbol, posSide, order.Quantity, order.StopPrice)
+		case "TRAILING_STOP_MARKET":
+			if placeTrailing == nil {
+				err = fmt.Errorf("不支持恢复追踪止损单")
+			} else {
+				err = placeTrailing(order)
+			}
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s %s@%.8g: %v", order.Type, posSide, order.StopPrice, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("恢复 %d/%d 个保护单失败: %s", len(failed), len(snapshot.Orders), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// withProtectiveOrdersSuspended 撤销保护单 -> 执行fn -> 无论fn是否成功都重新挂出保护单
+func withProtectiveOrdersSuspended(m ProtectiveOrderManager, t Trader, symbol string, fn func() error) error {
+	snapshot, err := m.SnapshotProtectiveOrders(symbol)
+	if err != nil {
+		// 无法记录快照时仍按原流程撤单，但撤销的保护单无法自动恢复
+		log.Printf("🚨 %s 保护单快照失败，撤单后将无法自动恢复，请检查止损: %v", symbol, err)
+	}
+
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消 %s 委托单失败: %v", symbol, err)
+	}
+
+	fnErr := fn()
+
+	if snapshot != nil && len(snapshot.Orders) > 0 {
+		if err := m.RestoreProtectiveOrders(snapshot); err != nil {
+			log.Printf("🚨 %s 保护单恢复失败，持仓可能没有止损保护，请立即检查: %v", symbol, err)
+		} else {
+			log.Printf("  ✓ %s 已恢复 %d 个保护单", symbol, len(snapshot.Orders))
+		}
+	}
+
+	return fnErr
+}
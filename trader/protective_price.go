@@ -0,0 +1,82 @@
+package trader
+
+import "math"
+
+// ErrInvalidStopPrice 止损触发价在当前价格错误的一侧或距离过近（使用 errors.Is 判断）
+var ErrInvalidStopPrice = newSentinelError(ErrCodeInvalidStopPrice, "err_invalid_stop_price")
+
+// ErrInvalidTakeProfitPrice 止盈触发价在当前价格错误的一侧或距离过近（使用 errors.Is 判断）
+var ErrInvalidTakeProfitPrice = newSentinelError(ErrCodeInvalidTakeProfitPrice, "err_invalid_take_profit_price")
+
+// ProtectivePriceCheck 止损止盈提交前的价格校验配置
+type ProtectivePriceCheck struct {
+	MinDistancePct        float64 // 触发价与参考价的最小距离（百分比，0表示只校验方向）
+	AllowImmediateTrigger bool    // 跳过校验，允许提交会立即触发的止损止盈
+}
+
+// ProtectivePriceChecker 可选接口：提交止损止盈前按触发价类型的参考价校验触发价
+type ProtectivePriceChecker interface {
+	SetProtectivePriceCheck(check ProtectivePriceCheck)
+}
+
+// ProtectivePriceError 带价格信息的止损/止盈价格错误
+type ProtectivePriceError struct {
+	TakeProfit     bool
+	Symbol         string
+	Side           PositionSide
+	TriggerPrice   float64
+	ReferencePrice float64
+	PriceType      TriggerPriceType
+	MinDistancePct float64 // 为0时表示方向错误，否则表示距离不足
+}
+
+func (e *ProtectivePriceError) Error() string {
+	kind := msg("kind_stop_loss")
+	if e.TakeProfit {
+		kind = msg("kind_take_profit")
+	}
+	if e.MinDistancePct > 0 {
+		return msg("err_protective_price_too_close", e.Symbol, e.Side, kind, e.TriggerPrice, e.PriceType, e.ReferencePrice, e.MinDistancePct)
+	}
+	return msg("err_protective_price_wrong_side", e.Symbol, e.Side, kind, e.TriggerPrice, e.PriceType, e.ReferencePrice)
+}
+
+func (e *ProtectivePriceError) ErrorCode() ErrorCode {
+	if e.TakeProfit {
+		return ErrCodeInvalidTakeProfitPrice
+	}
+	return ErrCodeInvalidStopPrice
+}
+
+// Is 使 errors.Is(err, ErrInvalidStopPrice) 或 errors.Is(err, ErrInvalidTakeProfitPrice) 成立
+func (e *ProtectivePriceError) Is(target error) bool {
+	if e.TakeProfit {
+		return target == ErrInvalidTakeProfitPrice
+	}
+	return target == ErrInvalidStopPrice
+}
+
+// check 校验触发价相对参考价的方向与距离：
+// 多仓止损与空仓止盈的触发价须低于参考价，多仓止盈与空仓止损的触发价须高于参考价
+func (c ProtectivePriceCheck) check(takeProfit bool, symbol string, side PositionSide, trigger, reference float64, priceType TriggerPriceType) error {
+	if c.AllowImmediateTrigger || reference <= 0 {
+		return nil
+	}
+	mustBeBelow := (side == PositionLong) != takeProfit
+	err := &ProtectivePriceError{
+		TakeProfit:     takeProfit,
+		Symbol:         symbol,
+		Side:           side,
+		TriggerPrice:   trigger,
+		ReferencePrice: reference,
+		PriceType:      priceType,
+	}
+	if (mustBeBelow && trigger >= reference) || (!mustBeBelow && trigger <= reference) {
+		return err
+	}
+	if c.MinDistancePct > 0 && math.Abs(reference-trigger)/reference*100 < c.MinDistancePct {
+		err.MinDistancePct = c.MinDistancePct
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package trader
+
+import (
+	"context"
+	"nofx/clock"
+	"sync"
+	"time"
+)
+
+// RequestPriority 交易所请求的优先级（数值越大越优先）
+type RequestPriority int
+
+const (
+	PriorityRead     RequestPriority = iota // 行情、余额、持仓查询
+	PriorityEntry                           // 开仓、杠杆/保证金设置
+	PriorityCritical                        // 平仓、止损止盈、撤单
+	priorityLevels
+)
+
+func (p RequestPriority) String() string {
+	switch p {
+	case PriorityRead:
+		return "read"
+	case PriorityEntry:
+		return "entry"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// RequestSchedulerConfig 请求调度配置（RatePerSecond<=0 表示不限速）
+type RequestSchedulerConfig struct {
+	RatePerSecond float64 // 每秒允许的请求数
+	Burst         int     // 突发请求数（默认1）
+}
+
+// schedulerWaiter 排队中的请求
+type schedulerWaiter struct {
+	ready chan struct{}
+}
+
+// RequestScheduler 带优先级的令牌桶限速器
+// 令牌不足时请求按优先级排队，有令牌时先放行高优先级请求（同优先级先到先得），
+// 因此平仓、止损等风控请求会越过排队中的行情刷新，限速器饱和时也能尽快发出
+type RequestScheduler struct {
+	rate  float64
+	burst float64
+	clock clock.Clock
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	queues      [priorityLevels][]*schedulerWaiter
+	dispatching bool
+}
+
+// NewRequestScheduler 创建请求调度器，RatePerSecond<=0 时返回nil（不限速）
+func NewRequestScheduler(config RequestSchedulerConfig, clk clock.Clock) *RequestScheduler {
+	if config.RatePerSecond <= 0 {
+		return nil
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	clk = clock.OrReal(clk)
+	return &RequestScheduler{
+		rate:   config.RatePerSecond,
+		burst:  float64(config.Burst),
+		clock:  clk,
+		tokens: float64(config.Burst),
+		last:   clk.Now(),
+	}
+}
+
+// Acquire 按优先级获取一个请求令牌，必要时阻塞等待（调度器为nil时立即返回）
+func (s *RequestScheduler) Acquire(priority RequestPriority) {
+	_ = s.AcquireContext(context.Background(), priority)
+}
+
+// AcquireContext 同Acquire，ctx结束时从队列中移除并返回ctx.Err()（未消耗令牌）
+func (s *RequestScheduler) AcquireContext(ctx context.Context, priority RequestPriority) error {
+	_, err := s.acquire(ctx, priority)
+	return err
+}
+
+// acquire 同AcquireContext，并返回请求是否排队等待过
+func (s *RequestScheduler) acquire(ctx context.Context, priority RequestPriority) (queued bool, err error) {
+	if s == nil {
+		return false, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	priority = clampPriority(priority)
+
+	s.mu.Lock()
+	s.refill()
+	if s.tokens >= 1 && !s.hasWaitersFrom(priority) {
+		s.tokens--
+		s.mu.Unlock()
+		return false, nil
+	}
+	w := &schedulerWaiter{ready: make(chan struct{})}
+	s.queues[priority] = append(s.queues[priority], w)
+	if !s.dispatching {
+		s.dispatching = true
+		go s.dispatch()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true, nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.remove(priority, w) {
+		// 取消与放行同时发生：令牌已分配给该请求，按获取成功处理
+		return true, nil
+	}
+	return true, ctx.Err()
+}
+
+// tryAcquire 不排队获取令牌：没有可用令牌、或有不低于priority的请求在排队时返回false，
+// 以及预计多久后轮到该优先级（调度器为nil时总是成功）
+func (s *RequestScheduler) tryAcquire(priority RequestPriority) (bool, time.Duration) {
+	if s == nil {
+		return true, 0
+	}
+	priority = clampPriority(priority)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refill()
+	if s.tokens >= 1 && !s.hasWaitersFrom(priority) {
+		s.tokens--
+		return true, 0
+	}
+	ahead := 0
+	for p := priority; p < priorityLevels; p++ {
+		ahead += len(s.queues[p])
+	}
+	wait := (float64(ahead) + 1 - s.tokens) / s.rate
+	if wait < 0 {
+		wait = 0
+	}
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// clampPriority 超出范围的优先级按最低或最高处理
+func clampPriority(priority RequestPriority) RequestPriority {
+	if priority < 0 {
+		return PriorityRead
+	}
+	if priority >= priorityLevels {
+		return PriorityCritical
+	}
+	return priority
+}
+
+// Pending 各优先级排队中的请求数
+func (s *RequestScheduler) Pending() map[RequestPriority]int {
+	pending := make(map[RequestPriority]int)
+	if s == nil {
+		return pending
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.queues {
+		if n := len(s.queues[p]); n > 0 {
+			pending[RequestPriority(p)] = n
+		}
+	}
+	return pending
+}
+
+// dispatch 有令牌时按优先级放行排队的请求，队列清空后退出
+func (s *RequestScheduler) dispatch() {
+	for {
+		s.mu.Lock()
+		s.refill()
+		for s.tokens >= 1 {
+			w := s.popHighest()
+			if w == nil {
+				break
+			}
+			s.tokens--
+			close(w.ready)
+		}
+		if s.peekHighest() == nil {
+			s.dispatching = false
+			s.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - s.tokens) / s.rate * float64(time.Second))
+		s.mu.Unlock()
+
+		if wait < time.Millisecond {
+			wait = time.Millisecond
+		}
+		<-s.clock.After(wait)
+	}
+}
+
+// refill 按经过的时间补充令牌（需持有锁）
+func (s *RequestScheduler) refill() {
+	now := s.clock.Now()
+	if elapsed := now.Sub(s.last); elapsed > 0 {
+		s.tokens += elapsed.Seconds() * s.rate
+		if s.tokens > s.burst {
+			s.tokens = s.burst
+		}
+	}
+	s.last = now
+}
+
+// hasWaitersFrom 是否有优先级不低于priority的请求在排队（需持有锁）
+func (s *RequestScheduler) hasWaitersFrom(priority RequestPriority) bool {
+	for p := priority; p < priorityLevels; p++ {
+		if len(s.queues[p]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// remove 从队列中移除等待者，已被放行时返回false（需持有锁）
+func (s *RequestScheduler) remove(priority RequestPriority, w *schedulerWaiter) bool {
+	q := s.queues[priority]
+	for i, queued := range q {
+		if queued == w {
+			s.queues[priority] = append(q[:i:i], q[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// popHighest 取出优先级最高的排队请求（需持有锁）
+func (s *RequestScheduler) popHighest() *schedulerWaiter {
+	for p := priorityLevels - 1; p >= 0; p-- {
+		if q := s.queues[p]; len(q) > 0 {
+			s.queues[p] = q[1:]
+			return q[0]
+		}
+	}
+	return nil
+}
+
+// peekHighest 返回优先级最高的排队请求但不取出（需持有锁）
+func (s *RequestScheduler) peekHighest() *schedulerWaiter {
+	for p := priorityLevels - 1; p >= 0; p-- {
+		if q := s.queues[p]; len(q) > 0 {
+			return q[0]
+		}
+	}
+	return nil
+}
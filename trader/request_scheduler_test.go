@@ -0,0 +1,115 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// orderRecorder 记录请求实际发给交易所的顺序
+type orderRecorder struct {
+	Trader
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *orderRecorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *orderRecorder) GetMarketPrice(symbol string) (float64, error) {
+	r.add("GetMarketPrice")
+	return 50000, nil
+}
+
+func (r *orderRecorder) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	r.add("CloseLong")
+	return map[string]interface{}{}, nil
+}
+
+func waitPending(t *testing.T, s *RequestScheduler, priority RequestPriority, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Pending()[priority] != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s 排队数 = %d, want %d", priority, s.Pending()[priority], want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestSchedulerCriticalBeforeQueuedReads 限速器饱和时，后到的平仓先于排队中的行情查询发出
+func TestSchedulerCriticalBeforeQueuedReads(t *testing.T) {
+	recorder := &orderRecorder{}
+	scheduler := NewRequestScheduler(RequestSchedulerConfig{RatePerSecond: 10, Burst: 1}, nil)
+	tr := newInstrumentedTrader(recorder)
+	tr.SetScheduler(scheduler)
+
+	scheduler.Acquire(PriorityRead) // 耗尽令牌
+	const reads = 5
+	var wg sync.WaitGroup
+	for i := 0; i < reads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr.GetMarketPrice("BTCUSDT")
+		}()
+	}
+	waitPending(t, scheduler, PriorityRead, reads)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tr.CloseLong("BTCUSDT", 0)
+	}()
+	wg.Wait()
+
+	if len(recorder.calls) != reads+1 || recorder.calls[0] != "CloseLong" {
+		t.Fatalf("发出顺序 = %v, want CloseLong 在最前", recorder.calls)
+	}
+}
+
+// TestSchedulerAcquireContextCancel ctx取消后等待者出队，不消耗令牌也不阻塞后续请求
+func TestSchedulerAcquireContextCancel(t *testing.T) {
+	scheduler := NewRequestScheduler(RequestSchedulerConfig{RatePerSecond: 20, Burst: 1}, nil)
+	scheduler.Acquire(PriorityRead)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- scheduler.AcquireContext(ctx, PriorityCritical) }()
+	waitPending(t, scheduler, PriorityCritical, 1)
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("AcquireContext = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后AcquireContext未返回")
+	}
+	if pending := scheduler.Pending(); len(pending) != 0 {
+		t.Errorf("取消后仍有排队请求: %v", pending)
+	}
+
+	// 已取消的ctx直接返回，不排队
+	if err := scheduler.AcquireContext(ctx, PriorityRead); !errors.Is(err, context.Canceled) {
+		t.Errorf("ctx已取消时 AcquireContext = %v", err)
+	}
+	// 令牌补充后后续请求正常获取
+	if err := scheduler.AcquireContext(t.Context(), PriorityRead); err != nil {
+		t.Errorf("AcquireContext = %v", err)
+	}
+}
+
+// TestNilSchedulerAcquireContext 未配置调度器时不限速
+func TestNilSchedulerAcquireContext(t *testing.T) {
+	var scheduler *RequestScheduler
+	if err := scheduler.AcquireContext(t.Context(), PriorityRead); err != nil {
+		t.Errorf("nil调度器 AcquireContext = %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"nofx/clock"
+)
+
+// RetryPolicy 交易所调用的重试策略
+// 查询类调用遇到可重试错误码、超时或网络错误时重试；
+// 变更类调用（下单、平仓、设置杠杆等）只在确定请求未被执行时重试：
+// 错误码在RetryableCodes中（限速、系统繁忙、时间戳过期等交易所在执行前拒绝的错误），或连接尚未建立。
+// 结果未知的错误（请求已发出后超时/取消，见IsOutcomeUnknown）不会重试变更类调用——
+// OKX下单在返回这类错误前已按clOrdId对账，仍然未知说明对账也失败了，重发可能重复开仓
+type RetryPolicy struct {
+	MaxAttempts    int           // 最多尝试次数（含第一次），默认3，1表示不重试
+	BaseDelay      time.Duration // 第一次重试前的等待时间，之后每次翻倍，默认200毫秒
+	MaxDelay       time.Duration // 单次等待的上限，默认2秒
+	MaxElapsed     time.Duration // 从第一次调用开始的总时长上限，超过后不再重试，默认10秒
+	RetryableCodes map[int]bool  // 可重试的交易所错误码（为空时使用DefaultRetryableCodes）
+}
+
+// DefaultRetryableCodes 默认可重试的OKX错误码（交易所在执行前拒绝，变更类调用重试也不会重复执行）
+var DefaultRetryableCodes = map[int]bool{
+	50001: true, // 服务暂时不可用
+	50011: true, // 请求频率过高
+	50013: true, // 系统繁忙
+	50061: true, // 订单请求频率过高
+	50102: true, // 请求时间戳过期
+}
+
+// okxReadRetryableCodes 只对查询类调用重试的OKX错误码（变更类调用的执行结果未知）
+var okxReadRetryableCodes = map[int]bool{
+	50004: true, // 接口请求超时（不代表请求成功或失败）
+	50026: true, // 系统错误，请稍后重试
+}
+
+// DefaultRetryPolicy 默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      200 * time.Millisecond,
+		MaxDelay:       2 * time.Second,
+		MaxElapsed:     10 * time.Second,
+		RetryableCodes: DefaultRetryableCodes,
+	}
+}
+
+// withDefaults 未设置的字段使用默认值
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaults.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = defaults.MaxElapsed
+	}
+	if len(p.RetryableCodes) == 0 {
+		p.RetryableCodes = defaults.RetryableCodes
+	}
+	return p
+}
+
+// backoff 第attempt次重试前的等待时间（指数退避，在[d/2, d]之间随机抖动，避免多个交易员同时重试）
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(d-half)+1))
+}
+
+// retryable 判断错误是否可以重试，mutation为true时只接受确定未执行的错误
+func (p RetryPolicy) retryable(err error, mutation bool) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var okxErr *OkxError
+	if errors.As(err, &okxErr) {
+		codes := []int{okxErr.Code, int(okxErr.SCode)}
+		for _, code := range codes {
+			if p.RetryableCodes[code] || (!mutation && okxReadRetryableCodes[code]) {
+				return true
+			}
+		}
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || notSent(err) {
+		return true
+	}
+	if mutation {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, ErrTimeout) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// notSent 请求确定没有到达交易所（DNS解析或建立连接失败）
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// RetryingTrader 按RetryPolicy重试Trader调用的装饰器
+// 只重试Trader接口中的方法，其他可选接口需通过Trader字段访问底层交易器
+type RetryingTrader struct {
+	Trader
+	policy RetryPolicy
+	clock  clock.Clock
+}
+
+// 编译期检查：RetryingTrader可替换为任意Trader使用
+var _ Trader = (*RetryingTrader)(nil)
+
+// NewRetryingTrader 包装Trader，未设置的策略字段使用默认值（clk为nil时使用系统时钟）
+func NewRetryingTrader(t Trader, policy RetryPolicy, clk clock.Clock) *RetryingTrader {
+	return &RetryingTrader{Trader: t, policy: policy.withDefaults(), clock: clock.OrReal(clk)}
+}
+
+// retryCall 按策略执行fn，返回最后一次调用的结果
+func retryCall[T any](t *RetryingTrader, method string, mutation bool, fn func() (T, error)) (T, error) {
+	start := t.clock.Now()
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= t.policy.MaxAttempts || !t.policy.retryable(err, mutation) {
+			return result, err
+		}
+		delay := t.policy.backoff(attempt)
+		if t.clock.Since(start)+delay > t.policy.MaxElapsed {
+			return result, err
+		}
+		log.Printf("  ⚠ %s 失败（第%d次），%v 后重试: %v", method, attempt, delay, err)
+		<-t.clock.After(delay)
+	}
+}
+
+// retryErr 只返回error的方法
+func retryErr(t *RetryingTrader, method string, mutation bool, fn func() error) error {
+	_, err := retryCall(t, method, mutation, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+func (t *RetryingTrader) GetBalance() (map[string]interface{}, error) {
+	return retryCall(t, "GetBalance", false, t.Trader.GetBalance)
+}
+
+func (t *RetryingTrader) GetPositions() ([]map[string]interface{}, error) {
+	return retryCall(t, "GetPositions", false, t.Trader.GetPositions)
+}
+
+func (t *RetryingTrader) GetMarketPrice(symbol string) (float64, error) {
+	return retryCall(t, "GetMarketPrice", false, func() (float64, error) {
+		return t.Trader.GetMarketPrice(symbol)
+	})
+}
+
+func (t *RetryingTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return retryCall(t, "FormatQuantity", false, func() (string, error) {
+		return t.Trader.FormatQuantity(symbol, quantity)
+	})
+}
+
+// OpenLong 开多仓（设置杠杆后下单失败时整体重试，重新设置相同杠杆不会产生副作用）
+func (t *RetryingTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return retryCall(t, "OpenLong", true, func() (map[string]interface{}, error) {
+		return t.Trader.OpenLong(symbol, quantity, leverage)
+	})
+}
+
+// OpenShort 开空仓（同OpenLong）
+func (t *RetryingTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return retryCall(t, "OpenShort", true, func() (map[string]interface{}, error) {
+		return t.Trader.OpenShort(symbol, quantity, leverage)
+	})
+}
+
+func (t *RetryingTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return retryCall(t, "CloseLong", true, func() (map[string]interface{}, error) {
+		return t.Trader.CloseLong(symbol, quantity)
+	})
+}
+
+func (t *RetryingTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return retryCall(t, "CloseShort", true, func() (map[string]interface{}, error) {
+		return t.Trader.CloseShort(symbol, quantity)
+	})
+}
+
+func (t *RetryingTrader) SetLeverage(symbol string, leverage int) error {
+	return retryErr(t, "SetLeverage", true, func() error {
+		return t.Trader.SetLeverage(symbol, leverage)
+	})
+}
+
+func (t *RetryingTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	return retryErr(t, "SetMarginMode", true, func() error {
+		return t.Trader.SetMarginMode(symbol, isCrossMargin)
+	})
+}
+
+func (t *RetryingTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	return retryErr(t, "SetStopLoss", true, func() error {
+		return t.Trader.SetStopLoss(symbol, positionSide, quantity, stopPrice)
+	})
+}
+
+func (t *RetryingTrader) SetTakeProfit(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64) error {
+	return retryErr(t, "SetTakeProfit", true, func() error {
+		return t.Trader.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	})
+}
+
+func (t *RetryingTrader) CancelAllOrders(symbol string) error {
+	return retryErr(t, "CancelAllOrders", true, func() error {
+		return t.Trader.CancelAllOrders(symbol)
+	})
+}
@@ -0,0 +1,194 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTrader 每次调用依次返回errs中的错误，用完后成功，记录各方法的调用次数
+type flakyTrader struct {
+	Trader
+	mu    sync.Mutex
+	errs  []error
+	calls map[string]int
+}
+
+func newFlakyTrader(errs ...error) *flakyTrader {
+	return &flakyTrader{errs: errs, calls: make(map[string]int)}
+}
+
+func (f *flakyTrader) next(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyTrader) GetBalance() (map[string]interface{}, error) {
+	if err := f.next("GetBalance"); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"totalWalletBalance": 1000.0}, nil
+}
+
+func (f *flakyTrader) GetPositions() ([]map[string]interface{}, error) {
+	return nil, f.next("GetPositions")
+}
+
+func (f *flakyTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if err := f.next("OpenLong"); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"orderId": "1"}, nil
+}
+
+func (f *flakyTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	if err := f.next("CloseShort"); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"orderId": "2"}, nil
+}
+
+func (f *flakyTrader) SetLeverage(symbol string, leverage int) error {
+	return f.next("SetLeverage")
+}
+
+func (f *flakyTrader) SetStopLoss(symbol string, positionSide PositionSide, quantity, stopPrice float64) error {
+	return f.next("SetStopLoss")
+}
+
+func (f *flakyTrader) CancelAllOrders(symbol string) error {
+	return f.next("CancelAllOrders")
+}
+
+// fastRetryPolicy 测试用的重试策略：等待时间为毫秒级
+func fastRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+}
+
+// retryTestCalls 查询类与变更类调用，method为底层交易器上被调用的方法
+var retryTestCalls = []struct {
+	method   string
+	mutation bool
+	call     func(*RetryingTrader) error
+}{
+	{"GetBalance", false, func(r *RetryingTrader) error { _, err := r.GetBalance(); return err }},
+	{"GetPositions", false, func(r *RetryingTrader) error { _, err := r.GetPositions(); return err }},
+	{"OpenLong", true, func(r *RetryingTrader) error { _, err := r.OpenLong("BTCUSDT", 0.1, 5); return err }},
+	{"CloseShort", true, func(r *RetryingTrader) error { _, err := r.CloseShort("BTCUSDT", 0); return err }},
+	{"SetLeverage", true, func(r *RetryingTrader) error { return r.SetLeverage("BTCUSDT", 5) }},
+	{"SetStopLoss", true, func(r *RetryingTrader) error { return r.SetStopLoss("BTCUSDT", PositionLong, 0.1, 48000) }},
+	{"CancelAllOrders", true, func(r *RetryingTrader) error { return r.CancelAllOrders("BTCUSDT") }},
+}
+
+// TestRetryingTraderRetryableErrors 第一次调用失败、第二次成功时，按错误类型与调用类型决定是否重试：
+// 交易所在执行前拒绝的错误（可重试错误码、客户端限速、连接未建立）所有调用都重试，
+// 结果未知的错误（超时、连接中断、只对查询重试的错误码）只重试查询，其他错误都不重试
+func TestRetryingTraderRetryableErrors(t *testing.T) {
+	type errCase struct {
+		name          string
+		err           error
+		retryRead     bool
+		retryMutation bool
+	}
+	var cases []errCase
+	for _, code := range []int{50001, 50011, 50013, 50061, 50102} {
+		cases = append(cases,
+			errCase{"code " + strconv.Itoa(code), &OkxError{Op: "test", Code: code, Msg: "busy"}, true, true},
+			errCase{"sCode " + strconv.Itoa(code), &OkxError{Op: "test", SCode: int64(code), SMsg: "busy"}, true, true},
+		)
+	}
+	cases = append(cases,
+		errCase{"rate limited", ErrRateLimited, true, true},
+		errCase{"wrapped rate limited", fmt.Errorf("PlaceOrder: %w", ErrRateLimited), true, true},
+		errCase{"dial failed", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true, true},
+		errCase{"dns failed", &net.DNSError{Err: "no such host", Name: "www.okx.com"}, true, true},
+		errCase{"okx 50004 timeout", &OkxError{Op: "test", Code: 50004, Msg: "timeout"}, true, false},
+		errCase{"okx 50026 system error", &OkxError{Op: "test", Code: 50026, Msg: "system error"}, true, false},
+		errCase{"client timeout", &TimeoutError{Op: "test", Class: OpMutation, Timeout: time.Second}, true, false},
+		errCase{"read reset", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}, true, false},
+		errCase{"unexpected eof", io.ErrUnexpectedEOF, true, false},
+		errCase{"okx 51008 insufficient margin", &OkxError{Op: "test", SCode: 51008, SMsg: "insufficient"}, false, false},
+		errCase{"okx 51000 parameter error", &OkxError{Op: "test", Code: 51000, Msg: "parameter error"}, false, false},
+		errCase{"plain error", errors.New("bad request"), false, false},
+		errCase{"canceled", context.Canceled, false, false},
+	)
+
+	for _, tc := range cases {
+		for _, c := range retryTestCalls {
+			retry := tc.retryRead
+			if c.mutation {
+				retry = tc.retryMutation
+			}
+			t.Run(tc.name+"/"+c.method, func(t *testing.T) {
+				flaky := newFlakyTrader(tc.err)
+				err := c.call(NewRetryingTrader(flaky, fastRetryPolicy(), nil))
+				wantCalls := 1
+				if retry {
+					wantCalls = 2
+				}
+				if got := flaky.calls[c.method]; got != wantCalls {
+					t.Errorf("调用次数 = %d, want %d", got, wantCalls)
+				}
+				switch {
+				case retry && err != nil:
+					t.Errorf("重试后仍返回错误: %v", err)
+				case !retry && !errors.Is(err, tc.err):
+					t.Errorf("err = %v, want 原始错误 %v", err, tc.err)
+				}
+			})
+		}
+	}
+}
+
+// TestRetryingTraderMaxAttempts 持续失败时最多尝试MaxAttempts次，返回最后一次的错误；MaxAttempts为1时不重试
+func TestRetryingTraderMaxAttempts(t *testing.T) {
+	busy := &OkxError{Op: "test", Code: 50013, Msg: "busy"}
+	for _, tc := range []struct {
+		maxAttempts int
+		wantCalls   int
+	}{
+		{0, 3}, // 默认3次
+		{1, 1},
+		{3, 3},
+		{5, 5},
+	} {
+		flaky := newFlakyTrader(busy, busy, busy, busy, busy, busy)
+		policy := fastRetryPolicy()
+		policy.MaxAttempts = tc.maxAttempts
+		_, err := NewRetryingTrader(flaky, policy, nil).OpenLong("BTCUSDT", 0.1, 5)
+		if !errors.Is(err, busy) {
+			t.Errorf("MaxAttempts %d: err = %v, want %v", tc.maxAttempts, err, busy)
+		}
+		if got := flaky.calls["OpenLong"]; got != tc.wantCalls {
+			t.Errorf("MaxAttempts %d: 调用次数 = %d, want %d", tc.maxAttempts, got, tc.wantCalls)
+		}
+	}
+}
+
+// TestRetryingTraderCustomCodes 自定义RetryableCodes替换默认错误码
+func TestRetryingTraderCustomCodes(t *testing.T) {
+	policy := fastRetryPolicy()
+	policy.RetryableCodes = map[int]bool{51008: true}
+
+	flaky := newFlakyTrader(&OkxError{Op: "test", SCode: 51008})
+	if _, err := NewRetryingTrader(flaky, policy, nil).OpenLong("BTCUSDT", 0.1, 5); err != nil || flaky.calls["OpenLong"] != 2 {
+		t.Errorf("自定义错误码: err = %v, 调用次数 = %d, want 重试成功", err, flaky.calls["OpenLong"])
+	}
+	flaky = newFlakyTrader(&OkxError{Op: "test", Code: 50013})
+	if _, err := NewRetryingTrader(flaky, policy, nil).OpenLong("BTCUSDT", 0.1, 5); err == nil || flaky.calls["OpenLong"] != 1 {
+		t.Errorf("默认错误码不在自定义列表中: err = %v, 调用次数 = %d, want 不重试", err, flaky.calls["OpenLong"])
+	}
+}
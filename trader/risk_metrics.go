@@ -0,0 +1,180 @@
+package trader
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"nofx/clock"
+)
+
+// RiskMetricsCollector 按固定间隔从账户快照刷新风险指标，实现prometheus.Collector（注册到与交易器指标相同的注册表）
+// 每次刷新整体替换该交易员的指标，已平仓的持仓不会残留；刷新失败时保留上次的值，
+// 通过 nofx_risk_last_refresh_timestamp_seconds 判断数据是否过期（告警阈值由Prometheus规则决定）
+type RiskMetricsCollector struct {
+	traders  func() map[string]*AutoTrader
+	interval time.Duration
+	clock    clock.Clock // 刷新间隔的时间源
+
+	mu     sync.RWMutex
+	series map[string]*traderRiskMetrics // key: trader ID
+	stop   chan struct{}
+}
+
+// traderRiskMetrics 单个交易员最近一次刷新的指标
+type traderRiskMetrics struct {
+	summary       *PortfolioSummary
+	marginRatio   float64
+	lastRefresh   time.Time
+	refreshErrors int64
+}
+
+// NewRiskMetricsCollector 创建指标采集器（interval<=0 默认60秒）
+// 账户数据经交易器的余额/持仓缓存获取，间隔短于缓存有效期时不会增加交易所请求
+func NewRiskMetricsCollector(traders func() map[string]*AutoTrader, interval time.Duration) *RiskMetricsCollector {
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	return &RiskMetricsCollector{
+		traders:  traders,
+		interval: interval,
+		clock:    clock.Real(),
+		series:   make(map[string]*traderRiskMetrics),
+	}
+}
+
+// SetClock 替换刷新间隔的时间源（测试中注入假时钟，需在Start之前调用）
+func (c *RiskMetricsCollector) SetClock(clk clock.Clock) {
+	c.clock = clock.OrReal(clk)
+}
+
+// Start 后台定时刷新（立即刷新一次）
+func (c *RiskMetricsCollector) Start() {
+	c.mu.Lock()
+	if c.stop != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.stop = make(chan struct{})
+	stop := c.stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := c.clock.NewTicker(c.interval)
+		defer ticker.Stop()
+		c.Refresh()
+		for {
+			select {
+			case <-ticker.C():
+				c.Refresh()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台刷新
+func (c *RiskMetricsCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// Refresh 刷新所有交易员的指标，已移除的交易员的指标一并删除
+func (c *RiskMetricsCollector) Refresh() {
+	traders := c.traders()
+	for id, at := range traders {
+		summary, err := at.GetPortfolioSummary()
+
+		c.mu.Lock()
+		m := c.series[id]
+		if m == nil {
+			m = &traderRiskMetrics{}
+			c.series[id] = m
+		}
+		if err != nil {
+			m.refreshErrors++
+			c.mu.Unlock()
+			log.Printf("⚠ [%s] 刷新风险指标失败: %v", at.GetName(), err)
+			continue
+		}
+		m.summary = summary
+		m.marginRatio = 0
+		if summary.TotalEquity > 0 {
+			m.marginRatio = summary.MarginUsed / summary.TotalEquity
+		}
+		m.lastRefresh = at.clock.Now()
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	for id := range c.series {
+		if _, ok := traders[id]; !ok {
+			delete(c.series, id)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// 风险指标的描述（交易员级按trader标签，持仓级另加symbol与side标签）
+var (
+	riskTraderLabels   = []string{"trader"}
+	riskPositionLabels = []string{"trader", "symbol", "side"}
+
+	riskEquityDesc        = prometheus.NewDesc("nofx_account_equity_usd", "Account equity (wallet + unrealized PnL)", riskTraderLabels, nil)
+	riskMarginFreeDesc    = prometheus.NewDesc("nofx_account_margin_free_usd", "Cross available margin", riskTraderLabels, nil)
+	riskMarginRatioDesc   = prometheus.NewDesc("nofx_account_margin_ratio", "Margin used / account equity", riskTraderLabels, nil)
+	riskGrossDesc         = prometheus.NewDesc("nofx_gross_exposure_usd", "Sum of position notionals", riskTraderLabels, nil)
+	riskNetDesc           = prometheus.NewDesc("nofx_net_exposure_usd", "Long notional minus short notional", riskTraderLabels, nil)
+	riskUpnlDesc          = prometheus.NewDesc("nofx_position_unrealized_pnl_usd", "Unrealized PnL per open position", riskPositionLabels, nil)
+	riskNotionalDesc      = prometheus.NewDesc("nofx_position_notional_usd", "Notional (quantity x mark price) per open position", riskPositionLabels, nil)
+	riskLiqDistDesc       = prometheus.NewDesc("nofx_position_liquidation_distance_pct", "Distance from mark price to liquidation price in percent (0 when unknown)", riskPositionLabels, nil)
+	riskLastRefreshDesc   = prometheus.NewDesc("nofx_risk_last_refresh_timestamp_seconds", "Unix time of the last successful refresh", riskTraderLabels, nil)
+	riskRefreshErrorsDesc = prometheus.NewDesc("nofx_risk_refresh_errors_total", "Failed refreshes", riskTraderLabels, nil)
+)
+
+// Describe 实现prometheus.Collector
+func (c *RiskMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		riskEquityDesc, riskMarginFreeDesc, riskMarginRatioDesc, riskGrossDesc, riskNetDesc,
+		riskUpnlDesc, riskNotionalDesc, riskLiqDistDesc, riskLastRefreshDesc, riskRefreshErrorsDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现prometheus.Collector：输出最近一次刷新的值（抓取时不请求交易所）
+func (c *RiskMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for id, m := range c.series {
+		ch <- prometheus.MustNewConstMetric(riskRefreshErrorsDesc, prometheus.CounterValue, float64(m.refreshErrors), id)
+		if m.summary == nil {
+			continue
+		}
+		s := m.summary
+		gauge(riskEquityDesc, s.TotalEquity, id)
+		gauge(riskMarginFreeDesc, s.MarginFree, id)
+		gauge(riskMarginRatioDesc, m.marginRatio, id)
+		gauge(riskGrossDesc, s.GrossExposure, id)
+		gauge(riskNetDesc, s.NetExposure, id)
+		gauge(riskLastRefreshDesc, float64(m.lastRefresh.Unix()), id)
+		for _, p := range s.Positions {
+			gauge(riskUpnlDesc, p.UnrealizedPnL, id, p.Symbol, p.Side.String())
+			gauge(riskNotionalDesc, p.Notional, id, p.Symbol, p.Side.String())
+			gauge(riskLiqDistDesc, p.LiqDistancePct, id, p.Symbol, p.Side.String())
+		}
+	}
+}
+
+var _ prometheus.Collector = (*RiskMetricsCollector)(nil)
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"nofx/testutil"
+)
+
+// TestRiskMetricsCollector 风险指标注册到注册表后经promhttp输出：按交易器时钟记录刷新时间，
+// 定时刷新整体替换持仓序列（已平仓的不残留），标签值中的引号与换行由客户端库转义
+func TestRiskMetricsCollector(t *testing.T) {
+	t.Chdir(t.TempDir())
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fc := testutil.NewFakeClock(start)
+	paper, err := NewPaperTrader(NewFixedPriceSource(map[string]float64{"BTCUSDT": 50000}), PaperConfig{InitialBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := paper.OpenLong("BTCUSDT", 0.1, 5); err != nil {
+		t.Fatal(err)
+	}
+	const id = "risk \"a\"\nb"
+	at, err := NewAutoTrader(AutoTraderConfig{
+		ID:             id,
+		Name:           "risk_test",
+		Exchange:       "paper",
+		Trader:         paper,
+		DemoTrading:    true,
+		InitialBalance: 10000,
+		StateFilePath:  filepath.Join(t.TempDir(), "state.json"),
+		Clock:          fc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const interval = time.Minute
+	collector := NewRiskMetricsCollector(func() map[string]*AutoTrader { return map[string]*AutoTrader{id: at} }, interval)
+	collector.SetClock(fc)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collector)
+	collector.Start()
+	defer collector.Stop()
+	fc.BlockUntil(1)
+
+	families := scrapeMetrics(t, reg)
+	for deadline := time.Now().Add(5 * time.Second); families["nofx_position_notional_usd"] == nil; families = scrapeMetrics(t, reg) {
+		if time.Now().After(deadline) {
+			t.Fatal("启动后没有刷新风险指标")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	traderLabels := map[string]string{"trader": id}
+	if got := findMetric(t, families, "nofx_risk_last_refresh_timestamp_seconds", traderLabels).GetGauge().GetValue(); got != float64(start.Unix()) {
+		t.Errorf("最近刷新时间 = %v, want %d（交易器时钟）", got, start.Unix())
+	}
+	position := map[string]string{"trader": id, "symbol": "BTCUSDT", "side": "long"}
+	if got := findMetric(t, families, "nofx_position_notional_usd", position).GetGauge().GetValue(); got != 5000 {
+		t.Errorf("持仓名义价值 = %v, want 5000", got)
+	}
+	if got := findMetric(t, families, "nofx_gross_exposure_usd", traderLabels).GetGauge().GetValue(); got != 5000 {
+		t.Errorf("总敞口 = %v, want 5000", got)
+	}
+
+	// 平仓后下一个刷新周期：持仓序列被移除，刷新时间前进
+	if _, err := paper.CloseLong("BTCUSDT", 0); err != nil {
+		t.Fatal(err)
+	}
+	fc.Advance(interval)
+	for deadline := time.Now().Add(5 * time.Second); families["nofx_position_notional_usd"] != nil; families = scrapeMetrics(t, reg) {
+		if time.Now().After(deadline) {
+			t.Fatal("平仓后持仓序列仍然存在")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := findMetric(t, families, "nofx_risk_last_refresh_timestamp_seconds", traderLabels).GetGauge().GetValue(); got != float64(start.Add(interval).Unix()) {
+		t.Errorf("最近刷新时间 = %v, want %d", got, start.Add(interval).Unix())
+	}
+	if got := findMetric(t, families, "nofx_risk_refresh_errors_total", traderLabels).GetCounter().GetValue(); got != 0 {
+		t.Errorf("刷新失败次数 = %v, want 0", got)
+	}
+}
@@ -0,0 +1,126 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Side 订单买卖方向
+// 各交易所要求的大小写（BUY/buy）只在具体交易器内部转换
+type Side int
+
+const (
+	SideBuy Side = iota + 1
+	SideSell
+)
+
+func (s Side) String() string {
+	switch s {
+	case SideBuy:
+		return "buy"
+	case SideSell:
+		return "sell"
+	}
+	return fmt.Sprintf("Side(%d)", int(s))
+}
+
+// ParseSide 解析买卖方向（buy/sell，不区分大小写），无法识别时返回错误
+func ParseSide(s string) (Side, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "buy":
+		return SideBuy, nil
+	case "sell":
+		return SideSell, nil
+	}
+	return 0, fmt.Errorf("无效的买卖方向: %q", s)
+}
+
+// Valid 是否为有效的买卖方向
+func (s Side) Valid() bool {
+	return s == SideBuy || s == SideSell
+}
+
+// MarshalText 输出小写字符串，零值输出空字符串
+func (s Side) MarshalText() ([]byte, error) {
+	if !s.Valid() {
+		return []byte{}, nil
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *Side) UnmarshalText(text []byte) error {
+	parsed, err := ParseSide(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// PositionSide 持仓方向
+type PositionSide int
+
+const (
+	PositionLong PositionSide = iota + 1
+	PositionShort
+)
+
+func (s PositionSide) String() string {
+	switch s {
+	case PositionLong:
+		return "long"
+	case PositionShort:
+		return "short"
+	}
+	return fmt.Sprintf("PositionSide(%d)", int(s))
+}
+
+// ParsePositionSide 解析持仓方向（long/short，不区分大小写），无法识别时返回错误
+// buy/sell 不能确定是开仓还是平仓方向，不被接受
+func ParsePositionSide(s string) (PositionSide, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "long":
+		return PositionLong, nil
+	case "short":
+		return PositionShort, nil
+	}
+	return 0, fmt.Errorf("无效的持仓方向: %q", s)
+}
+
+// Valid 是否为有效的持仓方向
+func (s PositionSide) Valid() bool {
+	return s == PositionLong || s == PositionShort
+}
+
+// OpenSide 开仓的买卖方向（多仓买入、空仓卖出）
+func (s PositionSide) OpenSide() Side {
+	if s == PositionShort {
+		return SideSell
+	}
+	return SideBuy
+}
+
+// CloseSide 平仓（止损止盈）的买卖方向（多仓卖出、空仓买入）
+func (s PositionSide) CloseSide() Side {
+	if s == PositionShort {
+		return SideBuy
+	}
+	return SideSell
+}
+
+// MarshalText 输出小写字符串，零值输出空字符串
+func (s PositionSide) MarshalText() ([]byte, error) {
+	if !s.Valid() {
+		return []byte{}, nil
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *PositionSide) UnmarshalText(text []byte) error {
+	parsed, err := ParsePositionSide(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
@@ -0,0 +1,266 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// traderStateVersion 当前状态文件的schema版本，修改结构时递增并在stateMigrations中添加迁移
+const traderStateVersion = 1
+
+// stateMigrations 状态文件迁移函数：key为源版本，迁移后版本+1
+// 迁移在原始JSON对象上进行，保证升级时不会丢失旧状态
+var stateMigrations = map[int]func(raw map[string]interface{}) error{}
+
+// ProtectiveOrderIDs 持仓对应的止损/止盈订单ID（OKX为algoId）
+type ProtectiveOrderIDs struct {
+	StopLoss   string `json:"stop_loss_order_id,omitempty"`
+	TakeProfit string `json:"take_profit_order_id,omitempty"`
+}
+
+// SymbolState 单个持仓（symbol_side）的运行状态
+type SymbolState struct {
+	FirstSeenTime int64   `json:"first_seen_time"`        // 持仓首次出现时间（毫秒）
+	MarginAdded   float64 `json:"margin_added,omitempty"` // 累计自动追加的逐仓保证金（USDT）
+	ProtectiveOrderIDs
+}
+
+// TraderState AutoTrader需要跨重启保留的状态
+type TraderState struct {
+	Version   int                     `json:"version"`
+	TraderID  string                  `json:"trader_id"`
+	SavedAt   time.Time               `json:"saved_at"`
+	StopUntil time.Time               `json:"stop_until"` // 风控暂停截止时间
+	Symbols   map[string]*SymbolState `json:"symbols"`    // key: symbol_side
+
+	PausedSymbols []SymbolPause `json:"paused_symbols,omitempty"` // 暂停交易的币种
+}
+
+// StateStore 基于JSON文件的状态存储（写临时文件后原子替换）
+type StateStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStateStore 创建状态存储
+func NewStateStore(path string) (*StateStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建状态目录失败: %w", err)
+	}
+	return &StateStore{path: path}, nil
+}
+
+// Path 状态文件路径
+func (s *StateStore) Path() string {
+	return s.path
+}
+
+// Load 读取状态文件并迁移到当前版本，文件不存在时返回空状态
+func (s *StateStore) Load() (*TraderState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *StateStore) loadLocked() (*TraderState, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return newTraderState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态文件失败: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %w", err)
+	}
+
+	version := traderStateVersion
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > traderStateVersion {
+		return nil, fmt.Errorf("状态文件版本 %d 高于当前支持的版本 %d", version, traderStateVersion)
+	}
+	for version < traderStateVersion {
+		migrate, ok := stateMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("缺少状态文件 v%d -> v%d 的迁移", version, version+1)
+		}
+		if err := migrate(raw); err != nil {
+			return nil, fmt.Errorf("状态文件 v%d 迁移失败: %w", version, err)
+		}
+		version++
+		raw["version"] = version
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	state := newTraderState()
+	if err := json.Unmarshal(migrated, state); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %w", err)
+	}
+	if state.Symbols == nil {
+		state.Symbols = make(map[string]*SymbolState)
+	}
+	return state, nil
+}
+
+// Save 保存状态（写入临时文件后rename，避免中途崩溃写坏文件）
+func (s *StateStore) Save(state *TraderState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked(state)
+}
+
+// Update 读取当前状态文件，修改后保存（只修改部分字段时使用）
+func (s *StateStore) Update(fn func(state *TraderState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	fn(state)
+	return s.saveLocked(state)
+}
+
+func (s *StateStore) saveLocked(state *TraderState) error {
+	state.Version = traderStateVersion
+	if state.SavedAt.IsZero() {
+		state.SavedAt = time.Now()
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("替换状态文件失败: %w", err)
+	}
+	return nil
+}
+
+func newTraderState() *TraderState {
+	return &TraderState{
+		Version: traderStateVersion,
+		Symbols: make(map[string]*SymbolState),
+	}
+}
+
+// saveState 将当前运行状态写入状态文件（失败只记录日志）
+func (at *AutoTrader) saveState() {
+	if at.stateStore == nil {
+		return
+	}
+	state := newTraderState()
+	state.TraderID = at.id
+	state.SavedAt = at.clock.Now()
+	state.StopUntil = at.stopUntil
+
+	// 暂停状态可能由API在其他goroutine修改，持锁到写入完成，避免覆盖更新的暂停状态
+	at.pauseMu.RLock()
+	defer at.pauseMu.RUnlock()
+	state.PausedSymbols = at.pausedSymbolsLocked()
+	for key, firstSeen := range at.positionFirstSeenTime {
+		state.Symbols[key] = &SymbolState{FirstSeenTime: firstSeen}
+	}
+	for key, ids := range at.protectiveOrders {
+		if symbolState, ok := state.Symbols[key]; ok {
+			symbolState.ProtectiveOrderIDs = ids
+		}
+	}
+	for key, added := range at.marginAdded {
+		if symbolState, ok := state.Symbols[key]; ok {
+			symbolState.MarginAdded = added
+		}
+	}
+	if err := at.stateStore.Save(state); err != nil {
+		log.Printf("⚠ [%s] 保存运行状态失败: %v", at.name, err)
+	}
+}
+
+// restoreState 启动时从状态文件恢复运行状态
+// 无法读取的文件会被备份，避免后续保存时覆盖
+func (at *AutoTrader) restoreState() {
+	if at.stateStore == nil {
+		return
+	}
+	state, err := at.stateStore.Load()
+	if err != nil {
+		backup := fmt.Sprintf("%s.%d.bak", at.stateStore.Path(), at.clock.Now().Unix())
+		log.Printf("⚠ [%s] 加载运行状态失败: %v，已备份至 %s", at.name, err, backup)
+		os.Rename(at.stateStore.Path(), backup)
+		return
+	}
+
+	at.stopUntil = state.StopUntil
+	for i := range state.PausedSymbols {
+		pause := state.PausedSymbols[i]
+		at.pausedSymbols[pause.Symbol] = &pause
+	}
+	if len(state.PausedSymbols) > 0 {
+		log.Printf("⏸ [%s] 已恢复 %d 个暂停交易的币种", at.name, len(state.PausedSymbols))
+	}
+	for key, symbolState := range state.Symbols {
+		if symbolState != nil {
+			at.positionFirstSeenTime[key] = symbolState.FirstSeenTime
+			if symbolState.ProtectiveOrderIDs != (ProtectiveOrderIDs{}) {
+				at.protectiveOrders[key] = symbolState.ProtectiveOrderIDs
+			}
+			if symbolState.MarginAdded > 0 {
+				at.marginAdded[key] = symbolState.MarginAdded
+			}
+		}
+	}
+	if len(state.Symbols) > 0 || !state.StopUntil.IsZero() {
+		log.Printf("📂 [%s] 已恢复运行状态: %d 个持仓记录 (保存于 %s)",
+			at.name, len(state.Symbols), state.SavedAt.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// reconcileState 与交易所持仓对账：交易所已不存在的持仓状态会被丢弃
+func (at *AutoTrader) reconcileState() {
+	if len(at.positionFirstSeenTime) == 0 {
+		return
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠ [%s] 状态对账失败，保留已恢复的状态: %v", at.name, err)
+		return
+	}
+
+	live := make(map[string]bool)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		live[symbol+"_"+side] = true
+	}
+
+	dropped := 0
+	for key := range at.positionFirstSeenTime {
+		if !live[key] {
+			delete(at.positionFirstSeenTime, key)
+			delete(at.protectiveOrders, key)
+			delete(at.marginAdded, key)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Printf("🧹 [%s] 状态对账: 丢弃 %d 个已不存在的持仓状态", at.name, dropped)
+		at.saveState()
+	}
+}
@@ -0,0 +1,141 @@
+package trader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newStateTestAutoTrader 在临时目录中创建使用模拟盘的AutoTrader（决策日志等相对路径写入临时目录）
+func newStateTestAutoTrader(t *testing.T, paper *PaperTrader, statePath string) *AutoTrader {
+	t.Helper()
+	at, err := NewAutoTrader(AutoTraderConfig{
+		ID:             "state_test",
+		Name:           "state_test",
+		Exchange:       "paper",
+		Trader:         paper,
+		DemoTrading:    true,
+		InitialBalance: 10000,
+		StateFilePath:  statePath,
+		AuditLogPath:   filepath.Join(filepath.Dir(statePath), "audit.jsonl"),
+	})
+	if err != nil {
+		t.Fatalf("NewAutoTrader: %v", err)
+	}
+	return at
+}
+
+// TestStateSurvivesKillAndRestart 持仓中途进程被杀（不调用Stop）后重启：状态从文件恢复，
+// 与交易所对账后保留仍存在的持仓，丢弃已平仓的持仓
+func TestStateSurvivesKillAndRestart(t *testing.T) {
+	t.Chdir(t.TempDir())
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	prices := NewFixedPriceSource(map[string]float64{"BTCUSDT": 50000, "ETHUSDT": 3000})
+	paper, err := NewPaperTrader(prices, PaperConfig{InitialBalance: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次运行：开两个仓位，记录状态后直接丢弃（模拟进程被杀）
+	at := newStateTestAutoTrader(t, paper, statePath)
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		if _, err := at.trader.OpenLong(symbol, 0.1, 5); err != nil {
+			t.Fatalf("OpenLong %s: %v", symbol, err)
+		}
+		at.positionFirstSeenTime[symbol+"_long"] = 1700000000000
+		at.marginAdded[symbol+"_long"] = 12.5
+	}
+	at.protectiveOrders["BTCUSDT_long"] = ProtectiveOrderIDs{StopLoss: "sl-1", TakeProfit: "tp-1"}
+	at.saveState()
+
+	// 停机期间ETH仓位被止损平掉
+	if _, err := paper.CloseLong("ETHUSDT", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启：恢复全部状态，对账后只保留BTC
+	restarted := newStateTestAutoTrader(t, paper, statePath)
+	if got := restarted.positionFirstSeenTime["ETHUSDT_long"]; got != 1700000000000 {
+		t.Fatalf("重启后应先恢复ETH状态, got %d", got)
+	}
+	restarted.reconcileState()
+
+	if got := restarted.positionFirstSeenTime["BTCUSDT_long"]; got != 1700000000000 {
+		t.Errorf("BTC首次出现时间 = %d, want 1700000000000", got)
+	}
+	if got := restarted.protectiveOrders["BTCUSDT_long"]; got != (ProtectiveOrderIDs{StopLoss: "sl-1", TakeProfit: "tp-1"}) {
+		t.Errorf("BTC止损止盈ID = %+v", got)
+	}
+	if got := restarted.marginAdded["BTCUSDT_long"]; got != 12.5 {
+		t.Errorf("BTC追加保证金 = %v, want 12.5", got)
+	}
+	if _, ok := restarted.positionFirstSeenTime["ETHUSDT_long"]; ok {
+		t.Error("已平仓的ETH状态应在对账后丢弃")
+	}
+
+	// 对账结果已写回文件，再次重启不会恢复ETH
+	state, err := restarted.stateStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Symbols["ETHUSDT_long"]; ok || state.Symbols["BTCUSDT_long"] == nil {
+		t.Errorf("对账后的状态文件 = %+v", state.Symbols)
+	}
+}
+
+// TestStateStoreMigratesOldVersion 旧版本状态文件按迁移链升级到当前版本，不丢失状态
+func TestStateStoreMigratesOldVersion(t *testing.T) {
+	// v0（测试用的旧格式）：持仓首次出现时间保存在 positions 中
+	stateMigrations[0] = func(raw map[string]interface{}) error {
+		old, _ := raw["positions"].(map[string]interface{})
+		symbols := make(map[string]interface{}, len(old))
+		for key, firstSeen := range old {
+			symbols[key] = map[string]interface{}{"first_seen_time": firstSeen}
+		}
+		raw["symbols"] = symbols
+		delete(raw, "positions")
+		return nil
+	}
+	t.Cleanup(func() { delete(stateMigrations, 0) })
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	v0 := `{"version":0,"trader_id":"old","positions":{"BTCUSDT_long":1700000000000}}`
+	if err := os.WriteFile(path, []byte(v0), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if state.Version != traderStateVersion || state.TraderID != "old" {
+		t.Errorf("迁移后 version=%d trader_id=%q", state.Version, state.TraderID)
+	}
+	if s := state.Symbols["BTCUSDT_long"]; s == nil || s.FirstSeenTime != 1700000000000 {
+		t.Fatalf("迁移后的持仓状态 = %+v", state.Symbols)
+	}
+
+	// 保存后文件为当前版本，再次读取不再迁移
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	delete(stateMigrations, 0)
+	if _, err := store.Load(); err != nil {
+		t.Fatalf("保存后重新读取: %v", err)
+	}
+}
+
+// TestStateStoreRejectsNewerVersion 高于当前版本的状态文件拒绝加载（由restoreState备份），不会被降级覆盖
+func TestStateStoreRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version":99}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, _ := NewStateStore(path)
+	if _, err := store.Load(); err == nil {
+		t.Fatal("应拒绝更高版本的状态文件")
+	}
+}
@@ -0,0 +1,115 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SymbolPause 单个币种的暂停信息
+// 暂停的币种不允许开仓或加仓；ManageExisting为true时仍执行平仓与保证金追加
+type SymbolPause struct {
+	Symbol         string    `json:"symbol"`
+	ManageExisting bool      `json:"manage_existing"` // 暂停期间是否继续管理已有持仓
+	Since          time.Time `json:"since"`
+	By             string    `json:"by"` // 触发者（如 api:admin、nofxctl、风控规则名）
+}
+
+// PauseSymbol 暂停单个币种的交易（不平仓），状态随运行状态持久化
+func (at *AutoTrader) PauseSymbol(symbol string, manageExisting bool, by string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return fmt.Errorf("币种不能为空")
+	}
+
+	at.pauseMu.Lock()
+	defer at.pauseMu.Unlock()
+	at.pausedSymbols[symbol] = &SymbolPause{
+		Symbol:         symbol,
+		ManageExisting: manageExisting,
+		Since:          at.clock.Now(),
+		By:             by,
+	}
+	mode := "冻结持仓管理"
+	if manageExisting {
+		mode = "继续管理已有持仓"
+	}
+	log.Printf("⏸ [%s] %s 已暂停交易（%s），触发者: %s", at.name, symbol, mode, by)
+	return at.savePausedSymbolsLocked()
+}
+
+// ResumeSymbol 恢复单个币种的交易
+func (at *AutoTrader) ResumeSymbol(symbol string, by string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	at.pauseMu.Lock()
+	defer at.pauseMu.Unlock()
+	if _, ok := at.pausedSymbols[symbol]; !ok {
+		return fmt.Errorf("%s 未暂停", symbol)
+	}
+	delete(at.pausedSymbols, symbol)
+	log.Printf("▶ [%s] %s 已恢复交易，触发者: %s", at.name, symbol, by)
+	return at.savePausedSymbolsLocked()
+}
+
+// GetPausedSymbols 返回所有暂停的币种（按币种排序）
+func (at *AutoTrader) GetPausedSymbols() []SymbolPause {
+	at.pauseMu.RLock()
+	defer at.pauseMu.RUnlock()
+	return at.pausedSymbolsLocked()
+}
+
+func (at *AutoTrader) pausedSymbolsLocked() []SymbolPause {
+	result := make([]SymbolPause, 0, len(at.pausedSymbols))
+	for _, pause := range at.pausedSymbols {
+		result = append(result, *pause)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
+
+// symbolPause 返回币种的暂停信息
+func (at *AutoTrader) symbolPause(symbol string) (SymbolPause, bool) {
+	at.pauseMu.RLock()
+	defer at.pauseMu.RUnlock()
+	pause, ok := at.pausedSymbols[strings.ToUpper(symbol)]
+	if !ok {
+		return SymbolPause{}, false
+	}
+	return *pause, true
+}
+
+// checkSymbolPause 检查暂停的币种是否允许执行该操作（opening=true为开仓/加仓）
+func (at *AutoTrader) checkSymbolPause(symbol string, opening bool) error {
+	pause, ok := at.symbolPause(symbol)
+	if !ok {
+		return nil
+	}
+	if opening {
+		return fmt.Errorf("%s 已暂停交易（%s 由 %s 暂停），不允许开仓或加仓",
+			symbol, pause.Since.Format("2006-01-02 15:04:05"), pause.By)
+	}
+	if !pause.ManageExisting {
+		return fmt.Errorf("%s 已暂停交易且冻结持仓管理（由 %s 暂停）", symbol, pause.By)
+	}
+	return nil
+}
+
+// savePausedSymbolsLocked 只更新状态文件中的暂停币种（不读取运行中的其他状态，可在任意goroutine调用）
+func (at *AutoTrader) savePausedSymbolsLocked() error {
+	if at.stateStore == nil {
+		return nil
+	}
+	paused := at.pausedSymbolsLocked()
+	err := at.stateStore.Update(func(state *TraderState) {
+		state.TraderID = at.id
+		state.SavedAt = at.clock.Now()
+		state.PausedSymbols = paused
+	})
+	if err != nil {
+		return fmt.Errorf("保存暂停状态失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package trader
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/clock"
+)
+
+// SymbolPhase 单个币种的交易生命周期阶段
+type SymbolPhase string
+
+const (
+	PhaseIdle     SymbolPhase = "idle"     // 无持仓，可以开仓
+	PhaseEntering SymbolPhase = "entering" // 开仓中，等待成交
+	PhaseManaging SymbolPhase = "managing" // 持仓中
+	PhaseExiting  SymbolPhase = "exiting"  // 平仓中
+	PhaseCooldown SymbolPhase = "cooldown" // 平仓后冷却，不允许立即开仓
+	PhaseHalted   SymbolPhase = "halted"   // 风控暂停
+)
+
+// symbolTransitions 合法的状态转换
+var symbolTransitions = map[SymbolPhase][]SymbolPhase{
+	PhaseIdle:     {PhaseEntering, PhaseManaging, PhaseExiting, PhaseHalted},
+	PhaseEntering: {PhaseManaging, PhaseIdle, PhaseHalted},
+	PhaseManaging: {PhaseEntering, PhaseExiting, PhaseCooldown, PhaseHalted},
+	PhaseExiting:  {PhaseCooldown, PhaseManaging, PhaseHalted},
+	PhaseCooldown: {PhaseIdle, PhaseManaging, PhaseHalted},
+	PhaseHalted:   {PhaseIdle, PhaseManaging},
+}
+
+// SymbolStatus 币种当前状态
+type SymbolStatus struct {
+	Symbol string      `json:"symbol"`
+	Phase  SymbolPhase `json:"phase"`
+	Since  time.Time   `json:"since"`
+	Reason string      `json:"reason"` // 最近一次状态转换的原因
+}
+
+// symbolStateMachine 按币种维护交易生命周期状态（未出现过的币种视为Idle）
+type symbolStateMachine struct {
+	name     string
+	clock    clock.Clock
+	cooldown time.Duration // 平仓后冷却时长，到期后自动回到Idle
+
+	mu     sync.Mutex
+	states map[string]*SymbolStatus
+}
+
+func newSymbolStateMachine(name string, clk clock.Clock, cooldown time.Duration) *symbolStateMachine {
+	return &symbolStateMachine{
+		name:     name,
+		clock:    clock.OrReal(clk),
+		cooldown: cooldown,
+		states:   make(map[string]*SymbolStatus),
+	}
+}
+
+// phaseLocked 返回币种当前阶段，冷却到期时先转换为Idle
+func (m *symbolStateMachine) phaseLocked(symbol, correlationID string) SymbolPhase {
+	state, ok := m.states[symbol]
+	if !ok {
+		return PhaseIdle
+	}
+	if state.Phase == PhaseCooldown && m.clock.Since(state.Since) >= m.cooldown {
+		m.setLocked(symbol, PhaseIdle, msg("reason_cooldown_over"), correlationID)
+		return PhaseIdle
+	}
+	return state.Phase
+}
+
+func (m *symbolStateMachine) setLocked(symbol string, to SymbolPhase, reason, correlationID string) {
+	from := PhaseIdle
+	if state, ok := m.states[symbol]; ok {
+		from = state.Phase
+	}
+	m.states[symbol] = &SymbolStatus{Symbol: symbol, Phase: to, Since: m.clock.Now(), Reason: reason}
+	log.Print(msg("log_symbol_transition", m.name, symbol, from, to, reason, correlationID))
+}
+
+// Transition 转换币种状态，非法转换返回错误且状态不变
+func (m *symbolStateMachine) Transition(symbol string, to SymbolPhase, reason, correlationID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := m.phaseLocked(symbol, correlationID)
+	if from == to {
+		return nil
+	}
+	for _, allowed := range symbolTransitions[from] {
+		if allowed == to {
+			m.setLocked(symbol, to, reason, correlationID)
+			return nil
+		}
+	}
+	return errors.New(msg("err_illegal_transition", symbol, from, to))
+}
+
+// Phase 返回币种当前阶段
+func (m *symbolStateMachine) Phase(symbol string) SymbolPhase {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.phaseLocked(symbol, "")
+}
+
+// HaltAll 将所有已知币种转为Halted
+func (m *symbolStateMachine) HaltAll(reason, correlationID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for symbol, state := range m.states {
+		if state.Phase != PhaseHalted {
+			m.setLocked(symbol, PhaseHalted, reason, correlationID)
+		}
+	}
+}
+
+// Sync 按交易所实际持仓校正状态：有持仓的币种进入Managing，持仓消失的币种进入冷却
+// 开仓/平仓进行中的币种不做校正
+func (m *symbolStateMachine) Sync(held map[string]bool, correlationID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for symbol := range held {
+		switch m.phaseLocked(symbol, correlationID) {
+		case PhaseIdle, PhaseCooldown, PhaseHalted:
+			m.setLocked(symbol, PhaseManaging, msg("reason_position_detected"), correlationID)
+		}
+	}
+	for symbol, state := range m.states {
+		if held[symbol] {
+			continue
+		}
+		switch state.Phase {
+		case PhaseManaging:
+			m.setLocked(symbol, PhaseCooldown, msg("reason_position_gone"), correlationID)
+		case PhaseHalted:
+			m.setLocked(symbol, PhaseIdle, msg("reason_halt_over"), correlationID)
+		}
+	}
+}
+
+// Status 返回所有币种的当前状态（按币种排序）
+func (m *symbolStateMachine) Status() []SymbolStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]SymbolStatus, 0, len(m.states))
+	for symbol := range m.states {
+		m.phaseLocked(symbol, "")
+		result = append(result, *m.states[symbol])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
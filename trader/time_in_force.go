@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TimeInForce 限价单的有效方式
+type TimeInForce string
+
+const (
+	TimeInForceGTC      TimeInForce = "GTC"       // 一直有效直到成交或撤销
+	TimeInForceIOC      TimeInForce = "IOC"       // 立即成交，未成交部分撤销
+	TimeInForceFOK      TimeInForce = "FOK"       // 全部成交，否则整单撤销
+	TimeInForcePostOnly TimeInForce = "POST_ONLY" // 只做Maker，会立即成交时交易所拒绝下单
+)
+
+// Valid 是否为支持的有效方式
+func (tif TimeInForce) Valid() bool {
+	switch tif {
+	case TimeInForceGTC, TimeInForceIOC, TimeInForceFOK, TimeInForcePostOnly:
+		return true
+	}
+	return false
+}
+
+// ParseTimeInForce 解析有效方式（不区分大小写，空字符串为GTC）
+func ParseTimeInForce(s string) (TimeInForce, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return TimeInForceGTC, nil
+	case "POSTONLY", "POST-ONLY", "GTX":
+		return TimeInForcePostOnly, nil
+	}
+	if tif := TimeInForce(s); tif.Valid() {
+		return tif, nil
+	}
+	return "", fmt.Errorf("无效的订单有效方式: %q（可选 GTC/IOC/FOK/POST_ONLY）", s)
+}
+
+// LimitOrderPlacer 可选接口：支持限价开仓的交易器
+// 下单后返回订单ID，调用方通过GetOrderStatus查询成交情况
+type LimitOrderPlacer interface {
+	OpenLongLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error)
+	OpenShortLimit(symbol string, quantity, price float64, leverage int, tif TimeInForce) (string, error)
+	GetOrderStatus(symbol, orderID string) (*OrderResult, error)
+}
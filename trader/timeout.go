@@ -0,0 +1,212 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"nofx/clock"
+	"time"
+)
+
+// OperationClass 交易所调用的类别，不同类别使用不同的超时时间
+type OperationClass int
+
+const (
+	OpPublicRead  OperationClass = iota // 公共行情/交易规则查询
+	OpPrivateRead                       // 账户、持仓、挂单查询
+	OpMutation                          // 下单、撤单、杠杆/保证金设置
+)
+
+func (c OperationClass) String() string {
+	switch c {
+	case OpPublicRead:
+		return "public_read"
+	case OpPrivateRead:
+		return "private_read"
+	case OpMutation:
+		return "mutation"
+	default:
+		return "unknown"
+	}
+}
+
+// TimeoutConfig 按调用类别配置的超时时间
+type TimeoutConfig struct {
+	PublicRead  time.Duration // 默认3秒
+	PrivateRead time.Duration // 默认3秒
+	Mutation    time.Duration // 默认5秒
+}
+
+// DefaultTimeoutConfig 默认超时配置
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		PublicRead:  3 * time.Second,
+		PrivateRead: 3 * time.Second,
+		Mutation:    5 * time.Second,
+	}
+}
+
+// For 返回指定类别的超时时间，未设置时使用默认值
+func (c TimeoutConfig) For(class OperationClass) time.Duration {
+	defaults := DefaultTimeoutConfig()
+	switch class {
+	case OpPublicRead:
+		if c.PublicRead > 0 {
+			return c.PublicRead
+		}
+		return defaults.PublicRead
+	case OpPrivateRead:
+		if c.PrivateRead > 0 {
+			return c.PrivateRead
+		}
+		return defaults.PrivateRead
+	default:
+		if c.Mutation > 0 {
+			return c.Mutation
+		}
+		return defaults.Mutation
+	}
+}
+
+// ErrTimeout 交易所调用超时（使用 errors.Is(err, ErrTimeout) 判断）
+var ErrTimeout = newSentinelError(ErrCodeTimeout, "err_timeout")
+
+// TimeoutError 带调用信息的超时错误
+type TimeoutError struct {
+	Op      string
+	Class   OperationClass
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return msg("err_timeout_detail", e.Op, e.Class, e.Timeout)
+}
+
+func (e *TimeoutError) ErrorCode() ErrorCode {
+	return ErrCodeTimeout
+}
+
+// Is 使 errors.Is(err, ErrTimeout) 成立
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// OutcomeUnknown 变更类请求超时后，交易所可能已经执行，不能直接重试
+func (e *TimeoutError) OutcomeUnknown() bool {
+	return e.Class == OpMutation
+}
+
+// ErrCanceled 调用方取消了交易所调用（使用 errors.Is(err, ErrCanceled) 判断）
+var ErrCanceled = newSentinelError(ErrCodeCanceled, "err_canceled")
+
+// CanceledError 调用方的context在交易所调用完成前结束（取消或到达截止时间）
+type CanceledError struct {
+	Op    string
+	Class OperationClass
+	Err   error // context.Canceled 或 context.DeadlineExceeded
+
+	sent bool // 请求是否已经发出
+}
+
+func (e *CanceledError) Error() string {
+	return msg("err_canceled_detail", e.Op, e.Class, e.Err)
+}
+
+func (e *CanceledError) ErrorCode() ErrorCode {
+	return ErrCodeCanceled
+}
+
+// Is 使 errors.Is(err, ErrCanceled) 成立
+func (e *CanceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+// Unwrap 使 errors.Is(err, context.Canceled) 等判断成立
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// OutcomeUnknown 变更类请求发出后被取消，交易所可能已经执行
+func (e *CanceledError) OutcomeUnknown() bool {
+	return e.sent && e.Class == OpMutation
+}
+
+// IsOutcomeUnknown 错误是否表示请求结果未知（需要对账后再决定是否重试）
+func IsOutcomeUnknown(err error) bool {
+	var unknown interface{ OutcomeUnknown() bool }
+	return errors.As(err, &unknown) && unknown.OutcomeUnknown()
+}
+
+// timeoutContext 创建带类别超时的context
+func timeoutContext(cfg TimeoutConfig, class OperationClass) (context.Context, context.CancelFunc) {
+	timeout := cfg.For(class)
+	ctx := context.WithValue(context.Background(), timeoutKey{}, timeout)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError 若ctx已超时，将err转换为TimeoutError
+func timeoutError(ctx context.Context, class OperationClass, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Op: op, Class: class, Timeout: timeoutOf(ctx)}
+	}
+	return err
+}
+
+// timeoutOf 读取context上记录的超时设置（用于错误信息）
+func timeoutOf(ctx context.Context) time.Duration {
+	if v, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		return v
+	}
+	return 0
+}
+
+type timeoutKey struct{}
+
+// callWithTimeout 在超时时间内执行不支持context的调用
+// 超时后立即返回TimeoutError，底层调用在后台继续直到自行结束
+func callWithTimeout[T any](cfg TimeoutConfig, class OperationClass, op string, fn func() (T, error)) (T, error) {
+	return callWithContext(context.Background(), cfg, class, op, fn)
+}
+
+// callWithContext 同callWithTimeout，ctx结束时也立即返回CanceledError
+// ctx在调用前已结束时不发起调用
+func callWithContext[T any](ctx context.Context, cfg TimeoutConfig, class OperationClass, op string, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, &CanceledError{Op: op, Class: class, Err: err}
+	}
+
+	timeout := cfg.For(class)
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		return zero, &TimeoutError{Op: op, Class: class, Timeout: timeout}
+	case <-ctx.Done():
+		return zero, &CanceledError{Op: op, Class: class, Err: ctx.Err(), sent: true}
+	}
+}
+
+// sleepContext 等待d或直到ctx结束（返回ctx的错误）
+func sleepContext(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	select {
+	case <-clk.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,156 @@
+package trader
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TradeEventType 交易器事件类型
+type TradeEventType string
+
+const (
+	EventOrderPlaced     TradeEventType = "order_placed"     // 订单已被交易所接受
+	EventOrderRejected   TradeEventType = "order_rejected"   // 订单被拒绝或提交失败（Error为原因）
+	EventPositionOpened  TradeEventType = "position_opened"  // 市价开仓成交（Size/Price为成交数量与均价）
+	EventPositionClosed  TradeEventType = "position_closed"  // 市价平仓成交（部分平仓时Size为平掉的数量）
+	EventStopLossSet     TradeEventType = "stop_loss_set"    // 止损单已提交（Price为触发价）
+	EventTakeProfitSet   TradeEventType = "take_profit_set"  // 止盈单已提交（Price为触发价）
+	EventOrdersCancelled TradeEventType = "orders_cancelled" // 批量撤单完成（Count为撤销成功的订单数）
+
+	EventStopLossFailed   TradeEventType = "stop_loss_failed"   // 止损单提交失败（持仓可能没有止损，Error为原因）
+	EventTakeProfitFailed TradeEventType = "take_profit_failed" // 止盈单提交失败（Error为原因）
+)
+
+// TradeEvent 交易器在下单、开平仓、设置止损止盈与撤单后发出的事件
+type TradeEvent struct {
+	Type          TradeEventType `json:"type"`
+	Time          time.Time      `json:"time"`
+	Trader        string         `json:"trader,omitempty"` // 交易员名称（经AutoTrader注册的回调才有）
+	Symbol        string         `json:"symbol"`
+	Side          string         `json:"side,omitempty"`        // 持仓方向 long/short
+	OrderSide     string         `json:"order_side,omitempty"`  // 订单方向 buy/sell
+	Size          float64        `json:"size,omitempty"`        // 数量（币）
+	Price         float64        `json:"price,omitempty"`       // 成交均价、委托价或触发价
+	EntryPrice    float64        `json:"entry_price,omitempty"` // 开仓均价（PositionOpened/PositionClosed）
+	Leverage      int            `json:"leverage,omitempty"`
+	OrderID       string         `json:"order_id,omitempty"`
+	ClientOrderID string         `json:"client_order_id,omitempty"`
+	AlgoID        string         `json:"algo_id,omitempty"`
+	RealizedPnL   float64        `json:"realized_pnl,omitempty"`
+	Count         int            `json:"count,omitempty"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// TradeEventSource 可选接口：注册交易事件回调
+// 回调在单独的goroutine中按事件顺序调用，慢回调不会阻塞下单；队列满时丢弃新事件并计数
+type TradeEventSource interface {
+	RegisterHook(fn func(TradeEvent))
+	DroppedEvents() int64
+}
+
+var (
+	defaultTradeHooksMu sync.Mutex
+	defaultTradeHooks   []func(TradeEvent)
+)
+
+// AddTradeEventHook 注册进程级的交易事件回调（如通知），之后创建的AutoTrader在交易器支持时注册该回调，
+// 事件的Trader字段为交易员名称
+func AddTradeEventHook(fn func(TradeEvent)) {
+	if fn == nil {
+		return
+	}
+	defaultTradeHooksMu.Lock()
+	defer defaultTradeHooksMu.Unlock()
+	defaultTradeHooks = append(defaultTradeHooks, fn)
+}
+
+// tradeEventHooks 返回进程级回调的副本
+func tradeEventHooks() []func(TradeEvent) {
+	defaultTradeHooksMu.Lock()
+	defer defaultTradeHooksMu.Unlock()
+	hooks := make([]func(TradeEvent), len(defaultTradeHooks))
+	copy(hooks, defaultTradeHooks)
+	return hooks
+}
+
+// defaultTradeEventQueue 事件队列的默认长度
+const defaultTradeEventQueue = 256
+
+// tradeEventHub 事件分发：emit只做非阻塞入队，第一次注册回调时启动分发goroutine
+type tradeEventHub struct {
+	size int
+
+	mu      sync.Mutex
+	hooks   []func(TradeEvent) // 注册后不修改，追加时整体替换
+	queue   chan TradeEvent
+	dropped atomic.Int64
+}
+
+func newTradeEventHub(size int) *tradeEventHub {
+	if size <= 0 {
+		size = defaultTradeEventQueue
+	}
+	return &tradeEventHub{size: size}
+}
+
+// register 追加回调（fn为nil时忽略）
+func (h *tradeEventHub) register(fn func(TradeEvent)) {
+	if fn == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hooks := make([]func(TradeEvent), 0, len(h.hooks)+1)
+	h.hooks = append(append(hooks, h.hooks...), fn)
+	if h.queue == nil {
+		h.queue = make(chan TradeEvent, h.size)
+		go h.loop(h.queue)
+	}
+}
+
+// active 是否已注册回调（未注册时调用方可以跳过构造事件）
+func (h *tradeEventHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.queue != nil
+}
+
+// emit 事件入队后立即返回；没有回调时不入队，队列满时丢弃
+func (h *tradeEventHub) emit(e TradeEvent) {
+	h.mu.Lock()
+	queue := h.queue
+	h.mu.Unlock()
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- e:
+	default:
+		if n := h.dropped.Add(1); n == 1 || n%100 == 0 {
+			log.Printf("⚠ 交易事件队列已满，已丢弃 %d 条事件", n)
+		}
+	}
+}
+
+func (h *tradeEventHub) loop(queue <-chan TradeEvent) {
+	for e := range queue {
+		h.mu.Lock()
+		hooks := h.hooks
+		h.mu.Unlock()
+		for _, fn := range hooks {
+			h.call(fn, e)
+		}
+	}
+}
+
+// call 调用单个回调，回调panic时记录日志，不影响其他回调与后续事件
+func (h *tradeEventHub) call(fn func(TradeEvent), e TradeEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ 交易事件回调异常 (%s %s): %v", e.Type, e.Symbol, r)
+		}
+	}()
+	fn(e)
+}
@@ -0,0 +1,44 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TriggerPriceType 止损/止盈的触发价类型
+type TriggerPriceType string
+
+const (
+	TriggerLast  TriggerPriceType = "last"  // 最新成交价（单笔插针即可触发）
+	TriggerMark  TriggerPriceType = "mark"  // 标记价格
+	TriggerIndex TriggerPriceType = "index" // 指数价格
+)
+
+// Valid 是否为支持的触发价类型
+func (t TriggerPriceType) Valid() bool {
+	return t == TriggerLast || t == TriggerMark || t == TriggerIndex
+}
+
+// ParseTriggerPriceType 解析触发价类型（不区分大小写，空字符串表示使用交易器默认值）
+func ParseTriggerPriceType(s string) (TriggerPriceType, error) {
+	t := TriggerPriceType(strings.ToLower(strings.TrimSpace(s)))
+	if t == "" || t.Valid() {
+		return t, nil
+	}
+	return "", fmt.Errorf("无效的触发价类型: %q（可选 last/mark/index）", s)
+}
+
+// TriggerPriceTrader 可选接口：支持选择止损/止盈触发价类型的交易器
+// 止损距离校验等需要参考价的逻辑应使用 ReferencePrice，与触发单使用同一种价格
+type TriggerPriceTrader interface {
+	// SetTriggerPriceType 设置默认触发价类型（默认last）
+	SetTriggerPriceType(t TriggerPriceType) error
+	// TriggerPriceType 当前默认触发价类型
+	TriggerPriceType() TriggerPriceType
+	// ReferencePrice 按触发价类型获取当前价格（t为空时使用默认类型）
+	ReferencePrice(symbol string, t TriggerPriceType) (float64, error)
+	// PlaceStopLossTriggeredBy 按指定触发价类型设置止损单（t为空时使用默认类型）
+	PlaceStopLossTriggeredBy(symbol string, positionSide PositionSide, quantity, stopPrice float64, t TriggerPriceType) (string, error)
+	// PlaceTakeProfitTriggeredBy 按指定触发价类型设置止盈单（t为空时使用默认类型）
+	PlaceTakeProfitTriggeredBy(symbol string, positionSide PositionSide, quantity, takeProfitPrice float64, t TriggerPriceType) (string, error)
+}